@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package roundtrip helps the conversions of the older API versions restore
+// the Hub data they cannot hold.
+package roundtrip
+
+import (
+	"reflect"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// SpokeUnchanged returns true if converting the Hub restored from the
+// annotation of a spoke yields the spec and status of the spoke, meaning the
+// spoke has not been changed since it was converted from the Hub. The
+// restored Hub then holds all the data of the spoke, including the fields the
+// spoke version does not have, and can be restored as a whole instead of
+// field by field.
+func SpokeUnchanged(spoke conversion.Convertible, restored conversion.Hub) bool {
+	converted, ok := reflect.New(reflect.TypeOf(spoke).Elem()).Interface().(conversion.Convertible)
+	if !ok {
+		return false
+	}
+	if err := converted.ConvertFrom(restored); err != nil {
+		return false
+	}
+
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spoke)
+	if err != nil {
+		return false
+	}
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(converted)
+	if err != nil {
+		return false
+	}
+	return apiequality.Semantic.DeepEqual(before["spec"], after["spec"]) &&
+		apiequality.Semantic.DeepEqual(before["status"], after["status"])
+}
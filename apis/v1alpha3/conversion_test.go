@@ -21,7 +21,9 @@ import (
 
 	fuzz "github.com/google/gofuzz"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
@@ -65,6 +67,50 @@ func TestFuzzyConversion(t *testing.T) {
 		Spoke:       &VSphereVM{},
 		FuzzerFuncs: []fuzzer.FuzzerFuncs{CustomNewFieldFuzzFunc},
 	}))
+	t.Run("for VSphereClusterIdentity", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereClusterIdentity{},
+		Spoke:  &VSphereClusterIdentity{},
+	}))
+	t.Run("for VSphereDeploymentZone", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereDeploymentZone{},
+		Spoke:  &VSphereDeploymentZone{},
+	}))
+	t.Run("for VSphereFailureDomain", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereFailureDomain{},
+		Spoke:  &VSphereFailureDomain{},
+	}))
+}
+
+func TestLegacyLoadBalancerRefConversion(t *testing.T) {
+	g := NewWithT(t)
+
+	src := &VSphereCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: VSphereClusterSpec{
+			LoadBalancerRef: &corev1.ObjectReference{
+				APIVersion: GroupVersion.String(),
+				Kind:       "HAProxyLoadBalancer",
+				Name:       "foo-lb",
+			},
+		},
+	}
+
+	hub := &nextver.VSphereCluster{}
+	g.Expect(src.ConvertTo(hub)).To(Succeed())
+	g.Expect(hub.Annotations).To(HaveKey(nextver.LegacyLoadBalancerRefAnnotation))
+	g.Expect(src.Annotations).ToNot(HaveKey(nextver.LegacyLoadBalancerRefAnnotation))
+
+	dst := &VSphereCluster{}
+	g.Expect(dst.ConvertFrom(hub)).To(Succeed())
+	g.Expect(dst.Spec.LoadBalancerRef).To(Equal(src.Spec.LoadBalancerRef))
+	g.Expect(dst.Annotations).ToNot(HaveKey(nextver.LegacyLoadBalancerRefAnnotation))
+	g.Expect(dst.Annotations).To(HaveKeyWithValue("foo", "bar"))
 }
 
 func overrideVSphereClusterDeprecatedFieldsFuncs(codecs runtimeserializer.CodecFactory) []interface{} {
@@ -89,6 +135,10 @@ func overrideVSphereClusterStatusFieldsFuncs(codecs runtimeserializer.CodecFacto
 		func(in *nextver.VSphereClusterStatus, c fuzz.Continue) {
 			c.FuzzNoCustom(in)
 			in.VCenterVersion = ""
+			in.ClusterModules = nil
+			in.TemplateReplicas = nil
+			in.RetainedVMs = nil
+			in.RetainedDisks = nil
 		},
 	}
 }
@@ -132,4 +182,13 @@ func CustomStatusNewFieldFuzzer(in *nextver.VSphereVMStatus, c fuzz.Continue) {
 
 	in.Host = ""
 	in.ModuleUUID = nil
+	in.TemplateInstanceUUID = ""
+	in.Task = nil
+	in.TaskProgress = nil
+	in.ISOImages = nil
+	in.Datastore = ""
+	in.ComputeCluster = ""
+	in.RetainedDisks = nil
+	in.CloneAttempts = 0
+	in.FailureDetails = nil
 }
@@ -18,10 +18,15 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apiconversion "k8s.io/apimachinery/pkg/conversion"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
@@ -34,13 +39,46 @@ func (src *VSphereCluster) ConvertTo(dstRaw conversion.Hub) error {
 
 	// Manually restore data.
 	restored := &infrav1beta1.VSphereCluster{}
-	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
 		return err
 	}
-	if restored.Spec.IdentityRef != nil {
-		dst.Spec.IdentityRef = restored.Spec.IdentityRef
+	switch {
+	case ok && roundtrip.SpokeUnchanged(src, restored):
+		// The spoke has not been changed since it was converted from the
+		// Hub, which then holds all its data.
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+	case ok:
+		if restored.Spec.IdentityRef != nil {
+			dst.Spec.IdentityRef = restored.Spec.IdentityRef
+		}
+		dst.Spec.DefaultPlacement = restored.Spec.DefaultPlacement
+		dst.Spec.TemplateReplication = restored.Spec.TemplateReplication
+		dst.Spec.Addons = restored.Spec.Addons
+		dst.Spec.Proxy = restored.Spec.Proxy
+		dst.Spec.NTPServers = restored.Spec.NTPServers
+		dst.Spec.Nameservers = restored.Spec.Nameservers
+		dst.Spec.NetworkSpec = restored.Spec.NetworkSpec
+		dst.Spec.CABundleRef = restored.Spec.CABundleRef
+		dst.Spec.ClusterModules = restored.Spec.ClusterModules
+		dst.Spec.ControlPlaneEndpointAddressFromPool = restored.Spec.ControlPlaneEndpointAddressFromPool
+		// The endpoint is defaulted again from the server when the server
+		// was changed on the spoke.
+		if dst.Spec.Server == restored.Spec.Server && dst.Spec.Thumbprint == restored.Spec.Thumbprint {
+			dst.Spec.Endpoint = restored.Spec.Endpoint
+		}
+		dst.Status.VCenterVersion = restored.Status.VCenterVersion
+		dst.Status.ClusterModules = restored.Status.ClusterModules
+		dst.Status.TemplateReplicas = restored.Status.TemplateReplicas
+		dst.Status.RetainedVMs = restored.Status.RetainedVMs
+		dst.Status.RetainedDisks = restored.Status.RetainedDisks
+		dst.Status.PortGroup = restored.Status.PortGroup
 	}
-	return nil
+
+	// The load balancer no longer exists in the hub, keep track of it so that
+	// the control plane endpoint can be migrated from it.
+	return setLegacyLoadBalancerRef(dst, src.Spec.LoadBalancerRef)
 }
 
 // ConvertFrom converts from the Hub version (v1beta1) to this VSphereCluster.
@@ -49,6 +87,11 @@ func (dst *VSphereCluster) ConvertFrom(srcRaw conversion.Hub) error {
 	if err := Convert_v1beta1_VSphereCluster_To_v1alpha3_VSphereCluster(src, dst, nil); err != nil {
 		return err
 	}
+	loadBalancerRef, err := popLegacyLoadBalancerRef(dst)
+	if err != nil {
+		return err
+	}
+	dst.Spec.LoadBalancerRef = loadBalancerRef
 
 	// Preserve Hub data on down-conversion.
 	if err := utilconversion.MarshalData(src, dst); err != nil {
@@ -72,3 +115,48 @@ func (dst *VSphereClusterList) ConvertFrom(srcRaw conversion.Hub) error {
 func Convert_v1alpha3_VSphereClusterSpec_To_v1beta1_VSphereClusterSpec(in *VSphereClusterSpec, out *infrav1beta1.VSphereClusterSpec, s apiconversion.Scope) error {
 	return autoConvert_v1alpha3_VSphereClusterSpec_To_v1beta1_VSphereClusterSpec(in, out, s)
 }
+
+// setLegacyLoadBalancerRef records the load balancer reference in the
+// annotations of the hub object.
+func setLegacyLoadBalancerRef(dst *infrav1beta1.VSphereCluster, ref *corev1.ObjectReference) error {
+	if ref == nil {
+		return nil
+	}
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal legacy load balancer reference")
+	}
+	// The annotations are shared with the source object, copy them before
+	// they are modified.
+	annotations := make(map[string]string, len(dst.Annotations)+1)
+	for k, v := range dst.Annotations {
+		annotations[k] = v
+	}
+	annotations[infrav1beta1.LegacyLoadBalancerRefAnnotation] = string(data)
+	dst.SetAnnotations(annotations)
+	return nil
+}
+
+// popLegacyLoadBalancerRef removes the load balancer reference recorded by
+// setLegacyLoadBalancerRef from the annotations and returns it.
+func popLegacyLoadBalancerRef(dst *VSphereCluster) (*corev1.ObjectReference, error) {
+	data, ok := dst.Annotations[infrav1beta1.LegacyLoadBalancerRefAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	ref := &corev1.ObjectReference{}
+	if err := json.Unmarshal([]byte(data), ref); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal legacy load balancer reference")
+	}
+	annotations := make(map[string]string, len(dst.Annotations)-1)
+	for k, v := range dst.Annotations {
+		if k != infrav1beta1.LegacyLoadBalancerRefAnnotation {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	dst.SetAnnotations(annotations)
+	return ref, nil
+}
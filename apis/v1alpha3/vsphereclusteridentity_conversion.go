@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha3
 
 import (
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
@@ -28,6 +30,26 @@ func (src *VSphereClusterIdentity) ConvertTo(dstRaw conversion.Hub) error {
 	if err := Convert_v1alpha3_VSphereClusterIdentity_To_v1beta1_VSphereClusterIdentity(src, dst, nil); err != nil {
 		return err
 	}
+
+	// Manually restore data.
+	restored := &infrav1beta1.VSphereClusterIdentity{}
+	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+		return err
+	}
+
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+		return nil
+	}
+
+	dst.Spec.CABundleRef = restored.Spec.CABundleRef
+	dst.Spec.CredentialSource = restored.Spec.CredentialSource
+	dst.Spec.Quota = restored.Spec.Quota
+	dst.Status.Usage = restored.Status.Usage
+
 	return nil
 }
 
@@ -37,7 +59,9 @@ func (dst *VSphereClusterIdentity) ConvertFrom(srcRaw conversion.Hub) error { //
 	if err := Convert_v1beta1_VSphereClusterIdentity_To_v1alpha3_VSphereClusterIdentity(src, dst, nil); err != nil {
 		return err
 	}
-	return nil
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
 }
 
 // ConvertTo converts this VSphereClusterIdentityList to the Hub version (v1beta1).
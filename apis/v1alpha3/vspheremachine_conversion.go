@@ -21,6 +21,7 @@ import (
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
@@ -37,12 +38,48 @@ func (src *VSphereMachine) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+		return nil
+	}
+
 	dst.Spec.AdditionalDisksGiB = restored.Spec.AdditionalDisksGiB
+	dst.Spec.AdditionalDisks = restored.Spec.AdditionalDisks
 	dst.Spec.TagIDs = restored.Spec.TagIDs
+	dst.Spec.CustomAttributes = restored.Spec.CustomAttributes
+	dst.Spec.CustomizationSpec = restored.Spec.CustomizationSpec
+	dst.Spec.BootstrapDataDelivery = restored.Spec.BootstrapDataDelivery
+	dst.Spec.CDROMs = restored.Spec.CDROMs
+	dst.Spec.TrustedPlatformModule = restored.Spec.TrustedPlatformModule
+	dst.Spec.SecureBoot = restored.Spec.SecureBoot
+	dst.Spec.CPUHotAddEnabled = restored.Spec.CPUHotAddEnabled
+	dst.Spec.MemoryHotAddEnabled = restored.Spec.MemoryHotAddEnabled
+	dst.Spec.CPUAllocation = restored.Spec.CPUAllocation
+	dst.Spec.MemoryAllocation = restored.Spec.MemoryAllocation
+	dst.Spec.RecoveryPolicy = restored.Spec.RecoveryPolicy
+	dst.Spec.DeletionPolicy = restored.Spec.DeletionPolicy
+	dst.Spec.CloneRetryPolicy = restored.Spec.CloneRetryPolicy
+	dst.Spec.LinkedClone = restored.Spec.LinkedClone
+	dst.Spec.DatastoreSelector = restored.Spec.DatastoreSelector
+	dst.Spec.ComputeSelector = restored.Spec.ComputeSelector
+	dst.Spec.PlacementPrecedence = restored.Spec.PlacementPrecedence
+	dst.Spec.NamingStrategy = restored.Spec.NamingStrategy
+	dst.Spec.TemplateSource = restored.Spec.TemplateSource
+	dst.Spec.HostNameTemplate = restored.Spec.HostNameTemplate
+	dst.Spec.HostNameDomain = restored.Spec.HostNameDomain
+	dst.Spec.DriftDetection = restored.Spec.DriftDetection
+	dst.Spec.Architecture = restored.Spec.Architecture
+	dst.Spec.HardwareVirtualization = restored.Spec.HardwareVirtualization
+	dst.Spec.VirtualIOMMU = restored.Spec.VirtualIOMMU
+	dst.Spec.TimeSync = restored.Spec.TimeSync
 	for i := range dst.Spec.Network.Devices {
 		dst.Spec.Network.Devices[i].AddressesFromPools = restored.Spec.Network.Devices[i].AddressesFromPools
 		dst.Spec.Network.Devices[i].DHCP4Overrides = restored.Spec.Network.Devices[i].DHCP4Overrides
 		dst.Spec.Network.Devices[i].DHCP6Overrides = restored.Spec.Network.Devices[i].DHCP6Overrides
+		dst.Spec.Network.Devices[i].IPv6AddressMode = restored.Spec.Network.Devices[i].IPv6AddressMode
 	}
 
 	return nil
@@ -21,6 +21,7 @@ import (
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
@@ -36,13 +37,63 @@ func (src *VSphereVM) ConvertTo(dstRaw conversion.Hub) error {
 	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
 		return err
 	}
+
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+		return nil
+	}
+
 	dst.Spec.TagIDs = restored.Spec.TagIDs
+	dst.Spec.CustomAttributes = restored.Spec.CustomAttributes
 	dst.Spec.AdditionalDisksGiB = restored.Spec.AdditionalDisksGiB
+	dst.Spec.AdditionalDisks = restored.Spec.AdditionalDisks
+	dst.Spec.CustomizationSpec = restored.Spec.CustomizationSpec
+	dst.Spec.BootstrapDataDelivery = restored.Spec.BootstrapDataDelivery
+	dst.Spec.CDROMs = restored.Spec.CDROMs
+	dst.Spec.TrustedPlatformModule = restored.Spec.TrustedPlatformModule
+	dst.Spec.SecureBoot = restored.Spec.SecureBoot
+	dst.Spec.CPUHotAddEnabled = restored.Spec.CPUHotAddEnabled
+	dst.Spec.MemoryHotAddEnabled = restored.Spec.MemoryHotAddEnabled
+	dst.Spec.CPUAllocation = restored.Spec.CPUAllocation
+	dst.Spec.MemoryAllocation = restored.Spec.MemoryAllocation
+	dst.Spec.RecoveryPolicy = restored.Spec.RecoveryPolicy
+	dst.Spec.DeletionPolicy = restored.Spec.DeletionPolicy
+	dst.Spec.CloneRetryPolicy = restored.Spec.CloneRetryPolicy
+	dst.Spec.LinkedClone = restored.Spec.LinkedClone
+	dst.Spec.DatastoreSelector = restored.Spec.DatastoreSelector
+	dst.Spec.ComputeSelector = restored.Spec.ComputeSelector
+	dst.Spec.TemplateSource = restored.Spec.TemplateSource
+	dst.Spec.HostNameTemplate = restored.Spec.HostNameTemplate
+	dst.Spec.HostNameDomain = restored.Spec.HostNameDomain
+	dst.Spec.DriftDetection = restored.Spec.DriftDetection
+	dst.Spec.Architecture = restored.Spec.Architecture
+	dst.Spec.HardwareVirtualization = restored.Spec.HardwareVirtualization
+	dst.Spec.VirtualIOMMU = restored.Spec.VirtualIOMMU
+	dst.Spec.TimeSync = restored.Spec.TimeSync
+	dst.Spec.Proxy = restored.Spec.Proxy
+	dst.Spec.NTPServers = restored.Spec.NTPServers
+	dst.Spec.VMName = restored.Spec.VMName
 	dst.Status.Host = restored.Status.Host
+	dst.Status.Topology = restored.Status.Topology
+	dst.Status.Placement = restored.Status.Placement
+	dst.Status.TemplateInstanceUUID = restored.Status.TemplateInstanceUUID
+	dst.Status.ISOImages = restored.Status.ISOImages
+	dst.Status.Datastore = restored.Status.Datastore
+	dst.Status.ComputeCluster = restored.Status.ComputeCluster
+	dst.Status.IncompatibleHosts = restored.Status.IncompatibleHosts
+	dst.Status.Task = restored.Status.Task
+	dst.Status.TaskProgress = restored.Status.TaskProgress
+	dst.Status.CloneAttempts = restored.Status.CloneAttempts
+	dst.Status.FailureDetails = restored.Status.FailureDetails
+	dst.Status.RetainedDisks = restored.Status.RetainedDisks
 	for i := range dst.Spec.Network.Devices {
 		dst.Spec.Network.Devices[i].AddressesFromPools = restored.Spec.Network.Devices[i].AddressesFromPools
 		dst.Spec.Network.Devices[i].DHCP4Overrides = restored.Spec.Network.Devices[i].DHCP4Overrides
 		dst.Spec.Network.Devices[i].DHCP6Overrides = restored.Spec.Network.Devices[i].DHCP6Overrides
+		dst.Spec.Network.Devices[i].IPv6AddressMode = restored.Spec.Network.Devices[i].IPv6AddressMode
 	}
 
 	return nil
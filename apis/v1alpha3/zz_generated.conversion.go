@@ -190,21 +190,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.VSphereClusterIdentitySpec)(nil), (*VSphereClusterIdentitySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec(a.(*v1beta1.VSphereClusterIdentitySpec), b.(*VSphereClusterIdentitySpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VSphereClusterIdentityStatus)(nil), (*v1beta1.VSphereClusterIdentityStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VSphereClusterIdentityStatus_To_v1beta1_VSphereClusterIdentityStatus(a.(*VSphereClusterIdentityStatus), b.(*v1beta1.VSphereClusterIdentityStatus), scope)
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.VSphereClusterIdentityStatus)(nil), (*VSphereClusterIdentityStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus(a.(*v1beta1.VSphereClusterIdentityStatus), b.(*VSphereClusterIdentityStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VSphereClusterList)(nil), (*v1beta1.VSphereClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VSphereClusterList_To_v1beta1_VSphereClusterList(a.(*VSphereClusterList), b.(*v1beta1.VSphereClusterList), scope)
 	}); err != nil {
@@ -255,11 +245,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.VSphereDeploymentZoneStatus)(nil), (*VSphereDeploymentZoneStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus(a.(*v1beta1.VSphereDeploymentZoneStatus), b.(*VSphereDeploymentZoneStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VSphereFailureDomain)(nil), (*v1beta1.VSphereFailureDomain)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VSphereFailureDomain_To_v1beta1_VSphereFailureDomain(a.(*VSphereFailureDomain), b.(*v1beta1.VSphereFailureDomain), scope)
 	}); err != nil {
@@ -345,11 +330,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.VSphereMachineTemplate)(nil), (*VSphereMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTemplate(a.(*v1beta1.VSphereMachineTemplate), b.(*VSphereMachineTemplate), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VSphereMachineTemplateList)(nil), (*v1beta1.VSphereMachineTemplateList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VSphereMachineTemplateList_To_v1beta1_VSphereMachineTemplateList(a.(*VSphereMachineTemplateList), b.(*v1beta1.VSphereMachineTemplateList), scope)
 	}); err != nil {
@@ -450,6 +430,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1beta1.VSphereClusterIdentitySpec)(nil), (*VSphereClusterIdentitySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec(a.(*v1beta1.VSphereClusterIdentitySpec), b.(*VSphereClusterIdentitySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta1.VSphereClusterIdentityStatus)(nil), (*VSphereClusterIdentityStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus(a.(*v1beta1.VSphereClusterIdentityStatus), b.(*VSphereClusterIdentityStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1beta1.VSphereClusterSpec)(nil), (*VSphereClusterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_VSphereClusterSpec_To_v1alpha3_VSphereClusterSpec(a.(*v1beta1.VSphereClusterSpec), b.(*VSphereClusterSpec), scope)
 	}); err != nil {
@@ -460,6 +450,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1beta1.VSphereDeploymentZoneStatus)(nil), (*VSphereDeploymentZoneStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus(a.(*v1beta1.VSphereDeploymentZoneStatus), b.(*VSphereDeploymentZoneStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta1.VSphereMachineTemplate)(nil), (*VSphereMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTemplate(a.(*v1beta1.VSphereMachineTemplate), b.(*VSphereMachineTemplate), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1beta1.VSphereVMStatus)(nil), (*VSphereVMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_VSphereVMStatus_To_v1alpha3_VSphereVMStatus(a.(*v1beta1.VSphereVMStatus), b.(*VSphereVMStatus), scope)
 	}); err != nil {
@@ -624,6 +624,7 @@ func autoConvert_v1beta1_NetworkDeviceSpec_To_v1alpha3_NetworkDeviceSpec(in *v1b
 	// WARNING: in.AddressesFromPools requires manual conversion: does not exist in peer-type
 	// WARNING: in.DHCP4Overrides requires manual conversion: does not exist in peer-type
 	// WARNING: in.DHCP6Overrides requires manual conversion: does not exist in peer-type
+	// WARNING: in.IPv6AddressMode requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -893,14 +894,12 @@ func Convert_v1alpha3_VSphereClusterIdentitySpec_To_v1beta1_VSphereClusterIdenti
 func autoConvert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec(in *v1beta1.VSphereClusterIdentitySpec, out *VSphereClusterIdentitySpec, s conversion.Scope) error {
 	out.SecretName = in.SecretName
 	out.AllowedNamespaces = (*AllowedNamespaces)(unsafe.Pointer(in.AllowedNamespaces))
+	// WARNING: in.CABundleRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.CredentialSource requires manual conversion: does not exist in peer-type
+	// WARNING: in.Quota requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec is an autogenerated conversion function.
-func Convert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec(in *v1beta1.VSphereClusterIdentitySpec, out *VSphereClusterIdentitySpec, s conversion.Scope) error {
-	return autoConvert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha3_VSphereClusterIdentitySpec(in, out, s)
-}
-
 func autoConvert_v1alpha3_VSphereClusterIdentityStatus_To_v1beta1_VSphereClusterIdentityStatus(in *VSphereClusterIdentityStatus, out *v1beta1.VSphereClusterIdentityStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
 	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
@@ -915,14 +914,10 @@ func Convert_v1alpha3_VSphereClusterIdentityStatus_To_v1beta1_VSphereClusterIden
 func autoConvert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus(in *v1beta1.VSphereClusterIdentityStatus, out *VSphereClusterIdentityStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.Usage requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus is an autogenerated conversion function.
-func Convert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus(in *v1beta1.VSphereClusterIdentityStatus, out *VSphereClusterIdentityStatus, s conversion.Scope) error {
-	return autoConvert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha3_VSphereClusterIdentityStatus(in, out, s)
-}
-
 func autoConvert_v1alpha3_VSphereClusterList_To_v1beta1_VSphereClusterList(in *VSphereClusterList, out *v1beta1.VSphereClusterList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -986,6 +981,16 @@ func autoConvert_v1beta1_VSphereClusterSpec_To_v1alpha3_VSphereClusterSpec(in *v
 	}
 	out.IdentityRef = (*VSphereIdentityReference)(unsafe.Pointer(in.IdentityRef))
 	// WARNING: in.ClusterModules requires manual conversion: does not exist in peer-type
+	// WARNING: in.DefaultPlacement requires manual conversion: does not exist in peer-type
+	// WARNING: in.TemplateReplication requires manual conversion: does not exist in peer-type
+	// WARNING: in.ControlPlaneEndpointAddressFromPool requires manual conversion: does not exist in peer-type
+	// WARNING: in.Endpoint requires manual conversion: does not exist in peer-type
+	// WARNING: in.Addons requires manual conversion: does not exist in peer-type
+	// WARNING: in.CABundleRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.Proxy requires manual conversion: does not exist in peer-type
+	// WARNING: in.NTPServers requires manual conversion: does not exist in peer-type
+	// WARNING: in.Nameservers requires manual conversion: does not exist in peer-type
+	// WARNING: in.NetworkSpec requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1006,6 +1011,11 @@ func autoConvert_v1beta1_VSphereClusterStatus_To_v1alpha3_VSphereClusterStatus(i
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
 	out.FailureDomains = *(*apiv1alpha3.FailureDomains)(unsafe.Pointer(&in.FailureDomains))
 	// WARNING: in.VCenterVersion requires manual conversion: does not exist in peer-type
+	// WARNING: in.ClusterModules requires manual conversion: does not exist in peer-type
+	// WARNING: in.TemplateReplicas requires manual conversion: does not exist in peer-type
+	// WARNING: in.RetainedVMs requires manual conversion: does not exist in peer-type
+	// WARNING: in.RetainedDisks requires manual conversion: does not exist in peer-type
+	// WARNING: in.PortGroup requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1043,7 +1053,17 @@ func Convert_v1beta1_VSphereDeploymentZone_To_v1alpha3_VSphereDeploymentZone(in
 
 func autoConvert_v1alpha3_VSphereDeploymentZoneList_To_v1beta1_VSphereDeploymentZoneList(in *VSphereDeploymentZoneList, out *v1beta1.VSphereDeploymentZoneList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]v1beta1.VSphereDeploymentZone)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta1.VSphereDeploymentZone, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha3_VSphereDeploymentZone_To_v1beta1_VSphereDeploymentZone(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -1054,7 +1074,17 @@ func Convert_v1alpha3_VSphereDeploymentZoneList_To_v1beta1_VSphereDeploymentZone
 
 func autoConvert_v1beta1_VSphereDeploymentZoneList_To_v1alpha3_VSphereDeploymentZoneList(in *v1beta1.VSphereDeploymentZoneList, out *VSphereDeploymentZoneList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]VSphereDeploymentZone)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereDeploymentZone, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_VSphereDeploymentZone_To_v1alpha3_VSphereDeploymentZone(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -1085,6 +1115,7 @@ func autoConvert_v1beta1_VSphereDeploymentZoneSpec_To_v1alpha3_VSphereDeployment
 	if err := Convert_v1beta1_PlacementConstraint_To_v1alpha3_PlacementConstraint(&in.PlacementConstraint, &out.PlacementConstraint, s); err != nil {
 		return err
 	}
+	// WARNING: in.Weight requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1107,14 +1138,11 @@ func Convert_v1alpha3_VSphereDeploymentZoneStatus_To_v1beta1_VSphereDeploymentZo
 func autoConvert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus(in *v1beta1.VSphereDeploymentZoneStatus, out *VSphereDeploymentZoneStatus, s conversion.Scope) error {
 	out.Ready = (*bool)(unsafe.Pointer(in.Ready))
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.ComputeCluster requires manual conversion: does not exist in peer-type
+	// WARNING: in.ResourcePool requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus is an autogenerated conversion function.
-func Convert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus(in *v1beta1.VSphereDeploymentZoneStatus, out *VSphereDeploymentZoneStatus, s conversion.Scope) error {
-	return autoConvert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha3_VSphereDeploymentZoneStatus(in, out, s)
-}
-
 func autoConvert_v1alpha3_VSphereFailureDomain_To_v1beta1_VSphereFailureDomain(in *VSphereFailureDomain, out *v1beta1.VSphereFailureDomain, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha3_VSphereFailureDomainSpec_To_v1beta1_VSphereFailureDomainSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -1315,6 +1343,8 @@ func autoConvert_v1beta1_VSphereMachineSpec_To_v1alpha3_VSphereMachineSpec(in *v
 	}
 	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
 	out.FailureDomain = (*string)(unsafe.Pointer(in.FailureDomain))
+	// WARNING: in.PlacementPrecedence requires manual conversion: does not exist in peer-type
+	// WARNING: in.NamingStrategy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1371,14 +1401,10 @@ func autoConvert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTempla
 	if err := Convert_v1beta1_VSphereMachineTemplateSpec_To_v1alpha3_VSphereMachineTemplateSpec(&in.Spec, &out.Spec, s); err != nil {
 		return err
 	}
+	// WARNING: in.Status requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTemplate is an autogenerated conversion function.
-func Convert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTemplate(in *v1beta1.VSphereMachineTemplate, out *VSphereMachineTemplate, s conversion.Scope) error {
-	return autoConvert_v1beta1_VSphereMachineTemplate_To_v1alpha3_VSphereMachineTemplate(in, out, s)
-}
-
 func autoConvert_v1alpha3_VSphereMachineTemplateList_To_v1beta1_VSphereMachineTemplateList(in *VSphereMachineTemplateList, out *v1beta1.VSphereMachineTemplateList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -1569,6 +1595,9 @@ func autoConvert_v1beta1_VSphereVMSpec_To_v1alpha3_VSphereVMSpec(in *v1beta1.VSp
 	}
 	out.BootstrapRef = (*v1.ObjectReference)(unsafe.Pointer(in.BootstrapRef))
 	out.BiosUUID = in.BiosUUID
+	// WARNING: in.Proxy requires manual conversion: does not exist in peer-type
+	// WARNING: in.NTPServers requires manual conversion: does not exist in peer-type
+	// WARNING: in.VMName requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1609,6 +1638,18 @@ func autoConvert_v1beta1_VSphereVMStatus_To_v1alpha3_VSphereVMStatus(in *v1beta1
 	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
 	// WARNING: in.ModuleUUID requires manual conversion: does not exist in peer-type
+	// WARNING: in.TemplateInstanceUUID requires manual conversion: does not exist in peer-type
+	// WARNING: in.Task requires manual conversion: does not exist in peer-type
+	// WARNING: in.TaskProgress requires manual conversion: does not exist in peer-type
+	// WARNING: in.ISOImages requires manual conversion: does not exist in peer-type
+	// WARNING: in.Datastore requires manual conversion: does not exist in peer-type
+	// WARNING: in.ComputeCluster requires manual conversion: does not exist in peer-type
+	// WARNING: in.Topology requires manual conversion: does not exist in peer-type
+	// WARNING: in.RetainedDisks requires manual conversion: does not exist in peer-type
+	// WARNING: in.CloneAttempts requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailureDetails requires manual conversion: does not exist in peer-type
+	// WARNING: in.Placement requires manual conversion: does not exist in peer-type
+	// WARNING: in.IncompatibleHosts requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1667,8 +1708,10 @@ func Convert_v1alpha3_VirtualMachineCloneSpec_To_v1beta1_VirtualMachineCloneSpec
 
 func autoConvert_v1beta1_VirtualMachineCloneSpec_To_v1alpha3_VirtualMachineCloneSpec(in *v1beta1.VirtualMachineCloneSpec, out *VirtualMachineCloneSpec, s conversion.Scope) error {
 	out.Template = in.Template
+	// WARNING: in.TemplateSource requires manual conversion: does not exist in peer-type
 	out.CloneMode = CloneMode(in.CloneMode)
 	out.Snapshot = in.Snapshot
+	// WARNING: in.LinkedClone requires manual conversion: does not exist in peer-type
 	out.Server = in.Server
 	out.Thumbprint = in.Thumbprint
 	out.Datacenter = in.Datacenter
@@ -1689,5 +1732,28 @@ func autoConvert_v1beta1_VirtualMachineCloneSpec_To_v1alpha3_VirtualMachineClone
 	// WARNING: in.PciDevices requires manual conversion: does not exist in peer-type
 	// WARNING: in.OS requires manual conversion: does not exist in peer-type
 	// WARNING: in.HardwareVersion requires manual conversion: does not exist in peer-type
+	// WARNING: in.CustomizationSpec requires manual conversion: does not exist in peer-type
+	// WARNING: in.BootstrapDataDelivery requires manual conversion: does not exist in peer-type
+	// WARNING: in.CDROMs requires manual conversion: does not exist in peer-type
+	// WARNING: in.TrustedPlatformModule requires manual conversion: does not exist in peer-type
+	// WARNING: in.SecureBoot requires manual conversion: does not exist in peer-type
+	// WARNING: in.CPUHotAddEnabled requires manual conversion: does not exist in peer-type
+	// WARNING: in.MemoryHotAddEnabled requires manual conversion: does not exist in peer-type
+	// WARNING: in.CPUAllocation requires manual conversion: does not exist in peer-type
+	// WARNING: in.MemoryAllocation requires manual conversion: does not exist in peer-type
+	// WARNING: in.CustomAttributes requires manual conversion: does not exist in peer-type
+	// WARNING: in.DatastoreSelector requires manual conversion: does not exist in peer-type
+	// WARNING: in.ComputeSelector requires manual conversion: does not exist in peer-type
+	// WARNING: in.RecoveryPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.DeletionPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdditionalDisks requires manual conversion: does not exist in peer-type
+	// WARNING: in.CloneRetryPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.HostNameTemplate requires manual conversion: does not exist in peer-type
+	// WARNING: in.HostNameDomain requires manual conversion: does not exist in peer-type
+	// WARNING: in.DriftDetection requires manual conversion: does not exist in peer-type
+	// WARNING: in.Architecture requires manual conversion: does not exist in peer-type
+	// WARNING: in.HardwareVirtualization requires manual conversion: does not exist in peer-type
+	// WARNING: in.VirtualIOMMU requires manual conversion: does not exist in peer-type
+	// WARNING: in.TimeSync requires manual conversion: does not exist in peer-type
 	return nil
 }
@@ -36,6 +36,22 @@ func Convert_v1beta1_VSphereClusterStatus_To_v1alpha4_VSphereClusterStatus(in *v
 	return autoConvert_v1beta1_VSphereClusterStatus_To_v1alpha4_VSphereClusterStatus(in, out, s)
 }
 
+func Convert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha4_VSphereClusterIdentitySpec(in *v1beta1.VSphereClusterIdentitySpec, out *VSphereClusterIdentitySpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_VSphereClusterIdentitySpec_To_v1alpha4_VSphereClusterIdentitySpec(in, out, s)
+}
+
+func Convert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha4_VSphereClusterIdentityStatus(in *v1beta1.VSphereClusterIdentityStatus, out *VSphereClusterIdentityStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_VSphereClusterIdentityStatus_To_v1alpha4_VSphereClusterIdentityStatus(in, out, s)
+}
+
 func Convert_v1beta1_VSphereClusterSpec_To_v1alpha4_VSphereClusterSpec(in *v1beta1.VSphereClusterSpec, out *VSphereClusterSpec, s conversion.Scope) error {
 	return autoConvert_v1beta1_VSphereClusterSpec_To_v1alpha4_VSphereClusterSpec(in, out, s)
 }
+
+func Convert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha4_VSphereDeploymentZoneStatus(in *v1beta1.VSphereDeploymentZoneStatus, out *VSphereDeploymentZoneStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_VSphereDeploymentZoneStatus_To_v1alpha4_VSphereDeploymentZoneStatus(in, out, s)
+}
+
+func Convert_v1beta1_VSphereMachineTemplate_To_v1alpha4_VSphereMachineTemplate(in *v1beta1.VSphereMachineTemplate, out *VSphereMachineTemplate, s conversion.Scope) error {
+	return autoConvert_v1beta1_VSphereMachineTemplate_To_v1alpha4_VSphereMachineTemplate(in, out, s)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	nextver "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+//nolint:paralleltest
+func TestFuzzyConversion(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(AddToScheme(scheme)).To(Succeed())
+	g.Expect(nextver.AddToScheme(scheme)).To(Succeed())
+
+	t.Run("for VSphereCluster", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereCluster{},
+		Spoke:  &VSphereCluster{},
+	}))
+	t.Run("for VSphereClusterTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereClusterTemplate{},
+		Spoke:  &VSphereClusterTemplate{},
+	}))
+	t.Run("for VSphereClusterIdentity", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereClusterIdentity{},
+		Spoke:  &VSphereClusterIdentity{},
+	}))
+	t.Run("for VSphereDeploymentZone", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereDeploymentZone{},
+		Spoke:  &VSphereDeploymentZone{},
+	}))
+	t.Run("for VSphereFailureDomain", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereFailureDomain{},
+		Spoke:  &VSphereFailureDomain{},
+	}))
+	t.Run("for VSphereMachine", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereMachine{},
+		Spoke:  &VSphereMachine{},
+	}))
+	t.Run("for VSphereMachineTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereMachineTemplate{},
+		Spoke:  &VSphereMachineTemplate{},
+	}))
+	t.Run("for VSphereVM", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &nextver.VSphereVM{},
+		Spoke:  &VSphereVM{},
+	}))
+}
+
+func TestConversionPreservesChangedSpoke(t *testing.T) {
+	g := NewWithT(t)
+
+	hub := &nextver.VSphereCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec: nextver.VSphereClusterSpec{
+			Server:              "vcenter.example.com",
+			TemplateReplication: &nextver.TemplateReplicationSpec{Templates: []string{"ubuntu-2004"}},
+		},
+		Status: nextver.VSphereClusterStatus{VCenterVersion: "7.0.3"},
+	}
+
+	spoke := &VSphereCluster{}
+	g.Expect(spoke.ConvertFrom(hub)).To(Succeed())
+	g.Expect(spoke.Annotations).To(HaveKey(utilconversion.DataAnnotation))
+
+	// The fields of the spoke changed since the down-conversion are kept,
+	// along with the fields the spoke does not have.
+	spoke.Spec.Server = "vcenter2.example.com"
+	restored := &nextver.VSphereCluster{}
+	g.Expect(spoke.ConvertTo(restored)).To(Succeed())
+	g.Expect(restored.Spec.Server).To(Equal("vcenter2.example.com"))
+	g.Expect(restored.Spec.TemplateReplication).To(Equal(hub.Spec.TemplateReplication))
+	g.Expect(restored.Status.VCenterVersion).To(Equal(hub.Status.VCenterVersion))
+}
@@ -18,21 +18,68 @@ limitations under the License.
 package v1alpha4
 
 import (
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
 // ConvertTo converts this VSphereCluster to the Hub version (v1beta1).
 func (src *VSphereCluster) ConvertTo(dstRaw conversion.Hub) error {
 	dst := dstRaw.(*infrav1beta1.VSphereCluster)
-	return Convert_v1alpha4_VSphereCluster_To_v1beta1_VSphereCluster(src, dst, nil)
+	if err := Convert_v1alpha4_VSphereCluster_To_v1beta1_VSphereCluster(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Manually restore data.
+	restored := &infrav1beta1.VSphereCluster{}
+	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+		return err
+	}
+
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+		return nil
+	}
+
+	dst.Spec.ClusterModules = restored.Spec.ClusterModules
+	dst.Spec.DefaultPlacement = restored.Spec.DefaultPlacement
+	dst.Spec.TemplateReplication = restored.Spec.TemplateReplication
+	dst.Spec.Addons = restored.Spec.Addons
+	dst.Spec.Proxy = restored.Spec.Proxy
+	dst.Spec.NTPServers = restored.Spec.NTPServers
+	dst.Spec.Nameservers = restored.Spec.Nameservers
+	dst.Spec.NetworkSpec = restored.Spec.NetworkSpec
+	dst.Spec.CABundleRef = restored.Spec.CABundleRef
+	dst.Spec.ControlPlaneEndpointAddressFromPool = restored.Spec.ControlPlaneEndpointAddressFromPool
+	// The endpoint is defaulted again from the server when the server was
+	// changed on the spoke.
+	if dst.Spec.Server == restored.Spec.Server && dst.Spec.Thumbprint == restored.Spec.Thumbprint {
+		dst.Spec.Endpoint = restored.Spec.Endpoint
+	}
+	dst.Status.VCenterVersion = restored.Status.VCenterVersion
+	dst.Status.ClusterModules = restored.Status.ClusterModules
+	dst.Status.TemplateReplicas = restored.Status.TemplateReplicas
+	dst.Status.RetainedVMs = restored.Status.RetainedVMs
+	dst.Status.RetainedDisks = restored.Status.RetainedDisks
+	dst.Status.PortGroup = restored.Status.PortGroup
+
+	return nil
 }
 
 // ConvertFrom converts from the Hub version (v1beta1) to this VSphereCluster.
 func (dst *VSphereCluster) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*infrav1beta1.VSphereCluster)
-	return Convert_v1beta1_VSphereCluster_To_v1alpha4_VSphereCluster(src, dst, nil)
+	if err := Convert_v1beta1_VSphereCluster_To_v1alpha4_VSphereCluster(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
 }
 
 // ConvertTo converts this VSphereClusterList to the Hub version (v1beta1).
@@ -17,21 +17,59 @@ limitations under the License.
 package v1alpha4
 
 import (
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
 // ConvertTo converts this VSphereClusterTemplate to the Hub version (v1beta1).
 func (src *VSphereClusterTemplate) ConvertTo(dstRaw conversion.Hub) error {
 	dst := dstRaw.(*infrav1beta1.VSphereClusterTemplate)
-	return Convert_v1alpha4_VSphereClusterTemplate_To_v1beta1_VSphereClusterTemplate(src, dst, nil)
+	if err := Convert_v1alpha4_VSphereClusterTemplate_To_v1beta1_VSphereClusterTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Manually restore data.
+	restored := &infrav1beta1.VSphereClusterTemplate{}
+	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+		return err
+	}
+
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		return nil
+	}
+
+	dst.Spec.Template.Spec.ClusterModules = restored.Spec.Template.Spec.ClusterModules
+	dst.Spec.Template.Spec.DefaultPlacement = restored.Spec.Template.Spec.DefaultPlacement
+	dst.Spec.Template.Spec.TemplateReplication = restored.Spec.Template.Spec.TemplateReplication
+	dst.Spec.Template.Spec.Addons = restored.Spec.Template.Spec.Addons
+	dst.Spec.Template.Spec.Proxy = restored.Spec.Template.Spec.Proxy
+	dst.Spec.Template.Spec.NTPServers = restored.Spec.Template.Spec.NTPServers
+	dst.Spec.Template.Spec.Nameservers = restored.Spec.Template.Spec.Nameservers
+	dst.Spec.Template.Spec.NetworkSpec = restored.Spec.Template.Spec.NetworkSpec
+	dst.Spec.Template.Spec.CABundleRef = restored.Spec.Template.Spec.CABundleRef
+	dst.Spec.Template.Spec.ControlPlaneEndpointAddressFromPool = restored.Spec.Template.Spec.ControlPlaneEndpointAddressFromPool
+	if dst.Spec.Template.Spec.Server == restored.Spec.Template.Spec.Server && dst.Spec.Template.Spec.Thumbprint == restored.Spec.Template.Spec.Thumbprint {
+		dst.Spec.Template.Spec.Endpoint = restored.Spec.Template.Spec.Endpoint
+	}
+
+	return nil
 }
 
 // ConvertFrom converts from the Hub version (v1beta1) to this VSphereClusterTemplate.
 func (dst *VSphereClusterTemplate) ConvertFrom(srcRaw conversion.Hub) error { // nolint
 	src := srcRaw.(*infrav1beta1.VSphereClusterTemplate)
-	return Convert_v1beta1_VSphereClusterTemplate_To_v1alpha4_VSphereClusterTemplate(src, dst, nil)
+	if err := Convert_v1beta1_VSphereClusterTemplate_To_v1alpha4_VSphereClusterTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
 }
 
 // ConvertTo converts this VSphereClusterIdentityList to the Hub version (v1beta1).
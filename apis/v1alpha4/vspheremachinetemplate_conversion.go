@@ -24,6 +24,7 @@ import (
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 
+	"sigs.k8s.io/cluster-api-provider-vsphere/apis/internal/roundtrip"
 	infrav1beta1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
@@ -39,12 +40,51 @@ func (src *VSphereMachineTemplate) ConvertTo(dstRaw conversion.Hub) error {
 	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
 		return err
 	}
+
+	// The spoke has not been changed since it was converted from the Hub,
+	// which then holds all its data.
+	if roundtrip.SpokeUnchanged(src, restored) {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+		return nil
+	}
+
+	dst.Status = restored.Status
+
 	dst.Spec.Template.Spec.TagIDs = restored.Spec.Template.Spec.TagIDs
+	dst.Spec.Template.Spec.CustomAttributes = restored.Spec.Template.Spec.CustomAttributes
 	dst.Spec.Template.Spec.AdditionalDisksGiB = restored.Spec.Template.Spec.AdditionalDisksGiB
+	dst.Spec.Template.Spec.AdditionalDisks = restored.Spec.Template.Spec.AdditionalDisks
+	dst.Spec.Template.Spec.CustomizationSpec = restored.Spec.Template.Spec.CustomizationSpec
+	dst.Spec.Template.Spec.BootstrapDataDelivery = restored.Spec.Template.Spec.BootstrapDataDelivery
+	dst.Spec.Template.Spec.CDROMs = restored.Spec.Template.Spec.CDROMs
+	dst.Spec.Template.Spec.TrustedPlatformModule = restored.Spec.Template.Spec.TrustedPlatformModule
+	dst.Spec.Template.Spec.SecureBoot = restored.Spec.Template.Spec.SecureBoot
+	dst.Spec.Template.Spec.CPUHotAddEnabled = restored.Spec.Template.Spec.CPUHotAddEnabled
+	dst.Spec.Template.Spec.MemoryHotAddEnabled = restored.Spec.Template.Spec.MemoryHotAddEnabled
+	dst.Spec.Template.Spec.CPUAllocation = restored.Spec.Template.Spec.CPUAllocation
+	dst.Spec.Template.Spec.MemoryAllocation = restored.Spec.Template.Spec.MemoryAllocation
+	dst.Spec.Template.Spec.RecoveryPolicy = restored.Spec.Template.Spec.RecoveryPolicy
+	dst.Spec.Template.Spec.DeletionPolicy = restored.Spec.Template.Spec.DeletionPolicy
+	dst.Spec.Template.Spec.CloneRetryPolicy = restored.Spec.Template.Spec.CloneRetryPolicy
+	dst.Spec.Template.Spec.LinkedClone = restored.Spec.Template.Spec.LinkedClone
+	dst.Spec.Template.Spec.DatastoreSelector = restored.Spec.Template.Spec.DatastoreSelector
+	dst.Spec.Template.Spec.ComputeSelector = restored.Spec.Template.Spec.ComputeSelector
+	dst.Spec.Template.Spec.PlacementPrecedence = restored.Spec.Template.Spec.PlacementPrecedence
+	dst.Spec.Template.Spec.NamingStrategy = restored.Spec.Template.Spec.NamingStrategy
+	dst.Spec.Template.Spec.TemplateSource = restored.Spec.Template.Spec.TemplateSource
+	dst.Spec.Template.Spec.HostNameTemplate = restored.Spec.Template.Spec.HostNameTemplate
+	dst.Spec.Template.Spec.HostNameDomain = restored.Spec.Template.Spec.HostNameDomain
+	dst.Spec.Template.Spec.DriftDetection = restored.Spec.Template.Spec.DriftDetection
+	dst.Spec.Template.Spec.Architecture = restored.Spec.Template.Spec.Architecture
+	dst.Spec.Template.Spec.HardwareVirtualization = restored.Spec.Template.Spec.HardwareVirtualization
+	dst.Spec.Template.Spec.VirtualIOMMU = restored.Spec.Template.Spec.VirtualIOMMU
+	dst.Spec.Template.Spec.TimeSync = restored.Spec.Template.Spec.TimeSync
 	for i := range dst.Spec.Template.Spec.Network.Devices {
 		dst.Spec.Template.Spec.Network.Devices[i].AddressesFromPools = restored.Spec.Template.Spec.Network.Devices[i].AddressesFromPools
 		dst.Spec.Template.Spec.Network.Devices[i].DHCP4Overrides = restored.Spec.Template.Spec.Network.Devices[i].DHCP4Overrides
 		dst.Spec.Template.Spec.Network.Devices[i].DHCP6Overrides = restored.Spec.Template.Spec.Network.Devices[i].DHCP6Overrides
+		dst.Spec.Template.Spec.Network.Devices[i].IPv6AddressMode = restored.Spec.Template.Spec.Network.Devices[i].IPv6AddressMode
 	}
 
 	return nil
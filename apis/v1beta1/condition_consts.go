@@ -72,7 +72,9 @@ const (
 
 	// CloningFailedReason (Severity=Warning) documents a VSphereMachine/VSphereVM controller detecting
 	// an error while provisioning; those kind of errors are usually transient and failed provisioning
-	// are automatically re-tried by the controller.
+	// are automatically re-tried by the controller. The VMs which are not cloned because their template
+	// is not compatible with their spec are reported with the reason of the incompatibility instead, e.g.
+	// TemplateArchitectureMismatchReason, with Severity=Error.
 	CloningFailedReason = "CloningFailed"
 
 	// PoweringOnReason documents (Severity=Info) a VSphereMachine/VSphereVM currently executing the power on sequence.
@@ -83,6 +85,11 @@ const (
 	// are automatically re-tried by the controller.
 	PoweringOnFailedReason = "PoweringOnFailed"
 
+	// VSphereOperationsPausedReason (Severity=Info) documents a VSphereVM which is not created, reconfigured or
+	// deleted because the vSphere operations of its cluster are paused by the
+	// VSphereOperationsPausedAnnotation of its VSphereCluster.
+	VSphereOperationsPausedReason = "VSphereOperationsPaused"
+
 	// TaskFailure (Severity=Warning) documents a VSphereMachine/VSphere task failure; the reconcile look will automatically
 	// retry the operation, but a user intervention might be required to fix the problem.
 	TaskFailure = "TaskFailure"
@@ -95,6 +102,14 @@ const (
 
 	// TagsAttachmentFailedReason (Severity=Error) documents a VSPhereMachine/VSphereVM tags attachment failure.
 	TagsAttachmentFailedReason = "TagsAttachmentFailed"
+
+	// CustomAttributesFailedReason (Severity=Error) documents a VSphereMachine/VSphereVM whose
+	// custom attribute values could not be set.
+	CustomAttributesFailedReason = "CustomAttributesFailed"
+
+	// TenantPolicyViolationReason (Severity=Error) documents a VSphereVM targeting vSphere inventory
+	// its namespace is not allowed to use by the VSphereTenantPolicies.
+	TenantPolicyViolationReason = "TenantPolicyViolation"
 )
 
 // Conditions and Reasons related to utilizing a VSphereIdentity to make connections to a VCenter.
@@ -109,6 +124,13 @@ const (
 	VCenterUnreachableReason = "VCenterUnreachable"
 )
 
+const (
+	// ExternallyManagedReason (Severity=Info) documents a VSphereCluster with the
+	// cluster.x-k8s.io/managed-by annotation, whose responsibility for the condition
+	// is ceded to the controller managing the cluster infrastructure.
+	ExternallyManagedReason = "ExternallyManaged"
+)
+
 const (
 	// ClusterModulesAvailableCondition documents the availability of cluster modules for the VSphereCluster object.
 	ClusterModulesAvailableCondition clusterv1.ConditionType = "ClusterModulesAvailable"
@@ -127,6 +149,105 @@ const (
 	ClusterModuleSetupFailedReason = "ClusterModuleSetupFailed"
 )
 
+const (
+	// TemplateReplicasReadyCondition documents whether the templates configured in the
+	// TemplateReplication of the VSphereCluster are replicated to the datastores of all
+	// its failure domains.
+	TemplateReplicasReadyCondition clusterv1.ConditionType = "TemplateReplicasReady"
+
+	// TemplateReplicationInProgressReason (Severity=Info) documents that one or more
+	// template replicas are being created.
+	TemplateReplicationInProgressReason = "TemplateReplicationInProgress"
+
+	// TemplateReplicationFailedReason (Severity=Warning) documents that one or more
+	// template replicas could not be created. The VMs of the affected failure domains
+	// are cloned from the source template instead.
+	TemplateReplicationFailedReason = "TemplateReplicationFailed"
+)
+
+const (
+	// PortGroupReadyCondition documents whether the distributed port group requested by the
+	// NetworkSpec of the VSphereCluster exists with the requested configuration.
+	PortGroupReadyCondition clusterv1.ConditionType = "PortGroupReady"
+
+	// PortGroupCreationFailedReason (Severity=Error) documents that the port group of the cluster
+	// could not be created or reconfigured. The VSphereCluster is not ready until it is.
+	PortGroupCreationFailedReason = "PortGroupCreationFailed"
+
+	// PortGroupsCompatibleCondition documents whether the distributed port groups of a VSphereVM were
+	// found compatible with the PortGroupValidation of its cluster before its VM was created. Its reason
+	// is PortGroupIncompatibleReason when they were not.
+	PortGroupsCompatibleCondition clusterv1.ConditionType = "PortGroupsCompatible"
+)
+
+const (
+	// PreflightChecksSucceededCondition documents whether the vSphere inventory and the control plane
+	// endpoint of a VSphereCluster passed the checks run before it first becomes ready, and so before
+	// any VM of the cluster is cloned.
+	PreflightChecksSucceededCondition clusterv1.ConditionType = "PreflightChecksSucceeded"
+
+	// PermissionsMissingReason (Severity=Error) documents that the vCenter session of the cluster lacks
+	// privileges required to clone the VMs of the cluster.
+	PermissionsMissingReason = "PermissionsMissing"
+
+	// TemplateNotFoundReason (Severity=Error) documents that a template of the machine templates of the
+	// cluster does not exist.
+	TemplateNotFoundReason = "TemplateNotFound"
+
+	// InventoryNotFoundReason (Severity=Error) documents that the datacenter, folder, resource pool or
+	// datastore of the machine templates of the cluster does not exist.
+	InventoryNotFoundReason = "InventoryNotFound"
+
+	// ControlPlaneEndpointUnreachableReason (Severity=Error) documents that the host of the control plane
+	// endpoint cannot be resolved. With Severity=Warning, it documents that the endpoint already answers
+	// before the control plane is created, which hints at an address conflict.
+	ControlPlaneEndpointUnreachableReason = "ControlPlaneEndpointUnreachable"
+
+	// DatastoreSpaceLowReason (Severity=Warning) documents that a datastore of the machine templates of
+	// the cluster does not have room for a full clone of their template.
+	DatastoreSpaceLowReason = "DatastoreSpaceLow"
+
+	// PortGroupIncompatibleReason (Severity=Warning) documents that a distributed port group of the machine
+	// templates of the cluster, or of a VSphereVM, is a VLAN trunk or an isolated private VLAN, or does not
+	// have the VLAN ID or the security policy expected by the PortGroupValidation of the cluster.
+	PortGroupIncompatibleReason = "PortGroupIncompatible"
+
+	// AddressPoolNotFoundReason (Severity=Error) documents that an IPAM pool the machine templates of the
+	// cluster claim their IP addresses from does not exist.
+	AddressPoolNotFoundReason = "AddressPoolNotFound"
+)
+
+const (
+	// TemplateValidCondition documents whether the template of a VSphereMachineTemplate, as validated
+	// periodically by the VSphereClusters using the VSphereMachineTemplate, can be cloned to the
+	// machines of its spec. Its reason is TemplateNotFoundReason or InventoryNotFoundReason when the
+	// template or its datacenter does not exist.
+	TemplateValidCondition clusterv1.ConditionType = "TemplateValid"
+
+	// TemplateToolsNotInstalledReason (Severity=Warning) documents that VMware Tools are not installed
+	// on a template, without which the VMs cloned from it do not report their IP addresses.
+	TemplateToolsNotInstalledReason = "TemplateToolsNotInstalled"
+
+	// TemplateHardwareVersionMismatchReason (Severity=Error) documents that the hardware version of a
+	// template is newer than the one of the spec, which the VMs cannot be downgraded to, or too old for
+	// the trusted platform module of the spec.
+	TemplateHardwareVersionMismatchReason = "TemplateHardwareVersionMismatch"
+
+	// TemplateFirmwareMismatchReason (Severity=Error) documents that a template does not have the EFI
+	// firmware required by the secure boot, the trusted platform module or the arm64 architecture of the
+	// spec.
+	TemplateFirmwareMismatchReason = "TemplateFirmwareMismatch"
+
+	// TemplateArchitectureMismatchReason (Severity=Error) documents that the guest ID of a template is
+	// the one of another architecture than the one of the spec, e.g. an arm64 template for amd64
+	// machines.
+	TemplateArchitectureMismatchReason = "TemplateArchitectureMismatch"
+
+	// TemplateOSMismatchReason (Severity=Error) documents that the guest ID of a template is the one
+	// of another operating system than the one of the spec, e.g. a Windows template for Linux machines.
+	TemplateOSMismatchReason = "TemplateOSMismatch"
+)
+
 const (
 	// CredentialsAvailableCondidtion is used by VSphereClusterIdentity when a credential
 	// secret is available and unused by other VSphereClusterIdentities.
@@ -140,6 +261,19 @@ const (
 
 	// SecretAlreadyInUseReason is used when another VSphereClusterIdentity is using the secret.
 	SecretAlreadyInUseReason = "SecretInUse"
+
+	// CredentialSourceUnavailableReason is used when the credentials cannot be
+	// read from the external credential source of the VSphereClusterIdentity.
+	CredentialSourceUnavailableReason = "CredentialSourceUnavailable"
+
+	// WithinQuotaCondition documents whether the clusters using a VSphereClusterIdentity consume less
+	// vSphere capacity than its quota allows.
+	WithinQuotaCondition clusterv1.ConditionType = "WithinQuota"
+
+	// QuotaExceededReason (Severity=Warning) documents a VSphereClusterIdentity whose clusters exceed its
+	// quota, or a VSphereVM which is not created because it would exceed the quota of the identity of
+	// its cluster.
+	QuotaExceededReason = "QuotaExceeded"
 )
 
 const (
@@ -187,16 +321,258 @@ const (
 	DatastoreNotFoundReason = "DatastoreNotFound"
 )
 
+const (
+	// InventoryPathsUpToDateCondition documents whether the compute cluster and the resource pool of the
+	// VSphereDeploymentZone are still found at the inventory paths it is configured with. They are tracked by
+	// managed object ID once resolved, so that machines are still placed when they are renamed in vCenter.
+	InventoryPathsUpToDateCondition clusterv1.ConditionType = "InventoryPathsUpToDate"
+
+	// InventoryObjectRenamedReason (Severity=Warning) documents that the compute cluster or the resource pool
+	// of the VSphereDeploymentZone was renamed or moved in vCenter, and is now found at another inventory path.
+	InventoryObjectRenamedReason = "InventoryObjectRenamed"
+)
+
 const (
 	// IPAddressClaimedCondition documents the status of claiming an IP address
 	// from an IPAM provider.
 	IPAddressClaimedCondition clusterv1.ConditionType = "IPAddressClaimed"
 
-	// WaitingForIPAddressReason (Severity=Info) documents that the VSphereVM is
-	// currently waiting for an IP address to be provisioned.
+	// WaitingForIPAddressReason (Severity=Info) documents that the VSphereVM, or
+	// the VSphereCluster claiming its control plane endpoint, is currently
+	// waiting for an IP address to be provisioned.
 	WaitingForIPAddressReason = "WaitingForIPAddress"
 
 	// IPAddressInvalidReason (Severity=Error) documents that the IP address
 	// provided by the IPAM provider is not valid.
 	IPAddressInvalidReason = "IPAddressInvalid"
 )
+
+// Conditions and Reasons related to the reports of the guest agent, when the
+// GuestAgent feature is enabled. Can currently be used by VSphereVM and
+// VSphereMachine.
+const (
+	// GuestBootstrapSucceededCondition documents the bootstrap of the machine as
+	// reported by the guest agent.
+	GuestBootstrapSucceededCondition clusterv1.ConditionType = "GuestBootstrapSucceeded"
+
+	// GuestBootstrapInProgressReason (Severity=Info) documents that the guest agent
+	// reports that the bootstrap of the machine is running.
+	GuestBootstrapInProgressReason = "GuestBootstrapInProgress"
+
+	// GuestBootstrapFailedReason (Severity=Error) documents that the guest agent
+	// reports that the bootstrap of the machine failed.
+	GuestBootstrapFailedReason = "GuestBootstrapFailed"
+
+	// GuestKubeadmFailedReason (Severity=Error) documents that the guest agent
+	// reports that kubeadm failed to initialize or join the node.
+	GuestKubeadmFailedReason = "GuestKubeadmFailed"
+
+	// GuestNodeHealthyCondition documents the health of the node of the machine
+	// as reported by the guest agent.
+	GuestNodeHealthyCondition clusterv1.ConditionType = "GuestNodeHealthy"
+
+	// GuestNodeUnhealthyReason (Severity=Warning) documents that the guest agent
+	// reports that the kubelet of the node is not healthy.
+	GuestNodeUnhealthyReason = "GuestNodeUnhealthy"
+
+	// GuestAgentReportStaleReason (Severity=Warning) documents that the guest agent
+	// stopped reporting the health of the node.
+	GuestAgentReportStaleReason = "GuestAgentReportStale"
+
+	// GuestAgentReportInvalidReason (Severity=Warning) documents that a report of
+	// the guest agent cannot be parsed.
+	GuestAgentReportInvalidReason = "GuestAgentReportInvalid"
+)
+
+// Conditions and Reasons related to resizing the VM of an existing VSphereVM.
+// Can currently be used by VSphereVM.
+const (
+	// VMResizedCondition documents the reconfiguration of the number of CPUs
+	// and of the memory of an existing VM to the ones of its spec.
+	VMResizedCondition clusterv1.ConditionType = "VMResized"
+
+	// ResizingReason (Severity=Info) documents that the VM is being
+	// reconfigured with the number of CPUs and the memory of its spec.
+	ResizingReason = "Resizing"
+
+	// PoweringOffForResizeReason (Severity=Info) documents that the node of
+	// the VM is cordoned and its guest shut down to be resized, as its CPUs or
+	// its memory cannot be hot-added.
+	PoweringOffForResizeReason = "PoweringOffForResize"
+
+	// ResizeFailedReason (Severity=Warning) documents that the VM could not be
+	// resized.
+	ResizeFailedReason = "ResizeFailed"
+)
+
+// Conditions and Reasons related to the placement of the VM of a VSphereMachine
+// in its failure domain.
+// Can currently be used by VSphereMachine.
+const (
+	// PlacementResolvedCondition documents the placement values the VSphereVM
+	// of a VSphereMachine is created with, when the VSphereMachine and its
+	// failure domain both set some of them. The condition is true when none of
+	// the values conflict.
+	PlacementResolvedCondition clusterv1.ConditionType = "PlacementResolved"
+
+	// MachinePlacementOverriddenReason (Severity=Info) documents placement
+	// values of a VSphereMachine which are overridden by the ones of its
+	// failure domain.
+	MachinePlacementOverriddenReason = "MachinePlacementOverridden"
+
+	// FailureDomainPlacementOverriddenReason (Severity=Info) documents
+	// placement values of a failure domain which are overridden by the ones
+	// of the VSphereMachine, as its placement precedence is Machine.
+	FailureDomainPlacementOverriddenReason = "FailureDomainPlacementOverridden"
+)
+
+// Conditions and Reasons related to the health of the ESXi host running the
+// VM of a VSphereVM. Can currently be used by VSphereVM and VSphereMachine.
+const (
+	// HostHealthyCondition documents the connection state of the ESXi host
+	// running the VM, as seen by vCenter. The condition is false when the host
+	// does not respond or is disconnected, and the VM is likely down with it.
+	HostHealthyCondition clusterv1.ConditionType = "HostHealthy"
+
+	// HostNotRespondingReason (Severity=Error) documents that vCenter lost the
+	// heartbeats of the ESXi host running the VM.
+	HostNotRespondingReason = "HostNotResponding"
+
+	// HostDisconnectedReason (Severity=Error) documents that the ESXi host
+	// running the VM is disconnected from vCenter.
+	HostDisconnectedReason = "HostDisconnected"
+)
+
+// Reasons of the VMProvisionedCondition related to the recovery of the VMs
+// found suspended, orphaned, inaccessible or invalid in vCenter, according to
+// the recovery policy of the VSphereVM.
+const (
+	// VMSuspendedReason (Severity=Warning) documents that the VM is suspended.
+	VMSuspendedReason = "VMSuspended"
+
+	// VMOrphanedReason (Severity=Error) documents that the VM is orphaned, as
+	// it is no longer registered on the host vCenter expects it on.
+	VMOrphanedReason = "VMOrphaned"
+
+	// VMInaccessibleReason (Severity=Error) documents that the files of the VM
+	// are inaccessible, e.g. because its datastore is unreachable.
+	VMInaccessibleReason = "VMInaccessible"
+
+	// VMInvalidReason (Severity=Error) documents that the configuration of the
+	// VM is invalid, e.g. because its configuration file is corrupted.
+	VMInvalidReason = "VMInvalid"
+
+	// RecoveringReason (Severity=Info) documents that the VM is being powered
+	// on, registered again or recreated by its recovery policy.
+	RecoveringReason = "Recovering"
+
+	// RecoveryFailedReason (Severity=Warning) documents that the recovery
+	// policy of the VM failed to recover it.
+	RecoveryFailedReason = "RecoveryFailed"
+)
+
+// Conditions and Reasons related to the migrations of the VM of a VSphereVM
+// to other ESXi hosts. Can currently be used by VSphereVM and VSphereMachine.
+const (
+	// RecentlyMigratedCondition documents that the VM was moved to another
+	// ESXi host recently, by vMotion, DRS or vSphere HA, so that blips of its
+	// node can be correlated with the migration. The condition turns false
+	// once the VM stayed on its host for a while.
+	RecentlyMigratedCondition clusterv1.ConditionType = "RecentlyMigrated"
+
+	// MigratedReason documents that the VM was migrated to another host with
+	// vMotion.
+	MigratedReason = "Migrated"
+
+	// DRSMigratedReason documents that the VM was migrated to another host
+	// by DRS.
+	DRSMigratedReason = "DRSMigrated"
+
+	// HARestartedReason documents that the VM was restarted on another host
+	// by vSphere HA after its host failed.
+	HARestartedReason = "HARestarted"
+
+	// MigrationSettledReason (Severity=Info) documents that the VM was not
+	// migrated since its last migration for a while.
+	MigrationSettledReason = "MigrationSettled"
+)
+
+// Conditions and Reasons related to the rotation of the credentials of the
+// identity of a cluster. Can currently be used by VSphereCluster.
+const (
+	// CredentialsUpToDateCondition documents that the secrets of the vSphere
+	// cloud provider and CSI driver of the workload cluster hold the current
+	// credentials of the identity of the cluster.
+	CredentialsUpToDateCondition clusterv1.ConditionType = "CredentialsUpToDate"
+
+	// CredentialsRotationFailedReason (Severity=Warning) documents a failure to
+	// write the rotated credentials of the identity of the cluster to the
+	// secrets of the workload cluster.
+	CredentialsRotationFailedReason = "CredentialsRotationFailed"
+)
+
+// Conditions and Reasons related to the privileges of the vCenter user of a
+// cluster. Can currently be used by VSphereCluster.
+const (
+	// PrivilegesAvailableCondition documents whether the vCenter user of the
+	// cluster has the privileges the controllers need for the features the
+	// cluster uses, on the datacenters of its machine templates.
+	PrivilegesAvailableCondition clusterv1.ConditionType = "PrivilegesAvailable"
+
+	// PrivilegesMissingReason (Severity=Warning) documents the privileges the
+	// vCenter user of the cluster lacks. The privileges granted on objects
+	// below the datacenters only, e.g. on a folder, are reported missing.
+	PrivilegesMissingReason = "PrivilegesMissing"
+)
+
+// Conditions and Reasons related to the drift of the VM of a VSphereVM from
+// the inputs it was cloned from. Can currently be used by VSphereVM and
+// VSphereMachine.
+const (
+	// SpecDriftedCondition documents that the VM no longer matches its
+	// template, networks, disk size, number of CPUs or memory, e.g. after it
+	// was reconfigured in vCenter, or that its template was replaced. The
+	// condition is only set when the DriftDetection of the VSphereVM is
+	// enabled, and is true while the VM drifted, its message listing the
+	// differences.
+	SpecDriftedCondition clusterv1.ConditionType = "SpecDrifted"
+
+	// DriftDetectedReason documents that the VM differs from the inputs it
+	// was cloned from.
+	DriftDetectedReason = "DriftDetected"
+
+	// NoDriftReason (Severity=Info) documents that the VM matches the inputs
+	// it was cloned from.
+	NoDriftReason = "NoDrift"
+)
+
+// Conditions and Reasons related to the names and inventory paths of the
+// objects a VM is cloned with. Can currently be used by VSphereVM and
+// VSphereMachine.
+const (
+	// AmbiguousReferenceCondition documents that a name or inventory path of
+	// the spec of the VSphereVM, e.g. of its datastore or of a network,
+	// matches several objects of the inventory, so that the VM cannot be
+	// cloned. The condition is true while a name is ambiguous, its message
+	// listing the inventory paths of the matched objects.
+	AmbiguousReferenceCondition clusterv1.ConditionType = "AmbiguousReference"
+
+	// MultipleObjectsFoundReason documents that a name or inventory path
+	// matches several objects of the inventory.
+	MultipleObjectsFoundReason = "MultipleObjectsFound"
+)
+
+// Conditions and Reasons related to the move of a cluster to another
+// management cluster with clusterctl move. Can currently be used by
+// VSphereCluster.
+const (
+	// MoveReadyCondition documents whether clusterctl move carries over all
+	// the objects and state of the cluster, e.g. when pivoting from a
+	// bootstrap cluster to the management cluster.
+	MoveReadyCondition clusterv1.ConditionType = "MoveReady"
+
+	// ObjectsLeftBehindReason (Severity=Warning) documents the objects of the
+	// cluster which clusterctl move would leave behind, as neither a moved
+	// object owns them nor their CRD is labelled to be moved.
+	ObjectsLeftBehindReason = "ObjectsLeftBehind"
+)
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	failureDomainBalancingWebhookPath = "/mutate-infrastructure-cluster-x-k8s-io-v1beta1-failure-domain-balancing"
+
+	// FailureDomainBalancingAnnotation is the annotation of a
+	// MachineDeployment choosing the policy its VSphereMachines are spread
+	// across the failure domains of the cluster with.
+	FailureDomainBalancingAnnotation = "infrastructure.cluster.x-k8s.io/failure-domain-balancing"
+)
+
+// FailureDomainBalancingPolicy is the policy the VSphereMachines of a
+// MachineDeployment are spread across failure domains with.
+type FailureDomainBalancingPolicy string
+
+const (
+	// RoundRobinBalancing places each new machine in the failure domain with
+	// the fewest machines of the MachineDeployment.
+	RoundRobinBalancing FailureDomainBalancingPolicy = "RoundRobin"
+
+	// CapacityWeightedBalancing places each new machine in the failure domain
+	// with the fewest machines of the MachineDeployment relative to the
+	// weight of its VSphereDeploymentZone.
+	CapacityWeightedBalancing FailureDomainBalancingPolicy = "CapacityWeighted"
+
+	// NoBalancing leaves the machines of the MachineDeployment without
+	// failure domain.
+	NoBalancing FailureDomainBalancingPolicy = "None"
+)
+
+// +kubebuilder:webhook:verbs=create,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-failure-domain-balancing,mutating=true,failurePolicy=ignore,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vspheremachines,versions=v1beta1,name=default.failuredomainbalancing.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// FailureDomainBalancingWebhook is an admission webhook that sets the failure
+// domain of the new VSphereMachines of the MachineDeployments whose machines
+// have none, so that they are spread across the failure domains of the
+// cluster instead of all landing in the same one. The Machine takes the
+// failure domain of its VSphereMachine.
+// +kubebuilder:object:generate=false
+type FailureDomainBalancingWebhook struct {
+	// Enabled reflects the FailureDomainBalancing feature gate. All the
+	// requests are allowed unchanged when it is false.
+	Enabled bool
+
+	client client.Reader
+	// apiReader lists the machines of the MachineDeployment without cache,
+	// so that the machines created in a row are all counted.
+	apiReader client.Reader
+	decoder   *admission.Decoder
+}
+
+var _ admission.Handler = &FailureDomainBalancingWebhook{}
+
+func (w *FailureDomainBalancingWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	w.apiReader = mgr.GetAPIReader()
+	mgr.GetWebhookServer().Register(failureDomainBalancingWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *FailureDomainBalancingWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle sets the failure domain of the VSphereMachine.
+func (w *FailureDomainBalancingWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if !w.Enabled {
+		return admission.Allowed("")
+	}
+	obj := &VSphereMachine{}
+	if err := w.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if obj.Namespace == "" {
+		obj.Namespace = req.Namespace
+	}
+
+	failureDomain, err := w.selectFailureDomain(ctx, obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if failureDomain == "" {
+		return admission.Allowed("")
+	}
+	obj.Spec.FailureDomain = &failureDomain
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// selectFailureDomain returns the failure domain of a new machine of a
+// MachineDeployment, or an empty string if the machine is not balanced.
+func (w *FailureDomainBalancingWebhook) selectFailureDomain(ctx context.Context, machine *VSphereMachine) (string, error) {
+	clusterName := machine.Labels[clusterv1.ClusterLabelName]
+	deploymentName := machine.Labels[clusterv1.MachineDeploymentLabelName]
+	machineSetName := machine.Labels[clusterv1.MachineSetLabelName]
+	if machine.Spec.FailureDomain != nil || clusterName == "" || deploymentName == "" || machineSetName == "" {
+		return "", nil
+	}
+
+	// The machines of MachineSets with a failure domain are placed by it.
+	machineSet := &clusterv1.MachineSet{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: machineSetName}, machineSet); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if machineSet.Spec.Template.Spec.FailureDomain != nil {
+		return "", nil
+	}
+	deployment := &clusterv1.MachineDeployment{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: deploymentName}, deployment); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	policy := FailureDomainBalancingPolicy(deployment.Annotations[FailureDomainBalancingAnnotation])
+	if policy == NoBalancing {
+		return "", nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: clusterName}, cluster); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if len(cluster.Status.FailureDomains) == 0 {
+		return "", nil
+	}
+
+	machines := &VSphereMachineList{}
+	if err := w.apiReader.List(ctx, machines, client.InNamespace(machine.Namespace), client.MatchingLabels{
+		clusterv1.ClusterLabelName:           clusterName,
+		clusterv1.MachineDeploymentLabelName: deploymentName,
+	}); err != nil {
+		return "", errors.Wrapf(err, "failed to list the VSphereMachines of MachineDeployment %s", deploymentName)
+	}
+	counts := map[string]int{}
+	for _, m := range machines.Items {
+		if m.Spec.FailureDomain != nil && m.DeletionTimestamp.IsZero() {
+			counts[*m.Spec.FailureDomain]++
+		}
+	}
+
+	weights := map[string]int32{}
+	for name := range cluster.Status.FailureDomains {
+		weights[name] = 1
+		if policy != CapacityWeightedBalancing {
+			continue
+		}
+		zone := &VSphereDeploymentZone{}
+		if err := w.client.Get(ctx, client.ObjectKey{Name: name}, zone); err != nil {
+			if err := client.IgnoreNotFound(err); err != nil {
+				return "", errors.Wrapf(err, "failed to get VSphereDeploymentZone %s", name)
+			}
+			continue
+		}
+		if zone.Spec.Weight != nil {
+			weights[name] = *zone.Spec.Weight
+		}
+	}
+	return pickFailureDomain(weights, counts), nil
+}
+
+// pickFailureDomain returns the failure domain with the fewest machines,
+// including the new one, relative to its weight, among the ones with a
+// positive weight. Failure domains with the same ratio are picked by name, so
+// that the selection is stable.
+func pickFailureDomain(weights map[string]int32, counts map[string]int) string {
+	names := make([]string, 0, len(weights))
+	for name, weight := range weights {
+		if weight > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// Compare (counts[a]+1)/weights[a] and (counts[b]+1)/weights[b]
+		// without rounding.
+		a, b := names[i], names[j]
+		ratioA, ratioB := int64(counts[a]+1)*int64(weights[b]), int64(counts[b]+1)*int64(weights[a])
+		if ratioA != ratioB {
+			return ratioA < ratioB
+		}
+		return a < b
+	})
+	return names[0]
+}
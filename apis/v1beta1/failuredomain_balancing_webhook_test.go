@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPickFailureDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int32
+		counts  map[string]int
+		want    string
+	}{
+		{
+			name:    "picks the failure domain with the fewest machines",
+			weights: map[string]int32{"zone-a": 1, "zone-b": 1, "zone-c": 1},
+			counts:  map[string]int{"zone-a": 2, "zone-b": 1, "zone-c": 2},
+			want:    "zone-b",
+		},
+		{
+			name:    "picks by name among failure domains with as many machines",
+			weights: map[string]int32{"zone-b": 1, "zone-a": 1},
+			counts:  map[string]int{"zone-a": 1, "zone-b": 1},
+			want:    "zone-a",
+		},
+		{
+			name:    "weighs the machines by the capacity of the failure domains",
+			weights: map[string]int32{"zone-a": 1, "zone-b": 3},
+			counts:  map[string]int{"zone-a": 1, "zone-b": 2},
+			want:    "zone-b",
+		},
+		{
+			name:    "fills the failure domains in proportion to their weight",
+			weights: map[string]int32{"zone-a": 1, "zone-b": 3},
+			counts:  map[string]int{"zone-a": 0, "zone-b": 3},
+			want:    "zone-a",
+		},
+		{
+			name:    "skips the failure domains without weight",
+			weights: map[string]int32{"zone-a": 0, "zone-b": 1},
+			counts:  map[string]int{"zone-b": 5},
+			want:    "zone-b",
+		},
+		{
+			name:    "no failure domain with a weight",
+			weights: map[string]int32{"zone-a": 0},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(pickFailureDomain(tc.weights, tc.counts)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestFailureDomainBalancingWebhook_selectFailureDomain(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = clusterv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	machineLabels := func(machineSet string) map[string]string {
+		return map[string]string{
+			clusterv1.ClusterLabelName:           "foo",
+			clusterv1.MachineDeploymentLabelName: "md",
+			clusterv1.MachineSetLabelName:        machineSet,
+		}
+	}
+	existingMachine := func(name, failureDomain string) *VSphereMachine {
+		return &VSphereMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: machineLabels("ms")},
+			Spec:       VSphereMachineSpec{FailureDomain: pointer.String(failureDomain)},
+		}
+	}
+	objects := func(annotations map[string]string) []client.Object {
+		return []client.Object{
+			&clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+				Status: clusterv1.ClusterStatus{FailureDomains: clusterv1.FailureDomains{
+					"zone-a": clusterv1.FailureDomainSpec{},
+					"zone-b": clusterv1.FailureDomainSpec{},
+				}},
+			},
+			&clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md", Annotations: annotations}},
+			&clusterv1.MachineSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ms"}},
+			&clusterv1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ms-zoned"},
+				Spec:       clusterv1.MachineSetSpec{Template: clusterv1.MachineTemplateSpec{Spec: clusterv1.MachineSpec{FailureDomain: pointer.String("zone-a")}}},
+			},
+			&VSphereDeploymentZone{ObjectMeta: metav1.ObjectMeta{Name: "zone-a"}, Spec: VSphereDeploymentZoneSpec{Weight: pointer.Int32(4)}},
+			&VSphereDeploymentZone{ObjectMeta: metav1.ObjectMeta{Name: "zone-b"}},
+			existingMachine("machine-1", "zone-a"),
+			existingMachine("machine-2", "zone-a"),
+			existingMachine("machine-3", "zone-b"),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		machine     *VSphereMachine
+		want        string
+	}{
+		{
+			name:    "machine without MachineSet",
+			machine: &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: map[string]string{clusterv1.ClusterLabelName: "foo"}}},
+		},
+		{
+			name: "machine with a failure domain",
+			machine: &VSphereMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: machineLabels("ms")},
+				Spec:       VSphereMachineSpec{FailureDomain: pointer.String("zone-a")},
+			},
+		},
+		{
+			name:    "MachineSet with a failure domain",
+			machine: &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: machineLabels("ms-zoned")}},
+		},
+		{
+			name:        "balancing disabled for the MachineDeployment",
+			annotations: map[string]string{FailureDomainBalancingAnnotation: string(NoBalancing)},
+			machine:     &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: machineLabels("ms")}},
+		},
+		{
+			name:    "round robin by default",
+			machine: &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: machineLabels("ms")}},
+			want:    "zone-b",
+		},
+		{
+			name:        "capacity weighted",
+			annotations: map[string]string{FailureDomainBalancingAnnotation: string(CapacityWeightedBalancing)},
+			machine:     &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Labels: machineLabels("ms")}},
+			want:        "zone-a",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects(tc.annotations)...).Build()
+			w := &FailureDomainBalancingWebhook{Enabled: true, client: c, apiReader: c}
+			got, err := w.selectFailureDomain(context.Background(), tc.machine)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tc.want))
+		})
+	}
+}
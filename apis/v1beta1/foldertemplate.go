@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// FolderTemplateData is the data the folder of a VSphereVM, e.g.
+// "{{ .ClusterName }}/{{ .MachineDeployment }}", is rendered with when its VM
+// is cloned.
+// +kubebuilder:object:generate=false
+type FolderTemplateData struct {
+	// Namespace is the namespace of the VSphereVM.
+	Namespace string
+
+	// ClusterName is the name of the cluster of the VSphereVM.
+	ClusterName string
+
+	// MachineDeployment is the name of the MachineDeployment of the machine
+	// of the VSphereVM, if any.
+	MachineDeployment string
+
+	// ControlPlane is true for the VSphereVMs of control plane machines.
+	ControlPlane bool
+}
+
+// IsFolderTemplate returns true if a folder is a template rendered with the
+// FolderTemplateData of the VSphereVMs.
+func IsFolderTemplate(folder string) bool {
+	return strings.Contains(folder, "{{")
+}
+
+// RenderFolder returns the folder of the VSphereVM, rendering it with the
+// data of its labels if it is a template. The empty elements of the rendered
+// path, e.g. the MachineDeployment of a control plane machine, are removed.
+func (r *VSphereVM) RenderFolder() (string, error) {
+	_, isControlPlane := r.Labels[clusterv1.MachineControlPlaneLabelName]
+	return renderFolderTemplate(r.Spec.Folder, &FolderTemplateData{
+		Namespace:         r.Namespace,
+		ClusterName:       r.Labels[clusterv1.ClusterLabelName],
+		MachineDeployment: r.Labels[clusterv1.MachineDeploymentLabelName],
+		ControlPlane:      isControlPlane,
+	})
+}
+
+func renderFolderTemplate(folder string, data *FolderTemplateData) (string, error) {
+	if !IsFolderTemplate(folder) {
+		return folder, nil
+	}
+	tpl, err := template.New("").Option("missingkey=error").Parse(folder)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid folder template %q", folder)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "invalid folder template %q", folder)
+	}
+
+	var elements []string
+	for _, element := range strings.Split(b.String(), "/") {
+		if element != "" {
+			elements = append(elements, element)
+		}
+	}
+	rendered := strings.Join(elements, "/")
+	if strings.HasPrefix(folder, "/") {
+		rendered = "/" + rendered
+	}
+	return rendered, nil
+}
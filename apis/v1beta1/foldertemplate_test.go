@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestVSphereVM_RenderFolder(t *testing.T) {
+	worker := map[string]string{
+		clusterv1.ClusterLabelName:           "prod",
+		clusterv1.MachineDeploymentLabelName: "md-0",
+	}
+	controlPlane := map[string]string{
+		clusterv1.ClusterLabelName:             "prod",
+		clusterv1.MachineControlPlaneLabelName: "",
+	}
+
+	tests := []struct {
+		name    string
+		folder  string
+		labels  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "folders without templates are kept",
+			folder: "/DC0/vm/{static}",
+			labels: worker,
+			want:   "/DC0/vm/{static}",
+		},
+		{
+			name:   "templates are rendered",
+			folder: "{{ .Namespace }}/{{ .ClusterName }}/{{ .MachineDeployment }}",
+			labels: worker,
+			want:   "default/prod/md-0",
+		},
+		{
+			name:   "empty elements are removed",
+			folder: "/DC0/vm/{{ .ClusterName }}/{{ .MachineDeployment }}/",
+			labels: controlPlane,
+			want:   "/DC0/vm/prod",
+		},
+		{
+			name:   "control plane machines",
+			folder: `{{ .ClusterName }}/{{ if .ControlPlane }}control-plane{{ else }}{{ .MachineDeployment }}{{ end }}`,
+			labels: controlPlane,
+			want:   "prod/control-plane",
+		},
+		{
+			name:    "unknown field",
+			folder:  "{{ .Rack }}",
+			labels:  worker,
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			folder:  "{{ .ClusterName ",
+			labels:  worker,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			vm := &VSphereVM{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Labels: tc.labels},
+				Spec:       VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Folder: tc.folder}},
+			}
+			folder, err := vm.RenderFolder()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(folder).To(Equal(tc.want))
+		})
+	}
+}
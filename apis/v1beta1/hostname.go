@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// HostNameTemplateData is the data the HostNameTemplate of a VSphereVM is
+// rendered with.
+// +kubebuilder:object:generate=false
+type HostNameTemplateData struct {
+	// Name is the name of the VSphereVM, which is the name of its machine.
+	Name string
+
+	// Namespace is the namespace of the VSphereVM.
+	Namespace string
+
+	// ClusterName is the name of the cluster of the VSphereVM.
+	ClusterName string
+
+	// MachineDeployment is the name of the MachineDeployment of the machine
+	// of the VSphereVM, if any.
+	MachineDeployment string
+
+	// ControlPlane is true for the VSphereVMs of control plane machines.
+	ControlPlane bool
+}
+
+// HostName returns the hostname of the guest of the VSphereVM, which is also
+// the name of its node: the rendered HostNameTemplate, or the name of the
+// VSphereVM, followed by the HostNameDomain, if any. It fails if the hostname
+// is not a valid node name.
+func (r *VSphereVM) HostName() (string, error) {
+	return renderHostName(&r.Spec.VirtualMachineCloneSpec, r.hostNameTemplateData())
+}
+
+// ShortHostName returns the hostname of the guest of the VSphereVM without
+// its domain.
+func (r *VSphereVM) ShortHostName() (string, error) {
+	hostName, err := r.HostName()
+	if err != nil {
+		return "", err
+	}
+	if r.Spec.HostNameDomain != "" {
+		hostName = strings.TrimSuffix(hostName, "."+r.Spec.HostNameDomain)
+	}
+	return hostName, nil
+}
+
+func (r *VSphereVM) hostNameTemplateData() *HostNameTemplateData {
+	_, isControlPlane := r.Labels[clusterv1.MachineControlPlaneLabelName]
+	return &HostNameTemplateData{
+		Name:              r.Name,
+		Namespace:         r.Namespace,
+		ClusterName:       r.Labels[clusterv1.ClusterLabelName],
+		MachineDeployment: r.Labels[clusterv1.MachineDeploymentLabelName],
+		ControlPlane:      isControlPlane,
+	}
+}
+
+func renderHostName(spec *VirtualMachineCloneSpec, data *HostNameTemplateData) (string, error) {
+	hostName := data.Name
+	if spec.HostNameTemplate != "" {
+		tpl, err := template.New("").Option("missingkey=error").Parse(spec.HostNameTemplate)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid hostname template %q", spec.HostNameTemplate)
+		}
+		var b strings.Builder
+		if err := tpl.Execute(&b, data); err != nil {
+			return "", errors.Wrapf(err, "invalid hostname template %q", spec.HostNameTemplate)
+		}
+		hostName = b.String()
+	}
+	if spec.HostNameDomain != "" {
+		hostName += "." + spec.HostNameDomain
+	}
+	if errs := validation.IsDNS1123Subdomain(hostName); len(errs) > 0 {
+		return "", errors.Errorf("hostname %q is not a valid node name: %s", hostName, strings.Join(errs, ", "))
+	}
+	return hostName, nil
+}
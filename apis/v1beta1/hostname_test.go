@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestVSphereVM_HostName(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		domain    string
+		want      string
+		wantShort string
+		wantErr   bool
+	}{
+		{
+			name:      "defaults to the name of the VSphereVM",
+			want:      "prod-md-0-xk2lp",
+			wantShort: "prod-md-0-xk2lp",
+		},
+		{
+			name:      "appends the domain",
+			domain:    "k8s.example.com",
+			want:      "prod-md-0-xk2lp.k8s.example.com",
+			wantShort: "prod-md-0-xk2lp",
+		},
+		{
+			name:      "renders the template",
+			template:  "{{ .MachineDeployment }}-{{ if .ControlPlane }}cp{{ else }}worker{{ end }}-{{ .Name }}",
+			want:      "md-0-worker-prod-md-0-xk2lp",
+			wantShort: "md-0-worker-prod-md-0-xk2lp",
+		},
+		{
+			name:      "renders the template with a domain",
+			template:  "{{ .Name }}.{{ .ClusterName }}",
+			domain:    "example.com",
+			want:      "prod-md-0-xk2lp.prod.example.com",
+			wantShort: "prod-md-0-xk2lp.prod",
+		},
+		{
+			name:     "fails with an invalid node name",
+			template: "{{ .Namespace }}_{{ .Name }}",
+			wantErr:  true,
+		},
+		{
+			name:     "fails with an unknown field",
+			template: "{{ .Zone }}",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			vm := &VSphereVM{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "prod-md-0-xk2lp",
+					Namespace: "default",
+					Labels: map[string]string{
+						clusterv1.ClusterLabelName:           "prod",
+						clusterv1.MachineDeploymentLabelName: "md-0",
+					},
+				},
+				Spec: VSphereVMSpec{
+					VirtualMachineCloneSpec: VirtualMachineCloneSpec{HostNameTemplate: tc.template, HostNameDomain: tc.domain},
+				},
+			}
+			hostName, err := vm.HostName()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(hostName).To(Equal(tc.want))
+
+			shortHostName, err := vm.ShortHostName()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(shortHostName).To(Equal(tc.wantShort))
+		})
+	}
+}
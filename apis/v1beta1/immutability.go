@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ImmutabilityOverrideAnnotation allows the overridable fields of an object,
+// which are immutable by default, to be updated when it is set to "true" on
+// the updated object. It is meant for exceptional changes of the
+// environment, e.g. the thumbprint of a vCenter whose certificate was
+// renewed, and does not change the existing VMs. The annotation is not
+// removed by the webhooks, so the overridable fields remain mutable until it
+// is removed from the object.
+const ImmutabilityOverrideAnnotation = "infrastructure.cluster.x-k8s.io/allow-immutable-updates"
+
+// mutability is the update policy of a field.
+type mutability int
+
+const (
+	// mutable fields can always be updated.
+	mutable mutability = iota
+
+	// mutableWhenUnset fields can only be updated as long as they are unset.
+	mutableWhenUnset
+
+	// overridable fields can only be updated along with the
+	// ImmutabilityOverrideAnnotation.
+	overridable
+)
+
+// immutabilityPolicy is the update policy of the spec of a type.
+type immutabilityPolicy struct {
+	// fields are the fields of the spec that are not immutable, by their
+	// JSON path relative to the spec, e.g. "network.devices". All the other
+	// fields are immutable.
+	fields map[string]mutability
+
+	// message is the error detail reported for the immutable fields that
+	// were updated.
+	message string
+}
+
+// The immutability policies of the types are all defined here so that they
+// stay consistent, and are enforced by the validating webhooks through
+// validateImmutability.
+//
+// Updates of the spec of a machine template are never rolled out to the
+// existing machines, even when the ImmutabilityOverrideAnnotation is set:
+// machines are only replaced when their owner references a new template.
+// Its policy applies to spec.template.spec, the metadata of the template
+// being mutable.
+var (
+	vsphereMachineImmutability = immutabilityPolicy{
+		fields: map[string]mutability{
+			"providerID":       mutable,
+			"network.devices":  mutable,
+			"numCPUs":          mutable,
+			"memoryMiB":        mutable,
+			"tagIDs":           mutable,
+			"customAttributes": mutable,
+			"recoveryPolicy":   mutable,
+			"deletionPolicy":   mutable,
+			"driftDetection":   mutable,
+			"timeSync":         mutable,
+			"server":           overridable,
+			"thumbprint":       overridable,
+		},
+		message: "cannot be modified",
+	}
+
+	vsphereVMImmutability = immutabilityPolicy{
+		fields: map[string]mutability{
+			"biosUUID":         mutable,
+			"bootstrapRef":     mutable,
+			"network.devices":  mutable,
+			"numCPUs":          mutable,
+			"memoryMiB":        mutable,
+			"tagIDs":           mutable,
+			"customAttributes": mutable,
+			"recoveryPolicy":   mutable,
+			"deletionPolicy":   mutable,
+			"driftDetection":   mutable,
+			"timeSync":         mutable,
+			"os":               mutableWhenUnset,
+			"server":           overridable,
+			"thumbprint":       overridable,
+		},
+		message: "cannot be modified",
+	}
+
+	vsphereMachineTemplateImmutability = immutabilityPolicy{
+		fields: map[string]mutability{
+			"server":     overridable,
+			"thumbprint": overridable,
+		},
+		message: "VSphereMachineTemplate spec.template.spec field is immutable. Please create a new resource instead.",
+	}
+
+	vsphereClusterTemplateImmutability = immutabilityPolicy{
+		message: "VSphereClusterTemplate spec is immutable",
+	}
+
+	vsphereFailureDomainImmutability = immutabilityPolicy{
+		message: "VSphereFailureDomainSpec is immutable",
+	}
+)
+
+// hasImmutabilityOverride returns true if the object has the
+// ImmutabilityOverrideAnnotation.
+func hasImmutabilityOverride(obj metav1.Object) bool {
+	return obj.GetAnnotations()[ImmutabilityOverrideAnnotation] == "true"
+}
+
+// validateImmutability returns an error for each field of the spec of the
+// updated object that may not be updated according to the policy. The
+// overridable fields may be updated if the updated object has the
+// ImmutabilityOverrideAnnotation.
+func validateImmutability(policy immutabilityPolicy, newObj metav1.Object, oldSpec, newSpec interface{}, fldPath *field.Path) field.ErrorList {
+	oldMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldSpec)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, errors.Wrap(err, "failed to convert old spec to unstructured object"))}
+	}
+	newMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newSpec)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, errors.Wrap(err, "failed to convert new spec to unstructured object"))}
+	}
+
+	var allErrs field.ErrorList
+	override := hasImmutabilityOverride(newObj)
+	paths := make([]string, 0, len(policy.fields))
+	for path := range policy.fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fields := strings.Split(path, ".")
+		oldVal, oldFound, _ := unstructured.NestedFieldNoCopy(oldMap, fields...)
+		newVal, _, _ := unstructured.NestedFieldNoCopy(newMap, fields...)
+
+		switch policy.fields[path] {
+		case mutableWhenUnset:
+			if oldFound {
+				continue
+			}
+		case overridable:
+			if !override && !reflect.DeepEqual(oldVal, newVal) {
+				allErrs = append(allErrs, field.Forbidden(fieldPath(fldPath, fields),
+					fmt.Sprintf("cannot be modified unless the %s annotation is set to \"true\"", ImmutabilityOverrideAnnotation)))
+			}
+		}
+		unstructured.RemoveNestedField(oldMap, fields...)
+		unstructured.RemoveNestedField(newMap, fields...)
+	}
+
+	allErrs = append(allErrs, diffImmutableFields(oldMap, newMap, fldPath, policy.message)...)
+	return allErrs
+}
+
+// diffImmutableFields returns an error for each field that differs between
+// the old and the new unstructured objects, descending into nested objects.
+func diffImmutableFields(oldMap, newMap map[string]interface{}, fldPath *field.Path, message string) field.ErrorList {
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for key := range oldMap {
+		keys = append(keys, key)
+	}
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var allErrs field.ErrorList
+	for _, key := range keys {
+		oldVal, newVal := oldMap[key], newMap[key]
+		if reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		oldNested, oldOK := oldVal.(map[string]interface{})
+		newNested, newOK := newVal.(map[string]interface{})
+		if oldOK && newOK {
+			allErrs = append(allErrs, diffImmutableFields(oldNested, newNested, fldPath.Child(key), message)...)
+			continue
+		}
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child(key), message))
+	}
+	return allErrs
+}
+
+func fieldPath(fldPath *field.Path, fields []string) *field.Path {
+	for _, f := range fields {
+		fldPath = fldPath.Child(f)
+	}
+	return fldPath
+}
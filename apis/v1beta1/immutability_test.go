@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateImmutability(t *testing.T) {
+	policy := immutabilityPolicy{
+		fields: map[string]mutability{
+			"biosUUID":        mutable,
+			"network.devices": mutable,
+			"os":              mutableWhenUnset,
+			"thumbprint":      overridable,
+		},
+		message: "cannot be modified",
+	}
+	override := map[string]string{ImmutabilityOverrideAnnotation: "true"}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		oldSpec     VSphereVMSpec
+		newSpec     VSphereVMSpec
+		wantFields  []string
+	}{
+		{
+			name:    "mutable fields",
+			oldSpec: VSphereVMSpec{},
+			newSpec: VSphereVMSpec{
+				BiosUUID:                "uuid",
+				VirtualMachineCloneSpec: VirtualMachineCloneSpec{Network: NetworkSpec{Devices: []NetworkDeviceSpec{{NetworkName: "nw"}}}},
+			},
+		},
+		{
+			name:    "unset field that is mutable when unset",
+			oldSpec: VSphereVMSpec{},
+			newSpec: VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{OS: Linux}},
+		},
+		{
+			name:       "set field that is mutable when unset",
+			oldSpec:    VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{OS: Windows}},
+			newSpec:    VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{OS: Linux}},
+			wantFields: []string{"spec.os"},
+		},
+		{
+			name:       "overridable field without the annotation",
+			oldSpec:    VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Thumbprint: "AA"}},
+			newSpec:    VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Thumbprint: "BB"}},
+			wantFields: []string{"spec.thumbprint"},
+		},
+		{
+			name:        "overridable field with the annotation",
+			annotations: override,
+			oldSpec:     VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Thumbprint: "AA"}},
+			newSpec:     VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Thumbprint: "BB"}},
+		},
+		{
+			name:        "immutable fields with the annotation",
+			annotations: override,
+			oldSpec:     VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Server: "foo.com", Network: NetworkSpec{PreferredAPIServerCIDR: "10.0.0.0/8"}}},
+			newSpec:     VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{Server: "bar.com", Network: NetworkSpec{PreferredAPIServerCIDR: "10.0.0.0/16"}}},
+			wantFields:  []string{"spec.network.preferredAPIServerCidr", "spec.server"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			newObj := &VSphereVM{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			oldSpec, newSpec := tc.oldSpec, tc.newSpec
+			errs := validateImmutability(policy, newObj, &oldSpec, &newSpec, field.NewPath("spec"))
+			fields := make([]string, 0, len(errs))
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(ConsistOf(tc.wantFields))
+		})
+	}
+}
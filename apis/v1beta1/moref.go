@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MoRefPrefix is the prefix of the vSphere objects given by managed object ID
+// rather than by name or inventory path, e.g. "moref:datastore-42". The type
+// of the object may be given with its ID, e.g.
+// "moref:DistributedVirtualPortgroup:dvportgroup-42", and is otherwise the
+// type of the objects of the field.
+const MoRefPrefix = "moref:"
+
+var moRefPattern = regexp.MustCompile(`^(?:([A-Za-z]+):)?([A-Za-z0-9._-]+)$`)
+
+// IsMoRef returns true if a vSphere object is given by managed object ID.
+func IsMoRef(name string) bool {
+	return strings.HasPrefix(name, MoRefPrefix)
+}
+
+// ParseMoRef returns the type, if given, and the managed object ID of a
+// vSphere object given by managed object ID.
+func ParseMoRef(name string) (typ, id string, err error) {
+	if !IsMoRef(name) {
+		return "", "", errors.Errorf("%q is not prefixed with %q", name, MoRefPrefix)
+	}
+	m := moRefPattern.FindStringSubmatch(strings.TrimPrefix(name, MoRefPrefix))
+	if m == nil {
+		return "", "", errors.Errorf("%q is not a managed object ID, e.g. %sdatastore-42", name, MoRefPrefix)
+	}
+	return m[1], m[2], nil
+}
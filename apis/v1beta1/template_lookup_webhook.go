@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const templateLookupWebhookPath = "/warn-infrastructure-cluster-x-k8s-io-v1beta1-template-lookup"
+
+// IsTemplateInstanceUUID returns true if the given template reference is an
+// instance UUID rather than a name or inventory path.
+func IsTemplateInstanceUUID(template string) bool {
+	_, err := uuid.Parse(template)
+	return err == nil
+}
+
+// TemplateLookupWarning returns a deprecation warning if the given template
+// reference is looked up by name or inventory path, or an empty string if it
+// is an immutable instance UUID.
+func TemplateLookupWarning(fieldPath, template string) string {
+	if template == "" || IsTemplateInstanceUUID(template) {
+		return ""
+	}
+	return fmt.Sprintf("%s: looking up templates by name or inventory path (%q) is deprecated, "+
+		"as renaming or moving the template changes what new machines are cloned from; "+
+		"use the template's instance UUID instead", fieldPath, template)
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/warn-infrastructure-cluster-x-k8s-io-v1beta1-template-lookup,mutating=false,failurePolicy=ignore,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vspheremachines;vspheremachinetemplates;vspherevms,versions=v1beta1,name=warning.template.infrastructure.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// TemplateLookupWebhook is an admission webhook that never rejects a request,
+// but returns a warning when an object references its template by name
+// instead of by instance UUID.
+// +kubebuilder:object:generate=false
+type TemplateLookupWebhook struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &TemplateLookupWebhook{}
+
+func (w *TemplateLookupWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(templateLookupWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *TemplateLookupWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle returns an allowed response, with a deprecation warning if the
+// object uses a name based template lookup.
+func (w *TemplateLookupWebhook) Handle(_ context.Context, req admission.Request) admission.Response {
+	var fieldPath, template string
+	switch req.Kind.Kind {
+	case "VSphereMachine":
+		obj := &VSphereMachine{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		fieldPath, template = "spec.template", obj.Spec.Template
+	case "VSphereMachineTemplate":
+		obj := &VSphereMachineTemplate{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		fieldPath, template = "spec.template.spec.template", obj.Spec.Template.Spec.Template
+	case "VSphereVM":
+		obj := &VSphereVM{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		fieldPath, template = "spec.template", obj.Spec.Template
+	}
+
+	resp := admission.Allowed("")
+	if warning := TemplateLookupWarning(fieldPath, template); warning != "" {
+		resp = resp.WithWarnings(warning)
+	}
+	return resp
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTemplateLookupWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		wantWarning bool
+	}{
+		{
+			name:        "empty template",
+			template:    "",
+			wantWarning: false,
+		},
+		{
+			name:        "template referenced by instance uuid",
+			template:    "42107e4c-2d1d-4ac1-a5d7-2c8e2b0fd2b0",
+			wantWarning: false,
+		},
+		{
+			name:        "template referenced by name",
+			template:    "ubuntu-2004-kube-v1.24.4",
+			wantWarning: true,
+		},
+		{
+			name:        "template referenced by inventory path",
+			template:    "/dc0/vm/templates/ubuntu-2004-kube-v1.24.4",
+			wantWarning: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			warning := TemplateLookupWarning("spec.template", tc.template)
+			if tc.wantWarning {
+				g.Expect(warning).To(ContainSubstring("spec.template"))
+			} else {
+				g.Expect(warning).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -18,9 +18,11 @@ limitations under the License.
 package v1beta1
 
 import (
-	"fmt"
+	"net"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -53,6 +55,82 @@ const (
 	LinkedClone CloneMode = "linkedClone"
 )
 
+// LinkedCloneFallbackPolicy is the action taken when a linked clone cannot be
+// created from a suitable snapshot.
+type LinkedCloneFallbackPolicy string
+
+const (
+	// LinkedCloneFallbackFullClone falls back to a full clone of the source
+	// VM/template.
+	LinkedCloneFallbackFullClone LinkedCloneFallbackPolicy = "fullClone"
+
+	// LinkedCloneFallbackFail fails the clone operation, which is retried
+	// until a suitable snapshot is available.
+	LinkedCloneFallbackFail LinkedCloneFallbackPolicy = "fail"
+)
+
+// VMRecoveryPolicy is the action taken when the VM of a machine is found
+// suspended, or orphaned, inaccessible or invalid in vCenter.
+type VMRecoveryPolicy string
+
+const (
+	// VMRecoveryNone only reports the state of the VM in the VMProvisioned
+	// condition, and leaves the VM as it is.
+	VMRecoveryNone VMRecoveryPolicy = "none"
+
+	// VMRecoveryPowerOn powers on suspended VMs. The other VMs are only
+	// reported.
+	VMRecoveryPowerOn VMRecoveryPolicy = "powerOn"
+
+	// VMRecoveryReregister powers on suspended VMs, and unregisters and
+	// registers again the orphaned, inaccessible and invalid VMs from their
+	// configuration file, on the same host, folder and resource pool.
+	VMRecoveryReregister VMRecoveryPolicy = "reregister"
+
+	// VMRecoveryRecreate powers on suspended VMs, and unregisters the
+	// orphaned, inaccessible and invalid VMs and clones them again with the
+	// same BIOS UUID. The VMs deleted from vCenter are cloned again as well.
+	// Only the VMs of machines whose node was not bootstrapped yet are
+	// recreated, as the bootstrap data cannot be applied twice.
+	VMRecoveryRecreate VMRecoveryPolicy = "recreate"
+)
+
+// VMDeletionPolicy is what becomes of the VM of a VSphereVM when the
+// VSphereVM is deleted.
+type VMDeletionPolicy string
+
+const (
+	// VMDeletionDelete powers off and destroys the VM.
+	VMDeletionDelete VMDeletionPolicy = "delete"
+
+	// VMDeletionRetain leaves the VM in vCenter as it is, including its power
+	// state, e.g. to inspect the memory of a running VM.
+	VMDeletionRetain VMDeletionPolicy = "retain"
+
+	// VMDeletionPowerOffOnly powers off the VM and leaves it in vCenter.
+	VMDeletionPowerOffOnly VMDeletionPolicy = "powerOffOnly"
+)
+
+// DefaultLinkedCloneSnapshotName is the name of the snapshot created on the
+// source VM/template of a linked clone when none is specified.
+const DefaultLinkedCloneSnapshotName = "capv-linked-clone"
+
+// BootstrapDataDelivery is the way the bootstrap data and metadata are passed
+// to the guest.
+type BootstrapDataDelivery string
+
+const (
+	// BootstrapDataDeliveryGuestInfo sets the bootstrap data and metadata as
+	// guestinfo keys of the extraConfig of the VM, as read by the VMware
+	// datasource of cloud-init and by Ignition.
+	BootstrapDataDeliveryGuestInfo BootstrapDataDelivery = "guestInfo"
+
+	// BootstrapDataDeliveryVAppProperties sets the bootstrap data and metadata
+	// as vApp properties of the OVF environment of the VM, as read by the OVF
+	// datasource of cloud-init.
+	BootstrapDataDeliveryVAppProperties BootstrapDataDelivery = "vAppProperties"
+)
+
 // OS is the type of Operating System the virtual machine uses.
 type OS string
 
@@ -64,13 +142,45 @@ const (
 	Windows OS = "Windows"
 )
 
+// Architecture is the CPU architecture of a virtual machine.
+type Architecture string
+
+const (
+	// ArchitectureAmd64 is the x86-64 architecture.
+	ArchitectureAmd64 Architecture = "amd64"
+
+	// ArchitectureArm64 is the 64-bit ARM architecture.
+	ArchitectureArm64 Architecture = "arm64"
+)
+
+// AdditionalDiskSpec configures an additional disk of a virtual machine.
+type AdditionalDiskSpec struct {
+	// SizeGiB is the size of the disk, in GiB.
+	// Defaults to the size of the disk in the template from which the virtual
+	// machine is cloned.
+	// +optional
+	SizeGiB int32 `json:"sizeGiB,omitempty"`
+	// RetainOnDelete detaches the disk from the virtual machine before it is
+	// destroyed, leaving the disk file in the folder of the virtual machine on
+	// its datastore, so that its data survives the deletion of the machine.
+	// +optional
+	RetainOnDelete bool `json:"retainOnDelete,omitempty"`
+}
+
 // VirtualMachineCloneSpec is information used to clone a virtual machine.
 type VirtualMachineCloneSpec struct {
 	// Template is the name or inventory path of the template used to clone
-	// the virtual machine.
+	// the virtual machine, its instance UUID, or its managed object ID, e.g.
+	// "moref:vm-42".
 	// +kubebuilder:validation:MinLength=1
 	Template string `json:"template"`
 
+	// TemplateSource is the vCenter the template is cloned from, when it is
+	// not the vCenter on which the virtual machine is created.
+	// Cross-vCenter clones are always full clones.
+	// +optional
+	TemplateSource *TemplateSource `json:"templateSource,omitempty"`
+
 	// CloneMode specifies the type of clone operation.
 	// The LinkedClone mode is only support for templates that have at least
 	// one snapshot. If the template has no snapshots, then CloneMode defaults
@@ -88,6 +198,12 @@ type VirtualMachineCloneSpec struct {
 	// +optional
 	Snapshot string `json:"snapshot,omitempty"`
 
+	// LinkedClone configures the lifecycle of the snapshot from which linked
+	// clones are created.
+	// This field is ignored if LinkedClone is not enabled.
+	// +optional
+	LinkedClone *LinkedCloneSpec `json:"linkedClone,omitempty"`
+
 	// Server is the IP address or FQDN of the vSphere server on which
 	// the virtual machine is created/located.
 	// +optional
@@ -107,25 +223,46 @@ type VirtualMachineCloneSpec struct {
 	Datacenter string `json:"datacenter,omitempty"`
 
 	// Folder is the name or inventory path of the folder in which the
-	// virtual machine is created/located.
+	// virtual machine is created/located. It may be a template rendered with
+	// the FolderTemplateData of the VSphereVM when the VM is cloned, e.g.
+	// "{{ .ClusterName }}/{{ .MachineDeployment }}", in which case the missing
+	// folders of its path are created. The relative paths of templates are
+	// relative to the VM folder of the datacenter. It may also be the managed
+	// object ID of the folder, e.g. "moref:group-v42".
 	// +optional
 	Folder string `json:"folder,omitempty"`
 
 	// Datastore is the name or inventory path of the datastore in which the
-	// virtual machine is created/located.
+	// virtual machine is created/located, or its managed object ID, e.g.
+	// "moref:datastore-42".
 	// +optional
 	Datastore string `json:"datastore,omitempty"`
 
+	// DatastoreSelector selects the datastore in which the virtual machine is
+	// created among candidate datastores, when it is cloned, based on their
+	// free space. It cannot be set together with Datastore, and is ignored
+	// when the failure domain of the machine sets a datastore.
+	// +optional
+	DatastoreSelector *DatastoreSelector `json:"datastoreSelector,omitempty"`
+
 	// StoragePolicyName of the storage policy to use with this
 	// Virtual Machine
 	// +optional
 	StoragePolicyName string `json:"storagePolicyName,omitempty"`
 
 	// ResourcePool is the name or inventory path of the resource pool in which
-	// the virtual machine is created/located.
+	// the virtual machine is created/located, or its managed object ID, e.g.
+	// "moref:resgroup-42".
 	// +optional
 	ResourcePool string `json:"resourcePool,omitempty"`
 
+	// ComputeSelector selects the compute cluster in whose root resource pool
+	// the virtual machine is created, when it is cloned. It cannot be set
+	// together with ResourcePool, and is ignored when the failure domain of
+	// the machine sets a resource pool.
+	// +optional
+	ComputeSelector *ComputeSelector `json:"computeSelector,omitempty"`
+
 	// Network is the network configuration for this machine's VM.
 	Network NetworkSpec `json:"network"`
 
@@ -155,14 +292,28 @@ type VirtualMachineCloneSpec struct {
 	// virtual machine is cloned.
 	// +optional
 	AdditionalDisksGiB []int32 `json:"additionalDisksGiB,omitempty"`
-	// CustomVMXKeys is a dictionary of advanced VMX options that can be set on VM
-	// Defaults to empty map
+	// AdditionalDisks configures the additional disks of the virtual machine,
+	// in the order of the additional disks of the template from which the
+	// virtual machine is cloned. Cannot be set together with AdditionalDisksGiB.
+	// +optional
+	AdditionalDisks []AdditionalDiskSpec `json:"additionalDisks,omitempty"`
+	// CustomVMXKeys is a dictionary of advanced VMX options set in the
+	// extraConfig of the virtual machine when it is cloned, e.g. the queue
+	// depth of its PVSCSI controllers. The keys are case-insensitive, and
+	// cannot be the guestinfo keys of the bootstrap data, metadata and guest
+	// agent, the keys prefixed with capv., or the keys set through the fields
+	// of the spec, e.g. vhv.enable or tools.syncTime.
 	// +optional
 	CustomVMXKeys map[string]string `json:"customVMXKeys,omitempty"`
 	// TagIDs is an optional set of tags to add to an instance. Specified tagIDs
 	// must use URN-notation instead of display names.
 	// +optional
 	TagIDs []string `json:"tagIDs,omitempty"`
+	// CustomAttributes are the values of the custom attributes of the virtual
+	// machine, keyed by the name of their definition, which must already
+	// exist in vCenter for virtual machines or for all types.
+	// +optional
+	CustomAttributes map[string]string `json:"customAttributes,omitempty"`
 	// PciDevices is the list of pci devices used by the virtual machine.
 	// +optional
 	PciDevices []PCIDeviceSpec `json:"pciDevices,omitempty"`
@@ -176,6 +327,430 @@ type VirtualMachineCloneSpec struct {
 	// Check the compatibility with the ESXi version before setting the value.
 	// +optional
 	HardwareVersion string `json:"hardwareVersion,omitempty"`
+	// CustomizationSpec is the guest OS customization applied to the virtual
+	// machine while it is cloned, e.g. to join a Windows guest to a domain.
+	// +optional
+	CustomizationSpec *CustomizationSpec `json:"customizationSpec,omitempty"`
+	// HostNameTemplate is the Go template the hostname of the guest, and so
+	// the name of its node, is rendered with, with the fields of
+	// HostNameTemplateData, e.g. "{{ .ClusterName }}-{{ .Name }}". Defaults
+	// to the name of the VSphereVM, which is the name of its machine.
+	// +optional
+	HostNameTemplate string `json:"hostNameTemplate,omitempty"`
+	// HostNameDomain is the domain appended to the hostname of the guest,
+	// which is then a FQDN, e.g. machine-0.k8s.example.com.
+	// +optional
+	HostNameDomain string `json:"hostNameDomain,omitempty"`
+	// BootstrapDataDelivery specifies how the bootstrap data and metadata are
+	// passed to the guest. vAppProperties is meant for images whose cloud-init
+	// datasource is OVF, and does not support Ignition.
+	// Defaults to guestInfo.
+	// +kubebuilder:validation:Enum=guestInfo;vAppProperties
+	// +optional
+	BootstrapDataDelivery BootstrapDataDelivery `json:"bootstrapDataDelivery,omitempty"`
+	// CDROMs are the ISO images inserted in CD-ROM drives of the virtual
+	// machine when it is cloned, e.g. to install drivers in airgapped
+	// environments. The existing drives of the template are used first, then
+	// drives are added to its IDE controllers.
+	// +optional
+	CDROMs []CDROMSpec `json:"cdroms,omitempty"`
+	// TrustedPlatformModule adds a virtual TPM device to the virtual machine
+	// when it is cloned, unless the template already has one. It requires a
+	// template with EFI firmware, hardware version vmx-14 or later, and a key
+	// provider configured in vCenter to encrypt the virtual machine files.
+	// +optional
+	TrustedPlatformModule bool `json:"trustedPlatformModule,omitempty"`
+	// SecureBoot enables UEFI secure boot on the virtual machine when it is
+	// cloned. It requires a template with EFI firmware.
+	// +optional
+	SecureBoot bool `json:"secureBoot,omitempty"`
+	// CPUHotAddEnabled allows virtual processors to be added to the virtual
+	// machine while it is powered on. It is applied before the virtual
+	// machine is first powered on.
+	// +optional
+	CPUHotAddEnabled bool `json:"cpuHotAddEnabled,omitempty"`
+	// MemoryHotAddEnabled allows memory to be added to the virtual machine
+	// while it is powered on. It is applied before the virtual machine is
+	// first powered on.
+	// +optional
+	MemoryHotAddEnabled bool `json:"memoryHotAddEnabled,omitempty"`
+	// CPUAllocation is the reservation, limit and shares of the CPU of the
+	// virtual machine, in MHz.
+	// Defaults to the allocation of the template from which the virtual
+	// machine is cloned.
+	// +optional
+	CPUAllocation *ResourceAllocation `json:"cpuAllocation,omitempty"`
+	// MemoryAllocation is the reservation, limit and shares of the memory of
+	// the virtual machine, in MiB.
+	// Defaults to the allocation of the template from which the virtual
+	// machine is cloned.
+	// +optional
+	MemoryAllocation *ResourceAllocation `json:"memoryAllocation,omitempty"`
+	// RecoveryPolicy is the action taken when the virtual machine is found
+	// suspended, or orphaned, inaccessible or invalid in vCenter, e.g. after
+	// the failure of its host or datastore.
+	// Defaults to none, which only reports the state of the virtual machine.
+	// +kubebuilder:validation:Enum=none;powerOn;reregister;recreate
+	// +optional
+	RecoveryPolicy VMRecoveryPolicy `json:"recoveryPolicy,omitempty"`
+	// DeletionPolicy is what becomes of the virtual machine when its machine
+	// is deleted. The retained virtual machines are reported in the status of
+	// the VSphereCluster.
+	// Defaults to delete.
+	// +kubebuilder:validation:Enum=delete;retain;powerOffOnly
+	// +optional
+	DeletionPolicy VMDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// CloneRetryPolicy limits and spaces out the retries of the failed clone
+	// tasks of the virtual machine.
+	// Defaults to retrying the failed clone tasks every minute indefinitely.
+	// +optional
+	CloneRetryPolicy *CloneRetryPolicy `json:"cloneRetryPolicy,omitempty"`
+	// DriftDetection compares the virtual machine with the inputs it was
+	// cloned from, i.e. its template, networks, disk size, number of CPUs and
+	// memory, and reports their differences, e.g. after the virtual machine
+	// was modified in vCenter, in the SpecDrifted condition, so that
+	// automation can replace its machine.
+	// +optional
+	DriftDetection bool `json:"driftDetection,omitempty"`
+	// Architecture is the CPU architecture of the virtual machine, which must
+	// match the guest OS of its template, e.g. to keep arm64 templates out
+	// of amd64 machine deployments. The virtual machine is not cloned from a
+	// template of another architecture.
+	// Defaults to amd64.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Architecture Architecture `json:"architecture,omitempty"`
+	// HardwareVirtualization exposes hardware-assisted virtualization to the
+	// guest when the virtual machine is cloned, i.e. sets vhv.enable, so that
+	// it can run nested virtual machines, e.g. with KubeVirt or for the
+	// clusters of Kubernetes-in-Kubernetes environments.
+	// +optional
+	HardwareVirtualization bool `json:"hardwareVirtualization,omitempty"`
+	// VirtualIOMMU exposes a virtual IOMMU, i.e. Intel VT-d, to the guest when
+	// the virtual machine is cloned, e.g. to assign devices to its nested
+	// virtual machines. It requires a template with EFI firmware, hardware
+	// version vmx-14 or later, and hosts with Intel CPUs.
+	// +optional
+	VirtualIOMMU bool `json:"virtualIOMMU,omitempty"`
+	// TimeSync is the synchronization of the time of the guest with the host
+	// by VMware Tools, set when the virtual machine is cloned and reconciled
+	// afterwards, including when it is updated. The guests whose time is synchronized by NTP usually
+	// disable the periodic synchronization, so that the two do not fight.
+	// +optional
+	TimeSync *TimeSync `json:"timeSync,omitempty"`
+}
+
+// CloneRetryPolicy is how the failed clone tasks of a virtual machine are
+// retried.
+type CloneRetryPolicy struct {
+	// MaxAttempts is the number of clone tasks started for the virtual
+	// machine before its machine is marked as failed, 0 meaning no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+
+	// Backoff is the delay before the first retry of a failed clone task,
+	// doubled at each further attempt up to ten minutes.
+	// Defaults to one minute.
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+
+	// DeleteFailedVM deletes the virtual machine left in vCenter by a failed
+	// clone task before the clone is retried. Otherwise, the virtual machine
+	// is reconfigured and powered on as if the clone succeeded.
+	// +optional
+	DeleteFailedVM bool `json:"deleteFailedVM,omitempty"`
+}
+
+// SharesLevel is the level of the shares of a resource of a virtual machine.
+type SharesLevel string
+
+const (
+	// SharesLevelLow is a quarter of the shares of SharesLevelHigh.
+	SharesLevelLow SharesLevel = "low"
+	// SharesLevelNormal is half of the shares of SharesLevelHigh.
+	SharesLevelNormal SharesLevel = "normal"
+	// SharesLevelHigh is the highest predefined level of shares.
+	SharesLevelHigh SharesLevel = "high"
+	// SharesLevelCustom is a custom number of shares.
+	SharesLevelCustom SharesLevel = "custom"
+)
+
+// ResourceAllocation is the allocation of the CPU or memory of a virtual
+// machine. The fields left unset keep the value of the template.
+type ResourceAllocation struct {
+	// Reservation is the amount of the resource guaranteed to the virtual
+	// machine.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Reservation *int64 `json:"reservation,omitempty"`
+
+	// Limit is the maximum amount of the resource the virtual machine may
+	// use, -1 meaning unlimited.
+	// +kubebuilder:validation:Minimum=-1
+	// +optional
+	Limit *int64 `json:"limit,omitempty"`
+
+	// SharesLevel is the relative priority of the virtual machine for the
+	// resource when it is contended.
+	// +kubebuilder:validation:Enum=low;normal;high;custom
+	// +optional
+	SharesLevel SharesLevel `json:"sharesLevel,omitempty"`
+
+	// Shares is the number of shares when SharesLevel is custom.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Shares int32 `json:"shares,omitempty"`
+}
+
+// TimeSync is the synchronization of the time of a guest with its host by
+// VMware Tools. The fields left unset keep the value of the template.
+type TimeSync struct {
+	// SyncOnStartup synchronizes the time of the guest with the host on the
+	// one-off events, i.e. when the guest starts or resumes, and after a
+	// vMotion or the revert of a snapshot. The periodic synchronization
+	// cannot be enabled without it. It requires vSphere 7.0 Update 1 or
+	// later.
+	// +optional
+	SyncOnStartup *bool `json:"syncOnStartup,omitempty"`
+
+	// PeriodicSync synchronizes the time of the guest with the host every
+	// minute.
+	// +optional
+	PeriodicSync *bool `json:"periodicSync,omitempty"`
+}
+
+// DatastoreSelector selects a datastore among candidates when a virtual
+// machine is cloned. The candidates are either listed by Datastores or
+// selected by TagIDs, exactly one of which must be set. Among the accessible
+// candidates which have room for a full clone of the template and stay
+// within MaxProvisionedPercent, the one with the most free space is selected.
+// When a storage policy is set, only the candidates compatible with the
+// policy are considered.
+type DatastoreSelector struct {
+	// Datastores are the names or inventory paths of the candidate
+	// datastores.
+	// +optional
+	Datastores []string `json:"datastores,omitempty"`
+
+	// TagIDs select the candidate datastores by the tags attached to them,
+	// in URN notation. A datastore is a candidate when it has all the tags.
+	// +optional
+	TagIDs []string `json:"tagIDs,omitempty"`
+
+	// MaxProvisionedPercent is the maximum ratio, in percent, of the space
+	// provisioned on a candidate datastore, including the clone, to its
+	// capacity. Thin provisioned datastores may be over-committed by setting
+	// a value over 100.
+	// Defaults to no limit.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxProvisionedPercent *int32 `json:"maxProvisionedPercent,omitempty"`
+
+	// ControlPlaneAntiAffinity spreads the VMs of the control plane machines
+	// of a cluster across distinct candidate datastores, so that a datastore
+	// failure does not cost the etcd cluster its quorum. It has no effect on
+	// the VMs of the other machines.
+	// Defaults to none.
+	// +kubebuilder:validation:Enum=none;preferred;required
+	// +optional
+	ControlPlaneAntiAffinity DatastoreAntiAffinity `json:"controlPlaneAntiAffinity,omitempty"`
+}
+
+// DatastoreAntiAffinity is the anti-affinity of the VMs of a group of
+// machines, on the datastores selected by a DatastoreSelector.
+type DatastoreAntiAffinity string
+
+const (
+	// DatastoreAntiAffinityNone selects the datastores regardless of the
+	// other VMs of the group.
+	DatastoreAntiAffinityNone DatastoreAntiAffinity = "none"
+	// DatastoreAntiAffinityPreferred selects a datastore without other VMs
+	// of the group when one fits, and any candidate otherwise.
+	DatastoreAntiAffinityPreferred DatastoreAntiAffinity = "preferred"
+	// DatastoreAntiAffinityRequired only selects a datastore without other
+	// VMs of the group, and fails the clone when none fits.
+	DatastoreAntiAffinityRequired DatastoreAntiAffinity = "required"
+)
+
+// ComputeSelectionPolicy is the policy by which a compute cluster is selected
+// among the candidates of a ComputeSelector.
+type ComputeSelectionPolicy string
+
+const (
+	// ComputeSelectionLeastVMs selects the compute cluster whose hosts run
+	// the fewest virtual machines.
+	ComputeSelectionLeastVMs ComputeSelectionPolicy = "LeastVMs"
+	// ComputeSelectionMostFreeMemory selects the compute cluster whose hosts
+	// have the most free memory.
+	ComputeSelectionMostFreeMemory ComputeSelectionPolicy = "MostFreeMemory"
+)
+
+// ComputeSelector selects a compute cluster when a virtual machine is cloned.
+// The candidates are the compute clusters of the datacenter with all the
+// TagIDs and whose name matches NameRegex, at least one of which must be set.
+// The hosts in maintenance mode are not accounted for.
+type ComputeSelector struct {
+	// TagIDs select the candidate compute clusters by the tags attached to
+	// them, in URN notation. A compute cluster is a candidate when it has all
+	// the tags.
+	// +optional
+	TagIDs []string `json:"tagIDs,omitempty"`
+
+	// NameRegex is a regular expression the name of the candidate compute
+	// clusters matches.
+	// +optional
+	NameRegex string `json:"nameRegex,omitempty"`
+
+	// Policy is the policy by which a compute cluster is selected among the
+	// candidates.
+	// Defaults to MostFreeMemory.
+	// +kubebuilder:validation:Enum=LeastVMs;MostFreeMemory
+	// +optional
+	Policy ComputeSelectionPolicy `json:"policy,omitempty"`
+}
+
+// CDROMSpec is an ISO image inserted in a CD-ROM drive of a virtual machine.
+// Exactly one of ISOPath and ContentLibraryItem must be set.
+type CDROMSpec struct {
+	// ISOPath is the datastore path of the ISO image, e.g.
+	// "[datastore1] iso/drivers.iso".
+	// +optional
+	ISOPath string `json:"isoPath,omitempty"`
+
+	// ContentLibraryItem is the ISO image item of a content library, as
+	// "<library name>/<item name>".
+	// +optional
+	ContentLibraryItem string `json:"contentLibraryItem,omitempty"`
+
+	// EjectAfterBootstrap specifies whether the ISO image is ejected once the
+	// node of the machine joined the cluster.
+	// +optional
+	EjectAfterBootstrap bool `json:"ejectAfterBootstrap,omitempty"`
+}
+
+// LinkedCloneSpec configures the snapshot from which linked clones are
+// created.
+type LinkedCloneSpec struct {
+	// CreateSnapshot specifies whether a snapshot is created on the source
+	// VM/template when it has no current snapshot, or when the snapshot named
+	// by Snapshot does not exist. The snapshot is named after Snapshot, or
+	// capv-linked-clone if Snapshot is empty.
+	// +optional
+	CreateSnapshot bool `json:"createSnapshot,omitempty"`
+
+	// MaxSnapshotDepth is the maximum depth of the snapshot in the snapshot
+	// tree of the source VM/template, the root snapshots having a depth of 1.
+	// Deep snapshot chains degrade the disk performance of the linked clones.
+	// Defaults to 0, which does not limit the depth.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSnapshotDepth int32 `json:"maxSnapshotDepth,omitempty"`
+
+	// FallbackPolicy is the action taken when no snapshot is found, or when
+	// the snapshot is deeper than MaxSnapshotDepth.
+	// Defaults to fullClone.
+	// +kubebuilder:validation:Enum=fullClone;fail
+	// +optional
+	FallbackPolicy LinkedCloneFallbackPolicy `json:"fallbackPolicy,omitempty"`
+}
+
+// TemplateSource is a vCenter from which templates are cloned across
+// vCenters. The same credentials as for the vCenter on which the virtual
+// machine is created are used to connect to it.
+type TemplateSource struct {
+	// Server is the IP address or FQDN of the vCenter the template is
+	// located on.
+	// +kubebuilder:validation:MinLength=1
+	Server string `json:"server"`
+
+	// Thumbprint is the colon-separated SHA-1 checksum of the vCenter's host
+	// certificate. When this is set to empty, the vCenter is accessed without
+	// TLS certificate validation.
+	// +optional
+	Thumbprint string `json:"thumbprint,omitempty"`
+
+	// Datacenter is the name or inventory path of the datacenter the template
+	// is located in.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+}
+
+// CustomizationSpec is the guest OS customization applied to a virtual
+// machine while it is cloned. Exactly one of Name, Linux and Windows must be
+// set.
+type CustomizationSpec struct {
+	// Name is the name of a Guest OS Customization Specification stored in
+	// vCenter. The specification is used as is, and must therefore match the
+	// network devices of the virtual machine.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Linux is an inline customization for Linux guests.
+	// +optional
+	Linux *LinuxCustomization `json:"linux,omitempty"`
+
+	// Windows is an inline customization for Windows guests.
+	// +optional
+	Windows *WindowsCustomization `json:"windows,omitempty"`
+}
+
+// LinuxCustomization is the customization of a Linux guest. The host name is
+// always set to the hostname of the virtual machine, without its domain, and
+// the network devices are configured from the network spec of the virtual
+// machine.
+type LinuxCustomization struct {
+	// Domain is the domain name of the guest. Defaults to the HostNameDomain
+	// of the virtual machine.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+
+	// TimeZone is the time zone of the guest, e.g. Europe/Paris.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// HWClockUTC specifies whether the hardware clock is in UTC.
+	// +optional
+	HWClockUTC *bool `json:"hwClockUTC,omitempty"`
+}
+
+// WindowsCustomization is the sysprep customization of a Windows guest. The
+// computer name is always set to the name of the virtual machine, and the
+// network devices are configured from the network spec of the virtual
+// machine. At most one of Workgroup and Domain may be set.
+type WindowsCustomization struct {
+	// FullName is the user's full name.
+	FullName string `json:"fullName"`
+
+	// OrgName is the user's organization.
+	OrgName string `json:"orgName"`
+
+	// ProductKey is the Windows product key.
+	// +optional
+	ProductKey string `json:"productKey,omitempty"`
+
+	// TimeZone is the Microsoft time zone index of the guest.
+	// +optional
+	TimeZone int32 `json:"timeZone,omitempty"`
+
+	// Workgroup is the workgroup the guest joins.
+	// +optional
+	Workgroup string `json:"workgroup,omitempty"`
+
+	// Domain is the Active Directory domain the guest joins.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+
+	// DomainAdmin is the name of the user allowed to join the guest to the
+	// domain.
+	// +optional
+	DomainAdmin string `json:"domainAdmin,omitempty"`
+
+	// DomainAdminPasswordSecretRef is a reference to the key of a secret, in
+	// the namespace of the virtual machine, that holds the password of the
+	// DomainAdmin user.
+	// +optional
+	DomainAdminPasswordSecretRef *corev1.SecretKeySelector `json:"domainAdminPasswordSecretRef,omitempty"`
 }
 
 // VSphereMachineTemplateResource describes the data needed to create a VSphereMachine from a template
@@ -214,9 +789,27 @@ func (v APIEndpoint) IsZero() bool {
 	return v.Host == "" || v.Port == 0
 }
 
-// String returns a formatted version HOST:PORT of this APIEndpoint.
+// String returns a formatted version HOST:PORT of this APIEndpoint, with the
+// IPv6 hosts in brackets.
 func (v APIEndpoint) String() string {
-	return fmt.Sprintf("%s:%d", v.Host, v.Port)
+	return net.JoinHostPort(v.Host, strconv.Itoa(int(v.Port)))
+}
+
+// ProxyConfig is the HTTP proxy of the guests of the machines.
+type ProxyConfig struct {
+	// HTTPProxy is the URL of the proxy of the HTTP requests.
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the URL of the proxy of the HTTPS requests.
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is the list of the hosts, domains, IP addresses and CIDRs
+	// reached without the proxy, such as the control plane endpoint and the
+	// pod and service CIDRs of the cluster.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
 }
 
 // PCIDeviceSpec defines virtual machine's PCI configuration
@@ -255,8 +848,13 @@ type NetworkSpec struct {
 // network device.
 type NetworkDeviceSpec struct {
 	// NetworkName is the name of the vSphere network to which the device
-	// will be connected.
-	NetworkName string `json:"networkName"`
+	// will be connected, or its managed object ID, e.g.
+	// "moref:dvportgroup-42". The type of the networks which are neither
+	// distributed port groups nor standard networks is given with their ID,
+	// e.g. "moref:OpaqueNetwork:network-o42".
+	// Defaults to the network of the default placement of the VSphereCluster.
+	// +optional
+	NetworkName string `json:"networkName,omitempty"`
 
 	// DeviceName may be used to explicitly assign a name to the network device
 	// as it exists in the guest operating system.
@@ -275,6 +873,15 @@ type NetworkDeviceSpec struct {
 	// +optional
 	DHCP6 bool `json:"dhcp6,omitempty"`
 
+	// IPv6AddressMode configures the device to autoconfigure its IPv6
+	// addresses from the router advertisements of its network, through SLAAC
+	// or stateless DHCPv6, as in the IPv6-only networks without a stateful
+	// DHCPv6 server.
+	// Cannot be set when DHCP6 is true.
+	// +kubebuilder:validation:Enum=slaac;statelessDHCPv6
+	// +optional
+	IPv6AddressMode IPv6AddressMode `json:"ipv6AddressMode,omitempty"`
+
 	// Gateway4 is the IPv4 gateway used by this device.
 	// Required when DHCP4 is false.
 	// +optional
@@ -343,6 +950,21 @@ type NetworkDeviceSpec struct {
 	DHCP6Overrides *DHCPOverrides `json:"dhcp6Overrides,omitempty"`
 }
 
+// IPv6AddressMode is the mode in which a network device autoconfigures its
+// IPv6 addresses.
+type IPv6AddressMode string
+
+const (
+	// IPv6AddressModeSLAAC configures the IPv6 addresses of a device from
+	// the prefixes of the router advertisements of its network.
+	IPv6AddressModeSLAAC IPv6AddressMode = "slaac"
+
+	// IPv6AddressModeStatelessDHCPv6 configures the IPv6 addresses of a
+	// device through SLAAC, and its other settings, such as its nameservers,
+	// through stateless DHCPv6.
+	IPv6AddressModeStatelessDHCPv6 IPv6AddressMode = "statelessDHCPv6"
+)
+
 // DHCPOverrides allows for the control over several DHCP behaviors.
 // Overrides will only be applied when the corresponding DHCP flag is set.
 // Only configured values will be sent, omitted values will default to
@@ -418,6 +1040,73 @@ type NetworkStatus struct {
 	NetworkName string `json:"networkName,omitempty"`
 }
 
+// TaskState describes the state of an in-flight vCenter task.
+type TaskState string
+
+const (
+	// TaskStateQueued is the state of a task waiting to be run.
+	TaskStateQueued TaskState = "queued"
+
+	// TaskStateRunning is the state of a task being run.
+	TaskStateRunning TaskState = "running"
+)
+
+// TaskStatus describes a vCenter task related to a VM.
+type TaskStatus struct {
+	// Ref is the managed object reference of the task.
+	Ref string `json:"ref"`
+
+	// Operation is the identifier of the operation performed by the task,
+	// e.g. VirtualMachine.clone or VirtualMachine.reconfigure.
+	// +optional
+	Operation string `json:"operation,omitempty"`
+
+	// Entity is the name of the managed entity the task operates on.
+	// +optional
+	Entity string `json:"entity,omitempty"`
+
+	// State is the state of the task.
+	// +kubebuilder:validation:Enum=queued;running
+	// +optional
+	State TaskState `json:"state,omitempty"`
+}
+
+// TaskFailureDetails describes the fault of a failed vCenter task related to
+// a VM.
+type TaskFailureDetails struct {
+	// TaskRef is the managed object reference of the task.
+	TaskRef string `json:"taskRef"`
+
+	// Operation is the identifier of the operation performed by the task,
+	// e.g. VirtualMachine.clone or VirtualMachine.reconfigure.
+	// +optional
+	Operation string `json:"operation,omitempty"`
+
+	// CompleteTime is the time at which the task failed.
+	// +optional
+	CompleteTime *metav1.Time `json:"completeTime,omitempty"`
+
+	// Faults is the fault of the task followed by the chain of its causes,
+	// down to the root cause.
+	// +optional
+	Faults []TaskFault `json:"faults,omitempty"`
+}
+
+// TaskFault is a fault reported by vCenter.
+type TaskFault struct {
+	// Type is the type of the fault, e.g. InvalidDeviceSpec or
+	// NoPermission.
+	Type string `json:"type"`
+
+	// Message is the localized message of the fault.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Details are the additional messages of the fault.
+	// +optional
+	Details []string `json:"details,omitempty"`
+}
+
 // VirtualMachineState describes the state of a VM.
 type VirtualMachineState string
 
@@ -431,6 +1120,10 @@ const (
 
 	// VirtualMachineStateReady is the string representing a powered-on VM with reported IP addresses.
 	VirtualMachineStateReady = "ready"
+
+	// VirtualMachineStateRetained is the string representing a VM that is
+	// left in vCenter by the deletion policy of its VSphereVM.
+	VirtualMachineStateRetained = "retained"
 )
 
 // VirtualMachinePowerState describe the power state of a VM
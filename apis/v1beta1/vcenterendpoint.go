@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultVCenterPort is the port of a VCenterEndpoint which does not set
+	// one.
+	DefaultVCenterPort = 443
+
+	// DefaultVCenterPath is the path of a VCenterEndpoint which does not set
+	// one.
+	DefaultVCenterPath = "/sdk"
+)
+
+// ParseVCenterEndpoint parses the address of a vCenter as set in the Server
+// of a VSphereCluster, which is a host, a host and port, or an https URL.
+// The host may be a bare IPv6 address, e.g. fd00::1, which is bracketed to be
+// parsed. The port and path of the returned endpoint are defaulted.
+func ParseVCenterEndpoint(server string) (*VCenterEndpoint, error) {
+	raw := server
+	if ip := net.ParseIP(raw); ip != nil && strings.Contains(raw, ":") {
+		raw = "[" + raw + "]"
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q, only https is supported", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("missing host in %q", server)
+	}
+
+	endpoint := &VCenterEndpoint{Host: u.Hostname(), Path: u.Path}
+	if port := u.Port(); port != "" {
+		p, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		endpoint.Port = int32(p)
+	}
+	endpoint.Default()
+	return endpoint, nil
+}
+
+// Default sets the port and path of the endpoint when they are not set.
+func (e *VCenterEndpoint) Default() {
+	if e.Port == 0 {
+		e.Port = DefaultVCenterPort
+	}
+	if e.Path == "" {
+		e.Path = DefaultVCenterPath
+	}
+}
+
+// Server returns the address of the endpoint in the format of the Server
+// fields of the API. It is the host alone when the port and path are the
+// defaults, so that it keeps matching the Server of the VSphereVMs and
+// VSphereDeploymentZones using the same vCenter.
+func (e *VCenterEndpoint) Server() string {
+	port, path := e.Port, e.Path
+	if port == 0 {
+		port = DefaultVCenterPort
+	}
+	if path == "" {
+		path = DefaultVCenterPath
+	}
+	if port == DefaultVCenterPort && path == DefaultVCenterPath {
+		return e.Host
+	}
+	u := url.URL{Scheme: "https", Host: net.JoinHostPort(e.Host, strconv.Itoa(int(port))), Path: path}
+	return u.String()
+}
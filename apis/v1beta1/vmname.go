@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxVMNameLength is the maximum length of the name of a VM in vSphere.
+	MaxVMNameLength = 80
+
+	// defaultVMNameTemplate names the VMs after their machine.
+	defaultVMNameTemplate = "{{ .MachineName }}"
+
+	// vmNameHashLength is the length of the hash appended to the names of
+	// the VMs, after a dash.
+	vmNameHashLength = 5
+)
+
+// VMNameTemplateData is the data the Template of a VSphereVMNamingStrategy is
+// rendered with.
+// +kubebuilder:object:generate=false
+type VMNameTemplateData struct {
+	// Namespace is the namespace of the machine.
+	Namespace string
+
+	// MachineName is the name of the machine.
+	MachineName string
+
+	// ClusterName is the name of the cluster of the machine.
+	ClusterName string
+
+	// MachineDeployment is the name of the MachineDeployment of the machine,
+	// if any.
+	MachineDeployment string
+
+	// ControlPlane is true for the control plane machines.
+	ControlPlane bool
+}
+
+// VMName returns the name of the VM of a machine. The rendered part of the
+// name is truncated so that the name, with its prefix, suffix and hash, fits
+// in the maximum length.
+func (s *VSphereVMNamingStrategy) VMName(data *VMNameTemplateData) (string, error) {
+	text := s.Template
+	if text == "" {
+		text = defaultVMNameTemplate
+	}
+	tpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid VM name template %q", text)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "invalid VM name template %q", text)
+	}
+	name := b.String()
+
+	maxLength := int(s.MaxLength)
+	if maxLength == 0 {
+		maxLength = MaxVMNameLength
+	}
+	available := maxLength - len(s.Prefix) - len(s.Suffix)
+	var hash string
+	if s.HashStrategy == VMNameHashAlways || (s.HashStrategy != VMNameHashNever && len(name) > available) {
+		sum := sha256.Sum256([]byte(data.Namespace + "/" + data.MachineName))
+		hash = "-" + hex.EncodeToString(sum[:])[:vmNameHashLength]
+		available -= len(hash)
+	}
+	if available < 1 {
+		return "", errors.Errorf("the prefix, suffix and hash of the VM names leave no room for the name within %d characters", maxLength)
+	}
+	if len(name) > available {
+		name = strings.TrimRight(name[:available], "-.")
+	}
+	return s.Prefix + name + hash + s.Suffix, nil
+}
+
+// VMName returns the name of the VM of the VSphereVM in vSphere.
+func (r *VSphereVM) VMName() string {
+	if r.Spec.VMName != "" {
+		return r.Spec.VMName
+	}
+	return r.Name
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestVSphereVMNamingStrategy_VMName(t *testing.T) {
+	data := &VMNameTemplateData{
+		Namespace:         "default",
+		MachineName:       "prod-md-0-7d9f8c-xk2lp",
+		ClusterName:       "prod",
+		MachineDeployment: "md-0",
+	}
+	long := &VMNameTemplateData{Namespace: "default", MachineName: strings.Repeat("a", 90)}
+
+	tests := []struct {
+		name       string
+		strategy   VSphereVMNamingStrategy
+		data       *VMNameTemplateData
+		want       string
+		wantLength int
+		wantErr    bool
+	}{
+		{
+			name:     "defaults to the name of the machine",
+			strategy: VSphereVMNamingStrategy{},
+			data:     data,
+			want:     "prod-md-0-7d9f8c-xk2lp",
+		},
+		{
+			name:     "renders the template with a prefix and suffix",
+			strategy: VSphereVMNamingStrategy{Template: "{{ .ClusterName }}-{{ .MachineDeployment }}", Prefix: "k8s-", Suffix: ".corp", HashStrategy: VMNameHashAlways},
+			data:     data,
+			want:     "k8s-prod-md-0-" + data.hash() + ".corp",
+		},
+		{
+			name:     "truncates the names with a hash",
+			strategy: VSphereVMNamingStrategy{MaxLength: 16, Suffix: "-vm"},
+			data:     data,
+			want:     "prod-md-" + data.hash() + "-vm",
+		},
+		{
+			name:     "truncates the names without hash",
+			strategy: VSphereVMNamingStrategy{MaxLength: 10, HashStrategy: VMNameHashNever},
+			data:     data,
+			want:     "prod-md-0",
+		},
+		{
+			name:       "truncates the names to the vSphere limit",
+			strategy:   VSphereVMNamingStrategy{},
+			data:       long,
+			wantLength: MaxVMNameLength,
+		},
+		{
+			name:     "fails without room for the name",
+			strategy: VSphereVMNamingStrategy{MaxLength: 8, Prefix: "k8s-", HashStrategy: VMNameHashAlways},
+			data:     data,
+			wantErr:  true,
+		},
+		{
+			name:     "fails with an unknown field",
+			strategy: VSphereVMNamingStrategy{Template: "{{ .Zone }}"},
+			data:     data,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			name, err := tc.strategy.VMName(tc.data)
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.want != "" {
+				g.Expect(name).To(Equal(tc.want))
+			}
+			if tc.wantLength != 0 {
+				g.Expect(name).To(HaveLen(tc.wantLength))
+			}
+		})
+	}
+}
+
+// hash returns the hash appended to the VM names of the machine.
+func (d *VMNameTemplateData) hash() string {
+	name, _ := (&VSphereVMNamingStrategy{Template: "x", HashStrategy: VMNameHashAlways}).VMName(d)
+	return strings.TrimPrefix(name, "x-")
+}
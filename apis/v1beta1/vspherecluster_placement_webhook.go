@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const clusterPlacementWebhookPath = "/mutate-infrastructure-cluster-x-k8s-io-v1beta1-cluster-placement"
+
+// +kubebuilder:webhook:verbs=create,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-cluster-placement,mutating=true,failurePolicy=ignore,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vspheremachines,versions=v1beta1,name=default.clusterplacement.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// ClusterPlacementWebhook is an admission webhook that defaults the
+// placement of the VSphereMachines to the default placement of the
+// VSphereCluster of their cluster.
+// The machine controller applies the same defaults when creating the
+// VSphereVMs, so failing to default here does not block the machines.
+// +kubebuilder:object:generate=false
+type ClusterPlacementWebhook struct {
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ClusterPlacementWebhook{}
+
+func (w *ClusterPlacementWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	mgr.GetWebhookServer().Register(clusterPlacementWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *ClusterPlacementWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle defaults the placement of the VSphereMachine.
+func (w *ClusterPlacementWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &VSphereMachine{}
+	if err := w.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	placement, err := w.getDefaultPlacement(ctx, obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if placement == nil {
+		return admission.Allowed("")
+	}
+	placement.ApplyTo(&obj.Spec.VirtualMachineCloneSpec)
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// getDefaultPlacement returns the default placement of the VSphereCluster of
+// the machine's cluster, or nil if there is none.
+func (w *ClusterPlacementWebhook) getDefaultPlacement(ctx context.Context, machine *VSphereMachine) (*VSphereClusterPlacement, error) {
+	clusterName := machine.Labels[clusterv1.ClusterLabelName]
+	if clusterName == "" {
+		return nil, nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: clusterName}, cluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || ref.Kind != "VSphereCluster" {
+		return nil, nil
+	}
+	vsphereCluster := &VSphereCluster{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: ref.Name}, vsphereCluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return vsphereCluster.Spec.DefaultPlacement, nil
+}
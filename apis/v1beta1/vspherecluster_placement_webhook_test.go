@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVSphereClusterPlacement_ApplyTo(t *testing.T) {
+	g := NewWithT(t)
+
+	placement := &VSphereClusterPlacement{
+		Folder:       "/dc0/vm/cluster",
+		Datastore:    "ds0",
+		ResourcePool: "/dc0/host/cluster0/Resources/pool",
+		Network:      "vm-network",
+
+		StoragePolicyName: "gold",
+		TagIDs:            []string{"urn:tag:cluster"},
+	}
+	spec := &VirtualMachineCloneSpec{
+		Datastore: "ds1",
+		Network: NetworkSpec{
+			Devices: []NetworkDeviceSpec{{}, {NetworkName: "other-network"}},
+		},
+	}
+	placement.ApplyTo(spec)
+	g.Expect(spec.Folder).To(Equal("/dc0/vm/cluster"))
+	g.Expect(spec.Datastore).To(Equal("ds1"))
+	g.Expect(spec.ResourcePool).To(Equal("/dc0/host/cluster0/Resources/pool"))
+	g.Expect(spec.Network.Devices[0].NetworkName).To(Equal("vm-network"))
+	g.Expect(spec.Network.Devices[1].NetworkName).To(Equal("other-network"))
+	g.Expect(spec.StoragePolicyName).To(BeEmpty())
+	g.Expect(spec.TagIDs).To(Equal([]string{"urn:tag:cluster"}))
+
+	spec = &VirtualMachineCloneSpec{TagIDs: []string{"urn:tag:machine"}}
+	placement.ApplyTo(spec)
+	g.Expect(spec.Datastore).To(Equal("ds0"))
+	g.Expect(spec.StoragePolicyName).To(Equal("gold"))
+	g.Expect(spec.TagIDs).To(Equal([]string{"urn:tag:machine"}))
+
+	spec = &VirtualMachineCloneSpec{StoragePolicyName: "silver"}
+	placement.ApplyTo(spec)
+	g.Expect(spec.Datastore).To(BeEmpty())
+	g.Expect(spec.StoragePolicyName).To(Equal("silver"))
+
+	var nilPlacement *VSphereClusterPlacement
+	spec = &VirtualMachineCloneSpec{}
+	nilPlacement.ApplyTo(spec)
+	g.Expect(spec.Folder).To(BeEmpty())
+}
+
+func TestClusterPlacementWebhook_getDefaultPlacement(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = clusterv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	placement := &VSphereClusterPlacement{Datastore: "ds0"}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Kind: "VSphereCluster", Name: "foo-infra"},
+		},
+	}
+	vsphereCluster := &VSphereCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-infra"},
+		Spec:       VSphereClusterSpec{DefaultPlacement: placement},
+	}
+	w := &ClusterPlacementWebhook{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, vsphereCluster).Build(),
+	}
+
+	tests := []struct {
+		name          string
+		labels        map[string]string
+		wantPlacement *VSphereClusterPlacement
+	}{
+		{name: "machine without cluster label"},
+		{name: "cluster not found", labels: map[string]string{clusterv1.ClusterLabelName: "bar"}},
+		{name: "placement of the cluster", labels: map[string]string{clusterv1.ClusterLabelName: "foo"}, wantPlacement: placement},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &VSphereMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine", Labels: tc.labels}}
+			got, err := w.getDefaultPlacement(context.Background(), machine)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tc.wantPlacement))
+		})
+	}
+}
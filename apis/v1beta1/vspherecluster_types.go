@@ -18,6 +18,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -27,6 +28,30 @@ const (
 	// resources associated with VSphereCluster before removing it from the
 	// API server.
 	ClusterFinalizer = "vspherecluster.infrastructure.cluster.x-k8s.io"
+
+	// LegacyLoadBalancerRefAnnotation holds the JSON encoded reference to the
+	// HAProxyLoadBalancer of the clusters created with v1alpha3. The control
+	// plane endpoint of these clusters is migrated from the load balancer's
+	// address by the VSphereCluster controller, after which the annotation is
+	// removed.
+	LegacyLoadBalancerRefAnnotation = "vspherecluster.infrastructure.cluster.x-k8s.io/legacy-load-balancer-ref"
+
+	// VSphereOperationsPausedAnnotation pauses the operations changing the
+	// vSphere inventory for a cluster, such as cloning, reconfiguring and
+	// destroying its VMs, for example during vCenter maintenance windows.
+	// Unlike the paused annotation of Cluster API, the status of the cluster
+	// and of its VMs is still read from vCenter.
+	VSphereOperationsPausedAnnotation = "vspherecluster.infrastructure.cluster.x-k8s.io/paused"
+
+	// ControlPlaneEndpointAddressClaimFinalizer prevents the IPAddressClaim of
+	// the control plane endpoint from being deleted, and the address released,
+	// while the VSphereCluster exists.
+	ControlPlaneEndpointAddressClaimFinalizer = "vspherecluster.infrastructure.cluster.x-k8s.io/ip-claim-protection"
+
+	// AddonsClusterLabel is set on the Clusters whose VSphereCluster installs
+	// vSphere addons, to the name of the Cluster, so that the
+	// ClusterResourceSet of the addons selects the Cluster only.
+	AddonsClusterLabel = "vspherecluster.infrastructure.cluster.x-k8s.io/addons"
 )
 
 // VCenterVersion conveys the API version of the vCenter instance.
@@ -39,16 +64,41 @@ func NewVCenterVersion(version string) VCenterVersion {
 // VSphereClusterSpec defines the desired state of VSphereCluster
 type VSphereClusterSpec struct {
 	// Server is the address of the vSphere endpoint.
+	// Deprecated: use Endpoint, which the Server is defaulted from and
+	// converted to, and which takes precedence when both are set.
 	Server string `json:"server,omitempty"`
 
 	// Thumbprint is the colon-separated SHA-1 checksum of the given vCenter server's host certificate
+	// Deprecated: use Endpoint.Thumbprint, which takes precedence when both
+	// are set.
 	// +optional
 	Thumbprint string `json:"thumbprint,omitempty"`
 
+	// Endpoint is the address of the vCenter, including its port and the path
+	// of its API, e.g. for vCenters behind a reverse proxy.
+	// Defaults to the Server and Thumbprint.
+	// +optional
+	Endpoint *VCenterEndpoint `json:"endpoint,omitempty"`
+
+	// CABundleRef references the PEM-encoded certificates of the certificate
+	// authorities the certificate of the vCenter is verified with, in the
+	// namespace of the VSphereCluster. It takes precedence over the CA bundle
+	// of the VSphereClusterIdentity of the cluster. The thumbprint, when set,
+	// is only checked if the certificate is not signed by these authorities.
+	// +optional
+	CABundleRef *CABundleReference `json:"caBundleRef,omitempty"`
+
 	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
 	// +optional
 	ControlPlaneEndpoint APIEndpoint `json:"controlPlaneEndpoint"`
 
+	// ControlPlaneEndpointAddressFromPool is a reference to the IPAddressPool
+	// from which the host of the ControlPlaneEndpoint is claimed when it is
+	// not set. The claimed address is written to the ControlPlaneEndpoint and
+	// released when the VSphereCluster is deleted.
+	// +optional
+	ControlPlaneEndpointAddressFromPool *corev1.TypedLocalObjectReference `json:"controlPlaneEndpointAddressFromPool,omitempty"`
+
 	// IdentityRef is a reference to either a Secret or VSphereClusterIdentity that contains
 	// the identity to use when reconciling the cluster.
 	// +optional
@@ -58,6 +108,334 @@ type VSphereClusterSpec struct {
 	// for each of the objects responsible for creation of VM objects belonging to the cluster.
 	// +optional
 	ClusterModules []ClusterModule `json:"clusterModules,omitempty"`
+
+	// DefaultPlacement is the placement of the VMs of the cluster, used for
+	// the fields the VSphereMachines leave empty.
+	// +optional
+	DefaultPlacement *VSphereClusterPlacement `json:"defaultPlacement,omitempty"`
+
+	// TemplateReplication configures the replication of templates to the
+	// datastores of the failure domains of the cluster, so that the VMs are
+	// cloned from a template stored on the datastore they are created in.
+	// +optional
+	TemplateReplication *TemplateReplicationSpec `json:"templateReplication,omitempty"`
+
+	// Addons configures the vSphere addons installed in the workload cluster
+	// through a ClusterResourceSet, which requires the ClusterAddons feature
+	// gate and the ClusterResourceSet feature of Cluster API to be enabled.
+	// +optional
+	Addons *VSphereClusterAddons `json:"addons,omitempty"`
+
+	// Proxy is the HTTP proxy configured in the guests of the machines of the
+	// cluster when they are created, for their container runtime and kubelet
+	// to reach the internet through it.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// NTPServers are the NTP servers configured in the guests of the machines
+	// of the cluster when they are created, unless their bootstrap data sets
+	// NTP servers.
+	// +optional
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// Nameservers are the DNS nameservers of the network devices of the
+	// machines of the cluster which do not set any.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// NetworkSpec configures the networks created in vCenter for the cluster.
+	// +optional
+	NetworkSpec *VSphereClusterNetworkSpec `json:"networkSpec,omitempty"`
+}
+
+// VCenterEndpoint is the address of a vCenter.
+type VCenterEndpoint struct {
+	// Host is the IP address or FQDN of the vCenter.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// Port is the HTTPS port of the vCenter.
+	// Defaults to 443.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Path is the path of the vSphere API on the vCenter.
+	// Defaults to /sdk.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Thumbprint is the colon-separated SHA-1 checksum of the certificate of
+	// the vCenter. When empty, the certificate of the vCenter is not verified.
+	// +optional
+	Thumbprint string `json:"thumbprint,omitempty"`
+}
+
+// CABundleKind is the kind of the object holding a CA bundle.
+type CABundleKind string
+
+const (
+	// ConfigMapCABundleKind is a CA bundle held by a ConfigMap.
+	ConfigMapCABundleKind = CABundleKind("ConfigMap")
+
+	// SecretCABundleKind is a CA bundle held by a Secret.
+	SecretCABundleKind = CABundleKind("Secret")
+
+	// DefaultCABundleKey is the key of a CA bundle which does not set one.
+	DefaultCABundleKey = "ca.crt"
+)
+
+// CABundleReference references the PEM-encoded certificates of certificate
+// authorities held by a ConfigMap or Secret. The changes of the certificates
+// are picked up by the sessions to the vCenter.
+type CABundleReference struct {
+	// Kind of the object holding the certificates, either ConfigMap or
+	// Secret.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind CABundleKind `json:"kind"`
+
+	// Name of the object holding the certificates.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key of the certificates in the data of the object.
+	// Defaults to ca.crt.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// TemplateReplicationSpec defines the templates that are replicated to the
+// datastores of the failure domains of a cluster.
+type TemplateReplicationSpec struct {
+	// Templates is the list of templates to replicate. The entries must match
+	// the template of the VSphereMachines using the replicas.
+	// +kubebuilder:validation:MinItems=1
+	Templates []string `json:"templates"`
+}
+
+// VSphereClusterAddons defines the vSphere addons of a workload cluster.
+type VSphereClusterAddons struct {
+	// CloudControllerManager installs the vSphere cloud controller manager.
+	// +optional
+	CloudControllerManager *CloudControllerManagerAddon `json:"cloudControllerManager,omitempty"`
+
+	// CSI installs the vSphere CSI driver. The driver is configured with the
+	// topology of the failure domains of the cluster, if any.
+	// +optional
+	CSI *CSIAddon `json:"csi,omitempty"`
+}
+
+// CloudControllerManagerAddon configures the vSphere cloud controller manager
+// of a workload cluster.
+type CloudControllerManagerAddon struct {
+	// Image is the image of the cloud controller manager.
+	// Defaults to gcr.io/cloud-provider-vsphere/cpi/release/manager:v1.18.1.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// CSIAddon configures the vSphere CSI driver of a workload cluster.
+type CSIAddon struct {
+	// DriverImage is the image of the controller and node plugins of the
+	// CSI driver.
+	// Defaults to gcr.io/cloud-provider-vsphere/csi/release/driver:v2.1.0.
+	// +optional
+	DriverImage string `json:"driverImage,omitempty"`
+
+	// SyncerImage is the image of the metadata syncer of the CSI driver.
+	// Defaults to gcr.io/cloud-provider-vsphere/csi/release/syncer:v2.1.0.
+	// +optional
+	SyncerImage string `json:"syncerImage,omitempty"`
+}
+
+// VSphereClusterPlacement is the default placement of the VMs of a cluster.
+type VSphereClusterPlacement struct {
+	// Folder is the name or inventory path of the folder in which the VMs
+	// are created.
+	// +optional
+	Folder string `json:"folder,omitempty"`
+
+	// Datastore is the name or inventory path of the datastore in which the
+	// VMs are created. It is not used for the VSphereMachines which set a
+	// datastore selector or a storage policy, since it may not be compatible
+	// with their storage policy.
+	// +optional
+	Datastore string `json:"datastore,omitempty"`
+
+	// ResourcePool is the name or inventory path of the resource pool in which
+	// the VMs are created. It is not used for the VSphereMachines which set a
+	// compute selector.
+	// +optional
+	ResourcePool string `json:"resourcePool,omitempty"`
+
+	// Network is the name of the network the network devices of the VMs
+	// are connected to.
+	// +optional
+	Network string `json:"network,omitempty"`
+
+	// StoragePolicyName is the name of the storage policy of the VMs. It is
+	// only used for the VSphereMachines which set neither a datastore nor a
+	// storage policy, so that it does not conflict with their datastore.
+	// +optional
+	StoragePolicyName string `json:"storagePolicyName,omitempty"`
+
+	// TagIDs are the tags attached to the VMs of the VSphereMachines which do
+	// not set any. The tags must use URN-notation instead of display names.
+	// +optional
+	TagIDs []string `json:"tagIDs,omitempty"`
+}
+
+// ApplyTo sets the fields of the clone spec that are empty to the default
+// placement.
+func (p *VSphereClusterPlacement) ApplyTo(spec *VirtualMachineCloneSpec) {
+	if p == nil {
+		return
+	}
+	if spec.Folder == "" {
+		spec.Folder = p.Folder
+	}
+	// The default datastore is not compatible with the storage policy of
+	// the machine, only with the default one.
+	machineStoragePolicy := spec.StoragePolicyName != ""
+	if spec.StoragePolicyName == "" && spec.Datastore == "" {
+		spec.StoragePolicyName = p.StoragePolicyName
+	}
+	if spec.Datastore == "" && spec.DatastoreSelector == nil && !machineStoragePolicy {
+		spec.Datastore = p.Datastore
+	}
+	if spec.ResourcePool == "" && spec.ComputeSelector == nil {
+		spec.ResourcePool = p.ResourcePool
+	}
+	for i := range spec.Network.Devices {
+		if spec.Network.Devices[i].NetworkName == "" {
+			spec.Network.Devices[i].NetworkName = p.Network
+		}
+	}
+	if len(spec.TagIDs) == 0 && len(p.TagIDs) > 0 {
+		spec.TagIDs = append([]string(nil), p.TagIDs...)
+	}
+}
+
+// VSphereClusterNetworkSpec configures the networks created in vCenter for a
+// cluster.
+type VSphereClusterNetworkSpec struct {
+	// CreatePortGroup creates a distributed port group dedicated to the
+	// cluster, which is deleted with the cluster. The network devices of the
+	// VMs of the cluster which do not set a network name are connected to it.
+	// +optional
+	CreatePortGroup *PortGroupSpec `json:"createPortGroup,omitempty"`
+
+	// PortGroupValidation configures the validation of the distributed port
+	// groups the machines of the cluster are connected to, before they are
+	// created. The port groups which are not compatible are reported by a
+	// warning, without preventing the machines from being created.
+	// +optional
+	PortGroupValidation *PortGroupValidation `json:"portGroupValidation,omitempty"`
+}
+
+// PortGroupValidation is the VLAN and security policy the distributed port
+// groups of the machines of a cluster are expected to have. The port groups
+// are also expected not to be VLAN trunks or isolated private VLANs, in which
+// the machines cannot reach each other.
+type PortGroupValidation struct {
+	// VLANID is the VLAN ID the port groups are expected to have.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLANID *int32 `json:"vlanID,omitempty"`
+
+	// SecurityPolicy is the security policy the port groups of all the
+	// machines are expected to have. Only the settings it sets are validated.
+	// +optional
+	SecurityPolicy *PortGroupSecurityPolicy `json:"securityPolicy,omitempty"`
+
+	// ControlPlaneSecurityPolicy is the security policy the port groups of
+	// the control plane machines are expected to have, such as the one
+	// required by the load balancer of the control plane endpoint. Its
+	// settings take precedence over the ones of SecurityPolicy.
+	// +optional
+	ControlPlaneSecurityPolicy *PortGroupSecurityPolicy `json:"controlPlaneSecurityPolicy,omitempty"`
+}
+
+// PortGroupSpec is the configuration of a distributed port group.
+type PortGroupSpec struct {
+	// Datacenter is the name or inventory path of the datacenter of the
+	// distributed switch. It defaults to the only datacenter of the vCenter.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// Switch is the name or inventory path of the distributed switch the
+	// port group is created on.
+	// +kubebuilder:validation:MinLength=1
+	Switch string `json:"switch"`
+
+	// Name is the name of the port group. It defaults to the namespace and
+	// name of the VSphereCluster separated by an underscore.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// VLANID is the VLAN ID of the port group, 0 for untagged traffic.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLANID int32 `json:"vlanID,omitempty"`
+
+	// NumPorts is the initial number of ports of the port group, which
+	// expands automatically as VMs are connected to it. It defaults to 8.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumPorts int32 `json:"numPorts,omitempty"`
+
+	// SecurityPolicy is the security policy of the ports of the port group.
+	// The settings it leaves unset are inherited from the distributed switch.
+	// +optional
+	SecurityPolicy *PortGroupSecurityPolicy `json:"securityPolicy,omitempty"`
+}
+
+// PortGroupSecurityPolicy is the security policy of the ports of a port group.
+type PortGroupSecurityPolicy struct {
+	// AllowPromiscuous allows the VMs to receive the traffic of the other
+	// VMs of the port group.
+	// +optional
+	AllowPromiscuous *bool `json:"allowPromiscuous,omitempty"`
+
+	// MACChanges allows the VMs to receive frames for a MAC address other
+	// than the one of their network device.
+	// +optional
+	MACChanges *bool `json:"macChanges,omitempty"`
+
+	// ForgedTransmits allows the VMs to send frames with a source MAC address
+	// other than the one of their network device.
+	// +optional
+	ForgedTransmits *bool `json:"forgedTransmits,omitempty"`
+}
+
+// ApplyPortGroup connects the network devices of a clone spec which do not set
+// a network name to the port group created for the cluster, if any.
+func (s *VSphereClusterStatus) ApplyPortGroup(spec *VirtualMachineCloneSpec) {
+	if s.PortGroup == nil {
+		return
+	}
+	for i := range spec.Network.Devices {
+		if spec.Network.Devices[i].NetworkName == "" {
+			spec.Network.Devices[i].NetworkName = s.PortGroup.Path
+		}
+	}
+}
+
+// ApplyNameservers sets the nameservers of the network devices of a clone
+// spec which do not set any to the default nameservers of the cluster.
+func (s *VSphereClusterSpec) ApplyNameservers(spec *VirtualMachineCloneSpec) {
+	if len(s.Nameservers) == 0 {
+		return
+	}
+	for i := range spec.Network.Devices {
+		if len(spec.Network.Devices[i].Nameservers) == 0 {
+			spec.Network.Devices[i].Nameservers = append([]string(nil), s.Nameservers...)
+		}
+	}
 }
 
 // ClusterModule holds the anti affinity construct `ClusterModule` identifier
@@ -76,6 +454,28 @@ type ClusterModule struct {
 	ModuleUUID string `json:"moduleUUID"`
 }
 
+// ClusterModuleStatus reports the VMs that are members of a `ClusterModule`.
+type ClusterModuleStatus struct {
+	// ControlPlane indicates whether the referred object is responsible for control plane nodes.
+	ControlPlane bool `json:"controlPlane"`
+
+	// TargetObjectName points to the object that uses the Cluster Module information to enforce
+	// anti-affinity amongst its descendant VM objects.
+	TargetObjectName string `json:"targetObjectName"`
+
+	// ModuleUUID is the unique identifier of the `ClusterModule` used by the object.
+	ModuleUUID string `json:"moduleUUID"`
+
+	// Members is the list of the managed object references of the VMs that
+	// are members of the `ClusterModule`.
+	// +optional
+	Members []string `json:"members,omitempty"`
+
+	// LastUpdated is the last time the membership was read from vCenter.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
 // VSphereClusterStatus defines the observed state of VSphereClusterSpec
 type VSphereClusterStatus struct {
 	// +optional
@@ -90,6 +490,105 @@ type VSphereClusterStatus struct {
 
 	// VCenterVersion defines the version of the vCenter server defined in the spec.
 	VCenterVersion VCenterVersion `json:"vCenterVersion,omitempty"`
+
+	// ClusterModules reports the VMs that are members of each of the
+	// `ClusterModule`s in use by the cluster. It is only populated when the
+	// NodeAntiAffinity feature gate is enabled.
+	// +optional
+	ClusterModules []ClusterModuleStatus `json:"clusterModules,omitempty"`
+
+	// TemplateReplicas reports the replicas of the templates configured in
+	// the TemplateReplication of the cluster, one per template and failure
+	// domain.
+	// +optional
+	TemplateReplicas []TemplateReplicaStatus `json:"templateReplicas,omitempty"`
+
+	// RetainedVMs reports the VMs of the deleted machines of the cluster that
+	// were left in vCenter by their deletion policy, until they are removed
+	// from the vCenter of the cluster.
+	// +optional
+	RetainedVMs []RetainedVM `json:"retainedVMs,omitempty"`
+
+	// RetainedDisks reports the additional disks of the deleted machines of
+	// the cluster that were detached from their VM to be retained, until the
+	// VSphereCluster is deleted.
+	// +optional
+	RetainedDisks []RetainedDisk `json:"retainedDisks,omitempty"`
+
+	// PortGroup is the distributed port group created for the cluster by
+	// the CreatePortGroup of its NetworkSpec.
+	// +optional
+	PortGroup *InventoryObject `json:"portGroup,omitempty"`
+}
+
+// RetainedVM reports a VM left in vCenter by the deletion policy of its
+// VSphereVM.
+type RetainedVM struct {
+	// Name is the name of the VM, and of its deleted VSphereVM.
+	Name string `json:"name"`
+
+	// Server is the vCenter the VM is on.
+	Server string `json:"server"`
+
+	// BiosUUID is the BIOS UUID of the VM.
+	// +optional
+	BiosUUID string `json:"biosUUID,omitempty"`
+
+	// DeletionPolicy is the deletion policy the VM was retained by.
+	DeletionPolicy VMDeletionPolicy `json:"deletionPolicy"`
+
+	// RetainedAt is the time the VSphereVM of the VM was deleted at.
+	RetainedAt metav1.Time `json:"retainedAt"`
+}
+
+// RetainedDisk reports an additional disk of a deleted VM which was detached
+// from the VM to be retained.
+type RetainedDisk struct {
+	// Path is the datastore path of the disk file.
+	Path string `json:"path"`
+
+	// VMName is the name of the VM the disk was detached from, and of its
+	// deleted VSphereVM.
+	VMName string `json:"vmName"`
+
+	// Server is the vCenter the disk is on.
+	Server string `json:"server"`
+
+	// RetainedAt is the time the VSphereVM of the VM was deleted at.
+	RetainedAt metav1.Time `json:"retainedAt"`
+}
+
+// TemplateReplicaStatus reports the replica of a template on the datastore
+// of a failure domain.
+type TemplateReplicaStatus struct {
+	// Template is the replicated template.
+	Template string `json:"template"`
+
+	// FailureDomain is the name of the failure domain the replica is used by.
+	FailureDomain string `json:"failureDomain"`
+
+	// Datastore is the datastore of the failure domain the replica is stored on.
+	Datastore string `json:"datastore"`
+
+	// Name is the name of the replica.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// InstanceUUID is the instance UUID of the replica. It is only set once
+	// the replica is ready.
+	// +optional
+	InstanceUUID string `json:"instanceUUID,omitempty"`
+
+	// Ready is true when the replica can be cloned from.
+	Ready bool `json:"ready"`
+
+	// TaskRef is the managed object reference of the task creating the replica.
+	// +optional
+	TaskRef string `json:"taskRef,omitempty"`
+
+	// Message describes why the replica is not ready.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -118,6 +617,24 @@ func (c *VSphereCluster) SetConditions(conditions clusterv1.Conditions) {
 	c.Status.Conditions = conditions
 }
 
+// VSphereOperationsPaused returns whether the operations changing the vSphere
+// inventory are paused for the cluster.
+func (c *VSphereCluster) VSphereOperationsPaused() bool {
+	_, ok := c.Annotations[VSphereOperationsPausedAnnotation]
+	return ok
+}
+
+// ReadyTemplateReplica returns the instance UUID of the ready replica of the
+// template for the failure domain. The boolean is false if there is none.
+func (c *VSphereCluster) ReadyTemplateReplica(template, failureDomain string) (string, bool) {
+	for _, replica := range c.Status.TemplateReplicas {
+		if replica.Template == template && replica.FailureDomain == failureDomain && replica.Ready && replica.InstanceUUID != "" {
+			return replica.InstanceUUID, true
+		}
+	}
+	return "", false
+}
+
 // +kubebuilder:object:root=true
 
 // VSphereClusterList contains a list of VSphereCluster
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *VSphereCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-vspherecluster,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters,versions=v1beta1,name=validation.vspherecluster.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-vspherecluster,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters,versions=v1beta1,name=default.vspherecluster.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &VSphereCluster{}
+
+var _ webhook.Defaulter = &VSphereCluster{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *VSphereCluster) Default() {
+	r.Spec.defaultEndpoint()
+}
+
+// defaultEndpoint defaults the endpoint from the server and thumbprint, then
+// sets the server, when it is not set, and the thumbprint from the endpoint,
+// so that they remain usable by the clients unaware of the endpoint. An
+// existing server is never rewritten, even in another format of the same
+// address, since it is copied to the VSphereVMs whose server cannot change.
+func (s *VSphereClusterSpec) defaultEndpoint() {
+	if s.Endpoint == nil {
+		if s.Server == "" {
+			return
+		}
+		endpoint, err := ParseVCenterEndpoint(s.Server)
+		if err != nil {
+			// The invalid server is reported by the validation.
+			return
+		}
+		s.Endpoint = endpoint
+	}
+	s.Endpoint.Default()
+	if s.Endpoint.Thumbprint == "" {
+		s.Endpoint.Thumbprint = s.Thumbprint
+	}
+	if s.Server == "" {
+		s.Server = s.Endpoint.Server()
+	}
+	s.Thumbprint = s.Endpoint.Thumbprint
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *VSphereCluster) ValidateCreate() error {
+	allErrs := validateVCenterEndpoint(&r.Spec, field.NewPath("spec"))
+	allErrs = append(allErrs, validateProxyConfig(r.Spec.Proxy, field.NewPath("spec", "proxy"))...)
+	allErrs = append(allErrs, validateNameservers(r.Spec.Nameservers, field.NewPath("spec", "nameservers"))...)
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *VSphereCluster) ValidateUpdate(oldRaw runtime.Object) error {
+	allErrs := validateProxyConfig(r.Spec.Proxy, field.NewPath("spec", "proxy"))
+	allErrs = append(allErrs, validateNameservers(r.Spec.Nameservers, field.NewPath("spec", "nameservers"))...)
+	if old, ok := oldRaw.(*VSphereCluster); ok {
+		allErrs = append(allErrs, validatePortGroupUpdate(old.Spec.NetworkSpec, r.Spec.NetworkSpec, field.NewPath("spec", "networkSpec", "createPortGroup"))...)
+	}
+	// The server of the clusters created before the endpoint was introduced
+	// may not parse, in which case it is left as is.
+	if r.Spec.Endpoint != nil {
+		allErrs = append(allErrs, validateVCenterEndpoint(&r.Spec, field.NewPath("spec"))...)
+	}
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *VSphereCluster) ValidateDelete() error {
+	return nil
+}
+
+// validateVCenterEndpoint validates the endpoint of a VSphereCluster spec,
+// or its server when the endpoint is not set. When both are set, the server
+// must be the address of the endpoint.
+func validateVCenterEndpoint(spec *VSphereClusterSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.Endpoint == nil {
+		if spec.Server != "" {
+			if _, err := ParseVCenterEndpoint(spec.Server); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("server"), spec.Server, err.Error()))
+			}
+		}
+		return allErrs
+	}
+	host := spec.Endpoint.Host
+	if net.ParseIP(host) == nil && strings.ContainsAny(host, ":/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint", "host"), host, "must be an IP address or FQDN, without scheme, port or path"))
+	}
+	if path := spec.Endpoint.Path; path != "" && !strings.HasPrefix(path, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint", "path"), path, "must start with /"))
+	}
+	if spec.Server != "" {
+		endpoint := spec.Endpoint.DeepCopy()
+		endpoint.Default()
+		if server, err := ParseVCenterEndpoint(spec.Server); err == nil &&
+			(server.Host != endpoint.Host || server.Port != endpoint.Port || server.Path != endpoint.Path) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("server"), spec.Server, fmt.Sprintf("must be the address of the endpoint, %s", endpoint.Server())))
+		}
+	}
+	return allErrs
+}
+
+// validateProxyConfig validates that the proxies of a proxy configuration are
+// HTTP or HTTPS URLs, and that the entries of its noProxy list can be joined
+// in the NO_PROXY environment variable of the guests.
+func validateProxyConfig(proxy *ProxyConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if proxy == nil {
+		return allErrs
+	}
+	for _, p := range []struct{ name, url string }{{"httpProxy", proxy.HTTPProxy}, {"httpsProxy", proxy.HTTPSProxy}} {
+		if p.url == "" {
+			continue
+		}
+		if u, err := url.Parse(p.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(p.url, " \t\n\"") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(p.name), p.url, "must be an HTTP or HTTPS URL"))
+		}
+	}
+	for i, noProxy := range proxy.NoProxy {
+		if noProxy == "" || strings.ContainsAny(noProxy, ", \t\n\"") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("noProxy").Index(i), noProxy, "must be a host, domain, IP address or CIDR"))
+		}
+	}
+	return allErrs
+}
+
+// validateNameservers validates that the default nameservers of a cluster are
+// IPv4 or IPv6 addresses.
+func validateNameservers(nameservers []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, nameserver := range nameservers {
+		if net.ParseIP(nameserver) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), nameserver, "should be an IPv4 or IPv6 address"))
+		}
+	}
+	return allErrs
+}
+
+// validatePortGroupUpdate forbids moving or renaming the port group created
+// for a cluster, which would leave the VMs of the cluster connected to the
+// previous one. The port group can be removed from the spec instead, which
+// deletes it unless VMs are still connected to it.
+func validatePortGroupUpdate(oldSpec, spec *VSphereClusterNetworkSpec, fldPath *field.Path) field.ErrorList {
+	if oldSpec == nil || oldSpec.CreatePortGroup == nil || spec == nil || spec.CreatePortGroup == nil {
+		return nil
+	}
+	oldPG, pg := oldSpec.CreatePortGroup, spec.CreatePortGroup
+	var allErrs field.ErrorList
+	for _, f := range []struct{ name, old, new string }{
+		{"datacenter", oldPG.Datacenter, pg.Datacenter},
+		{"switch", oldPG.Switch, pg.Switch},
+		{"name", oldPG.Name, pg.Name},
+	} {
+		if f.old != f.new {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child(f.name), "cannot be updated once the port group is created"))
+		}
+	}
+	return allErrs
+}
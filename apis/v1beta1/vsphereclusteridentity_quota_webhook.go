@@ -0,0 +1,313 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const identityQuotaWebhookPath = "/validate-infrastructure-cluster-x-k8s-io-v1beta1-identity-quota"
+
+// IdentityName returns the name of the VSphereClusterIdentity the cluster
+// uses, or "" if it does not use one.
+func (c *VSphereCluster) IdentityName() string {
+	if ref := c.Spec.IdentityRef; ref != nil && ref.Kind == VSphereClusterIdentityKind {
+		return ref.Name
+	}
+	return ""
+}
+
+// ownerClusterName returns the name of the Cluster owning a VSphereCluster, or
+// "" if it is not owned yet.
+func ownerClusterName(c *VSphereCluster) string {
+	for _, ref := range c.OwnerReferences {
+		if ref.Kind != "Cluster" {
+			continue
+		}
+		if gv, err := schema.ParseGroupVersion(ref.APIVersion); err == nil && gv.Group == clusterv1.GroupVersion.Group {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// Exceeded returns the limits of the quota the usage exceeds.
+func (q *IdentityQuota) Exceeded(usage *IdentityUsage) []string {
+	var exceeded []string
+	if q.MaxClusters != nil && usage.Clusters > *q.MaxClusters {
+		exceeded = append(exceeded, fmt.Sprintf("%d clusters exceed maxClusters %d", usage.Clusters, *q.MaxClusters))
+	}
+	if q.MaxVMs != nil && usage.VMs > *q.MaxVMs {
+		exceeded = append(exceeded, fmt.Sprintf("%d VMs exceed maxVMs %d", usage.VMs, *q.MaxVMs))
+	}
+	if q.MaxCPUs != nil && usage.CPUs > *q.MaxCPUs {
+		exceeded = append(exceeded, fmt.Sprintf("%d CPUs exceed maxCPUs %d", usage.CPUs, *q.MaxCPUs))
+	}
+	if q.MaxMemoryMiB != nil && usage.MemoryMiB > *q.MaxMemoryMiB {
+		exceeded = append(exceeded, fmt.Sprintf("%d MiB of memory exceed maxMemoryMiB %d", usage.MemoryMiB, *q.MaxMemoryMiB))
+	}
+	return exceeded
+}
+
+func (u *IdentityUsage) add(vm *VSphereVM) {
+	u.VMs++
+	u.CPUs += vm.Spec.NumCPUs
+	u.MemoryMiB += vm.Spec.MemoryMiB
+}
+
+// identityClusters returns the VSphereClusters using a VSphereClusterIdentity
+// which are not being deleted.
+func identityClusters(ctx context.Context, c client.Reader, identityName string) ([]*VSphereCluster, error) {
+	clusters := &VSphereClusterList{}
+	if err := c.List(ctx, clusters); err != nil {
+		return nil, errors.Wrap(err, "failed to list VSphereClusters")
+	}
+	var result []*VSphereCluster
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if cluster.IdentityName() == identityName && cluster.DeletionTimestamp.IsZero() {
+			result = append(result, cluster)
+		}
+	}
+	return result, nil
+}
+
+// GetIdentityUsage returns the vSphere capacity consumed by the VSphereClusters
+// using a VSphereClusterIdentity and by their VSphereVMs. Only the VSphereVMs
+// counted returns true for are counted, or all of them if counted is nil.
+func GetIdentityUsage(ctx context.Context, c client.Reader, identityName string, counted func(*VSphereVM) bool) (*IdentityUsage, error) {
+	clusters, err := identityClusters(ctx, c, identityName)
+	if err != nil {
+		return nil, err
+	}
+	usage := &IdentityUsage{Clusters: int32(len(clusters))}
+	for _, cluster := range clusters {
+		clusterName := ownerClusterName(cluster)
+		if clusterName == "" {
+			continue
+		}
+		vms := &VSphereVMList{}
+		if err := c.List(ctx, vms, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+			return nil, errors.Wrapf(err, "failed to list the VSphereVMs of cluster %s/%s", cluster.Namespace, clusterName)
+		}
+		for i := range vms.Items {
+			vm := &vms.Items[i]
+			if !vm.DeletionTimestamp.IsZero() || (counted != nil && !counted(vm)) {
+				continue
+			}
+			usage.add(vm)
+		}
+	}
+	return usage, nil
+}
+
+// getQuotaIdentity returns the VSphereClusterIdentity of a VSphereCluster if it
+// has a quota, or nil otherwise.
+func getQuotaIdentity(ctx context.Context, c client.Reader, vsphereCluster *VSphereCluster) (*VSphereClusterIdentity, error) {
+	identityName := vsphereCluster.IdentityName()
+	if identityName == "" {
+		return nil, nil
+	}
+	identity := &VSphereClusterIdentity{}
+	if err := c.Get(ctx, client.ObjectKey{Name: identityName}, identity); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get VSphereClusterIdentity %s", identityName)
+	}
+	if identity.Spec.Quota == nil {
+		return nil, nil
+	}
+	return identity, nil
+}
+
+// ValidateIdentityQuota validates that a VSphereVM of a VSphereCluster does not
+// exceed the quota of the VSphereClusterIdentity of the cluster, together with
+// the other VSphereVMs counted returns true for, or all of them if counted is
+// nil. The number of clusters is not validated, so that the existing clusters
+// keep their VMs when the quota is lowered.
+func ValidateIdentityQuota(ctx context.Context, c client.Reader, vsphereCluster *VSphereCluster, vm *VSphereVM, counted func(*VSphereVM) bool) (field.ErrorList, error) {
+	identity, err := getQuotaIdentity(ctx, c, vsphereCluster)
+	if err != nil || identity == nil {
+		return nil, err
+	}
+	quota := *identity.Spec.Quota
+	quota.MaxClusters = nil
+
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec")
+	if quota.MaxCPUs != nil && vm.Spec.NumCPUs <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("numCPUs"), fmt.Sprintf("must be set when VSphereClusterIdentity %s caps the CPUs", identity.Name)))
+	}
+	if quota.MaxMemoryMiB != nil && vm.Spec.MemoryMiB <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("memoryMiB"), fmt.Sprintf("must be set when VSphereClusterIdentity %s caps the memory", identity.Name)))
+	}
+	if len(allErrs) > 0 {
+		return allErrs, nil
+	}
+
+	usage, err := GetIdentityUsage(ctx, c, identity.Name, func(other *VSphereVM) bool {
+		if other.Namespace == vm.Namespace && other.Name == vm.Name {
+			return false
+		}
+		return counted == nil || counted(other)
+	})
+	if err != nil {
+		return nil, err
+	}
+	usage.add(vm)
+	if exceeded := quota.Exceeded(usage); len(exceeded) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("exceeds the quota of VSphereClusterIdentity %s: %s", identity.Name, strings.Join(exceeded, ", "))))
+	}
+	return allErrs, nil
+}
+
+// validateIdentityClusterQuota validates that a VSphereCluster starting to use
+// a VSphereClusterIdentity does not exceed the maximum number of clusters of
+// the identity.
+func validateIdentityClusterQuota(ctx context.Context, c client.Reader, vsphereCluster *VSphereCluster) (field.ErrorList, error) {
+	identity, err := getQuotaIdentity(ctx, c, vsphereCluster)
+	if err != nil || identity == nil || identity.Spec.Quota.MaxClusters == nil {
+		return nil, err
+	}
+	clusters, err := identityClusters(ctx, c, identity.Name)
+	if err != nil {
+		return nil, err
+	}
+	count := int32(1)
+	for _, cluster := range clusters {
+		if cluster.Namespace != vsphereCluster.Namespace || cluster.Name != vsphereCluster.Name {
+			count++
+		}
+	}
+	if maxClusters := *identity.Spec.Quota.MaxClusters; count > maxClusters {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec", "identityRef"),
+			fmt.Sprintf("exceeds the quota of VSphereClusterIdentity %s: %d clusters exceed maxClusters %d", identity.Name, count, maxClusters))}, nil
+	}
+	return nil, nil
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-identity-quota,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters;vspherevms,versions=v1beta1,name=validation.identityquota.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// IdentityQuotaWebhook is an admission webhook that rejects the VSphereClusters
+// and VSphereVMs exceeding the quota of the VSphereClusterIdentity of their
+// cluster. The VSphereVMs are only validated when they are created, and the
+// VSphereClusters when they start using an identity.
+// +kubebuilder:object:generate=false
+type IdentityQuotaWebhook struct {
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &IdentityQuotaWebhook{}
+
+func (w *IdentityQuotaWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	mgr.GetWebhookServer().Register(identityQuotaWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *IdentityQuotaWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle validates the object against the quota of the identity of its
+// cluster.
+func (w *IdentityQuotaWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var (
+		allErrs field.ErrorList
+		err     error
+		gk      = schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+	)
+	switch req.Kind.Kind {
+	case "VSphereCluster":
+		obj := &VSphereCluster{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if req.Operation == admissionv1.Update {
+			old := &VSphereCluster{}
+			if err := w.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+			if old.IdentityName() == obj.IdentityName() {
+				return admission.Allowed("")
+			}
+		}
+		allErrs, err = validateIdentityClusterQuota(ctx, w.client, obj)
+	case "VSphereVM":
+		if req.Operation != admissionv1.Create {
+			return admission.Allowed("")
+		}
+		obj := &VSphereVM{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		var vsphereCluster *VSphereCluster
+		if vsphereCluster, err = w.getVSphereCluster(ctx, obj); err == nil && vsphereCluster != nil {
+			allErrs, err = ValidateIdentityQuota(ctx, w.client, vsphereCluster, obj, nil)
+		}
+	default:
+		return admission.Allowed("")
+	}
+
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(allErrs) > 0 {
+		return admission.Denied(apierrors.NewInvalid(gk, req.Name, allErrs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// getVSphereCluster returns the VSphereCluster of the cluster of a VSphereVM,
+// or nil if there is none.
+func (w *IdentityQuotaWebhook) getVSphereCluster(ctx context.Context, vm *VSphereVM) (*VSphereCluster, error) {
+	clusterName := vm.Labels[clusterv1.ClusterLabelName]
+	if clusterName == "" {
+		return nil, nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: vm.Namespace, Name: clusterName}, cluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || ref.Kind != "VSphereCluster" {
+		return nil, nil
+	}
+	vsphereCluster := &VSphereCluster{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: vm.Namespace, Name: ref.Name}, vsphereCluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return vsphereCluster, nil
+}
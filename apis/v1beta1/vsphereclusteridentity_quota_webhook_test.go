@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIdentityQuota(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+
+	identity := &VSphereClusterIdentity{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"},
+		Spec: VSphereClusterIdentitySpec{
+			Quota: &IdentityQuota{
+				MaxClusters:  pointer.Int32(1),
+				MaxVMs:       pointer.Int32(3),
+				MaxCPUs:      pointer.Int32(8),
+				MaxMemoryMiB: pointer.Int64(16384),
+			},
+		},
+	}
+	newVSphereCluster := func(namespace, name string) *VSphereCluster {
+		return &VSphereCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       namespace,
+				Name:            name,
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: name}},
+			},
+			Spec: VSphereClusterSpec{IdentityRef: &VSphereIdentityReference{Kind: VSphereClusterIdentityKind, Name: "tenant-a"}},
+		}
+	}
+	newVM := func(name string, cpus int32, memoryMiB int64) *VSphereVM {
+		return &VSphereVM{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+			Spec: VSphereVMSpec{VirtualMachineCloneSpec: VirtualMachineCloneSpec{NumCPUs: cpus, MemoryMiB: memoryMiB}},
+		}
+	}
+	vsphereCluster := newVSphereCluster("ns", "cluster")
+	createdVM := newVM("created", 2, 4096)
+	createdVM.Spec.BiosUUID = "uuid"
+	pendingVM := newVM("pending", 2, 4096)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(identity, vsphereCluster, createdVM, pendingVM).Build()
+
+	t.Run("usage of the identity", func(t *testing.T) {
+		g := NewWithT(t)
+		usage, err := GetIdentityUsage(context.Background(), c, "tenant-a", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*usage).To(Equal(IdentityUsage{Clusters: 1, VMs: 2, CPUs: 4, MemoryMiB: 8192}))
+		g.Expect(identity.Spec.Quota.Exceeded(usage)).To(BeEmpty())
+
+		usage.CPUs = 10
+		g.Expect(identity.Spec.Quota.Exceeded(usage)).To(ConsistOf("10 CPUs exceed maxCPUs 8"))
+	})
+
+	vmTests := []struct {
+		name    string
+		vm      *VSphereVM
+		counted func(*VSphereVM) bool
+		wantErr string
+	}{
+		{name: "within the quota", vm: newVM("new", 2, 4096)},
+		{name: "exceeding the CPUs", vm: newVM("new", 6, 4096), wantErr: "10 CPUs exceed maxCPUs 8"},
+		{name: "exceeding the memory", vm: newVM("new", 2, 16384), wantErr: "24576 MiB of memory exceed maxMemoryMiB 16384"},
+		{name: "without CPUs", vm: newVM("new", 0, 4096), wantErr: "spec.numCPUs: Required value"},
+		{name: "already counted", vm: newVM("pending", 4, 8192)},
+		{
+			name: "only counting the created VMs",
+			vm:   newVM("new", 6, 4096),
+			counted: func(vm *VSphereVM) bool {
+				return vm.Spec.BiosUUID != ""
+			},
+		},
+	}
+	for _, tc := range vmTests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs, err := ValidateIdentityQuota(context.Background(), c, vsphereCluster, tc.vm, tc.counted)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.wantErr != "" {
+				g.Expect(errs.ToAggregate()).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+
+	t.Run("maximum number of clusters", func(t *testing.T) {
+		g := NewWithT(t)
+		errs, err := validateIdentityClusterQuota(context.Background(), c, vsphereCluster)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(errs).To(BeEmpty())
+
+		errs, err = validateIdentityClusterQuota(context.Background(), c, newVSphereCluster("other", "cluster"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("2 clusters exceed maxClusters 1")))
+	})
+
+	t.Run("identities without quota", func(t *testing.T) {
+		g := NewWithT(t)
+		other := newVSphereCluster("ns", "other")
+		other.Spec.IdentityRef.Name = "unknown"
+		errs, err := ValidateIdentityQuota(context.Background(), c, other, newVM("new", 64, 0), nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(errs).To(BeEmpty())
+	})
+}
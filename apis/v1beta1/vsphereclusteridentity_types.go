@@ -28,25 +28,140 @@ const (
 
 type VSphereClusterIdentitySpec struct {
 	// SecretName references a Secret inside the controller namespace with the credentials to use
+	// Ignored when CredentialSource is set.
 	// +kubebuilder:validation:MinLength=1
 	SecretName string `json:"secretName,omitempty"`
 
+	// CredentialSource is an external source of the credentials to use, in
+	// place of the Secret, e.g. for short-lived credentials which may not be
+	// stored in the management cluster.
+	// +optional
+	CredentialSource *CredentialSource `json:"credentialSource,omitempty"`
+
 	// AllowedNamespaces is used to identify which namespaces are allowed to use this account.
 	// Namespaces can be selected with a label selector.
 	// If this object is nil, no namespaces will be allowed
 	// +optional
 	AllowedNamespaces *AllowedNamespaces `json:"allowedNamespaces,omitempty"`
+
+	// CABundleRef references the PEM-encoded certificates of the certificate
+	// authorities the certificates of the vCenters are verified with, inside
+	// the controller namespace. The CA bundle of a VSphereCluster takes
+	// precedence.
+	// +optional
+	CABundleRef *CABundleReference `json:"caBundleRef,omitempty"`
+
+	// Quota caps the vSphere capacity the clusters using this identity may
+	// consume. If nil, the clusters are not capped.
+	// +optional
+	Quota *IdentityQuota `json:"quota,omitempty"`
 }
 
 type VSphereClusterIdentityStatus struct {
 	// +optional
 	Ready bool `json:"ready,omitempty"`
 
+	// Usage is the vSphere capacity consumed by the clusters using this
+	// identity.
+	// +optional
+	Usage *IdentityUsage `json:"usage,omitempty"`
+
 	// Conditions defines current service state of the VSphereCluster.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
+// CredentialSource is an external source of vCenter credentials. Exactly one
+// source must be set.
+type CredentialSource struct {
+	// Vault reads the credentials from a HashiCorp Vault secret.
+	// +optional
+	Vault *VaultCredentialSource `json:"vault,omitempty"`
+}
+
+// VaultCredentialSource reads the username and password of a vCenter user
+// from a HashiCorp Vault secret, the controllers logging in to Vault through
+// its Kubernetes auth method with their service account. The credentials are
+// read again once two thirds of the lease of the secret elapsed, or every 5
+// minutes for secrets without lease.
+type VaultCredentialSource struct {
+	// Address is the URL of the Vault server, e.g.
+	// https://vault.example.com:8200.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Path is the path of the secret holding the username and password, e.g.
+	// secret/data/vsphere for a key/value secret, or the credentials path of
+	// a secrets engine issuing short-lived credentials.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Role is the role of the Kubernetes auth method the controllers log in
+	// with.
+	// +kubebuilder:validation:MinLength=1
+	Role string `json:"role"`
+
+	// AuthPath is the mount path of the Kubernetes auth method.
+	// Defaults to kubernetes.
+	// +optional
+	AuthPath string `json:"authPath,omitempty"`
+
+	// Namespace is the Vault Enterprise namespace of the secret.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// CABundleRef references the certificate authorities the certificate of
+	// the Vault server is verified with, inside the controller namespace.
+	// Defaults to the certificate authorities of the system.
+	// +optional
+	CABundleRef *CABundleReference `json:"caBundleRef,omitempty"`
+}
+
+// IdentityQuota caps the vSphere capacity the clusters using a
+// VSphereClusterIdentity may consume. The VSphereClusters and VSphereVMs
+// exceeding it are rejected when they are created, and the VMs are not
+// created in vCenter while the quota is exceeded, e.g. after it was lowered.
+// Unset limits are not enforced.
+type IdentityQuota struct {
+	// MaxClusters is the maximum number of VSphereClusters using the identity.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+
+	// MaxVMs is the maximum number of VSphereVMs of these clusters.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxVMs *int32 `json:"maxVMs,omitempty"`
+
+	// MaxCPUs is the maximum total number of virtual processors of these
+	// VSphereVMs. When set, the VSphereVMs must set numCPUs.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCPUs *int32 `json:"maxCPUs,omitempty"`
+
+	// MaxMemoryMiB is the maximum total memory of these VSphereVMs, in MiB.
+	// When set, the VSphereVMs must set memoryMiB.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxMemoryMiB *int64 `json:"maxMemoryMiB,omitempty"`
+}
+
+// IdentityUsage is the vSphere capacity consumed by the clusters using a
+// VSphereClusterIdentity.
+type IdentityUsage struct {
+	// Clusters is the number of VSphereClusters using the identity.
+	Clusters int32 `json:"clusters"`
+
+	// VMs is the number of VSphereVMs of these clusters.
+	VMs int32 `json:"vms"`
+
+	// CPUs is the total number of virtual processors of these VSphereVMs.
+	CPUs int32 `json:"cpus"`
+
+	// MemoryMiB is the total memory of these VSphereVMs, in MiB.
+	MemoryMiB int64 `json:"memoryMiB"`
+}
+
 type AllowedNamespaces struct {
 	// Selector is a standard Kubernetes LabelSelector. A label query over a set of resources.
 	// +optional
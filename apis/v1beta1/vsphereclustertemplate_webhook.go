@@ -17,8 +17,6 @@ limitations under the License.
 package v1beta1
 
 import (
-	"reflect"
-
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -37,16 +35,15 @@ var _ webhook.Validator = &VSphereClusterTemplate{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (r *VSphereClusterTemplate) ValidateCreate() error {
-	return nil
+	allErrs := validateVCenterEndpoint(&r.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (r *VSphereClusterTemplate) ValidateUpdate(oldRaw runtime.Object) error {
 	old := oldRaw.(*VSphereClusterTemplate) //nolint:forcetypeassert
-	if !reflect.DeepEqual(r.Spec.Template.Spec, old.Spec.Template.Spec) {
-		return field.Forbidden(field.NewPath("spec", "template", "spec"), "VSphereClusterTemplate spec is immutable")
-	}
-	return nil
+	allErrs := validateImmutability(vsphereClusterTemplateImmutability, r, &old.Spec, &r.Spec, field.NewPath("spec"))
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
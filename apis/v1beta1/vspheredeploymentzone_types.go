@@ -45,6 +45,14 @@ type VSphereDeploymentZoneSpec struct {
 	// PlacementConstraint encapsulates the placement constraints
 	// used within this deployment zone.
 	PlacementConstraint PlacementConstraint `json:"placementConstraint"`
+
+	// Weight is the relative capacity of the deployment zone for the
+	// machines spread across deployment zones with the CapacityWeighted
+	// failure domain balancing. Deployment zones with a weight of 0 are not
+	// used by the balancing. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
 }
 
 // PlacementConstraint is the context information for VM placements within a failure domain
@@ -82,6 +90,29 @@ type VSphereDeploymentZoneStatus struct {
 	// Conditions defines current service state of the VSphereMachine.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// ComputeCluster is the compute cluster of the failure domain, tracked
+	// by its managed object ID once resolved.
+	// +optional
+	ComputeCluster *TrackedObject `json:"computeCluster,omitempty"`
+
+	// ResourcePool is the resource pool of the placement constraint, tracked
+	// by its managed object ID once resolved.
+	// +optional
+	ResourcePool *TrackedObject `json:"resourcePool,omitempty"`
+}
+
+// TrackedObject is a vSphere inventory object tracked by its managed object
+// ID, which does not change when the object is renamed in vCenter.
+type TrackedObject struct {
+	// Name is the name or inventory path the object was resolved from.
+	Name string `json:"name"`
+
+	// MoID is the managed object ID of the object.
+	MoID string `json:"moID"`
+
+	// Path is the inventory path of the object when it was last resolved.
+	Path string `json:"path"`
 }
 
 // +kubebuilder:object:root=true
@@ -106,6 +137,35 @@ func (z *VSphereDeploymentZone) SetConditions(conditions clusterv1.Conditions) {
 	z.Status.Conditions = conditions
 }
 
+// ResourcePoolPath returns the inventory path of the resource pool of the
+// placement constraint. It is the current path of the resource pool tracked by
+// the deployment zone, which differs from the configured one once the resource
+// pool is renamed in vCenter.
+func (z *VSphereDeploymentZone) ResourcePoolPath() string {
+	return z.Status.ResourcePool.currentPath(z.Spec.PlacementConstraint.ResourcePool)
+}
+
+// Topology returns the topology of the failure domain of the deployment zone,
+// with the current inventory path of the compute cluster tracked by the
+// deployment zone.
+func (z *VSphereDeploymentZone) Topology(failureDomain *VSphereFailureDomain) Topology {
+	topology := failureDomain.Spec.Topology
+	if topology.ComputeCluster != nil {
+		computeCluster := z.Status.ComputeCluster.currentPath(*topology.ComputeCluster)
+		topology.ComputeCluster = &computeCluster
+	}
+	return topology
+}
+
+// currentPath returns the current inventory path of the object configured with
+// the given name, if it is the tracked object.
+func (o *TrackedObject) currentPath(name string) string {
+	if o == nil || o.Name != name || name == "" {
+		return name
+	}
+	return o.Path
+}
+
 // +kubebuilder:object:root=true
 
 // VSphereDeploymentZoneList contains a list of VSphereDeploymentZone
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+)
+
+func TestVSphereDeploymentZone_TrackedPaths(t *testing.T) {
+	failureDomain := &VSphereFailureDomain{
+		Spec: VSphereFailureDomainSpec{
+			Topology: Topology{Datacenter: "dc0", ComputeCluster: pointer.String("cluster0")},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		status             VSphereDeploymentZoneStatus
+		wantResourcePool   string
+		wantComputeCluster string
+	}{
+		{
+			name:               "configured paths are used until the objects are tracked",
+			wantResourcePool:   "rp0",
+			wantComputeCluster: "cluster0",
+		},
+		{
+			name: "current paths of the tracked objects are used",
+			status: VSphereDeploymentZoneStatus{
+				ComputeCluster: &TrackedObject{Name: "cluster0", MoID: "domain-c1", Path: "/dc0/host/renamed"},
+				ResourcePool:   &TrackedObject{Name: "rp0", MoID: "resgroup-1", Path: "/dc0/host/renamed/Resources/rp0"},
+			},
+			wantResourcePool:   "/dc0/host/renamed/Resources/rp0",
+			wantComputeCluster: "/dc0/host/renamed",
+		},
+		{
+			name: "objects tracked for other configured paths are ignored",
+			status: VSphereDeploymentZoneStatus{
+				ComputeCluster: &TrackedObject{Name: "cluster1", MoID: "domain-c2", Path: "/dc0/host/cluster1"},
+				ResourcePool:   &TrackedObject{Name: "rp1", MoID: "resgroup-2", Path: "/dc0/host/cluster1/Resources/rp1"},
+			},
+			wantResourcePool:   "rp0",
+			wantComputeCluster: "cluster0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			zone := &VSphereDeploymentZone{
+				Spec: VSphereDeploymentZoneSpec{
+					PlacementConstraint: PlacementConstraint{ResourcePool: "rp0"},
+				},
+				Status: tt.status,
+			}
+
+			g.Expect(zone.ResourcePoolPath()).To(Equal(tt.wantResourcePool))
+			topology := zone.Topology(failureDomain)
+			g.Expect(*topology.ComputeCluster).To(Equal(tt.wantComputeCluster))
+			g.Expect(topology.Datacenter).To(Equal("dc0"))
+			g.Expect(*failureDomain.Spec.Topology.ComputeCluster).To(Equal("cluster0"))
+		})
+	}
+}
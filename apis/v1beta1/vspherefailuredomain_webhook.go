@@ -18,8 +18,8 @@ package v1beta1
 
 import (
 	"fmt"
-	"reflect"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
@@ -70,10 +70,11 @@ func (r *VSphereFailureDomain) ValidateCreate() error {
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (r *VSphereFailureDomain) ValidateUpdate(old runtime.Object) error {
 	oldVSphereFailureDomain, ok := old.(*VSphereFailureDomain)
-	if !ok || !reflect.DeepEqual(r.Spec, oldVSphereFailureDomain.Spec) {
-		return field.Forbidden(field.NewPath("spec"), "VSphereFailureDomainSpec is immutable")
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a VSphereFailureDomain but got a %T", old))
 	}
-	return nil
+	allErrs := validateImmutability(vsphereFailureDomainImmutability, r, &oldVSphereFailureDomain.Spec, &r.Spec, field.NewPath("spec"))
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
@@ -42,8 +42,89 @@ type VSphereMachineSpec struct {
 	// FailureDomain is the failure domain unique identifier this Machine should be attached to, as defined in Cluster API.
 	// For this infrastructure provider, the name is equivalent to the name of the VSphereDeploymentZone.
 	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// PlacementPrecedence decides which of the VSphereMachine and its failure
+	// domain sets the folder, resource pool, datastore and networks of the VM
+	// when both of them set one. Defaults to FailureDomain.
+	// The server and datacenter of the VM always are the ones of the failure
+	// domain.
+	// +optional
+	PlacementPrecedence PlacementPrecedence `json:"placementPrecedence,omitempty"`
+
+	// NamingStrategy controls the name of the VM in vSphere, which is the
+	// name of the machine by default. The name of the guest is not changed.
+	// +optional
+	NamingStrategy *VSphereVMNamingStrategy `json:"namingStrategy,omitempty"`
 }
 
+// VSphereVMNamingStrategy controls the name of the VMs of machines in
+// vSphere, independently of the name of their Kubernetes objects.
+type VSphereVMNamingStrategy struct {
+	// Template is the Go template the name of the VM is rendered with, with
+	// the fields of VMNameTemplateData, e.g.
+	// "{{ .ClusterName }}-{{ .MachineName }}". Defaults to
+	// "{{ .MachineName }}".
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// Prefix is prepended to the rendered name.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Suffix is appended to the rendered name.
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+
+	// MaxLength is the maximum length of the name, the rendered part of the
+	// longer names being truncated. Defaults to 80, the maximum length of
+	// the name of a VM in vSphere.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=80
+	// +optional
+	MaxLength int32 `json:"maxLength,omitempty"`
+
+	// HashStrategy decides when a hash of the namespace and name of the
+	// machine is appended to the rendered part of the name, so that the
+	// names stay unique when they are truncated or when the template does
+	// not include the name of the machine, which requires Always. Defaults
+	// to OnTruncation.
+	// +optional
+	HashStrategy VMNameHashStrategy `json:"hashStrategy,omitempty"`
+}
+
+// VMNameHashStrategy decides when a hash is appended to the name of a VM.
+// +kubebuilder:validation:Enum=OnTruncation;Always;Never
+type VMNameHashStrategy string
+
+const (
+	// VMNameHashOnTruncation appends the hash to the truncated names only.
+	VMNameHashOnTruncation VMNameHashStrategy = "OnTruncation"
+
+	// VMNameHashAlways appends the hash to all the names.
+	VMNameHashAlways VMNameHashStrategy = "Always"
+
+	// VMNameHashNever never appends the hash, the names being truncated
+	// as they are.
+	VMNameHashNever VMNameHashStrategy = "Never"
+)
+
+// PlacementPrecedence is the source of the placement values of a VM which
+// are set by both its VSphereMachine and its failure domain.
+// +kubebuilder:validation:Enum=FailureDomain;Machine
+type PlacementPrecedence string
+
+const (
+	// FailureDomainPrecedence uses the values of the failure domain, the
+	// values of the VSphereMachine only being used when the failure domain
+	// does not set one.
+	FailureDomainPrecedence PlacementPrecedence = "FailureDomain"
+
+	// MachinePrecedence uses the values of the VSphereMachine, the values of
+	// the failure domain only being used when the VSphereMachine does not set
+	// one.
+	MachinePrecedence PlacementPrecedence = "Machine"
+)
+
 // VSphereMachineStatus defines the observed state of VSphereMachine
 type VSphereMachineStatus struct {
 	// Ready is true when the provider resource is ready.
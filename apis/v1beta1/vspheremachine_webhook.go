@@ -19,9 +19,7 @@ package v1beta1
 import (
 	"fmt"
 	"net"
-	"reflect"
 
-	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -65,38 +63,36 @@ func (m *VSphereMachine) ValidateCreate() error {
 		}
 	}
 
+	allErrs = append(allErrs, validateCustomizationSpec(spec.CustomizationSpec, field.NewPath("spec", "customizationSpec"))...)
+	allErrs = append(allErrs, validateLinkedCloneSpec(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateCDROMs(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTrustedPlatformModule(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateVirtualIOMMU(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateCustomVMXKeys(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTimeSync(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateResourceAllocations(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateAdditionalDisks(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateDatastoreSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateComputeSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateNetworkAddressFamilies(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateZoneTemplates(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateFolderTemplate(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateMoRefs(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateNamingStrategy(spec.NamingStrategy, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateHostName(&spec.VirtualMachineCloneSpec, sampleHostNameTemplateData, field.NewPath("spec"))...)
+
 	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
-//nolint:forcetypeassert
 func (m *VSphereMachine) ValidateUpdate(old runtime.Object) error {
-	newVSphereMachine, err := runtime.DefaultUnstructuredConverter.ToUnstructured(m)
-	if err != nil {
-		return apierrors.NewInternalError(errors.Wrap(err, "failed to convert new VSphereMachine to unstructured object"))
-	}
-
-	oldVSphereMachine, err := runtime.DefaultUnstructuredConverter.ToUnstructured(old)
-	if err != nil {
-		return apierrors.NewInternalError(errors.Wrap(err, "failed to convert old VSphereMachine to unstructured object"))
+	oldVSphereMachine, ok := old.(*VSphereMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a VSphereMachine but got a %T", old))
 	}
 
 	var allErrs field.ErrorList
 
-	newVSphereMachineSpec := newVSphereMachine["spec"].(map[string]interface{})
-	oldVSphereMachineSpec := oldVSphereMachine["spec"].(map[string]interface{})
-
-	// allow changes to providerID
-	delete(oldVSphereMachineSpec, "providerID")
-	delete(newVSphereMachineSpec, "providerID")
-
-	newVSphereMachineNetwork := newVSphereMachineSpec["network"].(map[string]interface{})
-	oldVSphereMachineNetwork := oldVSphereMachineSpec["network"].(map[string]interface{})
-
-	// allow changes to the devices..
-	delete(oldVSphereMachineNetwork, "devices")
-	delete(newVSphereMachineNetwork, "devices")
-
 	// validate that IPAddrs in updaterequest are valid.
 	spec := m.Spec
 	for i, device := range spec.Network.Devices {
@@ -107,9 +103,8 @@ func (m *VSphereMachine) ValidateUpdate(old runtime.Object) error {
 		}
 	}
 
-	if !reflect.DeepEqual(oldVSphereMachineSpec, newVSphereMachineSpec) {
-		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "cannot be modified"))
-	}
+	allErrs = append(allErrs, validateTimeSync(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateImmutability(vsphereMachineImmutability, m, &oldVSphereMachine.Spec, &m.Spec, field.NewPath("spec"))...)
 
 	return aggregateObjErrors(m.GroupVersionKind().GroupKind(), m.Name, allErrs)
 }
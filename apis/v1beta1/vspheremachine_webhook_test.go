@@ -118,6 +118,18 @@ func TestVSphereMachine_ValidateUpdate(t *testing.T) {
 			vsphereMachine:    createVSphereMachine("bar.com", &someProviderID, "", []string{"192.168.0.1/32", "192.168.0.10/32"}),
 			wantErr:           true,
 		},
+		{
+			name:              "updating server can be done with the immutability override annotation",
+			oldVSphereMachine: createVSphereMachine("foo.com", nil, "", []string{"192.168.0.1/32"}),
+			vsphereMachine:    withImmutabilityOverride(createVSphereMachine("bar.com", &someProviderID, "", []string{"192.168.0.1/32"})),
+			wantErr:           false,
+		},
+		{
+			name:              "updating tags and custom attributes can be done",
+			oldVSphereMachine: createVSphereMachine("foo.com", nil, "", []string{"192.168.0.1/32"}),
+			vsphereMachine:    withTagsAndCustomAttributes(createVSphereMachine("foo.com", nil, "", []string{"192.168.0.1/32"})),
+			wantErr:           false,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -131,6 +143,17 @@ func TestVSphereMachine_ValidateUpdate(t *testing.T) {
 	}
 }
 
+func withImmutabilityOverride(m *VSphereMachine) *VSphereMachine {
+	m.Annotations = map[string]string{ImmutabilityOverrideAnnotation: "true"}
+	return m
+}
+
+func withTagsAndCustomAttributes(m *VSphereMachine) *VSphereMachine {
+	m.Spec.TagIDs = []string{"urn:vmomi:InventoryServiceTag:a8d4c5a1-0dd8-4b1a-9bd6-9c1d0a0a0a0a:GLOBAL"}
+	m.Spec.CustomAttributes = map[string]string{"owner": "team-a"}
+	return m
+}
+
 func createVSphereMachine(server string, providerID *string, preferredAPIServerCIDR string, ips []string) *VSphereMachine {
 	VSphereMachine := &VSphereMachine{
 		Spec: VSphereMachineSpec{
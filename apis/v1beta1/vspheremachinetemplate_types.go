@@ -19,6 +19,7 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 // VSphereMachineTemplateSpec defines the desired state of VSphereMachineTemplate
@@ -26,16 +27,34 @@ type VSphereMachineTemplateSpec struct {
 	Template VSphereMachineTemplateResource `json:"template"`
 }
 
+// VSphereMachineTemplateStatus defines the observed state of VSphereMachineTemplate
+type VSphereMachineTemplateStatus struct {
+	// Conditions defines the current state of the template, as validated
+	// by the VSphereClusters using it.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=vspheremachinetemplates,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
+// +kubebuilder:subresource:status
 
 // VSphereMachineTemplate is the Schema for the vspheremachinetemplates API
 type VSphereMachineTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec VSphereMachineTemplateSpec `json:"spec,omitempty"`
+	Spec   VSphereMachineTemplateSpec   `json:"spec,omitempty"`
+	Status VSphereMachineTemplateStatus `json:"status,omitempty"`
+}
+
+func (m *VSphereMachineTemplate) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+func (m *VSphereMachineTemplate) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
 }
 
 // +kubebuilder:object:root=true
@@ -19,7 +19,6 @@ package v1beta1
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"regexp"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,8 +30,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-const machineTemplateImmutableMsg = "VSphereMachineTemplate spec.template.spec field is immutable. Please create a new resource instead."
-
 func (v *VSphereMachineTemplateWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&VSphereMachineTemplate{}).
@@ -75,6 +72,23 @@ func (v *VSphereMachineTemplateWebhook) ValidateCreate(_ context.Context, raw ru
 			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "template", "spec", "hardwareVersion"), spec.HardwareVersion, "should be a valid VM hardware version, example vmx-17"))
 		}
 	}
+	allErrs = append(allErrs, validateCustomizationSpec(spec.CustomizationSpec, field.NewPath("spec", "template", "spec", "customizationSpec"))...)
+	allErrs = append(allErrs, validateLinkedCloneSpec(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateCDROMs(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateTrustedPlatformModule(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateVirtualIOMMU(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateCustomVMXKeys(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateTimeSync(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateResourceAllocations(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateAdditionalDisks(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateDatastoreSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateComputeSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateNetworkAddressFamilies(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateZoneTemplates(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateFolderTemplate(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateMoRefs(&spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateNamingStrategy(spec.NamingStrategy, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateHostName(&spec.VirtualMachineCloneSpec, sampleHostNameTemplateData, field.NewPath("spec", "template", "spec"))...)
 	return aggregateObjErrors(obj.GroupVersionKind().GroupKind(), obj.Name, allErrs)
 }
 
@@ -95,9 +109,8 @@ func (v *VSphereMachineTemplateWebhook) ValidateUpdate(ctx context.Context, oldR
 	}
 
 	var allErrs field.ErrorList
-	if !topology.ShouldSkipImmutabilityChecks(req, newObj) &&
-		!reflect.DeepEqual(newObj.Spec.Template.Spec, oldObj.Spec.Template.Spec) {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "template", "spec"), newObj, machineTemplateImmutableMsg))
+	if !topology.ShouldSkipImmutabilityChecks(req, newObj) {
+		allErrs = append(allErrs, validateImmutability(vsphereMachineTemplateImmutability, newObj, &oldObj.Spec.Template.Spec, &newObj.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))...)
 	}
 	return aggregateObjErrors(newObj.GroupVersionKind().GroupKind(), newObj.Name, allErrs)
 }
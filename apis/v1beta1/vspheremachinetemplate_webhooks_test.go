@@ -125,6 +125,20 @@ func TestVSphereMachineTemplate_ValidateUpdate(t *testing.T) {
 			req:               &admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: pointer.Bool(false)}},
 			wantErr:           false, // explicitly calling out that this is a valid scenario.
 		},
+		{
+			name:              "template metadata can be updated",
+			oldVSphereMachine: createVSphereMachineTemplate("foo.com", "vmx-16", nil, "", []string{"192.168.0.1/32"}),
+			vsphereMachine:    withTemplateLabels(createVSphereMachineTemplate("foo.com", "vmx-16", nil, "", []string{"192.168.0.1/32"}), map[string]string{"foo": "bar"}),
+			req:               &admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: pointer.Bool(false)}},
+			wantErr:           false,
+		},
+		{
+			name:              "server can be updated with the override annotation",
+			oldVSphereMachine: createVSphereMachineTemplate("foo.com", "", nil, "", []string{"192.168.0.1/32"}),
+			vsphereMachine:    withOverrideAnnotation(createVSphereMachineTemplate("baz.com", "", nil, "", []string{"192.168.0.1/32"})),
+			req:               &admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: pointer.Bool(false)}},
+			wantErr:           false,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -168,3 +182,13 @@ func createVSphereMachineTemplate(server, hwVersion string, providerID *string,
 	}
 	return vsphereMachineTemplate
 }
+
+func withTemplateLabels(t *VSphereMachineTemplate, labels map[string]string) *VSphereMachineTemplate {
+	t.Spec.Template.ObjectMeta.Labels = labels
+	return t
+}
+
+func withOverrideAnnotation(t *VSphereMachineTemplate) *VSphereMachineTemplate {
+	t.Annotations = map[string]string{ImmutabilityOverrideAnnotation: "true"}
+	return t
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VSphereSettingsSpec defines the defaults of the VSphereClusters of a
+// namespace.
+type VSphereSettingsSpec struct {
+	// Endpoint is the vCenter the VSphereClusters of the namespace default to
+	// when they set neither a server nor an endpoint.
+	// +optional
+	Endpoint *VCenterEndpoint `json:"endpoint,omitempty"`
+
+	// CABundleRef references the certificate authorities of the vCenter of
+	// Endpoint, in the namespace, which the VSphereClusters defaulting to the
+	// Endpoint also default to.
+	// +optional
+	CABundleRef *CABundleReference `json:"caBundleRef,omitempty"`
+
+	// IdentityRef is the identity the VSphereClusters of the namespace
+	// default to when they do not set one.
+	// +optional
+	IdentityRef *VSphereIdentityReference `json:"identityRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=vspheresettings,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// VSphereSettings defines the vCenter and identity the VSphereClusters of its
+// namespace default to when they are created, so that the tenants of the
+// namespace do not have to know them. A namespace has at most one
+// VSphereSettings.
+type VSphereSettings struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VSphereSettingsSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VSphereSettingsList contains a list of VSphereSettings.
+type VSphereSettingsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereSettings `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VSphereSettings{}, &VSphereSettingsList{})
+}
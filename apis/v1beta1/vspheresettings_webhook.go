@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const clusterSettingsWebhookPath = "/mutate-infrastructure-cluster-x-k8s-io-v1beta1-cluster-settings"
+
+// ApplyTo sets the vCenter and identity of a VSphereCluster spec from the
+// settings when the spec does not set them. The CA bundle is only set
+// together with the vCenter.
+func (s *VSphereSettingsSpec) ApplyTo(spec *VSphereClusterSpec) {
+	if s.Endpoint != nil && spec.Server == "" && spec.Endpoint == nil {
+		spec.Endpoint = s.Endpoint.DeepCopy()
+		if spec.CABundleRef == nil {
+			spec.CABundleRef = s.CABundleRef.DeepCopy()
+		}
+		spec.defaultEndpoint()
+	}
+	if s.IdentityRef != nil && spec.IdentityRef == nil {
+		spec.IdentityRef = s.IdentityRef.DeepCopy()
+	}
+}
+
+// GetVSphereSettings returns the VSphereSettings of a namespace, or nil if it
+// has none.
+func GetVSphereSettings(ctx context.Context, c client.Reader, namespace string) (*VSphereSettings, error) {
+	settings := &VSphereSettingsList{}
+	if err := c.List(ctx, settings, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the VSphereSettings of namespace %s", namespace)
+	}
+	switch len(settings.Items) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &settings.Items[0], nil
+	default:
+		return nil, errors.Errorf("namespace %s has %d VSphereSettings, at most one is allowed", namespace, len(settings.Items))
+	}
+}
+
+// +kubebuilder:webhook:verbs=create,path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-cluster-settings,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters,versions=v1beta1,name=default.clustersettings.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// ClusterSettingsWebhook is an admission webhook that defaults the vCenter and
+// identity of the VSphereClusters to the VSphereSettings of their namespace
+// when they are created.
+// +kubebuilder:object:generate=false
+type ClusterSettingsWebhook struct {
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ClusterSettingsWebhook{}
+
+func (w *ClusterSettingsWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	mgr.GetWebhookServer().Register(clusterSettingsWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *ClusterSettingsWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle defaults the vCenter and identity of the VSphereCluster.
+func (w *ClusterSettingsWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := &VSphereCluster{}
+	if err := w.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	settings, err := GetVSphereSettings(ctx, w.client, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if settings == nil {
+		return admission.Allowed("")
+	}
+	settings.Spec.ApplyTo(&obj.Spec)
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
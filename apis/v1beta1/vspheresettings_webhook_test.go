@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVSphereSettings_ApplyTo(t *testing.T) {
+	settings := &VSphereSettingsSpec{
+		Endpoint:    &VCenterEndpoint{Host: "vcenter.example.com", Thumbprint: "AA:BB"},
+		CABundleRef: &CABundleReference{Kind: ConfigMapCABundleKind, Name: "vcenter-ca"},
+		IdentityRef: &VSphereIdentityReference{Kind: VSphereClusterIdentityKind, Name: "tenant-a"},
+	}
+
+	t.Run("unset vCenter and identity", func(t *testing.T) {
+		g := NewWithT(t)
+		spec := &VSphereClusterSpec{}
+		settings.ApplyTo(spec)
+		g.Expect(spec.Server).To(Equal("vcenter.example.com"))
+		g.Expect(spec.Thumbprint).To(Equal("AA:BB"))
+		g.Expect(spec.Endpoint).To(Equal(&VCenterEndpoint{Host: "vcenter.example.com", Port: DefaultVCenterPort, Path: DefaultVCenterPath, Thumbprint: "AA:BB"}))
+		g.Expect(spec.CABundleRef).To(Equal(settings.CABundleRef))
+		g.Expect(spec.IdentityRef).To(Equal(settings.IdentityRef))
+
+		// The settings are not shared with the spec.
+		spec.IdentityRef.Name = "other"
+		g.Expect(settings.IdentityRef.Name).To(Equal("tenant-a"))
+	})
+
+	t.Run("set vCenter and identity", func(t *testing.T) {
+		g := NewWithT(t)
+		spec := &VSphereClusterSpec{
+			Server:      "other.example.com",
+			IdentityRef: &VSphereIdentityReference{Kind: SecretKind, Name: "credentials"},
+		}
+		settings.ApplyTo(spec)
+		g.Expect(spec.Server).To(Equal("other.example.com"))
+		g.Expect(spec.Endpoint).To(BeNil())
+		g.Expect(spec.CABundleRef).To(BeNil())
+		g.Expect(spec.IdentityRef.Name).To(Equal("credentials"))
+	})
+}
+
+func TestGetVSphereSettings(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+
+	newSettings := func(namespace, name string) *VSphereSettings {
+		return &VSphereSettings{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newSettings("tenant-a", "settings"),
+		newSettings("tenant-b", "settings"),
+		newSettings("tenant-b", "other"),
+	).Build()
+
+	g := NewWithT(t)
+	settings, err := GetVSphereSettings(context.Background(), c, "tenant-a")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(settings.Name).To(Equal("settings"))
+
+	settings, err = GetVSphereSettings(context.Background(), c, "tenant-c")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(settings).To(BeNil())
+
+	_, err = GetVSphereSettings(context.Background(), c, "tenant-b")
+	g.Expect(err).To(MatchError(ContainSubstring("at most one is allowed")))
+}
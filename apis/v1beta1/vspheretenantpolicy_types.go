@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VSphereTenantPolicySpec defines the part of the vSphere inventory the
+// clusters of a set of namespaces are allowed to use.
+type VSphereTenantPolicySpec struct {
+	// Namespaces selects the namespaces the policy applies to.
+	Namespaces AllowedNamespaces `json:"namespaces"`
+
+	// Datacenters is the list of datacenters the VMs may be created in.
+	// If empty, any datacenter is allowed.
+	// +optional
+	Datacenters []string `json:"datacenters,omitempty"`
+
+	// ResourcePools is the list of resource pools the VMs may be created in.
+	// An entry also allows the resource pools nested under it when it is an
+	// inventory path. If empty, any resource pool is allowed.
+	// +optional
+	ResourcePools []string `json:"resourcePools,omitempty"`
+
+	// Folders is the list of folders the VMs may be created in.
+	// An entry also allows the folders nested under it when it is an
+	// inventory path. If empty, any folder is allowed.
+	// +optional
+	Folders []string `json:"folders,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=vspheretenantpolicies,scope=Cluster,categories=cluster-api
+// +kubebuilder:storageversion
+
+// VSphereTenantPolicy restricts the vSphere inventory that the clusters of a
+// set of namespaces may target. It is enforced when the TenantIsolation
+// feature gate is enabled, in which case machines in namespaces that are not
+// selected by any policy are rejected.
+type VSphereTenantPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VSphereTenantPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VSphereTenantPolicyList contains a list of VSphereTenantPolicy.
+type VSphereTenantPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereTenantPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VSphereTenantPolicy{}, &VSphereTenantPolicyList{})
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const tenantIsolationWebhookPath = "/validate-infrastructure-cluster-x-k8s-io-v1beta1-tenant-isolation"
+
+// AppliesTo returns true if the policy selects the given namespace.
+func (p *VSphereTenantPolicy) AppliesTo(ns *corev1.Namespace) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&p.Spec.Namespaces.Selector)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid namespace selector in VSphereTenantPolicy %s", p.Name)
+	}
+	return selector.Matches(labels.Set(ns.GetLabels())), nil
+}
+
+// Validate returns the parts of the clone spec that target vSphere inventory
+// not allowed by the policy. Unset fields are only accepted if allowUnset is
+// true, which is the case for objects whose final placement is resolved
+// later, e.g. from a failure domain.
+func (p *VSphereTenantPolicy) Validate(spec *VirtualMachineCloneSpec, fldPath *field.Path, allowUnset bool) field.ErrorList {
+	var allErrs field.ErrorList
+	if err := validateTenantInventory(spec.Datacenter, p.Spec.Datacenters, false, allowUnset, fldPath.Child("datacenter")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateTenantInventory(spec.ResourcePool, p.Spec.ResourcePools, true, allowUnset, fldPath.Child("resourcePool")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateTenantInventory(spec.Folder, p.Spec.Folders, true, allowUnset, fldPath.Child("folder")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	return allErrs
+}
+
+func validateTenantInventory(value string, allowed []string, nested, allowUnset bool, fldPath *field.Path) *field.Error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	if value == "" {
+		if allowUnset {
+			return nil
+		}
+		return field.Required(fldPath, "must be set explicitly, as the tenant policy restricts it")
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+		if nested && strings.HasPrefix(a, "/") && strings.HasPrefix(value, strings.TrimSuffix(a, "/")+"/") {
+			return nil
+		}
+	}
+	return field.NotSupported(fldPath, value, allowed)
+}
+
+// ValidateTenancy validates the clone spec of an object in the given
+// namespace against the VSphereTenantPolicies. The spec is allowed if at
+// least one of the policies selecting the namespace allows it. Namespaces
+// that are not selected by any policy are not allowed to create VMs.
+func ValidateTenancy(ctx context.Context, c client.Reader, namespace string, spec *VirtualMachineCloneSpec, fldPath *field.Path, allowUnset bool) (field.ErrorList, error) {
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, errors.Wrapf(err, "failed to get namespace %s", namespace)
+	}
+	policies := &VSphereTenantPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil, errors.Wrap(err, "failed to list VSphereTenantPolicies")
+	}
+
+	var (
+		applied bool
+		allErrs field.ErrorList
+	)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		ok, err := policy.AppliesTo(ns)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		applied = true
+		errs := policy.Validate(spec, fldPath, allowUnset)
+		if len(errs) == 0 {
+			return nil, nil
+		}
+		allErrs = append(allErrs, errs...)
+	}
+	if !applied {
+		return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("no VSphereTenantPolicy applies to namespace %s", namespace))}, nil
+	}
+	return allErrs, nil
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-tenant-isolation,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vspheremachines;vspheremachinetemplates;vspherevms,versions=v1beta1,name=validation.tenantisolation.infrastructure.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// TenantIsolationWebhook is an admission webhook that rejects the objects
+// whose clone spec targets vSphere inventory their namespace is not allowed
+// to use by a VSphereTenantPolicy.
+// +kubebuilder:object:generate=false
+type TenantIsolationWebhook struct {
+	// Enabled reflects the TenantIsolation feature gate. All the requests are
+	// allowed when it is false.
+	Enabled bool
+
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &TenantIsolationWebhook{}
+
+func (w *TenantIsolationWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	mgr.GetWebhookServer().Register(tenantIsolationWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *TenantIsolationWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle validates the clone spec of the object against the tenant policies.
+func (w *TenantIsolationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if !w.Enabled {
+		return admission.Allowed("")
+	}
+
+	var (
+		spec    *VirtualMachineCloneSpec
+		fldPath *field.Path
+		gk      = schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+	)
+	switch req.Kind.Kind {
+	case "VSphereMachine":
+		obj := &VSphereMachine{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		spec, fldPath = &obj.Spec.VirtualMachineCloneSpec, field.NewPath("spec")
+	case "VSphereMachineTemplate":
+		obj := &VSphereMachineTemplate{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		spec, fldPath = &obj.Spec.Template.Spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec")
+	case "VSphereVM":
+		obj := &VSphereVM{}
+		if err := w.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		spec, fldPath = &obj.Spec.VirtualMachineCloneSpec, field.NewPath("spec")
+	default:
+		return admission.Allowed("")
+	}
+
+	// Machines and machine templates may get their placement from a failure
+	// domain, so unset fields are only rejected on the resulting VSphereVMs.
+	allowUnset := req.Kind.Kind != "VSphereVM"
+	allErrs, err := ValidateTenancy(ctx, w.client, req.Namespace, spec, fldPath, allowUnset)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(allErrs) > 0 {
+		return admission.Denied(apierrors.NewInvalid(gk, req.Name, allErrs).Error())
+	}
+	return admission.Allowed("")
+}
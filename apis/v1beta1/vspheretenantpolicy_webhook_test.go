@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVSphereTenantPolicy_Validate(t *testing.T) {
+	policy := &VSphereTenantPolicy{
+		Spec: VSphereTenantPolicySpec{
+			Datacenters:   []string{"dc0"},
+			ResourcePools: []string{"/dc0/host/cluster0/Resources/tenant-a"},
+			Folders:       []string{"tenant-a"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		spec       VirtualMachineCloneSpec
+		allowUnset bool
+		wantErrs   int
+	}{
+		{
+			name: "allowed inventory",
+			spec: VirtualMachineCloneSpec{Datacenter: "dc0", ResourcePool: "/dc0/host/cluster0/Resources/tenant-a", Folder: "tenant-a"},
+		},
+		{
+			name: "nested resource pool",
+			spec: VirtualMachineCloneSpec{Datacenter: "dc0", ResourcePool: "/dc0/host/cluster0/Resources/tenant-a/child", Folder: "tenant-a"},
+		},
+		{
+			name:     "sibling resource pool sharing a prefix",
+			spec:     VirtualMachineCloneSpec{Datacenter: "dc0", ResourcePool: "/dc0/host/cluster0/Resources/tenant-ab", Folder: "tenant-a"},
+			wantErrs: 1,
+		},
+		{
+			name:     "disallowed datacenter and folder",
+			spec:     VirtualMachineCloneSpec{Datacenter: "dc1", ResourcePool: "/dc0/host/cluster0/Resources/tenant-a", Folder: "tenant-b"},
+			wantErrs: 2,
+		},
+		{
+			name:       "unset fields allowed",
+			spec:       VirtualMachineCloneSpec{},
+			allowUnset: true,
+		},
+		{
+			name:     "unset fields not allowed",
+			spec:     VirtualMachineCloneSpec{},
+			wantErrs: 3,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := policy.Validate(&tc.spec, field.NewPath("spec"), tc.allowUnset)
+			g.Expect(errs).To(HaveLen(tc.wantErrs))
+		})
+	}
+}
+
+func TestValidateTenancy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"tenant": "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"tenant": "b"}}},
+	}
+	policy := &VSphereTenantPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"},
+		Spec: VSphereTenantPolicySpec{
+			Namespaces: AllowedNamespaces{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+			},
+			Datacenters: []string{"dc0"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespaces[0], namespaces[1], policy).Build()
+
+	tests := []struct {
+		name       string
+		namespace  string
+		datacenter string
+		wantErr    bool
+	}{
+		{name: "allowed by the policy", namespace: "tenant-a", datacenter: "dc0"},
+		{name: "rejected by the policy", namespace: "tenant-a", datacenter: "dc1", wantErr: true},
+		{name: "no policy applies", namespace: "tenant-b", datacenter: "dc0", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := &VirtualMachineCloneSpec{Datacenter: tc.datacenter}
+			errs, err := ValidateTenancy(context.Background(), c, tc.namespace, spec, field.NewPath("spec"), false)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
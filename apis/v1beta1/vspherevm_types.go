@@ -32,8 +32,31 @@ const (
 	// IPClaimFinalizer allows the reconciler to prevent deletion of an
 	// IPAddressClaim that is in use.
 	IPAddressClaimFinalizer = "vspherevm.infrastructure.cluster.x-k8s.io/ip-claim-protection"
+
+	// CloneSpecSummaryAnnotation is set on a VSphereVM to the JSON summary of
+	// the clone spec sent to vCenter to create its VM, i.e. its placement,
+	// devices, disks and extraConfig keys. The values of the extraConfig keys
+	// are left out, as they contain the bootstrap data.
+	CloneSpecSummaryAnnotation = "vspherevm.infrastructure.cluster.x-k8s.io/clone-spec"
+
+	// NoCloudISOAnnotation is set on a VSphereVM whose bootstrap data exceeds
+	// the size of the guestinfo keys, and is delivered through a NoCloud ISO
+	// image attached to its VM instead. Its value is the datastore path of the
+	// ISO image, once uploaded.
+	NoCloudISOAnnotation = "vspherevm.infrastructure.cluster.x-k8s.io/nocloud-iso"
+
+	// NodeCordonedForResizeAnnotation is set on the node of a VSphereVM which
+	// is cordoned while its VM is powered off to be resized, so that only the
+	// nodes cordoned for the resize are uncordoned once it is done.
+	NodeCordonedForResizeAnnotation = "vspherevm.infrastructure.cluster.x-k8s.io/cordoned-for-resize"
 )
 
+// NodeHostHealthyCondition is the condition set on the node of a VSphereVM
+// from the HostHealthyCondition of the VSphereVM, so that MachineHealthChecks
+// can remediate the machines whose ESXi host failed with an unhealthy
+// condition on it.
+const NodeHostHealthyCondition corev1.NodeConditionType = "VSphereHostHealthy"
+
 // VSphereVMSpec defines the desired state of VSphereVM.
 type VSphereVMSpec struct {
 	VirtualMachineCloneSpec `json:",inline"`
@@ -51,6 +74,84 @@ type VSphereVMSpec struct {
 	// this CRD as unstructured data.
 	// +optional
 	BiosUUID string `json:"biosUUID,omitempty"`
+
+	// Proxy is the HTTP proxy configured in the guest through the bootstrap
+	// data of the VM, set from the VSphereCluster when the VSphereVM is
+	// created.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// NTPServers are the NTP servers configured in the guest through the
+	// bootstrap data of the VM, unless it sets NTP servers, set from the
+	// VSphereCluster when the VSphereVM is created.
+	// +optional
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// VMName is the name of the VM in vSphere, set from the NamingStrategy
+	// of the VSphereMachine when the VSphereVM is created. Defaults to the
+	// name of the VSphereVM.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+}
+
+// VSphereVMTopology describes the vSphere inventory objects a VM is placed in.
+type VSphereVMTopology struct {
+	// Datacenter is the name of the datacenter of the VM.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// ComputeCluster is the name of the compute cluster of the VM, if its
+	// host is part of one.
+	// +optional
+	ComputeCluster string `json:"computeCluster,omitempty"`
+
+	// ResourcePool is the name of the resource pool of the VM.
+	// +optional
+	ResourcePool string `json:"resourcePool,omitempty"`
+
+	// Datastore is the name of the datastore holding the configuration of
+	// the VM.
+	// +optional
+	Datastore string `json:"datastore,omitempty"`
+}
+
+// VSphereVMPlacement describes the vSphere inventory objects resolved for a
+// VM when it was cloned.
+type VSphereVMPlacement struct {
+	// Folder is the folder the VM was cloned in.
+	// +optional
+	Folder *InventoryObject `json:"folder,omitempty"`
+
+	// ResourcePool is the resource pool the VM was cloned in.
+	// +optional
+	ResourcePool *InventoryObject `json:"resourcePool,omitempty"`
+
+	// Datastore is the datastore the VM was cloned on.
+	// +optional
+	Datastore *InventoryObject `json:"datastore,omitempty"`
+
+	// Networks are the networks of the network devices of the VM, in the
+	// order of the devices of its spec.
+	// +optional
+	Networks []InventoryObject `json:"networks,omitempty"`
+
+	// Host is the host on which vCenter placed the VM when it was cloned. It
+	// is recorded once the clone completes, and is not updated when the VM is
+	// migrated.
+	// +optional
+	Host *InventoryObject `json:"host,omitempty"`
+}
+
+// InventoryObject identifies an object of the vSphere inventory.
+type InventoryObject struct {
+	// Ref is the managed object reference of the object, e.g.
+	// Datastore:datastore-12.
+	Ref string `json:"ref"`
+
+	// Path is the inventory path of the object, e.g.
+	// /dc0/datastore/datastore0.
+	// +optional
+	Path string `json:"path,omitempty"`
 }
 
 // VSphereVMStatus defines the observed state of VSphereVM
@@ -60,6 +161,16 @@ type VSphereVMStatus struct {
 	// +optional
 	Host string `json:"host,omitempty"`
 
+	// Topology describes the vSphere inventory objects the VM currently is
+	// placed in, which change when it is migrated.
+	// +optional
+	Topology *VSphereVMTopology `json:"topology,omitempty"`
+
+	// Placement describes the vSphere inventory objects resolved for the VM
+	// when it was cloned, unlike Topology which follows its migrations.
+	// +optional
+	Placement *VSphereVMPlacement `json:"placement,omitempty"`
+
 	// Ready is true when the provider resource is ready.
 	// This field is required at runtime for other controllers that read
 	// this CRD as unstructured data.
@@ -94,6 +205,21 @@ type VSphereVMStatus struct {
 	// +optional
 	TaskRef string `json:"taskRef,omitempty"`
 
+	// Task describes the in-flight task referenced by TaskRef. It is cleared
+	// once the task completes.
+	// +optional
+	Task *TaskStatus `json:"task,omitempty"`
+
+	// TaskProgress is the completion percentage of the in-flight task
+	// referenced by TaskRef, if vCenter reports it.
+	// +optional
+	TaskProgress *int32 `json:"taskProgress,omitempty"`
+
+	// CloneAttempts is the number of clone tasks started for the machine
+	// since its last successful clone.
+	// +optional
+	CloneAttempts int32 `json:"cloneAttempts,omitempty"`
+
 	// Network returns the network status for each of the machine's configured
 	// network interfaces.
 	// +optional
@@ -129,6 +255,12 @@ type VSphereVMStatus struct {
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
 
+	// FailureDetails is the fault of the last failed vCenter task of the
+	// machine, e.g. its clone or reconfigure task, as reported by vCenter.
+	// It is cleared once a task performing the same operation succeeds.
+	// +optional
+	FailureDetails *TaskFailureDetails `json:"failureDetails,omitempty"`
+
 	// Conditions defines current service state of the VSphereVM.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
@@ -139,6 +271,44 @@ type VSphereVMStatus struct {
 	// the VMs on separate hosts.
 	// +optional
 	ModuleUUID *string `json:"moduleUUID,omitempty"`
+
+	// TemplateInstanceUUID is the instance UUID of the template the VM was
+	// cloned from. It is resolved when the clone is started and, unlike the
+	// template's name or inventory path, is not affected by the template
+	// being renamed or moved.
+	// +optional
+	TemplateInstanceUUID string `json:"templateInstanceUUID,omitempty"`
+
+	// ISOImages are the datastore paths of the ISO images inserted in the
+	// CD-ROM drives of the VM when it was cloned, in the order of the CDROMs
+	// of its spec. The paths of the content library items are resolved when
+	// the clone is started.
+	// +optional
+	ISOImages []string `json:"isoImages,omitempty"`
+
+	// Datastore is the name of the datastore selected by the
+	// DatastoreSelector of the spec when the VM was cloned.
+	// +optional
+	Datastore string `json:"datastore,omitempty"`
+
+	// ComputeCluster is the name of the compute cluster selected by the
+	// ComputeSelector of the spec when the VM was cloned.
+	// +optional
+	ComputeCluster string `json:"computeCluster,omitempty"`
+
+	// IncompatibleHosts are the names of the hosts of the compute cluster
+	// which do not carry all the standard port groups of the network devices
+	// of the VM when it was cloned. The placement of the VM was restricted to
+	// the other hosts.
+	// +optional
+	IncompatibleHosts []string `json:"incompatibleHosts,omitempty"`
+
+	// RetainedDisks are the datastore paths of the additional disks of the VM
+	// retained on delete. They are set when the VSphereVM is deleted, before
+	// the disks are detached from the VM, and reported in the status of the
+	// VSphereCluster once the VM is destroyed.
+	// +optional
+	RetainedDisks []string `json:"retainedDisks,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -19,10 +19,8 @@ package v1beta1
 import (
 	"fmt"
 	"net"
-	"reflect"
 	"strings"
 
-	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -68,53 +66,42 @@ func (r *VSphereVM) ValidateCreate() error {
 		}
 	}
 
-	if r.Spec.OS == Windows && len(r.Name) > 15 {
+	if r.Spec.OS == Windows && spec.HostNameTemplate == "" && len(r.Name) > 15 {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("name"), r.Name, "name has to be less than 16 characters for Windows VM"))
 	}
+	if r.Spec.OS == Windows && spec.HostNameTemplate != "" {
+		if hostName, err := r.ShortHostName(); err == nil && len(hostName) > 15 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "hostNameTemplate"), hostName, "hostname has to be less than 16 characters for Windows VM"))
+		}
+	}
+
+	allErrs = append(allErrs, validateCustomizationSpec(spec.CustomizationSpec, field.NewPath("spec", "customizationSpec"))...)
+	allErrs = append(allErrs, validateLinkedCloneSpec(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateCDROMs(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTrustedPlatformModule(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateVirtualIOMMU(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateCustomVMXKeys(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTimeSync(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateResourceAllocations(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateAdditionalDisks(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateDatastoreSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateComputeSelector(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateNetworkAddressFamilies(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateFolderTemplate(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateMoRefs(&spec.VirtualMachineCloneSpec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateHostName(&spec.VirtualMachineCloneSpec, r.hostNameTemplateData(), field.NewPath("spec"))...)
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
-//nolint:forcetypeassert
 func (r *VSphereVM) ValidateUpdate(old runtime.Object) error {
-	newVSphereVM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(r)
-	if err != nil {
-		return apierrors.NewInternalError(errors.Wrap(err, "failed to convert new VSphereVM to unstructured object"))
+	oldVSphereVM, ok := old.(*VSphereVM)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a VSphereVM but got a %T", old))
 	}
-	oldVSphereVM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(old)
-	if err != nil {
-		return apierrors.NewInternalError(errors.Wrap(err, "failed to convert old VSphereVM to unstructured object"))
-	}
-
-	var allErrs field.ErrorList
-
-	newVSphereVMSpec := newVSphereVM["spec"].(map[string]interface{})
-	oldVSphereVMSpec := oldVSphereVM["spec"].(map[string]interface{})
 
-	// allow changes to biosUUID
-	delete(oldVSphereVMSpec, "biosUUID")
-	delete(newVSphereVMSpec, "biosUUID")
-
-	// allow changes to bootstrapRef
-	delete(oldVSphereVMSpec, "bootstrapRef")
-	delete(newVSphereVMSpec, "bootstrapRef")
-
-	newVSphereVMNetwork := newVSphereVMSpec["network"].(map[string]interface{})
-	oldVSphereVMNetwork := oldVSphereVMSpec["network"].(map[string]interface{})
-
-	// allow changes to the network devices
-	delete(oldVSphereVMNetwork, "devices")
-	delete(newVSphereVMNetwork, "devices")
-
-	// allow changes to os only if the old spec has empty OS field
-	if _, ok := oldVSphereVMSpec["os"]; !ok {
-		delete(oldVSphereVMSpec, "os")
-		delete(newVSphereVMSpec, "os")
-	}
-
-	if !reflect.DeepEqual(oldVSphereVMSpec, newVSphereVMSpec) {
-		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "cannot be modified"))
-	}
+	allErrs := validateTimeSync(&r.Spec.VirtualMachineCloneSpec, field.NewPath("spec"))
+	allErrs = append(allErrs, validateImmutability(vsphereVMImmutability, r, &oldVSphereVM.Spec, &r.Spec, field.NewPath("spec"))...)
 
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
@@ -144,6 +144,27 @@ func TestVSphereVM_ValidateUpdate(t *testing.T) {
 			vSphereVM:    createVSphereVM("vsphere-vm-1-os", "foo.com", "", "", []string{"192.168.0.1/32"}, nil, Linux),
 			wantErr:      true,
 		},
+		{
+			name:         "updating the number of CPUs and the memory can be done",
+			oldVSphereVM: createVSphereVM("vsphere-vm-1", "foo.com", "", "", []string{"192.168.0.1/32"}, nil, Linux),
+			vSphereVM: func() *VSphereVM {
+				vm := createVSphereVM("vsphere-vm-1", "foo.com", "", "", []string{"192.168.0.1/32"}, nil, Linux)
+				vm.Spec.NumCPUs = 4
+				vm.Spec.MemoryMiB = 8192
+				return vm
+			}(),
+			wantErr: false,
+		},
+		{
+			name:         "updating the number of cores per socket cannot be done",
+			oldVSphereVM: createVSphereVM("vsphere-vm-1", "foo.com", "", "", []string{"192.168.0.1/32"}, nil, Linux),
+			vSphereVM: func() *VSphereVM {
+				vm := createVSphereVM("vsphere-vm-1", "foo.com", "", "", []string{"192.168.0.1/32"}, nil, Linux)
+				vm.Spec.NumCoresPerSocket = 2
+				return vm
+			}(),
+			wantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
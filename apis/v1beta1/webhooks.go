@@ -17,8 +17,16 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -33,3 +41,390 @@ func aggregateObjErrors(gk schema.GroupKind, name string, allErrs field.ErrorLis
 		allErrs,
 	)
 }
+
+// validateCustomizationSpec validates the guest OS customization of a clone
+// spec.
+func validateCustomizationSpec(spec *CustomizationSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec == nil {
+		return allErrs
+	}
+
+	set := 0
+	if spec.Name != "" {
+		set++
+	}
+	if spec.Linux != nil {
+		set++
+	}
+	if spec.Windows != nil {
+		set++
+	}
+	if set != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, "", "exactly one of name, linux and windows must be set"))
+	}
+
+	if w := spec.Windows; w != nil {
+		winPath := fldPath.Child("windows")
+		if w.Workgroup != "" && w.Domain != "" {
+			allErrs = append(allErrs, field.Forbidden(winPath.Child("workgroup"), "cannot be set together with domain"))
+		}
+		if w.Domain != "" && (w.DomainAdmin == "" || w.DomainAdminPasswordSecretRef == nil) {
+			allErrs = append(allErrs, field.Required(winPath.Child("domainAdmin"), "domainAdmin and domainAdminPasswordSecretRef are required to join a domain"))
+		}
+	}
+	return allErrs
+}
+
+// validateLinkedCloneSpec validates the linked clone snapshot settings of a
+// clone spec.
+func validateLinkedCloneSpec(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.LinkedClone == nil {
+		return allErrs
+	}
+
+	lcPath := fldPath.Child("linkedClone")
+	if spec.CloneMode == FullClone {
+		allErrs = append(allErrs, field.Forbidden(lcPath, "cannot be set when cloneMode is fullClone"))
+	}
+	if spec.LinkedClone.MaxSnapshotDepth < 0 {
+		allErrs = append(allErrs, field.Invalid(lcPath.Child("maxSnapshotDepth"), spec.LinkedClone.MaxSnapshotDepth, "must be greater than or equal to 0"))
+	}
+	switch spec.LinkedClone.FallbackPolicy {
+	case "", LinkedCloneFallbackFullClone, LinkedCloneFallbackFail:
+	default:
+		allErrs = append(allErrs, field.NotSupported(lcPath.Child("fallbackPolicy"), spec.LinkedClone.FallbackPolicy,
+			[]string{string(LinkedCloneFallbackFullClone), string(LinkedCloneFallbackFail)}))
+	}
+	return allErrs
+}
+
+// validateZoneTemplates validates the per-zone values of a clone spec.
+func validateZoneTemplates(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if _, err := renderZoneTemplate(spec.Datastore, &ZoneTemplateData{}); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("datastore"), spec.Datastore, err.Error()))
+	}
+	for i, device := range spec.Network.Devices {
+		if _, err := renderZoneTemplate(device.NetworkName, &ZoneTemplateData{}); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("network", "devices").Index(i).Child("networkName"), device.NetworkName, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateFolderTemplate validates the folder of a clone spec, when it is a
+// template.
+func validateFolderTemplate(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	if _, err := renderFolderTemplate(spec.Folder, &FolderTemplateData{}); err != nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("folder"), spec.Folder, err.Error())}
+	}
+	return nil
+}
+
+// validateMoRefs validates the vSphere objects of a clone spec given by
+// managed object ID.
+func validateMoRefs(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	validate := func(fldPath *field.Path, name string) {
+		if !IsMoRef(name) {
+			return
+		}
+		if _, _, err := ParseMoRef(name); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, name, err.Error()))
+		}
+	}
+	validate(fldPath.Child("template"), spec.Template)
+	validate(fldPath.Child("folder"), spec.Folder)
+	validate(fldPath.Child("datastore"), spec.Datastore)
+	validate(fldPath.Child("resourcePool"), spec.ResourcePool)
+	for i, device := range spec.Network.Devices {
+		validate(fldPath.Child("network", "devices").Index(i).Child("networkName"), device.NetworkName)
+	}
+	return allErrs
+}
+
+// validateNamingStrategy validates the naming strategy of the VMs of a
+// VSphereMachine. The strategy has to name the VMs of different machines
+// differently, so that a template not including the name of the machine
+// requires the Always hash strategy.
+func validateNamingStrategy(strategy *VSphereVMNamingStrategy, fldPath *field.Path) field.ErrorList {
+	if strategy == nil {
+		return nil
+	}
+	name, err := strategy.VMName(&VMNameTemplateData{MachineName: "machine-a"})
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("namingStrategy"), strategy, err.Error())}
+	}
+	if other, _ := strategy.VMName(&VMNameTemplateData{MachineName: "machine-b"}); other == name {
+		return field.ErrorList{field.Invalid(fldPath.Child("namingStrategy", "hashStrategy"), strategy.HashStrategy,
+			"the template does not include the name of the machine, so that the hash has to be appended to all the names with the Always hash strategy")}
+	}
+	return nil
+}
+
+// sampleHostNameTemplateData is the data the hostname templates are validated
+// with, before the VSphereVMs of the machines exist.
+var sampleHostNameTemplateData = &HostNameTemplateData{
+	Name:              "machine-0",
+	Namespace:         "default",
+	ClusterName:       "cluster",
+	MachineDeployment: "md-0",
+}
+
+// validateHostName validates the hostname template and domain of a clone
+// spec, rendering the hostname with the given data.
+func validateHostName(spec *VirtualMachineCloneSpec, data *HostNameTemplateData, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.HostNameDomain != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.HostNameDomain) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostNameDomain"), spec.HostNameDomain, msg))
+		}
+	}
+	if len(allErrs) == 0 && (spec.HostNameTemplate != "" || spec.HostNameDomain != "") {
+		if _, err := renderHostName(spec, data); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostNameTemplate"), spec.HostNameTemplate, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateCDROMs validates the ISO images inserted in the CD-ROM drives of a
+// clone spec.
+func validateCDROMs(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, cdrom := range spec.CDROMs {
+		cdromPath := fldPath.Child("cdroms").Index(i)
+		switch {
+		case (cdrom.ISOPath == "") == (cdrom.ContentLibraryItem == ""):
+			allErrs = append(allErrs, field.Invalid(cdromPath, "", "exactly one of isoPath and contentLibraryItem must be set"))
+		case cdrom.ISOPath != "" && !strings.HasPrefix(cdrom.ISOPath, "["):
+			allErrs = append(allErrs, field.Invalid(cdromPath.Child("isoPath"), cdrom.ISOPath, "must be a datastore path, e.g. [datastore1] iso/drivers.iso"))
+		case cdrom.ContentLibraryItem != "":
+			if library, item, ok := strings.Cut(cdrom.ContentLibraryItem, "/"); !ok || library == "" || item == "" || strings.Contains(item, "/") {
+				allErrs = append(allErrs, field.Invalid(cdromPath.Child("contentLibraryItem"), cdrom.ContentLibraryItem, "must be <library name>/<item name>"))
+			}
+		}
+	}
+	return allErrs
+}
+
+const (
+	// minTrustedPlatformModuleHardwareVersion is the first hardware version
+	// supporting virtual TPM devices.
+	minTrustedPlatformModuleHardwareVersion = 14
+
+	// minVirtualIOMMUHardwareVersion is the first hardware version supporting
+	// the virtual IOMMU.
+	minVirtualIOMMUHardwareVersion = 14
+)
+
+// validateTrustedPlatformModule validates the hardware version of a clone spec
+// adding a virtual TPM device, when it is set.
+func validateTrustedPlatformModule(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	if !spec.TrustedPlatformModule {
+		return nil
+	}
+	return validateMinHardwareVersion(spec, minTrustedPlatformModuleHardwareVersion, "trustedPlatformModule", fldPath)
+}
+
+// validateVirtualIOMMU validates the hardware version of a clone spec exposing
+// a virtual IOMMU, when it is set.
+func validateVirtualIOMMU(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	if !spec.VirtualIOMMU {
+		return nil
+	}
+	return validateMinHardwareVersion(spec, minVirtualIOMMUHardwareVersion, "virtualIOMMU", fldPath)
+}
+
+// vmxKeyPattern is the pattern of the VMX keys, e.g. scsi0:0.ctkEnabled.
+var vmxKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// reservedVMXKeyPrefixes are the prefixes of the extraConfig keys managed by
+// CAPV, i.e. the keys of the bootstrap data and metadata of the guests, of
+// their OVF environment, of the reports of the guest agent and of the NoCloud
+// ISO images, which cannot be set by the custom VMX keys.
+var reservedVMXKeyPrefixes = []string{
+	"guestinfo.userdata",
+	"guestinfo.metadata",
+	"guestinfo.ignition.",
+	"guestinfo.ovfenv",
+	"guestinfo.capv.",
+	"capv.",
+}
+
+// fieldVMXKeys are the VMX keys set through the fields of the clone specs,
+// which cannot be set by the custom VMX keys, by field.
+var fieldVMXKeys = map[string]string{
+	"disk.enableuuid":                "",
+	"vhv.enable":                     "hardwareVirtualization",
+	"vvtd.enable":                    "virtualIOMMU",
+	"tools.synctime":                 "timeSync",
+	"time.synchronize.tools.startup": "timeSync",
+}
+
+// validateCustomVMXKeys validates that the custom VMX keys of a clone spec are
+// valid VMX keys which do not conflict with the keys managed by CAPV. The VMX
+// keys are case-insensitive.
+func validateCustomVMXKeys(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	keys := make([]string, 0, len(spec.CustomVMXKeys))
+	for key := range spec.CustomVMXKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var allErrs field.ErrorList
+	for _, key := range keys {
+		keyPath := fldPath.Child("customVMXKeys").Key(key)
+		if !vmxKeyPattern.MatchString(key) {
+			allErrs = append(allErrs, field.Invalid(keyPath, key, "must consist of alphanumeric characters, '_', '.', ':' or '-'"))
+			continue
+		}
+		lower := strings.ToLower(key)
+		for _, prefix := range reservedVMXKeyPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				allErrs = append(allErrs, field.Forbidden(keyPath, "conflicts with the keys managed by CAPV"))
+				break
+			}
+		}
+		if specField, ok := fieldVMXKeys[lower]; ok {
+			detail := "is managed by CAPV"
+			if specField != "" {
+				detail = fmt.Sprintf("is managed by CAPV, use %s instead", fldPath.Child(specField))
+			}
+			allErrs = append(allErrs, field.Forbidden(keyPath, detail))
+		}
+	}
+	return allErrs
+}
+
+// validateTimeSync validates that the time synchronization of a clone spec
+// does not enable the periodic synchronization without the synchronization
+// on startup, which vSphere requires for it.
+func validateTimeSync(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	timeSync := spec.TimeSync
+	if timeSync == nil || timeSync.SyncOnStartup == nil || *timeSync.SyncOnStartup || timeSync.PeriodicSync == nil || !*timeSync.PeriodicSync {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(fldPath.Child("timeSync", "periodicSync"), *timeSync.PeriodicSync, "cannot be enabled when syncOnStartup is disabled")}
+}
+
+// validateMinHardwareVersion validates that the hardware version of a clone
+// spec, when it is set, is minVersion or later, as required by the feature
+// enabled by the field named feature.
+func validateMinHardwareVersion(spec *VirtualMachineCloneSpec, minVersion int, feature string, fldPath *field.Path) field.ErrorList {
+	if spec.HardwareVersion == "" {
+		return nil
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(spec.HardwareVersion, "vmx-"))
+	if err != nil || version < minVersion {
+		return field.ErrorList{field.Invalid(fldPath.Child("hardwareVersion"), spec.HardwareVersion, fmt.Sprintf("must be vmx-%d or later when %s is set", minVersion, feature))}
+	}
+	return nil
+}
+
+// validateDatastoreSelector validates that the datastore selector of a clone
+// spec has candidates and is not set together with a datastore.
+func validateDatastoreSelector(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	selector := spec.DatastoreSelector
+	if selector == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if spec.Datastore != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("datastoreSelector"), "cannot be set together with datastore"))
+	}
+	if len(selector.Datastores) == 0 && len(selector.TagIDs) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("datastoreSelector"), "one of datastores or tagIDs must be set"))
+	}
+	if len(selector.Datastores) > 0 && len(selector.TagIDs) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("datastoreSelector", "tagIDs"), "cannot be set together with datastores"))
+	}
+	return allErrs
+}
+
+// validateComputeSelector validates that the compute selector of a clone spec
+// has candidates and is not set together with a resource pool.
+func validateComputeSelector(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	selector := spec.ComputeSelector
+	if selector == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if spec.ResourcePool != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("computeSelector"), "cannot be set together with resourcePool"))
+	}
+	if len(selector.TagIDs) == 0 && selector.NameRegex == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("computeSelector"), "one of tagIDs or nameRegex must be set"))
+	}
+	if _, err := regexp.Compile(selector.NameRegex); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("computeSelector", "nameRegex"), selector.NameRegex, err.Error()))
+	}
+	return allErrs
+}
+
+// validateAdditionalDisks validates that the additional disks of a clone spec
+// are not configured both by size and by disk.
+func validateAdditionalDisks(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	if len(spec.AdditionalDisks) > 0 && len(spec.AdditionalDisksGiB) > 0 {
+		return field.ErrorList{field.Forbidden(fldPath.Child("additionalDisks"), "cannot be set together with additionalDisksGiB")}
+	}
+	return nil
+}
+
+// validateResourceAllocations validates the CPU and memory allocations of a
+// clone spec.
+func validateResourceAllocations(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateResourceAllocation(spec.CPUAllocation, fldPath.Child("cpuAllocation"))...)
+	allErrs = append(allErrs, validateResourceAllocation(spec.MemoryAllocation, fldPath.Child("memoryAllocation"))...)
+	return allErrs
+}
+
+func validateResourceAllocation(allocation *ResourceAllocation, fldPath *field.Path) field.ErrorList {
+	if allocation == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch {
+	case allocation.SharesLevel == SharesLevelCustom && allocation.Shares == 0:
+		allErrs = append(allErrs, field.Required(fldPath.Child("shares"), "must be set when sharesLevel is custom"))
+	case allocation.SharesLevel != SharesLevelCustom && allocation.Shares != 0:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("shares"), allocation.Shares, "can only be set when sharesLevel is custom"))
+	}
+	if allocation.Reservation != nil && allocation.Limit != nil && *allocation.Limit != -1 && *allocation.Reservation > *allocation.Limit {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("reservation"), *allocation.Reservation, "cannot be greater than the limit"))
+	}
+	return allErrs
+}
+
+// validateNetworkAddressFamilies validates that the gateways of the network
+// devices of a clone spec are of their address family, that their nameservers
+// are IPv4 or IPv6 addresses, and that they do not configure their IPv6
+// addresses through both stateful DHCPv6 and autoconfiguration.
+func validateNetworkAddressFamilies(spec *VirtualMachineCloneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, device := range spec.Network.Devices {
+		devicePath := fldPath.Child("network", "devices").Index(i)
+		if device.Gateway4 != "" {
+			if ip := net.ParseIP(device.Gateway4); ip == nil || ip.To4() == nil {
+				allErrs = append(allErrs, field.Invalid(devicePath.Child("gateway4"), device.Gateway4, "should be an IPv4 address"))
+			}
+		}
+		if device.DHCP6 && device.IPv6AddressMode != "" {
+			allErrs = append(allErrs, field.Forbidden(devicePath.Child("ipv6AddressMode"), "cannot be set when dhcp6 is true"))
+		}
+		if device.Gateway6 != "" {
+			if ip := net.ParseIP(device.Gateway6); ip == nil || ip.To4() != nil {
+				allErrs = append(allErrs, field.Invalid(devicePath.Child("gateway6"), device.Gateway6, "should be an IPv6 address"))
+			}
+		}
+		for j, nameserver := range device.Nameservers {
+			if net.ParseIP(nameserver) == nil {
+				allErrs = append(allErrs, field.Invalid(devicePath.Child("nameservers").Index(j), nameserver, "should be an IPv4 or IPv6 address"))
+			}
+		}
+	}
+	return allErrs
+}
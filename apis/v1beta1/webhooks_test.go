@@ -0,0 +1,693 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+)
+
+func TestValidateCustomizationSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    *CustomizationSpec
+		wantErr bool
+	}{
+		{
+			name: "no customization",
+		},
+		{
+			name: "named customization",
+			spec: &CustomizationSpec{Name: "linux-spec"},
+		},
+		{
+			name: "inline linux customization",
+			spec: &CustomizationSpec{Linux: &LinuxCustomization{Domain: "example.com"}},
+		},
+		{
+			name:    "named and inline customization",
+			spec:    &CustomizationSpec{Name: "linux-spec", Linux: &LinuxCustomization{}},
+			wantErr: true,
+		},
+		{
+			name:    "empty customization",
+			spec:    &CustomizationSpec{},
+			wantErr: true,
+		},
+		{
+			name: "windows domain join",
+			spec: &CustomizationSpec{Windows: &WindowsCustomization{
+				Domain:      "example.com",
+				DomainAdmin: "admin",
+				DomainAdminPasswordSecretRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "domain-admin"},
+					Key:                  "password",
+				},
+			}},
+		},
+		{
+			name:    "windows domain join without credentials",
+			spec:    &CustomizationSpec{Windows: &WindowsCustomization{Domain: "example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "windows workgroup and domain",
+			spec:    &CustomizationSpec{Windows: &WindowsCustomization{Workgroup: "WORKGROUP", Domain: "example.com", DomainAdmin: "admin", DomainAdminPasswordSecretRef: &corev1.SecretKeySelector{}}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateCustomizationSpec(tc.spec, field.NewPath("spec", "customizationSpec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateLinkedCloneSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{
+			name: "no linked clone settings",
+		},
+		{
+			name: "linked clone settings",
+			spec: VirtualMachineCloneSpec{
+				CloneMode:   LinkedClone,
+				LinkedClone: &LinkedCloneSpec{CreateSnapshot: true, MaxSnapshotDepth: 3, FallbackPolicy: LinkedCloneFallbackFail},
+			},
+		},
+		{
+			name: "linked clone settings with default clone mode",
+			spec: VirtualMachineCloneSpec{LinkedClone: &LinkedCloneSpec{CreateSnapshot: true}},
+		},
+		{
+			name: "linked clone settings with full clone mode",
+			spec: VirtualMachineCloneSpec{
+				CloneMode:   FullClone,
+				LinkedClone: &LinkedCloneSpec{CreateSnapshot: true},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative snapshot depth",
+			spec:    VirtualMachineCloneSpec{LinkedClone: &LinkedCloneSpec{MaxSnapshotDepth: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown fallback policy",
+			spec:    VirtualMachineCloneSpec{LinkedClone: &LinkedCloneSpec{FallbackPolicy: "consolidate"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := tc.spec
+			errs := validateLinkedCloneSpec(&spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateZoneTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{
+			name: "no templates",
+			spec: VirtualMachineCloneSpec{Datastore: "ds0"},
+		},
+		{
+			name: "valid templates",
+			spec: VirtualMachineCloneSpec{
+				Datastore: "ds-{{ .Zone }}",
+				Network:   NetworkSpec{Devices: []NetworkDeviceSpec{{NetworkName: "vm-network-{{ .Region }}"}}},
+			},
+		},
+		{
+			name:    "malformed template",
+			spec:    VirtualMachineCloneSpec{Datastore: "ds-{{ .Zone "},
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			spec: VirtualMachineCloneSpec{
+				Network: NetworkSpec{Devices: []NetworkDeviceSpec{{NetworkName: "vm-network-{{ .Rack }}"}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := tc.spec
+			errs := validateZoneTemplates(&spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateFolderTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  string
+		wantErr bool
+	}{
+		{name: "no template", folder: "/DC0/vm/k8s"},
+		{name: "valid template", folder: "k8s/{{ .ClusterName }}/{{ .MachineDeployment }}"},
+		{name: "malformed template", folder: "k8s/{{ .ClusterName ", wantErr: true},
+		{name: "unknown field", folder: "k8s/{{ .Zone }}", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateFolderTemplate(&VirtualMachineCloneSpec{Folder: tc.folder}, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateMoRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{name: "inventory paths", spec: VirtualMachineCloneSpec{Template: "/DC0/vm/ubuntu", Datastore: "LocalDS_0"}},
+		{name: "managed object IDs", spec: VirtualMachineCloneSpec{
+			Template:     "moref:vm-42",
+			Folder:       "moref:group-v3",
+			Datastore:    "moref:datastore-12",
+			ResourcePool: "moref:resgroup-9",
+			Network:      NetworkSpec{Devices: []NetworkDeviceSpec{{NetworkName: "moref:DistributedVirtualPortgroup:dvportgroup-34"}}},
+		}},
+		{name: "empty managed object ID", spec: VirtualMachineCloneSpec{Datastore: "moref:"}, wantErr: true},
+		{name: "invalid managed object ID", spec: VirtualMachineCloneSpec{Folder: "moref:group v3"}, wantErr: true},
+		{name: "invalid network", spec: VirtualMachineCloneSpec{
+			Network: NetworkSpec{Devices: []NetworkDeviceSpec{{NetworkName: "moref:/DC0/network/VM Network"}}},
+		}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := tc.spec
+			errs := validateMoRefs(&spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateNamingStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy *VSphereVMNamingStrategy
+		wantErr  bool
+	}{
+		{name: "no naming strategy"},
+		{name: "valid naming strategy", strategy: &VSphereVMNamingStrategy{Template: "{{ .ClusterName }}-{{ .MachineName }}", Prefix: "k8s-", MaxLength: 63}},
+		{name: "malformed template", strategy: &VSphereVMNamingStrategy{Template: "{{ .MachineName "}, wantErr: true},
+		{name: "unknown field", strategy: &VSphereVMNamingStrategy{Template: "{{ .Zone }}"}, wantErr: true},
+		{name: "no room for the name", strategy: &VSphereVMNamingStrategy{Prefix: "k8s-", Suffix: "-vm", MaxLength: 7, HashStrategy: VMNameHashNever}, wantErr: true},
+		{name: "template without the machine name", strategy: &VSphereVMNamingStrategy{Template: "{{ .ClusterName }}-{{ .MachineDeployment }}"}, wantErr: true},
+		{name: "template without the machine name and without hash", strategy: &VSphereVMNamingStrategy{Template: "{{ .ClusterName }}", HashStrategy: VMNameHashNever}, wantErr: true},
+		{name: "template without the machine name and with a hash", strategy: &VSphereVMNamingStrategy{Template: "{{ .ClusterName }}", HashStrategy: VMNameHashAlways}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateNamingStrategy(tc.strategy, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateHostName(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		domain   string
+		wantErr  bool
+	}{
+		{name: "default hostname"},
+		{name: "valid template and domain", template: "{{ .ClusterName }}-{{ .Name }}", domain: "k8s.example.com"},
+		{name: "malformed template", template: "{{ .Name ", wantErr: true},
+		{name: "unknown field", template: "{{ .Zone }}", wantErr: true},
+		{name: "invalid hostname", template: "{{ .Name }}_k8s", wantErr: true},
+		{name: "invalid domain", domain: "K8S.example.com", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := &VirtualMachineCloneSpec{HostNameTemplate: tc.template, HostNameDomain: tc.domain}
+			errs := validateHostName(spec, sampleHostNameTemplateData, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateCDROMs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cdroms  []CDROMSpec
+		wantErr bool
+	}{
+		{
+			name:   "datastore path and content library item",
+			cdroms: []CDROMSpec{{ISOPath: "[datastore1] iso/drivers.iso"}, {ContentLibraryItem: "isos/drivers", EjectAfterBootstrap: true}},
+		},
+		{
+			name:    "neither datastore path nor content library item",
+			cdroms:  []CDROMSpec{{EjectAfterBootstrap: true}},
+			wantErr: true,
+		},
+		{
+			name:    "both datastore path and content library item",
+			cdroms:  []CDROMSpec{{ISOPath: "[datastore1] iso/drivers.iso", ContentLibraryItem: "isos/drivers"}},
+			wantErr: true,
+		},
+		{
+			name:    "datastore path without datastore",
+			cdroms:  []CDROMSpec{{ISOPath: "iso/drivers.iso"}},
+			wantErr: true,
+		},
+		{
+			name:    "content library item without library",
+			cdroms:  []CDROMSpec{{ContentLibraryItem: "drivers"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateCDROMs(&VirtualMachineCloneSpec{CDROMs: tc.cdroms}, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateTrustedPlatformModule(t *testing.T) {
+	tests := []struct {
+		name            string
+		tpm             bool
+		hardwareVersion string
+		wantErr         bool
+	}{
+		{
+			name:            "without trusted platform module",
+			hardwareVersion: "vmx-13",
+		},
+		{
+			name: "with the hardware version of the template",
+			tpm:  true,
+		},
+		{
+			name:            "with a supported hardware version",
+			tpm:             true,
+			hardwareVersion: "vmx-17",
+		},
+		{
+			name:            "with an unsupported hardware version",
+			tpm:             true,
+			hardwareVersion: "vmx-13",
+			wantErr:         true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateTrustedPlatformModule(&VirtualMachineCloneSpec{TrustedPlatformModule: tc.tpm, HardwareVersion: tc.hardwareVersion}, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateVirtualIOMMU(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(validateVirtualIOMMU(&VirtualMachineCloneSpec{HardwareVersion: "vmx-13"}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateVirtualIOMMU(&VirtualMachineCloneSpec{VirtualIOMMU: true}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateVirtualIOMMU(&VirtualMachineCloneSpec{VirtualIOMMU: true, HardwareVersion: "vmx-19"}, field.NewPath("spec"))).To(BeEmpty())
+
+	errs := validateVirtualIOMMU(&VirtualMachineCloneSpec{VirtualIOMMU: true, HardwareVersion: "vmx-13"}, field.NewPath("spec"))
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Field).To(Equal("spec.hardwareVersion"))
+	g.Expect(errs[0].Detail).To(Equal("must be vmx-14 or later when virtualIOMMU is set"))
+}
+
+func TestValidateCustomVMXKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   map[string]string
+		fields []string
+	}{
+		{
+			name: "without custom keys",
+		},
+		{
+			name: "with tuning keys",
+			keys: map[string]string{"scsi0.queueDepth": "254", "time.synchronize.restore": "FALSE"},
+		},
+		{
+			name:   "with invalid keys",
+			keys:   map[string]string{"scsi0 queueDepth": "254", "": "x"},
+			fields: []string{"spec.customVMXKeys[]", "spec.customVMXKeys[scsi0 queueDepth]"},
+		},
+		{
+			name:   "with bootstrap keys",
+			keys:   map[string]string{"guestinfo.userdata": "", "GuestInfo.Metadata.Encoding": "base64", "guestinfo.ignition.config.data": ""},
+			fields: []string{"spec.customVMXKeys[GuestInfo.Metadata.Encoding]", "spec.customVMXKeys[guestinfo.ignition.config.data]", "spec.customVMXKeys[guestinfo.userdata]"},
+		},
+		{
+			name:   "with keys of the guest agent and of the NoCloud ISO images",
+			keys:   map[string]string{"guestinfo.capv.agent.ready": "true", "capv.nocloud.metadata": "", "guestinfo.custom": "x"},
+			fields: []string{"spec.customVMXKeys[capv.nocloud.metadata]", "spec.customVMXKeys[guestinfo.capv.agent.ready]"},
+		},
+		{
+			name:   "with keys managed by the spec",
+			keys:   map[string]string{"vhv.enable": "TRUE", "disk.EnableUUID": "FALSE"},
+			fields: []string{"spec.customVMXKeys[disk.EnableUUID]", "spec.customVMXKeys[vhv.enable]"},
+		},
+		{
+			name:   "with keys managed by the time synchronization",
+			keys:   map[string]string{"tools.syncTime": "FALSE", "time.synchronize.tools.startup": "FALSE"},
+			fields: []string{"spec.customVMXKeys[time.synchronize.tools.startup]", "spec.customVMXKeys[tools.syncTime]"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var fields []string
+			for _, err := range validateCustomVMXKeys(&VirtualMachineCloneSpec{CustomVMXKeys: tc.keys}, field.NewPath("spec")) {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.fields))
+		})
+	}
+
+	errs := validateCustomVMXKeys(&VirtualMachineCloneSpec{CustomVMXKeys: map[string]string{"vvtd.enable": "TRUE"}}, field.NewPath("spec"))
+	g := NewWithT(t)
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Detail).To(Equal("is managed by CAPV, use spec.virtualIOMMU instead"))
+}
+
+func TestValidateTimeSync(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(validateTimeSync(&VirtualMachineCloneSpec{}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateTimeSync(&VirtualMachineCloneSpec{TimeSync: &TimeSync{PeriodicSync: pointer.Bool(true)}}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateTimeSync(&VirtualMachineCloneSpec{TimeSync: &TimeSync{SyncOnStartup: pointer.Bool(false)}}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateTimeSync(&VirtualMachineCloneSpec{TimeSync: &TimeSync{SyncOnStartup: pointer.Bool(true), PeriodicSync: pointer.Bool(true)}}, field.NewPath("spec"))).To(BeEmpty())
+
+	errs := validateTimeSync(&VirtualMachineCloneSpec{TimeSync: &TimeSync{SyncOnStartup: pointer.Bool(false), PeriodicSync: pointer.Bool(true)}}, field.NewPath("spec"))
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Field).To(Equal("spec.timeSync.periodicSync"))
+}
+
+func TestValidateResourceAllocations(t *testing.T) {
+	tests := []struct {
+		name       string
+		allocation *ResourceAllocation
+		wantErr    bool
+	}{
+		{
+			name: "without allocation",
+		},
+		{
+			name:       "with reservation, limit and shares level",
+			allocation: &ResourceAllocation{Reservation: pointer.Int64(1024), Limit: pointer.Int64(2048), SharesLevel: SharesLevelHigh},
+		},
+		{
+			name:       "with reservation and unlimited limit",
+			allocation: &ResourceAllocation{Reservation: pointer.Int64(1024), Limit: pointer.Int64(-1)},
+		},
+		{
+			name:       "with custom shares",
+			allocation: &ResourceAllocation{SharesLevel: SharesLevelCustom, Shares: 4000},
+		},
+		{
+			name:       "with reservation greater than the limit",
+			allocation: &ResourceAllocation{Reservation: pointer.Int64(4096), Limit: pointer.Int64(2048)},
+			wantErr:    true,
+		},
+		{
+			name:       "with custom shares level without shares",
+			allocation: &ResourceAllocation{SharesLevel: SharesLevelCustom},
+			wantErr:    true,
+		},
+		{
+			name:       "with shares without custom shares level",
+			allocation: &ResourceAllocation{SharesLevel: SharesLevelNormal, Shares: 4000},
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateResourceAllocations(&VirtualMachineCloneSpec{CPUAllocation: tc.allocation, MemoryAllocation: tc.allocation}, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).To(HaveLen(2))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateDatastoreSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{
+			name: "without selector",
+			spec: VirtualMachineCloneSpec{Datastore: "ds1"},
+		},
+		{
+			name: "with candidate datastores",
+			spec: VirtualMachineCloneSpec{DatastoreSelector: &DatastoreSelector{Datastores: []string{"ds1", "ds2"}, MaxProvisionedPercent: pointer.Int32(150)}},
+		},
+		{
+			name: "with tags",
+			spec: VirtualMachineCloneSpec{DatastoreSelector: &DatastoreSelector{TagIDs: []string{"urn:vmomi:InventoryServiceTag:1:GLOBAL"}}},
+		},
+		{
+			name:    "with a datastore",
+			spec:    VirtualMachineCloneSpec{Datastore: "ds1", DatastoreSelector: &DatastoreSelector{Datastores: []string{"ds1", "ds2"}}},
+			wantErr: true,
+		},
+		{
+			name:    "without candidates",
+			spec:    VirtualMachineCloneSpec{DatastoreSelector: &DatastoreSelector{}},
+			wantErr: true,
+		},
+		{
+			name:    "with both candidate datastores and tags",
+			spec:    VirtualMachineCloneSpec{DatastoreSelector: &DatastoreSelector{Datastores: []string{"ds1"}, TagIDs: []string{"urn:vmomi:InventoryServiceTag:1:GLOBAL"}}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateDatastoreSelector(&tc.spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).To(HaveLen(1))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateComputeSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{
+			name: "without selector",
+			spec: VirtualMachineCloneSpec{ResourcePool: "pool"},
+		},
+		{
+			name: "with a name regex and tags",
+			spec: VirtualMachineCloneSpec{ComputeSelector: &ComputeSelector{NameRegex: "^cluster-", TagIDs: []string{"urn:vmomi:InventoryServiceTag:1:GLOBAL"}, Policy: ComputeSelectionLeastVMs}},
+		},
+		{
+			name:    "with a resource pool",
+			spec:    VirtualMachineCloneSpec{ResourcePool: "pool", ComputeSelector: &ComputeSelector{NameRegex: "^cluster-"}},
+			wantErr: true,
+		},
+		{
+			name:    "without candidates",
+			spec:    VirtualMachineCloneSpec{ComputeSelector: &ComputeSelector{Policy: ComputeSelectionMostFreeMemory}},
+			wantErr: true,
+		},
+		{
+			name:    "with an invalid name regex",
+			spec:    VirtualMachineCloneSpec{ComputeSelector: &ComputeSelector{NameRegex: "cluster-("}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateComputeSelector(&tc.spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).To(HaveLen(1))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateAdditionalDisks(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    VirtualMachineCloneSpec
+		wantErr bool
+	}{
+		{
+			name: "with disk sizes",
+			spec: VirtualMachineCloneSpec{AdditionalDisksGiB: []int32{20}},
+		},
+		{
+			name: "with disks",
+			spec: VirtualMachineCloneSpec{AdditionalDisks: []AdditionalDiskSpec{{SizeGiB: 20, RetainOnDelete: true}}},
+		},
+		{
+			name: "with disk sizes and disks",
+			spec: VirtualMachineCloneSpec{
+				AdditionalDisksGiB: []int32{20},
+				AdditionalDisks:    []AdditionalDiskSpec{{RetainOnDelete: true}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateAdditionalDisks(&tc.spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateNetworkAddressFamilies(t *testing.T) {
+	tests := []struct {
+		name    string
+		device  NetworkDeviceSpec
+		wantErr bool
+	}{
+		{
+			name: "dual-stack device",
+			device: NetworkDeviceSpec{
+				DHCP4:       true,
+				IPAddrs:     []string{"2001:db8::10/64"},
+				Gateway6:    "2001:db8::1",
+				Nameservers: []string{"10.0.0.53", "2001:db8::53"},
+			},
+		},
+		{
+			name:    "IPv6 gateway4",
+			device:  NetworkDeviceSpec{Gateway4: "2001:db8::1"},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 gateway6",
+			device:  NetworkDeviceSpec{Gateway6: "10.0.0.1"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid nameserver",
+			device:  NetworkDeviceSpec{Nameservers: []string{"dns.example.com"}},
+			wantErr: true,
+		},
+		{
+			name:   "IPv6-only device with SLAAC",
+			device: NetworkDeviceSpec{IPv6AddressMode: IPv6AddressModeSLAAC, Nameservers: []string{"2001:db8::53"}},
+		},
+		{
+			name:    "stateless DHCPv6 with dhcp6",
+			device:  NetworkDeviceSpec{DHCP6: true, IPv6AddressMode: IPv6AddressModeStatelessDHCPv6},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := VirtualMachineCloneSpec{Network: NetworkSpec{Devices: []NetworkDeviceSpec{tc.device}}}
+			errs := validateNetworkAddressFamilies(&spec, field.NewPath("spec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// ZoneTemplateData is the data the per-zone values of a clone spec, e.g.
+// "ds-{{ .Zone }}", are rendered with once the failure domain of the machine
+// is known.
+// +kubebuilder:object:generate=false
+type ZoneTemplateData struct {
+	// FailureDomain is the name of the VSphereDeploymentZone of the machine.
+	FailureDomain string
+
+	// Zone is the name of the zone of the VSphereFailureDomain.
+	Zone string
+
+	// Region is the name of the region of the VSphereFailureDomain.
+	Region string
+}
+
+// RenderZoneTemplates renders the per-zone values of the datastore and of the
+// network names of the clone spec with the given data. It fails if the spec
+// has per-zone values and data is nil, i.e. the machine has no failure
+// domain.
+func (s *VirtualMachineCloneSpec) RenderZoneTemplates(data *ZoneTemplateData) error {
+	var err error
+	if s.Datastore, err = renderZoneTemplate(s.Datastore, data); err != nil {
+		return errors.Wrap(err, "failed to render datastore")
+	}
+	for i := range s.Network.Devices {
+		device := &s.Network.Devices[i]
+		if device.NetworkName, err = renderZoneTemplate(device.NetworkName, data); err != nil {
+			return errors.Wrapf(err, "failed to render network name of device %d", i)
+		}
+	}
+	return nil
+}
+
+func renderZoneTemplate(value string, data *ZoneTemplateData) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	if data == nil {
+		return "", errors.Errorf("%q requires a failure domain", value)
+	}
+	tpl, err := template.New("").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid template %q", value)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "invalid template %q", value)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestVirtualMachineCloneSpec_RenderZoneTemplates(t *testing.T) {
+	data := &ZoneTemplateData{FailureDomain: "zone-a", Zone: "a", Region: "r1"}
+
+	tests := []struct {
+		name         string
+		spec         VirtualMachineCloneSpec
+		data         *ZoneTemplateData
+		wantErr      bool
+		wantDS       string
+		wantNetworks []string
+	}{
+		{
+			name:   "values without templates are kept",
+			spec:   VirtualMachineCloneSpec{Datastore: "ds0"},
+			wantDS: "ds0",
+		},
+		{
+			name: "templates are rendered",
+			spec: VirtualMachineCloneSpec{
+				Datastore: "ds-{{ .Zone }}",
+				Network: NetworkSpec{Devices: []NetworkDeviceSpec{
+					{NetworkName: "{{ .Region }}-{{ .FailureDomain }}"},
+					{NetworkName: "shared"},
+				}},
+			},
+			data:         data,
+			wantDS:       "ds-a",
+			wantNetworks: []string{"r1-zone-a", "shared"},
+		},
+		{
+			name:    "templates require a failure domain",
+			spec:    VirtualMachineCloneSpec{Datastore: "ds-{{ .Zone }}"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			spec:    VirtualMachineCloneSpec{Datastore: "ds-{{ .Rack }}"},
+			data:    data,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := tc.spec
+			err := spec.RenderZoneTemplates(tc.data)
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(spec.Datastore).To(Equal(tc.wantDS))
+			for i, network := range tc.wantNetworks {
+				g.Expect(spec.Network.Devices[i].NetworkName).To(Equal(network))
+			}
+		})
+	}
+}
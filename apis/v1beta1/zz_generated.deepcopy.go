@@ -23,6 +23,7 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/errors"
@@ -43,6 +44,21 @@ func (in *APIEndpoint) DeepCopy() *APIEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalDiskSpec) DeepCopyInto(out *AdditionalDiskSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalDiskSpec.
+func (in *AdditionalDiskSpec) DeepCopy() *AdditionalDiskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalDiskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AllowedNamespaces) DeepCopyInto(out *AllowedNamespaces) {
 	*out = *in
@@ -59,6 +75,86 @@ func (in *AllowedNamespaces) DeepCopy() *AllowedNamespaces {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleReference) DeepCopyInto(out *CABundleReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleReference.
+func (in *CABundleReference) DeepCopy() *CABundleReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CDROMSpec) DeepCopyInto(out *CDROMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CDROMSpec.
+func (in *CDROMSpec) DeepCopy() *CDROMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CDROMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIAddon) DeepCopyInto(out *CSIAddon) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIAddon.
+func (in *CSIAddon) DeepCopy() *CSIAddon {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIAddon)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudControllerManagerAddon) DeepCopyInto(out *CloudControllerManagerAddon) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudControllerManagerAddon.
+func (in *CloudControllerManagerAddon) DeepCopy() *CloudControllerManagerAddon {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudControllerManagerAddon)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneRetryPolicy) DeepCopyInto(out *CloneRetryPolicy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneRetryPolicy.
+func (in *CloneRetryPolicy) DeepCopy() *CloneRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterModule) DeepCopyInto(out *ClusterModule) {
 	*out = *in
@@ -74,6 +170,95 @@ func (in *ClusterModule) DeepCopy() *ClusterModule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterModuleStatus) DeepCopyInto(out *ClusterModuleStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterModuleStatus.
+func (in *ClusterModuleStatus) DeepCopy() *ClusterModuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterModuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComputeSelector) DeepCopyInto(out *ComputeSelector) {
+	*out = *in
+	if in.TagIDs != nil {
+		in, out := &in.TagIDs, &out.TagIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputeSelector.
+func (in *ComputeSelector) DeepCopy() *ComputeSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ComputeSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialSource) DeepCopyInto(out *CredentialSource) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultCredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialSource.
+func (in *CredentialSource) DeepCopy() *CredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomizationSpec) DeepCopyInto(out *CustomizationSpec) {
+	*out = *in
+	if in.Linux != nil {
+		in, out := &in.Linux, &out.Linux
+		*out = new(LinuxCustomization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = new(WindowsCustomization)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomizationSpec.
+func (in *CustomizationSpec) DeepCopy() *CustomizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DHCPOverrides) DeepCopyInto(out *DHCPOverrides) {
 	*out = *in
@@ -134,6 +319,36 @@ func (in *DHCPOverrides) DeepCopy() *DHCPOverrides {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatastoreSelector) DeepCopyInto(out *DatastoreSelector) {
+	*out = *in
+	if in.Datastores != nil {
+		in, out := &in.Datastores, &out.Datastores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TagIDs != nil {
+		in, out := &in.TagIDs, &out.TagIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxProvisionedPercent != nil {
+		in, out := &in.MaxProvisionedPercent, &out.MaxProvisionedPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatastoreSelector.
+func (in *DatastoreSelector) DeepCopy() *DatastoreSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(DatastoreSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FailureDomain) DeepCopyInto(out *FailureDomain) {
 	*out = *in
@@ -169,6 +384,106 @@ func (in *FailureDomainHosts) DeepCopy() *FailureDomainHosts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityQuota) DeepCopyInto(out *IdentityQuota) {
+	*out = *in
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxVMs != nil {
+		in, out := &in.MaxVMs, &out.MaxVMs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxCPUs != nil {
+		in, out := &in.MaxCPUs, &out.MaxCPUs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxMemoryMiB != nil {
+		in, out := &in.MaxMemoryMiB, &out.MaxMemoryMiB
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityQuota.
+func (in *IdentityQuota) DeepCopy() *IdentityQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityUsage) DeepCopyInto(out *IdentityUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityUsage.
+func (in *IdentityUsage) DeepCopy() *IdentityUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryObject) DeepCopyInto(out *InventoryObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryObject.
+func (in *InventoryObject) DeepCopy() *InventoryObject {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinkedCloneSpec) DeepCopyInto(out *LinkedCloneSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinkedCloneSpec.
+func (in *LinkedCloneSpec) DeepCopy() *LinkedCloneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LinkedCloneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxCustomization) DeepCopyInto(out *LinuxCustomization) {
+	*out = *in
+	if in.HWClockUTC != nil {
+		in, out := &in.HWClockUTC, &out.HWClockUTC
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxCustomization.
+func (in *LinuxCustomization) DeepCopy() *LinuxCustomization {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxCustomization)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Network) DeepCopyInto(out *Network) {
 	*out = *in
@@ -354,59 +669,382 @@ func (in *PlacementConstraint) DeepCopy() *PlacementConstraint {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SSHUser) DeepCopyInto(out *SSHUser) {
+func (in *PortGroupSecurityPolicy) DeepCopyInto(out *PortGroupSecurityPolicy) {
 	*out = *in
-	if in.AuthorizedKeys != nil {
-		in, out := &in.AuthorizedKeys, &out.AuthorizedKeys
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.AllowPromiscuous != nil {
+		in, out := &in.AllowPromiscuous, &out.AllowPromiscuous
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MACChanges != nil {
+		in, out := &in.MACChanges, &out.MACChanges
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForgedTransmits != nil {
+		in, out := &in.ForgedTransmits, &out.ForgedTransmits
+		*out = new(bool)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHUser.
-func (in *SSHUser) DeepCopy() *SSHUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortGroupSecurityPolicy.
+func (in *PortGroupSecurityPolicy) DeepCopy() *PortGroupSecurityPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(SSHUser)
+	out := new(PortGroupSecurityPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Topology) DeepCopyInto(out *Topology) {
+func (in *PortGroupSpec) DeepCopyInto(out *PortGroupSpec) {
 	*out = *in
-	if in.ComputeCluster != nil {
-		in, out := &in.ComputeCluster, &out.ComputeCluster
-		*out = new(string)
-		**out = **in
-	}
-	if in.Hosts != nil {
-		in, out := &in.Hosts, &out.Hosts
-		*out = new(FailureDomainHosts)
-		**out = **in
-	}
-	if in.Networks != nil {
-		in, out := &in.Networks, &out.Networks
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.SecurityPolicy != nil {
+		in, out := &in.SecurityPolicy, &out.SecurityPolicy
+		*out = new(PortGroupSecurityPolicy)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Topology.
-func (in *Topology) DeepCopy() *Topology {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortGroupSpec.
+func (in *PortGroupSpec) DeepCopy() *PortGroupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Topology)
+	out := new(PortGroupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereCluster) DeepCopyInto(out *VSphereCluster) {
+func (in *PortGroupValidation) DeepCopyInto(out *PortGroupValidation) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
+	if in.VLANID != nil {
+		in, out := &in.VLANID, &out.VLANID
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SecurityPolicy != nil {
+		in, out := &in.SecurityPolicy, &out.SecurityPolicy
+		*out = new(PortGroupSecurityPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneSecurityPolicy != nil {
+		in, out := &in.ControlPlaneSecurityPolicy, &out.ControlPlaneSecurityPolicy
+		*out = new(PortGroupSecurityPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortGroupValidation.
+func (in *PortGroupValidation) DeepCopy() *PortGroupValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(PortGroupValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfig.
+func (in *ProxyConfig) DeepCopy() *ProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceAllocation) DeepCopyInto(out *ResourceAllocation) {
+	*out = *in
+	if in.Reservation != nil {
+		in, out := &in.Reservation, &out.Reservation
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceAllocation.
+func (in *ResourceAllocation) DeepCopy() *ResourceAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainedDisk) DeepCopyInto(out *RetainedDisk) {
+	*out = *in
+	in.RetainedAt.DeepCopyInto(&out.RetainedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainedDisk.
+func (in *RetainedDisk) DeepCopy() *RetainedDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainedDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainedVM) DeepCopyInto(out *RetainedVM) {
+	*out = *in
+	in.RetainedAt.DeepCopyInto(&out.RetainedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainedVM.
+func (in *RetainedVM) DeepCopy() *RetainedVM {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainedVM)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHUser) DeepCopyInto(out *SSHUser) {
+	*out = *in
+	if in.AuthorizedKeys != nil {
+		in, out := &in.AuthorizedKeys, &out.AuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHUser.
+func (in *SSHUser) DeepCopy() *SSHUser {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskFailureDetails) DeepCopyInto(out *TaskFailureDetails) {
+	*out = *in
+	if in.CompleteTime != nil {
+		in, out := &in.CompleteTime, &out.CompleteTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Faults != nil {
+		in, out := &in.Faults, &out.Faults
+		*out = make([]TaskFault, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskFailureDetails.
+func (in *TaskFailureDetails) DeepCopy() *TaskFailureDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskFailureDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskFault) DeepCopyInto(out *TaskFault) {
+	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskFault.
+func (in *TaskFault) DeepCopy() *TaskFault {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskFault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
+func (in *TaskStatus) DeepCopy() *TaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReplicaStatus) DeepCopyInto(out *TemplateReplicaStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReplicaStatus.
+func (in *TemplateReplicaStatus) DeepCopy() *TemplateReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReplicationSpec) DeepCopyInto(out *TemplateReplicationSpec) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReplicationSpec.
+func (in *TemplateReplicationSpec) DeepCopy() *TemplateReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateSource) DeepCopyInto(out *TemplateSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateSource.
+func (in *TemplateSource) DeepCopy() *TemplateSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSync) DeepCopyInto(out *TimeSync) {
+	*out = *in
+	if in.SyncOnStartup != nil {
+		in, out := &in.SyncOnStartup, &out.SyncOnStartup
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PeriodicSync != nil {
+		in, out := &in.PeriodicSync, &out.PeriodicSync
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSync.
+func (in *TimeSync) DeepCopy() *TimeSync {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Topology) DeepCopyInto(out *Topology) {
+	*out = *in
+	if in.ComputeCluster != nil {
+		in, out := &in.ComputeCluster, &out.ComputeCluster
+		*out = new(string)
+		**out = **in
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = new(FailureDomainHosts)
+		**out = **in
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Topology.
+func (in *Topology) DeepCopy() *Topology {
+	if in == nil {
+		return nil
+	}
+	out := new(Topology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrackedObject) DeepCopyInto(out *TrackedObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrackedObject.
+func (in *TrackedObject) DeepCopy() *TrackedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(TrackedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VCenterEndpoint) DeepCopyInto(out *VCenterEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VCenterEndpoint.
+func (in *VCenterEndpoint) DeepCopy() *VCenterEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(VCenterEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereCluster) DeepCopyInto(out *VSphereCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
@@ -430,6 +1068,31 @@ func (in *VSphereCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterAddons) DeepCopyInto(out *VSphereClusterAddons) {
+	*out = *in
+	if in.CloudControllerManager != nil {
+		in, out := &in.CloudControllerManager, &out.CloudControllerManager
+		*out = new(CloudControllerManagerAddon)
+		**out = **in
+	}
+	if in.CSI != nil {
+		in, out := &in.CSI, &out.CSI
+		*out = new(CSIAddon)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterAddons.
+func (in *VSphereClusterAddons) DeepCopy() *VSphereClusterAddons {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterAddons)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereClusterIdentity) DeepCopyInto(out *VSphereClusterIdentity) {
 	*out = *in
@@ -490,73 +1153,423 @@ func (in *VSphereClusterIdentityList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterIdentitySpec) DeepCopyInto(out *VSphereClusterIdentitySpec) {
+func (in *VSphereClusterIdentitySpec) DeepCopyInto(out *VSphereClusterIdentitySpec) {
+	*out = *in
+	if in.CredentialSource != nil {
+		in, out := &in.CredentialSource, &out.CredentialSource
+		*out = new(CredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = new(AllowedNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CABundleRef != nil {
+		in, out := &in.CABundleRef, &out.CABundleRef
+		*out = new(CABundleReference)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(IdentityQuota)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterIdentitySpec.
+func (in *VSphereClusterIdentitySpec) DeepCopy() *VSphereClusterIdentitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterIdentitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterIdentityStatus) DeepCopyInto(out *VSphereClusterIdentityStatus) {
+	*out = *in
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(IdentityUsage)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterIdentityStatus.
+func (in *VSphereClusterIdentityStatus) DeepCopy() *VSphereClusterIdentityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterIdentityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterList) DeepCopyInto(out *VSphereClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterList.
+func (in *VSphereClusterList) DeepCopy() *VSphereClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterNetworkSpec) DeepCopyInto(out *VSphereClusterNetworkSpec) {
+	*out = *in
+	if in.CreatePortGroup != nil {
+		in, out := &in.CreatePortGroup, &out.CreatePortGroup
+		*out = new(PortGroupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PortGroupValidation != nil {
+		in, out := &in.PortGroupValidation, &out.PortGroupValidation
+		*out = new(PortGroupValidation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterNetworkSpec.
+func (in *VSphereClusterNetworkSpec) DeepCopy() *VSphereClusterNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterPlacement) DeepCopyInto(out *VSphereClusterPlacement) {
+	*out = *in
+	if in.TagIDs != nil {
+		in, out := &in.TagIDs, &out.TagIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterPlacement.
+func (in *VSphereClusterPlacement) DeepCopy() *VSphereClusterPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterSpec) DeepCopyInto(out *VSphereClusterSpec) {
+	*out = *in
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(VCenterEndpoint)
+		**out = **in
+	}
+	if in.CABundleRef != nil {
+		in, out := &in.CABundleRef, &out.CABundleRef
+		*out = new(CABundleReference)
+		**out = **in
+	}
+	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.ControlPlaneEndpointAddressFromPool != nil {
+		in, out := &in.ControlPlaneEndpointAddressFromPool, &out.ControlPlaneEndpointAddressFromPool
+		*out = new(v1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdentityRef != nil {
+		in, out := &in.IdentityRef, &out.IdentityRef
+		*out = new(VSphereIdentityReference)
+		**out = **in
+	}
+	if in.ClusterModules != nil {
+		in, out := &in.ClusterModules, &out.ClusterModules
+		*out = make([]ClusterModule, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultPlacement != nil {
+		in, out := &in.DefaultPlacement, &out.DefaultPlacement
+		*out = new(VSphereClusterPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateReplication != nil {
+		in, out := &in.TemplateReplication, &out.TemplateReplication
+		*out = new(TemplateReplicationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Addons != nil {
+		in, out := &in.Addons, &out.Addons
+		*out = new(VSphereClusterAddons)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkSpec != nil {
+		in, out := &in.NetworkSpec, &out.NetworkSpec
+		*out = new(VSphereClusterNetworkSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterSpec.
+func (in *VSphereClusterSpec) DeepCopy() *VSphereClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterStatus) DeepCopyInto(out *VSphereClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(apiv1beta1.FailureDomains, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.ClusterModules != nil {
+		in, out := &in.ClusterModules, &out.ClusterModules
+		*out = make([]ClusterModuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TemplateReplicas != nil {
+		in, out := &in.TemplateReplicas, &out.TemplateReplicas
+		*out = make([]TemplateReplicaStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetainedVMs != nil {
+		in, out := &in.RetainedVMs, &out.RetainedVMs
+		*out = make([]RetainedVM, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RetainedDisks != nil {
+		in, out := &in.RetainedDisks, &out.RetainedDisks
+		*out = make([]RetainedDisk, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PortGroup != nil {
+		in, out := &in.PortGroup, &out.PortGroup
+		*out = new(InventoryObject)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterStatus.
+func (in *VSphereClusterStatus) DeepCopy() *VSphereClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterTemplate) DeepCopyInto(out *VSphereClusterTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplate.
+func (in *VSphereClusterTemplate) DeepCopy() *VSphereClusterTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereClusterTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterTemplateList) DeepCopyInto(out *VSphereClusterTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereClusterTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateList.
+func (in *VSphereClusterTemplateList) DeepCopy() *VSphereClusterTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereClusterTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterTemplateResource) DeepCopyInto(out *VSphereClusterTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateResource.
+func (in *VSphereClusterTemplateResource) DeepCopy() *VSphereClusterTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereClusterTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereClusterTemplateSpec) DeepCopyInto(out *VSphereClusterTemplateSpec) {
 	*out = *in
-	if in.AllowedNamespaces != nil {
-		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
-		*out = new(AllowedNamespaces)
-		(*in).DeepCopyInto(*out)
-	}
+	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterIdentitySpec.
-func (in *VSphereClusterIdentitySpec) DeepCopy() *VSphereClusterIdentitySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateSpec.
+func (in *VSphereClusterTemplateSpec) DeepCopy() *VSphereClusterTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterIdentitySpec)
+	out := new(VSphereClusterTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterIdentityStatus) DeepCopyInto(out *VSphereClusterIdentityStatus) {
+func (in *VSphereDeploymentZone) DeepCopyInto(out *VSphereDeploymentZone) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterIdentityStatus.
-func (in *VSphereClusterIdentityStatus) DeepCopy() *VSphereClusterIdentityStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZone.
+func (in *VSphereDeploymentZone) DeepCopy() *VSphereDeploymentZone {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterIdentityStatus)
+	out := new(VSphereDeploymentZone)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereDeploymentZone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterList) DeepCopyInto(out *VSphereClusterList) {
+func (in *VSphereDeploymentZoneList) DeepCopyInto(out *VSphereDeploymentZoneList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereCluster, len(*in))
+		*out = make([]VSphereDeploymentZone, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterList.
-func (in *VSphereClusterList) DeepCopy() *VSphereClusterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneList.
+func (in *VSphereDeploymentZoneList) DeepCopy() *VSphereDeploymentZoneList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterList)
+	out := new(VSphereDeploymentZoneList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereClusterList) DeepCopyObject() runtime.Object {
+func (in *VSphereDeploymentZoneList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -564,34 +1577,39 @@ func (in *VSphereClusterList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterSpec) DeepCopyInto(out *VSphereClusterSpec) {
+func (in *VSphereDeploymentZoneSpec) DeepCopyInto(out *VSphereDeploymentZoneSpec) {
 	*out = *in
-	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
-	if in.IdentityRef != nil {
-		in, out := &in.IdentityRef, &out.IdentityRef
-		*out = new(VSphereIdentityReference)
+	if in.ControlPlane != nil {
+		in, out := &in.ControlPlane, &out.ControlPlane
+		*out = new(bool)
 		**out = **in
 	}
-	if in.ClusterModules != nil {
-		in, out := &in.ClusterModules, &out.ClusterModules
-		*out = make([]ClusterModule, len(*in))
-		copy(*out, *in)
+	out.PlacementConstraint = in.PlacementConstraint
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterSpec.
-func (in *VSphereClusterSpec) DeepCopy() *VSphereClusterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneSpec.
+func (in *VSphereDeploymentZoneSpec) DeepCopy() *VSphereDeploymentZoneSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterSpec)
+	out := new(VSphereDeploymentZoneSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterStatus) DeepCopyInto(out *VSphereClusterStatus) {
+func (in *VSphereDeploymentZoneStatus) DeepCopyInto(out *VSphereDeploymentZoneStatus) {
 	*out = *in
+	if in.Ready != nil {
+		in, out := &in.Ready, &out.Ready
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(apiv1beta1.Conditions, len(*in))
@@ -599,45 +1617,48 @@ func (in *VSphereClusterStatus) DeepCopyInto(out *VSphereClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.FailureDomains != nil {
-		in, out := &in.FailureDomains, &out.FailureDomains
-		*out = make(apiv1beta1.FailureDomains, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+	if in.ComputeCluster != nil {
+		in, out := &in.ComputeCluster, &out.ComputeCluster
+		*out = new(TrackedObject)
+		**out = **in
+	}
+	if in.ResourcePool != nil {
+		in, out := &in.ResourcePool, &out.ResourcePool
+		*out = new(TrackedObject)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterStatus.
-func (in *VSphereClusterStatus) DeepCopy() *VSphereClusterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneStatus.
+func (in *VSphereDeploymentZoneStatus) DeepCopy() *VSphereDeploymentZoneStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterStatus)
+	out := new(VSphereDeploymentZoneStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterTemplate) DeepCopyInto(out *VSphereClusterTemplate) {
+func (in *VSphereFailureDomain) DeepCopyInto(out *VSphereFailureDomain) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplate.
-func (in *VSphereClusterTemplate) DeepCopy() *VSphereClusterTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomain.
+func (in *VSphereFailureDomain) DeepCopy() *VSphereFailureDomain {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterTemplate)
+	out := new(VSphereFailureDomain)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereClusterTemplate) DeepCopyObject() runtime.Object {
+func (in *VSphereFailureDomain) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -645,31 +1666,31 @@ func (in *VSphereClusterTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterTemplateList) DeepCopyInto(out *VSphereClusterTemplateList) {
+func (in *VSphereFailureDomainList) DeepCopyInto(out *VSphereFailureDomainList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereClusterTemplate, len(*in))
+		*out = make([]VSphereFailureDomain, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateList.
-func (in *VSphereClusterTemplateList) DeepCopy() *VSphereClusterTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomainList.
+func (in *VSphereFailureDomainList) DeepCopy() *VSphereFailureDomainList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterTemplateList)
+	out := new(VSphereFailureDomainList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereClusterTemplateList) DeepCopyObject() runtime.Object {
+func (in *VSphereFailureDomainList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -677,39 +1698,40 @@ func (in *VSphereClusterTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterTemplateResource) DeepCopyInto(out *VSphereClusterTemplateResource) {
+func (in *VSphereFailureDomainSpec) DeepCopyInto(out *VSphereFailureDomainSpec) {
 	*out = *in
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.Region.DeepCopyInto(&out.Region)
+	in.Zone.DeepCopyInto(&out.Zone)
+	in.Topology.DeepCopyInto(&out.Topology)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateResource.
-func (in *VSphereClusterTemplateResource) DeepCopy() *VSphereClusterTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomainSpec.
+func (in *VSphereFailureDomainSpec) DeepCopy() *VSphereFailureDomainSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterTemplateResource)
+	out := new(VSphereFailureDomainSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereClusterTemplateSpec) DeepCopyInto(out *VSphereClusterTemplateSpec) {
+func (in *VSphereIdentityReference) DeepCopyInto(out *VSphereIdentityReference) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereClusterTemplateSpec.
-func (in *VSphereClusterTemplateSpec) DeepCopy() *VSphereClusterTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereIdentityReference.
+func (in *VSphereIdentityReference) DeepCopy() *VSphereIdentityReference {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereClusterTemplateSpec)
+	out := new(VSphereIdentityReference)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereDeploymentZone) DeepCopyInto(out *VSphereDeploymentZone) {
+func (in *VSphereMachine) DeepCopyInto(out *VSphereMachine) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -717,18 +1739,18 @@ func (in *VSphereDeploymentZone) DeepCopyInto(out *VSphereDeploymentZone) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZone.
-func (in *VSphereDeploymentZone) DeepCopy() *VSphereDeploymentZone {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachine.
+func (in *VSphereMachine) DeepCopy() *VSphereMachine {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereDeploymentZone)
+	out := new(VSphereMachine)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereDeploymentZone) DeepCopyObject() runtime.Object {
+func (in *VSphereMachine) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -736,31 +1758,31 @@ func (in *VSphereDeploymentZone) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereDeploymentZoneList) DeepCopyInto(out *VSphereDeploymentZoneList) {
+func (in *VSphereMachineList) DeepCopyInto(out *VSphereMachineList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereDeploymentZone, len(*in))
+		*out = make([]VSphereMachine, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneList.
-func (in *VSphereDeploymentZoneList) DeepCopy() *VSphereDeploymentZoneList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineList.
+func (in *VSphereMachineList) DeepCopy() *VSphereMachineList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereDeploymentZoneList)
+	out := new(VSphereMachineList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereDeploymentZoneList) DeepCopyObject() runtime.Object {
+func (in *VSphereMachineList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -768,32 +1790,59 @@ func (in *VSphereDeploymentZoneList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereDeploymentZoneSpec) DeepCopyInto(out *VSphereDeploymentZoneSpec) {
+func (in *VSphereMachineSpec) DeepCopyInto(out *VSphereMachineSpec) {
 	*out = *in
-	if in.ControlPlane != nil {
-		in, out := &in.ControlPlane, &out.ControlPlane
-		*out = new(bool)
+	in.VirtualMachineCloneSpec.DeepCopyInto(&out.VirtualMachineCloneSpec)
+	if in.ProviderID != nil {
+		in, out := &in.ProviderID, &out.ProviderID
+		*out = new(string)
+		**out = **in
+	}
+	if in.FailureDomain != nil {
+		in, out := &in.FailureDomain, &out.FailureDomain
+		*out = new(string)
+		**out = **in
+	}
+	if in.NamingStrategy != nil {
+		in, out := &in.NamingStrategy, &out.NamingStrategy
+		*out = new(VSphereVMNamingStrategy)
 		**out = **in
 	}
-	out.PlacementConstraint = in.PlacementConstraint
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneSpec.
-func (in *VSphereDeploymentZoneSpec) DeepCopy() *VSphereDeploymentZoneSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineSpec.
+func (in *VSphereMachineSpec) DeepCopy() *VSphereMachineSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereDeploymentZoneSpec)
+	out := new(VSphereMachineSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereDeploymentZoneStatus) DeepCopyInto(out *VSphereDeploymentZoneStatus) {
+func (in *VSphereMachineStatus) DeepCopyInto(out *VSphereMachineStatus) {
 	*out = *in
-	if in.Ready != nil {
-		in, out := &in.Ready, &out.Ready
-		*out = new(bool)
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]apiv1beta1.MachineAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = make([]NetworkStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
 		**out = **in
 	}
 	if in.Conditions != nil {
@@ -805,36 +1854,37 @@ func (in *VSphereDeploymentZoneStatus) DeepCopyInto(out *VSphereDeploymentZoneSt
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDeploymentZoneStatus.
-func (in *VSphereDeploymentZoneStatus) DeepCopy() *VSphereDeploymentZoneStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineStatus.
+func (in *VSphereMachineStatus) DeepCopy() *VSphereMachineStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereDeploymentZoneStatus)
+	out := new(VSphereMachineStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereFailureDomain) DeepCopyInto(out *VSphereFailureDomain) {
+func (in *VSphereMachineTemplate) DeepCopyInto(out *VSphereMachineTemplate) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomain.
-func (in *VSphereFailureDomain) DeepCopy() *VSphereFailureDomain {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplate.
+func (in *VSphereMachineTemplate) DeepCopy() *VSphereMachineTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereFailureDomain)
+	out := new(VSphereMachineTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereFailureDomain) DeepCopyObject() runtime.Object {
+func (in *VSphereMachineTemplate) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -842,31 +1892,31 @@ func (in *VSphereFailureDomain) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereFailureDomainList) DeepCopyInto(out *VSphereFailureDomainList) {
+func (in *VSphereMachineTemplateList) DeepCopyInto(out *VSphereMachineTemplateList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereFailureDomain, len(*in))
+		*out = make([]VSphereMachineTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomainList.
-func (in *VSphereFailureDomainList) DeepCopy() *VSphereFailureDomainList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateList.
+func (in *VSphereMachineTemplateList) DeepCopy() *VSphereMachineTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereFailureDomainList)
+	out := new(VSphereMachineTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereFailureDomainList) DeepCopyObject() runtime.Object {
+func (in *VSphereMachineTemplateList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -874,59 +1924,80 @@ func (in *VSphereFailureDomainList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereFailureDomainSpec) DeepCopyInto(out *VSphereFailureDomainSpec) {
+func (in *VSphereMachineTemplateResource) DeepCopyInto(out *VSphereMachineTemplateResource) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateResource.
+func (in *VSphereMachineTemplateResource) DeepCopy() *VSphereMachineTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereMachineTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereMachineTemplateSpec) DeepCopyInto(out *VSphereMachineTemplateSpec) {
 	*out = *in
-	in.Region.DeepCopyInto(&out.Region)
-	in.Zone.DeepCopyInto(&out.Zone)
-	in.Topology.DeepCopyInto(&out.Topology)
+	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereFailureDomainSpec.
-func (in *VSphereFailureDomainSpec) DeepCopy() *VSphereFailureDomainSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateSpec.
+func (in *VSphereMachineTemplateSpec) DeepCopy() *VSphereMachineTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereFailureDomainSpec)
+	out := new(VSphereMachineTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereIdentityReference) DeepCopyInto(out *VSphereIdentityReference) {
+func (in *VSphereMachineTemplateStatus) DeepCopyInto(out *VSphereMachineTemplateStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereIdentityReference.
-func (in *VSphereIdentityReference) DeepCopy() *VSphereIdentityReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateStatus.
+func (in *VSphereMachineTemplateStatus) DeepCopy() *VSphereMachineTemplateStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereIdentityReference)
+	out := new(VSphereMachineTemplateStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachine) DeepCopyInto(out *VSphereMachine) {
+func (in *VSphereSettings) DeepCopyInto(out *VSphereSettings) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachine.
-func (in *VSphereMachine) DeepCopy() *VSphereMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSettings.
+func (in *VSphereSettings) DeepCopy() *VSphereSettings {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachine)
+	out := new(VSphereSettings)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereMachine) DeepCopyObject() runtime.Object {
+func (in *VSphereSettings) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -934,31 +2005,31 @@ func (in *VSphereMachine) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineList) DeepCopyInto(out *VSphereMachineList) {
+func (in *VSphereSettingsList) DeepCopyInto(out *VSphereSettingsList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereMachine, len(*in))
+		*out = make([]VSphereSettings, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineList.
-func (in *VSphereMachineList) DeepCopy() *VSphereMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSettingsList.
+func (in *VSphereSettingsList) DeepCopy() *VSphereSettingsList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachineList)
+	out := new(VSphereSettingsList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereMachineList) DeepCopyObject() runtime.Object {
+func (in *VSphereSettingsList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -966,95 +2037,55 @@ func (in *VSphereMachineList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineSpec) DeepCopyInto(out *VSphereMachineSpec) {
+func (in *VSphereSettingsSpec) DeepCopyInto(out *VSphereSettingsSpec) {
 	*out = *in
-	in.VirtualMachineCloneSpec.DeepCopyInto(&out.VirtualMachineCloneSpec)
-	if in.ProviderID != nil {
-		in, out := &in.ProviderID, &out.ProviderID
-		*out = new(string)
-		**out = **in
-	}
-	if in.FailureDomain != nil {
-		in, out := &in.FailureDomain, &out.FailureDomain
-		*out = new(string)
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(VCenterEndpoint)
 		**out = **in
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineSpec.
-func (in *VSphereMachineSpec) DeepCopy() *VSphereMachineSpec {
-	if in == nil {
-		return nil
-	}
-	out := new(VSphereMachineSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineStatus) DeepCopyInto(out *VSphereMachineStatus) {
-	*out = *in
-	if in.Addresses != nil {
-		in, out := &in.Addresses, &out.Addresses
-		*out = make([]apiv1beta1.MachineAddress, len(*in))
-		copy(*out, *in)
-	}
-	if in.Network != nil {
-		in, out := &in.Network, &out.Network
-		*out = make([]NetworkStatus, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.FailureReason != nil {
-		in, out := &in.FailureReason, &out.FailureReason
-		*out = new(errors.MachineStatusError)
+	if in.CABundleRef != nil {
+		in, out := &in.CABundleRef, &out.CABundleRef
+		*out = new(CABundleReference)
 		**out = **in
 	}
-	if in.FailureMessage != nil {
-		in, out := &in.FailureMessage, &out.FailureMessage
-		*out = new(string)
+	if in.IdentityRef != nil {
+		in, out := &in.IdentityRef, &out.IdentityRef
+		*out = new(VSphereIdentityReference)
 		**out = **in
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineStatus.
-func (in *VSphereMachineStatus) DeepCopy() *VSphereMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSettingsSpec.
+func (in *VSphereSettingsSpec) DeepCopy() *VSphereSettingsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachineStatus)
+	out := new(VSphereSettingsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineTemplate) DeepCopyInto(out *VSphereMachineTemplate) {
+func (in *VSphereTenantPolicy) DeepCopyInto(out *VSphereTenantPolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplate.
-func (in *VSphereMachineTemplate) DeepCopy() *VSphereMachineTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereTenantPolicy.
+func (in *VSphereTenantPolicy) DeepCopy() *VSphereTenantPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachineTemplate)
+	out := new(VSphereTenantPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereMachineTemplate) DeepCopyObject() runtime.Object {
+func (in *VSphereTenantPolicy) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1062,31 +2093,31 @@ func (in *VSphereMachineTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineTemplateList) DeepCopyInto(out *VSphereMachineTemplateList) {
+func (in *VSphereTenantPolicyList) DeepCopyInto(out *VSphereTenantPolicyList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VSphereMachineTemplate, len(*in))
+		*out = make([]VSphereTenantPolicy, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateList.
-func (in *VSphereMachineTemplateList) DeepCopy() *VSphereMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereTenantPolicyList.
+func (in *VSphereTenantPolicyList) DeepCopy() *VSphereTenantPolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachineTemplateList)
+	out := new(VSphereTenantPolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VSphereMachineTemplateList) DeepCopyObject() runtime.Object {
+func (in *VSphereTenantPolicyList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1094,34 +2125,32 @@ func (in *VSphereMachineTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineTemplateResource) DeepCopyInto(out *VSphereMachineTemplateResource) {
+func (in *VSphereTenantPolicySpec) DeepCopyInto(out *VSphereTenantPolicySpec) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateResource.
-func (in *VSphereMachineTemplateResource) DeepCopy() *VSphereMachineTemplateResource {
-	if in == nil {
-		return nil
+	in.Namespaces.DeepCopyInto(&out.Namespaces)
+	if in.Datacenters != nil {
+		in, out := &in.Datacenters, &out.Datacenters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourcePools != nil {
+		in, out := &in.ResourcePools, &out.ResourcePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Folders != nil {
+		in, out := &in.Folders, &out.Folders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	out := new(VSphereMachineTemplateResource)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSphereMachineTemplateSpec) DeepCopyInto(out *VSphereMachineTemplateSpec) {
-	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTemplateSpec.
-func (in *VSphereMachineTemplateSpec) DeepCopy() *VSphereMachineTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereTenantPolicySpec.
+func (in *VSphereTenantPolicySpec) DeepCopy() *VSphereTenantPolicySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSphereMachineTemplateSpec)
+	out := new(VSphereTenantPolicySpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1185,6 +2214,61 @@ func (in *VSphereVMList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereVMNamingStrategy) DeepCopyInto(out *VSphereVMNamingStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereVMNamingStrategy.
+func (in *VSphereVMNamingStrategy) DeepCopy() *VSphereVMNamingStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereVMNamingStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereVMPlacement) DeepCopyInto(out *VSphereVMPlacement) {
+	*out = *in
+	if in.Folder != nil {
+		in, out := &in.Folder, &out.Folder
+		*out = new(InventoryObject)
+		**out = **in
+	}
+	if in.ResourcePool != nil {
+		in, out := &in.ResourcePool, &out.ResourcePool
+		*out = new(InventoryObject)
+		**out = **in
+	}
+	if in.Datastore != nil {
+		in, out := &in.Datastore, &out.Datastore
+		*out = new(InventoryObject)
+		**out = **in
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]InventoryObject, len(*in))
+		copy(*out, *in)
+	}
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		*out = new(InventoryObject)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereVMPlacement.
+func (in *VSphereVMPlacement) DeepCopy() *VSphereVMPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereVMPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereVMSpec) DeepCopyInto(out *VSphereVMSpec) {
 	*out = *in
@@ -1194,6 +2278,16 @@ func (in *VSphereVMSpec) DeepCopyInto(out *VSphereVMSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereVMSpec.
@@ -1209,12 +2303,32 @@ func (in *VSphereVMSpec) DeepCopy() *VSphereVMSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereVMStatus) DeepCopyInto(out *VSphereVMStatus) {
 	*out = *in
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(VSphereVMTopology)
+		**out = **in
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(VSphereVMPlacement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Addresses != nil {
 		in, out := &in.Addresses, &out.Addresses
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	in.RetryAfter.DeepCopyInto(&out.RetryAfter)
+	if in.Task != nil {
+		in, out := &in.Task, &out.Task
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	if in.TaskProgress != nil {
+		in, out := &in.TaskProgress, &out.TaskProgress
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Network != nil {
 		in, out := &in.Network, &out.Network
 		*out = make([]NetworkStatus, len(*in))
@@ -1232,6 +2346,11 @@ func (in *VSphereVMStatus) DeepCopyInto(out *VSphereVMStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FailureDetails != nil {
+		in, out := &in.FailureDetails, &out.FailureDetails
+		*out = new(TaskFailureDetails)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(apiv1beta1.Conditions, len(*in))
@@ -1244,6 +2363,21 @@ func (in *VSphereVMStatus) DeepCopyInto(out *VSphereVMStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ISOImages != nil {
+		in, out := &in.ISOImages, &out.ISOImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncompatibleHosts != nil {
+		in, out := &in.IncompatibleHosts, &out.IncompatibleHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetainedDisks != nil {
+		in, out := &in.RetainedDisks, &out.RetainedDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereVMStatus.
@@ -1256,6 +2390,41 @@ func (in *VSphereVMStatus) DeepCopy() *VSphereVMStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereVMTopology) DeepCopyInto(out *VSphereVMTopology) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereVMTopology.
+func (in *VSphereVMTopology) DeepCopy() *VSphereVMTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereVMTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultCredentialSource) DeepCopyInto(out *VaultCredentialSource) {
+	*out = *in
+	if in.CABundleRef != nil {
+		in, out := &in.CABundleRef, &out.CABundleRef
+		*out = new(CABundleReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultCredentialSource.
+func (in *VaultCredentialSource) DeepCopy() *VaultCredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultCredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachine) DeepCopyInto(out *VirtualMachine) {
 	*out = *in
@@ -1281,12 +2450,37 @@ func (in *VirtualMachine) DeepCopy() *VirtualMachine {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachineCloneSpec) DeepCopyInto(out *VirtualMachineCloneSpec) {
 	*out = *in
+	if in.TemplateSource != nil {
+		in, out := &in.TemplateSource, &out.TemplateSource
+		*out = new(TemplateSource)
+		**out = **in
+	}
+	if in.LinkedClone != nil {
+		in, out := &in.LinkedClone, &out.LinkedClone
+		*out = new(LinkedCloneSpec)
+		**out = **in
+	}
+	if in.DatastoreSelector != nil {
+		in, out := &in.DatastoreSelector, &out.DatastoreSelector
+		*out = new(DatastoreSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ComputeSelector != nil {
+		in, out := &in.ComputeSelector, &out.ComputeSelector
+		*out = new(ComputeSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Network.DeepCopyInto(&out.Network)
 	if in.AdditionalDisksGiB != nil {
 		in, out := &in.AdditionalDisksGiB, &out.AdditionalDisksGiB
 		*out = make([]int32, len(*in))
 		copy(*out, *in)
 	}
+	if in.AdditionalDisks != nil {
+		in, out := &in.AdditionalDisks, &out.AdditionalDisks
+		*out = make([]AdditionalDiskSpec, len(*in))
+		copy(*out, *in)
+	}
 	if in.CustomVMXKeys != nil {
 		in, out := &in.CustomVMXKeys, &out.CustomVMXKeys
 		*out = make(map[string]string, len(*in))
@@ -1299,6 +2493,13 @@ func (in *VirtualMachineCloneSpec) DeepCopyInto(out *VirtualMachineCloneSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomAttributes != nil {
+		in, out := &in.CustomAttributes, &out.CustomAttributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.PciDevices != nil {
 		in, out := &in.PciDevices, &out.PciDevices
 		*out = make([]PCIDeviceSpec, len(*in))
@@ -1306,6 +2507,36 @@ func (in *VirtualMachineCloneSpec) DeepCopyInto(out *VirtualMachineCloneSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CustomizationSpec != nil {
+		in, out := &in.CustomizationSpec, &out.CustomizationSpec
+		*out = new(CustomizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CDROMs != nil {
+		in, out := &in.CDROMs, &out.CDROMs
+		*out = make([]CDROMSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.CPUAllocation != nil {
+		in, out := &in.CPUAllocation, &out.CPUAllocation
+		*out = new(ResourceAllocation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemoryAllocation != nil {
+		in, out := &in.MemoryAllocation, &out.MemoryAllocation
+		*out = new(ResourceAllocation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloneRetryPolicy != nil {
+		in, out := &in.CloneRetryPolicy, &out.CloneRetryPolicy
+		*out = new(CloneRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TimeSync != nil {
+		in, out := &in.TimeSync, &out.TimeSync
+		*out = new(TimeSync)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMachineCloneSpec.
@@ -1317,3 +2548,23 @@ func (in *VirtualMachineCloneSpec) DeepCopy() *VirtualMachineCloneSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowsCustomization) DeepCopyInto(out *WindowsCustomization) {
+	*out = *in
+	if in.DomainAdminPasswordSecretRef != nil {
+		in, out := &in.DomainAdminPasswordSecretRef, &out.DomainAdminPasswordSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowsCustomization.
+func (in *WindowsCustomization) DeepCopy() *WindowsCustomization {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowsCustomization)
+	in.DeepCopyInto(out)
+	return out
+}
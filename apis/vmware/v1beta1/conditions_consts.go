@@ -69,6 +69,26 @@ const (
 	WaitingForNetworkAddressReason = "WaitingForNetworkAddress"
 	// WaitingForBIOSUUIDReason (Severity=Info) documents a VSphereMachine waiting for the the machine to have a BIOS UUID.
 	WaitingForBIOSUUIDReason = "WaitingForBIOSUUID"
+	// WaitingForDevicePlacementReason (Severity=Info) documents a VSphereMachine waiting for VM Operator to place its VM,
+	// which requires the vGPU or DirectPath I/O devices of its VirtualMachineClass. Whether a host has the devices
+	// available is not checked, VM Operator not reporting it.
+	WaitingForDevicePlacementReason = "WaitingForDevicePlacement"
+	// InvalidVMClassDevicesReason (Severity=Error) documents a VSphereMachine whose VirtualMachineClass has vGPU or
+	// DirectPath I/O devices which do not identify the vGPU profile or PCI device to attach to its VM.
+	InvalidVMClassDevicesReason = "InvalidVMClassDevices"
+)
+
+const (
+	// VMClassUpToDateCondition documents whether the VM of a VSphereMachine has
+	// the class of the VSphereMachine. It is only set on the VSphereMachines
+	// whose class changed with the replace strategy.
+	VMClassUpToDateCondition clusterv1.ConditionType = "VMClassUpToDate"
+
+	// ReplacingReason (Severity=Info) documents a VSphereMachine whose MachineDeployment is rolling out its class.
+	ReplacingReason = "Replacing"
+	// ReplacementNotSupportedReason (Severity=Warning) documents a VSphereMachine whose machine cannot be replaced,
+	// as it is not owned by a MachineDeployment using a VSphereMachineTemplate outside of a cluster topology.
+	ReplacementNotSupportedReason = "ReplacementNotSupported"
 )
 
 const (
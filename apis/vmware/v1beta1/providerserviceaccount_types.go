@@ -38,12 +38,91 @@ type ProviderServiceAccountSpec struct {
 	// TargetSecretName is the name of the secret in the target cluster that contains the generated service account
 	// token.
 	TargetSecretName string `json:"targetSecretName"`
+
+	// TargetRoleBindings are the templates of the RoleBindings created in the target cluster, e.g. to let the
+	// workloads of the target cluster read the secret containing the generated service account token.
+	// +optional
+	TargetRoleBindings []TargetRoleBindingTemplate `json:"targetRoleBindings,omitempty"`
+
+	// Mode is the mode of the ProviderServiceAccount. In the sync mode, the default, the service account is created
+	// and its token synced into the target cluster. In the audit mode, nothing is created and the permissions that
+	// would be granted are reported in the status instead, so that they can be reviewed before they are synced.
+	// +kubebuilder:validation:Enum=sync;audit
+	// +optional
+	Mode ProviderServiceAccountMode `json:"mode,omitempty"`
+}
+
+// ProviderServiceAccountMode is the mode of a ProviderServiceAccount.
+type ProviderServiceAccountMode string
+
+const (
+	// ProviderServiceAccountModeSync creates the service account and syncs its token into the target cluster.
+	ProviderServiceAccountModeSync ProviderServiceAccountMode = "sync"
+
+	// ProviderServiceAccountModeAudit only reports the permissions that would be granted.
+	ProviderServiceAccountModeAudit ProviderServiceAccountMode = "audit"
+)
+
+// TargetRoleBindingTemplate is the template of the RoleBindings created in namespaces of the target cluster.
+// The name of the RoleBinding, the name of the role it refers to and the names and namespaces of its subjects may
+// be templates, e.g. "{{ .Name }}-reader", rendered with the name of the ProviderServiceAccount ({{ .Name }}), the
+// namespace of the RoleBinding ({{ .Namespace }}) and the target namespace ({{ .TargetNamespace }}).
+type TargetRoleBindingTemplate struct {
+	// Name is the name of the RoleBindings. Defaults to the name of the ProviderServiceAccount.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespaces are the namespaces of the target cluster a RoleBinding is created in. Defaults to the target
+	// namespace.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// RoleRef is the Role or ClusterRole of the target cluster the RoleBindings refer to.
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+
+	// Subjects are the subjects the role is bound to.
+	Subjects []rbacv1.Subject `json:"subjects"`
 }
 
 // ProviderServiceAccountStatus defines the observed state of ProviderServiceAccount.
 type ProviderServiceAccountStatus struct {
 	Ready    bool   `json:"ready,omitempty"`
 	ErrorMsg string `json:"errorMsg,omitempty"`
+
+	// Audit reports the permissions the ProviderServiceAccount would grant. It is only set in the audit mode.
+	// +optional
+	Audit *ProviderServiceAccountAudit `json:"audit,omitempty"`
+}
+
+// ProviderServiceAccountAudit reports the permissions a ProviderServiceAccount would grant once synced.
+type ProviderServiceAccountAudit struct {
+	// Rules are the privileges that would be granted to the service account in the namespace of the
+	// ProviderServiceAccount.
+	// +optional
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+
+	// TargetSecret is the namespace and name of the secret of the target cluster the service account token would
+	// be synced to.
+	TargetSecret string `json:"targetSecret"`
+
+	// TargetRoleBindings are the RoleBindings that would be created in the target cluster.
+	// +optional
+	TargetRoleBindings []TargetRoleBinding `json:"targetRoleBindings,omitempty"`
+}
+
+// TargetRoleBinding is a RoleBinding rendered from a TargetRoleBindingTemplate.
+type TargetRoleBinding struct {
+	// Namespace is the namespace of the RoleBinding.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the RoleBinding.
+	Name string `json:"name"`
+
+	// RoleRef is the role the RoleBinding refers to.
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+
+	// Subjects are the subjects the role is bound to.
+	Subjects []rbacv1.Subject `json:"subjects"`
 }
 
 // +kubebuilder:object:root=true
@@ -53,6 +132,7 @@ type ProviderServiceAccountStatus struct {
 // +kubebuilder:printcolumn:name="VSphereCluster",type=string,JSONPath=.spec.ref.name
 // +kubebuilder:printcolumn:name="TargetNamespace",type=string,JSONPath=.spec.targetNamespace
 // +kubebuilder:printcolumn:name="TargetSecretName",type=string,JSONPath=.spec.targetSecretName
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=.spec.mode
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ProviderServiceAccount is the schema for the ProviderServiceAccount API.
@@ -60,7 +140,8 @@ type ProviderServiceAccount struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ProviderServiceAccountSpec `json:"spec,omitempty"`
+	Spec   ProviderServiceAccountSpec   `json:"spec,omitempty"`
+	Status ProviderServiceAccountStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
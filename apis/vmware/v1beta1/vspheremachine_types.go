@@ -35,6 +35,19 @@ type VSphereMachineVolume struct {
 	StorageClass string `json:"storageClass,omitempty"`
 }
 
+// ClassChangeStrategy is how the change of the class of a machine is rolled
+// out.
+type ClassChangeStrategy string
+
+const (
+	// ClassChangeStrategyInPlace changes the class of the VM of the machine.
+	ClassChangeStrategyInPlace ClassChangeStrategy = "inPlace"
+
+	// ClassChangeStrategyReplace replaces the machines of the MachineDeployment
+	// of the machine.
+	ClassChangeStrategyReplace ClassChangeStrategy = "replace"
+)
+
 // VSphereMachineSpec defines the desired state of VSphereMachine
 type VSphereMachineSpec struct {
 	// ProviderID is the virtual machine's BIOS UUID formated as
@@ -52,10 +65,27 @@ type VSphereMachineSpec struct {
 	// underlying virtual machine
 	ImageName string `json:"imageName"`
 
+	// FailureDomainImageNames are the names of the images used instead of
+	// ImageName in the failure domains they are keyed by, for the zones whose
+	// content libraries do not provide the image of ImageName under the same
+	// name.
+	// +optional
+	FailureDomainImageNames map[string]string `json:"failureDomainImageNames,omitempty"`
+
 	// ClassName is the name of the class used when specifying the underlying
 	// virtual machine
 	ClassName string `json:"className"`
 
+	// ClassChangeStrategy is how a change of the ClassName of an existing
+	// machine is rolled out. With inPlace, the default, the class of its VM is
+	// changed, for VM Operator to resize the VM if it supports it. With
+	// replace, the VM keeps its class and the MachineDeployment of the machine
+	// is switched to a copy of its machine template with the new class, for
+	// its rolling update to replace the machines.
+	// +kubebuilder:validation:Enum=inPlace;replace
+	// +optional
+	ClassChangeStrategy ClassChangeStrategy `json:"classChangeStrategy,omitempty"`
+
 	// StorageClass is the name of the storage class used when specifying the
 	// underlying virtual machine.
 	// +optional
@@ -66,6 +96,17 @@ type VSphereMachineSpec struct {
 	Volumes []VSphereMachineVolume `json:"volumes,omitempty"`
 }
 
+// GetImageName returns the name of the image of the machine in its failure
+// domain.
+func (s *VSphereMachineSpec) GetImageName() string {
+	if s.FailureDomain != nil {
+		if imageName, ok := s.FailureDomainImageNames[*s.FailureDomain]; ok && imageName != "" {
+			return imageName
+		}
+	}
+	return s.ImageName
+}
+
 // VSphereMachineStatus defines the observed state of VSphereMachine
 type VSphereMachineStatus struct {
 	// Ready is true when the provider resource is ready.
@@ -35,6 +35,7 @@ func (in *ProviderServiceAccount) DeepCopyInto(out *ProviderServiceAccount) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderServiceAccount.
@@ -55,6 +56,35 @@ func (in *ProviderServiceAccount) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderServiceAccountAudit) DeepCopyInto(out *ProviderServiceAccountAudit) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]rbacv1.PolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TargetRoleBindings != nil {
+		in, out := &in.TargetRoleBindings, &out.TargetRoleBindings
+		*out = make([]TargetRoleBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderServiceAccountAudit.
+func (in *ProviderServiceAccountAudit) DeepCopy() *ProviderServiceAccountAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderServiceAccountAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderServiceAccountList) DeepCopyInto(out *ProviderServiceAccountList) {
 	*out = *in
@@ -102,6 +132,13 @@ func (in *ProviderServiceAccountSpec) DeepCopyInto(out *ProviderServiceAccountSp
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TargetRoleBindings != nil {
+		in, out := &in.TargetRoleBindings, &out.TargetRoleBindings
+		*out = make([]TargetRoleBindingTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderServiceAccountSpec.
@@ -117,6 +154,11 @@ func (in *ProviderServiceAccountSpec) DeepCopy() *ProviderServiceAccountSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderServiceAccountStatus) DeepCopyInto(out *ProviderServiceAccountStatus) {
 	*out = *in
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(ProviderServiceAccountAudit)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderServiceAccountStatus.
@@ -129,6 +171,53 @@ func (in *ProviderServiceAccountStatus) DeepCopy() *ProviderServiceAccountStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRoleBinding) DeepCopyInto(out *TargetRoleBinding) {
+	*out = *in
+	out.RoleRef = in.RoleRef
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]rbacv1.Subject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRoleBinding.
+func (in *TargetRoleBinding) DeepCopy() *TargetRoleBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRoleBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRoleBindingTemplate) DeepCopyInto(out *TargetRoleBindingTemplate) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.RoleRef = in.RoleRef
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]rbacv1.Subject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRoleBindingTemplate.
+func (in *TargetRoleBindingTemplate) DeepCopy() *TargetRoleBindingTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRoleBindingTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereCluster) DeepCopyInto(out *VSphereCluster) {
 	*out = *in
@@ -395,6 +484,13 @@ func (in *VSphereMachineSpec) DeepCopyInto(out *VSphereMachineSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FailureDomainImageNames != nil {
+		in, out := &in.FailureDomainImageNames, &out.FailureDomainImageNames
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
 		*out = make([]VSphereMachineVolume, len(*in))
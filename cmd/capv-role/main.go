@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The capv-role command writes the definition of the vCenter role holding the
+// privileges the controllers need for the features of the clusters, so that
+// their vCenter user can be granted the least privileges.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/privileges"
+)
+
+var (
+	name     = flag.String("name", "capv", "The name of the role.")
+	features = flag.String("features", "", fmt.Sprintf("The comma-separated features of the clusters, among %s, or all.", joinFeatures()))
+	format   = flag.String("format", "govc", fmt.Sprintf("The format of the role definition, among %s.", strings.Join(privileges.Formats, ", ")))
+)
+
+func main() {
+	flag.Parse()
+
+	var enabled []privileges.Feature
+	switch *features {
+	case "":
+	case "all":
+		enabled = privileges.Features
+	default:
+		for _, feature := range strings.Split(*features, ",") {
+			f, err := privileges.ParseFeature(strings.TrimSpace(feature))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			enabled = append(enabled, f)
+		}
+	}
+
+	role := privileges.Role{Name: *name, Privileges: privileges.Required(enabled...)}
+	if err := role.Write(os.Stdout, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func joinFeatures() string {
+	names := make([]string, 0, len(privileges.Features))
+	for _, feature := range privileges.Features {
+		names = append(names, string(feature))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The guest agent runs in the VMs of the machines and reports the progress of
+// their bootstrap and the health of their node to guestinfo keys, from which
+// they are read by the controllers. It only depends on the standard library
+// and VMware Tools.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/guestagent"
+)
+
+var (
+	interval          = flag.Duration("interval", 30*time.Second, "The interval between two reports.")
+	bootstrapSentinel = flag.String("bootstrap-sentinel", "/run/cluster-api/bootstrap-success.complete", "The file written by the bootstrap data once it succeeded.")
+	cloudInitResult   = flag.String("cloud-init-result", "/run/cloud-init/result.json", "The file written by cloud-init once it finished.")
+	cloudInitStatus   = flag.String("cloud-init-status", "/run/cloud-init/status.json", "The file in which cloud-init reports the stage it runs.")
+	cloudInitOutput   = flag.String("cloud-init-output", "/var/log/cloud-init-output.log", "The file cloud-init writes the output of the bootstrap commands to.")
+	kubeletHealthz    = flag.String("kubelet-healthz", "http://127.0.0.1:10248/healthz", "The healthz endpoint of the kubelet.")
+	rpcTool           = flag.String("rpctool", "vmware-rpctool", "The VMware Tools command used to set the guestinfo keys.")
+)
+
+func main() {
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		bootstrap := bootstrapReport()
+		publish(guestagent.BootstrapKey, bootstrap)
+		if bootstrap.Status == guestagent.StatusSucceeded {
+			publish(guestagent.NodeHealthKey, nodeHealthReport(ctx))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// bootstrapReport reports the bootstrap as succeeded once the sentinel file
+// exists, and as failed if cloud-init finished with errors. The reports on
+// the bootstrap which did not succeed tell whether cloud-init or kubeadm is
+// running, or failed.
+func bootstrapReport() guestagent.Report {
+	report := guestagent.Report{Status: guestagent.StatusInProgress, Phase: guestagent.PhaseCloudInit, Time: time.Now()}
+	if _, err := os.Stat(*bootstrapSentinel); err == nil {
+		report.Status = guestagent.StatusSucceeded
+		report.Phase = ""
+		return report
+	}
+
+	data, err := os.ReadFile(*cloudInitResult)
+	if err != nil {
+		// cloud-init is still running.
+		if processRunning("kubeadm") {
+			report.Phase = guestagent.PhaseKubeadm
+		} else if stage := cloudInitStage(); stage != "" {
+			report.Message = fmt.Sprintf("cloud-init is running %s", stage)
+		}
+		return report
+	}
+	var result struct {
+		V1 struct {
+			Errors []string `json:"errors"`
+		} `json:"v1"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		report.Status = guestagent.StatusFailed
+		report.Message = fmt.Sprintf("unable to parse %s: %v", *cloudInitResult, err)
+		return report
+	}
+	report.Status = guestagent.StatusFailed
+	if output, err := os.ReadFile(*cloudInitOutput); err == nil {
+		if kubeadmErr := guestagent.KubeadmError(string(output)); kubeadmErr != "" {
+			report.Phase = guestagent.PhaseKubeadm
+			report.Message = kubeadmErr
+			return report
+		}
+	}
+	if len(result.V1.Errors) > 0 {
+		report.Message = strings.Join(result.V1.Errors, "; ")
+	} else {
+		report.Message = "cloud-init finished without writing the bootstrap sentinel file"
+	}
+	return report
+}
+
+// cloudInitStage returns the stage cloud-init runs, e.g. modules-final, or
+// an empty string if it is unknown.
+func cloudInitStage() string {
+	data, err := os.ReadFile(*cloudInitStatus)
+	if err != nil {
+		return ""
+	}
+	var status struct {
+		V1 struct {
+			Stage *string `json:"stage"`
+		} `json:"v1"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil || status.V1.Stage == nil {
+		return ""
+	}
+	return *status.V1.Stage
+}
+
+// processRunning returns true if a process of the given command runs.
+func processRunning(command string) bool {
+	comms, err := filepath.Glob("/proc/[0-9]*/comm")
+	if err != nil {
+		return false
+	}
+	for _, comm := range comms {
+		if data, err := os.ReadFile(comm); err == nil && strings.TrimSpace(string(data)) == command {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeHealthReport reports the health of the node from the healthz endpoint
+// of the kubelet.
+func nodeHealthReport(ctx context.Context) guestagent.Report {
+	report := guestagent.Report{Status: guestagent.StatusHealthy, Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *kubeletHealthz, http.NoBody)
+	if err != nil {
+		report.Status = guestagent.StatusUnhealthy
+		report.Message = err.Error()
+		return report
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		report.Status = guestagent.StatusUnhealthy
+		report.Message = fmt.Sprintf("the kubelet is not reachable: %v", err)
+		return report
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		report.Status = guestagent.StatusUnhealthy
+		report.Message = fmt.Sprintf("the kubelet healthz endpoint returned %s", resp.Status)
+	}
+	return report
+}
+
+// publish sets the guestinfo key to the report. Errors are only logged, the
+// report being published again on the next iteration.
+func publish(key string, report guestagent.Report) {
+	value, err := report.Marshal()
+	if err != nil {
+		log.Printf("unable to marshal the report for %s: %v", key, err)
+		return
+	}
+	//nolint:gosec
+	if out, err := exec.Command(*rpcTool, fmt.Sprintf("info-set %s %s", key, value)).CombinedOutput(); err != nil {
+		log.Printf("unable to set %s: %v: %s", key, err, out)
+	}
+}
@@ -18,7 +18,9 @@ package controllers
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,22 +41,48 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 )
 
+// clusterModuleMembershipRefreshPeriod is the default interval at which the
+// members of the cluster modules reported in the VSphereCluster status are
+// refreshed.
+const clusterModuleMembershipRefreshPeriod = 5 * time.Minute
+
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspheremachinetemplates,verbs=get;list;watch
 
+// Reconciler reconciles the cluster modules of a VSphereCluster. It is run
+// by the VSphereCluster controller as part of its reconcile and only changes
+// the VSphereCluster of the ClusterContext, which the controller patches.
 type Reconciler struct {
 	*context.ControllerContext
 
 	ClusterModuleService clustermodule.Service
+
+	// reconciles limits the number of VSphereClusters whose cluster modules
+	// are reconciled concurrently, if set.
+	reconciles chan struct{}
 }
 
 func NewReconciler(ctx *context.ControllerContext) Reconciler {
-	return Reconciler{
+	r := Reconciler{
 		ControllerContext:    ctx,
 		ClusterModuleService: clustermodule.NewService(),
 	}
+	if n := ctx.ControllerOptions[context.ClusterModuleControllerName].MaxConcurrentReconciles; n > 0 {
+		r.reconciles = make(chan struct{}, n)
+	}
+	return r
+}
+
+// membershipRefreshPeriod returns the interval at which the members of the
+// cluster modules are refreshed.
+func (r Reconciler) membershipRefreshPeriod() time.Duration {
+	if period := r.ControllerOptions[context.ClusterModuleControllerName].SyncPeriod; period > 0 {
+		return period
+	}
+	return clusterModuleMembershipRefreshPeriod
 }
 
 func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, error) {
@@ -67,6 +95,15 @@ func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, er
 		return reconcile.Result{}, nil
 	}
 
+	if r.reconciles != nil {
+		select {
+		case r.reconciles <- struct{}{}:
+			defer func() { <-r.reconciles }()
+		case <-ctx.Done():
+			return reconcile.Result{}, ctx.Err()
+		}
+	}
+
 	objectMap, err := r.fetchMachineOwnerObjects(ctx)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -90,8 +127,11 @@ func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, er
 			// verify the cluster module
 			exists, err := r.ClusterModuleService.DoesExist(ctx, obj, mod.ModuleUUID)
 			if err != nil {
+				// The module is kept until it can be verified, so that it is
+				// not duplicated while vCenter cannot be reached.
 				ctx.Logger.Error(err, "failed to verify cluster module for object",
 					"name", mod.TargetObjectName, "moduleUUID", mod.ModuleUUID)
+				exists = true
 			}
 			// append the module and object info to the VSphereCluster object
 			// and remove it from the object map since no new cluster module
@@ -113,7 +153,27 @@ func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, er
 
 	modErrs := []clusterModError{}
 	for _, obj := range objectMap {
-		moduleUUID, err := r.ClusterModuleService.Create(ctx, obj)
+		// The modules missing from the VSphereCluster, e.g. after the
+		// management cluster was restored from a backup, are found from the
+		// VMs of the object before creating new ones.
+		moduleUUID, err := r.ClusterModuleService.Discover(ctx, obj)
+		if err != nil {
+			ctx.Logger.Error(err, "failed to discover cluster module for target object", "name", obj.GetName())
+			modErrs = append(modErrs, clusterModError{obj.GetName(), err})
+			continue
+		}
+		if moduleUUID != "" {
+			ctx.Logger.Info("discovered cluster module for target object", "name", obj.GetName(), "moduleUUID", moduleUUID)
+			r.Recorder.Eventf(ctx.VSphereCluster, "ClusterModuleDiscovered", "discovered cluster module %s of %s from its VMs", moduleUUID, obj.GetName())
+			clusterModuleSpecs = append(clusterModuleSpecs, infrav1.ClusterModule{
+				ControlPlane:     obj.IsControlPlane(),
+				TargetObjectName: obj.GetName(),
+				ModuleUUID:       moduleUUID,
+			})
+			continue
+		}
+
+		moduleUUID, err = r.ClusterModuleService.Create(ctx, obj)
 		if err != nil {
 			ctx.Logger.Error(err, "failed to create cluster module for target object", "name", obj.GetName())
 			modErrs = append(modErrs, clusterModError{obj.GetName(), err})
@@ -130,6 +190,7 @@ func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, er
 		})
 	}
 	ctx.VSphereCluster.Spec.ClusterModules = clusterModuleSpecs
+	ctx.VSphereCluster.Status.ClusterModules = r.reconcileMembership(ctx, clusterModuleSpecs)
 
 	switch {
 	case len(modErrs) > 0:
@@ -148,9 +209,69 @@ func (r Reconciler) Reconcile(ctx *context.ClusterContext) (reconcile.Result, er
 	default:
 		conditions.Delete(ctx.VSphereCluster, infrav1.ClusterModulesAvailableCondition)
 	}
+	if len(clusterModuleSpecs) > 0 {
+		return reconcile.Result{RequeueAfter: r.membershipRefreshPeriod()}, err
+	}
 	return reconcile.Result{}, err
 }
 
+// reconcileMembership returns the status of the given cluster modules with
+// the VMs that are currently members of each of them. The previously reported
+// members are kept if they cannot be read from vCenter, and LastUpdated is
+// only bumped when the members changed or are due for a refresh so that the
+// status is not patched on every reconcile.
+func (r Reconciler) reconcileMembership(ctx *context.ClusterContext, modules []infrav1.ClusterModule) []infrav1.ClusterModuleStatus {
+	previous := map[string]infrav1.ClusterModuleStatus{}
+	for _, status := range ctx.VSphereCluster.Status.ClusterModules {
+		previous[status.ModuleUUID] = status
+	}
+
+	statuses := make([]infrav1.ClusterModuleStatus, 0, len(modules))
+	for _, mod := range modules {
+		prev, hasPrev := previous[mod.ModuleUUID]
+		status := infrav1.ClusterModuleStatus{
+			ControlPlane:     mod.ControlPlane,
+			TargetObjectName: mod.TargetObjectName,
+			ModuleUUID:       mod.ModuleUUID,
+			Members:          prev.Members,
+			LastUpdated:      prev.LastUpdated,
+		}
+
+		members, err := r.ClusterModuleService.ListMembers(ctx, mod.ModuleUUID)
+		if err != nil {
+			ctx.Logger.Error(err, "failed to list members of cluster module",
+				"name", mod.TargetObjectName, "moduleUUID", mod.ModuleUUID)
+			statuses = append(statuses, status)
+			continue
+		}
+		sort.Strings(members)
+		if !hasPrev || prev.LastUpdated == nil || !stringSlicesEqual(prev.Members, members) ||
+			time.Since(prev.LastUpdated.Time) >= r.membershipRefreshPeriod() {
+			now := metav1.Now()
+			status.Members = members
+			status.LastUpdated = &now
+		}
+		statuses = append(statuses, status)
+	}
+
+	if len(statuses) == 0 {
+		return nil
+	}
+	return statuses
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (r Reconciler) toAffinityInput(obj client.Object) []reconcile.Request {
 	cluster, err := util.GetClusterFromMetadata(r, r.Client, metav1.ObjectMeta{
 		Namespace:       obj.GetNamespace(),
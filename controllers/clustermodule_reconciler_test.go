@@ -72,6 +72,41 @@ func TestReconciler_Reconcile(t *testing.T) {
 				g.Expect(ctx.VSphereCluster.Spec.ClusterModules).To(gomega.HaveLen(2))
 			},
 		},
+		{
+			name: "reports the members of the cluster modules in the status",
+			clusterModules: []infrav1.ClusterModule{
+				{
+					ControlPlane:     true,
+					TargetObjectName: "kcp",
+					ModuleUUID:       kcpUUID,
+				},
+				{
+					ControlPlane:     false,
+					TargetObjectName: "md",
+					ModuleUUID:       mdUUID,
+				},
+			},
+			setupMocks: func(svc *cmodfake.CMService) {
+				svc.On("DoesExist", mock.Anything, mock.Anything, kcpUUID).Return(true, nil)
+				svc.On("DoesExist", mock.Anything, mock.Anything, mdUUID).Return(true, nil)
+				svc.On("ListMembers", mock.Anything, kcpUUID).Return([]string{"vm-2", "vm-1"}, nil)
+				svc.On("ListMembers", mock.Anything, mdUUID).Return([]string(nil), errors.New("failed to reach API"))
+			},
+			customAssert: func(g *gomega.WithT, ctx *context.ClusterContext) {
+				g.Expect(ctx.VSphereCluster.Status.ClusterModules).To(gomega.HaveLen(2))
+				for _, status := range ctx.VSphereCluster.Status.ClusterModules {
+					switch status.ModuleUUID {
+					case kcpUUID:
+						g.Expect(status.ControlPlane).To(gomega.BeTrue())
+						g.Expect(status.Members).To(gomega.Equal([]string{"vm-1", "vm-2"}))
+						g.Expect(status.LastUpdated).ToNot(gomega.BeNil())
+					case mdUUID:
+						g.Expect(status.Members).To(gomega.BeEmpty())
+						g.Expect(status.LastUpdated).To(gomega.BeNil())
+					}
+				}
+			},
+		},
 		{
 			name:           "when no cluster modules exist",
 			clusterModules: []infrav1.ClusterModule{},
@@ -92,6 +127,68 @@ func TestReconciler_Reconcile(t *testing.T) {
 				g.Expect(moduleUUIDs).To(gomega.ConsistOf(kcpUUID, mdUUID))
 			},
 		},
+		{
+			name:           "adopts the cluster modules discovered from the VMs",
+			clusterModules: []infrav1.ClusterModule{},
+			setupMocks: func(svc *cmodfake.CMService) {
+				svc.On("Discover", mock.Anything, clustermodule.NewWrapper(kcp)).Return(kcpUUID, nil)
+				svc.On("Discover", mock.Anything, clustermodule.NewWrapper(md)).Return(mdUUID, nil)
+			},
+			customAssert: func(g *gomega.WithT, ctx *context.ClusterContext) {
+				g.Expect(ctx.VSphereCluster.Spec.ClusterModules).To(gomega.ConsistOf(
+					infrav1.ClusterModule{ControlPlane: true, TargetObjectName: "kcp", ModuleUUID: kcpUUID},
+					infrav1.ClusterModule{ControlPlane: false, TargetObjectName: "md", ModuleUUID: mdUUID},
+				))
+			},
+		},
+		{
+			name: "replaces the missing cluster modules with the discovered ones",
+			clusterModules: []infrav1.ClusterModule{
+				{
+					ControlPlane:     true,
+					TargetObjectName: "kcp",
+					ModuleUUID:       kcpUUID,
+				},
+				{
+					ControlPlane:     false,
+					TargetObjectName: "md",
+					ModuleUUID:       "deleted",
+				},
+			},
+			setupMocks: func(svc *cmodfake.CMService) {
+				svc.On("DoesExist", mock.Anything, mock.Anything, kcpUUID).Return(true, nil)
+				svc.On("DoesExist", mock.Anything, mock.Anything, "deleted").Return(false, nil)
+				svc.On("Discover", mock.Anything, clustermodule.NewWrapper(md)).Return(mdUUID, nil)
+			},
+			customAssert: func(g *gomega.WithT, ctx *context.ClusterContext) {
+				g.Expect(ctx.VSphereCluster.Spec.ClusterModules).To(gomega.ConsistOf(
+					infrav1.ClusterModule{ControlPlane: true, TargetObjectName: "kcp", ModuleUUID: kcpUUID},
+					infrav1.ClusterModule{ControlPlane: false, TargetObjectName: "md", ModuleUUID: mdUUID},
+				))
+			},
+		},
+		{
+			name: "keeps the cluster modules which cannot be verified",
+			clusterModules: []infrav1.ClusterModule{
+				{
+					ControlPlane:     true,
+					TargetObjectName: "kcp",
+					ModuleUUID:       kcpUUID,
+				},
+				{
+					ControlPlane:     false,
+					TargetObjectName: "md",
+					ModuleUUID:       mdUUID,
+				},
+			},
+			setupMocks: func(svc *cmodfake.CMService) {
+				svc.On("DoesExist", mock.Anything, mock.Anything, kcpUUID).Return(true, nil)
+				svc.On("DoesExist", mock.Anything, mock.Anything, mdUUID).Return(false, errors.New("failed to reach API"))
+			},
+			customAssert: func(g *gomega.WithT, ctx *context.ClusterContext) {
+				g.Expect(ctx.VSphereCluster.Spec.ClusterModules).To(gomega.HaveLen(2))
+			},
+		},
 		{
 			name:           "when cluster module creation is called for a resource pool owned by non compute cluster resource",
 			clusterModules: []infrav1.ClusterModule{},
@@ -266,6 +363,8 @@ func TestReconciler_Reconcile(t *testing.T) {
 			if tt.setupMocks != nil {
 				tt.setupMocks(svc)
 			}
+			svc.On("ListMembers", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+			svc.On("Discover", mock.Anything, mock.Anything).Return("", nil).Maybe()
 
 			r := Reconciler{
 				ControllerContext:    controllerCtx,
@@ -0,0 +1,385 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	goctx "context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	clusterutilv1 "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/cloudprovider"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters;vsphereclusteridentities,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+const (
+	credentialsRotationControllerNameShort = "credentials-rotation-controller"
+)
+
+// iniEscaper escapes the characters of the string values of the INI
+// configuration of the CSI driver, the same way as CPIConfig.MarshalINI.
+var iniEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`)
+
+// AddCredentialsRotationControllerToManager adds the controller writing the
+// rotated credentials of the identities of the clusters to the workload
+// clusters to the provided manager.
+func AddCredentialsRotationControllerToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controllerNameLong = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, credentialsRotationControllerNameShort)
+	)
+
+	controllerContext := &context.ControllerContext{
+		ControllerManagerContext: ctx,
+		Name:                     credentialsRotationControllerNameShort,
+		Recorder:                 record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		Logger:                   ctx.Logger.WithName(credentialsRotationControllerNameShort),
+	}
+	r := credentialsRotationReconciler{
+		ControllerContext:  controllerContext,
+		remoteClientGetter: remote.NewClusterClient,
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(credentialsRotationControllerNameShort).
+		For(&infrav1.VSphereCluster{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		// Watch the secrets of the identities only, rather than every secret
+		// of the management cluster.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.identitySecretToVSphereClusters),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isIdentitySecret)),
+		).
+		Watches(
+			&source.Kind{Type: &infrav1.VSphereClusterIdentity{}},
+			handler.EnqueueRequestsFromMapFunc(r.identityToVSphereClusters),
+		).
+		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
+		Complete(r)
+}
+
+// credentialsRotationReconciler keeps the credentials the vSphere cloud
+// provider and CSI driver of a workload cluster use in line with the
+// credentials of the identity of its VSphereCluster, and recycles the
+// sessions of the previous user when the credentials are rotated.
+//
+// Only the secrets which exist in the workload cluster are updated, whether
+// they were created by the addons of the cluster, the CSI topology controller
+// or the cluster template.
+type credentialsRotationReconciler struct {
+	*context.ControllerContext
+
+	remoteClientGetter remote.ClusterClientGetter
+}
+
+func (r credentialsRotationReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ reconcile.Result, reterr error) {
+	logger := r.Logger.WithName(req.Namespace).WithName(req.Name)
+
+	vsphereCluster := &infrav1.VSphereCluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !vsphereCluster.DeletionTimestamp.IsZero() || vsphereCluster.Spec.IdentityRef == nil {
+		return reconcile.Result{}, nil
+	}
+
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, vsphereCluster.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	cluster, err := clusterutilv1.GetOwnerCluster(ctx, r.Client, vsphereCluster.ObjectMeta)
+	if err != nil || cluster == nil {
+		return reconcile.Result{}, err
+	}
+	if annotations.IsPaused(cluster, vsphereCluster) {
+		logger.V(4).Info("VSphereCluster linked to a cluster that is paused")
+		return reconcile.Result{}, nil
+	}
+	if !conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition) {
+		logger.V(4).Info("skipping the rotation of the credentials until the control plane is initialized")
+		return reconcile.Result{}, nil
+	}
+
+	creds, err := identity.GetCredentials(ctx, r.Client, vsphereCluster, r.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The cloud provider and CSI driver authenticate with a username and
+	// password only.
+	if creds.TokenProvider != nil {
+		logger.V(4).Info("skipping the rotation of the credentials of an identity holding a token")
+		return reconcile.Result{}, nil
+	}
+
+	clusterClient, err := r.remoteClientGetter(ctx, credentialsRotationControllerNameShort, r.Client, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		logger.Info("The control plane is not ready yet", "err", err)
+		return reconcile.Result{RequeueAfter: clusterNotReadyRequeueTime}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(vsphereCluster, r.Client)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to init patch helper for VSphereCluster %s/%s", vsphereCluster.Namespace, vsphereCluster.Name)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, vsphereCluster, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			infrav1.CredentialsUpToDateCondition,
+		}}); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+			logger.Error(err, "patch failed")
+		}
+	}()
+
+	previousUsernames, err := rotateWorkloadCredentials(ctx, clusterClient, creds)
+	if err != nil {
+		conditions.MarkFalse(vsphereCluster, infrav1.CredentialsUpToDateCondition, infrav1.CredentialsRotationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return reconcile.Result{}, err
+	}
+	if previousUsernames != nil {
+		// The sessions of the previous user are logged out, and the ones of
+		// a user whose password changed are recycled on their next use.
+		for _, username := range previousUsernames.List() {
+			session.Clear(ctx, vsphereCluster.Spec.Server, username)
+		}
+		logger.Info("Wrote the rotated credentials to the workload cluster")
+		r.Recorder.Eventf(vsphereCluster, "CredentialsRotated", "wrote the rotated credentials of user %s to the workload cluster", creds.Username)
+	}
+	conditions.MarkTrue(vsphereCluster, infrav1.CredentialsUpToDateCondition)
+	if !creds.RenewAt.IsZero() {
+		// Credentials read from a credential source are leased, and written
+		// again to the workload cluster once renewed.
+		return reconcile.Result{RequeueAfter: time.Until(creds.RenewAt)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// rotateWorkloadCredentials writes the credentials to the secrets of the
+// vSphere cloud provider and CSI driver of a workload cluster. It returns the
+// previous usernames of the secrets which were updated, nil if none was.
+func rotateWorkloadCredentials(ctx goctx.Context, c client.Client, creds *identity.Credentials) (sets.String, error) {
+	var previousUsernames sets.String
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: cloudprovider.CSINamespace, Name: cloudprovider.CloudControllerManagerCredentialsSecretName}
+	if err := c.Get(ctx, key, secret); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get secret %s", key)
+	} else if err == nil {
+		patchHelper, err := patch.NewHelper(secret, c)
+		if err != nil {
+			return nil, err
+		}
+		if usernames := rotateCloudProviderCredentials(secret, creds); usernames != nil {
+			if err := patchHelper.Patch(ctx, secret); err != nil {
+				return nil, errors.Wrapf(err, "failed to write the credentials to secret %s", key)
+			}
+			previousUsernames = usernames
+		}
+	}
+
+	secret = &corev1.Secret{}
+	key = client.ObjectKey{Namespace: cloudprovider.CSINamespace, Name: csiConfigSecretName}
+	if err := c.Get(ctx, key, secret); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get secret %s", key)
+	} else if err == nil {
+		patchHelper, err := patch.NewHelper(secret, c)
+		if err != nil {
+			return nil, err
+		}
+		data, usernames := rotateINICredentials(secret.Data[csiConfigSecretKey], creds)
+		if usernames != nil {
+			secret.Data[csiConfigSecretKey] = data
+			if err := patchHelper.Patch(ctx, secret); err != nil {
+				return nil, errors.Wrapf(err, "failed to write the credentials to secret %s", key)
+			}
+			previousUsernames = usernames.Union(previousUsernames)
+		}
+	}
+	return previousUsernames, nil
+}
+
+// rotateCloudProviderCredentials sets the credentials of all the vCenters of
+// the secret of the cloud provider, whose keys are <server>.username and
+// <server>.password. It returns the previous usernames if the secret changed,
+// nil otherwise.
+func rotateCloudProviderCredentials(secret *corev1.Secret, creds *identity.Credentials) sets.String {
+	usernames := sets.NewString()
+	changed := false
+	for key, value := range secret.Data {
+		var desired string
+		switch {
+		case strings.HasSuffix(key, ".username"):
+			usernames.Insert(string(value))
+			desired = creds.Username
+		case strings.HasSuffix(key, ".password"):
+			desired = creds.Password
+		default:
+			continue
+		}
+		if string(value) != desired {
+			secret.Data[key] = []byte(desired)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return usernames
+}
+
+// rotateINICredentials sets the user and password properties of the INI
+// configuration of the CSI driver, leaving the rest of the configuration,
+// e.g. its topology, as is. It returns the previous usernames if the
+// configuration changed, nil otherwise.
+func rotateINICredentials(data []byte, creds *identity.Credentials) ([]byte, sets.String) {
+	var (
+		out               bytes.Buffer
+		previousUsernames sets.String
+		username          string
+		changed           bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, value, ok := strings.Cut(line, "="); ok {
+			name = strings.TrimSpace(name)
+			value = strings.TrimSpace(value)
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			var desired string
+			switch name {
+			case "user":
+				username, desired = value, creds.Username
+			case "password":
+				desired = creds.Password
+			default:
+				fmt.Fprintln(&out, line)
+				continue
+			}
+			if value != desired {
+				changed = true
+				if previousUsernames == nil {
+					previousUsernames = sets.NewString()
+				}
+				previousUsernames.Insert(username)
+				line = fmt.Sprintf("%s = \"%s\"", name, iniEscaper.Replace(desired))
+			}
+		}
+		fmt.Fprintln(&out, line)
+	}
+	if !changed {
+		return data, nil
+	}
+	return out.Bytes(), previousUsernames
+}
+
+// identitySecretToVSphereClusters returns the VSphereClusters using a secret
+// as identity, either directly or through a VSphereClusterIdentity.
+func (r credentialsRotationReconciler) identitySecretToVSphereClusters(o client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, ref := range o.GetOwnerReferences() {
+		if !strings.HasPrefix(ref.APIVersion, infrav1.GroupName+"/") {
+			continue
+		}
+		switch ref.Kind {
+		case "VSphereCluster":
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKey{Namespace: o.GetNamespace(), Name: ref.Name},
+			})
+		case string(infrav1.VSphereClusterIdentityKind):
+			requests = append(requests, r.vsphereClustersUsingIdentity(ref.Name)...)
+		}
+	}
+	return requests
+}
+
+// isIdentitySecret returns true if a secret is owned by a VSphereCluster or a
+// VSphereClusterIdentity, which is the case of the secrets of the identities.
+func isIdentitySecret(o client.Object) bool {
+	for _, ref := range o.GetOwnerReferences() {
+		if !strings.HasPrefix(ref.APIVersion, infrav1.GroupName+"/") {
+			continue
+		}
+		if ref.Kind == "VSphereCluster" || ref.Kind == string(infrav1.VSphereClusterIdentityKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityToVSphereClusters returns the VSphereClusters using a
+// VSphereClusterIdentity, whose secret may have been replaced.
+func (r credentialsRotationReconciler) identityToVSphereClusters(o client.Object) []reconcile.Request {
+	return r.vsphereClustersUsingIdentity(o.GetName())
+}
+
+func (r credentialsRotationReconciler) vsphereClustersUsingIdentity(name string) []reconcile.Request {
+	clusters := &infrav1.VSphereClusterList{}
+	if err := r.Client.List(r, clusters); err != nil {
+		r.Logger.Error(err, "failed to list VSphereClusters")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range clusters.Items {
+		ref := clusters.Items[i].Spec.IdentityRef
+		if ref != nil && ref.Kind == infrav1.VSphereClusterIdentityKind && ref.Name == name {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&clusters.Items[i])})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/packaging/flavorgen/flavors/crs/types"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+)
+
+func Test_rotateCloudProviderCredentials(t *testing.T) {
+	newSecret := func() *corev1.Secret {
+		return &corev1.Secret{
+			Data: map[string][]byte{
+				"vcenter.example.com.username": []byte("old-user"),
+				"vcenter.example.com.password": []byte("old-pass"),
+			},
+		}
+	}
+
+	t.Run("the credentials of the vCenters are replaced", func(t *testing.T) {
+		g := NewWithT(t)
+		secret := newSecret()
+		usernames := rotateCloudProviderCredentials(secret, &identity.Credentials{Username: "new-user", Password: "new-pass"})
+		g.Expect(usernames.List()).To(ConsistOf("old-user"))
+		g.Expect(string(secret.Data["vcenter.example.com.username"])).To(Equal("new-user"))
+		g.Expect(string(secret.Data["vcenter.example.com.password"])).To(Equal("new-pass"))
+	})
+
+	t.Run("a rotated password reports the unchanged user", func(t *testing.T) {
+		g := NewWithT(t)
+		secret := newSecret()
+		usernames := rotateCloudProviderCredentials(secret, &identity.Credentials{Username: "old-user", Password: "new-pass"})
+		g.Expect(usernames.List()).To(ConsistOf("old-user"))
+		g.Expect(string(secret.Data["vcenter.example.com.password"])).To(Equal("new-pass"))
+	})
+
+	t.Run("up to date credentials are left as is", func(t *testing.T) {
+		g := NewWithT(t)
+		secret := newSecret()
+		g.Expect(rotateCloudProviderCredentials(secret, &identity.Credentials{Username: "old-user", Password: "old-pass"})).To(BeNil())
+	})
+}
+
+func Test_rotateINICredentials(t *testing.T) {
+	config := &types.CPIConfig{}
+	config.Global.ClusterID = "ns/cluster"
+	config.VCenter = map[string]types.CPIVCenterConfig{
+		"vcenter.example.com": {Username: "old-user", Password: `old"pass`, Datacenters: "dc"},
+	}
+	config.Labels.Region = "k8s-region"
+	config.Labels.Zone = "k8s-zone"
+	data, err := config.MarshalINI()
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+
+	t.Run("the credentials are replaced and the rest of the configuration kept", func(t *testing.T) {
+		g := NewWithT(t)
+		rotated, usernames := rotateINICredentials(data, &identity.Credentials{Username: "new-user", Password: `new\pass`})
+		g.Expect(usernames.List()).To(ConsistOf("old-user"))
+		g.Expect(string(rotated)).To(ContainSubstring(`user = "new-user"`))
+		g.Expect(string(rotated)).To(ContainSubstring(`password = "new\\pass"`))
+		g.Expect(string(rotated)).To(ContainSubstring(`datacenters = "dc"`))
+		g.Expect(string(rotated)).To(ContainSubstring(`zone = "k8s-zone"`))
+		g.Expect(string(rotated)).NotTo(ContainSubstring("old"))
+	})
+
+	t.Run("up to date credentials are left as is", func(t *testing.T) {
+		g := NewWithT(t)
+		rotated, usernames := rotateINICredentials(data, &identity.Credentials{Username: "old-user", Password: `old"pass`})
+		g.Expect(usernames).To(BeNil())
+		g.Expect(rotated).To(Equal(data))
+	})
+}
+
+func Test_isIdentitySecret(t *testing.T) {
+	secret := func(refs ...metav1.OwnerReference) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "secret", OwnerReferences: refs}}
+	}
+
+	g := NewWithT(t)
+	g.Expect(isIdentitySecret(secret(metav1.OwnerReference{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereCluster", Name: "cluster"}))).To(BeTrue())
+	g.Expect(isIdentitySecret(secret(metav1.OwnerReference{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereClusterIdentity", Name: "identity"}))).To(BeTrue())
+	g.Expect(isIdentitySecret(secret(metav1.OwnerReference{APIVersion: "v1", Kind: "ServiceAccount", Name: "sa"}))).To(BeFalse())
+	g.Expect(isIdentitySecret(secret())).To(BeFalse())
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	goctx "context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	clusterutilv1 "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/packaging/flavorgen/flavors/crs/types"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/cloudprovider"
+)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters;vspheredeploymentzones;vspherefailuredomains,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+const (
+	csiTopologyControllerNameShort = "csi-topology-controller"
+
+	// csiConfigSecretName is the name of the secret of the workload cluster
+	// holding the configuration of the vSphere CSI driver.
+	csiConfigSecretName = "csi-vsphere-config"
+	csiConfigSecretKey  = "csi-vsphere.conf"
+)
+
+// AddCSITopologyControllerToManager adds the controller writing the vSphere
+// CSI configuration of the workload clusters spread over failure domains to
+// the provided manager.
+func AddCSITopologyControllerToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controllerNameLong = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, csiTopologyControllerNameShort)
+	)
+
+	controllerContext := &context.ControllerContext{
+		ControllerManagerContext: ctx,
+		Name:                     csiTopologyControllerNameShort,
+		Recorder:                 record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		Logger:                   ctx.Logger.WithName(csiTopologyControllerNameShort),
+	}
+	r := csiTopologyReconciler{
+		ControllerContext:  controllerContext,
+		remoteClientGetter: remote.NewClusterClient,
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(csiTopologyControllerNameShort).
+		For(&infrav1.VSphereCluster{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		Watches(
+			&source.Kind{Type: &infrav1.VSphereDeploymentZone{}},
+			handler.EnqueueRequestsFromMapFunc(r.deploymentZoneToVSphereClusters),
+		).
+		Watches(
+			&source.Kind{Type: &infrav1.VSphereFailureDomain{}},
+			handler.EnqueueRequestsFromMapFunc(r.failureDomainToVSphereClusters),
+		).
+		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
+		Complete(r)
+}
+
+// csiTopologyReconciler keeps the vSphere CSI configuration of a workload
+// cluster in line with the failure domains of its VSphereCluster, so that the
+// volumes are provisioned in the zone of the nodes consuming them.
+type csiTopologyReconciler struct {
+	*context.ControllerContext
+
+	remoteClientGetter remote.ClusterClientGetter
+}
+
+func (r csiTopologyReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (reconcile.Result, error) {
+	logger := r.Logger.WithName(req.Namespace).WithName(req.Name)
+
+	vsphereCluster := &infrav1.VSphereCluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !vsphereCluster.DeletionTimestamp.IsZero() || len(vsphereCluster.Status.FailureDomains) == 0 {
+		return reconcile.Result{}, nil
+	}
+
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, vsphereCluster.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	cluster, err := clusterutilv1.GetOwnerCluster(ctx, r.Client, vsphereCluster.ObjectMeta)
+	if err != nil || cluster == nil {
+		return reconcile.Result{}, err
+	}
+	if annotations.IsPaused(cluster, vsphereCluster) {
+		logger.V(4).Info("VSphereCluster linked to a cluster that is paused")
+		return reconcile.Result{}, nil
+	}
+	if !conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition) {
+		logger.V(4).Info("skipping the CSI configuration until the control plane is initialized")
+		return reconcile.Result{}, nil
+	}
+
+	config, err := csiConfig(ctx, r.ControllerContext, vsphereCluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	data, err := config.MarshalINI()
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to marshal the CSI configuration")
+	}
+
+	clusterClient, err := r.remoteClientGetter(ctx, csiTopologyControllerNameShort, r.Client, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		logger.Info("The control plane is not ready yet", "err", err)
+		return reconcile.Result{RequeueAfter: clusterNotReadyRequeueTime}, nil
+	}
+
+	secret := &corev1.Secret{}
+	secret.Namespace, secret.Name = cloudprovider.CSINamespace, csiConfigSecretName
+	result, err := controllerutil.CreateOrPatch(ctx, clusterClient, secret, func() error {
+		secret.Type = corev1.SecretTypeOpaque
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[csiConfigSecretKey] = data
+		return nil
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to write the CSI configuration of cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Wrote the CSI configuration", "secret", csiConfigSecretName, "operation", result)
+		r.Recorder.Eventf(vsphereCluster, "CSIConfigured", "%s the CSI topology configuration of the workload cluster", result)
+	}
+	return reconcile.Result{}, nil
+}
+
+// csiConfig returns the vSphere CSI configuration of a VSphereCluster, whose
+// datacenters and topology categories are the ones of its failure domains, if
+// any.
+func csiConfig(ctx goctx.Context, controllerCtx *context.ControllerContext, vsphereCluster *infrav1.VSphereCluster) (*types.CPIConfig, error) {
+	failureDomains := make([]*infrav1.VSphereFailureDomain, 0, len(vsphereCluster.Status.FailureDomains))
+	for name := range vsphereCluster.Status.FailureDomains {
+		zone := &infrav1.VSphereDeploymentZone{}
+		if err := controllerCtx.Client.Get(ctx, client.ObjectKey{Name: name}, zone); err != nil {
+			return nil, errors.Wrapf(err, "failed to get VSphereDeploymentZone %s", name)
+		}
+		failureDomain := &infrav1.VSphereFailureDomain{}
+		if err := controllerCtx.Client.Get(ctx, client.ObjectKey{Name: zone.Spec.FailureDomain}, failureDomain); err != nil {
+			return nil, errors.Wrapf(err, "failed to get VSphereFailureDomain %s", zone.Spec.FailureDomain)
+		}
+		failureDomains = append(failureDomains, failureDomain)
+	}
+
+	username, password := controllerCtx.Username, controllerCtx.Password
+	if vsphereCluster.Spec.IdentityRef != nil {
+		creds, err := identity.GetCredentials(ctx, controllerCtx.Client, vsphereCluster, controllerCtx.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		username, password = creds.Username, creds.Password
+	}
+	return csiTopologyConfig(vsphereCluster, failureDomains, username, password)
+}
+
+// csiTopologyConfig returns the vSphere CSI configuration of the failure
+// domains of a cluster. The failure domains must all use the same region and
+// zone tag categories, which the CSI driver reads the topology of the nodes
+// from.
+func csiTopologyConfig(vsphereCluster *infrav1.VSphereCluster, failureDomains []*infrav1.VSphereFailureDomain, username, password string) (*types.CPIConfig, error) {
+	datacenters := sets.NewString()
+	regions, zones := sets.NewString(), sets.NewString()
+	for _, failureDomain := range failureDomains {
+		datacenters.Insert(failureDomain.Spec.Topology.Datacenter)
+		regions.Insert(failureDomain.Spec.Region.TagCategory)
+		zones.Insert(failureDomain.Spec.Zone.TagCategory)
+	}
+	if regions.Len() > 1 || zones.Len() > 1 {
+		return nil, errors.Errorf("the failure domains of VSphereCluster %s/%s use different tag categories, regions: %s, zones: %s",
+			vsphereCluster.Namespace, vsphereCluster.Name, strings.Join(regions.List(), ","), strings.Join(zones.List(), ","))
+	}
+
+	config := &types.CPIConfig{}
+	config.Global.ClusterID = fmt.Sprintf("%s/%s", vsphereCluster.Namespace, vsphereCluster.Name)
+	config.Global.Insecure = vsphereCluster.Spec.Thumbprint == ""
+	vcenter := types.CPIVCenterConfig{
+		Username:    username,
+		Password:    password,
+		Datacenters: strings.Join(datacenters.List(), ","),
+		Thumbprint:  vsphereCluster.Spec.Thumbprint,
+	}
+	server := vsphereCluster.Spec.Server
+	if endpoint := vsphereCluster.Spec.Endpoint; endpoint != nil {
+		// The CSI driver does not support a path other than the default
+		// one, which the endpoint is then expected to use.
+		server = endpoint.Host
+		if endpoint.Port != 0 && endpoint.Port != infrav1.DefaultVCenterPort {
+			vcenter.Port = strconv.Itoa(int(endpoint.Port))
+		}
+	}
+	config.VCenter = map[string]types.CPIVCenterConfig{server: vcenter}
+	if regions.Len() == 1 && zones.Len() == 1 {
+		config.Labels.Region = regions.List()[0]
+		config.Labels.Zone = zones.List()[0]
+	}
+	return config, nil
+}
+
+// deploymentZoneToVSphereClusters returns the VSphereClusters using the
+// vCenter of a deployment zone.
+func (r csiTopologyReconciler) deploymentZoneToVSphereClusters(o client.Object) []reconcile.Request {
+	zone, ok := o.(*infrav1.VSphereDeploymentZone)
+	if !ok {
+		return nil
+	}
+	clusters := &infrav1.VSphereClusterList{}
+	if err := r.Client.List(r, clusters); err != nil {
+		r.Logger.Error(err, "failed to list VSphereClusters")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range clusters.Items {
+		if clusters.Items[i].Spec.Server == zone.Spec.Server {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&clusters.Items[i])})
+		}
+	}
+	return requests
+}
+
+// failureDomainToVSphereClusters returns the VSphereClusters using the
+// deployment zones of a failure domain.
+func (r csiTopologyReconciler) failureDomainToVSphereClusters(o client.Object) []reconcile.Request {
+	zones := &infrav1.VSphereDeploymentZoneList{}
+	if err := r.Client.List(r, zones); err != nil {
+		r.Logger.Error(err, "failed to list VSphereDeploymentZones")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range zones.Items {
+		if zones.Items[i].Spec.FailureDomain == o.GetName() {
+			requests = append(requests, r.deploymentZoneToVSphereClusters(&zones.Items[i])...)
+		}
+	}
+	return requests
+}
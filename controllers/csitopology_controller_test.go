@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_csiTopologyConfig(t *testing.T) {
+	vsphereCluster := &infrav1.VSphereCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cluster"},
+		Spec: infrav1.VSphereClusterSpec{
+			Server:     "vcenter.example.com",
+			Thumbprint: "AA:BB",
+		},
+	}
+	failureDomain := func(datacenter, regionCategory, zoneCategory string) *infrav1.VSphereFailureDomain {
+		return &infrav1.VSphereFailureDomain{
+			Spec: infrav1.VSphereFailureDomainSpec{
+				Region:   infrav1.FailureDomain{Name: "region", Type: infrav1.DatacenterFailureDomain, TagCategory: regionCategory},
+				Zone:     infrav1.FailureDomain{Name: "zone", Type: infrav1.ComputeClusterFailureDomain, TagCategory: zoneCategory},
+				Topology: infrav1.Topology{Datacenter: datacenter},
+			},
+		}
+	}
+
+	t.Run("the datacenters and tag categories of the failure domains are configured", func(t *testing.T) {
+		g := NewWithT(t)
+		config, err := csiTopologyConfig(vsphereCluster, []*infrav1.VSphereFailureDomain{
+			failureDomain("dc-b", "k8s-region", "k8s-zone"),
+			failureDomain("dc-a", "k8s-region", "k8s-zone"),
+			failureDomain("dc-a", "k8s-region", "k8s-zone"),
+		}, "user", "pass")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(config.Global.ClusterID).To(Equal("ns/cluster"))
+		g.Expect(config.Global.Insecure).To(BeFalse())
+		g.Expect(config.VCenter).To(HaveKey("vcenter.example.com"))
+		vcenter := config.VCenter["vcenter.example.com"]
+		g.Expect(vcenter.Datacenters).To(Equal("dc-a,dc-b"))
+		g.Expect(vcenter.Thumbprint).To(Equal("AA:BB"))
+		g.Expect(vcenter.Username).To(Equal("user"))
+		g.Expect(config.Labels.Region).To(Equal("k8s-region"))
+		g.Expect(config.Labels.Zone).To(Equal("k8s-zone"))
+
+		data, err := config.MarshalINI()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).To(ContainSubstring(`[Labels]`))
+		g.Expect(string(data)).To(ContainSubstring(`zone = "k8s-zone"`))
+	})
+
+	t.Run("failure domains with different tag categories are rejected", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := csiTopologyConfig(vsphereCluster, []*infrav1.VSphereFailureDomain{
+			failureDomain("dc-a", "k8s-region", "k8s-zone"),
+			failureDomain("dc-a", "k8s-region", "other-zone"),
+		}, "user", "pass")
+		g.Expect(err).To(MatchError(ContainSubstring("different tag categories")))
+	})
+
+	t.Run("the host and port of the endpoint are configured", func(t *testing.T) {
+		g := NewWithT(t)
+		withEndpoint := vsphereCluster.DeepCopy()
+		withEndpoint.Spec.Server = "https://vcenter.example.com:8443/sdk"
+		withEndpoint.Spec.Endpoint = &infrav1.VCenterEndpoint{Host: "vcenter.example.com", Port: 8443, Path: "/sdk"}
+		config, err := csiTopologyConfig(withEndpoint, []*infrav1.VSphereFailureDomain{
+			failureDomain("dc-a", "k8s-region", "k8s-zone"),
+		}, "user", "pass")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(config.VCenter).To(HaveKey("vcenter.example.com"))
+		g.Expect(config.VCenter["vcenter.example.com"].Port).To(Equal("8443"))
+	})
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+)
+
+// reconcileMove labels the CA bundle the VSphereCluster references to be
+// moved, then sets its MoveReadyCondition from the objects of the cluster
+// clusterctl move would leave behind. clusterctl move carries over the objects
+// owned by the moved objects, the objects labelled to be moved, and the
+// objects of the CRDs labelled to be moved, e.g. the VSphereClusterIdentities
+// and VSphereDeploymentZones.
+func (r clusterReconciler) reconcileMove(ctx *context.ClusterContext) error {
+	var leftBehind []string
+
+	if ref := ctx.VSphereCluster.Spec.CABundleRef; ref != nil {
+		if err := identity.EnsureCABundleMoveLabel(ctx, ctx.Client, ref, ctx.VSphereCluster.Namespace); err != nil {
+			ctx.Logger.Error(err, "unable to label the CA bundle to be moved", "kind", ref.Kind, "name", ref.Name)
+			leftBehind = append(leftBehind, fmt.Sprintf("%s %s", ref.Kind, ref.Name))
+		}
+	}
+
+	// The VSphereVMs are owned by their VSphereMachine, unless they were
+	// created by other means than a VSphereMachine.
+	vms := &infrav1.VSphereVMList{}
+	if err := ctx.Client.List(ctx, vms,
+		client.InNamespace(ctx.VSphereCluster.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: ctx.Cluster.Name}); err != nil {
+		return errors.Wrapf(err, "unable to list the VSphereVMs of %s", ctx)
+	}
+	for _, vm := range vms.Items {
+		if len(vm.OwnerReferences) == 0 {
+			leftBehind = append(leftBehind, fmt.Sprintf("VSphereVM %s", vm.Name))
+		}
+	}
+
+	// clusterctl move does not carry over the status of the objects.
+	if len(ctx.VSphereCluster.Status.RetainedVMs) > 0 {
+		leftBehind = append(leftBehind, "the retained VMs of status.retainedVMs")
+	}
+	if len(ctx.VSphereCluster.Status.RetainedDisks) > 0 {
+		leftBehind = append(leftBehind, "the retained disks of status.retainedDisks")
+	}
+
+	if len(leftBehind) > 0 {
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.MoveReadyCondition, infrav1.ObjectsLeftBehindReason, clusterv1.ConditionSeverityWarning,
+			"clusterctl move would leave behind %s", strings.Join(leftBehind, ", "))
+		return nil
+	}
+	conditions.MarkTrue(ctx.VSphereCluster, infrav1.MoveReadyCondition)
+	return nil
+}
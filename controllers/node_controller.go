@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pkg/errors"
 	apiv1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -37,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
@@ -122,6 +124,19 @@ func (r nodeLabelReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ c
 		return reconcile.Result{}, nil
 	}
 
+	server, err := r.machineServer(ctx, machine, cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	owned, err := r.Shard.Owns(ctx, r.Client, machine.Namespace, server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logger.V(4).Info("Machine reconciled by another shard, won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	nodeCtx := &nodeContext{
 		Cluster: cluster,
 		Machine: machine,
@@ -129,6 +144,37 @@ func (r nodeLabelReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ c
 	return r.reconcileNormal(nodeCtx)
 }
 
+// machineServer returns the vCenter server of a Machine, which is the server
+// of its VSphereMachine, defaulting to the server of the VSphereCluster of its
+// cluster. It is empty for the Supervisor machines and the machines of the
+// other providers, and when the VSphereMachine or VSphereCluster is not known.
+func (r nodeLabelReconciler) machineServer(ctx goctx.Context, machine *clusterv1.Machine, cluster *clusterv1.Cluster) (string, error) {
+	ref := machine.Spec.InfrastructureRef
+	if ref.Kind != "VSphereMachine" || ref.GroupVersionKind().Group != infrav1.GroupVersion.Group {
+		return "", nil
+	}
+	vsphereMachine := &infrav1.VSphereMachine{}
+	key := client.ObjectKey{Namespace: machine.Namespace, Name: ref.Name}
+	if err := r.Client.Get(ctx, key, vsphereMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get VSphereMachine %s", key)
+	}
+	if vsphereMachine.Spec.Server != "" || cluster == nil || cluster.Spec.InfrastructureRef == nil {
+		return vsphereMachine.Spec.Server, nil
+	}
+	vsphereCluster := &infrav1.VSphereCluster{}
+	key = client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.InfrastructureRef.Name}
+	if err := r.Client.Get(ctx, key, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get VSphereCluster %s", key)
+	}
+	return vsphereCluster.Spec.Server, nil
+}
+
 func (r nodeLabelReconciler) reconcileNormal(ctx *nodeContext) (reconcile.Result, error) {
 	logger := r.Logger.WithName(ctx.Machine.Namespace).WithName(ctx.Machine.Name)
 	logger = logger.WithValues("cluster", ctx.Cluster.Name, "machine", ctx.Machine.Name)
@@ -146,6 +192,15 @@ func (r nodeLabelReconciler) reconcileNormal(ctx *nodeContext) (reconcile.Result
 		return reconcile.Result{}, nil
 	}
 
+	// The Node is named after the hostname of the VM, which is not always the
+	// name of the Machine, so it is looked up by the reference the Machine
+	// gets once its Node joined the cluster, whose update requeues it.
+	if ctx.Machine.Status.NodeRef == nil {
+		logger.V(4).Info("Waiting for the node of the machine to join the cluster")
+		return reconcile.Result{}, nil
+	}
+	nodeName := ctx.Machine.Status.NodeRef.Name
+
 	clusterClient, err := r.remoteClientGetter(r, nodeLabelControllerNameShort, r.Client, client.ObjectKeyFromObject(ctx.Cluster))
 	if err != nil {
 		logger.Info("The control plane is not ready yet", "err", err)
@@ -153,8 +208,8 @@ func (r nodeLabelReconciler) reconcileNormal(ctx *nodeContext) (reconcile.Result
 	}
 
 	node := &apiv1.Node{}
-	if err := clusterClient.Get(r, client.ObjectKey{Name: ctx.Machine.GetName()}, node); err != nil {
-		logger.Error(err, "unable to get node object", "node", ctx.Machine.GetName())
+	if err := clusterClient.Get(r, client.ObjectKey{Name: nodeName}, node); err != nil {
+		logger.Error(err, "unable to get node object", "node", nodeName)
 		return reconcile.Result{}, err
 	}
 
@@ -164,9 +219,18 @@ func (r nodeLabelReconciler) reconcileNormal(ctx *nodeContext) (reconcile.Result
 	}
 
 	nodeLabels := node.GetLabels()
+	if nodeLabels == nil {
+		nodeLabels = map[string]string{}
+	}
 	for k, v := range nodePrefixLabels {
 		nodeLabels[k] = v
 	}
+	// Remove the topology labels the Machine does not have anymore.
+	for _, k := range constants.TopologyInfoLabels {
+		if _, ok := nodePrefixLabels[k]; !ok {
+			delete(nodeLabels, k)
+		}
+	}
 	node.Labels = nodeLabels
 	if err := patchHelper.Patch(r, node); err != nil {
 		logger.Error(err, "unable to patch node object", "node", node.Name)
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/portgroup"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// reconcilePortGroup ensures the distributed port group requested by the
+// NetworkSpec of the VSphereCluster exists, and deletes the port group which
+// is no longer requested.
+func (r clusterReconciler) reconcilePortGroup(ctx *context.ClusterContext, s *session.Session) error {
+	var spec *infrav1.PortGroupSpec
+	if ctx.VSphereCluster.Spec.NetworkSpec != nil {
+		spec = ctx.VSphereCluster.Spec.NetworkSpec.CreatePortGroup
+	}
+	if spec == nil {
+		conditions.Delete(ctx.VSphereCluster, infrav1.PortGroupReadyCondition)
+		return r.deletePortGroup(ctx, s)
+	}
+
+	pg, err := portgroup.Ensure(ctx, s, portGroupName(ctx.VSphereCluster), portGroupOwner(ctx.VSphereCluster), spec)
+	if err != nil {
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.PortGroupReadyCondition, infrav1.PortGroupCreationFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	}
+	ctx.VSphereCluster.Status.PortGroup = pg
+	conditions.MarkTrue(ctx.VSphereCluster, infrav1.PortGroupReadyCondition)
+	return nil
+}
+
+// deletePortGroup deletes the port group created for the cluster. The port
+// group is left in vCenter if VMs are still connected to it, such as the VMs
+// retained by their deletion policy, or if it is no longer marked as created
+// for the cluster.
+func (r clusterReconciler) deletePortGroup(ctx *context.ClusterContext, s *session.Session) error {
+	pg := ctx.VSphereCluster.Status.PortGroup
+	if pg == nil {
+		return nil
+	}
+	if err := portgroup.Delete(ctx, s, pg.Ref, portGroupOwner(ctx.VSphereCluster)); err != nil {
+		switch {
+		case portgroup.IsInUse(err):
+			ctx.Logger.Info("VMs are connected to the port group of the cluster, leaving it in vCenter", "portGroup", pg.Path)
+			r.Recorder.Warnf(ctx.VSphereCluster, "PortGroupRetained", "port group %s was left in vCenter as VMs are connected to it", pg.Path)
+		case portgroup.IsNotOwned(err):
+			ctx.Logger.Info("the port group of the cluster is not marked as created for it, leaving it in vCenter", "portGroup", pg.Path)
+			r.Recorder.Warnf(ctx.VSphereCluster, "PortGroupRetained", "port group %s was left in vCenter as it is not marked as created for the cluster", pg.Path)
+		default:
+			return err
+		}
+	}
+	ctx.VSphereCluster.Status.PortGroup = nil
+	return nil
+}
+
+// portGroupName returns the name of the port group created for a cluster.
+// The namespace and name of the cluster are separated by an underscore, which
+// neither can contain, so that the port groups of two clusters do not get
+// the same name.
+func portGroupName(vsphereCluster *infrav1.VSphereCluster) string {
+	if name := vsphereCluster.Spec.NetworkSpec.CreatePortGroup.Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s_%s", vsphereCluster.Namespace, vsphereCluster.Name)
+}
+
+// portGroupOwner returns the owner the port group created for a cluster is
+// marked with.
+func portGroupOwner(vsphereCluster *infrav1.VSphereCluster) string {
+	return fmt.Sprintf("VSphereCluster %s/%s", vsphereCluster.Namespace, vsphereCluster.Name)
+}
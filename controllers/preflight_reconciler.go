@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/preflight"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// preflightChecksRequeuePeriod is the interval at which the VSphereCluster is
+// requeued while its preflight checks fail.
+const preflightChecksRequeuePeriod = time.Minute
+
+// reconcilePreflightChecks checks, until the VSphereCluster is first ready,
+// that the VSphereMachineTemplates of the cluster can be cloned to compatible
+// port groups with addresses from existing IPAM pools, and that the control
+// plane endpoint can be used. It returns false when a check fails
+// with the Error severity, in which case the VSphereCluster must not become
+// ready, so that no VM of the cluster is cloned.
+func (r clusterReconciler) reconcilePreflightChecks(ctx *context.ClusterContext, s *session.Session) (bool, error) {
+	// The checks are only relevant before the first VM of the cluster is
+	// cloned, and are not run again once they passed.
+	if ctx.VSphereCluster.Status.Ready || conditions.IsTrue(ctx.Cluster, clusterv1.ControlPlaneInitializedCondition) {
+		return true, nil
+	}
+
+	specs, controlPlane, err := r.preflightCloneSpecs(ctx)
+	if err != nil {
+		return false, err
+	}
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var validation *infrav1.PortGroupValidation
+	if networkSpec := ctx.VSphereCluster.Spec.NetworkSpec; networkSpec != nil {
+		validation = networkSpec.PortGroupValidation
+	}
+	var failures []preflight.Failure
+	for _, name := range names {
+		fldPath := fmt.Sprintf("VSphereMachineTemplate %s: spec.template.spec", name)
+		f, err := preflight.CheckCloneSpec(ctx, s, specs[name], fldPath)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to run the preflight checks of VSphereMachineTemplate %s", name)
+		}
+		failures = append(failures, f...)
+
+		f, err = preflight.CheckPortGroups(ctx, s, specs[name], validation, controlPlane.Has(name), fldPath)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to check the port groups of VSphereMachineTemplate %s", name)
+		}
+		failures = append(failures, f...)
+
+		f, err = preflight.CheckAddressPools(ctx, ctx.Client, ctx.VSphereCluster.Namespace, specs[name], fldPath)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to check the IPAM pools of VSphereMachineTemplate %s", name)
+		}
+		failures = append(failures, f...)
+	}
+	failures = append(failures, preflight.CheckControlPlaneEndpoint(ctx, net.DefaultResolver, ctx.VSphereCluster.Spec.ControlPlaneEndpoint)...)
+
+	return markPreflightFailures(ctx.VSphereCluster, infrav1.PreflightChecksSucceededCondition, failures), nil
+}
+
+// markPreflightFailures sets a condition from the failures of preflight
+// checks. It returns false when a check failed with the Error severity.
+func markPreflightFailures(to conditions.Setter, t clusterv1.ConditionType, failures []preflight.Failure) bool {
+	if len(failures) == 0 {
+		conditions.MarkTrue(to, t)
+		return true
+	}
+
+	// The reason of the condition is the one of the first failure of the
+	// highest severity, and the message lists all the failures.
+	first := failures[0]
+	messages := make([]string, 0, len(failures))
+	for _, f := range failures {
+		if first.Severity != clusterv1.ConditionSeverityError && f.Severity == clusterv1.ConditionSeverityError {
+			first = f
+		}
+		messages = append(messages, f.Message)
+	}
+	conditions.MarkFalse(to, t, first.Reason, first.Severity, "%s", strings.Join(messages, "; "))
+	return first.Severity != clusterv1.ConditionSeverityError
+}
+
+// preflightCloneSpecs returns the clone specs of the VSphereMachineTemplates
+// of the control plane and the machine deployments of the cluster, by name,
+// completed with the port group and the default placement of the
+// VSphereCluster, along with the names of the templates of the control plane.
+// The machine templates without datacenter are skipped for clusters with
+// failure domains, as their placement is completed by the failure domain of
+// each machine.
+func (r clusterReconciler) preflightCloneSpecs(ctx *context.ClusterContext) (map[string]*infrav1.VirtualMachineCloneSpec, sets.String, error) {
+	var refs []*corev1.ObjectReference
+	labels := client.MatchingLabels{clusterv1.ClusterLabelName: ctx.Cluster.Name}
+
+	kcpList := &controlplanev1.KubeadmControlPlaneList{}
+	if err := r.Client.List(ctx, kcpList, client.InNamespace(ctx.Cluster.Namespace), labels); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list control plane objects")
+	}
+	controlPlane := sets.NewString()
+	for i := range kcpList.Items {
+		refs = append(refs, &kcpList.Items[i].Spec.MachineTemplate.InfrastructureRef)
+		controlPlane.Insert(kcpList.Items[i].Spec.MachineTemplate.InfrastructureRef.Name)
+	}
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := r.Client.List(ctx, mdList, client.InNamespace(ctx.Cluster.Namespace), labels); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list machine deployment objects")
+	}
+	for i := range mdList.Items {
+		refs = append(refs, &mdList.Items[i].Spec.Template.Spec.InfrastructureRef)
+	}
+
+	specs := map[string]*infrav1.VirtualMachineCloneSpec{}
+	for _, ref := range refs {
+		if ref.Kind != "VSphereMachineTemplate" || !strings.HasPrefix(ref.APIVersion, infrav1.GroupVersion.Group+"/") {
+			continue
+		}
+		if _, ok := specs[ref.Name]; ok {
+			continue
+		}
+		machineTemplate := &infrav1.VSphereMachineTemplate{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: ctx.Cluster.Namespace, Name: ref.Name}, machineTemplate); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, errors.Wrapf(err, "failed to get VSphereMachineTemplate %s", ref.Name)
+		}
+		spec := machineTemplate.Spec.Template.Spec.VirtualMachineCloneSpec.DeepCopy()
+		if spec.Datacenter == "" && len(ctx.VSphereCluster.Status.FailureDomains) > 0 {
+			continue
+		}
+		ctx.VSphereCluster.Status.ApplyPortGroup(spec)
+		ctx.VSphereCluster.Spec.DefaultPlacement.ApplyTo(spec)
+		specs[ref.Name] = spec
+	}
+	return specs, controlPlane, nil
+}
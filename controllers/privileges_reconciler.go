@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/feature"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/privileges"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// reconcilePrivileges checks that the vCenter user of the cluster has the
+// privileges required for the features the cluster uses, on the datacenters
+// of its machine templates, or on the root folder if none of them sets one.
+func (r clusterReconciler) reconcilePrivileges(ctx *context.ClusterContext, s *session.Session) error {
+	specs, _, err := r.preflightCloneSpecs(ctx)
+	if err != nil {
+		return err
+	}
+	cloneSpecs := make([]*infrav1.VirtualMachineCloneSpec, 0, len(specs))
+	for _, spec := range specs {
+		cloneSpecs = append(cloneSpecs, spec)
+	}
+	required := privileges.Required(clusterFeatures(ctx.VSphereCluster, cloneSpecs)...)
+
+	finder := find.NewFinder(s.Client.Client, false)
+	datacenters := sets.NewString()
+	var entities []types.ManagedObjectReference
+	for _, spec := range cloneSpecs {
+		if spec.Datacenter == "" || datacenters.Has(spec.Datacenter) {
+			continue
+		}
+		datacenters.Insert(spec.Datacenter)
+		dc, err := finder.Datacenter(ctx, spec.Datacenter)
+		if err != nil {
+			// The missing inventory is reported by the preflight checks.
+			continue
+		}
+		entities = append(entities, dc.Reference())
+	}
+
+	missing, err := privileges.Missing(ctx, s, required, entities...)
+	if err != nil {
+		return errors.Wrap(err, "unable to check the privileges of the vCenter user")
+	}
+	if len(missing) > 0 {
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.PrivilegesAvailableCondition, infrav1.PrivilegesMissingReason, clusterv1.ConditionSeverityWarning,
+			"the vCenter user lacks the privileges %s", strings.Join(missing, ", "))
+		return nil
+	}
+	conditions.MarkTrue(ctx.VSphereCluster, infrav1.PrivilegesAvailableCondition)
+	return nil
+}
+
+// clusterFeatures returns the optional features requiring privileges a
+// cluster uses, according to the feature gates, its spec and its clone specs.
+func clusterFeatures(vsphereCluster *infrav1.VSphereCluster, specs []*infrav1.VirtualMachineCloneSpec) []privileges.Feature {
+	var features []privileges.Feature
+	if feature.Gates.Enabled(feature.NodeAntiAffinity) {
+		features = append(features, privileges.ClusterModules)
+	}
+	var tags, contentLibrary, storagePolicies, vmRecovery, folderTemplates bool
+	for _, spec := range specs {
+		tags = tags || len(spec.TagIDs) > 0
+		storagePolicies = storagePolicies || spec.StoragePolicyName != ""
+		vmRecovery = vmRecovery || spec.RecoveryPolicy == infrav1.VMRecoveryReregister || spec.RecoveryPolicy == infrav1.VMRecoveryRecreate
+		folderTemplates = folderTemplates || infrav1.IsFolderTemplate(spec.Folder)
+		for _, cdrom := range spec.CDROMs {
+			contentLibrary = contentLibrary || cdrom.ContentLibraryItem != ""
+		}
+	}
+	if tags {
+		features = append(features, privileges.Tags)
+	}
+	if contentLibrary {
+		features = append(features, privileges.ContentLibrary)
+	}
+	if storagePolicies {
+		features = append(features, privileges.StoragePolicies)
+	}
+	if vmRecovery {
+		features = append(features, privileges.VMRecovery)
+	}
+	if networkSpec := vsphereCluster.Spec.NetworkSpec; networkSpec != nil && networkSpec.CreatePortGroup != nil {
+		features = append(features, privileges.PortGroups)
+	}
+	if folderTemplates {
+		features = append(features, privileges.FolderTemplates)
+	}
+	return features
+}
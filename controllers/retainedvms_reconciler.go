@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// reconcileRetainedVMs stops reporting the VMs retained by the deletion
+// policy of their VSphereVM once they are removed from the vCenter of the
+// cluster. The VMs retained on other vCenters are reported until the
+// VSphereCluster is deleted.
+//
+// The VMs are retained concurrently by the VSphereVM controller, so the status
+// is patched with an optimistic lock on a copy of the VSphereCluster, and the
+// list is left as is in the context for the final patch not to overwrite the
+// VMs retained in the meantime.
+func (r clusterReconciler) reconcileRetainedVMs(ctx *context.ClusterContext, s *session.Session) error {
+	if len(ctx.VSphereCluster.Status.RetainedVMs) == 0 {
+		return nil
+	}
+
+	retained := make([]infrav1.RetainedVM, 0, len(ctx.VSphereCluster.Status.RetainedVMs))
+	for _, vm := range ctx.VSphereCluster.Status.RetainedVMs {
+		if vm.Server == ctx.VSphereCluster.Spec.Server && vm.BiosUUID != "" {
+			ref, err := s.FindByBIOSUUID(ctx, vm.BiosUUID)
+			if err != nil {
+				ctx.Logger.Error(err, "unable to find retained VM", "name", vm.Name, "biosUUID", vm.BiosUUID)
+			} else if ref == nil {
+				ctx.Logger.Info("retained VM was removed from vCenter", "name", vm.Name, "biosUUID", vm.BiosUUID)
+				continue
+			}
+		}
+		retained = append(retained, vm)
+	}
+	if len(retained) == len(ctx.VSphereCluster.Status.RetainedVMs) {
+		return nil
+	}
+	if len(retained) == 0 {
+		retained = nil
+	}
+
+	vsphereCluster := ctx.VSphereCluster.DeepCopy()
+	base := vsphereCluster.DeepCopy()
+	vsphereCluster.Status.RetainedVMs = retained
+	if err := r.Client.Status().Patch(ctx, vsphereCluster, ctrlclient.MergeFromWithOptions(base, ctrlclient.MergeFromWithOptimisticLock{})); err != nil {
+		return errors.Wrapf(err, "failed to remove the deleted VMs from the retained VMs of %s", ctx)
+	}
+	return nil
+}
@@ -117,6 +117,17 @@ func (r ServiceAccountReconciler) Reconcile(ctx goctx.Context, req reconcile.Req
 		return reconcile.Result{}, err
 	}
 
+	// The vCenter server of the Supervisor clusters is not known, so they are
+	// only sharded by namespace.
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, "")
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		r.Logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile", "cluster", clusterKey)
+		return reconcile.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(vsphereCluster, r.Client)
 	if err != nil {
@@ -221,6 +232,14 @@ func (r ServiceAccountReconciler) ReconcileNormal(ctx *vmwarecontext.GuestCluste
 // Ensure service accounts from provider spec is created.
 func (r ServiceAccountReconciler) ensureProviderServiceAccounts(ctx *vmwarecontext.GuestClusterContext, pSvcAccounts []vmwarev1.ProviderServiceAccount) error {
 	for _, pSvcAccount := range pSvcAccounts {
+		// In the audit mode, only report the permissions that would be granted
+		if pSvcAccount.Spec.Mode == vmwarev1.ProviderServiceAccountModeAudit {
+			if err := r.auditProviderServiceAccount(ctx, pSvcAccount); err != nil {
+				return errors.Wrapf(err, "unable to audit provider serviceaccount %s", pSvcAccount.Name)
+			}
+			continue
+		}
+
 		// 1. Create service accounts by the name specified in Provider Spec
 		if err := r.ensureServiceAccount(ctx.ClusterContext, pSvcAccount); err != nil {
 			return errors.Wrapf(err, "unable to create provider serviceaccount %s", pSvcAccount.Name)
@@ -248,6 +267,18 @@ func (r ServiceAccountReconciler) ensureProviderServiceAccounts(ctx *vmwareconte
 		if err := r.syncServiceAccountSecret(ctx, pSvcAccount); err != nil {
 			return errors.Wrapf(err, "unable to sync secret for provider serviceaccount %s", pSvcAccount.Name)
 		}
+
+		// 7. Sync the rolebindings of the target
+		if err := r.syncTargetRoleBindings(ctx, pSvcAccount); err != nil {
+			return errors.Wrapf(err, "unable to sync target rolebindings for provider serviceaccount %s", pSvcAccount.Name)
+		}
+
+		// 8. Clear the report of the audit mode
+		if pSvcAccount.Status.Audit != nil {
+			if err := patchProviderServiceAccountAudit(ctx, pSvcAccount, nil); err != nil {
+				return errors.Wrapf(err, "unable to clear the audit of provider serviceaccount %s", pSvcAccount.Name)
+			}
+		}
 	}
 	return nil
 }
@@ -374,21 +405,8 @@ func (r ServiceAccountReconciler) syncServiceAccountSecret(ctx *vmwarecontext.Gu
 	}
 
 	// Create the target namespace if it is not existing
-	targetNamespace := &corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: pSvcAccount.Spec.TargetNamespace,
-		},
-	}
-
-	if err = ctx.GuestClient.Get(ctx, client.ObjectKey{Name: pSvcAccount.Spec.TargetNamespace}, targetNamespace); err != nil {
-		if apierrors.IsNotFound(err) {
-			err = ctx.GuestClient.Create(ctx, targetNamespace)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
+	if err = ensureGuestNamespace(ctx, pSvcAccount.Spec.TargetNamespace); err != nil {
+		return err
 	}
 
 	targetSecret := &corev1.Secret{
@@ -23,6 +23,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	capiutil "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -128,6 +131,97 @@ func unitTestsReconcileNormal() {
 				assertProviderServiceAccountsCondition(ctx.VSphereCluster, corev1.ConditionTrue, "", "", "")
 			})
 		})
+		Context("When target rolebindings are templated", func() {
+			BeforeEach(func() {
+				pSvcAccount := initObjects[1].(*vmwarev1.ProviderServiceAccount)
+				pSvcAccount.Spec.TargetRoleBindings = []vmwarev1.TargetRoleBindingTemplate{
+					{
+						Name:       "{{ .Name }}-view",
+						Namespaces: []string{testTargetNS, "other"},
+						RoleRef: rbacv1.RoleRef{
+							APIGroup: rbacv1.GroupName,
+							Kind:     "ClusterRole",
+							Name:     "view",
+						},
+						Subjects: []rbacv1.Subject{
+							{
+								Kind:      "ServiceAccount",
+								Name:      "reader",
+								Namespace: "{{ .Namespace }}",
+							},
+						},
+					},
+				}
+			})
+			It("Should create the rolebindings in the target cluster", func() {
+				for _, ns := range []string{testTargetNS, "other"} {
+					roleBinding := &rbacv1.RoleBinding{}
+					key := client.ObjectKey{Namespace: ns, Name: vsphereCluster.GetName() + "-view"}
+					Expect(ctx.GuestClient.Get(ctx, key, roleBinding)).To(Succeed())
+					Expect(roleBinding.RoleRef.Name).To(Equal("view"))
+					Expect(roleBinding.Subjects).To(ConsistOf(rbacv1.Subject{Kind: "ServiceAccount", Name: "reader", Namespace: ns}))
+				}
+				assertProviderServiceAccountsCondition(ctx.VSphereCluster, corev1.ConditionTrue, "", "", "")
+			})
+			It("Should delete the rolebindings no longer templated", func() {
+				stale := &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: testTargetNS,
+						Name:      "stale",
+						Labels:    map[string]string{providerServiceAccountLabelName: vsphereCluster.GetName()},
+					},
+					RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit"},
+				}
+				Expect(ctx.GuestClient.Create(ctx, stale)).To(Succeed())
+
+				_, err := reconciler.ReconcileNormal(ctx.GuestClusterContext)
+				Expect(err).NotTo(HaveOccurred())
+				err = ctx.GuestClient.Get(ctx, client.ObjectKeyFromObject(stale), &rbacv1.RoleBinding{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+		Context("When the ProviderServiceAccount is in the audit mode", func() {
+			BeforeEach(func() {
+				pSvcAccount := initObjects[1].(*vmwarev1.ProviderServiceAccount)
+				pSvcAccount.Spec.Mode = vmwarev1.ProviderServiceAccountModeAudit
+				pSvcAccount.Spec.TargetRoleBindings = []vmwarev1.TargetRoleBindingTemplate{
+					{
+						RoleRef: rbacv1.RoleRef{
+							APIGroup: rbacv1.GroupName,
+							Kind:     "ClusterRole",
+							Name:     "view",
+						},
+						Subjects: []rbacv1.Subject{
+							{
+								Kind:      "ServiceAccount",
+								Name:      "reader",
+								Namespace: "{{ .TargetNamespace }}",
+							},
+						},
+					},
+				}
+			})
+			It("Should only report the permissions that would be granted", func() {
+				By("Not creating the service account")
+				err := ctx.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: vsphereCluster.GetName()}, &corev1.ServiceAccount{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				assertTargetNamespace(ctx, ctx.GuestClient, testTargetNS, false)
+
+				By("Reporting the permissions in the status")
+				pSvcAccount := &vmwarev1.ProviderServiceAccount{}
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: vsphereCluster.GetName()}, pSvcAccount)).To(Succeed())
+				Expect(pSvcAccount.Status.Audit).NotTo(BeNil())
+				Expect(pSvcAccount.Status.Audit.Rules).To(Equal(pSvcAccount.Spec.Rules))
+				Expect(pSvcAccount.Status.Audit.TargetSecret).To(Equal(testTargetNS + "/" + testTargetSecret))
+				Expect(pSvcAccount.Status.Audit.TargetRoleBindings).To(ConsistOf(vmwarev1.TargetRoleBinding{
+					Namespace: testTargetNS,
+					Name:      vsphereCluster.GetName(),
+					RoleRef:   pSvcAccount.Spec.TargetRoleBindings[0].RoleRef,
+					Subjects:  []rbacv1.Subject{{Kind: "ServiceAccount", Name: "reader", Namespace: testTargetNS}},
+				}))
+				assertProviderServiceAccountsCondition(ctx.VSphereCluster, corev1.ConditionTrue, "", "", "")
+			})
+		})
 	})
 }
 
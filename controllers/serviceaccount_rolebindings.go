@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmwarev1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/vmware/v1beta1"
+	vmwarecontext "sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
+)
+
+// providerServiceAccountLabelName is the label of the RoleBindings created in
+// the target cluster for a ProviderServiceAccount, used to remove the ones no
+// longer rendered from its templates.
+const providerServiceAccountLabelName = "vmware.infrastructure.cluster.x-k8s.io/provider-serviceaccount"
+
+// targetRoleBindingData is the data the templates of the target RoleBindings
+// of a ProviderServiceAccount are rendered with.
+type targetRoleBindingData struct {
+	// Name is the name of the ProviderServiceAccount.
+	Name string
+
+	// Namespace is the namespace of the RoleBinding.
+	Namespace string
+
+	// TargetNamespace is the namespace of the target secret.
+	TargetNamespace string
+}
+
+// renderTargetRoleBindings renders the RoleBindings of the target cluster of
+// a ProviderServiceAccount, one per template and namespace.
+func renderTargetRoleBindings(pSvcAccount vmwarev1.ProviderServiceAccount) ([]vmwarev1.TargetRoleBinding, error) {
+	var bindings []vmwarev1.TargetRoleBinding
+	for i, tpl := range pSvcAccount.Spec.TargetRoleBindings {
+		namespaces := tpl.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{pSvcAccount.Spec.TargetNamespace}
+		}
+		for _, namespace := range namespaces {
+			binding, err := renderTargetRoleBinding(tpl, targetRoleBindingData{
+				Name:            pSvcAccount.Name,
+				Namespace:       namespace,
+				TargetNamespace: pSvcAccount.Spec.TargetNamespace,
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to render target rolebinding %d in namespace %s", i, namespace)
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings, nil
+}
+
+func renderTargetRoleBinding(tpl vmwarev1.TargetRoleBindingTemplate, data targetRoleBindingData) (vmwarev1.TargetRoleBinding, error) {
+	var errs []error
+	render := func(value string) string {
+		rendered, err := renderTargetRoleBindingTemplate(value, data)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return rendered
+	}
+
+	name := tpl.Name
+	if name == "" {
+		name = data.Name
+	}
+	binding := vmwarev1.TargetRoleBinding{
+		Namespace: data.Namespace,
+		Name:      render(name),
+		RoleRef:   tpl.RoleRef,
+	}
+	binding.RoleRef.Name = render(tpl.RoleRef.Name)
+	for _, subject := range tpl.Subjects {
+		subject.Name = render(subject.Name)
+		subject.Namespace = render(subject.Namespace)
+		binding.Subjects = append(binding.Subjects, subject)
+	}
+	if len(errs) > 0 {
+		return vmwarev1.TargetRoleBinding{}, errs[0]
+	}
+	return binding, nil
+}
+
+func renderTargetRoleBindingTemplate(value string, data targetRoleBindingData) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tpl, err := template.New("").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid template %q", value)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "invalid template %q", value)
+	}
+	return b.String(), nil
+}
+
+// syncTargetRoleBindings creates or updates the RoleBindings of the target
+// cluster of a ProviderServiceAccount and deletes the ones it created which
+// are no longer rendered from its templates.
+func (r ServiceAccountReconciler) syncTargetRoleBindings(ctx *vmwarecontext.GuestClusterContext, pSvcAccount vmwarev1.ProviderServiceAccount) error {
+	logger := ctx.Logger.WithValues("providerserviceaccount", pSvcAccount.Name)
+
+	bindings, err := renderTargetRoleBindings(pSvcAccount)
+	if err != nil {
+		return err
+	}
+
+	desired := map[client.ObjectKey]bool{}
+	for _, binding := range bindings {
+		desired[client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}] = true
+
+		if err := ensureGuestNamespace(ctx, binding.Namespace); err != nil {
+			return err
+		}
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      binding.Name,
+				Namespace: binding.Namespace,
+			},
+		}
+		logger.V(4).Info("Creating or updating rolebinding in cluster", "namespace", roleBinding.Namespace, "name", roleBinding.Name)
+		_, err := controllerutil.CreateOrPatch(ctx, ctx.GuestClient, roleBinding, func() error {
+			if roleBinding.Labels == nil {
+				roleBinding.Labels = map[string]string{}
+			}
+			roleBinding.Labels[providerServiceAccountLabelName] = pSvcAccount.Name
+			// The role of a RoleBinding cannot be changed, the RoleBinding
+			// is recreated below if it has.
+			if roleBinding.CreationTimestamp.IsZero() {
+				roleBinding.RoleRef = binding.RoleRef
+			}
+			roleBinding.Subjects = binding.Subjects
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "unable to sync rolebinding %s/%s", binding.Namespace, binding.Name)
+		}
+		if roleBinding.RoleRef != binding.RoleRef {
+			logger.Info("Recreating rolebinding in cluster with a new role", "namespace", roleBinding.Namespace, "name", roleBinding.Name)
+			if err := ctx.GuestClient.Delete(ctx, roleBinding); err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "unable to delete rolebinding %s/%s", binding.Namespace, binding.Name)
+			}
+			roleBinding = &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      binding.Name,
+					Namespace: binding.Namespace,
+					Labels:    map[string]string{providerServiceAccountLabelName: pSvcAccount.Name},
+				},
+				RoleRef:  binding.RoleRef,
+				Subjects: binding.Subjects,
+			}
+			if err := ctx.GuestClient.Create(ctx, roleBinding); err != nil {
+				return errors.Wrapf(err, "unable to create rolebinding %s/%s", binding.Namespace, binding.Name)
+			}
+		}
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := ctx.GuestClient.List(ctx, roleBindings, client.MatchingLabels{providerServiceAccountLabelName: pSvcAccount.Name}); err != nil {
+		return err
+	}
+	for i := range roleBindings.Items {
+		roleBinding := &roleBindings.Items[i]
+		if desired[client.ObjectKeyFromObject(roleBinding)] {
+			continue
+		}
+		logger.Info("Deleting rolebinding in cluster", "namespace", roleBinding.Namespace, "name", roleBinding.Name)
+		if err := ctx.GuestClient.Delete(ctx, roleBinding); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to delete rolebinding %s/%s", roleBinding.Namespace, roleBinding.Name)
+		}
+	}
+	return nil
+}
+
+// ensureGuestNamespace creates a namespace of the target cluster if it does
+// not exist.
+func ensureGuestNamespace(ctx *vmwarecontext.GuestClusterContext, name string) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	if err := ctx.GuestClient.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := ctx.GuestClient.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditProviderServiceAccount reports the permissions a ProviderServiceAccount
+// in the audit mode would grant in its status, without creating anything.
+func (r ServiceAccountReconciler) auditProviderServiceAccount(ctx *vmwarecontext.GuestClusterContext, pSvcAccount vmwarev1.ProviderServiceAccount) error {
+	bindings, err := renderTargetRoleBindings(pSvcAccount)
+	if err != nil {
+		return err
+	}
+	audit := &vmwarev1.ProviderServiceAccountAudit{
+		TargetSecret:       fmt.Sprintf("%s/%s", pSvcAccount.Spec.TargetNamespace, pSvcAccount.Spec.TargetSecretName),
+		TargetRoleBindings: bindings,
+	}
+	if len(pSvcAccount.Spec.Rules) > 0 {
+		audit.Rules = pSvcAccount.Spec.Rules
+	}
+	if reflect.DeepEqual(pSvcAccount.Status.Audit, audit) {
+		return nil
+	}
+
+	if err := patchProviderServiceAccountAudit(ctx, pSvcAccount, audit); err != nil {
+		return err
+	}
+	ctx.Recorder.Eventf(&pSvcAccount, "Audited", "would grant %d rules to the service account and create %d rolebindings in the target cluster",
+		len(audit.Rules), len(audit.TargetRoleBindings))
+	return nil
+}
+
+// patchProviderServiceAccountAudit sets the audit report in the status of a
+// ProviderServiceAccount.
+func patchProviderServiceAccountAudit(ctx *vmwarecontext.GuestClusterContext, pSvcAccount vmwarev1.ProviderServiceAccount, audit *vmwarev1.ProviderServiceAccountAudit) error {
+	patchHelper, err := patch.NewHelper(&pSvcAccount, ctx.Client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init patch helper for provider serviceaccount %s", pSvcAccount.Name)
+	}
+	pSvcAccount.Status.Audit = audit
+	return patchHelper.Patch(ctx, &pSvcAccount)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/template"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// templateReplicationRequeuePeriod is the interval at which the VSphereCluster
+// is requeued while some of its template replicas are not ready.
+const templateReplicationRequeuePeriod = 30 * time.Second
+
+// reconcileTemplateReplicas ensures the templates configured in the
+// TemplateReplication of the VSphereCluster have a replica on the datastore
+// of each failure domain of the cluster. Replicas are never deleted, since
+// they may be shared with other clusters.
+func (r clusterReconciler) reconcileTemplateReplicas(ctx *context.ClusterContext, s *session.Session) reconcile.Result {
+	replication := ctx.VSphereCluster.Spec.TemplateReplication
+	if replication == nil || len(ctx.VSphereCluster.Status.FailureDomains) == 0 {
+		ctx.VSphereCluster.Status.TemplateReplicas = nil
+		conditions.Delete(ctx.VSphereCluster, infrav1.TemplateReplicasReadyCondition)
+		return reconcile.Result{}
+	}
+
+	failureDomainNames := make([]string, 0, len(ctx.VSphereCluster.Status.FailureDomains))
+	for name := range ctx.VSphereCluster.Status.FailureDomains {
+		failureDomainNames = append(failureDomainNames, name)
+	}
+	sort.Strings(failureDomainNames)
+
+	type result struct {
+		replica template.Replica
+		err     error
+	}
+	// Failure domains sharing a datastore share the replicas.
+	results := map[string]result{}
+
+	replicas := []infrav1.TemplateReplicaStatus{}
+	pending, failed := 0, 0
+	for _, name := range failureDomainNames {
+		topology, err := r.getFailureDomainTopology(ctx, name)
+		if err != nil {
+			ctx.Logger.Error(err, "unable to replicate templates to failure domain", "name", name)
+			failed++
+			continue
+		}
+		// The VMs of failure domains without datastore are created on the
+		// datastore of their clone spec, there is nothing to replicate to.
+		if topology.Datastore == "" {
+			continue
+		}
+
+		for _, tpl := range replication.Templates {
+			key := strings.Join([]string{topology.Datacenter, tpl, topology.Datastore}, "/")
+			res, ok := results[key]
+			if !ok {
+				taskRef := previousTemplateReplicaTaskRef(ctx.VSphereCluster, tpl, topology.Datastore)
+				res.replica, res.err = template.EnsureReplica(ctx, s, topology.Datacenter, tpl, topology.Datastore, taskRef)
+				results[key] = res
+			}
+
+			status := infrav1.TemplateReplicaStatus{
+				Template:      tpl,
+				FailureDomain: name,
+				Datastore:     topology.Datastore,
+				Name:          res.replica.Name,
+				InstanceUUID:  res.replica.InstanceUUID,
+				Ready:         res.replica.Ready(),
+				TaskRef:       res.replica.TaskRef,
+			}
+			switch {
+			case res.err != nil:
+				status.Message = res.err.Error()
+				failed++
+			case !status.Ready:
+				status.Message = "replica is being created"
+				pending++
+			}
+			replicas = append(replicas, status)
+		}
+	}
+	ctx.VSphereCluster.Status.TemplateReplicas = replicas
+
+	switch {
+	case failed > 0:
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.TemplateReplicasReadyCondition, infrav1.TemplateReplicationFailedReason, clusterv1.ConditionSeverityWarning,
+			"%d of the template replicas could not be created", failed)
+	case pending > 0:
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.TemplateReplicasReadyCondition, infrav1.TemplateReplicationInProgressReason, clusterv1.ConditionSeverityInfo,
+			"%d of the template replicas are being created", pending)
+	default:
+		conditions.MarkTrue(ctx.VSphereCluster, infrav1.TemplateReplicasReadyCondition)
+		return reconcile.Result{}
+	}
+	return reconcile.Result{RequeueAfter: templateReplicationRequeuePeriod}
+}
+
+// getFailureDomainTopology returns the topology of the VSphereFailureDomain
+// of the VSphereDeploymentZone with the given name.
+func (r clusterReconciler) getFailureDomainTopology(ctx *context.ClusterContext, name string) (*infrav1.Topology, error) {
+	vsphereDeploymentZone := &infrav1.VSphereDeploymentZone{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, vsphereDeploymentZone); err != nil {
+		return nil, errors.Wrapf(err, "unable to get VSphereDeploymentZone %s", name)
+	}
+	vsphereFailureDomain := &infrav1.VSphereFailureDomain{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: vsphereDeploymentZone.Spec.FailureDomain}, vsphereFailureDomain); err != nil {
+		return nil, errors.Wrapf(err, "unable to get VSphereFailureDomain %s", vsphereDeploymentZone.Spec.FailureDomain)
+	}
+	return &vsphereFailureDomain.Spec.Topology, nil
+}
+
+// previousTemplateReplicaTaskRef returns the in-flight task creating the
+// replica of the template on the datastore, as reported by the status.
+func previousTemplateReplicaTaskRef(vsphereCluster *infrav1.VSphereCluster, tpl, datastore string) string {
+	for _, replica := range vsphereCluster.Status.TemplateReplicas {
+		if replica.Template == tpl && replica.Datastore == datastore && replica.TaskRef != "" {
+			return replica.TaskRef
+		}
+	}
+	return ""
+}
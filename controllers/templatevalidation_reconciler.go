@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/preflight"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// templateValidationPeriod is the interval at which the templates of the
+// VSphereMachineTemplates of a cluster are validated.
+const templateValidationPeriod = 10 * time.Minute
+
+// validatedTemplates caches the properties of the validated templates, so that
+// the clusters reconciled more often than templateValidationPeriod, and the
+// machine templates sharing a template, do not retrieve them from vCenter
+// every time.
+var validatedTemplates = preflight.NewTemplateCache(templateValidationPeriod)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspheremachinetemplates/status,verbs=get;update;patch
+
+// reconcileTemplateValidation validates the templates of the
+// VSphereMachineTemplates of the control plane and the machine deployments of
+// the cluster, and sets their TemplateValid condition. Unlike the preflight
+// checks, the templates are validated for the whole life of the cluster, as
+// they may be changed or replaced in vCenter at any time.
+func (r clusterReconciler) reconcileTemplateValidation(ctx *context.ClusterContext, s *session.Session) (reconcile.Result, error) {
+	specs, _, err := r.preflightCloneSpecs(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		// The templates cloned across vCenters are not in the inventory of
+		// the session.
+		if specs[name].TemplateSource != nil {
+			continue
+		}
+		if err := r.validateMachineTemplate(ctx, s, name, specs[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return reconcile.Result{RequeueAfter: templateValidationPeriod}, kerrors.NewAggregate(errs)
+}
+
+// validateMachineTemplate validates the template of a VSphereMachineTemplate
+// with the placement of the cluster and sets its TemplateValid condition.
+func (r clusterReconciler) validateMachineTemplate(ctx *context.ClusterContext, s *session.Session, name string, spec *infrav1.VirtualMachineCloneSpec) error {
+	failures, err := validatedTemplates.CheckTemplate(ctx, s, spec, "spec.template.spec")
+	if err != nil {
+		return errors.Wrapf(err, "unable to validate the template of VSphereMachineTemplate %s", name)
+	}
+
+	machineTemplate := &infrav1.VSphereMachineTemplate{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: ctx.Cluster.Namespace, Name: name}, machineTemplate); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get VSphereMachineTemplate %s", name)
+	}
+	patchHelper, err := patch.NewHelper(machineTemplate, r.Client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init patch helper for VSphereMachineTemplate %s", name)
+	}
+	if !markPreflightFailures(machineTemplate, infrav1.TemplateValidCondition, failures) {
+		ctx.Logger.Info("template of VSphereMachineTemplate is not valid", "name", name, "template", spec.Template)
+	}
+	return patchHelper.Patch(ctx, machineTemplate)
+}
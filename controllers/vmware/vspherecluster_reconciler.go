@@ -78,6 +78,17 @@ func (r ClusterReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctr
 		return reconcile.Result{}, err
 	}
 
+	// The vCenter server of the Supervisor clusters is not known, so they are
+	// only sharded by namespace.
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, "")
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		r.Logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile", "key", req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
 	// Fetch the Cluster.
 	cluster, err := clusterutilv1.GetOwnerCluster(r, r.Client, vsphereCluster.ObjectMeta)
 	if err != nil {
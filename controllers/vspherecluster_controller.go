@@ -22,16 +22,16 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	clusterutilv1 "sigs.k8s.io/cluster-api/util"
-	"sigs.k8s.io/cluster-api/util/annotations"
-	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
@@ -40,16 +40,23 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	inframanager "sigs.k8s.io/cluster-api-provider-vsphere/pkg/manager"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/resync"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/vmoperator"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
 
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch;update
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusteridentities,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=haproxyloadbalancers,verbs=get
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspheresettings,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools;globalinclusterippools,verbs=get
+// +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=topology.tanzu.vmware.com,resources=availabilityzones,verbs=get;list;watch
 // +kubebuilder:rbac:groups=topology.tanzu.vmware.com,resources=availabilityzones/status,verbs=get;list;watch
 
@@ -99,7 +106,7 @@ func AddClusterControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 				&source.Kind{Type: &vmwarev1.VSphereMachine{}},
 				handler.EnqueueRequestsFromMapFunc(reconciler.VSphereMachineToCluster),
 			).
-			WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+			WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconcilesFor(context.VSphereClusterControllerName)}).
 			Complete(reconciler)
 	}
 
@@ -108,30 +115,21 @@ func AddClusterControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 		clusterModuleReconciler: NewReconciler(controllerContext),
 	}
 	clusterToInfraFn := clusterToInfrastructureMapFunc(ctx)
+	resyncHandler := resync.NewHandler(controllerNameShort, ctx.SyncPeriodFor(context.VSphereClusterControllerName))
+	resyncHandler.ResyncPeriod = ctx.ResyncPeriod
 	c, err := ctrl.NewControllerManagedBy(mgr).
-		// Watch the controlled, infrastructure resource.
-		For(clusterControlledType).
+		// Watch the controlled, infrastructure resource. The resyncs are
+		// queued by the resync handler below, at the sync period of the
+		// controller.
+		For(clusterControlledType, builder.WithPredicates(resync.SkipResyncs())).
+		Watches(
+			&source.Kind{Type: clusterControlledType},
+			resyncHandler,
+		).
 		// Watch the CAPI resource that owns this infrastructure resource.
 		Watches(
 			&source.Kind{Type: &clusterv1.Cluster{}},
-			handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
-				requests := clusterToInfraFn(o)
-				if requests == nil {
-					return nil
-				}
-
-				c := &infrav1.VSphereCluster{}
-				if err := reconciler.Client.Get(ctx, requests[0].NamespacedName, c); err != nil {
-					reconciler.Logger.V(4).Error(err, "Failed to get VSphereCluster")
-					return nil
-				}
-
-				if annotations.IsExternallyManaged(c) {
-					reconciler.Logger.V(4).Info("VSphereCluster is externally managed, skipping mapping.")
-					return nil
-				}
-				return requests
-			}),
+			handler.EnqueueRequestsFromMapFunc(clusterToInfraFn),
 		).
 
 		// Watch the infrastructure machine resources that belong to the control
@@ -147,6 +145,22 @@ func AddClusterControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 			&source.Kind{Type: &infrav1.VSphereDeploymentZone{}},
 			handler.EnqueueRequestsFromMapFunc(reconciler.deploymentZoneToCluster),
 		).
+		// Watch the ConfigMaps and Secrets holding the CA bundles the vCenters
+		// are verified with, to recycle the sessions when a bundle changes.
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(reconciler.caBundleToClusters),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(reconciler.caBundleToClusters),
+		).
+		// Watch the IPAddressClaim of the control plane endpoint, to set the
+		// endpoint once an address is bound to the claim.
+		Watches(
+			&source.Kind{Type: &ipamv1.IPAddressClaim{}},
+			&handler.EnqueueRequestForOwner{OwnerType: &infrav1.VSphereCluster{}},
+		).
 		// Watch a GenericEvent channel for the controlled resource.
 		//
 		// This is useful when there are events outside of Kubernetes that
@@ -156,8 +170,7 @@ func AddClusterControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 			&source.Channel{Source: ctx.GetGenericEventChannelFor(clusterControlledTypeGVK)},
 			&handler.EnqueueRequestForObject{},
 		).
-		WithEventFilter(predicates.ResourceIsNotExternallyManaged(reconciler.Logger)).
-		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconcilesFor(context.VSphereClusterControllerName)}).
 		Build(reconciler)
 	if err != nil {
 		return err
@@ -19,7 +19,9 @@ package controllers
 
 import (
 	goctx "context"
+	"encoding/json"
 	"fmt"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -27,10 +29,13 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	clusterutilv1 "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -43,6 +48,7 @@ import (
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/feature"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
@@ -56,6 +62,12 @@ const legacyIdentityFinalizer string = "identity/infrastructure.cluster.x-k8s.io
 type clusterReconciler struct {
 	*context.ControllerContext
 
+	// clusterModuleReconciler is run by Reconcile on the same VSphereCluster
+	// object, whose changes are written by a single patch. It has no
+	// controller of its own: its watches enqueue the VSphereCluster in the
+	// queue of this controller, which never reconciles the same
+	// VSphereCluster concurrently, so that the two cannot overwrite each
+	// other's changes.
 	clusterModuleReconciler Reconciler
 }
 
@@ -71,6 +83,15 @@ func (r clusterReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctr
 		return reconcile.Result{}, err
 	}
 
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, vsphereCluster.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		r.Logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile", "key", req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
 	// Fetch the CAPI Cluster.
 	cluster, err := clusterutilv1.GetOwnerCluster(r, r.Client, vsphereCluster.ObjectMeta)
 	if err != nil {
@@ -149,8 +170,14 @@ func (r clusterReconciler) reconcileDelete(ctx *context.ClusterContext) (reconci
 			machineDeletionCount++
 			// Remove the finalizer since VM creation wouldn't proceed
 			r.Logger.Info("Removing finalizer from VSphereMachine", "namespace", vsphereMachine.Namespace, "name", vsphereMachine.Name)
+			// Patch rather than update the VSphereMachine, so that the fields
+			// unknown to this version are not dropped during upgrades.
+			patchHelper, err := patch.NewHelper(vsphereMachine, r.Client)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
 			ctrlutil.RemoveFinalizer(vsphereMachine, infrav1.MachineFinalizer)
-			if err := r.Client.Update(ctx, vsphereMachine); err != nil {
+			if err := patchHelper.Patch(ctx, vsphereMachine); err != nil {
 				return reconcile.Result{}, err
 			}
 			if err := r.Client.Delete(ctx, vsphereMachine); err != nil && !apierrors.IsNotFound(err) {
@@ -165,15 +192,43 @@ func (r clusterReconciler) reconcileDelete(ctx *context.ClusterContext) (reconci
 
 	if len(vsphereMachines)-machineDeletionCount > 0 {
 		ctx.Logger.Info("Waiting for VSphereMachines to be deleted", "count", len(vsphereMachines)-machineDeletionCount)
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		return reconcile.Result{RequeueAfter: session.ServerLoad(ctx.VSphereCluster.Spec.Server).Scale(10 * time.Second)}, nil
 	}
 
-	// The cluster module info needs to be reconciled before the secret deletion
-	// since it needs access to the vCenter instance to be able to perform LCM operations
-	// on the cluster modules.
-	affinityReconcileResult, err := r.reconcileClusterModules(ctx)
-	if err != nil {
-		return affinityReconcileResult, err
+	// The cluster modules and the port group are deleted from vCenter once
+	// the vSphere operations resume.
+	if ctx.VSphereCluster.VSphereOperationsPaused() {
+		ctx.Logger.Info("vSphere operations are paused, won't delete the cluster modules and port group")
+		return reconcile.Result{}, nil
+	}
+
+	// The cluster modules, the port group and the control plane endpoint of
+	// externally managed clusters are not managed by CAPV.
+	if !annotations.IsExternallyManaged(ctx.VSphereCluster) {
+		// The cluster module info needs to be reconciled before the secret deletion
+		// since it needs access to the vCenter instance to be able to perform LCM operations
+		// on the cluster modules.
+		affinityReconcileResult, err := r.reconcileClusterModules(ctx)
+		if err != nil {
+			return affinityReconcileResult, err
+		}
+
+		// The VMs of the cluster are deleted by now, so that its port group
+		// can be deleted.
+		if ctx.VSphereCluster.Status.PortGroup != nil {
+			vcenterSession, err := r.reconcileVCenterConnectivity(ctx)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrapf(err,
+					"unexpected error while probing vcenter for %s", ctx)
+			}
+			if err := r.deletePortGroup(ctx, vcenterSession); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+
+		if err := r.removeControlPlaneEndpointAddressClaimFinalizer(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	// Remove finalizer on Identity Secret
@@ -219,6 +274,18 @@ func (r clusterReconciler) reconcileNormal(ctx *context.ClusterContext) (reconci
 	// If the VSphereCluster doesn't have our finalizer, add it.
 	ctrlutil.AddFinalizer(ctx.VSphereCluster, infrav1.ClusterFinalizer)
 
+	if annotations.IsExternallyManaged(ctx.VSphereCluster) {
+		return r.reconcileExternallyManaged(ctx)
+	}
+
+	if err := r.reconcileLegacyLoadBalancer(ctx); err != nil {
+		ctx.Logger.Error(err, "failed to migrate the control plane endpoint from the legacy load balancer")
+	}
+
+	if err := r.reconcileControlPlaneEndpointAddress(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	ok, err := r.reconcileDeploymentZones(ctx)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -233,9 +300,18 @@ func (r clusterReconciler) reconcileNormal(ctx *context.ClusterContext) (reconci
 		return reconcile.Result{}, err
 	}
 
+	if err := r.reconcileMove(ctx); err != nil {
+		ctx.Logger.Error(err, "could not check whether clusterctl move carries over the cluster")
+	}
+
 	vcenterSession, err := r.reconcileVCenterConnectivity(ctx)
 	if err != nil {
 		conditions.MarkFalse(ctx.VSphereCluster, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
+		var unavailable *session.UnavailableError
+		if errors.As(err, &unavailable) {
+			ctx.Logger.V(4).Info("vCenter is fenced, skipping VSphereCluster", "server", unavailable.Server, "retry-after", unavailable.RetryAfter)
+			return reconcile.Result{RequeueAfter: unavailable.RetryAfter}, nil
+		}
 		return reconcile.Result{}, errors.Wrapf(err,
 			"unexpected error while probing vcenter for %s", ctx)
 	}
@@ -247,10 +323,48 @@ func (r clusterReconciler) reconcileNormal(ctx *context.ClusterContext) (reconci
 		ctx.Logger.Error(err, "could not reconcile vCenter version")
 	}
 
-	affinityReconcileResult, err := r.reconcileClusterModules(ctx)
+	if err := r.reconcilePrivileges(ctx, vcenterSession); err != nil {
+		ctx.Logger.Error(err, "could not reconcile the privileges of the vCenter user")
+	}
+
+	if err := r.reconcileRetainedVMs(ctx, vcenterSession); err != nil {
+		ctx.Logger.Error(err, "could not reconcile the retained VMs")
+	}
+
+	// The port group, the cluster modules and the template replicas are
+	// created in vCenter, and are left as they are while the vSphere
+	// operations are paused.
+	var reconcileResult reconcile.Result
+	if ctx.VSphereCluster.VSphereOperationsPaused() {
+		ctx.Logger.Info("vSphere operations are paused, won't reconcile the port group, cluster modules and template replicas")
+	} else {
+		if err := r.reconcilePortGroup(ctx, vcenterSession); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the port group of %s", ctx)
+		}
+
+		affinityReconcileResult, err := r.reconcileClusterModules(ctx)
+		if err != nil {
+			conditions.MarkFalse(ctx.VSphereCluster, infrav1.ClusterModulesAvailableCondition, infrav1.ClusterModuleSetupFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return affinityReconcileResult, err
+		}
+
+		replicationReconcileResult := r.reconcileTemplateReplicas(ctx, vcenterSession)
+		reconcileResult = clusterutilv1.LowestNonZeroResult(affinityReconcileResult, replicationReconcileResult)
+	}
+
+	validationReconcileResult, err := r.reconcileTemplateValidation(ctx, vcenterSession)
+	if err != nil {
+		ctx.Logger.Error(err, "could not validate the templates of the cluster")
+	}
+	reconcileResult = clusterutilv1.LowestNonZeroResult(reconcileResult, validationReconcileResult)
+
+	ok, err = r.reconcilePreflightChecks(ctx, vcenterSession)
 	if err != nil {
-		conditions.MarkFalse(ctx.VSphereCluster, infrav1.ClusterModulesAvailableCondition, infrav1.ClusterModuleSetupFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
-		return affinityReconcileResult, err
+		return reconcile.Result{}, err
+	}
+	if !ok {
+		ctx.Logger.Info("waiting for the preflight checks to succeed")
+		return clusterutilv1.LowestNonZeroResult(reconcileResult, reconcile.Result{RequeueAfter: preflightChecksRequeuePeriod}), nil
 	}
 
 	ctx.VSphereCluster.Status.Ready = true
@@ -261,7 +375,7 @@ func (r clusterReconciler) reconcileNormal(ctx *context.ClusterContext) (reconci
 	r.reconcileVSphereClusterWhenAPIServerIsOnline(ctx)
 	if ctx.VSphereCluster.Spec.ControlPlaneEndpoint.IsZero() {
 		ctx.Logger.Info("control plane endpoint is not reconciled")
-		return reconcile.Result{}, nil
+		return reconcileResult, nil
 	}
 
 	// If the cluster is deleted, that's mean that the workload cluster is being deleted and so the CCM/CSI instances
@@ -271,10 +385,212 @@ func (r clusterReconciler) reconcileNormal(ctx *context.ClusterContext) (reconci
 
 	// Wait until the API server is online and accessible.
 	if !r.isAPIServerOnline(ctx) {
-		return reconcile.Result{}, nil
+		return reconcileResult, nil
 	}
 
-	return reconcile.Result{}, nil
+	return reconcileResult, nil
+}
+
+// reconcileExternallyManaged reconciles a VSphereCluster whose infrastructure
+// is managed by another controller, as it has the cluster.x-k8s.io/managed-by
+// annotation. The control plane endpoint, the failure domains, the cluster
+// modules and the readiness of the VSphereCluster are left to that controller,
+// while the credentials, the connectivity to vCenter and the template replicas
+// the machines rely on are still reconciled.
+func (r clusterReconciler) reconcileExternallyManaged(ctx *context.ClusterContext) (reconcile.Result, error) {
+	conditions.MarkFalse(ctx.VSphereCluster, infrav1.FailureDomainsAvailableCondition, infrav1.ExternallyManagedReason, clusterv1.ConditionSeverityInfo,
+		"the failure domains are managed externally")
+	conditions.MarkFalse(ctx.VSphereCluster, infrav1.ClusterModulesAvailableCondition, infrav1.ExternallyManagedReason, clusterv1.ConditionSeverityInfo,
+		"the cluster modules are not managed for externally managed clusters")
+
+	if err := r.reconcileIdentitySecret(ctx); err != nil {
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	vcenterSession, err := r.reconcileVCenterConnectivity(ctx)
+	if err != nil {
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
+		var unavailable *session.UnavailableError
+		if errors.As(err, &unavailable) {
+			ctx.Logger.V(4).Info("vCenter is fenced, skipping VSphereCluster", "server", unavailable.Server, "retry-after", unavailable.RetryAfter)
+			return reconcile.Result{RequeueAfter: unavailable.RetryAfter}, nil
+		}
+		return reconcile.Result{}, errors.Wrapf(err,
+			"unexpected error while probing vcenter for %s", ctx)
+	}
+	conditions.MarkTrue(ctx.VSphereCluster, infrav1.VCenterAvailableCondition)
+
+	if err := r.reconcileVCenterVersion(ctx, vcenterSession); err != nil {
+		ctx.Logger.Error(err, "could not reconcile vCenter version")
+	}
+
+	return r.reconcileTemplateReplicas(ctx, vcenterSession), nil
+}
+
+// reconcileLegacyLoadBalancer migrates the clusters created with the
+// HAProxyLoadBalancer, which was removed in v1alpha4, onto the control plane
+// endpoint model. The endpoint is set from the address of the load balancer
+// if it is not set yet, after which the load balancer is no longer tracked.
+// The load balancer VM itself is left untouched.
+func (r clusterReconciler) reconcileLegacyLoadBalancer(ctx *context.ClusterContext) error {
+	data, ok := ctx.VSphereCluster.Annotations[infrav1.LegacyLoadBalancerRefAnnotation]
+	if !ok {
+		return nil
+	}
+
+	if ctx.VSphereCluster.Spec.ControlPlaneEndpoint.IsZero() {
+		ref := &apiv1.ObjectReference{}
+		if err := json.Unmarshal([]byte(data), ref); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal annotation %s", infrav1.LegacyLoadBalancerRefAnnotation)
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = ctx.VSphereCluster.Namespace
+		}
+
+		loadBalancer := &unstructured.Unstructured{}
+		loadBalancer.SetAPIVersion(ref.APIVersion)
+		loadBalancer.SetKind(ref.Kind)
+		if err := ctx.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, loadBalancer); err != nil {
+			return errors.Wrapf(err, "failed to get load balancer %s %s/%s", ref.Kind, namespace, ref.Name)
+		}
+		address, _, err := unstructured.NestedString(loadBalancer.Object, "status", "address")
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the address of load balancer %s %s/%s", ref.Kind, namespace, ref.Name)
+		}
+		if address == "" {
+			return errors.Errorf("load balancer %s %s/%s has no address", ref.Kind, namespace, ref.Name)
+		}
+
+		ctx.VSphereCluster.Spec.ControlPlaneEndpoint = infrav1.APIEndpoint{
+			Host: address,
+			Port: constants.DefaultBindPort,
+		}
+		ctx.Logger.Info("migrated control plane endpoint from legacy load balancer",
+			"loadBalancer", fmt.Sprintf("%s %s/%s", ref.Kind, namespace, ref.Name),
+			"endpoint", ctx.VSphereCluster.Spec.ControlPlaneEndpoint.String())
+	}
+
+	delete(ctx.VSphereCluster.Annotations, infrav1.LegacyLoadBalancerRefAnnotation)
+	return nil
+}
+
+// controlPlaneEndpointAddressClaimName returns the name of the IPAddressClaim
+// of the control plane endpoint of a VSphereCluster.
+func controlPlaneEndpointAddressClaimName(vsphereCluster *infrav1.VSphereCluster) string {
+	return fmt.Sprintf("%s-control-plane-endpoint", vsphereCluster.Name)
+}
+
+// reconcileControlPlaneEndpointAddress claims an address for the control
+// plane endpoint from the IPAddressPool referenced by the VSphereCluster, if
+// the endpoint is not set yet. The claimed address is written to the control
+// plane endpoint once an IPAM provider has bound it to the claim. The claim is
+// kept for the lifetime of the VSphereCluster, so that the address is not
+// allocated to another cluster.
+func (r clusterReconciler) reconcileControlPlaneEndpointAddress(ctx *context.ClusterContext) error {
+	poolRef := ctx.VSphereCluster.Spec.ControlPlaneEndpointAddressFromPool
+	if poolRef == nil {
+		return nil
+	}
+
+	claim := &ipamv1.IPAddressClaim{}
+	claimKey := client.ObjectKey{
+		Namespace: ctx.VSphereCluster.Namespace,
+		Name:      controlPlaneEndpointAddressClaimName(ctx.VSphereCluster),
+	}
+	err := ctx.Client.Get(ctx, claimKey, claim)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get IPAddressClaim %s", claimKey)
+	}
+	// The endpoint set by the user or migrated from the legacy load balancer
+	// takes precedence over the pool.
+	if !ctx.VSphereCluster.Spec.ControlPlaneEndpoint.IsZero() {
+		return nil
+	}
+
+	if apierrors.IsNotFound(err) {
+		ctx.Logger.Info("creating IPAddressClaim for the control plane endpoint", "name", claimKey.Name)
+		claim = &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      claimKey.Name,
+				Namespace: claimKey.Namespace,
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: ctx.Cluster.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: infrav1.GroupVersion.String(),
+						Kind:       "VSphereCluster",
+						Name:       ctx.VSphereCluster.Name,
+						UID:        ctx.VSphereCluster.UID,
+					},
+				},
+				Finalizers: []string{infrav1.ControlPlaneEndpointAddressClaimFinalizer},
+			},
+			Spec: ipamv1.IPAddressClaimSpec{PoolRef: *poolRef},
+		}
+		if err := ctx.Client.Create(ctx, claim); err != nil {
+			return errors.Wrapf(err, "failed to create IPAddressClaim %s", claimKey)
+		}
+	}
+
+	addressName := claim.Status.AddressRef.Name
+	if addressName == "" {
+		ctx.Logger.Info("waiting for IPAddressClaim of the control plane endpoint to have an IPAddress bound", "name", claimKey.Name)
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.IPAddressClaimedCondition, infrav1.WaitingForIPAddressReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for IPAddressClaim %s to have an IPAddress bound", claimKey.Name)
+		return nil
+	}
+
+	address := &ipamv1.IPAddress{}
+	addressKey := client.ObjectKey{Namespace: claimKey.Namespace, Name: addressName}
+	if err := ctx.Client.Get(ctx, addressKey, address); err != nil {
+		return errors.Wrapf(err, "failed to get IPAddress %s", addressKey)
+	}
+	if _, err := netip.ParseAddr(address.Spec.Address); err != nil {
+		msg := fmt.Sprintf("IPAddress %s has invalid ip address: %q", addressKey, address.Spec.Address)
+		conditions.MarkFalse(ctx.VSphereCluster, infrav1.IPAddressClaimedCondition, infrav1.IPAddressInvalidReason, clusterv1.ConditionSeverityError, msg)
+		return errors.New(msg)
+	}
+
+	ctx.VSphereCluster.Spec.ControlPlaneEndpoint = infrav1.APIEndpoint{
+		Host: address.Spec.Address,
+		Port: constants.DefaultBindPort,
+	}
+	conditions.MarkTrue(ctx.VSphereCluster, infrav1.IPAddressClaimedCondition)
+	ctx.Logger.Info("claimed control plane endpoint from IPAddressPool",
+		"pool", fmt.Sprintf("%s %s", poolRef.Kind, poolRef.Name),
+		"endpoint", ctx.VSphereCluster.Spec.ControlPlaneEndpoint.String())
+	return nil
+}
+
+// removeControlPlaneEndpointAddressClaimFinalizer removes the finalizer of the
+// IPAddressClaim of the control plane endpoint, so that the claim is deleted
+// along with the VSphereCluster and the address is released.
+func (r clusterReconciler) removeControlPlaneEndpointAddressClaimFinalizer(ctx *context.ClusterContext) error {
+	claim := &ipamv1.IPAddressClaim{}
+	claimKey := client.ObjectKey{
+		Namespace: ctx.VSphereCluster.Namespace,
+		Name:      controlPlaneEndpointAddressClaimName(ctx.VSphereCluster),
+	}
+	if err := ctx.Client.Get(ctx, claimKey, claim); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get IPAddressClaim %s", claimKey)
+	}
+	patchHelper, err := patch.NewHelper(claim, ctx.Client)
+	if err != nil {
+		return err
+	}
+	if ctrlutil.RemoveFinalizer(claim, infrav1.ControlPlaneEndpointAddressClaimFinalizer) {
+		ctx.Logger.Info("removing finalizer", "IPAddressClaim", claimKey.Name)
+		if err := patchHelper.Patch(ctx, claim); err != nil {
+			return errors.Wrapf(err, "failed to patch IPAddressClaim %s", claimKey)
+		}
+	}
+	return nil
 }
 
 func (r clusterReconciler) reconcileIdentitySecret(ctx *context.ClusterContext) error {
@@ -324,13 +640,19 @@ func (r clusterReconciler) reconcileVCenterConnectivity(ctx *context.ClusterCont
 			KeepAliveDuration: r.KeepAliveDuration,
 		})
 
+	caBundle, err := identity.GetCABundle(ctx, r.Client, ctx.VSphereCluster, r.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the CA bundle of the vCenter")
+	}
+	params = params.WithCABundle(caBundle)
+
 	if ctx.VSphereCluster.Spec.IdentityRef != nil {
 		creds, err := identity.GetCredentials(ctx, r.Client, ctx.VSphereCluster, r.Namespace)
 		if err != nil {
 			return nil, err
 		}
 
-		params = params.WithUserInfo(creds.Username, creds.Password)
+		params = params.WithUserInfo(creds.Username, creds.Password).WithTokenProvider(creds.TokenProvider)
 		return session.GetOrCreate(ctx, params)
 	}
 
@@ -609,3 +931,69 @@ func (r clusterReconciler) deploymentZoneToCluster(o client.Object) []ctrl.Reque
 	}
 	return requests
 }
+
+// caBundleToClusters returns the requests of the VSphereClusters whose vCenter
+// is verified with the CA bundle of a ConfigMap or Secret, referenced either by
+// the VSphereCluster or by its VSphereClusterIdentity, so that their sessions
+// are recycled with the new certificate authorities.
+func (r clusterReconciler) caBundleToClusters(o client.Object) []ctrl.Request {
+	var kind infrav1.CABundleKind
+	switch o.(type) {
+	case *apiv1.ConfigMap:
+		kind = infrav1.ConfigMapCABundleKind
+	case *apiv1.Secret:
+		kind = infrav1.SecretCABundleKind
+	default:
+		r.Logger.Error(nil, fmt.Sprintf("expected a ConfigMap or Secret but got a %T", o))
+		return nil
+	}
+	references := func(ref *infrav1.CABundleReference) bool {
+		return ref != nil && ref.Kind == kind && ref.Name == o.GetName()
+	}
+
+	// The CA bundles of the VSphereClusterIdentities are in the controller
+	// namespace.
+	identities := sets.NewString()
+	if o.GetNamespace() == r.Namespace {
+		var identityList infrav1.VSphereClusterIdentityList
+		if err := r.Client.List(r.Context, &identityList); err != nil {
+			r.Logger.Error(err, "unable to list identities")
+			return nil
+		}
+		for _, clusterIdentity := range identityList.Items {
+			if references(clusterIdentity.Spec.CABundleRef) {
+				identities.Insert(clusterIdentity.Name)
+			}
+		}
+	}
+
+	var opts []client.ListOption
+	if identities.Len() == 0 {
+		opts = append(opts, client.InNamespace(o.GetNamespace()))
+	}
+	var clusterList infrav1.VSphereClusterList
+	if err := r.Client.List(r.Context, &clusterList, opts...); err != nil {
+		r.Logger.Error(err, "unable to list clusters")
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, cluster := range clusterList.Items {
+		ref, identityRef := cluster.Spec.CABundleRef, cluster.Spec.IdentityRef
+		switch {
+		case ref != nil:
+			if cluster.Namespace != o.GetNamespace() || !references(ref) {
+				continue
+			}
+		case identityRef == nil || identityRef.Kind != infrav1.VSphereClusterIdentityKind || !identities.Has(identityRef.Name):
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      cluster.Name,
+				Namespace: cluster.Namespace,
+			},
+		})
+	}
+	return requests
+}
@@ -28,8 +28,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	clusterutil1v1 "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -160,6 +163,97 @@ var _ = Describe("VIM based VSphere ClusterReconciler", func() {
 			}, timeout).Should(BeTrue())
 		})
 
+		It("should only reconcile the credentials of an externally managed cluster", func() {
+			fakeVCenter := startVcenter()
+			vcURL := fakeVCenter.ServerURL()
+			defer fakeVCenter.Destroy()
+
+			// Create the secret containing the credentials
+			password, _ := vcURL.User.Password()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "secret-",
+					Namespace:    "default",
+				},
+				Data: map[string][]byte{
+					identity.UsernameKey: []byte(vcURL.User.Username()),
+					identity.PasswordKey: []byte(password),
+				},
+			}
+			Expect(testEnv.Create(ctx, secret)).To(Succeed())
+
+			// Create the externally managed VSphereCluster object
+			instance := &infrav1.VSphereCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "vsphere-test-external",
+					Namespace:    "default",
+					Annotations:  map[string]string{clusterv1.ManagedByAnnotation: ""},
+				},
+				Spec: infrav1.VSphereClusterSpec{
+					IdentityRef: &infrav1.VSphereIdentityReference{
+						Kind: infrav1.SecretKind,
+						Name: secret.Name,
+					},
+					Server: fmt.Sprintf("%s://%s", vcURL.Scheme, vcURL.Host),
+				},
+			}
+			Expect(testEnv.Create(ctx, instance)).To(Succeed())
+			key := client.ObjectKey{Namespace: instance.Namespace, Name: instance.Name}
+			defer func() {
+				Expect(testEnv.Delete(ctx, instance)).To(Succeed())
+			}()
+
+			capiCluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-external-",
+					Namespace:    "default",
+				},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &corev1.ObjectReference{
+						APIVersion: infrav1.GroupVersion.String(),
+						Kind:       "VsphereCluster",
+						Name:       instance.Name,
+					},
+				},
+			}
+			// Create the CAPI cluster (owner) object
+			Expect(testEnv.Create(ctx, capiCluster)).To(Succeed())
+			defer func() {
+				Expect(testEnv.Cleanup(ctx, capiCluster)).To(Succeed())
+			}()
+
+			By("setting the OwnerRef on the VSphereCluster")
+			Eventually(func() error {
+				if err := testEnv.Get(ctx, key, instance); err != nil {
+					return err
+				}
+				ph, err := patch.NewHelper(instance, testEnv)
+				Expect(err).ShouldNot(HaveOccurred())
+				instance.OwnerReferences = append(instance.OwnerReferences, metav1.OwnerReference{
+					Kind:       "Cluster",
+					APIVersion: clusterv1.GroupVersion.String(),
+					Name:       capiCluster.Name,
+					UID:        "blah",
+				})
+				return ph.Patch(ctx, instance, patch.WithStatusObservedGeneration{})
+			}, timeout).Should(BeNil())
+
+			By("reconciling the connectivity to vCenter")
+			Eventually(func() bool {
+				if err := testEnv.Get(ctx, key, instance); err != nil {
+					return false
+				}
+				return conditions.IsTrue(instance, infrav1.VCenterAvailableCondition)
+			}, timeout).Should(BeTrue())
+			Expect(instance.Finalizers).To(ContainElement(infrav1.ClusterFinalizer))
+
+			By("ceding the failure domains, the cluster modules and the readiness")
+			Expect(conditions.GetReason(instance, infrav1.FailureDomainsAvailableCondition)).To(Equal(infrav1.ExternallyManagedReason))
+			Expect(conditions.GetReason(instance, infrav1.ClusterModulesAvailableCondition)).To(Equal(infrav1.ExternallyManagedReason))
+			Expect(instance.Status.Ready).To(BeFalse())
+			Expect(instance.Spec.ControlPlaneEndpoint.IsZero()).To(BeTrue())
+		})
+
 		It("should error if secret is already owned by a different cluster", func() {
 			ctx := context.Background()
 
@@ -733,6 +827,271 @@ func TestClusterReconciler_ReconcileDeploymentZones(t *testing.T) {
 	}
 }
 
+func TestClusterReconciler_ReconcileLegacyLoadBalancer(t *testing.T) {
+	loadBalancerRef := `{"apiVersion":"infrastructure.cluster.x-k8s.io/v1alpha3","kind":"HAProxyLoadBalancer","name":"lb"}`
+
+	tests := []struct {
+		name        string
+		initObjs    []client.Object
+		annotations map[string]string
+		endpoint    infrav1.APIEndpoint
+		hasError    bool
+		assert      func(*WithT, *infrav1.VSphereCluster)
+	}{
+		{
+			name: "without legacy load balancer",
+			assert: func(g *WithT, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint.IsZero()).To(BeTrue())
+			},
+		},
+		{
+			name:        "sets the control plane endpoint from the load balancer address",
+			initObjs:    []client.Object{legacyLoadBalancer("lb", "10.0.0.10")},
+			annotations: map[string]string{infrav1.LegacyLoadBalancerRefAnnotation: loadBalancerRef},
+			assert: func(g *WithT, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint).To(Equal(infrav1.APIEndpoint{Host: "10.0.0.10", Port: 6443}))
+				g.Expect(vsphereCluster.Annotations).ToNot(HaveKey(infrav1.LegacyLoadBalancerRefAnnotation))
+			},
+		},
+		{
+			name:        "keeps the control plane endpoint if already set",
+			initObjs:    []client.Object{legacyLoadBalancer("lb", "10.0.0.10")},
+			annotations: map[string]string{infrav1.LegacyLoadBalancerRefAnnotation: loadBalancerRef},
+			endpoint:    infrav1.APIEndpoint{Host: "10.0.0.20", Port: 6443},
+			assert: func(g *WithT, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint).To(Equal(infrav1.APIEndpoint{Host: "10.0.0.20", Port: 6443}))
+				g.Expect(vsphereCluster.Annotations).ToNot(HaveKey(infrav1.LegacyLoadBalancerRefAnnotation))
+			},
+		},
+		{
+			name:        "waits for the load balancer address",
+			initObjs:    []client.Object{legacyLoadBalancer("lb", "")},
+			annotations: map[string]string{infrav1.LegacyLoadBalancerRefAnnotation: loadBalancerRef},
+			hasError:    true,
+			assert: func(g *WithT, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint.IsZero()).To(BeTrue())
+				g.Expect(vsphereCluster.Annotations).To(HaveKey(infrav1.LegacyLoadBalancerRefAnnotation))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			controllerCtx := fake.NewControllerContext(fake.NewControllerManagerContext(tt.initObjs...))
+			ctx := fake.NewClusterContext(controllerCtx)
+			ctx.VSphereCluster.Annotations = tt.annotations
+			ctx.VSphereCluster.Spec.ControlPlaneEndpoint = tt.endpoint
+
+			r := clusterReconciler{ControllerContext: controllerCtx}
+			err := r.reconcileLegacyLoadBalancer(ctx)
+			if tt.hasError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tt.assert(g, ctx.VSphereCluster)
+		})
+	}
+}
+
+func TestClusterReconciler_ReconcileControlPlaneEndpointAddress(t *testing.T) {
+	poolRef := &corev1.TypedLocalObjectReference{
+		APIGroup: pointer.String("ipam.cluster.x-k8s.io"),
+		Kind:     "InClusterIPPool",
+		Name:     "vip-pool",
+	}
+	claimKey := client.ObjectKey{Namespace: fake.Namespace, Name: fake.Clusterv1a2Name + "-control-plane-endpoint"}
+
+	tests := []struct {
+		name     string
+		initObjs []client.Object
+		poolRef  *corev1.TypedLocalObjectReference
+		endpoint infrav1.APIEndpoint
+		hasError bool
+		assert   func(*WithT, client.Client, *infrav1.VSphereCluster)
+	}{
+		{
+			name: "without address pool",
+			assert: func(g *WithT, c client.Client, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(apierrors.IsNotFound(c.Get(context.Background(), claimKey, &ipamv1.IPAddressClaim{}))).To(BeTrue())
+				g.Expect(conditions.Has(vsphereCluster, infrav1.IPAddressClaimedCondition)).To(BeFalse())
+			},
+		},
+		{
+			name:    "creates the claim and waits for an address",
+			poolRef: poolRef,
+			assert: func(g *WithT, c client.Client, vsphereCluster *infrav1.VSphereCluster) {
+				claim := &ipamv1.IPAddressClaim{}
+				g.Expect(c.Get(context.Background(), claimKey, claim)).To(Succeed())
+				g.Expect(claim.Spec.PoolRef).To(Equal(*poolRef))
+				g.Expect(claim.Finalizers).To(ContainElement(infrav1.ControlPlaneEndpointAddressClaimFinalizer))
+				g.Expect(claim.OwnerReferences).To(HaveLen(1))
+				g.Expect(claim.OwnerReferences[0].UID).To(Equal(vsphereCluster.UID))
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint.IsZero()).To(BeTrue())
+				g.Expect(conditions.IsFalse(vsphereCluster, infrav1.IPAddressClaimedCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(vsphereCluster, infrav1.IPAddressClaimedCondition)).To(Equal(infrav1.WaitingForIPAddressReason))
+			},
+		},
+		{
+			name:     "sets the control plane endpoint from the bound address",
+			initObjs: []client.Object{controlPlaneEndpointAddressClaim(claimKey, "vip"), ipAddress("vip", "10.0.0.30")},
+			poolRef:  poolRef,
+			assert: func(g *WithT, c client.Client, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint).To(Equal(infrav1.APIEndpoint{Host: "10.0.0.30", Port: 6443}))
+				g.Expect(conditions.IsTrue(vsphereCluster, infrav1.IPAddressClaimedCondition)).To(BeTrue())
+			},
+		},
+		{
+			name:     "rejects an invalid address",
+			initObjs: []client.Object{controlPlaneEndpointAddressClaim(claimKey, "vip"), ipAddress("vip", "10.0.0")},
+			poolRef:  poolRef,
+			hasError: true,
+			assert: func(g *WithT, c client.Client, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint.IsZero()).To(BeTrue())
+				g.Expect(conditions.GetReason(vsphereCluster, infrav1.IPAddressClaimedCondition)).To(Equal(infrav1.IPAddressInvalidReason))
+			},
+		},
+		{
+			name:     "keeps the control plane endpoint if already set",
+			poolRef:  poolRef,
+			endpoint: infrav1.APIEndpoint{Host: "10.0.0.20", Port: 6443},
+			assert: func(g *WithT, c client.Client, vsphereCluster *infrav1.VSphereCluster) {
+				g.Expect(vsphereCluster.Spec.ControlPlaneEndpoint).To(Equal(infrav1.APIEndpoint{Host: "10.0.0.20", Port: 6443}))
+				g.Expect(apierrors.IsNotFound(c.Get(context.Background(), claimKey, &ipamv1.IPAddressClaim{}))).To(BeTrue())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			controllerCtx := fake.NewControllerContext(fake.NewControllerManagerContext(tt.initObjs...))
+			ctx := fake.NewClusterContext(controllerCtx)
+			ctx.VSphereCluster.Spec.ControlPlaneEndpointAddressFromPool = tt.poolRef
+			ctx.VSphereCluster.Spec.ControlPlaneEndpoint = tt.endpoint
+
+			r := clusterReconciler{ControllerContext: controllerCtx}
+			err := r.reconcileControlPlaneEndpointAddress(ctx)
+			if tt.hasError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tt.assert(g, controllerCtx.Client, ctx.VSphereCluster)
+		})
+	}
+}
+
+func TestClusterReconciler_ReconcileMove(t *testing.T) {
+	caBundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: fake.Namespace, Name: "vcenter-ca"},
+		Data:       map[string]string{infrav1.DefaultCABundleKey: "ca"},
+	}
+	vsphereVM := func(name string, ownerReferences ...metav1.OwnerReference) *infrav1.VSphereVM {
+		return &infrav1.VSphereVM{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       fake.Namespace,
+				Name:            name,
+				Labels:          map[string]string{clusterv1.ClusterLabelName: fake.Clusterv1a2Name},
+				OwnerReferences: ownerReferences,
+			},
+		}
+	}
+	machineOwner := metav1.OwnerReference{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereMachine", Name: "machine", UID: "machine-uid"}
+
+	tests := []struct {
+		name        string
+		initObjs    []client.Object
+		caBundleRef *infrav1.CABundleReference
+		retainedVMs []infrav1.RetainedVM
+		leftBehind  string
+	}{
+		{
+			name:     "without objects left behind",
+			initObjs: []client.Object{vsphereVM("owned", machineOwner)},
+		},
+		{
+			name:        "labels the CA bundle to be moved",
+			initObjs:    []client.Object{caBundle.DeepCopy()},
+			caBundleRef: &infrav1.CABundleReference{Kind: infrav1.ConfigMapCABundleKind, Name: caBundle.Name},
+		},
+		{
+			name:        "reports the missing CA bundle",
+			caBundleRef: &infrav1.CABundleReference{Kind: infrav1.SecretCABundleKind, Name: "missing"},
+			leftBehind:  "clusterctl move would leave behind Secret missing",
+		},
+		{
+			name:        "reports the VSphereVMs without owner and the retained VMs",
+			initObjs:    []client.Object{vsphereVM("owned", machineOwner), vsphereVM("orphan")},
+			retainedVMs: []infrav1.RetainedVM{{Name: "retained"}},
+			leftBehind:  "clusterctl move would leave behind VSphereVM orphan, the retained VMs of status.retainedVMs",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			controllerCtx := fake.NewControllerContext(fake.NewControllerManagerContext(tt.initObjs...))
+			ctx := fake.NewClusterContext(controllerCtx)
+			ctx.VSphereCluster.Spec.CABundleRef = tt.caBundleRef
+			ctx.VSphereCluster.Status.RetainedVMs = tt.retainedVMs
+
+			r := clusterReconciler{ControllerContext: controllerCtx}
+			g.Expect(r.reconcileMove(ctx)).To(Succeed())
+			if tt.leftBehind == "" {
+				g.Expect(conditions.IsTrue(ctx.VSphereCluster, infrav1.MoveReadyCondition)).To(BeTrue())
+			} else {
+				g.Expect(conditions.GetReason(ctx.VSphereCluster, infrav1.MoveReadyCondition)).To(Equal(infrav1.ObjectsLeftBehindReason))
+				g.Expect(conditions.GetMessage(ctx.VSphereCluster, infrav1.MoveReadyCondition)).To(Equal(tt.leftBehind))
+			}
+
+			if tt.caBundleRef != nil && tt.leftBehind == "" {
+				configMap := &corev1.ConfigMap{}
+				g.Expect(controllerCtx.Client.Get(context.Background(), client.ObjectKeyFromObject(caBundle), configMap)).To(Succeed())
+				g.Expect(configMap.Labels).To(HaveKeyWithValue(clusterctlv1.ClusterctlMoveLabelName, ""))
+			}
+		})
+	}
+}
+
+func controlPlaneEndpointAddressClaim(key client.ObjectKey, addressName string) *ipamv1.IPAddressClaim {
+	return &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  key.Namespace,
+			Name:       key.Name,
+			Finalizers: []string{infrav1.ControlPlaneEndpointAddressClaimFinalizer},
+		},
+		Status: ipamv1.IPAddressClaimStatus{
+			AddressRef: corev1.LocalObjectReference{Name: addressName},
+		},
+	}
+}
+
+func ipAddress(name, address string) *ipamv1.IPAddress {
+	return &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: fake.Namespace, Name: name},
+		Spec: ipamv1.IPAddressSpec{
+			Address: address,
+			Prefix:  24,
+			Gateway: "10.0.0.1",
+		},
+	}
+}
+
+func legacyLoadBalancer(name, address string) *unstructured.Unstructured {
+	loadBalancer := &unstructured.Unstructured{}
+	loadBalancer.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	loadBalancer.SetKind("HAProxyLoadBalancer")
+	loadBalancer.SetNamespace(fake.Namespace)
+	loadBalancer.SetName(name)
+	_ = unstructured.SetNestedField(loadBalancer.Object, address, "status", "address")
+	return loadBalancer
+}
+
 func deploymentZone(server, fdName string, cp, ready *bool) *infrav1.VSphereDeploymentZone {
 	return &infrav1.VSphereDeploymentZone{
 		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("zone-%s", fdName)},
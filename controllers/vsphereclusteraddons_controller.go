@@ -0,0 +1,271 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	goctx "context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1beta1"
+	clusterutilv1 "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/packaging/flavorgen/flavors/crs/types"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/cloudprovider"
+)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusters;vspheremachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=addons.cluster.x-k8s.io,resources=clusterresourcesets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	addonsControllerNameShort = "addons-controller"
+
+	// addonsDatacentersRequeueTime is the time after which the addons of a
+	// cluster without failure domains are rendered again, when none of its
+	// VSphereMachines defines the datacenter of the cluster yet.
+	addonsDatacentersRequeueTime = time.Second * 30
+
+	cloudControllerManagerAddonKey = "cloud-controller-manager.yaml"
+	csiAddonKey                    = "csi.yaml"
+)
+
+// AddAddonsControllerToManager adds the controller rendering the vSphere
+// addons of the workload clusters to the provided manager.
+func AddAddonsControllerToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controllerNameLong = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, addonsControllerNameShort)
+	)
+
+	controllerContext := &context.ControllerContext{
+		ControllerManagerContext: ctx,
+		Name:                     addonsControllerNameShort,
+		Recorder:                 record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		Logger:                   ctx.Logger.WithName(addonsControllerNameShort),
+	}
+	r := addonsReconciler{ControllerContext: controllerContext}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(addonsControllerNameShort).
+		For(&infrav1.VSphereCluster{}).
+		Owns(&addonsv1.ClusterResourceSet{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
+		Complete(r)
+}
+
+// addonsReconciler renders the manifests of the vSphere cloud controller
+// manager and CSI driver of a workload cluster in a secret, which a
+// ClusterResourceSet applies to the workload cluster.
+//
+// A ClusterResourceSet applies its resources once, so that the manifests
+// must be complete before the control plane of the workload cluster is
+// reachable, and later changes of the addons are not applied to the workload
+// cluster.
+type addonsReconciler struct {
+	*context.ControllerContext
+}
+
+func (r addonsReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (reconcile.Result, error) {
+	logger := r.Logger.WithName(req.Namespace).WithName(req.Name)
+
+	vsphereCluster := &infrav1.VSphereCluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	// The ClusterResourceSet and secret of the addons are owned by the
+	// VSphereCluster and are then garbage collected with it.
+	if !vsphereCluster.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereCluster.Namespace, vsphereCluster.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logger.V(4).Info("VSphereCluster reconciled by another shard, won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	cluster, err := clusterutilv1.GetOwnerCluster(ctx, r.Client, vsphereCluster.ObjectMeta)
+	if err != nil || cluster == nil {
+		return reconcile.Result{}, err
+	}
+	if annotations.IsPaused(cluster, vsphereCluster) {
+		logger.V(4).Info("VSphereCluster linked to a cluster that is paused")
+		return reconcile.Result{}, nil
+	}
+
+	addons := vsphereCluster.Spec.Addons
+	if addons == nil || (addons.CloudControllerManager == nil && addons.CSI == nil) {
+		return reconcile.Result{}, r.deleteAddons(ctx, vsphereCluster)
+	}
+
+	config, err := csiConfig(ctx, r.ControllerContext, vsphereCluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(vsphereCluster.Status.FailureDomains) == 0 {
+		datacenters, err := r.machineDatacenters(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(datacenters) == 0 {
+			logger.V(4).Info("waiting for the VSphereMachines of the cluster to render the addons")
+			return reconcile.Result{RequeueAfter: addonsDatacentersRequeueTime}, nil
+		}
+		for server, vcenter := range config.VCenter {
+			vcenter.Datacenters = strings.Join(datacenters, ",")
+			config.VCenter[server] = vcenter
+		}
+	}
+
+	data, err := addonsData(addons, config)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	name := addonsName(vsphereCluster)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: vsphereCluster.Namespace, Name: name}}
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, secret, func() error {
+		secret.Type = addonsv1.ClusterResourceSetSecretType
+		secret.Data = data
+		return controllerutil.SetControllerReference(vsphereCluster, secret, r.Scheme)
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to write the addons secret %s/%s", secret.Namespace, secret.Name)
+	}
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Wrote the addons secret", "secret", name, "operation", result)
+	}
+
+	crs := &addonsv1.ClusterResourceSet{ObjectMeta: metav1.ObjectMeta{Namespace: vsphereCluster.Namespace, Name: name}}
+	result, err = controllerutil.CreateOrPatch(ctx, r.Client, crs, func() error {
+		crs.Spec.ClusterSelector = metav1.LabelSelector{
+			MatchLabels: map[string]string{infrav1.AddonsClusterLabel: cluster.Name},
+		}
+		crs.Spec.Resources = []addonsv1.ResourceRef{{Name: name, Kind: "Secret"}}
+		return controllerutil.SetControllerReference(vsphereCluster, crs, r.Scheme)
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to write the ClusterResourceSet %s/%s", crs.Namespace, crs.Name)
+	}
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Wrote the addons ClusterResourceSet", "clusterresourceset", name, "operation", result)
+		r.Recorder.Eventf(vsphereCluster, "AddonsConfigured", "%s the ClusterResourceSet of the vSphere addons", result)
+	}
+
+	if cluster.Labels[infrav1.AddonsClusterLabel] != cluster.Name {
+		patch := client.MergeFrom(cluster.DeepCopy())
+		if cluster.Labels == nil {
+			cluster.Labels = map[string]string{}
+		}
+		cluster.Labels[infrav1.AddonsClusterLabel] = cluster.Name
+		if err := r.Client.Patch(ctx, cluster, patch); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to label cluster %s/%s", cluster.Namespace, cluster.Name)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// deleteAddons deletes the ClusterResourceSet and secret of the addons of a
+// VSphereCluster which no longer defines any. The addons already applied to
+// the workload cluster are left in place.
+func (r addonsReconciler) deleteAddons(ctx goctx.Context, vsphereCluster *infrav1.VSphereCluster) error {
+	key := client.ObjectKey{Namespace: vsphereCluster.Namespace, Name: addonsName(vsphereCluster)}
+	for _, obj := range []client.Object{&addonsv1.ClusterResourceSet{}, &corev1.Secret{}} {
+		if err := r.Client.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(obj, vsphereCluster) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete %T %s", obj, key)
+		}
+	}
+	return nil
+}
+
+// machineDatacenters returns the datacenters of the VSphereMachines of a
+// cluster, which the cloud controller manager and CSI driver of a cluster
+// without failure domains are configured with.
+func (r addonsReconciler) machineDatacenters(ctx goctx.Context, cluster *clusterv1.Cluster) ([]string, error) {
+	machines := &infrav1.VSphereMachineList{}
+	if err := r.Client.List(ctx, machines, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the VSphereMachines of cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	datacenters := sets.NewString()
+	for _, machine := range machines.Items {
+		if machine.Spec.Datacenter != "" {
+			datacenters.Insert(machine.Spec.Datacenter)
+		}
+	}
+	return datacenters.List(), nil
+}
+
+// addonsData returns the manifests of the addons of a cluster, keyed by the
+// addon, using the vSphere CSI configuration of the cluster.
+func addonsData(addons *infrav1.VSphereClusterAddons, config *types.CPIConfig) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	if ccm := addons.CloudControllerManager; ccm != nil {
+		manifests, err := cloudprovider.CloudControllerManagerManifests(ccm.Image, config)
+		if err != nil {
+			return nil, err
+		}
+		data[cloudControllerManagerAddonKey] = manifests
+	}
+	if csi := addons.CSI; csi != nil {
+		manifests, err := cloudprovider.CSIManifests(csi.DriverImage, csi.SyncerImage, config)
+		if err != nil {
+			return nil, err
+		}
+		data[csiAddonKey] = manifests
+	}
+	return data, nil
+}
+
+// addonsName returns the name of the ClusterResourceSet and secret of the
+// addons of a VSphereCluster.
+func addonsName(vsphereCluster *infrav1.VSphereCluster) string {
+	return fmt.Sprintf("%s-vsphere-addons", vsphereCluster.Name)
+}
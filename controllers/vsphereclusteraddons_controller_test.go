@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/cloudprovider"
+)
+
+func Test_addonsData(t *testing.T) {
+	vsphereCluster := &infrav1.VSphereCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cluster"},
+		Spec: infrav1.VSphereClusterSpec{
+			Server:     "vcenter.example.com",
+			Thumbprint: "AA:BB",
+		},
+	}
+	config, err := csiTopologyConfig(vsphereCluster, []*infrav1.VSphereFailureDomain{
+		{
+			Spec: infrav1.VSphereFailureDomainSpec{
+				Region:   infrav1.FailureDomain{Name: "region", Type: infrav1.DatacenterFailureDomain, TagCategory: "k8s-region"},
+				Zone:     infrav1.FailureDomain{Name: "zone", Type: infrav1.ComputeClusterFailureDomain, TagCategory: "k8s-zone"},
+				Topology: infrav1.Topology{Datacenter: "dc"},
+			},
+		},
+	}, "user", "pass")
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+
+	t.Run("only the addons which are defined are rendered", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := addonsData(&infrav1.VSphereClusterAddons{CSI: &infrav1.CSIAddon{}}, config)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(HaveLen(1))
+		g.Expect(data).To(HaveKey(csiAddonKey))
+	})
+
+	t.Run("the cloud controller manager reads the credentials from a secret", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := addonsData(&infrav1.VSphereClusterAddons{
+			CloudControllerManager: &infrav1.CloudControllerManagerAddon{},
+		}, config)
+		g.Expect(err).NotTo(HaveOccurred())
+		manifests := string(data[cloudControllerManagerAddonKey])
+		g.Expect(manifests).To(ContainSubstring("kind: DaemonSet"))
+		g.Expect(manifests).To(ContainSubstring("image: " + cloudprovider.DefaultCPIControllerImage))
+		g.Expect(manifests).To(ContainSubstring("vcenter.example.com.username: user"))
+		g.Expect(manifests).To(ContainSubstring("secretName: " + cloudprovider.CloudControllerManagerCredentialsSecretName))
+		g.Expect(manifests).To(ContainSubstring("zone: k8s-zone"))
+	})
+
+	t.Run("the CSI driver is configured with the topology of the cluster", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := addonsData(&infrav1.VSphereClusterAddons{
+			CSI: &infrav1.CSIAddon{DriverImage: "registry.example.com/csi/driver:v2.5.0"},
+		}, config)
+		g.Expect(err).NotTo(HaveOccurred())
+		manifests := string(data[csiAddonKey])
+		g.Expect(manifests).To(ContainSubstring("apiVersion: storage.k8s.io/v1\nkind: CSIDriver"))
+		g.Expect(manifests).To(ContainSubstring("image: registry.example.com/csi/driver:v2.5.0"))
+		g.Expect(manifests).To(ContainSubstring("image: " + cloudprovider.DefaultCSIMetadataSyncerImage))
+		g.Expect(manifests).To(ContainSubstring("csi-vsphere.conf"))
+		g.Expect(manifests).To(ContainSubstring("k8s-zone"))
+	})
+}
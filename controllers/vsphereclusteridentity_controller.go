@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -31,11 +32,15 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
@@ -51,6 +56,7 @@ var (
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusteridentities,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vsphereclusteridentities/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch;update;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;patch
 
 func AddVsphereClusterIdentityControllerToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
 	var (
@@ -71,6 +77,18 @@ func AddVsphereClusterIdentityControllerToManager(ctx *context.ControllerManager
 	return ctrl.NewControllerManagedBy(mgr).
 		For(identityControlledType).
 		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		// Keep the usage of the identities up to date as their clusters and
+		// VMs are created, resized and deleted.
+		Watches(
+			&source.Kind{Type: &infrav1.VSphereCluster{}},
+			handler.EnqueueRequestsFromMapFunc(reconciler.vsphereClusterToIdentity),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&source.Kind{Type: &infrav1.VSphereVM{}},
+			handler.EnqueueRequestsFromMapFunc(reconciler.vsphereVMToIdentity),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(reconciler)
 }
 
@@ -79,6 +97,13 @@ type clusterIdentityReconciler struct {
 }
 
 func (r clusterIdentityReconciler) Reconcile(ctx _context.Context, req reconcile.Request) (_ reconcile.Result, reterr error) {
+	// VSphereClusterIdentities are neither on a server nor in a namespace,
+	// so they are only reconciled by the shard of the cluster-wide objects.
+	if !r.Shard.OwnsClusterWide() {
+		r.Logger.V(4).Info("VSphereClusterIdentity reconciled by another shard, won't reconcile", "key", req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
 	// TODO(gab-satchi) consider creating a context for the clusterIdentity
 	// Get VSphereClusterIdentity
 	identity := &infrav1.VSphereClusterIdentity{}
@@ -117,6 +142,16 @@ func (r clusterIdentityReconciler) Reconcile(ctx _context.Context, req reconcile
 		return r.reconcileDelete(ctx, identity)
 	}
 
+	if err := r.reconcileUsage(ctx, identity); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.reconcileCABundleMoveLabels(ctx, identity)
+
+	if identity.Spec.CredentialSource != nil {
+		return r.reconcileCredentialSource(ctx, identity)
+	}
+
 	// fetch secret
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{
@@ -159,8 +194,69 @@ func (r clusterIdentityReconciler) Reconcile(ctx _context.Context, req reconcile
 	return reconcile.Result{}, nil
 }
 
+// reconcileUsage reports the vSphere capacity consumed by the clusters using
+// an identity, and whether it is within the quota of the identity.
+func (r clusterIdentityReconciler) reconcileUsage(ctx _context.Context, identity *infrav1.VSphereClusterIdentity) error {
+	usage, err := infrav1.GetIdentityUsage(ctx, r.Client, identity.Name, nil)
+	if err != nil {
+		return err
+	}
+	identity.Status.Usage = usage
+
+	if identity.Spec.Quota == nil {
+		conditions.Delete(identity, infrav1.WithinQuotaCondition)
+		return nil
+	}
+	if exceeded := identity.Spec.Quota.Exceeded(usage); len(exceeded) > 0 {
+		conditions.MarkFalse(identity, infrav1.WithinQuotaCondition, infrav1.QuotaExceededReason, clusterv1.ConditionSeverityWarning, strings.Join(exceeded, ", "))
+		return nil
+	}
+	conditions.MarkTrue(identity, infrav1.WithinQuotaCondition)
+	return nil
+}
+
+// reconcileCABundleMoveLabels labels the CA bundles an identity references in
+// the controller namespace, so that clusterctl move carries them over with
+// it. The missing CA bundles are reported when the clusters of the identity
+// connect to vCenter.
+func (r clusterIdentityReconciler) reconcileCABundleMoveLabels(ctx _context.Context, identity *infrav1.VSphereClusterIdentity) {
+	refs := []*infrav1.CABundleReference{identity.Spec.CABundleRef}
+	if source := identity.Spec.CredentialSource; source != nil && source.Vault != nil {
+		refs = append(refs, source.Vault.CABundleRef)
+	}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		if err := pkgidentity.EnsureCABundleMoveLabel(ctx, r.Client, ref, r.Namespace); err != nil {
+			r.Logger.Error(err, "unable to label the CA bundle to be moved", "identity", identity.Name, "kind", ref.Kind, "name", ref.Name)
+		}
+	}
+}
+
+// reconcileCredentialSource reads the credentials of an identity from its
+// credential source, and requeues the identity to read them again once they
+// are due for renewal.
+func (r clusterIdentityReconciler) reconcileCredentialSource(ctx _context.Context, identity *infrav1.VSphereClusterIdentity) (reconcile.Result, error) {
+	creds, err := pkgidentity.GetSourcedCredentials(ctx, r.Client, identity, r.Namespace)
+	if err != nil {
+		conditions.MarkFalse(identity, infrav1.CredentialsAvailableCondidtion, infrav1.CredentialSourceUnavailableReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return reconcile.Result{}, errors.Wrap(err, "failed to read the credentials from the credential source")
+	}
+
+	conditions.MarkTrue(identity, infrav1.CredentialsAvailableCondidtion)
+	identity.Status.Ready = true
+	return reconcile.Result{RequeueAfter: time.Until(creds.RenewAt)}, nil
+}
+
 func (r clusterIdentityReconciler) reconcileDelete(ctx _context.Context, identity *infrav1.VSphereClusterIdentity) (reconcile.Result, error) {
 	r.Logger.Info("Reconciling VSphereClusterIdentity delete")
+	if identity.Spec.CredentialSource != nil {
+		// The secret, if any, was never claimed by the identity.
+		pkgidentity.ForgetSourcedCredentials(identity.Name)
+		return reconcile.Result{}, nil
+	}
+
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{
 		Namespace: r.Namespace,
@@ -189,3 +285,31 @@ func (r clusterIdentityReconciler) reconcileDelete(ctx _context.Context, identit
 
 	return reconcile.Result{}, nil
 }
+
+// vsphereClusterToIdentity returns the VSphereClusterIdentity a VSphereCluster
+// uses.
+func (r clusterIdentityReconciler) vsphereClusterToIdentity(o client.Object) []reconcile.Request {
+	vsphereCluster, ok := o.(*infrav1.VSphereCluster)
+	if !ok || vsphereCluster.IdentityName() == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: vsphereCluster.IdentityName()}}}
+}
+
+// vsphereVMToIdentity returns the VSphereClusterIdentity the VSphereCluster of
+// the cluster of a VSphereVM uses.
+func (r clusterIdentityReconciler) vsphereVMToIdentity(o client.Object) []reconcile.Request {
+	cluster, err := clusterutilv1.GetClusterFromMetadata(r, r.Client, metav1.ObjectMeta{
+		Namespace: o.GetNamespace(),
+		Labels:    o.GetLabels(),
+	})
+	if err != nil || cluster.Spec.InfrastructureRef == nil {
+		return nil
+	}
+	vsphereCluster := &infrav1.VSphereCluster{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.InfrastructureRef.Name}
+	if err := r.Client.Get(r, key, vsphereCluster); err != nil {
+		return nil
+	}
+	return r.vsphereClusterToIdentity(vsphereCluster)
+}
@@ -45,6 +45,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
@@ -107,6 +108,17 @@ func (r vsphereDeploymentZoneReconciler) Reconcile(ctx goctx.Context, request re
 		return reconcile.Result{}, err
 	}
 
+	// VSphereDeploymentZones are cluster-scoped, so they are only sharded by
+	// vCenter server.
+	owned, err := r.Shard.Owns(ctx, r.Client, "", vsphereDeploymentZone.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logr.V(4).Info("VSphereDeploymentZone reconciled by another shard, won't reconcile", "key", request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
 	failureDomain := &infrav1.VSphereFailureDomain{}
 	failureDomainKey := client.ObjectKey{Name: vsphereDeploymentZone.Spec.FailureDomain}
 	if err := r.Client.Get(ctx, failureDomainKey, failureDomain); err != nil {
@@ -158,11 +170,20 @@ func (r vsphereDeploymentZoneReconciler) reconcileNormal(ctx *context.VSphereDep
 		ctx.Logger.V(4).Error(err, "unable to create session")
 		conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
 		ctx.VSphereDeploymentZone.Status.Ready = pointer.Bool(false)
+		var unavailable *session.UnavailableError
+		if errors.As(err, &unavailable) {
+			return reconcile.Result{RequeueAfter: unavailable.RetryAfter}, nil
+		}
 		return reconcile.Result{}, errors.Wrapf(err, "unable to create auth session")
 	}
 	ctx.AuthSession = authSession
 	conditions.MarkTrue(ctx.VSphereDeploymentZone, infrav1.VCenterAvailableCondition)
 
+	if err := r.reconcileTrackedObjects(ctx); err != nil {
+		ctx.VSphereDeploymentZone.Status.Ready = pointer.Bool(false)
+		return reconcile.Result{}, errors.Wrap(err, "unable to resolve the compute cluster and resource pool")
+	}
+
 	if err := r.reconcilePlacementConstraint(ctx); err != nil {
 		ctx.VSphereDeploymentZone.Status.Ready = pointer.Bool(false)
 		return reconcile.Result{}, errors.Wrap(err, "placement constraint is misconfigured")
@@ -199,19 +220,61 @@ func (r vsphereDeploymentZoneReconciler) reconcileNormal(ctx *context.VSphereDep
 	return reconcile.Result{}, nil
 }
 
-func (r vsphereDeploymentZoneReconciler) reconcilePlacementConstraint(ctx *context.VSphereDeploymentZoneContext) error {
-	placementConstraint := ctx.VSphereDeploymentZone.Spec.PlacementConstraint
+// reconcileTrackedObjects resolves the compute cluster of the failure domain
+// and the resource pool of the placement constraint. They are tracked by
+// managed object ID once resolved, and the machines of the deployment zone are
+// placed with their current inventory paths, so that they are still placed
+// when the objects are renamed in vCenter.
+func (r vsphereDeploymentZoneReconciler) reconcileTrackedObjects(ctx *context.VSphereDeploymentZoneContext) error {
+	status := &ctx.VSphereDeploymentZone.Status
+	vimClient := ctx.AuthSession.Client.Client
+	var renamed []string
+
+	if computeCluster := ctx.VSphereFailureDomain.Spec.Topology.ComputeCluster; computeCluster != nil {
+		tracked, isRenamed, err := find.Tracked(ctx, vimClient, "ClusterComputeResource", *computeCluster, status.ComputeCluster, find.ClusterComputeResource(ctx.AuthSession.Finder))
+		if err != nil {
+			ctx.Logger.V(4).Error(err, "unable to find compute cluster", "name", *computeCluster)
+			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ComputeClusterNotFoundReason, clusterv1.ConditionSeverityError, "compute cluster %s not found", *computeCluster)
+			return errors.Wrapf(err, "unable to find compute cluster %s", *computeCluster)
+		}
+		status.ComputeCluster = tracked
+		if isRenamed {
+			renamed = append(renamed, fmt.Sprintf("compute cluster %s is now %s", *computeCluster, tracked.Path))
+		}
+	} else {
+		status.ComputeCluster = nil
+	}
 
-	if resourcePool := placementConstraint.ResourcePool; resourcePool != "" {
-		if _, err := ctx.AuthSession.Finder.ResourcePool(ctx, resourcePool); err != nil {
+	if resourcePool := ctx.VSphereDeploymentZone.Spec.PlacementConstraint.ResourcePool; resourcePool != "" {
+		tracked, isRenamed, err := find.Tracked(ctx, vimClient, "ResourcePool", resourcePool, status.ResourcePool, find.ResourcePool(ctx.AuthSession.Finder))
+		if err != nil {
 			ctx.Logger.V(4).Error(err, "unable to find resource pool", "name", resourcePool)
 			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.PlacementConstraintMetCondition, infrav1.ResourcePoolNotFoundReason, clusterv1.ConditionSeverityError, "resource pool %s is misconfigured", resourcePool)
 			return errors.Wrapf(err, "unable to find resource pool %s", resourcePool)
 		}
+		status.ResourcePool = tracked
+		if isRenamed {
+			renamed = append(renamed, fmt.Sprintf("resource pool %s is now %s", resourcePool, tracked.Path))
+		}
+	} else {
+		status.ResourcePool = nil
+	}
+
+	if len(renamed) > 0 {
+		ctx.Logger.Info("objects of the deployment zone were renamed in vCenter", "objects", renamed)
+		conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.InventoryPathsUpToDateCondition, infrav1.InventoryObjectRenamedReason, clusterv1.ConditionSeverityWarning,
+			"%s, machines are placed with the new inventory paths", strings.Join(renamed, ", "))
+		return nil
 	}
+	conditions.MarkTrue(ctx.VSphereDeploymentZone, infrav1.InventoryPathsUpToDateCondition)
+	return nil
+}
+
+func (r vsphereDeploymentZoneReconciler) reconcilePlacementConstraint(ctx *context.VSphereDeploymentZoneContext) error {
+	placementConstraint := ctx.VSphereDeploymentZone.Spec.PlacementConstraint
 
 	if folder := placementConstraint.Folder; folder != "" {
-		if _, err := ctx.AuthSession.Finder.Folder(ctx, placementConstraint.Folder); err != nil {
+		if _, err := find.ByMoRef(ctx.AuthSession.Client.Client, "Folder", find.Folder(ctx.AuthSession.Finder))(ctx, folder); err != nil {
 			ctx.Logger.V(4).Error(err, "unable to find folder", "name", folder)
 			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.PlacementConstraintMetCondition, infrav1.FolderNotFoundReason, clusterv1.ConditionSeverityError, "datastore %s is misconfigured", folder)
 			return errors.Wrapf(err, "unable to find folder %s", folder)
@@ -234,24 +297,46 @@ func (r vsphereDeploymentZoneReconciler) getVCenterSession(ctx *context.VSphereD
 		return nil, err
 	}
 
-	for _, vsphereCluster := range clusterList.Items {
-		if ctx.VSphereDeploymentZone.Spec.Server == vsphereCluster.Spec.Server && vsphereCluster.Spec.IdentityRef != nil {
-			logger := ctx.Logger.WithValues("cluster", vsphereCluster.Name)
-			params = params.WithThumbprint(vsphereCluster.Spec.Thumbprint)
-			clust := vsphereCluster
-			creds, err := identity.GetCredentials(ctx, r.Client, &clust, r.Namespace)
-			if err != nil {
-				logger.Error(err, "error retrieving credentials from IdentityRef")
-				continue
+	var managerCredsCluster *infrav1.VSphereCluster
+	for i := range clusterList.Items {
+		vsphereCluster := &clusterList.Items[i]
+		if ctx.VSphereDeploymentZone.Spec.Server != vsphereCluster.Spec.Server {
+			continue
+		}
+		if vsphereCluster.Spec.IdentityRef == nil {
+			if managerCredsCluster == nil {
+				managerCredsCluster = vsphereCluster
 			}
-			logger.Info("using server credentials to create the authenticated session")
-			params = params.WithUserInfo(creds.Username, creds.Password)
-			return session.GetOrCreate(r.Context,
-				params)
+			continue
+		}
+		logger := ctx.Logger.WithValues("cluster", vsphereCluster.Name)
+		creds, err := identity.GetCredentials(ctx, r.Client, vsphereCluster, r.Namespace)
+		if err != nil {
+			logger.Error(err, "error retrieving credentials from IdentityRef")
+			continue
 		}
+		caBundle, err := identity.GetCABundle(ctx, r.Client, vsphereCluster, r.Namespace)
+		if err != nil {
+			logger.Error(err, "error retrieving the CA bundle of the vCenter")
+			continue
+		}
+		logger.Info("using server credentials to create the authenticated session")
+		params = params.WithThumbprint(vsphereCluster.Spec.Thumbprint).
+			WithUserInfo(creds.Username, creds.Password).WithTokenProvider(creds.TokenProvider).WithCABundle(caBundle)
+		return session.GetOrCreate(r.Context,
+			params)
 	}
 
-	// Fallback to using credentials provided to the manager
+	// Fallback to using credentials provided to the manager. The vCenter is
+	// verified as for the clusters using them, so that their sessions are
+	// shared rather than recycled for a different CA bundle.
+	if managerCredsCluster != nil {
+		caBundle, err := identity.GetCABundle(ctx, r.Client, managerCredsCluster, r.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve the CA bundle of the vCenter")
+		}
+		params = params.WithThumbprint(managerCredsCluster.Spec.Thumbprint).WithCABundle(caBundle)
+	}
 	return session.GetOrCreate(r.Context,
 		params)
 }
@@ -18,6 +18,8 @@ package controllers
 
 import (
 	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 	apierrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -26,6 +28,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/cluster"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/metadata"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/taggable"
 )
@@ -69,16 +72,16 @@ func (r vsphereDeploymentZoneReconciler) reconcileInfraFailureDomain(ctx *contex
 }
 
 func (r vsphereDeploymentZoneReconciler) reconcileTopology(ctx *context.VSphereDeploymentZoneContext) error {
-	topology := ctx.VSphereFailureDomain.Spec.Topology
+	topology := ctx.VSphereDeploymentZone.Topology(ctx.VSphereFailureDomain)
 	if datastore := topology.Datastore; datastore != "" {
-		if _, err := ctx.AuthSession.Finder.Datastore(ctx, datastore); err != nil {
+		if _, err := find.ByMoRef(ctx.AuthSession.Client.Client, "Datastore", find.Datastore(ctx.AuthSession.Finder))(ctx, datastore); err != nil {
 			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.DatastoreNotFoundReason, clusterv1.ConditionSeverityError, "datastore %s is misconfigured", datastore)
 			return errors.Wrapf(err, "unable to find datastore %s", datastore)
 		}
 	}
 
 	for _, network := range topology.Networks {
-		if _, err := ctx.AuthSession.Finder.Network(ctx, network); err != nil {
+		if _, err := find.ByMoRef(ctx.AuthSession.Client.Client, "Network", find.Network(ctx.AuthSession.Finder))(ctx, network); err != nil {
 			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.NetworkNotFoundReason, clusterv1.ConditionSeverityError, "network %s is misconfigured", network)
 			return errors.Wrapf(err, "unable to find network %s", network)
 		}
@@ -101,32 +104,22 @@ func (r vsphereDeploymentZoneReconciler) reconcileTopology(ctx *context.VSphereD
 }
 
 func (r vsphereDeploymentZoneReconciler) reconcileComputeCluster(ctx *context.VSphereDeploymentZoneContext) error {
-	computeCluster := ctx.VSphereFailureDomain.Spec.Topology.ComputeCluster
-	if computeCluster == nil {
+	// The compute cluster and the resource pool are tracked by managed object
+	// ID, as resolved by reconcileTrackedObjects.
+	computeCluster, resourcePool := ctx.VSphereDeploymentZone.Status.ComputeCluster, ctx.VSphereDeploymentZone.Status.ResourcePool
+	if computeCluster == nil || resourcePool == nil {
 		return nil
 	}
 
-	ccr, err := ctx.AuthSession.Finder.ClusterComputeResource(ctx, *computeCluster)
+	rp := object.NewResourcePool(ctx.AuthSession.Client.Client, types.ManagedObjectReference{Type: "ResourcePool", Value: resourcePool.MoID})
+	ref, err := rp.Owner(ctx)
 	if err != nil {
-		conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ComputeClusterNotFoundReason, clusterv1.ConditionSeverityError, "compute cluster %s not found", *computeCluster)
-		return errors.Wrap(err, "compute cluster not found")
+		conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ComputeClusterNotFoundReason, clusterv1.ConditionSeverityError, "resource pool owner not found")
+		return errors.Wrap(err, "unable to find owner compute resource")
 	}
-
-	if resourcePool := ctx.VSphereDeploymentZone.Spec.PlacementConstraint.ResourcePool; resourcePool != "" {
-		rp, err := ctx.AuthSession.Finder.ResourcePool(ctx, resourcePool)
-		if err != nil {
-			return errors.Wrapf(err, "unable to find resource pool")
-		}
-
-		ref, err := rp.Owner(ctx)
-		if err != nil {
-			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ComputeClusterNotFoundReason, clusterv1.ConditionSeverityError, "resource pool owner not found")
-			return errors.Wrap(err, "unable to find owner compute resource")
-		}
-		if ref.Reference() != ccr.Reference() {
-			conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ResourcePoolNotFoundReason, clusterv1.ConditionSeverityError, "resource pool is not owned by compute cluster")
-			return errors.Errorf("compute cluster %s does not own resource pool %s", *computeCluster, resourcePool)
-		}
+	if ref.Reference().Value != computeCluster.MoID {
+		conditions.MarkFalse(ctx.VSphereDeploymentZone, infrav1.VSphereFailureDomainValidatedCondition, infrav1.ResourcePoolNotFoundReason, clusterv1.ConditionSeverityError, "resource pool is not owned by compute cluster")
+		return errors.Errorf("compute cluster %s does not own resource pool %s", computeCluster.Path, resourcePool.Path)
 	}
 	return nil
 }
@@ -54,9 +54,12 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
 	inframanager "sigs.k8s.io/cluster-api-provider-vsphere/pkg/manager"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/ratelimiter"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/resync"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/vmoperator"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
 
@@ -69,9 +72,11 @@ const hostInfoErrStr = "host info cannot be used as a label value"
 // +kubebuilder:rbac:groups=vmware.infrastructure.cluster.x-k8s.io,resources=vspheremachinetemplates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmware.infrastructure.cluster.x-k8s.io,resources=vspheremachinetemplates/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinesets,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages;virtualmachineimages/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes;events;configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps/status,verbs=get;update;patch
 
@@ -105,9 +110,17 @@ func AddMachineControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 		Logger:                   ctx.Logger.WithName(controllerNameShort),
 	}
 
+	resyncHandler := resync.NewHandler(controllerNameShort, ctx.SyncPeriodFor(context.VSphereMachineControllerName))
+	resyncHandler.ResyncPeriod = ctx.ResyncPeriod
 	builder := ctrl.NewControllerManagedBy(mgr).
-		// Watch the controlled, infrastructure resource.
-		For(controlledType).
+		// Watch the controlled, infrastructure resource. The resyncs are
+		// queued by the resync handler below, at the sync period of the
+		// controller.
+		For(controlledType, ctrlbldr.WithPredicates(resync.SkipResyncs())).
+		Watches(
+			&source.Kind{Type: controlledType},
+			resyncHandler,
+		).
 		// Watch the CAPI resource that owns this infrastructure resource.
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
@@ -122,7 +135,11 @@ func AddMachineControllerToManager(ctx *context.ControllerManagerContext, mgr ma
 			&source.Channel{Source: ctx.GetGenericEventChannelFor(controlledTypeGVK)},
 			&handler.EnqueueRequestForObject{},
 		).
-		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles})
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: ctx.MaxConcurrentReconcilesFor(context.VSphereMachineControllerName),
+			RateLimiter: ratelimiter.NewClusterFair(ctx.ClusterRateLimitQPS, ctx.ClusterRateLimitBurst,
+				ratelimiter.ClusterFromLabel(mgr.GetClient(), controlledType)),
+		})
 
 	r := machineReconciler{
 		ControllerContext: controllerContext,
@@ -205,6 +222,19 @@ func (r machineReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctr
 
 	cluster := r.fetchCAPICluster(machine, machineContext.GetVSphereMachine())
 
+	server, err := r.machineServer(ctx, machineContext.GetVSphereMachine(), cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	owned, err := r.Shard.Owns(ctx, r.Client, req.Namespace, server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		logger.V(4).Info("VSphereMachine reconciled by another shard, won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(machineContext.GetVSphereMachine(), r.Client)
 	if err != nil {
@@ -274,7 +304,7 @@ func (r machineReconciler) reconcileDelete(ctx context.MachineContext) (reconcil
 	}
 
 	// VM is being deleted
-	return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	return reconcile.Result{RequeueAfter: r.requeueAfter(ctx, 10*time.Second)}, nil
 }
 
 func (r machineReconciler) reconcileNormal(ctx context.MachineContext) (reconcile.Result, error) {
@@ -323,7 +353,7 @@ func (r machineReconciler) reconcileNormal(ctx context.MachineContext) (reconcil
 	if err != nil {
 		return reconcile.Result{}, err
 	} else if requeue {
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		return reconcile.Result{RequeueAfter: r.requeueAfter(ctx, 10*time.Second)}, nil
 	}
 
 	// The machine is patched at the last stage before marking the VM as provisioned
@@ -331,7 +361,7 @@ func (r machineReconciler) reconcileNormal(ctx context.MachineContext) (reconcil
 	// before attempting to patch.
 	err = r.patchMachineLabelsWithHostInfo(ctx)
 	if err != nil {
-		r.Logger.Error(err, "failed to patch machine with host info label", "machine ", ctx.GetMachine().Name)
+		r.Logger.Error(err, "failed to patch machine with host info labels", "machine ", ctx.GetMachine().Name)
 		return reconcile.Result{}, err
 	}
 
@@ -339,14 +369,19 @@ func (r machineReconciler) reconcileNormal(ctx context.MachineContext) (reconcil
 	return reconcile.Result{}, nil
 }
 
-// patchMachineLabelsWithHostInfo adds the ESXi host information as a label to the Machine object.
-// The ESXi host information is added with the CAPI node label prefix
-// which would be added onto the node by the CAPI controllers.
+// patchMachineLabelsWithHostInfo adds the ESXi host information and the vSphere topology of the VM
+// as labels to the Machine object. The labels are added with the CAPI node label prefix
+// which would be added onto the node by the node label controller. The topology labels
+// whose value is empty are removed, so that the labels follow the VM when it is migrated.
 func (r *machineReconciler) patchMachineLabelsWithHostInfo(ctx context.MachineContext) error {
 	hostInfo, err := r.VMService.GetHostInfo(ctx)
 	if err != nil {
 		return err
 	}
+	topologyInfo, err := r.VMService.GetTopologyInfo(ctx)
+	if err != nil {
+		return err
+	}
 
 	info := util.SanitizeHostInfoLabel(hostInfo)
 	errs := validation.IsValidLabelValue(info)
@@ -364,6 +399,19 @@ func (r *machineReconciler) patchMachineLabelsWithHostInfo(ctx context.MachineCo
 
 	labels := machine.GetLabels()
 	labels[constants.ESXiHostInfoLabel] = info
+	if machine.Spec.FailureDomain != nil {
+		if topologyInfo == nil {
+			topologyInfo = map[string]string{}
+		}
+		topologyInfo[constants.FailureDomainInfoLabel] = *machine.Spec.FailureDomain
+	}
+	for key, value := range topologyInfo {
+		if value = util.SanitizeLabelValue(value); value == "" {
+			delete(labels, key)
+			continue
+		}
+		labels[key] = value
+	}
 	machine.Labels = labels
 
 	return patchHelper.Patch(r, machine)
@@ -401,6 +449,48 @@ func (r *machineReconciler) fetchCAPICluster(machine *clusterv1.Machine, vsphere
 	return cluster
 }
 
+// machineServer returns the vCenter server of a VSphereMachine, which defaults
+// to the server of the VSphereCluster of its cluster. It is empty for the
+// Supervisor machines, and when the cluster or its VSphereCluster is not
+// known. The other errors getting the VSphereCluster are returned, so that
+// the machine is retried rather than considered owned by another shard.
+func (r *machineReconciler) machineServer(ctx goctx.Context, vsphereMachine context.VSphereMachine, cluster *clusterv1.Cluster) (string, error) {
+	vimMachine, ok := vsphereMachine.(*infrav1.VSphereMachine)
+	if !ok {
+		return "", nil
+	}
+	if vimMachine.Spec.Server != "" {
+		return vimMachine.Spec.Server, nil
+	}
+	if cluster == nil || cluster.Spec.InfrastructureRef == nil {
+		return "", nil
+	}
+	vsphereCluster := &infrav1.VSphereCluster{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.InfrastructureRef.Name}
+	if err := r.Client.Get(ctx, key, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get VSphereCluster %s", key)
+	}
+	return vsphereCluster.Spec.Server, nil
+}
+
+// requeueAfter returns the period after which a VSphereMachine waiting for its
+// VM is requeued, scaled by the load of its vCenter. It is not scaled for the
+// Supervisor machines, whose VMs are created by the VM Operator.
+func (r *machineReconciler) requeueAfter(ctx context.MachineContext, period time.Duration) time.Duration {
+	vimMachineCtx, ok := ctx.(*context.VIMMachineContext)
+	if !ok {
+		return period
+	}
+	server := vimMachineCtx.VSphereMachine.Spec.Server
+	if server == "" && vimMachineCtx.VSphereCluster != nil {
+		server = vimMachineCtx.VSphereCluster.Spec.Server
+	}
+	return session.ServerLoad(server).Scale(period)
+}
+
 // Return hooks that will be invoked when a VirtualMachine is created.
 func (r *machineReconciler) setVMModifiers(c context.MachineContext) error {
 	ctx, ok := c.(*vmware.SupervisorMachineContext)
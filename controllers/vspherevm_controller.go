@@ -19,6 +19,7 @@ package controllers
 import (
 	goctx "context"
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 	"time"
@@ -28,6 +29,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/remote"
@@ -37,6 +39,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -48,16 +51,37 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/feature"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/clustermodule"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/ratelimiter"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/resync"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/preflight"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
 
+// taskProgressRequeuePeriod is the interval at which a VSphereVM is requeued
+// while a task is running, so that its status reflects the task's progress.
+// It is scaled by the load of the vCenter of the VSphereVM.
+const taskProgressRequeuePeriod = 15 * time.Second
+
+// ipAllocationRequeuePeriod is the interval at which a VSphereVM is requeued
+// while it waits for the IP addresses of its VM. It is scaled by the load of
+// the vCenter of the VSphereVM.
+const ipAllocationRequeuePeriod = 10 * time.Second
+
+// identityQuotaRequeuePeriod is the interval at which a VSphereVM exceeding
+// the quota of the identity of its cluster is requeued, until capacity is
+// freed up or the quota is raised.
+const identityQuotaRequeuePeriod = time.Minute
+
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspherevms,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspherevms/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinesets,verbs=get;list;watch
@@ -66,6 +90,8 @@ import (
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;delete
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;create;update;watch;list
 // +kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=vspheretenantpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 // AddVMControllerToManager adds the VM controller to the provided manager.
 //
@@ -91,9 +117,18 @@ func AddVMControllerToManager(ctx *context.ControllerManagerContext, mgr manager
 		ControllerContext: controllerContext,
 		VMService:         &govmomi.VMService{},
 	}
+	resyncHandler := resync.NewHandler(controllerNameShort, ctx.SyncPeriodFor(context.VSphereVMControllerName))
+	resyncHandler.ResyncPeriod = ctx.ResyncPeriod
 	controller, err := ctrl.NewControllerManagedBy(mgr).
-		// Watch the controlled, infrastructure resource.
-		For(controlledType).
+		// Watch the controlled, infrastructure resource. The resyncs are
+		// queued by the resync handler below.
+		For(controlledType, builder.WithPredicates(resync.SkipResyncs())).
+		// Spread the reconciles of the resyncs over the sync period, so that
+		// the VMs are not all reconciled against vCenter at once.
+		Watches(
+			&source.Kind{Type: controlledType},
+			resyncHandler,
+		).
 		// Watch a GenericEvent channel for the controlled resource.
 		//
 		// This is useful when there are events outside of Kubernetes that
@@ -103,7 +138,11 @@ func AddVMControllerToManager(ctx *context.ControllerManagerContext, mgr manager
 			&source.Channel{Source: ctx.GetGenericEventChannelFor(controlledTypeGVK)},
 			&handler.EnqueueRequestForObject{},
 		).
-		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: ctx.MaxConcurrentReconcilesFor(context.VSphereVMControllerName),
+			RateLimiter: ratelimiter.NewClusterFair(ctx.ClusterRateLimitQPS, ctx.ClusterRateLimitBurst,
+				ratelimiter.ClusterFromLabel(mgr.GetClient(), controlledType)),
+		}).
 		Build(r)
 	if err != nil {
 		return err
@@ -136,7 +175,8 @@ func AddVMControllerToManager(ctx *context.ControllerManagerContext, mgr manager
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				oldCluster := e.ObjectOld.(*infrav1.VSphereCluster)
 				newCluster := e.ObjectNew.(*infrav1.VSphereCluster)
-				return !clustermodule.Compare(oldCluster.Spec.ClusterModules, newCluster.Spec.ClusterModules)
+				return !clustermodule.Compare(oldCluster.Spec.ClusterModules, newCluster.Spec.ClusterModules) ||
+					oldCluster.VSphereOperationsPaused() != newCluster.VSphereOperationsPaused()
 			},
 			CreateFunc:  func(e event.CreateEvent) bool { return false },
 			DeleteFunc:  func(e event.DeleteEvent) bool { return false },
@@ -145,6 +185,17 @@ func AddVMControllerToManager(ctx *context.ControllerManagerContext, mgr manager
 	if err != nil {
 		return err
 	}
+
+	if feature.Gates.Enabled(feature.VCenterEvents) {
+		eventChannel := ctx.GetGenericEventChannelFor(controlledTypeGVK)
+		session.RegisterVMEventHandler(func(e session.VMEvent) {
+			r.vmEventToVSphereVMs(e, eventChannel)
+		})
+	}
+
+	// Resolve the inventory paths of the VMs again once the objects they
+	// resolved to are found to be deleted.
+	session.RegisterObjectNotFoundHandler(find.Paths.Invalidate)
 	return nil
 }
 
@@ -161,11 +212,32 @@ func (r vmReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Res
 	if err := r.Client.Get(r, req.NamespacedName, vsphereVM); err != nil {
 		if apierrors.IsNotFound(err) {
 			r.Logger.Info("VSphereVM not found, won't reconcile", "key", req.NamespacedName)
+			metrics.ForgetTemplateLookup(req.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
 
+	owned, err := r.Shard.Owns(ctx, r.Client, vsphereVM.Namespace, vsphereVM.Spec.Server)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !owned {
+		r.Logger.V(4).Info("VSphereVM reconciled by another shard, won't reconcile", "key", req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	// Keep track of the VSphereVMs that still look up their template by name.
+	if vsphereVM.DeletionTimestamp.IsZero() {
+		lookup := metrics.TemplateLookupByName
+		if infrav1.IsTemplateInstanceUUID(vsphereVM.Spec.Template) {
+			lookup = metrics.TemplateLookupByInstanceUUID
+		}
+		metrics.RecordTemplateLookup(req.NamespacedName, lookup)
+	} else {
+		metrics.ForgetTemplateLookup(req.NamespacedName)
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(vsphereVM, r.Client)
 	if err != nil {
@@ -180,7 +252,18 @@ func (r vmReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Res
 	authSession, err := r.retrieveVcenterSession(ctx, vsphereVM)
 	if err != nil {
 		conditions.MarkFalse(vsphereVM, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
-		return reconcile.Result{}, err
+		return r.reconcileSessionError(vsphereVM, patchHelper, err)
+	}
+
+	// The template session is only needed to clone the VM, so it is not
+	// retrieved while the VSphereVM is deleted.
+	var templateSession *session.Session
+	if vsphereVM.DeletionTimestamp.IsZero() {
+		templateSession, err = r.retrieveTemplateSession(ctx, vsphereVM)
+		if err != nil {
+			conditions.MarkFalse(vsphereVM, infrav1.VCenterAvailableCondition, infrav1.VCenterUnreachableReason, clusterv1.ConditionSeverityError, err.Error())
+			return r.reconcileSessionError(vsphereVM, patchHelper, err)
+		}
 	}
 	conditions.MarkTrue(vsphereVM, infrav1.VCenterAvailableCondition)
 
@@ -222,6 +305,9 @@ func (r vmReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Res
 		if err := r.Client.Get(r, apitypes.NamespacedName{Name: vsphereDeploymentZone.Spec.FailureDomain}, vsphereFailureDomain); err != nil {
 			return reconcile.Result{}, errors.Wrapf(err, "failed to find vsphere failure domain %s", vsphereDeploymentZone.Spec.FailureDomain)
 		}
+		// Use the current inventory path of the compute cluster tracked by the
+		// deployment zone, which may have been renamed in vCenter.
+		vsphereFailureDomain.Spec.Topology = vsphereDeploymentZone.Topology(vsphereFailureDomain)
 	}
 
 	// Create the VM context for this request.
@@ -230,8 +316,10 @@ func (r vmReconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Res
 		VSphereVM:            vsphereVM,
 		VSphereFailureDomain: vsphereFailureDomain,
 		Session:              authSession,
+		TemplateSession:      templateSession,
 		Logger:               r.Logger.WithName(req.Namespace).WithName(req.Name),
 		PatchHelper:          patchHelper,
+		Bootstrapped:         machine.Status.NodeRef != nil,
 	}
 
 	// Print the task-ref upon entry and upon exit.
@@ -296,16 +384,23 @@ func (r vmReconciler) reconcile(ctx *context.VMContext, input fetchClusterModule
 
 	// Handle deleted machines
 	if !ctx.VSphereVM.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx)
+		return r.reconcileDelete(ctx, input.VSphereCluster)
 	}
 
 	// Handle non-deleted machines
-	return r.reconcileNormal(ctx)
+	return r.reconcileNormal(ctx, input.VSphereCluster)
 }
 
-func (r vmReconciler) reconcileDelete(ctx *context.VMContext) (reconcile.Result, error) {
+func (r vmReconciler) reconcileDelete(ctx *context.VMContext, vsphereCluster *infrav1.VSphereCluster) (reconcile.Result, error) {
 	ctx.Logger.Info("Handling deleted VSphereVM")
 
+	if vsphereCluster.VSphereOperationsPaused() {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.VSphereOperationsPausedReason, clusterv1.ConditionSeverityInfo,
+			"the vSphere operations of the cluster are paused, the VM is deleted once they resume")
+		ctx.Logger.Info("vSphere operations are paused, won't delete the VM")
+		return reconcile.Result{}, nil
+	}
+
 	conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
 	vm, err := r.VMService.DestroyVM(ctx)
 	if err != nil {
@@ -313,14 +408,24 @@ func (r vmReconciler) reconcileDelete(ctx *context.VMContext) (reconcile.Result,
 		return reconcile.Result{}, errors.Wrapf(err, "failed to destroy VM")
 	}
 
-	// Requeue the operation until the VM is "notfound".
-	if vm.State != infrav1.VirtualMachineStateNotFound {
+	// Requeue the operation until the VM is "notfound", or "retained" by the
+	// deletion policy of the VSphereVM.
+	switch vm.State {
+	case infrav1.VirtualMachineStateNotFound:
+		if err := r.recordRetainedDisks(ctx, vsphereCluster); err != nil {
+			return reconcile.Result{}, err
+		}
+	case infrav1.VirtualMachineStateRetained:
+		if err := r.recordRetainedVM(ctx, vsphereCluster, vm); err != nil {
+			return reconcile.Result{}, err
+		}
+	default:
 		ctx.Logger.Info("vm state is not reconciled", "expected-vm-state", infrav1.VirtualMachineStateNotFound, "actual-vm-state", vm.State)
 		return reconcile.Result{}, nil
 	}
 
 	// Attempt to delete the node corresponding to the vsphere VM
-	err = r.deleteNode(ctx, vm.Name)
+	err = r.deleteNode(ctx)
 	if err != nil {
 		r.Logger.V(6).Info("unable to delete node", "err", err)
 	}
@@ -342,9 +447,13 @@ func (r vmReconciler) reconcileDelete(ctx *context.VMContext) (reconcile.Result,
 				}
 				return reconcile.Result{}, errors.Wrapf(err, fmt.Sprintf("failed to find IPAddressClaim %q to remove the finalizer", ipAddrClaimName))
 			}
+			patchHelper, err := patch.NewHelper(ipAddrClaim, ctx.Client)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
 			if ctrlutil.RemoveFinalizer(ipAddrClaim, infrav1.IPAddressClaimFinalizer) {
-				if err := ctx.Client.Update(ctx, ipAddrClaim); err != nil {
-					return reconcile.Result{}, errors.Wrapf(err, fmt.Sprintf("failed to update IPAddressClaim %q", ipAddrClaimName))
+				if err := patchHelper.Patch(ctx, ipAddrClaim); err != nil {
+					return reconcile.Result{}, errors.Wrapf(err, fmt.Sprintf("failed to patch IPAddressClaim %q", ipAddrClaimName))
 				}
 			}
 		}
@@ -356,11 +465,84 @@ func (r vmReconciler) reconcileDelete(ctx *context.VMContext) (reconcile.Result,
 	return reconcile.Result{}, nil
 }
 
+// recordRetainedVM reports a VM retained by the deletion policy of its
+// VSphereVM in the status of the VSphereCluster, before the finalizer of the
+// VSphereVM is removed, so that the VM is not lost track of. The status is
+// patched with an optimistic lock, as the VMs of the cluster may be retained
+// concurrently.
+func (r vmReconciler) recordRetainedVM(ctx *context.VMContext, vsphereCluster *infrav1.VSphereCluster, vm infrav1.VirtualMachine) error {
+	server := ctx.VSphereVM.Spec.Server
+	for _, retained := range vsphereCluster.Status.RetainedVMs {
+		if retained.Name == vm.Name && retained.Server == server && retained.BiosUUID == vm.BiosUUID {
+			return nil
+		}
+	}
+
+	base := vsphereCluster.DeepCopy()
+	vsphereCluster.Status.RetainedVMs = append(vsphereCluster.Status.RetainedVMs, infrav1.RetainedVM{
+		Name:           vm.Name,
+		Server:         server,
+		BiosUUID:       vm.BiosUUID,
+		DeletionPolicy: ctx.VSphereVM.Spec.DeletionPolicy,
+		RetainedAt:     metav1.Now(),
+	})
+	if err := r.Client.Status().Patch(ctx, vsphereCluster, ctrlclient.MergeFromWithOptions(base, ctrlclient.MergeFromWithOptimisticLock{})); err != nil {
+		return errors.Wrapf(err, "failed to report the retained VM %s in the status of VSphereCluster %s/%s",
+			vm.Name, vsphereCluster.Namespace, vsphereCluster.Name)
+	}
+	ctx.Logger.Info("VM retained by the deletion policy", "deletionPolicy", ctx.VSphereVM.Spec.DeletionPolicy, "biosUUID", vm.BiosUUID)
+	r.Recorder.Eventf(ctx.VSphereVM, "VMRetained", "VM %s was left in vCenter by the %s deletion policy", vm.Name, ctx.VSphereVM.Spec.DeletionPolicy)
+	return nil
+}
+
+// recordRetainedDisks reports the disks detached from the VM of a VSphereVM
+// to be retained in the status of the VSphereCluster, once the VM is
+// destroyed and before the finalizer of the VSphereVM is removed, so that the
+// disks are not lost track of with the VSphereVM. As for the retained VMs,
+// the status is patched with an optimistic lock.
+func (r vmReconciler) recordRetainedDisks(ctx *context.VMContext, vsphereCluster *infrav1.VSphereCluster) error {
+	server := ctx.VSphereVM.Spec.Server
+	recorded := map[string]bool{}
+	for _, disk := range vsphereCluster.Status.RetainedDisks {
+		if disk.Server == server {
+			recorded[disk.Path] = true
+		}
+	}
+
+	base := vsphereCluster.DeepCopy()
+	now := metav1.Now()
+	for _, path := range ctx.VSphereVM.Status.RetainedDisks {
+		if recorded[path] {
+			continue
+		}
+		vsphereCluster.Status.RetainedDisks = append(vsphereCluster.Status.RetainedDisks, infrav1.RetainedDisk{
+			Path:       path,
+			VMName:     ctx.VSphereVM.VMName(),
+			Server:     server,
+			RetainedAt: now,
+		})
+	}
+	if len(vsphereCluster.Status.RetainedDisks) == len(base.Status.RetainedDisks) {
+		return nil
+	}
+	if err := r.Client.Status().Patch(ctx, vsphereCluster, ctrlclient.MergeFromWithOptions(base, ctrlclient.MergeFromWithOptimisticLock{})); err != nil {
+		return errors.Wrapf(err, "failed to report the retained disks of VSphereVM %s in the status of VSphereCluster %s/%s",
+			ctx.VSphereVM.Name, vsphereCluster.Namespace, vsphereCluster.Name)
+	}
+	return nil
+}
+
 // deleteNode attempts to find and best effort delete the node corresponding to the VM
 // This is necessary since CAPI does not the nodeRef field on the owner Machine object
 // until the node moves to Ready state. Hence, on Machine deletion it is unable to delete
-// the kubernetes node corresponding to the VM.
-func (r vmReconciler) deleteNode(ctx *context.VMContext, name string) error {
+// the kubernetes node corresponding to the VM. The node is named after the
+// hostname of the VM.
+func (r vmReconciler) deleteNode(ctx *context.VMContext) error {
+	name, err := ctx.VSphereVM.HostName()
+	if err != nil {
+		return err
+	}
+
 	// Fetching the cluster object from the VSphereVM object to create a remote client to the cluster
 	cluster, err := clusterutilv1.GetClusterFromMetadata(r.ControllerContext, r.Client, ctx.VSphereVM.ObjectMeta)
 	if err != nil {
@@ -383,7 +565,7 @@ func (r vmReconciler) deleteNode(ctx *context.VMContext, name string) error {
 	return nil
 }
 
-func (r vmReconciler) reconcileNormal(ctx *context.VMContext) (reconcile.Result, error) {
+func (r vmReconciler) reconcileNormal(ctx *context.VMContext, vsphereCluster *infrav1.VSphereCluster) (reconcile.Result, error) {
 	if ctx.VSphereVM.Status.FailureReason != nil || ctx.VSphereVM.Status.FailureMessage != nil {
 		r.Logger.Info("VM is failed, won't reconcile", "namespace", ctx.VSphereVM.Namespace, "name", ctx.VSphereVM.Name)
 		return reconcile.Result{}, nil
@@ -391,6 +573,47 @@ func (r vmReconciler) reconcileNormal(ctx *context.VMContext) (reconcile.Result,
 	// If the VSphereVM doesn't have our finalizer, add it.
 	ctrlutil.AddFinalizer(ctx.VSphereVM, infrav1.VMFinalizer)
 
+	if vsphereCluster.VSphereOperationsPaused() {
+		return r.reconcilePaused(ctx)
+	}
+
+	if feature.Gates.Enabled(feature.TenantIsolation) {
+		allErrs, err := infrav1.ValidateTenancy(ctx, r.Client, ctx.VSphereVM.Namespace, &ctx.VSphereVM.Spec.VirtualMachineCloneSpec, field.NewPath("spec"), false)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(allErrs) > 0 {
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.TenantPolicyViolationReason, clusterv1.ConditionSeverityError, allErrs.ToAggregate().Error())
+			ctx.Logger.Info("vm violates the tenant policies, won't reconcile", "errors", allErrs.ToAggregate().Error())
+			return reconcile.Result{}, nil
+		}
+	}
+
+	// The quota of the identity of the cluster was validated when the
+	// VSphereVM was created, and is validated again against the VMs created
+	// or being created until the VM is created, in case it was lowered since.
+	if ctx.VSphereVM.Spec.BiosUUID == "" && ctx.VSphereVM.Status.TaskRef == "" {
+		allErrs, err := infrav1.ValidateIdentityQuota(ctx, r.Client, vsphereCluster, ctx.VSphereVM, func(vm *infrav1.VSphereVM) bool {
+			return vm.Spec.BiosUUID != "" || vm.Status.TaskRef != ""
+		})
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(allErrs) > 0 {
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.QuotaExceededReason, clusterv1.ConditionSeverityWarning, allErrs.ToAggregate().Error())
+			ctx.Logger.Info("vm exceeds the quota of the identity of the cluster, won't create it", "errors", allErrs.ToAggregate().Error())
+			return reconcile.Result{RequeueAfter: identityQuotaRequeuePeriod}, nil
+		}
+	}
+
+	// The port groups are validated until the VM is created, so that the
+	// incompatible ones are reported before the machine fails to join.
+	if ctx.VSphereVM.Spec.BiosUUID == "" && ctx.VSphereVM.Status.TaskRef == "" {
+		if err := r.reconcilePortGroupValidation(ctx, vsphereCluster); err != nil {
+			ctx.Logger.Error(err, "could not validate the port groups of the VM")
+		}
+	}
+
 	if r.isWaitingForStaticIPAllocation(ctx) {
 		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
 		ctx.Logger.Info("vm is waiting for static ip to be available")
@@ -410,6 +633,14 @@ func (r vmReconciler) reconcileNormal(ctx *context.VMContext) (reconcile.Result,
 			"VM state is not reconciled",
 			"expected-vm-state", infrav1.VirtualMachineStateReady,
 			"actual-vm-state", vm.State)
+		// Keep the progress of the in-flight task up to date.
+		if ctx.VSphereVM.Status.Task != nil && ctx.VSphereVM.Status.Task.State == infrav1.TaskStateRunning {
+			return reconcile.Result{RequeueAfter: session.ServerLoad(ctx.VSphereVM.Spec.Server).Scale(taskProgressRequeuePeriod)}, nil
+		}
+		// The shutdown of the guest to resize the VM has no task to wait for.
+		if conditions.GetReason(ctx.VSphereVM, infrav1.VMResizedCondition) == infrav1.PoweringOffForResizeReason {
+			return reconcile.Result{RequeueAfter: session.ServerLoad(ctx.VSphereVM.Spec.Server).Scale(taskProgressRequeuePeriod)}, nil
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -429,7 +660,13 @@ func (r vmReconciler) reconcileNormal(ctx *context.VMContext) (reconcile.Result,
 	// we didn't get any addresses, requeue
 	if len(ctx.VSphereVM.Status.Addresses) == 0 {
 		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.WaitingForIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		return reconcile.Result{RequeueAfter: session.ServerLoad(ctx.VSphereVM.Spec.Server).Scale(ipAllocationRequeuePeriod)}, nil
+	}
+	// dual-stack VMs are only ready once they have an address of each family
+	if family := missingAddressFamily(ctx.VSphereVM); family != "" {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.WaitingForIPAllocationReason, clusterv1.ConditionSeverityInfo,
+			"waiting for an %s address", family)
+		return reconcile.Result{RequeueAfter: session.ServerLoad(ctx.VSphereVM.Spec.Server).Scale(ipAllocationRequeuePeriod)}, nil
 	}
 
 	// Once the network is online the VM is considered ready.
@@ -440,6 +677,46 @@ func (r vmReconciler) reconcileNormal(ctx *context.VMContext) (reconcile.Result,
 	return reconcile.Result{}, nil
 }
 
+// reconcilePortGroupValidation checks the distributed port groups of the
+// VSphereVM against the PortGroupValidation of its cluster, and sets its
+// PortGroupsCompatible condition. The VM is created whatever the result.
+func (r vmReconciler) reconcilePortGroupValidation(ctx *context.VMContext, vsphereCluster *infrav1.VSphereCluster) error {
+	networkSpec := vsphereCluster.Spec.NetworkSpec
+	if networkSpec == nil || networkSpec.PortGroupValidation == nil {
+		conditions.Delete(ctx.VSphereVM, infrav1.PortGroupsCompatibleCondition)
+		return nil
+	}
+	failures, err := preflight.CheckPortGroups(ctx, ctx.Session, &ctx.VSphereVM.Spec.VirtualMachineCloneSpec,
+		networkSpec.PortGroupValidation, util.IsControlPlaneMachine(ctx.VSphereVM), "spec")
+	if err != nil {
+		return err
+	}
+	markPreflightFailures(ctx.VSphereVM, infrav1.PortGroupsCompatibleCondition, failures)
+	return nil
+}
+
+// reconcilePaused updates the status of a VSphereVM whose cluster has its
+// vSphere operations paused from its VM, without creating or reconfiguring
+// the VM.
+func (r vmReconciler) reconcilePaused(ctx *context.VMContext) (reconcile.Result, error) {
+	vm, err := r.VMService.GetVM(ctx)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get VM")
+	}
+	if vm.State != infrav1.VirtualMachineStateReady {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.VSphereOperationsPausedReason, clusterv1.ConditionSeverityInfo,
+			"the vSphere operations of the cluster are paused")
+		ctx.Logger.Info("vSphere operations are paused, won't reconcile the VM", "vm-state", vm.State)
+		return reconcile.Result{}, nil
+	}
+
+	if vm.BiosUUID != "" {
+		ctx.VSphereVM.Spec.BiosUUID = vm.BiosUUID
+	}
+	r.reconcileNetwork(ctx, vm)
+	return reconcile.Result{}, nil
+}
+
 // isWaitingForStaticIPAllocation checks whether the VM should wait for a static IP
 // to be allocated.
 // It checks the state of both DHCP4 and DHCP6 for all the network devices and if
@@ -456,6 +733,38 @@ func (r vmReconciler) isWaitingForStaticIPAllocation(ctx *context.VMContext) boo
 	return false
 }
 
+// missingAddressFamily returns the address family, IPv4 or IPv6, requested by
+// the network devices of a VSphereVM through DHCP, IPv6 autoconfiguration or
+// static addresses but not reported in its addresses yet, if any.
+func missingAddressFamily(vm *infrav1.VSphereVM) string {
+	var wantIPv4, wantIPv6 bool
+	for _, device := range vm.Spec.Network.Devices {
+		wantIPv4 = wantIPv4 || device.DHCP4
+		wantIPv6 = wantIPv6 || device.DHCP6 || device.IPv6AddressMode != ""
+		for _, addr := range device.IPAddrs {
+			if ip, _, err := net.ParseCIDR(addr); err == nil {
+				wantIPv4 = wantIPv4 || ip.To4() != nil
+				wantIPv6 = wantIPv6 || ip.To4() == nil
+			}
+		}
+	}
+
+	var hasIPv4, hasIPv6 bool
+	for _, addr := range vm.Status.Addresses {
+		if ip := net.ParseIP(addr); ip != nil {
+			hasIPv4 = hasIPv4 || ip.To4() != nil
+			hasIPv6 = hasIPv6 || ip.To4() == nil
+		}
+	}
+	switch {
+	case wantIPv4 && !hasIPv4:
+		return "IPv4"
+	case wantIPv6 && !hasIPv6:
+		return "IPv6"
+	}
+	return ""
+}
+
 func (r vmReconciler) reconcileNetwork(ctx *context.VMContext, vm infrav1.VirtualMachine) {
 	ctx.VSphereVM.Status.Network = vm.Network
 	ipAddrs := make([]string, 0, len(vm.Network))
@@ -520,22 +829,97 @@ func (r vmReconciler) vsphereClusterToVSphereVMs(a ctrlclient.Object) []reconcil
 	return requests
 }
 
-func (r vmReconciler) retrieveVcenterSession(ctx goctx.Context, vsphereVM *infrav1.VSphereVM) (*session.Session, error) {
-	// Get cluster object and then get VSphereCluster object
+// vmEventToVSphereVMs triggers a reconcile of the VSphereVMs backed by the VM
+// a vCenter event was observed for. The VSphereVMs are matched by the name of
+// their VM, which is set by their naming strategy, and by server.
+func (r vmReconciler) vmEventToVSphereVMs(e session.VMEvent, eventChannel chan event.GenericEvent) {
+	vms := &infrav1.VSphereVMList{}
+	if err := r.Client.List(r, vms); err != nil {
+		r.Logger.Error(err, "failed to list VSphereVMs for vCenter event", "vmref", e.Ref, "reason", e.Reason)
+		return
+	}
+	for i := range vms.Items {
+		vsphereVM := &vms.Items[i]
+		if vsphereVM.VMName() != e.Name || vsphereVM.Spec.Server != e.Server {
+			continue
+		}
+		r.Logger.V(4).Info("triggering GenericEvent for vCenter event",
+			"key", ctrlclient.ObjectKeyFromObject(vsphereVM), "vmref", e.Ref, "reason", e.Reason)
+		go func() {
+			eventChannel <- event.GenericEvent{Object: vsphereVM}
+		}()
+	}
+}
 
-	params := session.NewParams().
+func (r vmReconciler) retrieveVcenterSession(ctx goctx.Context, vsphereVM *infrav1.VSphereVM) (*session.Session, error) {
+	params, err := r.retrieveVcenterParams(ctx, vsphereVM)
+	if err != nil {
+		return nil, err
+	}
+	params = params.
 		WithServer(vsphereVM.Spec.Server).
 		WithDatacenter(vsphereVM.Spec.Datacenter).
+		WithThumbprint(vsphereVM.Spec.Thumbprint)
+	return session.GetOrCreate(r.Context,
+		params)
+}
+
+// reconcileSessionError returns the result of a reconcile which failed to get
+// a session to vCenter with err. The VSphereVMs on a fenced vCenter are skipped
+// until it is probed again, once their VCenterAvailable condition is patched,
+// so that they do not hold a worker while vCenter is unreachable.
+func (r vmReconciler) reconcileSessionError(vsphereVM *infrav1.VSphereVM, patchHelper *patch.Helper, err error) (reconcile.Result, error) {
+	var unavailable *session.UnavailableError
+	if !errors.As(err, &unavailable) {
+		return reconcile.Result{}, err
+	}
+	r.Logger.V(4).Info("vCenter is fenced, skipping VSphereVM", "server", unavailable.Server, "retry-after", unavailable.RetryAfter,
+		"key", ctrlclient.ObjectKeyFromObject(vsphereVM))
+	if err := patchHelper.Patch(r, vsphereVM); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: unavailable.RetryAfter}, nil
+}
+
+// retrieveTemplateSession returns a session to the vCenter the template of
+// the VSphereVM is cloned from, or nil if the VSphereVM has no TemplateSource.
+func (r vmReconciler) retrieveTemplateSession(ctx goctx.Context, vsphereVM *infrav1.VSphereVM) (*session.Session, error) {
+	source := vsphereVM.Spec.TemplateSource
+	if source == nil {
+		return nil, nil
+	}
+	params, err := r.retrieveVcenterParams(ctx, vsphereVM)
+	if err != nil {
+		return nil, err
+	}
+	// The CA bundle of the cluster only verifies the vCenter of the cluster.
+	if source.Server != vsphereVM.Spec.Server {
+		params = params.WithCABundle(nil)
+	}
+	params = params.
+		WithServer(source.Server).
+		WithDatacenter(source.Datacenter).
+		WithThumbprint(source.Thumbprint)
+	return session.GetOrCreate(r.Context,
+		params)
+}
+
+// retrieveVcenterParams returns the parameters of the sessions of the
+// VSphereVM, with the credentials of the IdentityRef of the VSphereCluster of
+// the VSphereVM, if any, or the credentials provided to the manager, and with
+// the CA bundle of the VSphereCluster, if any.
+func (r vmReconciler) retrieveVcenterParams(ctx goctx.Context, vsphereVM *infrav1.VSphereVM) (*session.Params, error) {
+	params := session.NewParams().
 		WithUserInfo(r.ControllerContext.Username, r.ControllerContext.Password).
-		WithThumbprint(vsphereVM.Spec.Thumbprint).
 		WithFeatures(session.Feature{
 			KeepAliveDuration: r.KeepAliveDuration,
 		})
+
+	// Get cluster object and then get VSphereCluster object
 	cluster, err := clusterutilv1.GetClusterFromMetadata(r.ControllerContext, r.Client, vsphereVM.ObjectMeta)
 	if err != nil {
 		r.Logger.Info("VsphereVM is missing cluster label or cluster does not exist")
-		return session.GetOrCreate(r.Context,
-			params)
+		return params, nil
 	}
 
 	key := ctrlclient.ObjectKey{
@@ -546,23 +930,23 @@ func (r vmReconciler) retrieveVcenterSession(ctx goctx.Context, vsphereVM *infra
 	err = r.Client.Get(r, key, vsphereCluster)
 	if err != nil {
 		r.Logger.Info("VSphereCluster couldn't be retrieved")
-		return session.GetOrCreate(r.Context,
-			params)
+		return params, nil
+	}
+
+	caBundle, err := identity.GetCABundle(ctx, r.Client, vsphereCluster, r.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the CA bundle of the vCenter")
 	}
+	params = params.WithCABundle(caBundle)
 
 	if vsphereCluster.Spec.IdentityRef != nil {
 		creds, err := identity.GetCredentials(ctx, r.Client, vsphereCluster, r.Namespace)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to retrieve credentials from IdentityRef")
 		}
-		params = params.WithUserInfo(creds.Username, creds.Password)
-		return session.GetOrCreate(r.Context,
-			params)
+		params = params.WithUserInfo(creds.Username, creds.Password).WithTokenProvider(creds.TokenProvider)
 	}
-
-	// Fallback to using credentials provided to the manager
-	return session.GetOrCreate(r.Context,
-		params)
+	return params, nil
 }
 
 func (r vmReconciler) fetchClusterModuleInfo(clusterModInput fetchClusterModuleInput) (*string, error) {
@@ -29,12 +29,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apirecord "k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
@@ -44,6 +46,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services"
 	fake_svc "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/fake"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
 )
 
@@ -353,6 +356,55 @@ func TestVmReconciler_WaitingForStaticIPAllocation(t *testing.T) {
 	}
 }
 
+func TestMissingAddressFamily(t *testing.T) {
+	tests := []struct {
+		name      string
+		devices   []infrav1.NetworkDeviceSpec
+		addresses []string
+		family    string
+	}{
+		{
+			name:      "DHCP4 with an IPv4 address",
+			devices:   []infrav1.NetworkDeviceSpec{{NetworkName: "nw-1", DHCP4: true}},
+			addresses: []string{"192.168.1.10"},
+		},
+		{
+			name:      "DHCP4 and static IPv6 without an IPv6 address",
+			devices:   []infrav1.NetworkDeviceSpec{{NetworkName: "nw-1", DHCP4: true, IPAddrs: []string{"fd00::10/64"}}},
+			addresses: []string{"192.168.1.10"},
+			family:    "IPv6",
+		},
+		{
+			name:      "DHCP6 without an IPv4 address",
+			devices:   []infrav1.NetworkDeviceSpec{{NetworkName: "nw-1", DHCP4: true}, {NetworkName: "nw-2", DHCP6: true}},
+			addresses: []string{"fd00::10"},
+			family:    "IPv4",
+		},
+		{
+			name:    "SLAAC without an IPv6 address",
+			devices: []infrav1.NetworkDeviceSpec{{NetworkName: "nw-1", IPv6AddressMode: infrav1.IPv6AddressModeSLAAC}},
+			family:  "IPv6",
+		},
+		{
+			name:      "static dual-stack with both addresses",
+			devices:   []infrav1.NetworkDeviceSpec{{NetworkName: "nw-1", IPAddrs: []string{"192.168.1.10/24", "fd00::10/64"}}},
+			addresses: []string{"192.168.1.10", "fd00::10"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			vm := &infrav1.VSphereVM{
+				Spec:   infrav1.VSphereVMSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Network: infrav1.NetworkSpec{Devices: tt.devices}}},
+				Status: infrav1.VSphereVMStatus{Addresses: tt.addresses},
+			}
+			g.Expect(missingAddressFamily(vm)).To(Equal(tt.family))
+		})
+	}
+}
+
 func TestRetrievingVCenterCredentialsFromCluster(t *testing.T) {
 	// initializing a fake server to replace the vSphere endpoint
 	model := simulator.VPX()
@@ -559,6 +611,89 @@ func Test_reconcile(t *testing.T) {
 			g := NewWithT(t)
 			g.Expect(err).NotTo(HaveOccurred())
 		})
+
+		t.Run("when the quota of the identity is exceeded", func(t *testing.T) {
+			identity := &infrav1.VSphereClusterIdentity{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant"},
+				Spec: infrav1.VSphereClusterIdentitySpec{
+					Quota: &infrav1.IdentityQuota{MaxVMs: pointer.Int32(0)},
+				},
+			}
+			quotaCluster := vsphereCluster.DeepCopy()
+			quotaCluster.Spec.IdentityRef = &infrav1.VSphereIdentityReference{Kind: infrav1.VSphereClusterIdentityKind, Name: identity.Name}
+			// The VM is not created yet.
+			vm := vsphereVM.DeepCopy()
+			vm.Spec.BiosUUID = ""
+			objsWithHierarchy := []client.Object{identity, quotaCluster, machine, vm}
+			objsWithHierarchy = append(objsWithHierarchy, createMachineOwnerHierarchy(machine)...)
+
+			// The VM service has no expectations, so that creating the VM fails the test.
+			r := setupReconciler(new(fake_svc.VMService), objsWithHierarchy...)
+			result, err := r.reconcile(&context.VMContext{
+				ControllerContext: r.ControllerContext,
+				VSphereVM:         vm,
+				Logger:            r.Logger,
+			}, fetchClusterModuleInput{
+				VSphereCluster: quotaCluster,
+				Machine:        machine,
+			})
+
+			g := NewWithT(t)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.RequeueAfter).To(Equal(identityQuotaRequeuePeriod))
+			g.Expect(conditions.GetReason(vm, infrav1.VMProvisionedCondition)).To(Equal(infrav1.QuotaExceededReason))
+		})
+
+		t.Run("when the vSphere operations are paused", func(t *testing.T) {
+			pausedCluster := vsphereCluster.DeepCopy()
+			pausedCluster.Annotations = map[string]string{infrav1.VSphereOperationsPausedAnnotation: ""}
+			vm := vsphereVM.DeepCopy()
+			objsWithHierarchy := []client.Object{pausedCluster, machine, vm}
+			objsWithHierarchy = append(objsWithHierarchy, createMachineOwnerHierarchy(machine)...)
+
+			// The VM is only read, ReconcileVM has no expectation.
+			fakeVMSvc := new(fake_svc.VMService)
+			fakeVMSvc.On("GetVM", mock.Anything).Return(infrav1.VirtualMachine{
+				Name:  vm.Name,
+				State: infrav1.VirtualMachineStateNotFound,
+			}, nil)
+
+			r := setupReconciler(fakeVMSvc, objsWithHierarchy...)
+			_, err := r.reconcile(&context.VMContext{
+				ControllerContext: r.ControllerContext,
+				VSphereVM:         vm,
+				Logger:            r.Logger,
+			}, fetchClusterModuleInput{
+				VSphereCluster: pausedCluster,
+				Machine:        machine,
+			})
+
+			g := NewWithT(t)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(conditions.GetReason(vm, infrav1.VMProvisionedCondition)).To(Equal(infrav1.VSphereOperationsPausedReason))
+			fakeVMSvc.AssertNotCalled(t, "ReconcileVM", mock.Anything)
+
+			// The status of an existing VM is still updated.
+			fakeVMSvc = new(fake_svc.VMService)
+			fakeVMSvc.On("GetVM", mock.Anything).Return(infrav1.VirtualMachine{
+				Name:     vm.Name,
+				BiosUUID: "265104de-1472-547c-b873-6dc7883fb6cb",
+				State:    infrav1.VirtualMachineStateReady,
+				Network:  []infrav1.NetworkStatus{{IPAddrs: []string{"192.168.0.10"}}},
+			}, nil)
+			r = setupReconciler(fakeVMSvc, objsWithHierarchy...)
+			_, err = r.reconcile(&context.VMContext{
+				ControllerContext: r.ControllerContext,
+				VSphereVM:         vm,
+				Logger:            r.Logger,
+			}, fetchClusterModuleInput{
+				VSphereCluster: pausedCluster,
+				Machine:        machine,
+			})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(vm.Spec.BiosUUID).To(Equal("265104de-1472-547c-b873-6dc7883fb6cb"))
+			g.Expect(vm.Status.Addresses).To(Equal([]string{"192.168.0.10"}))
+		})
 	})
 
 	t.Run("during VM deletion", func(t *testing.T) {
@@ -606,6 +741,102 @@ func Test_reconcile(t *testing.T) {
 			// Assertion to verify that cluster module info is not mandatory
 			g.Expect(err).NotTo(HaveOccurred())
 		})
+
+		t.Run("when the vSphere operations are paused", func(t *testing.T) {
+			pausedCluster := vsphereCluster.DeepCopy()
+			pausedCluster.Annotations = map[string]string{infrav1.VSphereOperationsPausedAnnotation: ""}
+			vm := deletedVM.DeepCopy()
+
+			// DestroyVM has no expectation.
+			r := setupReconciler(new(fake_svc.VMService), pausedCluster, machine, vm)
+			_, err := r.reconcile(&context.VMContext{
+				ControllerContext: r.ControllerContext,
+				VSphereVM:         vm,
+				Logger:            r.Logger,
+			}, fetchClusterModuleInput{
+				VSphereCluster: pausedCluster,
+				Machine:        machine,
+			})
+
+			g := NewWithT(t)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(vm.Finalizers).To(ContainElement(infrav1.VMFinalizer))
+			g.Expect(conditions.GetReason(vm, infrav1.VMProvisionedCondition)).To(Equal(infrav1.VSphereOperationsPausedReason))
+		})
+
+		t.Run("when the VM is retained", func(t *testing.T) {
+			retainedVM := deletedVM.DeepCopy()
+			retainedVM.Spec.Server = "vcenter.test"
+			retainedVM.Spec.DeletionPolicy = infrav1.VMDeletionPowerOffOnly
+			retainingVMSvc := new(fake_svc.VMService)
+			retainingVMSvc.On("DestroyVM", mock.Anything).Return(infrav1.VirtualMachine{
+				Name:     retainedVM.Name,
+				BiosUUID: "265104de-1472-547c-b873-6dc7883fb6cb",
+				State:    infrav1.VirtualMachineStateRetained,
+			}, nil)
+
+			r := setupReconciler(retainingVMSvc, vsphereCluster, machine, retainedVM)
+			g := NewWithT(t)
+			cluster := &infrav1.VSphereCluster{}
+			g.Expect(r.Client.Get(goctx.Background(), client.ObjectKeyFromObject(vsphereCluster), cluster)).To(Succeed())
+
+			// The VM is only reported once.
+			for i := 0; i < 2; i++ {
+				_, err := r.reconcile(&context.VMContext{
+					ControllerContext: r.ControllerContext,
+					VSphereVM:         retainedVM,
+					Logger:            r.Logger,
+				}, fetchClusterModuleInput{
+					VSphereCluster: cluster,
+					Machine:        machine,
+				})
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(retainedVM.Finalizers).NotTo(ContainElement(infrav1.VMFinalizer))
+
+			g.Expect(r.Client.Get(goctx.Background(), client.ObjectKeyFromObject(vsphereCluster), cluster)).To(Succeed())
+			g.Expect(cluster.Status.RetainedVMs).To(HaveLen(1))
+			g.Expect(cluster.Status.RetainedVMs[0].Name).To(Equal(retainedVM.Name))
+			g.Expect(cluster.Status.RetainedVMs[0].Server).To(Equal("vcenter.test"))
+			g.Expect(cluster.Status.RetainedVMs[0].BiosUUID).To(Equal("265104de-1472-547c-b873-6dc7883fb6cb"))
+			g.Expect(cluster.Status.RetainedVMs[0].DeletionPolicy).To(Equal(infrav1.VMDeletionPowerOffOnly))
+		})
+
+		t.Run("when disks of the VM are retained", func(t *testing.T) {
+			vm := deletedVM.DeepCopy()
+			vm.Spec.Server = "vcenter.test"
+			vm.Status.RetainedDisks = []string{"[LocalDS_0] vm/vm_1.vmdk"}
+			vmSvc := new(fake_svc.VMService)
+			vmSvc.On("DestroyVM", mock.Anything).Return(infrav1.VirtualMachine{
+				Name:  vm.Name,
+				State: infrav1.VirtualMachineStateNotFound,
+			}, nil)
+
+			r := setupReconciler(vmSvc, vsphereCluster, machine, vm)
+			g := NewWithT(t)
+			cluster := &infrav1.VSphereCluster{}
+			g.Expect(r.Client.Get(goctx.Background(), client.ObjectKeyFromObject(vsphereCluster), cluster)).To(Succeed())
+
+			// The disks are only reported once.
+			for i := 0; i < 2; i++ {
+				_, err := r.reconcile(&context.VMContext{
+					ControllerContext: r.ControllerContext,
+					VSphereVM:         vm,
+					Logger:            r.Logger,
+				}, fetchClusterModuleInput{
+					VSphereCluster: cluster,
+					Machine:        machine,
+				})
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(vm.Finalizers).NotTo(ContainElement(infrav1.VMFinalizer))
+
+			g.Expect(r.Client.Get(goctx.Background(), client.ObjectKeyFromObject(vsphereCluster), cluster)).To(Succeed())
+			g.Expect(cluster.Status.RetainedDisks).To(HaveLen(1))
+			g.Expect(cluster.Status.RetainedDisks[0].Path).To(Equal("[LocalDS_0] vm/vm_1.vmdk"))
+			g.Expect(cluster.Status.RetainedDisks[0].VMName).To(Equal(vm.Name))
+			g.Expect(cluster.Status.RetainedDisks[0].Server).To(Equal("vcenter.test"))
+		})
 	})
 }
 
@@ -651,3 +882,37 @@ func createMachineOwnerHierarchy(machine *clusterv1.Machine) []client.Object {
 	})
 	return objs
 }
+
+func Test_vmEventToVSphereVMs(t *testing.T) {
+	g := NewWithT(t)
+	named := &infrav1.VSphereVM{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+		Spec: infrav1.VSphereVMSpec{
+			VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Server: "vcenter.example.com"},
+			VMName:                  "prefix-foo-1a2b3c",
+		},
+	}
+	other := &infrav1.VSphereVM{
+		ObjectMeta: metav1.ObjectMeta{Name: "prefix-foo-1a2b3c", Namespace: "other"},
+		Spec: infrav1.VSphereVMSpec{
+			VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Server: "vcenter.example.com"},
+			VMName:                  "bar",
+		},
+	}
+	r := vmReconciler{
+		ControllerContext: &context.ControllerContext{
+			ControllerManagerContext: fake.NewControllerManagerContext(named, other),
+			Logger:                   log.Log,
+		},
+	}
+
+	eventChannel := make(chan event.GenericEvent, 2)
+	r.vmEventToVSphereVMs(session.VMEvent{Server: "vcenter.example.com", Name: "prefix-foo-1a2b3c"}, eventChannel)
+	var e event.GenericEvent
+	g.Eventually(eventChannel).Should(Receive(&e))
+	g.Expect(client.ObjectKeyFromObject(e.Object)).To(Equal(client.ObjectKeyFromObject(named)))
+	g.Consistently(eventChannel, 100*time.Millisecond).ShouldNot(Receive())
+
+	r.vmEventToVSphereVMs(session.VMEvent{Server: "vcenter.example.com", Name: "foo"}, eventChannel)
+	g.Consistently(eventChannel, 100*time.Millisecond).ShouldNot(Receive())
+}
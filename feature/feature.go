@@ -41,6 +41,63 @@ const (
 	//
 	// alpha: v1.4
 	NodeLabeling featuregate.Feature = "NodeLabeling"
+
+	// VCenterEvents is a feature gate for reconciling VSphereVMs when vCenter
+	// reports a power state change, a migration or the completion of a task
+	// for their VM, instead of relying on periodic resyncs.
+	//
+	// alpha: v1.5
+	VCenterEvents featuregate.Feature = "VCenterEvents"
+
+	// TenantIsolation is a feature gate for enforcing the VSphereTenantPolicies,
+	// which restrict the datacenters, resource pools and folders the clusters
+	// of a namespace may use.
+	//
+	// alpha: v1.5
+	TenantIsolation featuregate.Feature = "TenantIsolation"
+
+	// GuestAgent is a feature gate for installing the CAPV guest agent in the
+	// VMs bootstrapped with cloud-init, and for reporting the bootstrap
+	// progress and node health it publishes as conditions of the VSphereVMs.
+	//
+	// alpha: v1.5
+	GuestAgent featuregate.Feature = "GuestAgent"
+
+	// InventoryValidation is a feature gate for rejecting the creation of the
+	// VSphereMachineTemplates and VSphereVMs referencing vSphere inventory
+	// which does not exist in their vCenter.
+	//
+	// alpha: v1.5
+	InventoryValidation featuregate.Feature = "InventoryValidation"
+
+	// CSITopology is a feature gate for writing the configuration of the
+	// vSphere CSI driver of the workload clusters spread over failure domains,
+	// so that the CSI driver provisions volumes in the zones of the nodes.
+	//
+	// alpha: v1.5
+	CSITopology featuregate.Feature = "CSITopology"
+
+	// FailureDomainBalancing is a feature gate for spreading the machines of
+	// the MachineDeployments without failure domain across the failure
+	// domains of their cluster.
+	//
+	// alpha: v1.5
+	FailureDomainBalancing featuregate.Feature = "FailureDomainBalancing"
+
+	// CredentialsRotation is a feature gate for writing the rotated
+	// credentials of the identities of the clusters to the secrets of the
+	// vSphere cloud provider and CSI driver of the workload clusters.
+	//
+	// alpha: v1.5
+	CredentialsRotation featuregate.Feature = "CredentialsRotation"
+
+	// ClusterAddons is a feature gate for rendering the vSphere cloud
+	// controller manager and CSI driver of the workload clusters from the
+	// addons of their VSphereCluster, and applying them with a
+	// ClusterResourceSet.
+	//
+	// alpha: v1.5
+	ClusterAddons featuregate.Feature = "ClusterAddons"
 )
 
 func init() {
@@ -51,6 +108,14 @@ func init() {
 // To add a new feature, define a key for it above and add it here.
 var defaultCAPVFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
 	// Every feature should be initiated here:
-	NodeAntiAffinity: {Default: false, PreRelease: featuregate.Alpha},
-	NodeLabeling:     {Default: false, PreRelease: featuregate.Alpha},
+	NodeAntiAffinity:       {Default: false, PreRelease: featuregate.Alpha},
+	NodeLabeling:           {Default: false, PreRelease: featuregate.Alpha},
+	VCenterEvents:          {Default: false, PreRelease: featuregate.Alpha},
+	TenantIsolation:        {Default: false, PreRelease: featuregate.Alpha},
+	GuestAgent:             {Default: false, PreRelease: featuregate.Alpha},
+	InventoryValidation:    {Default: false, PreRelease: featuregate.Alpha},
+	CSITopology:            {Default: false, PreRelease: featuregate.Alpha},
+	FailureDomainBalancing: {Default: false, PreRelease: featuregate.Alpha},
+	CredentialsRotation:    {Default: false, PreRelease: featuregate.Alpha},
+	ClusterAddons:          {Default: false, PreRelease: featuregate.Alpha},
 }
@@ -46,6 +46,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/manager"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/ratelimiter"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/validate"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/version"
 )
 
@@ -58,6 +60,10 @@ var (
 	profilerAddress string
 	tlsMinVersion   string
 	tlsCipherSuites string
+	shardServers    string
+
+	// The options of the controllers overriding the ones of the manager.
+	vsphereVMOpts, vsphereMachineOpts, vsphereClusterOpts, clusterModuleOpts context.ControllerOptions
 
 	defaultProfilerAddr      = os.Getenv("PROFILER_ADDR")
 	defaultSyncPeriod        = manager.DefaultSyncPeriod
@@ -108,6 +114,56 @@ func InitFlags(fs *pflag.FlagSet) {
 		"max-concurrent-reconciles",
 		10,
 		"The maximum number of allowed, concurrent reconciles.")
+	flag.IntVar(
+		&vsphereVMOpts.MaxConcurrentReconciles,
+		"vspherevm-concurrency",
+		0,
+		"The maximum number of concurrent reconciles of the VSphereVMs. Defaults to --max-concurrent-reconciles.")
+	flag.DurationVar(
+		&vsphereVMOpts.SyncPeriod,
+		"vspherevm-sync-period",
+		0,
+		"The interval at which the VSphereVMs are reconciled. Defaults to --sync-period.")
+	flag.IntVar(
+		&vsphereMachineOpts.MaxConcurrentReconciles,
+		"vspheremachine-concurrency",
+		0,
+		"The maximum number of concurrent reconciles of the VSphereMachines. Defaults to --max-concurrent-reconciles.")
+	flag.DurationVar(
+		&vsphereMachineOpts.SyncPeriod,
+		"vspheremachine-sync-period",
+		0,
+		"The interval at which the VSphereMachines are reconciled. Defaults to --sync-period.")
+	flag.IntVar(
+		&vsphereClusterOpts.MaxConcurrentReconciles,
+		"vspherecluster-concurrency",
+		0,
+		"The maximum number of concurrent reconciles of the VSphereClusters. Defaults to --max-concurrent-reconciles.")
+	flag.DurationVar(
+		&vsphereClusterOpts.SyncPeriod,
+		"vspherecluster-sync-period",
+		0,
+		"The interval at which the VSphereClusters are reconciled. Defaults to --sync-period.")
+	flag.IntVar(
+		&clusterModuleOpts.MaxConcurrentReconciles,
+		"clustermodule-concurrency",
+		0,
+		"The maximum number of VSphereClusters whose cluster modules are reconciled concurrently. Defaults to --vspherecluster-concurrency.")
+	flag.DurationVar(
+		&clusterModuleOpts.SyncPeriod,
+		"clustermodule-sync-period",
+		0,
+		"The interval at which the members of the cluster modules are refreshed. Defaults to 5m.")
+	flag.Float64Var(
+		&managerOpts.ClusterRateLimitQPS,
+		"cluster-rate-limit-qps",
+		ratelimiter.DefaultClusterQPS,
+		"The rate at which the failed reconciles of the machines of a single cluster are retried.")
+	flag.IntVar(
+		&managerOpts.ClusterRateLimitBurst,
+		"cluster-rate-limit-burst",
+		ratelimiter.DefaultClusterBurst,
+		"The number of failed reconciles of the machines of a single cluster that may be retried at once.")
 	flag.StringVar(
 		&managerOpts.PodName,
 		"pod-name",
@@ -147,6 +203,84 @@ func InitFlags(fs *pflag.FlagSet) {
 		"",
 		"network provider to be used by Supervisor based clusters.",
 	)
+	flag.StringVar(
+		&managerOpts.GuestAgentURL,
+		"guest-agent-url",
+		"",
+		"URL the guest agent is downloaded from by the VMs when the GuestAgent feature is enabled.",
+	)
+	flag.StringVar(
+		&managerOpts.GuestAgentSHA256,
+		"guest-agent-sha256",
+		"",
+		"SHA-256 checksum of the guest agent binary downloaded from --guest-agent-url.",
+	)
+	flag.StringVar(
+		&managerOpts.FaultInjectionConfig,
+		"fault-injection-config",
+		"",
+		"File describing the latency and faults to inject into the calls to vCenter, for resilience testing only.",
+	)
+	flag.BoolVar(
+		&managerOpts.DryRun,
+		"dry-run",
+		false,
+		"Log the calls changing the vSphere inventory, e.g. the clones, deletions and reconfigurations of the VMs, and report them in the conditions of the objects instead of executing them.",
+	)
+	flag.IntVar(
+		&managerOpts.VCenterFenceThreshold,
+		"vcenter-fence-threshold",
+		0,
+		"Number of consecutive calls a vCenter fails to respond to before the objects on it are skipped, with their VCenterAvailable condition false, until it responds again. Zero disables the fencing.",
+	)
+	flag.DurationVar(
+		&managerOpts.VCenterFenceDuration,
+		"vcenter-fence-duration",
+		time.Minute,
+		"Time the objects on a fenced vCenter are skipped for before it is probed again.",
+	)
+	flag.StringVar(
+		&managerOpts.VCenterHealthCheck,
+		"vcenter-health-check",
+		"",
+		"Check that a session is alive for each of the users of the vCenters, reporting the failed checks in the logs and metrics with report, or also failing the readiness and liveness probes with liveness when no session of any vCenter is alive. Disabled when empty.",
+	)
+	flag.DurationVar(
+		&managerOpts.VCenterHealthCheckInterval,
+		"vcenter-health-check-interval",
+		manager.DefaultVCenterHealthCheckInterval,
+		"Interval the vCenter sessions are checked at with --vcenter-health-check.",
+	)
+	flag.StringVar(
+		&shardServers,
+		"shard-servers",
+		"",
+		"Comma-separated list of the vCenter servers whose objects are reconciled, when several instances of the controller manager split them. All the servers when empty.",
+	)
+	flag.StringVar(
+		&managerOpts.ShardNamespaceSelector,
+		"shard-namespace-selector",
+		"",
+		"Label selector of the namespaces whose objects are reconciled, when several instances of the controller manager split them. All the namespaces when empty.",
+	)
+	flag.BoolVar(
+		&managerOpts.ShardClusterWide,
+		"shard-cluster-wide",
+		false,
+		"Reconcile the objects which are neither on a vCenter server nor in a namespace, e.g. the VSphereClusterIdentities, when several instances of the controller manager split the objects with --shard-servers or --shard-namespace-selector.",
+	)
+	flag.BoolVar(
+		&managerOpts.AuditEvents,
+		"audit-events",
+		false,
+		"Record the calls changing the vSphere inventory as events of the objects they are made for.",
+	)
+	flag.StringVar(
+		&managerOpts.AuditLogPath,
+		"audit-log-path",
+		"",
+		"File the calls changing the vSphere inventory are appended to as JSON lines, or - for the standard output.",
+	)
 	flag.StringVar(
 		&tlsMinVersion,
 		"tls-min-version",
@@ -166,6 +300,12 @@ func InitFlags(fs *pflag.FlagSet) {
 }
 
 func main() {
+	// The validate command validates cluster definitions instead of running
+	// the manager, so it can run in CI pipelines from the manager image.
+	if len(os.Args) > 1 && os.Args[1] == validate.Name {
+		os.Exit(validate.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	rand.Seed(time.Now().UnixNano())
 
 	InitFlags(pflag.CommandLine)
@@ -199,7 +339,19 @@ func main() {
 	}
 	setupLog.V(1).Info(fmt.Sprintf("feature gates: %+v\n", feature.Gates))
 
+	if feature.Gates.Enabled(feature.VCenterEvents) && !pflag.CommandLine.Changed("sync-period") {
+		syncPeriod = manager.DefaultEventDrivenSyncPeriod
+	}
 	managerOpts.SyncPeriod = &syncPeriod
+	if shardServers != "" {
+		managerOpts.ShardServers = strings.Split(shardServers, ",")
+	}
+	managerOpts.Controllers = map[string]context.ControllerOptions{
+		context.VSphereVMControllerName:      vsphereVMOpts,
+		context.VSphereMachineControllerName: vsphereMachineOpts,
+		context.VSphereClusterControllerName: vsphereClusterOpts,
+		context.ClusterModuleControllerName:  clusterModuleOpts,
+	}
 
 	// Create a function that adds all the controllers and webhooks to the manager.
 	addToManager := func(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
@@ -281,6 +433,10 @@ func main() {
 }
 
 func setupVAPIControllers(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	if err := (&v1beta1.VSphereCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+
 	if err := (&v1beta1.VSphereClusterTemplate{}).SetupWebhookWithManager(mgr); err != nil {
 		return err
 	}
@@ -320,6 +476,33 @@ func setupVAPIControllers(ctx *context.ControllerManagerContext, mgr ctrlmgr.Man
 		return err
 	}
 
+	if err := (&v1beta1.TemplateLookupWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1beta1.TenantIsolationWebhook{Enabled: feature.Gates.Enabled(feature.TenantIsolation)}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1beta1.ClusterPlacementWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1beta1.ClusterSettingsWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1beta1.IdentityQuotaWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1beta1.FailureDomainBalancingWebhook{Enabled: feature.Gates.Enabled(feature.FailureDomainBalancing)}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&validate.InventoryWebhook{
+		Enabled:   feature.Gates.Enabled(feature.InventoryValidation),
+		Namespace: ctx.Namespace,
+		Username:  ctx.Username,
+		Password:  ctx.Password,
+	}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+
 	if err := controllers.AddClusterControllerToManager(ctx, mgr, &v1beta1.VSphereCluster{}); err != nil {
 		return err
 	}
@@ -335,12 +518,28 @@ func setupVAPIControllers(ctx *context.ControllerManagerContext, mgr ctrlmgr.Man
 	if err := controllers.AddVSphereDeploymentZoneControllerToManager(ctx, mgr); err != nil {
 		return err
 	}
-
 	if feature.Gates.Enabled(feature.NodeLabeling) {
 		if err := controllers.AddNodeLabelControllerToManager(ctx, mgr); err != nil {
 			return err
 		}
 	}
+	if feature.Gates.Enabled(feature.CSITopology) {
+		if err := controllers.AddCSITopologyControllerToManager(ctx, mgr); err != nil {
+			return err
+		}
+	}
+	if feature.Gates.Enabled(feature.CredentialsRotation) {
+		if err := controllers.AddCredentialsRotationControllerToManager(ctx, mgr); err != nil {
+			return err
+		}
+	}
+	// The addons are applied by ClusterResourceSets, whose CRD is only
+	// installed with the ClusterResourceSet feature of Cluster API.
+	if feature.Gates.Enabled(feature.ClusterAddons) {
+		if err := controllers.AddAddonsControllerToManager(ctx, mgr); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -41,6 +41,7 @@ const (
 	VSphereTemplateVar          = "${VSPHERE_TEMPLATE}"
 	WorkerMachineCountVar       = "${WORKER_MACHINE_COUNT}"
 	ControlPlaneEndpointVar     = "${CONTROL_PLANE_ENDPOINT_IP}"
+	VipCIDRVar                  = "${VIP_CIDR=32}"
 	// Set the default to an empty string to let kube-vip autodetect the interface.
 	VipNetworkInterfaceVar       = "${VIP_NETWORK_INTERFACE=\"\"}"
 	VSphereUsername              = "${VSPHERE_USERNAME}"
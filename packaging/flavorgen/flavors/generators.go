@@ -508,6 +508,11 @@ func kubeVIPPodSpec() *corev1.Pod {
 							Name:  "port",
 							Value: "6443",
 						},
+						{
+							// Prefix length of the VIP, 128 for an IPv6 VIP
+							Name:  "vip_cidr",
+							Value: env.VipCIDRVar,
+						},
 						{
 							// Enables ARP brodcasts from Leader (requires L2 connectivity)
 							Name:  "vip_arp",
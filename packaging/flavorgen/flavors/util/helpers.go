@@ -83,6 +83,7 @@ var (
 		regexVar(env.VSphereStoragePolicyVar),
 		// TODO: Why was thumbprint not here?
 		regexVar(env.VSphereThumbprint),
+		regexVar(env.VipCIDRVar),
 	}
 )
 
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records the calls to vCenter changing its inventory, e.g. the
+// clones, deletions and reconfigurations of the VMs, so that every change of
+// the infrastructure can be traced to the object CAPV made it for.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+)
+
+const (
+	// CallSucceededReason is the reason of the events of the calls to vCenter
+	// which succeeded. The calls starting a task succeed when the task is
+	// started.
+	CallSucceededReason = "VCenterCallSucceeded"
+
+	// CallFailedReason is the reason of the events of the calls to vCenter
+	// which failed.
+	CallFailedReason = "VCenterCallFailed"
+)
+
+// ObjectKey is the key of the value of a context holding the object the calls
+// to vCenter made with the context are made for.
+type ObjectKey struct{}
+
+// WithObject returns a context whose calls to vCenter are made for obj.
+func WithObject(ctx context.Context, obj client.Object) context.Context {
+	return context.WithValue(ctx, ObjectKey{}, obj)
+}
+
+// ObjectFrom returns the object the calls to vCenter made with ctx are made
+// for, if any.
+func ObjectFrom(ctx context.Context) client.Object {
+	obj, _ := ctx.Value(ObjectKey{}).(client.Object)
+	return obj
+}
+
+// Object identifies the object a call to vCenter was made for.
+type Object struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+}
+
+// Record is the record of a call to vCenter changing its inventory.
+type Record struct {
+	Time time.Time `json:"time"`
+
+	// Server is the vCenter the call was made to.
+	Server string `json:"server"`
+
+	// User is the vCenter user who made the call.
+	User string `json:"user"`
+
+	// Call is the method of a SOAP call, e.g. CloneVM_Task, or the HTTP
+	// method and path of a REST call.
+	Call string `json:"call"`
+
+	// Target is the managed object the SOAP call was made on, e.g.
+	// VirtualMachine:vm-42.
+	Target string `json:"target,omitempty"`
+
+	// Task is the task started by the call, if any, e.g. Task:task-1234.
+	Task string `json:"task,omitempty"`
+
+	// Error is the error the call failed with, if it did.
+	Error string `json:"error,omitempty"`
+
+	// Object is the object the call was made for, if known.
+	Object *Object `json:"object,omitempty"`
+}
+
+// String returns a description of the call, as used in the messages of the
+// events.
+func (r Record) String() string {
+	s := r.Call
+	if r.Target != "" {
+		s = fmt.Sprintf("%s on %s", s, r.Target)
+	}
+	s = fmt.Sprintf("%s by %s on %s", s, r.User, r.Server)
+	if r.Task != "" {
+		s = fmt.Sprintf("%s started %s", s, r.Task)
+	}
+	if r.Error != "" {
+		s = fmt.Sprintf("%s failed: %s", s, r.Error)
+	}
+	return s
+}
+
+// Auditor records the calls to vCenter as events of the objects they were
+// made for, and as JSON lines written to a sink.
+type Auditor struct {
+	logger   logr.Logger
+	scheme   *runtime.Scheme
+	recorder record.Recorder
+
+	mu   sync.Mutex
+	sink io.Writer
+}
+
+// New returns an Auditor recording the calls as events with recorder, unless
+// it is nil, and as JSON lines written to sink, unless it is nil. The kinds of
+// the objects are looked up in scheme.
+func New(logger logr.Logger, scheme *runtime.Scheme, recorder record.Recorder, sink io.Writer) *Auditor {
+	return &Auditor{logger: logger, scheme: scheme, recorder: recorder, sink: sink}
+}
+
+// Audit records a call to vCenter made for obj, which is nil for the calls
+// made outside of the reconciliation of an object.
+func (a *Auditor) Audit(obj client.Object, r Record) {
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	if obj != nil {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if a.scheme != nil {
+			if kind, err := apiutil.GVKForObject(obj, a.scheme); err == nil {
+				gvk = kind
+			}
+		}
+		r.Object = &Object{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			UID:        string(obj.GetUID()),
+		}
+	}
+
+	if a.recorder != nil && obj != nil {
+		if r.Error != "" {
+			a.recorder.Warnf(obj, CallFailedReason, "%s", r)
+		} else {
+			a.recorder.Eventf(obj, CallSucceededReason, "%s", r)
+		}
+	}
+
+	if a.sink != nil {
+		data, err := json.Marshal(r)
+		if err != nil {
+			a.logger.Error(err, "unable to marshal audit record", "call", r.Call)
+			return
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if _, err := a.sink.Write(append(data, '\n')); err != nil {
+			a.logger.Error(err, "unable to write audit record", "call", r.Call)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apirecord "k8s.io/client-go/tools/record"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+)
+
+func TestAuditor_Audit(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	vm := &infrav1.VSphereVM{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm", UID: "uid"}}
+
+	events := apirecord.NewFakeRecorder(10)
+	sink := &bytes.Buffer{}
+	a := New(logr.Discard(), scheme, record.New(events), sink)
+
+	a.Audit(ObjectFrom(WithObject(context.Background(), vm)), Record{
+		Server: "vcenter", User: "capv@vsphere.local", Call: "CloneVM_Task", Target: "VirtualMachine:vm-1", Task: "Task:task-1",
+	})
+	a.Audit(nil, Record{Server: "vcenter", User: "capv@vsphere.local", Call: "Destroy_Task", Error: "dry run: Destroy_Task was not executed"})
+
+	g.Expect(events.Events).To(Receive(Equal("Normal VCenterCallSucceeded CloneVM_Task on VirtualMachine:vm-1 by capv@vsphere.local on vcenter started Task:task-1")))
+	g.Expect(events.Events).NotTo(Receive())
+
+	dec := json.NewDecoder(sink)
+	var r Record
+	g.Expect(dec.Decode(&r)).To(Succeed())
+	g.Expect(r.Time.IsZero()).To(BeFalse())
+	g.Expect(r.Task).To(Equal("Task:task-1"))
+	g.Expect(r.Object).To(Equal(&Object{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereVM", Namespace: "default", Name: "vm", UID: "uid"}))
+	r = Record{}
+	g.Expect(dec.Decode(&r)).To(Succeed())
+	g.Expect(r.Call).To(Equal("Destroy_Task"))
+	g.Expect(r.Error).To(ContainSubstring("dry run"))
+	g.Expect(r.Object).To(BeNil())
+}
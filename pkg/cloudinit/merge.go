@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudinit merges the cloud-configs of CAPV into the cloud-init
+// bootstrap data of the machines.
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+)
+
+// multipartHeader is the header of the multipart MIME messages built by
+// MergeCloudConfig.
+const multipartHeader = "Content-Type: multipart/mixed;"
+
+type part struct {
+	contentType string
+	data        []byte
+}
+
+// MergeCloudConfig returns the cloud-init user data merging a cloud-config
+// into the bootstrap data. The result is a multipart MIME message, the type of
+// the bootstrap data part being detected by cloud-init. The bootstrap data
+// already merged with other cloud-configs gets the cloud-config appended to
+// its parts, as cloud-init does not process nested multipart messages.
+func MergeCloudConfig(bootstrapData, cloudConfig []byte) ([]byte, error) {
+	parts := []part{{`text/plain; charset="utf-8"`, bootstrapData}}
+	if bytes.HasPrefix(bootstrapData, []byte(multipartHeader)) {
+		var err error
+		if parts, err = readParts(bootstrapData); err != nil {
+			return nil, err
+		}
+	}
+	parts = append(parts, part{`text/cloud-config; charset="utf-8"`, cloudConfig})
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	// The bootstrap data comes first, so that the cloud-configs are merged
+	// into it.
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("MIME-Version", "1.0")
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(p.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "%s boundary=%q\nMIME-Version: 1.0\n\n", multipartHeader, w.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// readParts returns the parts of a multipart MIME message.
+func readParts(data []byte) ([]part, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	var parts []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part{p.Header.Get("Content-Type"), data})
+	}
+}
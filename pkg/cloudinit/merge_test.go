@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMergeCloudConfig(t *testing.T) {
+	t.Run("wraps the bootstrap data with the cloud-config", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := MergeCloudConfig([]byte("## template: jinja\n#cloud-config\n"), []byte("#cloud-config\nruncmd: []\n"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).To(HavePrefix(multipartHeader))
+
+		parts, err := readParts(data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(parts).To(HaveLen(2))
+		g.Expect(parts[0].contentType).To(HavePrefix("text/plain;"))
+		g.Expect(string(parts[0].data)).To(Equal("## template: jinja\n#cloud-config\n"))
+		g.Expect(parts[1].contentType).To(HavePrefix("text/cloud-config;"))
+		g.Expect(string(parts[1].data)).To(Equal("#cloud-config\nruncmd: []\n"))
+	})
+
+	t.Run("appends the cloud-config to merged bootstrap data", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := MergeCloudConfig([]byte("#cloud-config\n"), []byte("#cloud-config\nbootcmd: []\n"))
+		g.Expect(err).NotTo(HaveOccurred())
+		data, err = MergeCloudConfig(data, []byte("#cloud-config\nruncmd: []\n"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(strings.Count(string(data), multipartHeader)).To(Equal(1))
+
+		parts, err := readParts(data)
+		g.Expect(err).NotTo(HaveOccurred())
+		var bodies []string
+		for _, p := range parts {
+			bodies = append(bodies, string(p.data))
+		}
+		g.Expect(bodies).To(Equal([]string{"#cloud-config\n", "#cloud-config\nbootcmd: []\n", "#cloud-config\nruncmd: []\n"}))
+	})
+}
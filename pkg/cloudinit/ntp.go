@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// ntpCloudConfig enables NTP with the given servers. Unlike the other
+// cloud-configs, its lists are not merged into the ones of the bootstrap data,
+// so that the NTP servers of the bootstrap data take precedence.
+var ntpCloudConfig = template.Must(template.New("ntp").Parse(`#cloud-config
+merge_how:
+- name: dict
+  settings: [no_replace]
+ntp:
+  enabled: true
+  servers:
+  {{- range . }}
+  - {{ printf "%q" . }}
+  {{- end }}
+`))
+
+// NTPCloudConfig returns the cloud-config setting the NTP servers of a guest.
+func NTPCloudConfig(servers []string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ntpCloudConfig.Execute(&buf, servers); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
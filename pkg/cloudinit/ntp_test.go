@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNTPCloudConfig(t *testing.T) {
+	g := NewWithT(t)
+	data, err := NTPCloudConfig([]string{"0.pool.ntp.org", "10.0.0.123"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`#cloud-config
+merge_how:
+- name: dict
+  settings: [no_replace]
+ntp:
+  enabled: true
+  servers:
+  - "0.pool.ntp.org"
+  - "10.0.0.123"
+`))
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// proxyServices are the systemd services configured with the proxy.
+var proxyServices = []string{"containerd", "kubelet"}
+
+// proxyCloudConfig configures the proxy in the environment of the container
+// runtime and the kubelet before the bootstrap commands run. Its lists are
+// prepended to the ones of the bootstrap data instead of replacing them.
+var proxyCloudConfig = template.Must(template.New("proxy").Parse(`#cloud-config
+merge_how:
+- name: list
+  settings: [prepend]
+- name: dict
+  settings: [no_replace, recurse_list]
+write_files:
+{{- range .Services }}
+- path: /etc/systemd/system/{{ . }}.service.d/http-proxy.conf
+  owner: root:root
+  permissions: "0644"
+  content: |
+    [Service]
+    {{- range $.Environment }}
+    Environment="{{ . }}"
+    {{- end }}
+{{- end }}
+runcmd:
+- [systemctl, daemon-reload]
+- [systemctl, restart, containerd]
+`))
+
+// ProxyCloudConfig returns the cloud-config setting the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables, and their lower case
+// variants, of the container runtime and the kubelet of a guest.
+func ProxyCloudConfig(proxy *infrav1.ProxyConfig) ([]byte, error) {
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", proxy.HTTPProxy},
+		{"HTTPS_PROXY", proxy.HTTPSProxy},
+		{"NO_PROXY", strings.Join(proxy.NoProxy, ",")},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := proxyCloudConfig.Execute(&buf, struct {
+		Services    []string
+		Environment []string
+	}{proxyServices, env}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
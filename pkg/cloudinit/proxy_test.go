@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func TestProxyCloudConfig(t *testing.T) {
+	g := NewWithT(t)
+	data, err := ProxyCloudConfig(&infrav1.ProxyConfig{
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    []string{"localhost", "10.0.0.0/8"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`#cloud-config
+merge_how:
+- name: list
+  settings: [prepend]
+- name: dict
+  settings: [no_replace, recurse_list]
+write_files:
+- path: /etc/systemd/system/containerd.service.d/http-proxy.conf
+  owner: root:root
+  permissions: "0644"
+  content: |
+    [Service]
+    Environment="HTTPS_PROXY=http://proxy.example.com:3128"
+    Environment="https_proxy=http://proxy.example.com:3128"
+    Environment="NO_PROXY=localhost,10.0.0.0/8"
+    Environment="no_proxy=localhost,10.0.0.0/8"
+- path: /etc/systemd/system/kubelet.service.d/http-proxy.conf
+  owner: root:root
+  permissions: "0644"
+  content: |
+    [Service]
+    Environment="HTTPS_PROXY=http://proxy.example.com:3128"
+    Environment="https_proxy=http://proxy.example.com:3128"
+    Environment="NO_PROXY=localhost,10.0.0.0/8"
+    Environment="no_proxy=localhost,10.0.0.0/8"
+runcmd:
+- [systemctl, daemon-reload]
+- [systemctl, restart, containerd]
+`))
+}
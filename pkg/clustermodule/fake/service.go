@@ -37,7 +37,17 @@ func (f *CMService) DoesExist(ctx *context.ClusterContext, wrapper clustermodule
 	return args.Bool(0), args.Error(1)
 }
 
+func (f *CMService) Discover(ctx *context.ClusterContext, wrapper clustermodule.Wrapper) (string, error) {
+	args := f.Called(ctx, wrapper)
+	return args.String(0), args.Error(1)
+}
+
 func (f *CMService) Remove(ctx *context.ClusterContext, moduleUUID string) error {
 	args := f.Called(ctx, moduleUUID)
 	return args.Error(0)
 }
+
+func (f *CMService) ListMembers(ctx *context.ClusterContext, moduleUUID string) ([]string, error) {
+	args := f.Called(ctx, moduleUUID)
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -23,5 +23,9 @@ type Service interface {
 
 	DoesExist(ctx *context.ClusterContext, wrapper Wrapper, moduleUUID string) (bool, error)
 
+	Discover(ctx *context.ClusterContext, wrapper Wrapper) (string, error)
+
 	Remove(ctx *context.ClusterContext, moduleUUID string) error
+
+	ListMembers(ctx *context.ClusterContext, moduleUUID string) ([]string, error)
 }
@@ -18,14 +18,18 @@ package clustermodule
 
 import (
 	goctx "context"
+	"sort"
 
 	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
 
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/clustermodules"
+	capvfind "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
 
 const validMachineTemplate = "VSphereMachineTemplate"
@@ -59,6 +63,12 @@ func (s service) Create(ctx *context.ClusterContext, wrapper Wrapper) (string, e
 		logger.V(4).Info("skipping module creation for object since template uses a different server", "server", server)
 		return "", nil
 	}
+	// The VMs of a template selecting their compute cluster may be spread
+	// over several compute clusters, which a module cannot span.
+	if template.Spec.Template.Spec.ComputeSelector != nil && template.Spec.Template.Spec.ResourcePool == "" {
+		logger.V(4).Info("skipping module creation for object since template selects its compute cluster")
+		return "", nil
+	}
 
 	vCenterSession, err := fetchSessionForObject(ctx, template)
 	if err != nil {
@@ -117,6 +127,98 @@ func (s service) DoesExist(ctx *context.ClusterContext, wrapper Wrapper, moduleU
 	return provider.DoesModuleExist(ctx, moduleUUID, computeClusterRef)
 }
 
+// Discover returns the UUID of the cluster module of the compute cluster of
+// the object whose members include the most VMs of the machines of the
+// object, or "" if none of them is a member of a module. It finds the modules
+// missing from the VSphereCluster, e.g. after the management cluster was
+// restored from a backup taken before they were created.
+func (s service) Discover(ctx *context.ClusterContext, wrapper Wrapper) (string, error) {
+	logger := ctx.Logger.WithValues("object", wrapper.GetName(), "namespace", wrapper.GetNamespace())
+
+	templateRef, err := fetchTemplateRef(ctx, ctx.Client, wrapper)
+	if err != nil {
+		logger.V(4).Error(err, "error fetching template for object")
+		return "", errors.Wrapf(err, "error fetching machine template for object %s/%s", wrapper.GetNamespace(), wrapper.GetName())
+	}
+	if templateRef.Kind != validMachineTemplate {
+		return "", nil
+	}
+	template, err := fetchMachineTemplate(ctx, wrapper, templateRef.Name)
+	if err != nil {
+		logger.V(4).Error(err, "error fetching template")
+		return "", err
+	}
+	if template.Spec.Template.Spec.Server != ctx.VSphereCluster.Spec.Server ||
+		(template.Spec.Template.Spec.ComputeSelector != nil && template.Spec.Template.Spec.ResourcePool == "") {
+		return "", nil
+	}
+
+	machines, err := fetchMachines(ctx, wrapper)
+	if err != nil {
+		return "", errors.Wrapf(err, "error fetching machines of object %s/%s", wrapper.GetNamespace(), wrapper.GetName())
+	}
+	if len(machines) == 0 {
+		return "", nil
+	}
+
+	vCenterSession, err := fetchSessionForObject(ctx, template)
+	if err != nil {
+		logger.V(4).Error(err, "error fetching session")
+		return "", err
+	}
+	vms := map[types.ManagedObjectReference]bool{}
+	for _, machine := range machines {
+		biosUUID := util.ConvertProviderIDToUUID(machine.Spec.ProviderID)
+		if biosUUID == "" {
+			continue
+		}
+		ref, err := vCenterSession.FindByBIOSUUID(ctx, biosUUID)
+		if err != nil {
+			return "", err
+		}
+		if ref != nil {
+			vms[ref.Reference()] = true
+		}
+	}
+	if len(vms) == 0 {
+		return "", nil
+	}
+
+	computeClusterRef, err := getComputeClusterResource(ctx, vCenterSession, template.Spec.Template.Spec.ResourcePool)
+	if err != nil {
+		logger.V(4).Error(err, "error fetching compute cluster resource")
+		return "", err
+	}
+	provider := clustermodules.NewProvider(vCenterSession.TagManager.Client)
+	moduleUUIDs, err := provider.ListModules(ctx, computeClusterRef)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the cluster modules of %s", computeClusterRef)
+	}
+	sort.Strings(moduleUUIDs)
+
+	var discovered string
+	var discoveredMembers int
+	for _, moduleUUID := range moduleUUIDs {
+		members, err := provider.ListModuleMembers(ctx, moduleUUID)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list members of cluster module %s", moduleUUID)
+		}
+		count := 0
+		for _, member := range members {
+			if vms[member.Reference()] {
+				count++
+			}
+		}
+		if count > discoveredMembers {
+			discovered, discoveredMembers = moduleUUID, count
+		}
+	}
+	if discovered != "" {
+		logger.V(4).Info("discovered cluster module for object", "moduleUUID", discovered, "members", discoveredMembers)
+	}
+	return discovered, nil
+}
+
 func (s service) Remove(ctx *context.ClusterContext, moduleUUID string) error {
 	params := newParams(*ctx)
 	vcenterSession, err := fetchSession(ctx, params)
@@ -131,13 +233,36 @@ func (s service) Remove(ctx *context.ClusterContext, moduleUUID string) error {
 	return nil
 }
 
+// ListMembers returns the managed object references of the VMs that are
+// members of the cluster module.
+func (s service) ListMembers(ctx *context.ClusterContext, moduleUUID string) ([]string, error) {
+	params := newParams(*ctx)
+	vcenterSession, err := fetchSession(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := clustermodules.NewProvider(vcenterSession.TagManager.Client)
+	members, err := provider.ListModuleMembers(ctx, moduleUUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list members of cluster module %s", moduleUUID)
+	}
+	refs := make([]string, 0, len(members))
+	for _, member := range members {
+		refs = append(refs, member.Value)
+	}
+	return refs, nil
+}
+
 func getComputeClusterResource(ctx goctx.Context, s *session.Session, resourcePool string) (types.ManagedObjectReference, error) {
-	rp, err := s.Finder.ResourcePoolOrDefault(ctx, resourcePool)
+	rp, err := capvfind.ByMoRef(s.Client.Client, "ResourcePool", func(ctx goctx.Context, path string) (object.Reference, error) {
+		return s.Finder.ResourcePoolOrDefault(ctx, path)
+	})(ctx, resourcePool)
 	if err != nil {
 		return types.ManagedObjectReference{}, err
 	}
 
-	cc, err := rp.Owner(ctx)
+	cc, err := rp.(*object.ResourcePool).Owner(ctx)
 	if err != nil {
 		return types.ManagedObjectReference{}, err
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -49,13 +50,19 @@ func newParams(ctx context.ClusterContext) *session.Params {
 }
 
 func fetchSession(ctx *context.ClusterContext, params *session.Params) (*session.Session, error) {
+	caBundle, err := identity.GetCABundle(ctx, ctx.Client, ctx.VSphereCluster, ctx.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the CA bundle of the vCenter")
+	}
+	params = params.WithCABundle(caBundle)
+
 	if ctx.VSphereCluster.Spec.IdentityRef != nil {
 		creds, err := identity.GetCredentials(ctx, ctx.Client, ctx.VSphereCluster, ctx.Namespace)
 		if err != nil {
 			return nil, err
 		}
 
-		params = params.WithUserInfo(creds.Username, creds.Password)
+		params = params.WithUserInfo(creds.Username, creds.Password).WithTokenProvider(creds.TokenProvider)
 		return session.GetOrCreate(ctx, params)
 	}
 
@@ -90,3 +97,22 @@ func fetchMachineTemplate(ctx *context.ClusterContext, input Wrapper, templateNa
 	}
 	return template, nil
 }
+
+// fetchMachines returns the machines of the object: the control plane
+// machines of a KubeadmControlPlane, or the machines of a MachineDeployment.
+func fetchMachines(ctx *context.ClusterContext, input Wrapper) ([]clusterv1.Machine, error) {
+	opts := []client.ListOption{
+		client.InNamespace(input.GetNamespace()),
+		client.MatchingLabels{clusterv1.ClusterLabelName: ctx.Cluster.Name},
+	}
+	if input.IsControlPlane() {
+		opts = append(opts, client.HasLabels{clusterv1.MachineControlPlaneLabelName})
+	} else {
+		opts = append(opts, client.MatchingLabels{clusterv1.MachineDeploymentLabelName: input.GetName()})
+	}
+	machines := &clusterv1.MachineList{}
+	if err := ctx.Client.List(ctx, machines, opts...); err != nil {
+		return nil, err
+	}
+	return machines.Items, nil
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance validates that an environment can host the clusters of
+// CAPV before any of them is created: the privileges of the vCenter user, the
+// templates and inventory of the machines, their networks, the control plane
+// endpoint and the IPAM pools the machines claim their addresses from. It
+// runs the checks CAPV runs before cloning the VMs of a cluster, and is meant
+// to be imported by the tools validating custom environments.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/privileges"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/preflight"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// Check is a group of checks run against an environment.
+type Check string
+
+const (
+	// PrivilegesCheck checks that the vCenter user has the privileges
+	// required by the features of CAPV the clusters use.
+	PrivilegesCheck Check = "Privileges"
+
+	// TemplatesCheck checks that the templates of the machines exist and are
+	// compatible with them, and that they can be cloned to the folders,
+	// resource pools and datastores of the machines.
+	TemplatesCheck Check = "Templates"
+
+	// NetworksCheck checks that the networks of the machines exist, and that
+	// their distributed port groups let the machines reach each other.
+	NetworksCheck Check = "Networks"
+
+	// ControlPlaneEndpointCheck checks that the control plane endpoint
+	// resolves and is not used yet.
+	ControlPlaneEndpointCheck Check = "ControlPlaneEndpoint"
+
+	// AddressPoolsCheck checks that the IPAM pools of the machines exist.
+	AddressPoolsCheck Check = "AddressPools"
+)
+
+// Checks are all the checks, in the order they are run.
+var Checks = []Check{PrivilegesCheck, TemplatesCheck, NetworksCheck, ControlPlaneEndpointCheck, AddressPoolsCheck}
+
+// Machine is a kind of machine of the clusters of an environment, e.g. the
+// machines of a VSphereMachineTemplate.
+type Machine struct {
+	// Name identifies the machine in the messages of the failures.
+	Name string
+
+	// Spec is the clone spec of the machine.
+	Spec *infrav1.VirtualMachineCloneSpec
+
+	// ControlPlane is true for the control plane machines.
+	ControlPlane bool
+}
+
+// Environment is the infrastructure the clusters are created in.
+type Environment struct {
+	// Session is a session to the vCenter of the clusters, e.g. opened with
+	// session.GetOrCreate.
+	Session *session.Session
+
+	// Features are the optional features of CAPV the clusters use, whose
+	// privileges are checked along with the ones of the base feature.
+	Features []privileges.Feature
+
+	// Machines are the kinds of machines of the clusters.
+	Machines []Machine
+
+	// PortGroupValidation is the VLAN ID and the security policy expected
+	// from the distributed port groups of the machines, if any.
+	PortGroupValidation *infrav1.PortGroupValidation
+
+	// ControlPlaneEndpoint is the control plane endpoint of a cluster. The
+	// ControlPlaneEndpointCheck is skipped when it is not set.
+	ControlPlaneEndpoint infrav1.APIEndpoint
+
+	// Resolver resolves the host of the control plane endpoint.
+	// Defaults to net.DefaultResolver.
+	Resolver preflight.Resolver
+
+	// Client is a client to the management cluster the IPAM pools are read
+	// from. The AddressPoolsCheck is skipped when it is not set.
+	Client client.Client
+
+	// Namespace is the namespace of the clusters on the management cluster.
+	Namespace string
+}
+
+// Failure is a failed check.
+type Failure struct {
+	// Reason is the reason of the condition CAPV sets when the check fails,
+	// e.g. TemplateNotFound.
+	Reason string `json:"reason"`
+
+	// Severity is Error for the failures preventing the clusters from being
+	// created, and Warning for the failures which may not.
+	Severity clusterv1.ConditionSeverity `json:"severity"`
+
+	Message string `json:"message"`
+}
+
+// Result is the result of a check.
+type Result struct {
+	Check Check `json:"check"`
+
+	// Failures are the failures of the check.
+	Failures []Failure `json:"failures,omitempty"`
+
+	// Skipped is the reason why the check was not run, if it was not.
+	Skipped string `json:"skipped,omitempty"`
+
+	// Error is set when the check could not be run, e.g. because the
+	// vCenter could not be queried.
+	Error string `json:"error,omitempty"`
+}
+
+// Passed returns true if the check ran and none of its failures has the Error
+// severity.
+func (r Result) Passed() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, f := range r.Failures {
+		if f.Severity == clusterv1.ConditionSeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Report is the result of the checks of an environment.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Passed returns true if all the checks passed.
+func (r Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Run runs all the checks against an environment. The checks are all run even
+// if some of them fail, so that the report lists all the problems of the
+// environment.
+func Run(ctx context.Context, env *Environment) Report {
+	checks := map[Check]func(context.Context, *Environment) ([]Failure, string, error){
+		PrivilegesCheck:           checkPrivileges,
+		TemplatesCheck:            checkTemplates,
+		NetworksCheck:             checkNetworks,
+		ControlPlaneEndpointCheck: checkControlPlaneEndpoint,
+		AddressPoolsCheck:         checkAddressPools,
+	}
+
+	var report Report
+	for _, check := range Checks {
+		result := Result{Check: check}
+		failures, skipped, err := checks[check](ctx, env)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case skipped != "":
+			result.Skipped = skipped
+		default:
+			result.Failures = failures
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// checkPrivileges checks the privileges of the vCenter user on the
+// datacenters of the machines, or on the root folder if none of them sets
+// one.
+func checkPrivileges(ctx context.Context, env *Environment) ([]Failure, string, error) {
+	finder := find.NewFinder(env.Session.Client.Client, false)
+	datacenters := sets.NewString()
+	var entities []types.ManagedObjectReference
+	for _, m := range env.Machines {
+		if m.Spec.Datacenter == "" || datacenters.Has(m.Spec.Datacenter) {
+			continue
+		}
+		datacenters.Insert(m.Spec.Datacenter)
+		dc, err := finder.Datacenter(ctx, m.Spec.Datacenter)
+		if err != nil {
+			// The missing datacenters are reported by the TemplatesCheck.
+			continue
+		}
+		entities = append(entities, dc.Reference())
+	}
+
+	missing, err := privileges.Missing(ctx, env.Session, privileges.Required(env.Features...), entities...)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(missing) > 0 {
+		return []Failure{{
+			Reason:   infrav1.PrivilegesMissingReason,
+			Severity: clusterv1.ConditionSeverityError,
+			Message:  fmt.Sprintf("the vCenter user lacks the privileges %s", strings.Join(missing, ", ")),
+		}}, "", nil
+	}
+	return nil, "", nil
+}
+
+// checkTemplates checks the templates of the machines, then whether they can
+// be cloned to the inventory of the machines.
+func checkTemplates(ctx context.Context, env *Environment) ([]Failure, string, error) {
+	var failures []Failure
+	for _, m := range env.Machines {
+		fldPath := machinePath(m)
+		f, err := preflight.CheckTemplate(ctx, env.Session, m.Spec, fldPath)
+		if err != nil {
+			return nil, "", err
+		}
+		failures = appendFailures(failures, f)
+		if hasReason(f, infrav1.TemplateNotFoundReason, infrav1.InventoryNotFoundReason) {
+			// The template, or its datacenter, does not exist.
+			continue
+		}
+
+		f, err = preflight.CheckCloneSpec(ctx, env.Session, m.Spec, fldPath)
+		if err != nil {
+			return nil, "", err
+		}
+		failures = appendFailures(failures, f)
+	}
+	return failures, "", nil
+}
+
+// checkNetworks checks that the networks of the machines exist, then their
+// distributed port groups.
+func checkNetworks(ctx context.Context, env *Environment) ([]Failure, string, error) {
+	var failures []Failure
+	for _, m := range env.Machines {
+		fldPath := machinePath(m)
+		finder := find.NewFinder(env.Session.Client.Client, false)
+		dc, err := finder.DatacenterOrDefault(ctx, m.Spec.Datacenter)
+		if err != nil {
+			// The missing datacenters are reported by the TemplatesCheck.
+			continue
+		}
+		finder.SetDatacenter(dc)
+		for i, device := range m.Spec.Network.Devices {
+			if device.NetworkName == "" {
+				continue
+			}
+			if _, err := finder.Network(ctx, device.NetworkName); err != nil {
+				failures = append(failures, Failure{
+					Reason:   infrav1.InventoryNotFoundReason,
+					Severity: clusterv1.ConditionSeverityError,
+					Message:  fmt.Sprintf("%s.network.devices[%d].networkName: %s", fldPath, i, err),
+				})
+			}
+		}
+
+		f, err := preflight.CheckPortGroups(ctx, env.Session, m.Spec, env.PortGroupValidation, m.ControlPlane, fldPath)
+		if err != nil {
+			return nil, "", err
+		}
+		failures = appendFailures(failures, f)
+	}
+	return failures, "", nil
+}
+
+func checkControlPlaneEndpoint(ctx context.Context, env *Environment) ([]Failure, string, error) {
+	if env.ControlPlaneEndpoint.IsZero() {
+		return nil, "the control plane endpoint is not set", nil
+	}
+	var resolver preflight.Resolver = net.DefaultResolver
+	if env.Resolver != nil {
+		resolver = env.Resolver
+	}
+	return appendFailures(nil, preflight.CheckControlPlaneEndpoint(ctx, resolver, env.ControlPlaneEndpoint)), "", nil
+}
+
+func checkAddressPools(ctx context.Context, env *Environment) ([]Failure, string, error) {
+	if env.Client == nil {
+		return nil, "no client to the management cluster", nil
+	}
+	var failures []Failure
+	for _, m := range env.Machines {
+		f, err := preflight.CheckAddressPools(ctx, env.Client, env.Namespace, m.Spec, machinePath(m))
+		if err != nil {
+			return nil, "", err
+		}
+		failures = appendFailures(failures, f)
+	}
+	return failures, "", nil
+}
+
+// machinePath returns the path the messages of the failures of a machine
+// start with.
+func machinePath(m Machine) string {
+	return fmt.Sprintf("%s: spec", m.Name)
+}
+
+func appendFailures(failures []Failure, f []preflight.Failure) []Failure {
+	for _, failure := range f {
+		failures = append(failures, Failure(failure))
+	}
+	return failures
+}
+
+func hasReason(failures []preflight.Failure, reasons ...string) bool {
+	for _, f := range failures {
+		for _, reason := range reasons {
+			if f.Reason == reason {
+				return true
+			}
+		}
+	}
+	return false
+}
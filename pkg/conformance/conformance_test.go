@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/privileges"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	s, err := session.GetOrCreate(context.Background(), session.NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// VMware Tools are not installed on the templates of the simulator, which
+	// is a warning of the TemplatesCheck.
+	finder := find.NewFinder(s.Client.Client)
+	dc, err := finder.Datacenter(context.Background(), "DC0")
+	g.Expect(err).NotTo(HaveOccurred())
+	finder.SetDatacenter(dc)
+	tpl, err := finder.VirtualMachine(context.Background(), "DC0_H0_VM0")
+	g.Expect(err).NotTo(HaveOccurred())
+	simulator.Map.Get(tpl.Reference()).(*simulator.VirtualMachine).Config.Tools.ToolsVersion = 12352 //nolint:forcetypeassert
+
+	spec := func() *infrav1.VirtualMachineCloneSpec {
+		return &infrav1.VirtualMachineCloneSpec{
+			Datacenter:   "DC0",
+			Template:     "DC0_H0_VM0",
+			Folder:       "/DC0/vm",
+			Datastore:    "LocalDS_0",
+			ResourcePool: "/DC0/host/DC0_C0/Resources",
+			Network: infrav1.NetworkSpec{
+				Devices: []infrav1.NetworkDeviceSpec{{NetworkName: "VM Network"}},
+			},
+		}
+	}
+
+	t.Run("a conformant environment passes", func(t *testing.T) {
+		g := NewWithT(t)
+		report := Run(context.Background(), &Environment{
+			Session:  s,
+			Features: privileges.Features,
+			Machines: []Machine{{Name: "control-plane", Spec: spec(), ControlPlane: true}, {Name: "md-0", Spec: spec()}},
+		})
+		g.Expect(report.Passed()).To(BeTrue())
+		g.Expect(report.Results).To(HaveLen(len(Checks)))
+		for _, result := range report.Results {
+			g.Expect(result.Failures).To(BeEmpty(), "check %s", result.Check)
+		}
+		// The endpoint and the client to the management cluster are not set.
+		g.Expect(report.Results[3].Skipped).NotTo(BeEmpty())
+		g.Expect(report.Results[4].Skipped).NotTo(BeEmpty())
+	})
+
+	t.Run("a missing template and network fail", func(t *testing.T) {
+		g := NewWithT(t)
+		missing := spec()
+		missing.Template = "missing-template"
+		missing.Network.Devices[0].NetworkName = "missing-network"
+		report := Run(context.Background(), &Environment{
+			Session:  s,
+			Machines: []Machine{{Name: "md-0", Spec: missing}},
+		})
+		g.Expect(report.Passed()).To(BeFalse())
+
+		templates := report.Results[1]
+		g.Expect(templates.Check).To(Equal(TemplatesCheck))
+		g.Expect(templates.Failures).To(HaveLen(1))
+		g.Expect(templates.Failures[0].Reason).To(Equal(infrav1.TemplateNotFoundReason))
+		g.Expect(templates.Failures[0].Severity).To(Equal(clusterv1.ConditionSeverityError))
+		g.Expect(templates.Failures[0].Message).To(HavePrefix("md-0: spec.template: "))
+
+		networks := report.Results[2]
+		g.Expect(networks.Check).To(Equal(NetworksCheck))
+		g.Expect(networks.Failures).To(HaveLen(1))
+		g.Expect(networks.Failures[0].Reason).To(Equal(infrav1.InventoryNotFoundReason))
+		g.Expect(networks.Failures[0].Message).To(HavePrefix("md-0: spec.network.devices[0].networkName: "))
+	})
+}
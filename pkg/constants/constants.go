@@ -60,4 +60,30 @@ const (
 	NodeLabelPrefix = "node.cluster.x-k8s.io"
 
 	ESXiHostInfoLabel = NodeLabelPrefix + "/esxi-host"
+
+	// DatacenterInfoLabel, ComputeClusterInfoLabel, ResourcePoolInfoLabel,
+	// DatastoreInfoLabel and FailureDomainInfoLabel describe the vSphere
+	// topology of the VM of a node.
+	DatacenterInfoLabel     = NodeLabelPrefix + "/datacenter"
+	ComputeClusterInfoLabel = NodeLabelPrefix + "/compute-cluster"
+	ResourcePoolInfoLabel   = NodeLabelPrefix + "/resource-pool"
+	DatastoreInfoLabel      = NodeLabelPrefix + "/datastore"
+	FailureDomainInfoLabel  = NodeLabelPrefix + "/failure-domain"
+
+	// VGPUProfileInfoLabel and DirectPathIODevicesInfoLabel describe the vGPU
+	// profiles and the number of DirectPath I/O devices of the VM of a node,
+	// in supervisor clusters.
+	VGPUProfileInfoLabel         = NodeLabelPrefix + "/vgpu-profile"
+	DirectPathIODevicesInfoLabel = NodeLabelPrefix + "/directpath-io-devices"
 )
+
+// TopologyInfoLabels are the labels of the vSphere topology of the VM of a
+// node. They are removed from the nodes once their Machine does not have them
+// anymore.
+var TopologyInfoLabels = []string{
+	DatacenterInfoLabel,
+	ComputeClusterInfoLabel,
+	ResourcePoolInfoLabel,
+	DatastoreInfoLabel,
+	FailureDomainInfoLabel,
+}
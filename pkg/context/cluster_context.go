@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
 )
 
 // ClusterContext is a Go context used with a VSphereCluster.
@@ -47,8 +48,18 @@ func (c *ClusterContext) Patch() error {
 	conditions.SetSummary(c.VSphereCluster,
 		conditions.WithConditions(
 			infrav1.VCenterAvailableCondition,
+			infrav1.PreflightChecksSucceededCondition,
 		),
 	)
 
 	return c.PatchHelper.Patch(c, c.VSphereCluster)
 }
+
+// Value returns the VSphereCluster for the audit.ObjectKey, so that the calls
+// to vCenter made with the context are audited for it.
+func (c *ClusterContext) Value(key interface{}) interface{} {
+	if _, ok := key.(audit.ObjectKey); ok && c.VSphereCluster != nil {
+		return c.VSphereCluster
+	}
+	return c.ControllerContext.Value(key)
+}
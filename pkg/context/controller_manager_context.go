@@ -28,6 +28,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/shard"
 )
 
 // ControllerManagerContext is the context of the controller that owns the
@@ -70,6 +71,32 @@ type ControllerManagerContext struct {
 	// controller will receive concurrently.
 	MaxConcurrentReconciles int
 
+	// ClusterRateLimitQPS is the rate at which the reconcile requests of the
+	// objects of a single cluster may be requeued.
+	ClusterRateLimitQPS float64
+
+	// ClusterRateLimitBurst is the number of reconcile requests of the objects
+	// of a single cluster that may be requeued at once.
+	ClusterRateLimitBurst int
+
+	// SyncPeriod is the period at which the objects of the controllers are
+	// reconciled, unless overridden by their ControllerOptions.
+	SyncPeriod time.Duration
+
+	// ResyncPeriod is the period at which the informers of the manager resync
+	// the objects they watch, which is the SyncPeriod of the manager whatever
+	// the sync periods of the controllers.
+	ResyncPeriod time.Duration
+
+	// ControllerOptions are the options of the controllers overriding the
+	// ones of the controller manager, by name of controller.
+	ControllerOptions map[string]ControllerOptions
+
+	// Shard is the share of the objects reconciled by the controller
+	// manager, when several instances of it split them. Nil when it
+	// reconciles all the objects.
+	Shard *shard.Shard
+
 	// Username is the username for the account used to access remote vSphere
 	// endpoints.
 	Username string
@@ -89,9 +116,55 @@ type ControllerManagerContext struct {
 	// NetworkProvider is the network provider used by Supervisor based clusters
 	NetworkProvider string
 
+	// GuestAgentURL is the URL the guest agent is downloaded from by the
+	// VMs when the GuestAgent feature is enabled.
+	GuestAgentURL string
+
+	// GuestAgentSHA256 is the SHA-256 checksum of the guest agent binary.
+	GuestAgentSHA256 string
+
 	genericEventCache sync.Map
 }
 
+// Names of the controllers whose options can be overridden by the
+// ControllerOptions of the controller manager.
+const (
+	VSphereVMControllerName      = "vspherevm"
+	VSphereMachineControllerName = "vspheremachine"
+	VSphereClusterControllerName = "vspherecluster"
+	ClusterModuleControllerName  = "clustermodule"
+)
+
+// ControllerOptions are the options of a controller overriding the ones of the
+// controller manager.
+type ControllerOptions struct {
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles
+	// of the controller.
+	MaxConcurrentReconciles int
+
+	// SyncPeriod is the period at which the objects of the controller are
+	// reconciled.
+	SyncPeriod time.Duration
+}
+
+// MaxConcurrentReconcilesFor returns the maximum number of concurrent
+// reconciles of a controller.
+func (c *ControllerManagerContext) MaxConcurrentReconcilesFor(controller string) int {
+	if n := c.ControllerOptions[controller].MaxConcurrentReconciles; n > 0 {
+		return n
+	}
+	return c.MaxConcurrentReconciles
+}
+
+// SyncPeriodFor returns the period at which the objects of a controller are
+// reconciled.
+func (c *ControllerManagerContext) SyncPeriodFor(controller string) time.Duration {
+	if period := c.ControllerOptions[controller].SyncPeriod; period > 0 {
+		return period
+	}
+	return c.SyncPeriod
+}
+
 // String returns ControllerManagerName.
 func (c *ControllerManagerContext) String() string {
 	return c.Name
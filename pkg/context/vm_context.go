@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 )
 
@@ -35,6 +36,14 @@ type VMContext struct {
 	Logger               logr.Logger
 	Session              *session.Session
 	VSphereFailureDomain *infrav1.VSphereFailureDomain
+
+	// TemplateSession is the session to the vCenter the template is cloned
+	// from, if the VSphereVM has a TemplateSource.
+	TemplateSession *session.Session
+
+	// Bootstrapped is true once the node of the machine of the VSphereVM
+	// joined the cluster.
+	Bootstrapped bool
 }
 
 // String returns VSphereVMGroupVersionKind VSphereVMNamespace/VSphereVMName.
@@ -56,3 +65,12 @@ func (c *VMContext) GetLogger() logr.Logger {
 func (c *VMContext) GetSession() *session.Session {
 	return c.Session
 }
+
+// Value returns the VSphereVM for the audit.ObjectKey, so that the calls to
+// vCenter made with the context are audited for it.
+func (c *VMContext) Value(key interface{}) interface{} {
+	if _, ok := key.(audit.ObjectKey); ok && c.VSphereVM != nil {
+		return c.VSphereVM
+	}
+	return c.ControllerContext.Value(key)
+}
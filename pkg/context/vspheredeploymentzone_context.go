@@ -24,6 +24,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 )
 
@@ -55,6 +56,21 @@ func (c *VSphereDeploymentZoneContext) GetSession() *session.Session {
 	return c.AuthSession
 }
 
+// Value returns the VSphereDeploymentZone for the audit.ObjectKey, so that the
+// calls to vCenter made with the context are audited for it.
+func (c *VSphereDeploymentZoneContext) Value(key interface{}) interface{} {
+	if _, ok := key.(audit.ObjectKey); ok && c.VSphereDeploymentZone != nil {
+		return c.VSphereDeploymentZone
+	}
+	return c.ControllerContext.Value(key)
+}
+
+// GetVsphereFailureDomain returns the failure domain of the deployment zone,
+// with the current inventory path of its compute cluster.
 func (c *VSphereDeploymentZoneContext) GetVsphereFailureDomain() infrav1.VSphereFailureDomain {
-	return *c.VSphereFailureDomain
+	failureDomain := *c.VSphereFailureDomain
+	if c.VSphereDeploymentZone != nil {
+		failureDomain.Spec.Topology = c.VSphereDeploymentZone.Topology(c.VSphereFailureDomain)
+	}
+	return failureDomain
 }
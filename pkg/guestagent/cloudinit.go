@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestagent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"text/template"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/cloudinit"
+)
+
+const (
+	// BinaryPath is the path the agent is installed at in the guest.
+	BinaryPath = "/usr/local/bin/capv-guest-agent"
+
+	// ServiceName is the name of the systemd service running the agent.
+	ServiceName = "capv-guest-agent.service"
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// installCloudConfig installs the agent before the bootstrap commands run, so
+// that it reports their progress. Its lists are prepended to the ones of the
+// bootstrap data instead of replacing them.
+var installCloudConfig = template.Must(template.New("install").Parse(`#cloud-config
+merge_how:
+- name: list
+  settings: [prepend]
+- name: dict
+  settings: [no_replace, recurse_list]
+write_files:
+- path: /etc/systemd/system/{{ .ServiceName }}
+  owner: root:root
+  permissions: "0644"
+  content: |
+    [Unit]
+    Description=CAPV guest agent
+    After=vmtoolsd.service
+
+    [Service]
+    ExecStart={{ .BinaryPath }}
+    Restart=always
+    RestartSec=10
+
+    [Install]
+    WantedBy=multi-user.target
+runcmd:
+- [curl, --fail, --silent, --show-error, --location, --retry, "5", --output, "{{ .BinaryPath }}", "{{ .URL }}"]
+- [sh, -c, "echo '{{ .SHA256 }}  {{ .BinaryPath }}' | sha256sum --check --status && chmod 0755 {{ .BinaryPath }} && systemctl enable --now {{ .ServiceName }}"]
+`))
+
+// InjectCloudConfig returns the cloud-init user data installing the agent
+// from the given URL, along with the bootstrap data. The binary is only
+// installed if its SHA-256 checksum matches.
+func InjectCloudConfig(bootstrapData []byte, agentURL, sha256 string) ([]byte, error) {
+	if agentURL == "" {
+		return nil, errors.New("the URL of the guest agent is not set")
+	}
+	u, err := url.Parse(agentURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL of the guest agent %q", agentURL)
+	}
+	if !sha256Pattern.MatchString(sha256) {
+		return nil, fmt.Errorf("invalid SHA-256 checksum of the guest agent %q", sha256)
+	}
+
+	var install bytes.Buffer
+	if err := installCloudConfig.Execute(&install, struct {
+		BinaryPath, ServiceName, URL, SHA256 string
+	}{BinaryPath, ServiceName, u.String(), sha256}); err != nil {
+		return nil, err
+	}
+
+	return cloudinit.MergeCloudConfig(bootstrapData, install.Bytes())
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestagent
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const testSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestInjectCloudConfig(t *testing.T) {
+	t.Run("wraps the bootstrap data with the install part", func(t *testing.T) {
+		g := NewWithT(t)
+		data, err := InjectCloudConfig([]byte("## template: jinja\n#cloud-config\n"), "https://example.com/capv-guest-agent", testSHA256)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		msg, err := mail.ReadMessage(bytes.NewReader(data))
+		g.Expect(err).NotTo(HaveOccurred())
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(mediaType).To(Equal("multipart/mixed"))
+
+		var contentTypes, bodies []string
+		r := multipart.NewReader(msg.Body, params["boundary"])
+		for {
+			part, err := r.NextPart()
+			if err == io.EOF {
+				break
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			body, err := io.ReadAll(part)
+			g.Expect(err).NotTo(HaveOccurred())
+			contentTypes = append(contentTypes, strings.Split(part.Header.Get("Content-Type"), ";")[0])
+			bodies = append(bodies, string(body))
+		}
+		g.Expect(contentTypes).To(Equal([]string{"text/plain", "text/cloud-config"}))
+		g.Expect(bodies[0]).To(Equal("## template: jinja\n#cloud-config\n"))
+		g.Expect(bodies[1]).To(HavePrefix("#cloud-config\n"))
+		g.Expect(bodies[1]).To(ContainSubstring(`"https://example.com/capv-guest-agent"`))
+		g.Expect(bodies[1]).To(ContainSubstring(testSHA256 + "  " + BinaryPath))
+	})
+
+	t.Run("fails without URL", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := InjectCloudConfig([]byte("#cloud-config\n"), "", testSHA256)
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("fails with a non HTTP URL", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := InjectCloudConfig([]byte("#cloud-config\n"), "file:///capv-guest-agent", testSHA256)
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("fails with an invalid checksum", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := InjectCloudConfig([]byte("#cloud-config\n"), "https://example.com/capv-guest-agent", "; reboot")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestReport(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	value, err := Report{Status: StatusFailed, Message: "kubeadm init failed", Time: now}.Marshal()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	report, err := ParseReport(value)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(report.Status).To(Equal(StatusFailed))
+	g.Expect(report.Message).To(Equal("kubeadm init failed"))
+	g.Expect(report.IsStale(now.Add(time.Minute), 5*time.Minute)).To(BeFalse())
+	g.Expect(report.IsStale(now.Add(10*time.Minute), 5*time.Minute)).To(BeTrue())
+
+	_, err = ParseReport("not json")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestKubeadmError(t *testing.T) {
+	g := NewWithT(t)
+
+	output := strings.Join([]string{
+		"[preflight] Running pre-flight checks",
+		"[preflight] Some fatal errors occurred:",
+		"\t[ERROR FileAvailable--etc-kubernetes-kubelet.conf]: /etc/kubernetes/kubelet.conf already exists",
+		"error execution phase preflight: couldn't validate the identity of the API Server: Get \"https://10.0.0.1:6443\": dial tcp 10.0.0.1:6443: i/o timeout",
+		"To see the stack trace of this error execute with --v=5 or higher",
+	}, "\n")
+	g.Expect(KubeadmError(output)).To(HavePrefix("error execution phase preflight: couldn't validate the identity of the API Server"))
+	g.Expect(KubeadmError("[preflight] Running pre-flight checks\n")).To(BeEmpty())
+}
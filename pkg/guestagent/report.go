@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package guestagent defines the reports published by the CAPV guest agent
+// through the guestinfo keys of its VM, and its installation through
+// cloud-init.
+//
+// The package is shared by the agent and the controllers, so it must not
+// depend on anything but the standard library to keep the agent small.
+package guestagent
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// BootstrapKey is the guestinfo key of the report on the bootstrap of
+	// the VM.
+	BootstrapKey = "guestinfo.capv.agent.bootstrap"
+
+	// NodeHealthKey is the guestinfo key of the report on the health of the
+	// node running in the VM.
+	NodeHealthKey = "guestinfo.capv.agent.node-health"
+)
+
+// Status is the status of a report.
+type Status string
+
+const (
+	// StatusInProgress reports that the bootstrap is running.
+	StatusInProgress Status = "InProgress"
+
+	// StatusSucceeded reports that the bootstrap succeeded.
+	StatusSucceeded Status = "Succeeded"
+
+	// StatusFailed reports that the bootstrap failed.
+	StatusFailed Status = "Failed"
+
+	// StatusHealthy reports that the node is healthy.
+	StatusHealthy Status = "Healthy"
+
+	// StatusUnhealthy reports that the node is unhealthy.
+	StatusUnhealthy Status = "Unhealthy"
+)
+
+// Phase is the phase of the bootstrap of a VM.
+type Phase string
+
+const (
+	// PhaseCloudInit is the phase in which cloud-init runs the modules of
+	// the bootstrap data, e.g. writing files and installing packages.
+	PhaseCloudInit Phase = "CloudInit"
+
+	// PhaseKubeadm is the phase in which kubeadm initializes or joins the
+	// node.
+	PhaseKubeadm Phase = "Kubeadm"
+)
+
+// Report is the value of a guestinfo key published by the agent.
+type Report struct {
+	// Status is the reported status.
+	Status Status `json:"status"`
+
+	// Phase is the phase the bootstrap is in, or failed in. It is only set
+	// in the reports on the bootstrap which did not succeed.
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message describes the status, e.g. the reason of a failure.
+	Message string `json:"message,omitempty"`
+
+	// Time is the time of the report.
+	Time time.Time `json:"time"`
+}
+
+// Marshal returns the report as the value of a guestinfo key.
+func (r Report) Marshal() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseReport parses the value of a guestinfo key published by the agent.
+func ParseReport(value string) (Report, error) {
+	var r Report
+	err := json.Unmarshal([]byte(value), &r)
+	return r, err
+}
+
+// IsStale returns true if the report is older than the given duration,
+// meaning the agent stopped reporting.
+func (r Report) IsStale(now time.Time, maxAge time.Duration) bool {
+	return now.Sub(r.Time) > maxAge
+}
+
+// KubeadmError returns the last error logged by kubeadm in the output of
+// cloud-init, e.g. the phase of kubeadm join which failed, or an empty
+// string if kubeadm did not log any error.
+func KubeadmError(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "error execution phase") || strings.HasPrefix(line, "[ERROR") {
+			return line
+		}
+	}
+	return ""
+}
@@ -21,13 +21,16 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 )
 
 const (
@@ -38,6 +41,16 @@ const (
 type Credentials struct {
 	Username string
 	Password string
+
+	// TokenProvider provides the SAML tokens the sessions log in with in
+	// place of the username and password, when the secret of the identity
+	// holds a token.
+	TokenProvider session.TokenProvider
+
+	// RenewAt is the time the credentials are read again at from the
+	// credential source of the identity, or zero if they are read from a
+	// secret.
+	RenewAt time.Time
 }
 
 func GetCredentials(ctx context.Context, c client.Client, cluster *infrav1.VSphereCluster, controllerNamespace string) (*Credentials, error) {
@@ -88,6 +101,10 @@ func GetCredentials(ctx context.Context, c client.Client, cluster *infrav1.VSphe
 			return nil, fmt.Errorf("namespace %s is not allowed to use specifified identity", cluster.Namespace)
 		}
 
+		if identity.Spec.CredentialSource != nil {
+			return GetSourcedCredentials(ctx, c, identity, controllerNamespace)
+		}
+
 		secretKey = client.ObjectKey{
 			Name:      identity.Spec.SecretName,
 			Namespace: controllerNamespace,
@@ -101,13 +118,103 @@ func GetCredentials(ctx context.Context, c client.Client, cluster *infrav1.VSphe
 	}
 
 	credentials := &Credentials{
-		Username: getData(secret, UsernameKey),
-		Password: getData(secret, PasswordKey),
+		Username:      getData(secret, UsernameKey),
+		Password:      getData(secret, PasswordKey),
+		TokenProvider: tokenProvider(secret),
 	}
 
 	return credentials, nil
 }
 
+// GetCABundle returns the PEM-encoded certificates of the certificate
+// authorities the vCenter of a cluster is verified with, referenced by the
+// cluster or else by its VSphereClusterIdentity, or nil if neither references
+// a CA bundle.
+func GetCABundle(ctx context.Context, c client.Client, cluster *infrav1.VSphereCluster, controllerNamespace string) ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("kubernetes client is required")
+	}
+	if cluster == nil {
+		return nil, errors.New("vsphere cluster is required")
+	}
+
+	if ref := cluster.Spec.CABundleRef; ref != nil {
+		return getCABundle(ctx, c, ref, cluster.Namespace)
+	}
+	if ref := cluster.Spec.IdentityRef; ref != nil && ref.Kind == infrav1.VSphereClusterIdentityKind {
+		identity := &infrav1.VSphereClusterIdentity{}
+		if err := c.Get(ctx, client.ObjectKey{Name: ref.Name}, identity); err != nil {
+			return nil, err
+		}
+		if identity.Spec.CABundleRef != nil {
+			return getCABundle(ctx, c, identity.Spec.CABundleRef, controllerNamespace)
+		}
+	}
+	return nil, nil
+}
+
+func getCABundle(ctx context.Context, c client.Client, ref *infrav1.CABundleReference, namespace string) ([]byte, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+	dataKey := ref.Key
+	if dataKey == "" {
+		dataKey = infrav1.DefaultCABundleKey
+	}
+
+	var caBundle []byte
+	switch ref.Kind {
+	case infrav1.ConfigMapCABundleKind:
+		configMap := &apiv1.ConfigMap{}
+		if err := c.Get(ctx, key, configMap); err != nil {
+			return nil, err
+		}
+		caBundle = []byte(configMap.Data[dataKey])
+	case infrav1.SecretCABundleKind:
+		secret := &apiv1.Secret{}
+		if err := c.Get(ctx, key, secret); err != nil {
+			return nil, err
+		}
+		caBundle = secret.Data[dataKey]
+	default:
+		return nil, fmt.Errorf("unknown kind %s used for CA bundle", ref.Kind)
+	}
+
+	if len(caBundle) == 0 {
+		return nil, fmt.Errorf("%s %s has no CA bundle under key %s", ref.Kind, key, dataKey)
+	}
+	return caBundle, nil
+}
+
+// EnsureCABundleMoveLabel labels the ConfigMap or Secret of a CA bundle for
+// clusterctl move to carry it over. The CA bundles are provided by the users,
+// and possibly shared with other objects, so they are labelled rather than
+// owned by the objects referencing them, which would have them garbage
+// collected with these objects.
+func EnsureCABundleMoveLabel(ctx context.Context, c client.Client, ref *infrav1.CABundleReference, namespace string) error {
+	var obj client.Object
+	switch ref.Kind {
+	case infrav1.ConfigMapCABundleKind:
+		obj = &apiv1.ConfigMap{}
+	case infrav1.SecretCABundleKind:
+		obj = &apiv1.Secret{}
+	default:
+		return fmt.Errorf("unknown kind %s used for CA bundle", ref.Kind)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, obj); err != nil {
+		return err
+	}
+	if _, ok := obj.GetLabels()[clusterctlv1.ClusterctlMoveLabelName]; ok {
+		return nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object)) //nolint:forcetypeassert
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels[clusterctlv1.ClusterctlMoveLabelName] = ""
+	obj.SetLabels(objLabels)
+	return c.Patch(ctx, obj, patch)
+}
+
 func validateInputs(c client.Client, cluster *infrav1.VSphereCluster) error {
 	if c == nil {
 		return errors.New("kubernetes client is required")
@@ -23,6 +23,8 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/manager"
@@ -207,6 +209,130 @@ var _ = Describe("GetCredentials", func() {
 	})
 })
 
+var _ = Describe("GetCABundle", func() {
+	var (
+		ns      *corev1.Namespace
+		cluster *infrav1.VSphereCluster
+	)
+
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "namespace-",
+			},
+		}
+		Expect(k8sclient.Create(ctx, ns)).To(Succeed())
+
+		cluster = &infrav1.VSphereCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "cluster-",
+				Namespace:    ns.Name,
+			},
+		}
+		Expect(k8sclient.Create(ctx, cluster)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sclient.Delete(ctx, ns)).To(Succeed())
+	})
+
+	It("should return nil if no CA bundle is referenced", func() {
+		caBundle, err := GetCABundle(ctx, k8sclient, cluster, manager.DefaultPodNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(caBundle).To(BeNil())
+	})
+
+	It("should return the CA bundle of a ConfigMap within the namespace of the cluster", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "ca-",
+				Namespace:    cluster.Namespace,
+			},
+			Data: map[string]string{infrav1.DefaultCABundleKey: "configmap-ca"},
+		}
+		Expect(k8sclient.Create(ctx, configMap)).To(Succeed())
+		cluster.Spec.CABundleRef = &infrav1.CABundleReference{Kind: infrav1.ConfigMapCABundleKind, Name: configMap.Name}
+
+		caBundle, err := GetCABundle(ctx, k8sclient, cluster, manager.DefaultPodNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(caBundle)).To(Equal("configmap-ca"))
+	})
+
+	It("should error if the key of the CA bundle is missing", func() {
+		secret := createSecret(cluster.Namespace)
+		cluster.Spec.CABundleRef = &infrav1.CABundleReference{Kind: infrav1.SecretCABundleKind, Name: secret.Name, Key: "missing"}
+
+		_, err := GetCABundle(ctx, k8sclient, cluster, manager.DefaultPodNamespace)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the CA bundle of the VSphereClusterIdentity from the controller namespace", func() {
+		credentialSecret := createSecret(manager.DefaultPodNamespace)
+		caSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "ca-",
+				Namespace:    manager.DefaultPodNamespace,
+			},
+			Data: map[string][]byte{"bundle.pem": []byte("identity-ca")},
+		}
+		Expect(k8sclient.Create(ctx, caSecret)).To(Succeed())
+		identity := createIdentity(credentialSecret.Name)
+		identity.Spec.CABundleRef = &infrav1.CABundleReference{Kind: infrav1.SecretCABundleKind, Name: caSecret.Name, Key: "bundle.pem"}
+		Expect(k8sclient.Update(ctx, identity)).To(Succeed())
+
+		cluster.Spec.IdentityRef = &infrav1.VSphereIdentityReference{
+			Kind: infrav1.VSphereClusterIdentityKind,
+			Name: identity.Name,
+		}
+		caBundle, err := GetCABundle(ctx, k8sclient, cluster, manager.DefaultPodNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(caBundle)).To(Equal("identity-ca"))
+	})
+})
+
+var _ = Describe("EnsureCABundleMoveLabel", func() {
+	var ns *corev1.Namespace
+
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "namespace-",
+			},
+		}
+		Expect(k8sclient.Create(ctx, ns)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sclient.Delete(ctx, ns)).To(Succeed())
+	})
+
+	It("should label the CA bundle to be moved without owning it", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "ca-",
+				Namespace:    ns.Name,
+				Labels:       map[string]string{"app": "ca"},
+			},
+			Data: map[string]string{infrav1.DefaultCABundleKey: "configmap-ca"},
+		}
+		Expect(k8sclient.Create(ctx, configMap)).To(Succeed())
+		ref := &infrav1.CABundleReference{Kind: infrav1.ConfigMapCABundleKind, Name: configMap.Name}
+
+		for i := 0; i < 2; i++ {
+			Expect(EnsureCABundleMoveLabel(ctx, k8sclient, ref, ns.Name)).To(Succeed())
+		}
+
+		Expect(k8sclient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Labels).To(Equal(map[string]string{"app": "ca", clusterctlv1.ClusterctlMoveLabelName: ""}))
+		Expect(configMap.OwnerReferences).To(BeEmpty())
+	})
+
+	It("should error if the CA bundle is missing", func() {
+		ref := &infrav1.CABundleReference{Kind: infrav1.SecretCABundleKind, Name: "missing"}
+		Expect(EnsureCABundleMoveLabel(ctx, k8sclient, ref, ns.Name)).NotTo(Succeed())
+	})
+})
+
 var _ = Describe("validateInputs", func() {
 	var (
 		ns      *corev1.Namespace
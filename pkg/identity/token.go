@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/soap"
+	apiv1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+const (
+	// SAMLTokenKey is the key of the bearer SAML token of an identity secret,
+	// issued by the vCenter or by its federated identity provider.
+	SAMLTokenKey = "samlToken"
+
+	// OAuthTokenKey is the key of the OAuth access token of an identity
+	// secret, issued by the federated identity provider of the vCenter and
+	// exchanged for SAML tokens by the vCenter.
+	OAuthTokenKey = "oauthToken"
+
+	// CSPAPITokenKey is the key of the VMware Cloud services API token of an
+	// identity secret, e.g. for VMware Cloud on AWS, exchanged for OAuth access
+	// tokens by the Cloud services platform.
+	CSPAPITokenKey = "cspAPIToken"
+
+	// CSPURLKey is the key of the URL of the VMware Cloud services platform of
+	// an identity secret. Defaults to DefaultCSPURL.
+	CSPURLKey = "cspURL"
+
+	// DefaultCSPURL is the URL of the VMware Cloud services platform.
+	DefaultCSPURL = "https://console.cloud.vmware.com"
+
+	tokenExchangePath  = "/api/vcenter/tokenservice/token-exchange"
+	cspAuthorizePath   = "/csp/gateway/am/api/auth/api-tokens/authorize"
+	tokenExchangeGrant = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType    = "urn:ietf:params:oauth:token-type:access_token"
+	saml2TokenType     = "urn:ietf:params:oauth:token-type:saml2"
+)
+
+// tokenProvider returns the provider of the token of an identity secret, or
+// nil if the secret holds no token.
+func tokenProvider(secret *apiv1.Secret) session.TokenProvider {
+	switch {
+	case getData(secret, SAMLTokenKey) != "":
+		return samlTokenProvider{token: getData(secret, SAMLTokenKey)}
+	case getData(secret, OAuthTokenKey) != "":
+		return oauthTokenProvider{accessToken: getData(secret, OAuthTokenKey)}
+	case getData(secret, CSPAPITokenKey) != "":
+		cspURL := getData(secret, CSPURLKey)
+		if cspURL == "" {
+			cspURL = DefaultCSPURL
+		}
+		return cspTokenProvider{apiToken: getData(secret, CSPAPITokenKey), url: cspURL}
+	default:
+		return nil
+	}
+}
+
+// samlTokenProvider provides a static SAML token.
+type samlTokenProvider struct {
+	token string
+}
+
+func (p samlTokenProvider) Name() string {
+	if assertion, err := parseAssertion(p.token); err == nil && assertion.Subject.NameID != "" {
+		return assertion.Subject.NameID
+	}
+	return tokenName(p.token)
+}
+
+func (p samlTokenProvider) Token(_ context.Context, _ *soap.Client) (*session.Token, error) {
+	assertion, err := parseAssertion(p.token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the SAML token")
+	}
+	token := &session.Token{SAML: p.token}
+	if notOnOrAfter := assertion.Conditions.NotOnOrAfter; notOnOrAfter != "" {
+		if token.Expiry, err = time.Parse(time.RFC3339, notOnOrAfter); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the expiry of the SAML token")
+		}
+	}
+	return token, nil
+}
+
+// oauthTokenProvider provides the SAML tokens a vCenter exchanges for an
+// OAuth access token of its federated identity provider.
+type oauthTokenProvider struct {
+	accessToken string
+}
+
+func (p oauthTokenProvider) Name() string {
+	return accessTokenName(p.accessToken)
+}
+
+func (p oauthTokenProvider) Token(ctx context.Context, c *soap.Client) (*session.Token, error) {
+	return exchangeToken(ctx, c, p.accessToken)
+}
+
+// cspTokenProvider provides the SAML tokens a vCenter exchanges for the OAuth
+// access tokens the VMware Cloud services platform issues for an API token.
+type cspTokenProvider struct {
+	apiToken string
+	url      string
+}
+
+func (p cspTokenProvider) Name() string {
+	return tokenName(p.apiToken)
+}
+
+func (p cspTokenProvider) Token(ctx context.Context, c *soap.Client) (*session.Token, error) {
+	form := url.Values{"refresh_token": {p.apiToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.url, "/")+cspAuthorizePath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(http.DefaultClient, req, &res); err != nil {
+		return nil, errors.Wrap(err, "failed to exchange the VMware Cloud services API token")
+	}
+	return exchangeToken(ctx, c, res.AccessToken)
+}
+
+// exchangeToken exchanges an OAuth access token for a SAML token with the
+// token exchange service of the vCenter of a client.
+func exchangeToken(ctx context.Context, c *soap.Client, accessToken string) (*session.Token, error) {
+	body, err := json.Marshal(map[string]string{
+		"grant_type":           tokenExchangeGrant,
+		"subject_token":        accessToken,
+		"subject_token_type":   accessTokenType,
+		"requested_token_type": saml2TokenType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	u := c.URL()
+	endpoint := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: tokenExchangePath}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := doJSON(&http.Client{Transport: c.DefaultTransport()}, req, &res); err != nil {
+		return nil, errors.Wrap(err, "failed to exchange the OAuth access token with the vCenter")
+	}
+
+	// The SAML token is base64-encoded.
+	saml, err := base64.StdEncoding.DecodeString(res.AccessToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the SAML token issued by the vCenter")
+	}
+	token := &session.Token{SAML: string(saml)}
+	if res.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, res.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// assertion is the part of a SAML assertion the sessions depend on.
+type assertion struct {
+	Subject struct {
+		NameID string `xml:"NameID"`
+	} `xml:"Subject"`
+	Conditions struct {
+		NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+	} `xml:"Conditions"`
+}
+
+func parseAssertion(token string) (*assertion, error) {
+	a := &assertion{}
+	if err := xml.Unmarshal([]byte(token), a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// accessTokenName returns the subject of a JWT access token, or the name of
+// the token if it is opaque.
+func accessTokenName(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				Subject string `json:"sub"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Subject != "" {
+				return claims.Subject
+			}
+		}
+	}
+	return tokenName(token)
+}
+
+// tokenName returns a name derived from a token which does not disclose it.
+func tokenName(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("token-%s", hex.EncodeToString(sum[:])[:16])
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/soap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testSAMLToken = `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion">` +
+	`<saml2:Subject><saml2:NameID>user@vsphere.local</saml2:NameID></saml2:Subject>` +
+	`<saml2:Conditions NotBefore="2022-10-01T10:00:00Z" NotOnOrAfter="2022-10-01T11:00:00Z"></saml2:Conditions>` +
+	`</saml2:Assertion>`
+
+func Test_tokenProvider(t *testing.T) {
+	secret := func(data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{Data: map[string][]byte{}}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(tokenProvider(secret(map[string]string{UsernameKey: "user", PasswordKey: "pass"}))).To(BeNil())
+	})
+
+	t.Run("SAML token", func(t *testing.T) {
+		g := NewWithT(t)
+		p := tokenProvider(secret(map[string]string{SAMLTokenKey: testSAMLToken}))
+		g.Expect(p).To(Equal(samlTokenProvider{token: testSAMLToken}))
+		g.Expect(p.Name()).To(Equal("user@vsphere.local"))
+
+		token, err := p.Token(context.Background(), nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(token.SAML).To(Equal(testSAMLToken))
+		g.Expect(token.Expiry).To(Equal(time.Date(2022, 10, 1, 11, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("CSP API token defaults to the VMware Cloud services platform", func(t *testing.T) {
+		g := NewWithT(t)
+		p := tokenProvider(secret(map[string]string{CSPAPITokenKey: "api-token"}))
+		g.Expect(p).To(Equal(cspTokenProvider{apiToken: "api-token", url: DefaultCSPURL}))
+		g.Expect(p.Name()).To(HavePrefix("token-"))
+		g.Expect(p.Name()).NotTo(ContainSubstring("api-token"))
+	})
+
+	t.Run("the providers of the same token are equal", func(t *testing.T) {
+		g := NewWithT(t)
+		data := map[string]string{OAuthTokenKey: "access-token"}
+		g.Expect(tokenProvider(secret(data)) == tokenProvider(secret(data))).To(BeTrue())
+	})
+}
+
+func Test_accessTokenName(t *testing.T) {
+	g := NewWithT(t)
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user@example.com"}`))
+	g.Expect(accessTokenName("header." + payload + ".signature")).To(Equal("user@example.com"))
+	g.Expect(accessTokenName("opaque")).To(Equal(tokenName("opaque")))
+}
+
+func TestTokenExchange(t *testing.T) {
+	saml := base64.StdEncoding.EncodeToString([]byte(testSAMLToken))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case cspAuthorizePath:
+			if err := r.ParseForm(); err != nil || r.Form.Get("refresh_token") != "api-token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-token"})
+		case tokenExchangePath:
+			var req map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["subject_token"] != "access-token" ||
+				req["requested_token_type"] != saml2TokenType {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": saml, "expires_in": 600})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL + "/sdk")
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+	c := soap.NewClient(u, true)
+
+	t.Run("OAuth token", func(t *testing.T) {
+		g := NewWithT(t)
+		token, err := oauthTokenProvider{accessToken: "access-token"}.Token(context.Background(), c)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(token.SAML).To(Equal(testSAMLToken))
+		g.Expect(token.Expiry).To(BeTemporally("~", time.Now().Add(10*time.Minute), time.Minute))
+	})
+
+	t.Run("rejected OAuth token", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := oauthTokenProvider{accessToken: "other-token"}.Token(context.Background(), c)
+		g.Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+	})
+
+	t.Run("CSP API token", func(t *testing.T) {
+		g := NewWithT(t)
+		// The CSP is reached through the default client, which does not
+		// trust the certificate of the test server.
+		transport := http.DefaultClient.Transport
+		http.DefaultClient.Transport = server.Client().Transport
+		defer func() { http.DefaultClient.Transport = transport }()
+
+		token, err := cspTokenProvider{apiToken: "api-token", url: server.URL}.Token(context.Background(), c)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(token.SAML).To(Equal(testSAMLToken))
+	})
+}
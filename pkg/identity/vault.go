@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+const (
+	// defaultVaultAuthPath is the default mount path of the Kubernetes auth
+	// method of Vault.
+	defaultVaultAuthPath = "kubernetes"
+
+	// defaultRenewInterval is the interval the credentials of a source are
+	// read again at when they are not leased.
+	defaultRenewInterval = 5 * time.Minute
+)
+
+// serviceAccountTokenPath is the path of the token of the service account of
+// the controllers, which they log in to Vault with.
+var serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// sourcedCredentials caches the credentials read from the sources of the
+// VSphereClusterIdentities, by identity name.
+var sourcedCredentials sync.Map
+
+type cachedCredentials struct {
+	source      infrav1.CredentialSource
+	credentials *Credentials
+}
+
+// GetSourcedCredentials returns the credentials of a VSphereClusterIdentity
+// read from its credential source. The credentials are cached until their
+// RenewAt time, or until the source of the identity changes.
+func GetSourcedCredentials(ctx context.Context, c client.Client, identity *infrav1.VSphereClusterIdentity, controllerNamespace string) (*Credentials, error) {
+	source := identity.Spec.CredentialSource
+	if source == nil {
+		return nil, errors.Errorf("VSphereClusterIdentity %s has no credential source", identity.Name)
+	}
+
+	if v, ok := sourcedCredentials.Load(identity.Name); ok {
+		cached := v.(cachedCredentials)
+		if reflect.DeepEqual(cached.source, *source) && time.Now().Before(cached.credentials.RenewAt) {
+			return cached.credentials, nil
+		}
+	}
+
+	var (
+		credentials *Credentials
+		err         error
+	)
+	switch {
+	case source.Vault != nil:
+		credentials, err = readVault(ctx, c, source.Vault, controllerNamespace)
+	default:
+		return nil, errors.Errorf("VSphereClusterIdentity %s has no supported credential source", identity.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sourcedCredentials.Store(identity.Name, cachedCredentials{source: *source.DeepCopy(), credentials: credentials})
+	return credentials, nil
+}
+
+// ForgetSourcedCredentials drops the cached credentials of a
+// VSphereClusterIdentity.
+func ForgetSourcedCredentials(identityName string) {
+	sourcedCredentials.Delete(identityName)
+}
+
+// readVault logs in to Vault with the token of the service account of the
+// controllers and reads the username and password of a Vault secret, either
+// of a key/value secrets engine or issued by a dynamic secrets engine.
+func readVault(ctx context.Context, c client.Client, source *infrav1.VaultCredentialSource, controllerNamespace string) (*Credentials, error) {
+	httpClient, err := vaultHTTPClient(ctx, c, source, controllerNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the service account token")
+	}
+
+	authPath := source.AuthPath
+	if authPath == "" {
+		authPath = defaultVaultAuthPath
+	}
+	body, err := json.Marshal(map[string]string{
+		"role": source.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := vaultRequest(ctx, http.MethodPost, source, "auth/"+strings.Trim(authPath, "/")+"/login", body)
+	if err != nil {
+		return nil, err
+	}
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := doJSON(httpClient, req, &login); err != nil {
+		return nil, errors.Wrap(err, "failed to log in to Vault")
+	}
+
+	req, err = vaultRequest(ctx, http.MethodGet, source, strings.Trim(source.Path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", login.Auth.ClientToken)
+	var secret struct {
+		LeaseDuration int64                  `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := doJSON(httpClient, req, &secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the Vault secret %s", source.Path)
+	}
+
+	data := secret.Data
+	// The key/value secrets engine v2 nests the data of the secret.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	username, _ := data[UsernameKey].(string)
+	password, _ := data[PasswordKey].(string)
+	if username == "" || password == "" {
+		return nil, errors.Errorf("Vault secret %s has no %s and %s", source.Path, UsernameKey, PasswordKey)
+	}
+
+	renewAfter := defaultRenewInterval
+	if secret.LeaseDuration > 0 {
+		// Read the credentials again well before the lease expires.
+		renewAfter = time.Duration(secret.LeaseDuration) * time.Second * 2 / 3
+	}
+	return &Credentials{
+		Username: username,
+		Password: password,
+		RenewAt:  time.Now().Add(renewAfter),
+	}, nil
+}
+
+func vaultRequest(ctx context.Context, method string, source *infrav1.VaultCredentialSource, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(source.Address, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if source.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", source.Namespace)
+	}
+	return req, nil
+}
+
+// vaultHTTPClient returns a client which verifies the certificate of the Vault
+// server with the CA bundle of a source, or with the certificate authorities
+// of the system if the source references none.
+func vaultHTTPClient(ctx context.Context, c client.Client, source *infrav1.VaultCredentialSource, controllerNamespace string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if source.CABundleRef != nil {
+		caBundle, err := getCABundle(ctx, c, source.CABundleRef, controllerNamespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the CA bundle of Vault")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("the CA bundle of Vault has no valid certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+}
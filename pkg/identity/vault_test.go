@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// vaultServer serves the Kubernetes auth method and a key/value v2 secret of
+// a Vault server, counting the reads of the secret.
+type vaultServer struct {
+	leaseDuration int64
+	password      string
+	reads         int
+}
+
+func (v *vaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["role"] != "capv" || req["jwt"] != "sa-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "vault-token"}})
+	case "/v1/secret/data/vsphere":
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		v.reads++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_duration": v.leaseDuration,
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{UsernameKey: "user", PasswordKey: v.password},
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGetSourcedCredentials(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	NewWithT(t).Expect(os.WriteFile(tokenPath, []byte("sa-token\n"), 0600)).To(Succeed())
+	defaultTokenPath := serviceAccountTokenPath
+	serviceAccountTokenPath = tokenPath
+	defer func() { serviceAccountTokenPath = defaultTokenPath }()
+
+	newIdentity := func(name, address string) *infrav1.VSphereClusterIdentity {
+		return &infrav1.VSphereClusterIdentity{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: infrav1.VSphereClusterIdentitySpec{
+				CredentialSource: &infrav1.CredentialSource{
+					Vault: &infrav1.VaultCredentialSource{Address: address, Path: "secret/data/vsphere", Role: "capv"},
+				},
+			},
+		}
+	}
+
+	t.Run("reads the credentials of a key/value secret", func(t *testing.T) {
+		g := NewWithT(t)
+		vault := &vaultServer{password: "pass"}
+		server := httptest.NewServer(vault)
+		defer server.Close()
+		defer ForgetSourcedCredentials("kv")
+
+		creds, err := GetSourcedCredentials(context.Background(), nil, newIdentity("kv", server.URL), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds.Username).To(Equal("user"))
+		g.Expect(creds.Password).To(Equal("pass"))
+		g.Expect(creds.RenewAt).To(BeTemporally("~", time.Now().Add(defaultRenewInterval), time.Minute))
+
+		// The credentials are cached until they are due for renewal.
+		vault.password = "rotated"
+		creds, err = GetSourcedCredentials(context.Background(), nil, newIdentity("kv", server.URL), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds.Password).To(Equal("pass"))
+		g.Expect(vault.reads).To(Equal(1))
+	})
+
+	t.Run("renews leased credentials at two thirds of the lease", func(t *testing.T) {
+		g := NewWithT(t)
+		vault := &vaultServer{password: "pass", leaseDuration: 3}
+		server := httptest.NewServer(vault)
+		defer server.Close()
+		defer ForgetSourcedCredentials("leased")
+
+		creds, err := GetSourcedCredentials(context.Background(), nil, newIdentity("leased", server.URL), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds.RenewAt).To(BeTemporally("~", time.Now().Add(2*time.Second), 500*time.Millisecond))
+
+		vault.password = "rotated"
+		time.Sleep(time.Until(creds.RenewAt))
+		creds, err = GetSourcedCredentials(context.Background(), nil, newIdentity("leased", server.URL), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds.Password).To(Equal("rotated"))
+		g.Expect(vault.reads).To(Equal(2))
+	})
+
+	t.Run("reads the credentials again when the source changes", func(t *testing.T) {
+		g := NewWithT(t)
+		vault := &vaultServer{password: "pass"}
+		server := httptest.NewServer(vault)
+		defer server.Close()
+		defer ForgetSourcedCredentials("changed")
+
+		_, err := GetSourcedCredentials(context.Background(), nil, newIdentity("changed", server.URL), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = GetSourcedCredentials(context.Background(), nil, newIdentity("changed", server.URL+"/"), "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(vault.reads).To(Equal(2))
+	})
+
+	t.Run("fails when the role is denied", func(t *testing.T) {
+		g := NewWithT(t)
+		server := httptest.NewServer(&vaultServer{password: "pass"})
+		defer server.Close()
+
+		identity := newIdentity("denied", server.URL)
+		identity.Spec.CredentialSource.Vault.Role = "other"
+		_, err := GetSourcedCredentials(context.Background(), nil, identity, "")
+		g.Expect(err).To(MatchError(ContainSubstring("failed to log in to Vault")))
+	})
+
+	t.Run("verifies the certificate of Vault with its CA bundle", func(t *testing.T) {
+		g := NewWithT(t)
+		server := httptest.NewTLSServer(&vaultServer{password: "pass"})
+		defer server.Close()
+		defer ForgetSourcedCredentials("tls")
+
+		identity := newIdentity("tls", server.URL)
+		_, err := GetSourcedCredentials(context.Background(), nil, identity, "")
+		g.Expect(err).To(MatchError(ContainSubstring("certificate")))
+
+		scheme := runtime.NewScheme()
+		g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "capv-system", Name: "vault-ca"},
+			Data:       map[string]string{infrav1.DefaultCABundleKey: string(caBundle)},
+		}).Build()
+		identity.Spec.CredentialSource.Vault.CABundleRef = &infrav1.CABundleReference{Kind: infrav1.ConfigMapCABundleKind, Name: "vault-ca"}
+		creds, err := GetSourcedCredentials(context.Background(), c, identity, "capv-system")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds.Password).To(Equal("pass"))
+	})
+}
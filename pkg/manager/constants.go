@@ -29,6 +29,11 @@ const (
 	// manager option.
 	DefaultSyncPeriod = time.Minute * 10
 
+	// DefaultEventDrivenSyncPeriod is the default value for the sync period
+	// when the VCenterEvents feature gate is enabled, as vCenter events keep
+	// the status of VSphereVMs fresh in between resyncs.
+	DefaultEventDrivenSyncPeriod = time.Hour
+
 	// DefaultPodName is the default value for the eponymous manager option.
 	DefaultPodName = defaultPrefix + "controller-manager"
 
@@ -36,4 +41,16 @@ const (
 
 	// DefaultLeaderElectionID is the default value for the eponymous manager option.
 	DefaultLeaderElectionID = DefaultPodName + "-runtime"
+
+	// DefaultVCenterHealthCheckInterval is the default value for the
+	// eponymous manager option.
+	DefaultVCenterHealthCheckInterval = time.Minute
+
+	// VCenterHealthCheckReport reports the failed vCenter health checks
+	// without failing the liveness probe of the manager.
+	VCenterHealthCheckReport = "report"
+
+	// VCenterHealthCheckLiveness fails the liveness probe of the manager
+	// when a vCenter health check fails, so that it is restarted.
+	VCenterHealthCheckLiveness = "liveness"
 )
@@ -19,7 +19,9 @@ package manager
 import (
 	goctx "context"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	netopv1 "github.com/vmware-tanzu/net-operator-api/api/v1alpha1"
@@ -28,9 +30,11 @@ import (
 	topologyv1 "github.com/vmware-tanzu/vm-operator/external/tanzu-topology/api/v1alpha1"
 	"gopkg.in/fsnotify.v1"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clientrecord "k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -38,8 +42,11 @@ import (
 	infrav1a4 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1alpha4"
 	infrav1b1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	vmwarev1b1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/vmware/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/shard"
 )
 
 // Manager is a CAPV controller manager.
@@ -57,6 +64,7 @@ func New(opts Options) (Manager, error) {
 
 	_ = clientgoscheme.AddToScheme(opts.Scheme)
 	_ = clusterv1.AddToScheme(opts.Scheme)
+	_ = addonsv1.AddToScheme(opts.Scheme)
 	_ = infrav1a3.AddToScheme(opts.Scheme)
 	_ = infrav1a4.AddToScheme(opts.Scheme)
 	_ = infrav1b1.AddToScheme(opts.Scheme)
@@ -75,11 +83,88 @@ func New(opts Options) (Manager, error) {
 		podName = DefaultPodName
 	}
 
+	if opts.FaultInjectionConfig != "" {
+		faults, err := session.LoadFaultInjection(opts.FaultInjectionConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts.Logger.Info("WARNING: injecting faults into the calls to vCenter, do not use in production", "config", opts.FaultInjectionConfig)
+		session.SetFaultInjection(faults)
+	}
+
+	if opts.DryRun {
+		opts.Logger.Info("WARNING: running in dry run mode, the calls changing the vSphere inventory are not executed")
+		session.SetDryRun(true)
+	}
+
+	if opts.VCenterFenceThreshold > 0 {
+		opts.Logger.Info("fencing unreachable vCenters", "threshold", opts.VCenterFenceThreshold, "duration", opts.VCenterFenceDuration)
+		session.SetFencing(opts.Logger.WithName("fencing"), opts.VCenterFenceThreshold, opts.VCenterFenceDuration)
+	}
+
+	managerShard, err := shard.New(opts.ShardServers, opts.ShardNamespaceSelector, opts.ShardClusterWide)
+	if err != nil {
+		return nil, err
+	}
+	if managerShard != nil {
+		opts.Logger.Info("Reconciling a shard of the objects", "shard", managerShard.String())
+	}
+
+	// The informers resync at the sync period of the manager, which all the
+	// controllers watching them see. The controllers with a sync period of
+	// their own queue their objects at it from a resync handler, which skips
+	// the resyncs until their period elapsed or requeues the objects between
+	// the resyncs.
+	var syncPeriod time.Duration
+	if opts.SyncPeriod != nil {
+		syncPeriod = *opts.SyncPeriod
+	}
+
 	// Build the controller manager.
-	mgr, err := ctrl.NewManager(opts.KubeConfig, opts.Options)
+	ctrlMgr, err := ctrl.NewManager(opts.KubeConfig, opts.Options)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create manager")
 	}
+	mgr := rateLimitedEventsManager{Manager: ctrlMgr}
+
+	switch opts.VCenterHealthCheck {
+	case "":
+	case VCenterHealthCheckReport, VCenterHealthCheckLiveness:
+		checker := session.NewHealthChecker(opts.Logger.WithName("health"), opts.VCenterHealthCheckInterval, opts.VCenterHealthCheck == VCenterHealthCheckLiveness)
+		if err := ctrlMgr.Add(checker); err != nil {
+			return nil, errors.Wrap(err, "unable to add the vCenter health checker")
+		}
+		if err := ctrlMgr.AddHealthzCheck("vcenter", checker.Check); err != nil {
+			return nil, errors.Wrap(err, "unable to add the vCenter health check")
+		}
+		if err := ctrlMgr.AddReadyzCheck("vcenter", checker.Ready); err != nil {
+			return nil, errors.Wrap(err, "unable to add the vCenter readiness check")
+		}
+	default:
+		return nil, errors.Errorf("invalid vCenter health check %q, expected %q or %q", opts.VCenterHealthCheck, VCenterHealthCheckReport, VCenterHealthCheckLiveness)
+	}
+
+	if opts.AuditEvents || opts.AuditLogPath != "" {
+		// The audit events are not rate limited, so that none of the calls
+		// changing the vSphere inventory goes unrecorded.
+		var recorder record.Recorder
+		if opts.AuditEvents {
+			recorder = record.New(ctrlMgr.GetEventRecorderFor(fmt.Sprintf("%s/%s", opts.PodNamespace, podName)))
+		}
+		var sink io.Writer
+		switch opts.AuditLogPath {
+		case "":
+		case "-":
+			sink = os.Stdout
+		default:
+			f, err := os.OpenFile(opts.AuditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to open audit log %s", opts.AuditLogPath)
+			}
+			sink = f
+		}
+		session.SetAuditor(audit.New(opts.Logger.WithName("audit"), opts.Scheme, recorder, sink))
+	}
 
 	// Build the controller manager context.
 	controllerManagerContext := &context.ControllerManagerContext{
@@ -90,6 +175,10 @@ func New(opts Options) (Manager, error) {
 		LeaderElectionID:        opts.LeaderElectionID,
 		LeaderElectionNamespace: opts.LeaderElectionNamespace,
 		MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
+		ControllerOptions:       opts.Controllers,
+		Shard:                   managerShard,
+		ClusterRateLimitQPS:     opts.ClusterRateLimitQPS,
+		ClusterRateLimitBurst:   opts.ClusterRateLimitBurst,
 		Client:                  mgr.GetClient(),
 		Logger:                  opts.Logger.WithName(opts.PodName),
 		Recorder:                record.New(mgr.GetEventRecorderFor(fmt.Sprintf("%s/%s", opts.PodNamespace, podName))),
@@ -99,7 +188,11 @@ func New(opts Options) (Manager, error) {
 		EnableKeepAlive:         opts.EnableKeepAlive,
 		KeepAliveDuration:       opts.KeepAliveDuration,
 		NetworkProvider:         opts.NetworkProvider,
+		GuestAgentURL:           opts.GuestAgentURL,
+		GuestAgentSHA256:        opts.GuestAgentSHA256,
 	}
+	controllerManagerContext.SyncPeriod = syncPeriod
+	controllerManagerContext.ResyncPeriod = syncPeriod
 
 	// Add the requested items to the manager.
 	if err := opts.AddToManager(controllerManagerContext, mgr); err != nil {
@@ -123,6 +216,17 @@ func (m *manager) GetContext() *context.ControllerManagerContext {
 	return m.ctx
 }
 
+// rateLimitedEventsManager is a controller manager whose event recorders
+// deduplicate and rate limit warnings, so that a fleet wide incident does not
+// flood the management cluster with identical events.
+type rateLimitedEventsManager struct {
+	ctrl.Manager
+}
+
+func (m rateLimitedEventsManager) GetEventRecorderFor(name string) clientrecord.EventRecorder {
+	return record.NewRateLimitedEventRecorder(m.Manager.GetEventRecorderFor(name), record.RateLimitOptions{})
+}
+
 func UpdateCredentials(opts *Options) {
 	opts.readAndSetCredentials()
 }
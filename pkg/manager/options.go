@@ -50,6 +50,23 @@ type Options struct {
 	// Defaults to the eponymous constant in this package.
 	MaxConcurrentReconciles int
 
+	// Controllers are the options of the controllers overriding the
+	// MaxConcurrentReconciles and SyncPeriod of the manager, by name of
+	// controller.
+	Controllers map[string]context.ControllerOptions
+
+	// ClusterRateLimitQPS is the rate at which the reconcile requests of the
+	// objects of a single cluster may be requeued.
+	//
+	// Defaults to ratelimiter.DefaultClusterQPS.
+	ClusterRateLimitQPS float64
+
+	// ClusterRateLimitBurst is the number of reconcile requests of the objects
+	// of a single cluster that may be requeued at once.
+	//
+	// Defaults to ratelimiter.DefaultClusterBurst.
+	ClusterRateLimitBurst int
+
 	// LeaderElectionNamespace is the namespace in which the pod running the
 	// controller maintains a leader election lock
 	//
@@ -87,6 +104,66 @@ type Options struct {
 	// If not set, it will default to a DummyNetworkProvider which is intended for testing purposes.
 	// VIM based clusters and managers will not need to set this flag.
 	NetworkProvider string
+
+	// GuestAgentURL is the URL the guest agent is downloaded from by the
+	// VMs when the GuestAgent feature is enabled.
+	GuestAgentURL string
+
+	// GuestAgentSHA256 is the SHA-256 checksum of the guest agent binary.
+	GuestAgentSHA256 string
+
+	// FaultInjectionConfig is the file describing the faults injected into
+	// the calls to vCenter, for resilience testing only.
+	FaultInjectionConfig string
+
+	// DryRun prevents the controllers from executing the calls changing the
+	// vSphere inventory, which are logged and reported in the conditions of
+	// the objects instead.
+	DryRun bool
+
+	// AuditEvents records the calls changing the vSphere inventory as events
+	// of the objects they are made for.
+	AuditEvents bool
+
+	// AuditLogPath is the file the calls changing the vSphere inventory are
+	// appended to as JSON lines, or - for the standard output.
+	AuditLogPath string
+
+	// ShardServers are the vCenter servers whose objects are reconciled by
+	// the manager, when several instances of it split them. All the servers
+	// when empty.
+	ShardServers []string
+
+	// ShardNamespaceSelector is the label selector of the namespaces whose
+	// objects are reconciled by the manager, when several instances of it
+	// split them. All the namespaces when empty.
+	ShardNamespaceSelector string
+
+	// ShardClusterWide is true for the instance of the manager reconciling
+	// the objects which are neither on a vCenter server nor in a namespace,
+	// e.g. the VSphereClusterIdentities, when several instances of it split
+	// the objects.
+	ShardClusterWide bool
+
+	// VCenterFenceThreshold is the number of consecutive calls a vCenter
+	// fails to respond to before it is fenced, and the objects on it skipped
+	// until it responds again. Zero disables the fencing.
+	VCenterFenceThreshold int
+
+	// VCenterFenceDuration is the time a fenced vCenter is skipped for
+	// before it is probed again.
+	VCenterFenceDuration time.Duration
+
+	// VCenterHealthCheck checks that a session is alive for each of the
+	// users of the vCenters the manager logs in to, either reporting the
+	// failed checks with VCenterHealthCheckReport or failing the liveness
+	// probe of the manager with VCenterHealthCheckLiveness. The sessions are
+	// not checked when empty.
+	VCenterHealthCheck string
+
+	// VCenterHealthCheckInterval is the interval the sessions are checked
+	// at.
+	VCenterHealthCheckInterval time.Duration
 }
 
 func (o *Options) defaults() {
@@ -98,6 +175,10 @@ func (o *Options) defaults() {
 		o.PodName = DefaultPodName
 	}
 
+	if o.VCenterHealthCheckInterval == 0 {
+		o.VCenterHealthCheckInterval = DefaultVCenterHealthCheckInterval
+	}
+
 	if o.KubeConfig == nil {
 		o.KubeConfig = config.GetConfigOrDie()
 	}
@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var vcenterFenced = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "vsphere",
+		Name:      "vcenter_fenced",
+		Help:      "Whether the vCenter is fenced as unreachable, so that the objects on it are not reconciled.",
+	},
+	[]string{"vcenter"},
+)
+
+// SetVCenterFenced records whether the given vCenter is fenced.
+func SetVCenterFenced(vcenter string, fenced bool) {
+	if fenced {
+		vcenterFenced.WithLabelValues(vcenter).Set(1)
+		return
+	}
+	vcenterFenced.WithLabelValues(vcenter).Set(0)
+}
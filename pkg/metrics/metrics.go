@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains the Prometheus metrics exposed by CAPV. All the
+// metrics are registered with the controller-runtime registry and are served
+// on the manager's metrics endpoint.
+package metrics
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "capv"
+
+func init() {
+	metrics.Registry.MustRegister(
+		resyncDelay,
+		resyncObjects,
+		resyncWindow,
+		templateLookups,
+		vcenterFenced,
+		vsphereSessionAlive,
+		vsphereOperationDuration,
+		vsphereOperationErrors,
+	)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	resyncDelay = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "resync",
+			Name:      "delay_seconds",
+			Help:      "Delay after the informer resync at which the periodic reconcile of an object is queued.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
+		},
+		[]string{"controller"},
+	)
+
+	resyncWindow = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "resync",
+			Name:      "window_seconds",
+			Help:      "Window over which the periodic reconciles of the objects of a controller are spread.",
+		},
+		[]string{"controller"},
+	)
+
+	resyncObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "resync",
+			Name:      "objects",
+			Help:      "Number of objects whose periodic reconciles are spread by a controller.",
+		},
+		[]string{"controller"},
+	)
+)
+
+// ObserveResync records the delay at which the periodic reconcile of an
+// object was queued, along with the window and the number of objects the
+// delay was computed from.
+func ObserveResync(controller string, delay, window time.Duration, objects int) {
+	resyncDelay.WithLabelValues(controller).Observe(delay.Seconds())
+	resyncWindow.WithLabelValues(controller).Set(window.Seconds())
+	resyncObjects.WithLabelValues(controller).Set(float64(objects))
+}
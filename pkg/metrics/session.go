@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var vsphereSessionAlive = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "vsphere",
+		Name:      "session_alive",
+		Help:      "Whether a session of the user on the vCenter was alive at the last health check.",
+	},
+	[]string{"vcenter", "user"},
+)
+
+// SetVSphereSessionAlive records whether a session of the given user on the
+// given vCenter was alive at the last health check.
+func SetVSphereSessionAlive(vcenter, user string, alive bool) {
+	if alive {
+		vsphereSessionAlive.WithLabelValues(vcenter, user).Set(1)
+		return
+	}
+	vsphereSessionAlive.WithLabelValues(vcenter, user).Set(0)
+}
+
+// ForgetVSphereSessionAlive stops reporting the sessions of the given user on
+// the given vCenter.
+func ForgetVSphereSessionAlive(vcenter, user string) {
+	vsphereSessionAlive.DeleteLabelValues(vcenter, user)
+}
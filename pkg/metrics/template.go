@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// TemplateLookupByName is the lookup label value for templates
+	// referenced by name or inventory path.
+	TemplateLookupByName = "name"
+
+	// TemplateLookupByInstanceUUID is the lookup label value for templates
+	// referenced by instance UUID.
+	TemplateLookupByInstanceUUID = "instance-uuid"
+)
+
+var (
+	templateLookups = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "vspherevm",
+			Name:      "template_lookups",
+			Help:      "Number of VSphereVMs by the kind of lookup used to find their template.",
+		},
+		[]string{"lookup"},
+	)
+
+	templateLookupsMu    sync.Mutex
+	templateLookupsByObj = map[apitypes.NamespacedName]string{}
+)
+
+// RecordTemplateLookup records the kind of template lookup used by the
+// VSphereVM with the given key.
+func RecordTemplateLookup(key apitypes.NamespacedName, lookup string) {
+	templateLookupsMu.Lock()
+	defer templateLookupsMu.Unlock()
+
+	if prev, ok := templateLookupsByObj[key]; ok {
+		if prev == lookup {
+			return
+		}
+		templateLookups.WithLabelValues(prev).Dec()
+	}
+	templateLookupsByObj[key] = lookup
+	templateLookups.WithLabelValues(lookup).Inc()
+}
+
+// ForgetTemplateLookup stops accounting for the VSphereVM with the given key.
+func ForgetTemplateLookup(key apitypes.NamespacedName) {
+	templateLookupsMu.Lock()
+	defer templateLookupsMu.Unlock()
+
+	if prev, ok := templateLookupsByObj[key]; ok {
+		templateLookups.WithLabelValues(prev).Dec()
+		delete(templateLookupsByObj, key)
+	}
+}
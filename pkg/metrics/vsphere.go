@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The operation label values of the vSphere API operation metrics.
+const (
+	VSphereOperationClone       = "clone"
+	VSphereOperationReconfigure = "reconfigure"
+	VSphereOperationPower       = "power"
+	VSphereOperationFind        = "find"
+	VSphereOperationTag         = "tag"
+	VSphereOperationCustomField = "custom_field"
+	VSphereOperationSnapshot    = "snapshot"
+	VSphereOperationRegister    = "register"
+)
+
+var (
+	vsphereOperationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "vsphere",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of the vSphere API operations issued by CAPV.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"operation", "vcenter"},
+	)
+
+	vsphereOperationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "vsphere",
+			Name:      "operation_errors_total",
+			Help:      "Number of vSphere API operations issued by CAPV that failed.",
+		},
+		[]string{"operation", "vcenter"},
+	)
+)
+
+// TrackVSphereOperation starts timing a vSphere API operation against the
+// given vCenter. The returned function must be called with the error of the
+// operation, if any, once the call returned.
+//
+// The duration is the one of the API call, not of the task it may start.
+func TrackVSphereOperation(operation, vcenter string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		vsphereOperationDuration.WithLabelValues(operation, vcenter).Observe(time.Since(start).Seconds())
+		if err != nil {
+			vsphereOperationErrors.WithLabelValues(operation, vcenter).Inc()
+		}
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTrackVSphereOperation(t *testing.T) {
+	g := NewWithT(t)
+
+	TrackVSphereOperation(VSphereOperationClone, "vcenter.test")(nil)
+	TrackVSphereOperation(VSphereOperationClone, "vcenter.test")(errors.New("boom"))
+	TrackVSphereOperation(VSphereOperationPower, "vcenter.test")(nil)
+
+	g.Expect(testutil.CollectAndCount(vsphereOperationDuration)).To(Equal(2))
+	g.Expect(testutil.ToFloat64(vsphereOperationErrors.WithLabelValues(VSphereOperationClone, "vcenter.test"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(vsphereOperationErrors.WithLabelValues(VSphereOperationPower, "vcenter.test"))).To(Equal(0.0))
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package privileges defines the vSphere privileges the controllers need for
+// the features a cluster uses, checks that a session has them, and renders
+// them as the definition of a vCenter role.
+package privileges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// Feature is an optional feature of a cluster requiring privileges of its
+// own.
+type Feature string
+
+const (
+	// ClusterModules is the anti-affinity of the machines through vCenter
+	// cluster modules, with the NodeAntiAffinity feature gate.
+	ClusterModules Feature = "cluster-modules"
+
+	// Tags is the attachment of tags to the VMs.
+	Tags Feature = "tags"
+
+	// ContentLibrary is the insertion of the ISO images of content libraries
+	// in the CD-ROM drives of the VMs.
+	ContentLibrary Feature = "content-library"
+
+	// StoragePolicies is the placement of the VMs on the datastores of a
+	// storage policy.
+	StoragePolicies Feature = "storage-policies"
+
+	// VMRecovery is the registration of the orphaned, inaccessible and
+	// invalid VMs again by the reregister and recreate recovery policies.
+	VMRecovery Feature = "vm-recovery"
+
+	// PortGroups is the creation of a distributed port group per cluster.
+	PortGroups Feature = "port-groups"
+
+	// FolderTemplates is the creation of the folders of the VMs whose folder
+	// is a template.
+	FolderTemplates Feature = "folder-templates"
+)
+
+// Features are all the optional features, in order.
+var Features = []Feature{ClusterModules, Tags, ContentLibrary, StoragePolicies, VMRecovery, PortGroups, FolderTemplates}
+
+// base are the privileges required to clone, configure, power and delete the
+// VMs of the machines, whatever the features of the cluster.
+var base = []string{
+	"Datastore.AllocateSpace",
+	"Datastore.Browse",
+	"Datastore.FileManagement",
+	"Network.Assign",
+	"Resource.AssignVMToPool",
+	"VirtualMachine.Config.AddExistingDisk",
+	"VirtualMachine.Config.AddNewDisk",
+	"VirtualMachine.Config.AddRemoveDevice",
+	"VirtualMachine.Config.AdvancedConfig",
+	"VirtualMachine.Config.CPUCount",
+	"VirtualMachine.Config.DiskExtend",
+	"VirtualMachine.Config.EditDevice",
+	"VirtualMachine.Config.Memory",
+	"VirtualMachine.Config.RemoveDisk",
+	"VirtualMachine.Config.Settings",
+	"VirtualMachine.Interact.PowerOff",
+	"VirtualMachine.Interact.PowerOn",
+	"VirtualMachine.Inventory.CreateFromExisting",
+	"VirtualMachine.Inventory.Delete",
+	"VirtualMachine.Provisioning.Clone",
+	"VirtualMachine.Provisioning.DeployTemplate",
+	"VirtualMachine.State.CreateSnapshot",
+}
+
+// featurePrivileges are the privileges required by each feature, in addition
+// to the base ones.
+var featurePrivileges = map[Feature][]string{
+	ClusterModules: {
+		"Host.Inventory.EditCluster",
+	},
+	Tags: {
+		"InventoryService.Tagging.AttachTag",
+		"InventoryService.Tagging.ObjectAttachable",
+	},
+	ContentLibrary: {
+		"ContentLibrary.ReadStorage",
+	},
+	StoragePolicies: {
+		"StorageProfile.View",
+	},
+	VMRecovery: {
+		"VirtualMachine.Inventory.Register",
+		"VirtualMachine.Inventory.Unregister",
+	},
+	PortGroups: {
+		"DVPortgroup.Create",
+		"DVPortgroup.Delete",
+		"DVPortgroup.Modify",
+		"DVPortgroup.PolicyOp",
+	},
+	FolderTemplates: {
+		"Folder.Create",
+	},
+}
+
+// ParseFeature returns the feature of a name.
+func ParseFeature(name string) (Feature, error) {
+	for _, feature := range Features {
+		if string(feature) == name {
+			return feature, nil
+		}
+	}
+	return "", errors.Errorf("unknown feature %q, the features are %s", name, strings.Join(featureNames(), ", "))
+}
+
+func featureNames() []string {
+	names := make([]string, 0, len(Features))
+	for _, feature := range Features {
+		names = append(names, string(feature))
+	}
+	return names
+}
+
+// Required returns the privileges required for a cluster using the features,
+// sorted.
+func Required(features ...Feature) []string {
+	privileges := sets.NewString(base...)
+	for _, feature := range features {
+		privileges.Insert(featurePrivileges[feature]...)
+	}
+	return privileges.List()
+}
+
+// Missing returns the privileges the user of a session lacks on the
+// entities, in order. A privilege is missing when it is not granted on all
+// the entities, or on the root folder if no entity is given.
+func Missing(ctx context.Context, s *session.Session, privileges []string, entities ...types.ManagedObjectReference) ([]string, error) {
+	userSession, err := s.SessionManager.UserSession(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get the vCenter session")
+	}
+	if userSession == nil {
+		return nil, errors.New("the vCenter session is not logged in")
+	}
+	if len(entities) == 0 {
+		entities = []types.ManagedObjectReference{s.Client.ServiceContent.RootFolder}
+	}
+
+	missing := sets.NewString()
+	authz := object.NewAuthorizationManager(s.Client.Client)
+	for _, entity := range entities {
+		granted, err := authz.HasPrivilegeOnEntity(ctx, entity, userSession.Key, privileges)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to check the privileges on %s", entity)
+		}
+		for i, privilege := range privileges {
+			if i >= len(granted) || !granted[i] {
+				missing.Insert(privilege)
+			}
+		}
+	}
+	return missing.List(), nil
+}
+
+// Role is the definition of a vCenter role.
+type Role struct {
+	Name       string   `json:"name"`
+	Privileges []string `json:"privileges"`
+}
+
+// Formats are the formats a role can be written in.
+var Formats = []string{"govc", "json"}
+
+// Write writes a role in a format, either as the govc command creating it or
+// as JSON.
+func (r Role) Write(w io.Writer, format string) error {
+	switch format {
+	case "govc":
+		privileges := append([]string(nil), r.Privileges...)
+		sort.Strings(privileges)
+		_, err := fmt.Fprintf(w, "govc role.create %s %s\n", r.Name, strings.Join(privileges, " "))
+		return err
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	default:
+		return errors.Errorf("unknown format %q, the formats are %s", format, strings.Join(Formats, ", "))
+	}
+}
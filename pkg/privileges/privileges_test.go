@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package privileges
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestRequired(t *testing.T) {
+	t.Run("the base privileges are always required", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(Required()).To(ConsistOf(base))
+	})
+
+	t.Run("the privileges of the features are added, sorted and without duplicates", func(t *testing.T) {
+		g := NewWithT(t)
+		privileges := Required(Tags, StoragePolicies, Tags)
+		g.Expect(privileges).To(ContainElements("InventoryService.Tagging.AttachTag", "StorageProfile.View"))
+		g.Expect(privileges).NotTo(ContainElement("Host.Inventory.EditCluster"))
+		g.Expect(privileges).To(HaveLen(len(base) + 3))
+		g.Expect(privileges).To(BeEquivalentTo(Required(StoragePolicies, Tags)))
+	})
+}
+
+func TestParseFeature(t *testing.T) {
+	g := NewWithT(t)
+
+	feature, err := ParseFeature("content-library")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(feature).To(Equal(ContentLibrary))
+
+	_, err = ParseFeature("unknown")
+	g.Expect(err).To(MatchError(ContainSubstring("cluster-modules, tags, content-library, storage-policies")))
+}
+
+func TestRoleWrite(t *testing.T) {
+	role := Role{Name: "capv", Privileges: []string{"Network.Assign", "Datastore.Browse"}}
+
+	t.Run("govc", func(t *testing.T) {
+		g := NewWithT(t)
+		var buf bytes.Buffer
+		g.Expect(role.Write(&buf, "govc")).To(Succeed())
+		g.Expect(buf.String()).To(Equal("govc role.create capv Datastore.Browse Network.Assign\n"))
+		g.Expect(role.Privileges).To(Equal([]string{"Network.Assign", "Datastore.Browse"}))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		g := NewWithT(t)
+		var buf bytes.Buffer
+		g.Expect(role.Write(&buf, "json")).To(Succeed())
+		decoded := Role{}
+		g.Expect(json.Unmarshal(buf.Bytes(), &decoded)).To(Succeed())
+		g.Expect(decoded).To(Equal(role))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(role.Write(&bytes.Buffer{}, "yaml")).NotTo(Succeed())
+	})
+}
+
+func TestMissing(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	params := session.NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password())
+	s, err := session.GetOrCreate(context.Background(), params)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The simulator grants all the privileges to its user.
+	missing, err := Missing(context.Background(), s, Required(Features...))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(missing).To(BeEmpty())
+
+	dc, err := s.Finder.DefaultDatacenter(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	missing, err = Missing(context.Background(), s, Required(), dc.Reference())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(missing).To(BeEmpty())
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimiter provides work queue rate limiters that share the
+// reconcile capacity of a controller fairly between workload clusters.
+package ratelimiter
+
+import (
+	goctx "context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultClusterQPS is the default rate at which the requests of a single
+	// cluster may be requeued.
+	DefaultClusterQPS = 10
+
+	// DefaultClusterBurst is the default number of requests of a single
+	// cluster that may be requeued at once.
+	DefaultClusterBurst = 100
+
+	// baseDelay and maxDelay bound the per item exponential backoff, and
+	// match the ones of the controller-runtime default rate limiter.
+	baseDelay = 5 * time.Millisecond
+	maxDelay  = 1000 * time.Second
+
+	// idleBucketTTL is the time after which the bucket of a cluster that has
+	// not requeued any request is dropped.
+	idleBucketTTL = 10 * time.Minute
+)
+
+// ClusterFunc returns the key of the cluster the object of a request belongs
+// to. Requests that share a key share a token bucket.
+type ClusterFunc func(req reconcile.Request) string
+
+// ClusterFair is a rate limiter that combines a per item exponential backoff
+// with a token bucket per cluster.
+//
+// The default controller rate limiter uses a single token bucket for all the
+// items of a queue, which lets a cluster with many failing machines consume
+// all the tokens and delay the requeues of every other cluster. Giving each
+// cluster its own bucket confines the delay to the cluster that causes it.
+type ClusterFair struct {
+	failures    workqueue.RateLimiter
+	clusterFunc ClusterFunc
+	limit       rate.Limit
+	burst       int
+	now         func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*clusterBucket
+	lastPrune time.Time
+}
+
+type clusterBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+var _ workqueue.RateLimiter = &ClusterFair{}
+
+// NewClusterFair returns a ClusterFair rate limiter allowing each cluster to
+// requeue qps requests per second, with bursts of up to burst requests.
+func NewClusterFair(qps float64, burst int, clusterFunc ClusterFunc) *ClusterFair {
+	if qps <= 0 {
+		qps = DefaultClusterQPS
+	}
+	if burst <= 0 {
+		burst = DefaultClusterBurst
+	}
+	return &ClusterFair{
+		failures:    workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		clusterFunc: clusterFunc,
+		limit:       rate.Limit(qps),
+		burst:       burst,
+		now:         time.Now,
+		buckets:     map[string]*clusterBucket{},
+	}
+}
+
+// When returns the time to wait before the item is requeued, which is the
+// longest of its exponential backoff and the delay imposed by the token
+// bucket of its cluster.
+func (r *ClusterFair) When(item interface{}) time.Duration {
+	delay := r.failures.When(item)
+	req, ok := item.(reconcile.Request)
+	if !ok {
+		return delay
+	}
+
+	now := r.now()
+	if bucketDelay := r.bucket(r.clusterFunc(req), now).ReserveN(now, 1).DelayFrom(now); bucketDelay > delay {
+		delay = bucketDelay
+	}
+	return delay
+}
+
+// Forget resets the exponential backoff of the item.
+func (r *ClusterFair) Forget(item interface{}) {
+	r.failures.Forget(item)
+}
+
+// NumRequeues returns the number of times the item failed.
+func (r *ClusterFair) NumRequeues(item interface{}) int {
+	return r.failures.NumRequeues(item)
+}
+
+func (r *ClusterFair) bucket(key string, now time.Time) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastPrune) > idleBucketTTL {
+		for k, b := range r.buckets {
+			if now.Sub(b.lastUsed) > idleBucketTTL {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastPrune = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &clusterBucket{limiter: rate.NewLimiter(r.limit, r.burst)}
+		r.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b.limiter
+}
+
+// ClusterFromLabel returns a ClusterFunc that reads the cluster name label of
+// the requested object from the given client. Objects that cannot be read or
+// that are not labelled share the bucket of their namespace.
+func ClusterFromLabel(c client.Reader, obj client.Object) ClusterFunc {
+	return func(req reconcile.Request) string {
+		o, _ := obj.DeepCopyObject().(client.Object)
+		if o != nil {
+			if err := c.Get(goctx.Background(), req.NamespacedName, o); err == nil {
+				if name, ok := o.GetLabels()[clusterv1.ClusterLabelName]; ok && name != "" {
+					return req.Namespace + "/" + name
+				}
+			}
+		}
+		return req.Namespace
+	}
+}
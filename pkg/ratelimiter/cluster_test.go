@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestClusterFair(t *testing.T) {
+	request := func(namespace, name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	}
+	byNamespace := func(req reconcile.Request) string { return req.Namespace }
+
+	t.Run("a busy cluster does not delay other clusters", func(t *testing.T) {
+		g := NewWithT(t)
+		now := time.Now()
+		r := NewClusterFair(1, 2, byNamespace)
+		r.now = func() time.Time { return now }
+
+		g.Expect(r.When(request("busy", "m0"))).To(BeNumerically("<", time.Second))
+		g.Expect(r.When(request("busy", "m1"))).To(BeNumerically("<", time.Second))
+		g.Expect(r.When(request("busy", "m2"))).To(BeNumerically(">=", time.Second))
+		g.Expect(r.When(request("busy", "m3"))).To(BeNumerically(">=", 2*time.Second))
+
+		g.Expect(r.When(request("quiet", "m0"))).To(BeNumerically("<", time.Second))
+	})
+
+	t.Run("failures back off per item", func(t *testing.T) {
+		g := NewWithT(t)
+		r := NewClusterFair(1000, 1000, byNamespace)
+		req := request("ns", "m0")
+
+		first := r.When(req)
+		second := r.When(req)
+		g.Expect(second).To(BeNumerically(">", first))
+		g.Expect(r.NumRequeues(req)).To(Equal(2))
+
+		r.Forget(req)
+		g.Expect(r.NumRequeues(req)).To(Equal(0))
+	})
+
+	t.Run("idle buckets are dropped", func(t *testing.T) {
+		g := NewWithT(t)
+		now := time.Now()
+		r := NewClusterFair(1, 1, byNamespace)
+		r.now = func() time.Time { return now }
+
+		r.When(request("a", "m0"))
+		r.When(request("b", "m0"))
+		g.Expect(r.buckets).To(HaveLen(2))
+
+		now = now.Add(2 * idleBucketTTL)
+		r.When(request("a", "m0"))
+		g.Expect(r.buckets).To(HaveLen(1))
+		g.Expect(r.buckets).To(HaveKey("a"))
+	})
+}
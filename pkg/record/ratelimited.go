@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package record
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// DefaultDedupInterval is the default interval during which a warning
+	// identical to one already recorded for the same object is dropped.
+	DefaultDedupInterval = 10 * time.Minute
+
+	// DefaultReasonQPS is the default rate at which the warnings with the
+	// same reason may be recorded across all objects.
+	DefaultReasonQPS = 1
+
+	// DefaultReasonBurst is the default number of warnings with the same
+	// reason that may be recorded at once across all objects.
+	DefaultReasonBurst = 25
+)
+
+// RateLimitOptions configures the rate limited EventRecorder.
+type RateLimitOptions struct {
+	// DedupInterval is the interval during which a warning identical to one
+	// already recorded for the same object is dropped.
+	DedupInterval time.Duration
+
+	// ReasonQPS is the rate at which the warnings with the same reason may be
+	// recorded across all objects.
+	ReasonQPS float64
+
+	// ReasonBurst is the number of warnings with the same reason that may be
+	// recorded at once across all objects.
+	ReasonBurst int
+}
+
+// NewRateLimitedEventRecorder returns an EventRecorder that deduplicates and
+// rate limits the warnings recorded through it before passing them on to the
+// given EventRecorder.
+//
+// A warning is dropped if an identical one was recorded for the same object
+// during the dedup interval, or if too many warnings with the same reason
+// were recorded recently, e.g. when hundreds of VMs wait for an IP address
+// during an incident. Normal events are never dropped.
+func NewRateLimitedEventRecorder(eventRecorder record.EventRecorder, opts RateLimitOptions) record.EventRecorder {
+	if opts.DedupInterval <= 0 {
+		opts.DedupInterval = DefaultDedupInterval
+	}
+	if opts.ReasonQPS <= 0 {
+		opts.ReasonQPS = DefaultReasonQPS
+	}
+	if opts.ReasonBurst <= 0 {
+		opts.ReasonBurst = DefaultReasonBurst
+	}
+	return &rateLimitedRecorder{
+		EventRecorder: eventRecorder,
+		opts:          opts,
+		now:           time.Now,
+		lastSeen:      map[string]time.Time{},
+		reasons:       map[string]*rate.Limiter{},
+	}
+}
+
+type rateLimitedRecorder struct {
+	record.EventRecorder
+	opts RateLimitOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	lastSeen  map[string]time.Time
+	reasons   map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+// Event records the event unless it is a duplicated or rate limited warning.
+func (r *rateLimitedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(object, eventtype, reason, message) {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf is just like Event, but with Sprintf for the message field.
+func (r *rateLimitedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf is just like Eventf, but with annotations attached.
+func (r *rateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.allow(object, eventtype, reason, message) {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+func (r *rateLimitedRecorder) allow(object runtime.Object, eventtype, reason, message string) bool {
+	if eventtype != corev1.EventTypeWarning {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.prune(now)
+
+	key := objectKey(object) + "/" + reason + "/" + message
+	if last, ok := r.lastSeen[key]; ok && now.Sub(last) < r.opts.DedupInterval {
+		return false
+	}
+
+	limiter, ok := r.reasons[reason]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(r.opts.ReasonQPS), r.opts.ReasonBurst)
+		r.reasons[reason] = limiter
+	}
+	if !limiter.AllowN(now, 1) {
+		return false
+	}
+
+	r.lastSeen[key] = now
+	return true
+}
+
+// prune drops the warnings that are no longer within the dedup interval.
+func (r *rateLimitedRecorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < r.opts.DedupInterval {
+		return
+	}
+	for key, last := range r.lastSeen {
+		if now.Sub(last) >= r.opts.DedupInterval {
+			delete(r.lastSeen, key)
+		}
+	}
+	r.lastPrune = now
+}
+
+func objectKey(object runtime.Object) string {
+	if object == nil {
+		return ""
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return fmt.Sprintf("%p", object)
+	}
+	if uid := accessor.GetUID(); uid != "" {
+		return string(uid)
+	}
+	return accessor.GetNamespace() + "/" + accessor.GetName()
+}
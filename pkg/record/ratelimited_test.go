@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package record_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apirecord "k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+)
+
+var _ = Describe("Rate limited event recorder", func() {
+	var (
+		fakeRecorder *apirecord.FakeRecorder
+		recorder     apirecord.EventRecorder
+		vm0, vm1     *corev1.ConfigMap
+	)
+
+	BeforeEach(func() {
+		fakeRecorder = apirecord.NewFakeRecorder(100)
+		recorder = record.NewRateLimitedEventRecorder(fakeRecorder, record.RateLimitOptions{ReasonBurst: 3})
+		vm0 = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm0", UID: "uid-0"}}
+		vm1 = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm1", UID: "uid-1"}}
+	})
+
+	It("should drop identical warnings for the same object", func() {
+		recorder.Event(vm0, corev1.EventTypeWarning, "WaitingForIP", "no ip")
+		recorder.Eventf(vm0, corev1.EventTypeWarning, "WaitingForIP", "no %s", "ip")
+		recorder.Event(vm1, corev1.EventTypeWarning, "WaitingForIP", "no ip")
+		recorder.Event(vm0, corev1.EventTypeWarning, "WaitingForIP", "still no ip")
+		Expect(fakeRecorder.Events).To(HaveLen(3))
+	})
+
+	It("should rate limit warnings with the same reason across objects", func() {
+		for i := 0; i < 10; i++ {
+			recorder.Eventf(vm0, corev1.EventTypeWarning, "WaitingForIP", "attempt %d", i)
+		}
+		recorder.Event(vm0, corev1.EventTypeWarning, "CloneFailure", "boom")
+		Expect(fakeRecorder.Events).To(HaveLen(4))
+	})
+
+	It("should never drop normal events", func() {
+		for i := 0; i < 10; i++ {
+			recorder.Event(vm0, corev1.EventTypeNormal, "Created", "created")
+		}
+		Expect(fakeRecorder.Events).To(HaveLen(10))
+		Expect(<-fakeRecorder.Events).To(Equal(fmt.Sprintf("%s Created created", corev1.EventTypeNormal)))
+	})
+})
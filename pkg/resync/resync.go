@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resync spreads the periodic reconciles triggered by the informer
+// resyncs over time.
+package resync
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// DefaultSpacing is the default minimum time between the periodic reconciles
+// of two objects.
+const DefaultSpacing = 100 * time.Millisecond
+
+// Handler queues the reconciles of the informer resyncs, which deliver an
+// update event for every object at once, each after a delay derived from the
+// UID of its object.
+//
+// The objects are reconciled once per sync period. When the sync period is
+// longer than the ResyncPeriod of the informer, the resyncs are skipped until
+// the sync period elapsed since the object was last queued. When it is
+// shorter, the object is queued again every sync period until the next
+// resync. The informers of a manager thus resync at the sync period of the
+// manager, while the controllers sharing them are reconciled at their own
+// period.
+//
+// The delays are spread over a window that grows with the number of objects,
+// by Spacing per object, up to the sync period. A handful of objects are
+// reconciled almost at once, while thousands of objects are reconciled over
+// the whole sync period instead of all at the same instant, which would
+// otherwise issue as many concurrent calls to vCenter. The delay of an
+// object is the same on every resync, so that it is still reconciled once
+// per sync period.
+type Handler struct {
+	// Spacing is the minimum time between the periodic reconciles of two
+	// objects.
+	Spacing time.Duration
+
+	// ResyncPeriod is the period of the resyncs of the informer. Defaults to
+	// the sync period.
+	ResyncPeriod time.Duration
+
+	controller string
+	period     time.Duration
+	now        func() time.Time
+	after      func(time.Duration, func())
+
+	mu sync.Mutex
+	// uids are the times at which the objects were last queued, or created.
+	uids map[types.UID]time.Time
+}
+
+var _ handler.EventHandler = &Handler{}
+
+// NewHandler returns a Handler spreading the periodic reconciles of the
+// objects of a controller over at most the given sync period.
+func NewHandler(controller string, period time.Duration) *Handler {
+	return &Handler{
+		Spacing:    DefaultSpacing,
+		controller: controller,
+		period:     period,
+		now:        time.Now,
+		after:      func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+		uids:       map[types.UID]time.Time{},
+	}
+}
+
+// Create counts the object. The object itself is queued by the handler of
+// the controlled type, and again until the first resync if the sync period is
+// shorter than the resyncs.
+func (h *Handler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.mu.Lock()
+	h.uids[e.Object.GetUID()] = h.now()
+	h.mu.Unlock()
+
+	h.requeue(e.Object.GetUID(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(e.Object)}, 0, q)
+}
+
+// Update queues the object after its delay if the event is a resync.
+func (h *Handler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if !IsResync(e) {
+		return
+	}
+
+	h.mu.Lock()
+	now := h.now()
+	if last, ok := h.uids[e.ObjectNew.GetUID()]; ok && !h.due(now.Sub(last)) {
+		h.mu.Unlock()
+		return
+	}
+	h.uids[e.ObjectNew.GetUID()] = now
+	objects := len(h.uids)
+	h.mu.Unlock()
+
+	window := h.window(objects)
+	delay := Delay(e.ObjectNew.GetUID(), window)
+	metrics.ObserveResync(h.controller, delay, window, objects)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(e.ObjectNew)}
+	q.AddAfter(req, delay)
+	h.requeue(e.ObjectNew.GetUID(), req, delay, q)
+}
+
+// Delete stops counting the object.
+func (h *Handler) Delete(e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.uids, e.Object.GetUID())
+}
+
+// Generic does nothing.
+func (h *Handler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {}
+
+// requeue queues an object every sync period after the given delay, until
+// the next resync of the informer, if the sync period is shorter than the
+// resyncs. The requeues are timed by the handler rather than added after a
+// delay to the queue, which only keeps the earliest of the delays of an
+// object. The requeues of a deleted object are dropped.
+func (h *Handler) requeue(uid types.UID, req reconcile.Request, delay time.Duration, q workqueue.RateLimitingInterface) {
+	if h.period <= 0 || h.ResyncPeriod <= 0 || h.period >= h.ResyncPeriod {
+		return
+	}
+	for next := delay + h.period; next < delay+h.ResyncPeriod; next += h.period {
+		h.after(next, func() {
+			h.mu.Lock()
+			_, ok := h.uids[uid]
+			h.mu.Unlock()
+			if ok {
+				q.Add(req)
+			}
+		})
+	}
+}
+
+// due returns true if an object last queued the given time ago is due for a
+// periodic reconcile. The resyncs of the informer do not happen at exactly
+// the same interval, so the object is due half a resync period early.
+func (h *Handler) due(elapsed time.Duration) bool {
+	if h.ResyncPeriod <= 0 || h.period <= h.ResyncPeriod {
+		return true
+	}
+	return elapsed >= h.period-h.ResyncPeriod/2
+}
+
+// window returns the window over which the reconciles of the given number of
+// objects are spread.
+func (h *Handler) window(objects int) time.Duration {
+	window := time.Duration(objects) * h.Spacing
+	if h.period > 0 && window > h.period {
+		window = h.period
+	}
+	return window
+}
+
+// Delay returns the delay of the periodic reconcile of the object with the
+// given UID in a window, which is the same for as long as the window does
+// not change.
+func Delay(uid types.UID, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(uid))
+	return time.Duration(h.Sum64() % uint64(window))
+}
+
+// IsResync returns true if an update event was delivered by an informer
+// resync rather than by a change of the object.
+func IsResync(e event.UpdateEvent) bool {
+	return e.ObjectOld != nil && e.ObjectNew != nil &&
+		e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion()
+}
+
+// SkipResyncs returns a predicate filtering out the update events of the
+// informer resyncs, so that they are only queued by a Handler.
+func SkipResyncs() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !IsResync(e)
+		},
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// delayingQueue records the delays of the requests added after a delay, and
+// counts the requests added at once.
+type delayingQueue struct {
+	workqueue.RateLimitingInterface
+	delays map[interface{}]time.Duration
+	adds   int
+}
+
+func (q *delayingQueue) Add(interface{}) {
+	q.adds++
+}
+
+func (q *delayingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delays[item] = duration
+}
+
+func newVM(i int, resourceVersion string) *infrav1.VSphereVM {
+	return &infrav1.VSphereVM{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            fmt.Sprintf("vm-%d", i),
+			UID:             types.UID(fmt.Sprintf("2c7ef4a4-6a4b-4d2b-9f0c-%012d", i)),
+			ResourceVersion: resourceVersion,
+		},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	const objects = 1000
+
+	t.Run("resyncs are spread over a window growing with the number of objects", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", 10*time.Minute)
+		q := &delayingQueue{delays: map[interface{}]time.Duration{}}
+
+		for i := 0; i < objects; i++ {
+			h.Create(event.CreateEvent{Object: newVM(i, "1")}, q)
+		}
+		for i := 0; i < objects; i++ {
+			h.Update(event.UpdateEvent{ObjectOld: newVM(i, "1"), ObjectNew: newVM(i, "1")}, q)
+		}
+		g.Expect(q.delays).To(HaveLen(objects))
+
+		window := objects * DefaultSpacing
+		buckets := make([]int, 10)
+		for _, delay := range q.delays {
+			g.Expect(delay).To(BeNumerically(">=", 0))
+			g.Expect(delay).To(BeNumerically("<", window))
+			buckets[delay*time.Duration(len(buckets))/window]++
+		}
+		// Each tenth of the window gets about a tenth of the reconciles.
+		for _, n := range buckets {
+			g.Expect(n).To(BeNumerically("~", objects/len(buckets), objects/len(buckets)/2))
+		}
+	})
+
+	t.Run("the window is bounded by the sync period", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", time.Minute)
+		g.Expect(h.window(objects)).To(Equal(time.Minute))
+		g.Expect(h.window(10)).To(Equal(time.Second))
+	})
+
+	t.Run("the delay of an object does not change", func(t *testing.T) {
+		g := NewWithT(t)
+		uid := newVM(0, "1").UID
+		g.Expect(Delay(uid, time.Minute)).To(Equal(Delay(uid, time.Minute)))
+		g.Expect(Delay(uid, 0)).To(BeZero())
+	})
+
+	t.Run("resyncs are skipped until the sync period elapsed", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", 30*time.Minute)
+		h.ResyncPeriod = 10 * time.Minute
+		now := time.Now()
+		h.now = func() time.Time { return now }
+		q := &delayingQueue{delays: map[interface{}]time.Duration{}}
+		resync := func(after time.Duration) bool {
+			now = now.Add(after)
+			q.delays = map[interface{}]time.Duration{}
+			h.Update(event.UpdateEvent{ObjectOld: newVM(0, "1"), ObjectNew: newVM(0, "1")}, q)
+			return len(q.delays) > 0
+		}
+
+		h.Create(event.CreateEvent{Object: newVM(0, "1")}, q)
+		g.Expect(resync(10 * time.Minute)).To(BeFalse())
+		g.Expect(resync(10 * time.Minute)).To(BeFalse())
+		// The resyncs are a little early.
+		g.Expect(resync(9 * time.Minute)).To(BeTrue())
+		g.Expect(resync(10 * time.Minute)).To(BeFalse())
+
+		// Every resync is queued for a sync period shorter than the resyncs.
+		h = NewHandler("test", 5*time.Minute)
+		h.ResyncPeriod = 10 * time.Minute
+		h.after = func(time.Duration, func()) {}
+		h.Create(event.CreateEvent{Object: newVM(0, "1")}, q)
+		g.Expect(resync(0)).To(BeTrue())
+	})
+
+	t.Run("objects are requeued between the resyncs for a shorter sync period", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", 3*time.Minute)
+		h.ResyncPeriod = 10 * time.Minute
+		var afters []time.Duration
+		var requeues []func()
+		h.after = func(d time.Duration, f func()) {
+			afters = append(afters, d)
+			requeues = append(requeues, f)
+		}
+		q := &delayingQueue{delays: map[interface{}]time.Duration{}}
+
+		h.Create(event.CreateEvent{Object: newVM(0, "1")}, q)
+		g.Expect(afters).To(Equal([]time.Duration{3 * time.Minute, 6 * time.Minute, 9 * time.Minute}))
+
+		afters = nil
+		h.Update(event.UpdateEvent{ObjectOld: newVM(0, "1"), ObjectNew: newVM(0, "1")}, q)
+		delay := q.delays[reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "vm-0"}}]
+		g.Expect(afters).To(Equal([]time.Duration{delay + 3*time.Minute, delay + 6*time.Minute, delay + 9*time.Minute}))
+
+		// The requeues of a deleted object are dropped.
+		h.Delete(event.DeleteEvent{Object: newVM(0, "1")}, q)
+		for _, requeue := range requeues {
+			requeue()
+		}
+		g.Expect(q.adds).To(BeZero())
+	})
+
+	t.Run("objects are not requeued for a sync period longer than the resyncs", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", 30*time.Minute)
+		h.ResyncPeriod = 10 * time.Minute
+		h.after = func(time.Duration, func()) { t.Fatal("unexpected requeue") }
+		q := &delayingQueue{delays: map[interface{}]time.Duration{}}
+
+		h.Create(event.CreateEvent{Object: newVM(0, "1")}, q)
+		g.Expect(q.delays).To(BeEmpty())
+	})
+
+	t.Run("changes and deleted objects are not queued", func(t *testing.T) {
+		g := NewWithT(t)
+		h := NewHandler("test", time.Minute)
+		q := &delayingQueue{delays: map[interface{}]time.Duration{}}
+
+		h.Create(event.CreateEvent{Object: newVM(0, "1")}, q)
+		h.Create(event.CreateEvent{Object: newVM(1, "1")}, q)
+		h.Update(event.UpdateEvent{ObjectOld: newVM(0, "1"), ObjectNew: newVM(0, "2")}, q)
+		g.Expect(q.delays).To(BeEmpty())
+
+		h.Delete(event.DeleteEvent{Object: newVM(1, "1")}, q)
+		g.Expect(h.uids).To(HaveLen(1))
+	})
+}
+
+func TestSkipResyncs(t *testing.T) {
+	g := NewWithT(t)
+	p := SkipResyncs()
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newVM(0, "1"), ObjectNew: newVM(0, "1")})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newVM(0, "1"), ObjectNew: newVM(0, "2")})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: newVM(0, "1")})).To(BeTrue())
+}
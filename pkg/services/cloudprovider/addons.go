@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/packaging/flavorgen/flavors/crs/types"
+)
+
+const (
+	// CloudControllerManagerCredentialsSecretName is the name of the secret
+	// of the workload cluster holding the vCenter credentials of the cloud
+	// controller manager.
+	CloudControllerManagerCredentialsSecretName = "cloud-provider-vsphere-credentials"
+)
+
+// CloudControllerManagerCredentialsSecret returns the Secret holding the
+// vCenter credentials of the cloud-controller-manager, keyed by
+// <server>.username and <server>.password.
+func CloudControllerManagerCredentialsSecret(credentials map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CloudControllerManagerCredentialsSecretName,
+			Namespace: metav1.NamespaceSystem,
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: credentials,
+	}
+}
+
+// CloudControllerManagerManifests returns the manifests of the vSphere cloud
+// controller manager connecting to the vCenters of the configuration, whose
+// credentials are stored in a secret of the workload cluster.
+func CloudControllerManagerManifests(image string, config *types.CPIConfig) ([]byte, error) {
+	if image == "" {
+		image = DefaultCPIControllerImage
+	}
+
+	credentials := map[string]string{}
+	vcenters := map[string]interface{}{}
+	for server, vcenter := range config.VCenter {
+		credentials[fmt.Sprintf("%s.username", server)] = vcenter.Username
+		credentials[fmt.Sprintf("%s.password", server)] = vcenter.Password
+		vcenterConfig := map[string]interface{}{
+			"server":          server,
+			"datacenters":     strings.Split(vcenter.Datacenters, ","),
+			"thumbprint":      vcenter.Thumbprint,
+			"secretName":      CloudControllerManagerCredentialsSecretName,
+			"secretNamespace": metav1.NamespaceSystem,
+		}
+		if vcenter.Port != "" {
+			vcenterConfig["port"] = vcenter.Port
+		}
+		vcenters[server] = vcenterConfig
+	}
+	cloudConfig := map[string]interface{}{
+		"global": map[string]interface{}{
+			"secretName":      CloudControllerManagerCredentialsSecretName,
+			"secretNamespace": metav1.NamespaceSystem,
+			"insecureFlag":    config.Global.Insecure,
+		},
+		"vcenter": vcenters,
+	}
+	if config.Labels.Region != "" && config.Labels.Zone != "" {
+		cloudConfig["labels"] = map[string]interface{}{
+			"region": config.Labels.Region,
+			"zone":   config.Labels.Zone,
+		}
+	}
+	data, err := yaml.Marshal(cloudConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the cloud controller manager configuration")
+	}
+
+	args := []string{
+		"--v=2",
+		"--cloud-provider=vsphere",
+		"--cloud-config=/etc/cloud/vsphere.conf",
+	}
+	return Manifests(
+		CloudControllerManagerServiceAccount(),
+		CloudControllerManagerCredentialsSecret(credentials),
+		CloudControllerManagerClusterRole(),
+		CloudControllerManagerClusterRoleBinding(),
+		CloudControllerManagerRoleBinding(),
+		CloudControllerManagerConfigMap(string(data)),
+		CloudControllerManagerService(),
+		CloudControllerManagerDaemonSet(image, args),
+	)
+}
+
+// CSIManifests returns the manifests of the vSphere CSI driver using the
+// configuration, which holds the vCenter credentials of the driver.
+func CSIManifests(driverImage, syncerImage string, config *types.CPIConfig) ([]byte, error) {
+	if driverImage == "" {
+		driverImage = DefaultCSIControllerImage
+	}
+	if syncerImage == "" {
+		syncerImage = DefaultCSIMetadataSyncerImage
+	}
+	storageConfig := &types.CPIStorageConfig{
+		ControllerImage:     driverImage,
+		NodeDriverImage:     driverImage,
+		AttacherImage:       DefaultCSIAttacherImage,
+		ProvisionerImage:    DefaultCSIProvisionerImage,
+		MetadataSyncerImage: syncerImage,
+		LivenessProbeImage:  DefaultCSILivenessProbeImage,
+		RegistrarImage:      DefaultCSIRegistrarImage,
+	}
+
+	data, err := config.MarshalINI()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the CSI configuration")
+	}
+
+	// The CSIDriver is served as storage.k8s.io/v1 by the Kubernetes versions
+	// the driver supports, whose spec is the same as the v1beta1 one.
+	csiDriver := CSIDriver()
+	csiDriver.TypeMeta = metav1.TypeMeta{
+		Kind:       "CSIDriver",
+		APIVersion: storagev1.SchemeGroupVersion.String(),
+	}
+	return Manifests(
+		CSIControllerServiceAccount(),
+		CSIControllerClusterRole(),
+		CSIControllerClusterRoleBinding(),
+		CSICloudConfigSecret(string(data)),
+		csiDriver,
+		VSphereCSINodeDaemonSet(storageConfig),
+		CSIControllerDeployment(storageConfig),
+	)
+}
+
+// Manifests returns the YAML documents of the objects, in order. The kind of
+// the objects which do not set one is looked up in the client-go scheme.
+func Manifests(objs ...runtime.Object) ([]byte, error) {
+	docs := make([][]byte, 0, len(objs))
+	for _, obj := range objs {
+		if obj.GetObjectKind().GroupVersionKind().Empty() {
+			gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the kind of %T", obj)
+			}
+			obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %T", obj)
+		}
+		docs = append(docs, doc)
+	}
+	return bytes.Join(docs, []byte("---\n")), nil
+}
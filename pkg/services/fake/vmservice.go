@@ -36,3 +36,8 @@ func (v *VMService) DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine, e
 	args := v.Called(ctx)
 	return args.Get(0).(infrav1.VirtualMachine), args.Error(1)
 }
+
+func (v *VMService) GetVM(ctx *context.VMContext) (infrav1.VirtualMachine, error) {
+	args := v.Called(ctx)
+	return args.Get(0).(infrav1.VirtualMachine), args.Error(1)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cdrom inserts ISO images in the CD-ROM drives of VMs and ejects
+// them.
+package cdrom
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/exp/slices"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// libraryItemStoragePath is the path of the content library API returning
+// the storage of the files of a library item.
+const libraryItemStoragePath = "/com/vmware/content/library/item/storage"
+
+// libraryItemStorage is the storage of a file of a library item.
+type libraryItemStorage struct {
+	Name           string `json:"name"`
+	StorageBacking struct {
+		DatastoreID string `json:"datastore_id"`
+	} `json:"storage_backing"`
+	StorageURIs []string `json:"storage_uris"`
+}
+
+// ResolveISO returns the datastore path of the ISO image of a CD-ROM spec.
+// The ISO image of a content library item is the file of the item stored on
+// the datastore backing its library.
+func ResolveISO(ctx context.Context, s *session.Session, cdrom infrav1.CDROMSpec) (string, error) {
+	if cdrom.ISOPath != "" {
+		return cdrom.ISOPath, nil
+	}
+
+	libraryName, itemName, ok := strings.Cut(cdrom.ContentLibraryItem, "/")
+	if !ok {
+		return "", errors.Errorf("invalid content library item %q", cdrom.ContentLibraryItem)
+	}
+	m := library.NewManager(s.TagManager.Client)
+	lib, err := m.GetLibraryByName(ctx, libraryName)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find the content library %q", libraryName)
+	}
+	itemIDs, err := m.FindLibraryItems(ctx, library.FindItem{LibraryID: lib.ID, Name: itemName})
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find the item %q of the content library %q", itemName, libraryName)
+	}
+	if len(itemIDs) == 0 {
+		return "", errors.Errorf("no item %q in the content library %q", itemName, libraryName)
+	}
+
+	var storage []libraryItemStorage
+	url := m.Resource(libraryItemStoragePath).WithParam("library_item_id", itemIDs[0])
+	if err := m.Do(ctx, url.Request(http.MethodGet), &storage); err != nil {
+		return "", errors.Wrapf(err, "unable to get the storage of the content library item %q", cdrom.ContentLibraryItem)
+	}
+	for _, file := range storage {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".iso") || len(file.StorageURIs) == 0 {
+			continue
+		}
+		var ds mo.Datastore
+		dsRef := types.ManagedObjectReference{Type: "Datastore", Value: file.StorageBacking.DatastoreID}
+		if err := s.RetrieveOne(ctx, dsRef, []string{"name", "summary.url"}, &ds); err != nil {
+			return "", errors.Wrapf(err, "unable to get the datastore of the content library item %q", cdrom.ContentLibraryItem)
+		}
+		dsURL := strings.TrimSuffix(ds.Summary.Url, "/") + "/"
+		if !strings.HasPrefix(file.StorageURIs[0], dsURL) {
+			return "", errors.Errorf("the storage URI %q of the content library item %q is not on the datastore %s", file.StorageURIs[0], cdrom.ContentLibraryItem, ds.Name)
+		}
+		return (&object.DatastorePath{Datastore: ds.Name, Path: strings.TrimPrefix(file.StorageURIs[0], dsURL)}).String(), nil
+	}
+	return "", errors.Errorf("no ISO image in the content library item %q", cdrom.ContentLibraryItem)
+}
+
+// InsertSpecs returns the device changes inserting the ISO images in CD-ROM
+// drives of a VM with the given devices. The existing drives which have no
+// ISO image inserted are used first, then drives are added to the IDE
+// controllers of the VM, or to its SATA controllers once they are full or
+// if it has none, e.g. for the arm64 VMs.
+func InsertSpecs(devices object.VirtualDeviceList, isoFiles []string) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	var free []*types.VirtualCdrom
+	for _, device := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		cdrom := device.(*types.VirtualCdrom)
+		if _, ok := cdrom.Backing.(*types.VirtualCdromIsoBackingInfo); !ok {
+			free = append(free, cdrom)
+		}
+	}
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	for _, isoFile := range isoFiles {
+		operation := types.VirtualDeviceConfigSpecOperationEdit
+		var cdrom *types.VirtualCdrom
+		if len(free) > 0 {
+			cdrom, free = free[0], free[1:]
+		} else {
+			controller := freeController(devices)
+			if controller == nil {
+				return nil, errors.Errorf("unable to add a CD-ROM drive for %s: no IDE or SATA controller with a free unit", isoFile)
+			}
+			cdrom = &types.VirtualCdrom{}
+			devices.AssignController(cdrom, controller)
+			// Account for the new drive, so that the next ones are added to
+			// the free units of the controllers.
+			controller.GetVirtualController().Device = append(controller.GetVirtualController().Device, cdrom.Key)
+			devices = append(devices, cdrom)
+			operation = types.VirtualDeviceConfigSpecOperationAdd
+		}
+		devices.InsertIso(cdrom, isoFile)
+		cdrom.Connectable = &types.VirtualDeviceConnectInfo{AllowGuestControl: true, StartConnected: true}
+		specs = append(specs, &types.VirtualDeviceConfigSpec{Operation: operation, Device: cdrom})
+	}
+	return specs, nil
+}
+
+// sataControllerUnits is the number of devices a SATA controller can hold.
+const sataControllerUnits = 30
+
+// freeController returns a controller of a VM with the given devices which
+// has a free unit for a CD-ROM drive, if any. The IDE controllers are used
+// first, as the drives of the templates usually are.
+func freeController(devices object.VirtualDeviceList) types.BaseVirtualController {
+	if ide, err := devices.FindIDEController(""); err == nil {
+		return ide
+	}
+	for _, device := range devices.SelectByType((*types.VirtualSATAController)(nil)) {
+		if sata := device.(types.BaseVirtualController); len(sata.GetVirtualController().Device) < sataControllerUnits {
+			return sata
+		}
+	}
+	return nil
+}
+
+// EjectSpecs returns the device changes ejecting the ISO images from the
+// CD-ROM drives of a VM with the given devices. The drives are left in place,
+// backed by the client device and disconnected.
+func EjectSpecs(devices object.VirtualDeviceList, isoFiles []string) []types.BaseVirtualDeviceConfigSpec {
+	var specs []types.BaseVirtualDeviceConfigSpec
+	for _, device := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		cdrom := device.(*types.VirtualCdrom)
+		backing, ok := cdrom.Backing.(*types.VirtualCdromIsoBackingInfo)
+		if !ok || !slices.Contains(isoFiles, backing.FileName) {
+			continue
+		}
+		cdrom.Backing = &types.VirtualCdromRemotePassthroughBackingInfo{}
+		cdrom.Connectable = &types.VirtualDeviceConnectInfo{AllowGuestControl: true}
+		specs = append(specs, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    cdrom,
+		})
+	}
+	return specs
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdrom
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func newDevices() object.VirtualDeviceList {
+	ide0 := &types.VirtualIDEController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 200}, Device: []int32{3000}}}
+	ide1 := &types.VirtualIDEController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 201}, BusNumber: 1}}
+	cdrom := &types.VirtualCdrom{VirtualDevice: types.VirtualDevice{
+		Key:           3000,
+		ControllerKey: 200,
+		UnitNumber:    new(int32),
+		Backing:       &types.VirtualCdromRemotePassthroughBackingInfo{},
+	}}
+	return object.VirtualDeviceList{ide0, ide1, cdrom}
+}
+
+func isoFile(spec types.BaseVirtualDeviceConfigSpec) string {
+	return spec.GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Backing.(*types.VirtualCdromIsoBackingInfo).FileName
+}
+
+func TestInsertSpecs(t *testing.T) {
+	t.Run("uses the existing drives first", func(t *testing.T) {
+		g := NewWithT(t)
+		specs, err := InsertSpecs(newDevices(), []string{"[ds] a.iso", "[ds] b.iso", "[ds] c.iso"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(specs).To(HaveLen(3))
+
+		g.Expect(specs[0].GetVirtualDeviceConfigSpec().Operation).To(Equal(types.VirtualDeviceConfigSpecOperationEdit))
+		g.Expect(specs[0].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Key).To(Equal(int32(3000)))
+		g.Expect(isoFile(specs[0])).To(Equal("[ds] a.iso"))
+
+		var units []int32
+		for _, spec := range specs[1:] {
+			device := spec.GetVirtualDeviceConfigSpec().Device.GetVirtualDevice()
+			g.Expect(spec.GetVirtualDeviceConfigSpec().Operation).To(Equal(types.VirtualDeviceConfigSpecOperationAdd))
+			g.Expect(device.Connectable.StartConnected).To(BeTrue())
+			units = append(units, device.ControllerKey*10+*device.UnitNumber)
+		}
+		g.Expect(units).To(Equal([]int32{2001, 2010}))
+		g.Expect(isoFile(specs[1])).To(Equal("[ds] b.iso"))
+		g.Expect(isoFile(specs[2])).To(Equal("[ds] c.iso"))
+	})
+
+	t.Run("fails when the IDE controllers are full", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := InsertSpecs(newDevices(), []string{"[ds] a.iso", "[ds] b.iso", "[ds] c.iso", "[ds] d.iso", "[ds] e.iso"})
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("adds drives to the SATA controllers without IDE controllers", func(t *testing.T) {
+		g := NewWithT(t)
+		// The arm64 VMs have no IDE controllers.
+		sata := &types.VirtualAHCIController{VirtualSATAController: types.VirtualSATAController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 15000}}}}
+		specs, err := InsertSpecs(object.VirtualDeviceList{sata}, []string{"[ds] a.iso", "[ds] b.iso"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(specs).To(HaveLen(2))
+
+		var units []int32
+		for _, spec := range specs {
+			device := spec.GetVirtualDeviceConfigSpec().Device.GetVirtualDevice()
+			g.Expect(spec.GetVirtualDeviceConfigSpec().Operation).To(Equal(types.VirtualDeviceConfigSpecOperationAdd))
+			g.Expect(device.ControllerKey).To(Equal(int32(15000)))
+			units = append(units, *device.UnitNumber)
+		}
+		g.Expect(units).To(Equal([]int32{0, 1}))
+		g.Expect(isoFile(specs[1])).To(Equal("[ds] b.iso"))
+	})
+}
+
+func TestEjectSpecs(t *testing.T) {
+	g := NewWithT(t)
+
+	devices := newDevices()
+	specs, err := InsertSpecs(devices, []string{"[ds] a.iso", "[ds] b.iso"})
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, spec := range specs {
+		if spec.GetVirtualDeviceConfigSpec().Operation == types.VirtualDeviceConfigSpecOperationAdd {
+			devices = append(devices, spec.GetVirtualDeviceConfigSpec().Device)
+		}
+	}
+
+	specs = EjectSpecs(devices, []string{"[ds] b.iso"})
+	g.Expect(specs).To(HaveLen(1))
+	device := specs[0].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice()
+	g.Expect(device.Backing).To(BeAssignableToTypeOf(&types.VirtualCdromRemotePassthroughBackingInfo{}))
+	g.Expect(device.Connectable.StartConnected).To(BeFalse())
+
+	g.Expect(EjectSpecs(devices, []string{"[ds] b.iso"})).To(BeEmpty())
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+const (
+	// cloneTaskDescriptionID is the description ID of the tasks cloning VMs.
+	cloneTaskDescriptionID = "VirtualMachine.clone"
+
+	// defaultTaskRetryBackoff is the delay before a failed task is retried.
+	defaultTaskRetryBackoff = time.Minute
+
+	// maxCloneRetryBackoff caps the backoff of the clone retry policies.
+	maxCloneRetryBackoff = 10 * time.Minute
+)
+
+// isCloneTask returns whether a task clones a VM.
+func isCloneTask(task *mo.Task) bool {
+	return task.Info.DescriptionId == cloneTaskDescriptionID
+}
+
+// taskRetryBackoff returns the delay before a failed task of a VSphereVM is
+// retried. The delay of the failed clone tasks of the VSphereVMs with a clone
+// retry policy doubles at each attempt.
+func taskRetryBackoff(vm *infrav1.VSphereVM, task *mo.Task) time.Duration {
+	policy := vm.Spec.CloneRetryPolicy
+	if policy == nil || !isCloneTask(task) {
+		return defaultTaskRetryBackoff
+	}
+	backoff := defaultTaskRetryBackoff
+	if policy.Backoff != nil && policy.Backoff.Duration > 0 {
+		backoff = policy.Backoff.Duration
+	}
+	for i := int32(1); i < vm.Status.CloneAttempts && backoff < maxCloneRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxCloneRetryBackoff {
+		backoff = maxCloneRetryBackoff
+	}
+	return backoff
+}
+
+// cloneAttemptsExhausted returns whether the VM of a VSphereVM was cloned as
+// many times as its clone retry policy allows.
+func cloneAttemptsExhausted(vm *infrav1.VSphereVM) bool {
+	policy := vm.Spec.CloneRetryPolicy
+	return policy != nil && policy.MaxAttempts > 0 && vm.Status.CloneAttempts >= policy.MaxAttempts
+}
+
+// reconcileFailedClone deletes the VM left in vCenter by a failed clone task
+// when the clone is due to be retried, if the clone retry policy of the
+// VSphereVM says so. It returns whether the VM is being deleted, in which case
+// the deletion task is tracked as the in-flight task of the VSphereVM.
+func reconcileFailedClone(ctx *context.VMContext, task *mo.Task) (bool, error) {
+	policy := ctx.VSphereVM.Spec.CloneRetryPolicy
+	if task == nil || task.Info.State != types.TaskInfoStateError || !isCloneTask(task) ||
+		policy == nil || !policy.DeleteFailedVM || cloneAttemptsExhausted(ctx.VSphereVM) ||
+		ctx.VSphereVM.Status.RetryAfter.IsZero() || time.Now().Before(ctx.VSphereVM.Status.RetryAfter.Time) {
+		return false, nil
+	}
+
+	vmRef, err := findVM(ctx)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	ctx.Logger.Info("deleting the VM left by the failed clone task before retrying", "vmref", vmRef)
+	destroyTask, err := object.NewVirtualMachine(ctx.Session.Client.Client, vmRef).Destroy(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to delete the VM left by the failed clone task of %s", ctx)
+	}
+	ctx.VSphereVM.Status.TaskRef = destroyTask.Reference().Value
+	ctx.VSphereVM.Status.RetryAfter = metav1.Time{}
+	return true, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/cloudinit"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// injectCloudConfigs returns the bootstrap data merged with the cloud-configs
+// installing the guest agent and configuring the proxy and the NTP servers of
+// the VSphereVM.
+func injectCloudConfigs(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Format) ([]byte, error) {
+	bootstrapData, err := injectGuestAgent(ctx, bootstrapData, format)
+	if err != nil {
+		return nil, err
+	}
+	if bootstrapData, err = injectProxy(ctx, bootstrapData, format); err != nil {
+		return nil, err
+	}
+	return injectNTP(ctx, bootstrapData, format)
+}
+
+// injectProxy returns the bootstrap data configuring the proxy of the
+// VSphereVM in the guest, if any. Only cloud-init bootstrap data is
+// supported, other bootstrap data is returned as is.
+func injectProxy(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Format) ([]byte, error) {
+	proxy := ctx.VSphereVM.Spec.Proxy
+	if proxy == nil || len(bootstrapData) == 0 {
+		return bootstrapData, nil
+	}
+	if format != "" && format != bootstrapv1.CloudConfig {
+		ctx.Logger.Info("skipping the configuration of the proxy, it is only supported with cloud-init", "format", format)
+		return bootstrapData, nil
+	}
+	cloudConfig, err := cloudinit.ProxyCloudConfig(proxy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to render the proxy configuration of vm %s", ctx)
+	}
+	data, err := cloudinit.MergeCloudConfig(bootstrapData, cloudConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to configure the proxy of vm %s", ctx)
+	}
+	return data, nil
+}
+
+// injectNTP returns the bootstrap data configuring the NTP servers of the
+// VSphereVM in the guest, if any. Only cloud-init bootstrap data is
+// supported, other bootstrap data is returned as is.
+func injectNTP(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Format) ([]byte, error) {
+	servers := ctx.VSphereVM.Spec.NTPServers
+	if len(servers) == 0 || len(bootstrapData) == 0 {
+		return bootstrapData, nil
+	}
+	if format != "" && format != bootstrapv1.CloudConfig {
+		ctx.Logger.Info("skipping the configuration of the NTP servers, it is only supported with cloud-init", "format", format)
+		return bootstrapData, nil
+	}
+	cloudConfig, err := cloudinit.NTPCloudConfig(servers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to render the NTP configuration of vm %s", ctx)
+	}
+	data, err := cloudinit.MergeCloudConfig(bootstrapData, cloudConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to configure the NTP servers of vm %s", ctx)
+	}
+	return data, nil
+}
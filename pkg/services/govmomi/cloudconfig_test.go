@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func Test_injectProxy(t *testing.T) {
+	bootstrapData := []byte("#cloud-config\n")
+	proxy := &infrav1.ProxyConfig{HTTPSProxy: "http://proxy.example.com:3128"}
+
+	t.Run("without proxy", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+		data, err := injectProxy(ctx, bootstrapData, bootstrapv1.CloudConfig)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(Equal(bootstrapData))
+	})
+
+	t.Run("with cloud-init bootstrap data", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+		ctx.VSphereVM.Spec.Proxy = proxy
+		data, err := injectProxy(ctx, bootstrapData, bootstrapv1.CloudConfig)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).To(HavePrefix("Content-Type: multipart/mixed;"))
+		g.Expect(string(data)).To(ContainSubstring(`Environment="HTTPS_PROXY=http://proxy.example.com:3128"`))
+	})
+
+	t.Run("with ignition bootstrap data", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+		ctx.VSphereVM.Spec.Proxy = proxy
+		data, err := injectProxy(ctx, []byte("{}"), bootstrapv1.Ignition)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(Equal([]byte("{}")))
+	})
+}
+
+func Test_injectNTP(t *testing.T) {
+	g := NewWithT(t)
+	ctx := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	ctx.VSphereVM.Spec.NTPServers = []string{"10.0.0.123"}
+	data, err := injectNTP(ctx, []byte("#cloud-config\n"), bootstrapv1.CloudConfig)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(HavePrefix("Content-Type: multipart/mixed;"))
+	g.Expect(string(data)).To(ContainSubstring("ntp:\n  enabled: true\n  servers:\n  - \"10.0.0.123\"\n"))
+}
@@ -35,7 +35,9 @@ type Provider interface {
 	CreateModule(ctx context.Context, clusterRef types.ManagedObjectReference) (string, error)
 	DeleteModule(ctx context.Context, moduleID string) error
 	DoesModuleExist(ctx context.Context, moduleID string, cluster types.ManagedObjectReference) (bool, error)
+	ListModules(ctx context.Context, cluster types.ManagedObjectReference) ([]string, error)
 
+	ListModuleMembers(ctx context.Context, moduleID string) ([]types.ManagedObjectReference, error)
 	IsMoRefModuleMember(ctx context.Context, moduleID string, moRef types.ManagedObjectReference) (bool, error)
 	AddMoRefToModule(ctx context.Context, moduleID string, moRef types.ManagedObjectReference) error
 	RemoveMoRefFromModule(ctx context.Context, moduleID string, moRef types.ManagedObjectReference) error
@@ -97,6 +99,25 @@ func (cm *provider) DoesModuleExist(ctx context.Context, moduleID string, cluste
 	return false, nil
 }
 
+// ListModules returns the IDs of the cluster modules of a compute cluster.
+func (cm *provider) ListModules(ctx context.Context, clusterRef types.ManagedObjectReference) ([]string, error) {
+	modules, err := cm.manager.ListModules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var moduleIDs []string
+	for _, mod := range modules {
+		if mod.Cluster == clusterRef.Value {
+			moduleIDs = append(moduleIDs, mod.Module)
+		}
+	}
+	return moduleIDs, nil
+}
+
+func (cm *provider) ListModuleMembers(ctx context.Context, moduleID string) ([]types.ManagedObjectReference, error) {
+	return cm.manager.ListModuleMembers(ctx, moduleID)
+}
+
 func (cm *provider) IsMoRefModuleMember(ctx context.Context, moduleID string, moRef types.ManagedObjectReference) (bool, error) {
 	moduleMembers, err := cm.manager.ListModuleMembers(ctx, moduleID)
 	if err != nil {
@@ -17,10 +17,17 @@ limitations under the License.
 package govmomi
 
 import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/esxi"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/template"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
 )
 
@@ -32,3 +39,36 @@ func createVM(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.F
 	}
 	return esxi.Clone(ctx, bootstrapData, format)
 }
+
+// markCloneFailed sets the VMProvisionedCondition of a VSphereVM whose clone
+// failed. The VMs whose template is not compatible with their spec are not
+// cloned until either of them changes, which is reported as an error.
+func markCloneFailed(ctx *context.VMContext, err error) {
+	var incompatible *template.IncompatibleError
+	if errors.As(err, &incompatible) {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, incompatible.Reason(), clusterv1.ConditionSeverityError, err.Error())
+		return
+	}
+	conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+}
+
+// reconcileAmbiguousReference sets the AmbiguousReferenceCondition of a
+// VSphereVM from the error of its clone, i.e. while a name or inventory path
+// of its spec matches several objects of the inventory.
+func reconcileAmbiguousReference(ctx *context.VMContext, err error) {
+	var ambiguous *find.AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		conditions.Delete(ctx.VSphereVM, infrav1.AmbiguousReferenceCondition)
+		return
+	}
+	if !conditions.IsTrue(ctx.VSphereVM, infrav1.AmbiguousReferenceCondition) {
+		ctx.Logger.Info("name of the spec matches several objects", "kind", ambiguous.Kind, "name", ambiguous.Name, "paths", ambiguous.Paths)
+		ctx.Recorder.Warnf(ctx.VSphereVM, infrav1.MultipleObjectsFoundReason, "%s", ambiguous)
+	}
+	conditions.Set(ctx.VSphereVM, &clusterv1.Condition{
+		Type:    infrav1.AmbiguousReferenceCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  infrav1.MultipleObjectsFoundReason,
+		Message: ambiguous.Error(),
+	})
+}
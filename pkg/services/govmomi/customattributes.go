@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// reconcileCustomAttributes sets the values of the custom attributes of the
+// spec which differ from the ones of the VM. The attributes removed from the
+// spec keep their value, since they cannot be told apart from the attributes
+// set by other tools.
+func (vms *VMService) reconcileCustomAttributes(ctx *virtualMachineContext) error {
+	if len(ctx.VSphereVM.Spec.CustomAttributes) == 0 {
+		return nil
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"customValue"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to fetch the custom attributes of vm %s", ctx)
+	}
+
+	manager, err := object.GetCustomFieldsManager(ctx.Session.Client.Client)
+	if err != nil {
+		return errors.Wrap(err, "unable to get the custom fields manager")
+	}
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationCustomField, ctx.Session.URL().Host)
+	defs, err := manager.Field(ctx)
+	done(err)
+	if err != nil {
+		return errors.Wrap(err, "unable to list the custom attribute definitions")
+	}
+
+	changes, err := getCustomAttributeChanges(ctx.VSphereVM.Spec.CustomAttributes, defs, obj.CustomValue)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		ctx.Logger.V(4).Info("setting custom attribute", "name", change.name)
+		done := metrics.TrackVSphereOperation(metrics.VSphereOperationCustomField, ctx.Session.URL().Host)
+		err := manager.Set(ctx, ctx.Ref, change.key, change.value)
+		done(err)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set custom attribute %q of vm %s", change.name, ctx)
+		}
+	}
+	return nil
+}
+
+type customAttributeChange struct {
+	name  string
+	key   int32
+	value string
+}
+
+// getCustomAttributeChanges returns the custom attribute values of the spec
+// which differ from the current values of a VM, sorted by name. An error is
+// returned if an attribute has no definition for VMs.
+func getCustomAttributeChanges(attributes map[string]string, defs []types.CustomFieldDef, current []types.BaseCustomFieldValue) ([]customAttributeChange, error) {
+	values := map[int32]string{}
+	for _, v := range current {
+		if s, ok := v.(*types.CustomFieldStringValue); ok {
+			values[s.Key] = s.Value
+		}
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []customAttributeChange
+	for _, name := range names {
+		def := findCustomAttributeDef(defs, name)
+		if def == nil {
+			return nil, errors.Errorf("custom attribute %q is not defined for virtual machines", name)
+		}
+		if value, ok := values[def.Key]; ok && value == attributes[name] {
+			continue
+		}
+		changes = append(changes, customAttributeChange{name: name, key: def.Key, value: attributes[name]})
+	}
+	return changes, nil
+}
+
+// findCustomAttributeDef returns the definition of a custom attribute that
+// applies to VMs, either specifically or to all the types.
+func findCustomAttributeDef(defs []types.CustomFieldDef, name string) *types.CustomFieldDef {
+	for i := range defs {
+		if defs[i].Name == name && (defs[i].ManagedObjectType == "" || defs[i].ManagedObjectType == "VirtualMachine") {
+			return &defs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_getCustomAttributeChanges(t *testing.T) {
+	defs := []types.CustomFieldDef{
+		{Key: 1, Name: "owner", ManagedObjectType: "VirtualMachine"},
+		{Key: 2, Name: "cost-center"},
+		{Key: 3, Name: "rack", ManagedObjectType: "HostSystem"},
+	}
+	current := []types.BaseCustomFieldValue{
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 1}, Value: "team-a"},
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 2}, Value: "1234"},
+	}
+
+	t.Run("only the values which differ are set", func(t *testing.T) {
+		g := NewWithT(t)
+		changes, err := getCustomAttributeChanges(map[string]string{"owner": "team-b", "cost-center": "1234"}, defs, current)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changes).To(Equal([]customAttributeChange{{name: "owner", key: 1, value: "team-b"}}))
+	})
+
+	t.Run("unset values are set", func(t *testing.T) {
+		g := NewWithT(t)
+		changes, err := getCustomAttributeChanges(map[string]string{"owner": "team-a", "cost-center": "5678"}, defs, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changes).To(Equal([]customAttributeChange{
+			{name: "cost-center", key: 2, value: "5678"},
+			{name: "owner", key: 1, value: "team-a"},
+		}))
+	})
+
+	t.Run("attributes without definition for VMs are rejected", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := getCustomAttributeChanges(map[string]string{"rack": "r1"}, defs, current)
+		g.Expect(err).To(MatchError(ContainSubstring(`custom attribute "rack" is not defined`)))
+	})
+}
+
+func Test_missingTags(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(missingTags([]string{"urn:tag:a", "urn:tag:b"}, []string{"urn:tag:b", "urn:tag:c"})).To(Equal([]string{"urn:tag:a"}))
+	g.Expect(missingTags([]string{"urn:tag:a"}, []string{"urn:tag:a"})).To(BeEmpty())
+}
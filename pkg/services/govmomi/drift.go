@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/template"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
+)
+
+// reconcileDrift sets the SpecDriftedCondition of a VSphereVM whose
+// DriftDetection is enabled from the differences between its VM and the
+// inputs it was cloned from. It runs once the VM is otherwise reconciled, so
+// that the resizes in progress are not reported as drifts.
+func (vms *VMService) reconcileDrift(ctx *virtualMachineContext) error {
+	if !ctx.VSphereVM.Spec.DriftDetection {
+		conditions.Delete(ctx.VSphereVM, infrav1.SpecDriftedCondition)
+		return nil
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.hardware"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to fetch the configuration of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return nil
+	}
+
+	tplConfig, drifts := getTemplateConfig(ctx)
+	drifts = append(drifts, getConfigDrifts(
+		&ctx.VSphereVM.Spec.VirtualMachineCloneSpec, ctx.VSphereVM.Status.CloneMode, obj.Config, tplConfig, getNetworkBackings(ctx))...)
+
+	if len(drifts) == 0 {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.SpecDriftedCondition, infrav1.NoDriftReason, clusterv1.ConditionSeverityInfo, "")
+		return nil
+	}
+	message := strings.Join(drifts, "; ")
+	if !conditions.IsTrue(ctx.VSphereVM, infrav1.SpecDriftedCondition) {
+		ctx.Logger.Info("VM drifted from its spec", "drifts", drifts)
+		ctx.Recorder.Warnf(ctx.VSphereVM, infrav1.DriftDetectedReason, "VM drifted from its spec: %s", message)
+	}
+	conditions.Set(ctx.VSphereVM, &clusterv1.Condition{
+		Type:    infrav1.SpecDriftedCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  infrav1.DriftDetectedReason,
+		Message: message,
+	})
+	return nil
+}
+
+// getTemplateConfig returns the configuration of the template of a VSphereVM,
+// or nil if it cannot be compared with the VM. The template drifted when it
+// is not the one the VM was cloned from anymore, e.g. after it was replaced
+// by a new image with the same name.
+func getTemplateConfig(ctx *virtualMachineContext) (*types.VirtualMachineConfigInfo, []string) {
+	// The template is looked up on the vCenter it is cloned from, which is
+	// not the vCenter of the VM for cross-vCenter clones.
+	tplCtx := &ctx.VMContext
+	if ctx.VSphereVM.Spec.TemplateSource != nil {
+		if ctx.TemplateSession == nil {
+			return nil, nil
+		}
+		tplCtx = &context.VMContext{
+			ControllerContext: ctx.ControllerContext,
+			VSphereVM:         ctx.VSphereVM,
+			Session:           ctx.TemplateSession,
+			Logger:            ctx.Logger,
+		}
+	}
+	tpl, err := template.FindTemplate(tplCtx, ctx.VSphereVM.Spec.Template)
+	if err != nil {
+		ctx.Logger.Error(err, "unable to find the template to detect the drifts of the VM")
+		return nil, nil
+	}
+	var tplObj mo.VirtualMachine
+	if err := tpl.Properties(ctx, tpl.Reference(), []string{"config.instanceUuid", "config.hardware"}, &tplObj); err != nil || tplObj.Config == nil {
+		ctx.Logger.Error(err, "unable to get the configuration of the template to detect the drifts of the VM")
+		return nil, nil
+	}
+	if uuid := ctx.VSphereVM.Status.TemplateInstanceUUID; uuid != "" && uuid != tplObj.Config.InstanceUuid {
+		return nil, []string{fmt.Sprintf("template %s is instance %s instead of %s", ctx.VSphereVM.Spec.Template, tplObj.Config.InstanceUuid, uuid)}
+	}
+	return tplObj.Config, nil
+}
+
+// getNetworkBackings returns the backings of the network devices of the spec
+// of a VSphereVM, as returned by networkBacking. The backings of the networks
+// which cannot be found are empty, and not compared with the VM.
+func getNetworkBackings(ctx *virtualMachineContext) []string {
+	backings := make([]string, 0, len(ctx.VSphereVM.Spec.Network.Devices))
+	for _, device := range ctx.VSphereVM.Spec.Network.Devices {
+		obj, err := find.ByMoRef(ctx.Session.Client.Client, "Network", find.Network(ctx.Session.Finder))(ctx, device.NetworkName)
+		if err != nil {
+			ctx.Logger.Error(err, "unable to find the network to detect the drifts of the VM", "network", device.NetworkName)
+			backings = append(backings, "")
+			continue
+		}
+		backing, err := obj.(object.NetworkReference).EthernetCardBackingInfo(ctx)
+		if err != nil {
+			ctx.Logger.Error(err, "unable to get the backing of the network to detect the drifts of the VM", "network", device.NetworkName)
+			backings = append(backings, "")
+			continue
+		}
+		backings = append(backings, networkBacking(backing))
+	}
+	return backings
+}
+
+// getConfigDrifts returns the differences between the configuration of a VM
+// and the clone spec it was cloned from. The number of CPUs and the memory are
+// compared with those the VM is cloned with, defaulted as vcenter.Size does.
+// The size of the primary disk which the clone spec does not set is that of
+// the template, and is not compared when its configuration is unknown.
+func getConfigDrifts(spec *infrav1.VirtualMachineCloneSpec, cloneMode infrav1.CloneMode, config, tplConfig *types.VirtualMachineConfigInfo, networks []string) []string {
+	var drifts []string
+
+	numCPUs, _, memoryMiB := vcenter.Size(spec)
+	if numCPUs != config.Hardware.NumCPU {
+		drifts = append(drifts, fmt.Sprintf("numCPUs is %d instead of %d", config.Hardware.NumCPU, numCPUs))
+	}
+	if memoryMiB != int64(config.Hardware.MemoryMB) {
+		drifts = append(drifts, fmt.Sprintf("memoryMiB is %d instead of %d", config.Hardware.MemoryMB, memoryMiB))
+	}
+
+	// The linked clones keep the size of the disk of the template.
+	diskKB := int64(spec.DiskGiB) * 1024 * 1024
+	if diskKB == 0 || cloneMode == infrav1.LinkedClone {
+		diskKB = 0
+		if tplConfig != nil {
+			diskKB = primaryDiskCapacityKB(tplConfig)
+		}
+	}
+	if capacityKB := primaryDiskCapacityKB(config); diskKB > 0 && capacityKB != diskKB {
+		drifts = append(drifts, fmt.Sprintf("the primary disk is %s instead of %s", units.ByteSize(capacityKB*1024), units.ByteSize(diskKB*1024)))
+	}
+
+	nics := object.VirtualDeviceList(config.Hardware.Device).SelectByType((*types.VirtualEthernetCard)(nil))
+	if len(nics) != len(networks) {
+		drifts = append(drifts, fmt.Sprintf("the VM has %d network devices instead of %d", len(nics), len(networks)))
+		return drifts
+	}
+	for i, nic := range nics {
+		if backing := networkBacking(nic.GetVirtualDevice().Backing); networks[i] != "" && backing != networks[i] {
+			drifts = append(drifts, fmt.Sprintf("network device %d is on %s instead of %s", i, backing, networks[i]))
+		}
+	}
+	return drifts
+}
+
+// primaryDiskCapacityKB returns the capacity of the first disk of a VM, which
+// is the one resized when the VM is cloned.
+func primaryDiskCapacityKB(config *types.VirtualMachineConfigInfo) int64 {
+	disks := object.VirtualDeviceList(config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))
+	if len(disks) == 0 {
+		return 0
+	}
+	return disks[0].(*types.VirtualDisk).CapacityInKB //nolint:forcetypeassert
+}
+
+// networkBacking returns the network the backing of a network device connects
+// it to: the name of a standard port group, or the key of a distributed port
+// group or the identifier of an opaque network.
+func networkBacking(backing types.BaseVirtualDeviceBackingInfo) string {
+	switch b := backing.(type) {
+	case *types.VirtualEthernetCardNetworkBackingInfo:
+		return b.DeviceName
+	case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+		return b.Port.PortgroupKey
+	case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+		return b.OpaqueNetworkId
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_getConfigDrifts(t *testing.T) {
+	config := func(numCPUs int32, memoryMB int32, diskGiB int64, networks ...types.BaseVirtualDeviceBackingInfo) *types.VirtualMachineConfigInfo {
+		devices := []types.BaseVirtualDevice{
+			&types.VirtualDisk{CapacityInKB: diskGiB * 1024 * 1024},
+		}
+		for _, backing := range networks {
+			devices = append(devices, &types.VirtualVmxnet3{VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: types.VirtualEthernetCard{
+				VirtualDevice: types.VirtualDevice{Backing: backing},
+			}}})
+		}
+		return &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{NumCPU: numCPUs, MemoryMB: memoryMB, Device: devices},
+		}
+	}
+	standard := &types.VirtualEthernetCardNetworkBackingInfo{
+		VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{DeviceName: "VM Network"},
+	}
+	distributed := &types.VirtualEthernetCardDistributedVirtualPortBackingInfo{
+		Port: types.DistributedVirtualSwitchPortConnection{PortgroupKey: "dvportgroup-42"},
+	}
+
+	tests := []struct {
+		name      string
+		cloneSpec infrav1.VirtualMachineCloneSpec
+		cloneMode infrav1.CloneMode
+		config    *types.VirtualMachineConfigInfo
+		tplConfig *types.VirtualMachineConfigInfo
+		networks  []string
+		drifts    []string
+	}{
+		{
+			name:      "without drift",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 2, MemoryMiB: 4096, DiskGiB: 40},
+			cloneMode: infrav1.FullClone,
+			config:    config(2, 4096, 40, standard, distributed),
+			tplConfig: config(1, 2048, 20),
+			networks:  []string{"VM Network", "dvportgroup-42"},
+		},
+		{
+			name:      "compares the CPUs and memory with the defaults and the disk with the template when the spec does not set them",
+			cloneMode: infrav1.FullClone,
+			config:    config(4, 8192, 40, standard),
+			tplConfig: config(1, 4096, 20),
+			networks:  []string{"VM Network"},
+			drifts: []string{
+				"numCPUs is 4 instead of 2",
+				"memoryMiB is 8192 instead of 2048",
+				"the primary disk is 40.0GB instead of 20.0GB",
+			},
+		},
+		{
+			name:      "compares the disk of linked clones with the template",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{DiskGiB: 40},
+			cloneMode: infrav1.LinkedClone,
+			config:    config(2, 2048, 20),
+			tplConfig: config(2, 4096, 20),
+		},
+		{
+			name:      "does not compare the disk with an unknown template",
+			cloneMode: infrav1.FullClone,
+			config:    config(2, 2048, 40),
+		},
+		{
+			name:      "compares the CPUs with the minimum",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 1, MemoryMiB: 4096},
+			cloneMode: infrav1.FullClone,
+			config:    config(1, 4096, 20),
+			drifts:    []string{"numCPUs is 1 instead of 2"},
+		},
+		{
+			name:      "reports the networks the devices were moved to",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 2},
+			config:    config(2, 2048, 20, distributed, standard),
+			networks:  []string{"VM Network", ""},
+			drifts:    []string{"network device 0 is on dvportgroup-42 instead of VM Network"},
+		},
+		{
+			name:     "reports the network devices which were added or removed",
+			config:   config(2, 2048, 20, standard),
+			networks: []string{"VM Network", "dvportgroup-42"},
+			drifts:   []string{"the VM has 1 network devices instead of 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			drifts := getConfigDrifts(&tt.cloneSpec, tt.cloneMode, tt.config, tt.tplConfig, tt.networks)
+			g.Expect(drifts).To(Equal(tt.drifts))
+		})
+	}
+}
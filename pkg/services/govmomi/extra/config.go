@@ -18,7 +18,10 @@ package extra
 
 import (
 	"encoding/base64"
+	"sort"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -33,12 +36,24 @@ const (
 )
 
 // SetCustomVMXKeys sets the custom VMX keys as
-// OptionValues in extraConfig.
+// OptionValues in extraConfig, sorted by key. It fails without
+// setting any key if one of them is already set, e.g. the bootstrap
+// data, the VMX keys being case-insensitive.
 func (e *Config) SetCustomVMXKeys(customKeys map[string]string) error {
-	for k, v := range customKeys {
+	keys := make([]string, 0, len(customKeys))
+	for k := range customKeys {
+		for _, o := range *e {
+			if strings.EqualFold(o.GetOptionValue().Key, k) {
+				return errors.Errorf("custom VMX key %q conflicts with key %q managed by CAPV", k, o.GetOptionValue().Key)
+			}
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
 		*e = append(*e, &types.OptionValue{
 			Key:   k,
-			Value: v,
+			Value: customKeys[k],
 		})
 	}
 	return nil
@@ -53,6 +53,19 @@ var _ = Describe("Config_SetCustomVMXKeys", func() {
 				}))
 			}
 		})
+
+		It("refuses the keys set by CAPV", func() {
+			config := Config{}
+			config.SetCloudInitUserData([]byte("hello"))
+
+			err := config.SetCustomVMXKeys(map[string]string{
+				"customKey1":         "customVal1",
+				"GuestInfo.UserData": "world",
+			})
+
+			Expect(err).To(HaveOccurred())
+			Expect(config).To(HaveLen(2))
+		})
 	})
 })
 
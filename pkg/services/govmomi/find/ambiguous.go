@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+)
+
+// AmbiguousError is the error of the lookups of the names or inventory paths
+// matching several objects of the inventory, e.g. datastores or networks with
+// the same name in different folders.
+type AmbiguousError struct {
+	// Kind is the kind of the objects, e.g. Datastore.
+	Kind string
+
+	// Name is the name or inventory path the objects were looked up with.
+	Name string
+
+	// Paths are the inventory paths of the objects it matches.
+	Paths []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s %q matches %d objects, give one of %s or its managed object ID instead",
+		e.Kind, e.Name, len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+// Ambiguous returns an AmbiguousError listing the objects of the given kind
+// matching name with finder if err is the error of finder for a name matching
+// several objects, and err otherwise.
+func Ambiguous(ctx context.Context, finder *find.Finder, kind, name string, err error) error {
+	if _, ok := err.(*find.MultipleFoundError); !ok {
+		return err
+	}
+	paths, listErr := matchingPaths(ctx, finder, kind, name)
+	if listErr != nil || len(paths) < 2 {
+		return err
+	}
+	return &AmbiguousError{Kind: kind, Name: name, Paths: paths}
+}
+
+// matchingPaths returns the inventory paths of the objects of the given kind
+// matching name.
+func matchingPaths(ctx context.Context, finder *find.Finder, kind, name string) ([]string, error) {
+	var paths []string
+	switch kind {
+	case "Datacenter":
+		objs, err := finder.DatacenterList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.InventoryPath)
+		}
+	case "Folder":
+		objs, err := finder.FolderList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.InventoryPath)
+		}
+	case "ResourcePool":
+		objs, err := finder.ResourcePoolList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.InventoryPath)
+		}
+	case "Datastore":
+		objs, err := finder.DatastoreList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.InventoryPath)
+		}
+	case "Network":
+		objs, err := finder.NetworkList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.GetInventoryPath())
+		}
+	case "VirtualMachine":
+		objs, err := finder.VirtualMachineList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			paths = append(paths, obj.InventoryPath)
+		}
+	default:
+		return nil, errors.Errorf("unable to list the objects of kind %s", kind)
+	}
+	return paths, nil
+}
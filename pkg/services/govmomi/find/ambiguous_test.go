@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+)
+
+func TestAmbiguous(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	sim, authSession, _, err := setupSimulatorAndSession(simulator.VPX())
+	g.Expect(err).ToNot(HaveOccurred(), "a vcsim instance and authSession should be established")
+	t.Cleanup(sim.Destroy)
+
+	for _, cmd := range []string{
+		"folder.create /DC0/vm/templates",
+		"object.mv /DC0/vm/DC0_H0_VM1 /DC0/vm/templates",
+		"object.rename /DC0/vm/templates/DC0_H0_VM1 DC0_H0_VM0",
+	} {
+		g.Expect(sim.Run(cmd, gbytes.NewBuffer())).To(Succeed())
+	}
+
+	t.Run("the names matching several objects are ambiguous", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := authSession.Finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		g.Expect(err).To(HaveOccurred())
+
+		err = find.Ambiguous(ctx, authSession.Finder, "VirtualMachine", "DC0_H0_VM0", err)
+		var ambiguous *find.AmbiguousError
+		g.Expect(errors.As(err, &ambiguous)).To(BeTrue())
+		g.Expect(ambiguous.Paths).To(ConsistOf("/DC0/vm/DC0_H0_VM0", "/DC0/vm/templates/DC0_H0_VM0"))
+		g.Expect(err.Error()).To(ContainSubstring("/DC0/vm/templates/DC0_H0_VM0"))
+	})
+
+	t.Run("the other errors are returned as is", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := authSession.Finder.VirtualMachine(ctx, "missing")
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(find.Ambiguous(ctx, authSession.Finder, "VirtualMachine", "missing", err)).To(Equal(err))
+	})
+}
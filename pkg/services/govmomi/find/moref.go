@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// moRefTypes are the types of the objects of the kinds which are not all of
+// the same type, which may be given with their managed object ID.
+var moRefTypes = map[string]sets.String{
+	"Network": sets.NewString("Network", "DistributedVirtualPortgroup", "OpaqueNetwork"),
+}
+
+// ByMoRef returns a ByPathFunc finding the objects of the given kind given by
+// managed object ID, e.g. moref:datastore-42, with client, and the others with
+// byPath.
+func ByMoRef(client *vim25.Client, kind string, byPath ByPathFunc) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		if !infrav1.IsMoRef(path) {
+			return byPath(ctx, path)
+		}
+		return MoRef(ctx, client, kind, path)
+	}
+}
+
+// MoRef returns the object of the given kind given by managed object ID, e.g.
+// moref:datastore-42, holding its inventory path. It fails if the object does
+// not exist.
+func MoRef(ctx context.Context, client *vim25.Client, kind, name string) (object.Reference, error) {
+	typ, id, err := infrav1.ParseMoRef(name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case typ == "" && kind == "Network" && strings.HasPrefix(id, "dvportgroup-"):
+		typ = "DistributedVirtualPortgroup"
+	case typ == "":
+		typ = kind
+	case typ != kind && !moRefTypes[kind].Has(typ):
+		return nil, errors.Errorf("%s is a %s, not a %s", name, typ, kind)
+	}
+
+	ref := types.ManagedObjectReference{Type: typ, Value: id}
+	path, err := find.InventoryPath(ctx, client, ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find %s %s", kind, name)
+	}
+	obj := object.NewReference(client, ref)
+	if common, ok := obj.(interface{ SetInventoryPath(string) }); ok {
+		common.SetInventoryPath(path)
+	}
+	return obj, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+)
+
+func TestByMoRef(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	sim, authSession, _, err := setupSimulatorAndSession(simulator.VPX())
+	g.Expect(err).ToNot(HaveOccurred(), "a vcsim instance and authSession should be established")
+	t.Cleanup(sim.Destroy)
+
+	client := authSession.Client.Client
+	ds, err := authSession.Finder.Datastore(ctx, "LocalDS_0")
+	g.Expect(err).ToNot(HaveOccurred())
+	pg, err := authSession.Finder.Network(ctx, "DC0_DVPG0")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Run("the objects given by managed object ID are found by reference", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := find.ByMoRef(client, "Datastore", find.Datastore(authSession.Finder))(ctx, "moref:"+ds.Reference().Value)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Reference()).To(Equal(ds.Reference()))
+		g.Expect(obj.(*object.Datastore).InventoryPath).To(Equal("/DC0/datastore/LocalDS_0"))
+		g.Expect(obj.(*object.Datastore).Name()).To(Equal("LocalDS_0"))
+	})
+
+	t.Run("the other objects are found by path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := find.ByMoRef(client, "Datastore", find.Datastore(authSession.Finder))(ctx, "LocalDS_0")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Reference()).To(Equal(ds.Reference()))
+	})
+
+	t.Run("the type of distributed port groups is inferred from their ID", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj, err := find.MoRef(ctx, client, "Network", "moref:"+pg.Reference().Value)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Reference()).To(Equal(pg.Reference()))
+		g.Expect(obj).To(BeAssignableToTypeOf(&object.DistributedVirtualPortgroup{}))
+
+		obj, err = find.MoRef(ctx, client, "Network", "moref:"+pg.Reference().String())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(obj.Reference()).To(Equal(pg.Reference()))
+	})
+
+	t.Run("the objects of another type are not found", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := find.MoRef(ctx, client, "Network", "moref:"+ds.Reference().String())
+		g.Expect(err).To(MatchError(ContainSubstring("is a Datastore, not a Network")))
+	})
+
+	t.Run("the objects which do not exist are not found", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := find.MoRef(ctx, client, "Datastore", "moref:datastore-404")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DefaultPathCacheTTL is the time the inventory paths resolved by Paths are
+// cached for.
+const DefaultPathCacheTTL = 5 * time.Minute
+
+// Paths is the cache of the inventory paths resolved by the controllers.
+var Paths = NewPathCache(DefaultPathCacheTTL)
+
+// PathCache caches the managed objects the names and inventory paths of the
+// objects of a vCenter resolve to, so that the objects of a large inventory
+// are not searched for by the Finder on every reconcile. The objects are
+// cached for all the sessions of the same user to the same vCenter, as the
+// users may not see the same objects, by datacenter, until the TTL expires or
+// they are invalidated, e.g. when a call fails to find them.
+type PathCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[pathKey]pathEntry
+}
+
+type pathKey struct {
+	server, username, datacenter, kind, path string
+}
+
+type pathEntry struct {
+	ref           types.ManagedObjectReference
+	inventoryPath string
+	expires       time.Time
+}
+
+// NewPathCache returns a PathCache caching the resolved paths for ttl.
+func NewPathCache(ttl time.Duration) *PathCache {
+	return &PathCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[pathKey]pathEntry{},
+	}
+}
+
+// Resolve returns the object of the given kind the path resolves to in a
+// datacenter of server, as found by byPath for username unless it was resolved
+// for them before. The returned object is bound to client. The paths which
+// fail to resolve are not cached.
+func (c *PathCache) Resolve(ctx context.Context, client *vim25.Client, server, username, datacenter, kind, path string, byPath ByPathFunc) (object.Reference, error) {
+	key := pathKey{server: server, username: username, datacenter: datacenter, kind: kind, path: path}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.now().After(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		obj := object.NewReference(client, entry.ref)
+		if common, ok := obj.(interface{ SetInventoryPath(string) }); ok {
+			common.SetInventoryPath(entry.inventoryPath)
+		}
+		return obj, nil
+	}
+
+	obj, err := byPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = pathEntry{
+		ref:           obj.Reference(),
+		inventoryPath: inventoryPath(obj),
+		expires:       c.now().Add(c.ttl),
+	}
+	return obj, nil
+}
+
+// Invalidate drops the paths resolving to ref on server, e.g. once ref was
+// found to be deleted. Its signature is the one of the handlers of the
+// session package, with which it is meant to be registered.
+func (c *PathCache) Invalidate(server string, ref types.ManagedObjectReference) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if key.server == server && entry.ref == ref {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// inventoryPath returns the inventory path of an object found by the Finder.
+func inventoryPath(obj object.Reference) string {
+	switch o := obj.(type) {
+	case *object.Datacenter:
+		return o.InventoryPath
+	case *object.Folder:
+		return o.InventoryPath
+	case *object.ResourcePool:
+		return o.InventoryPath
+	case *object.ClusterComputeResource:
+		return o.InventoryPath
+	case *object.Datastore:
+		return o.InventoryPath
+	case *object.Network:
+		return o.InventoryPath
+	case *object.DistributedVirtualPortgroup:
+		return o.InventoryPath
+	case *object.OpaqueNetwork:
+		return o.InventoryPath
+	}
+	return ""
+}
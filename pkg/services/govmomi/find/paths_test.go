@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestPathCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	c := NewPathCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	ds := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"}
+	lookups := 0
+	byPath := func(_ context.Context, path string) (object.Reference, error) {
+		lookups++
+		obj := object.NewDatastore(nil, ds)
+		obj.InventoryPath = "/DC0/datastore/" + path
+		return obj, nil
+	}
+	resolve := func(server, username string) *object.Datastore {
+		obj, err := c.Resolve(ctx, nil, server, username, "DC0", "Datastore", "LocalDS_0", byPath)
+		g.Expect(err).NotTo(HaveOccurred())
+		return obj.(*object.Datastore)
+	}
+
+	g.Expect(resolve("vcenter-1", "user-1").Name()).To(Equal("LocalDS_0"))
+	obj := resolve("vcenter-1", "user-1")
+	g.Expect(obj.Reference()).To(Equal(ds))
+	g.Expect(obj.InventoryPath).To(Equal("/DC0/datastore/LocalDS_0"))
+	g.Expect(lookups).To(Equal(1))
+
+	// The paths are cached by vCenter and user.
+	resolve("vcenter-2", "user-1")
+	g.Expect(lookups).To(Equal(2))
+	resolve("vcenter-1", "user-2")
+	g.Expect(lookups).To(Equal(3))
+
+	// The objects found to be deleted are resolved again.
+	c.Invalidate("vcenter-1", ds)
+	resolve("vcenter-1", "user-1")
+	resolve("vcenter-2", "user-1")
+	g.Expect(lookups).To(Equal(4))
+
+	now = now.Add(2 * time.Minute)
+	resolve("vcenter-1", "user-1")
+	g.Expect(lookups).To(Equal(5))
+
+	// The paths failing to resolve are not cached.
+	failing := func(_ context.Context, path string) (object.Reference, error) {
+		lookups++
+		return nil, errors.Errorf("datastore '%s' not found", path)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := c.Resolve(ctx, nil, "vcenter-1", "user-1", "DC0", "Datastore", "missing", failing)
+		g.Expect(err).To(HaveOccurred())
+	}
+	g.Expect(lookups).To(Equal(7))
+}
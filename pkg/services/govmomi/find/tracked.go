@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// ByPathFunc finds an object by name or inventory path.
+type ByPathFunc func(ctx context.Context, path string) (object.Reference, error)
+
+// ClusterComputeResource finds a compute cluster by name or inventory path.
+func ClusterComputeResource(finder *find.Finder) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		return finder.ClusterComputeResource(ctx, path)
+	}
+}
+
+// ResourcePool finds a resource pool by name or inventory path.
+func ResourcePool(finder *find.Finder) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		return finder.ResourcePool(ctx, path)
+	}
+}
+
+// Datastore finds a datastore by name or inventory path.
+func Datastore(finder *find.Finder) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		return finder.Datastore(ctx, path)
+	}
+}
+
+// Network finds a network by name or inventory path.
+func Network(finder *find.Finder) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		return finder.Network(ctx, path)
+	}
+}
+
+// Folder finds a folder by name or inventory path.
+func Folder(finder *find.Finder) ByPathFunc {
+	return func(ctx context.Context, path string) (object.Reference, error) {
+		return finder.Folder(ctx, path)
+	}
+}
+
+// Tracked resolves the object of the given type configured with the given
+// name or inventory path. Once resolved, the object is tracked by its managed
+// object ID for as long as it is configured with the same name, so that it is
+// still found when it is renamed or moved in vCenter. A tracked object which
+// was deleted is resolved by name again.
+//
+// The returned object holds the current inventory path of the object, and
+// renamed is true if the object is no longer found at the configured path.
+func Tracked(ctx context.Context, client *vim25.Client, kind, name string, tracked *infrav1.TrackedObject, byPath ByPathFunc) (_ *infrav1.TrackedObject, renamed bool, _ error) {
+	obj, err := byPath(ctx, name)
+	if err != nil && !isNotFound(err) {
+		return nil, false, err
+	}
+
+	if tracked != nil && tracked.Name == name {
+		ref := types.ManagedObjectReference{Type: kind, Value: tracked.MoID}
+		path, err := find.InventoryPath(ctx, client, ref)
+		switch {
+		case err == nil:
+			renamed := obj == nil || obj.Reference() != ref
+			return &infrav1.TrackedObject{Name: name, MoID: ref.Value, Path: path}, renamed, nil
+		case !isManagedObjectNotFound(err):
+			return nil, false, errors.Wrapf(err, "unable to get the inventory path of %s %s", kind, ref.Value)
+		}
+	}
+
+	if obj == nil {
+		return nil, false, errors.Errorf("%s %q not found", kind, name)
+	}
+	ref := obj.Reference()
+	path, err := find.InventoryPath(ctx, client, ref)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "unable to get the inventory path of %s %s", kind, ref.Value)
+	}
+	return &infrav1.TrackedObject{Name: name, MoID: ref.Value, Path: path}, false, nil
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*find.NotFoundError)
+	return ok
+}
+
+func isManagedObjectNotFound(err error) bool {
+	if soap.IsSoapFault(err) {
+		_, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+)
+
+func TestTracked(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	sim, authSession, _, err := setupSimulatorAndSession(simulator.VPX())
+	g.Expect(err).ToNot(HaveOccurred(), "a vcsim instance and authSession should be established")
+	t.Cleanup(sim.Destroy)
+
+	byPath := find.ClusterComputeResource(authSession.Finder)
+
+	tracked, renamed, err := find.Tracked(ctx, authSession.Client.Client, "ClusterComputeResource", "DC0_C0", nil, byPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(renamed).To(BeFalse())
+	g.Expect(tracked.Name).To(Equal("DC0_C0"))
+	g.Expect(tracked.MoID).ToNot(BeEmpty())
+	g.Expect(tracked.Path).To(Equal("/DC0/host/DC0_C0"))
+	moID := tracked.MoID
+
+	t.Run("the tracked object is found after it is renamed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(sim.Run("object.rename /DC0/host/DC0_C0 DC0_renamed", gbytes.NewBuffer())).To(Succeed())
+
+		tracked, renamed, err = find.Tracked(ctx, authSession.Client.Client, "ClusterComputeResource", "DC0_C0", tracked, byPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renamed).To(BeTrue())
+		g.Expect(tracked.MoID).To(Equal(moID))
+		g.Expect(tracked.Path).To(Equal("/DC0/host/DC0_renamed"))
+	})
+
+	t.Run("the object is not found by its former name when it is not tracked", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, _, err := find.Tracked(ctx, authSession.Client.Client, "ClusterComputeResource", "DC0_C0", nil, byPath)
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("the object is resolved by name when the configured name changes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tracked, renamed, err = find.Tracked(ctx, authSession.Client.Client, "ClusterComputeResource", "DC0_renamed", tracked, byPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renamed).To(BeFalse())
+		g.Expect(tracked.Name).To(Equal("DC0_renamed"))
+		g.Expect(tracked.MoID).To(Equal(moID))
+	})
+}
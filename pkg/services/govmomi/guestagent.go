@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/mo"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/feature"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/guestagent"
+)
+
+// guestAgentReportMaxAge is the age after which the node health reported by
+// the guest agent is considered stale. The agent reports every 30 seconds.
+const guestAgentReportMaxAge = 5 * time.Minute
+
+// injectGuestAgent returns the bootstrap data installing the guest agent when
+// the GuestAgent feature is enabled. Only cloud-init bootstrap data is
+// supported, other bootstrap data is returned as is.
+func injectGuestAgent(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Format) ([]byte, error) {
+	if !feature.Gates.Enabled(feature.GuestAgent) || ctx.GuestAgentURL == "" {
+		return bootstrapData, nil
+	}
+	if format != "" && format != bootstrapv1.CloudConfig {
+		ctx.Logger.V(4).Info("skipping the installation of the guest agent", "format", format)
+		return bootstrapData, nil
+	}
+	data, err := guestagent.InjectCloudConfig(bootstrapData, ctx.GuestAgentURL, ctx.GuestAgentSHA256)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to install the guest agent on vm %s", ctx)
+	}
+	return data, nil
+}
+
+// reconcileGuestAgentReports sets the conditions of the VSphereVM from the
+// reports published by the guest agent. The conditions are left unset until
+// the agent publishes its first report.
+func (vms *VMService) reconcileGuestAgentReports(ctx *virtualMachineContext) error {
+	if !feature.Gates.Enabled(feature.GuestAgent) {
+		return nil
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.extraConfig"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to fetch the extra config of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return nil
+	}
+	values := map[string]string{}
+	for _, ec := range obj.Config.ExtraConfig {
+		if optVal := ec.GetOptionValue(); optVal != nil {
+			if v, ok := optVal.Value.(string); ok {
+				values[optVal.Key] = v
+			}
+		}
+	}
+
+	if value, ok := values[guestagent.BootstrapKey]; ok {
+		report, err := guestagent.ParseReport(value)
+		switch {
+		case err != nil:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestBootstrapSucceededCondition, infrav1.GuestAgentReportInvalidReason, clusterv1.ConditionSeverityWarning, err.Error())
+		case report.Status == guestagent.StatusSucceeded:
+			conditions.MarkTrue(ctx.VSphereVM, infrav1.GuestBootstrapSucceededCondition)
+		case report.Status == guestagent.StatusFailed && report.Phase == guestagent.PhaseKubeadm:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestBootstrapSucceededCondition, infrav1.GuestKubeadmFailedReason, clusterv1.ConditionSeverityError, "%s", report.Message)
+		case report.Status == guestagent.StatusFailed:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestBootstrapSucceededCondition, infrav1.GuestBootstrapFailedReason, clusterv1.ConditionSeverityError, "%s", bootstrapPhaseMessage(report))
+		default:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestBootstrapSucceededCondition, infrav1.GuestBootstrapInProgressReason, clusterv1.ConditionSeverityInfo, "%s", bootstrapPhaseMessage(report))
+		}
+	}
+
+	if value, ok := values[guestagent.NodeHealthKey]; ok {
+		report, err := guestagent.ParseReport(value)
+		switch {
+		case err != nil:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestNodeHealthyCondition, infrav1.GuestAgentReportInvalidReason, clusterv1.ConditionSeverityWarning, err.Error())
+		case report.IsStale(time.Now(), guestAgentReportMaxAge):
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestNodeHealthyCondition, infrav1.GuestAgentReportStaleReason, clusterv1.ConditionSeverityWarning,
+				"the guest agent did not report since %s", report.Time.Format(time.RFC3339))
+		case report.Status == guestagent.StatusHealthy:
+			conditions.MarkTrue(ctx.VSphereVM, infrav1.GuestNodeHealthyCondition)
+		default:
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.GuestNodeHealthyCondition, infrav1.GuestNodeUnhealthyReason, clusterv1.ConditionSeverityWarning, "%s", report.Message)
+		}
+	}
+	return nil
+}
+
+// bootstrapPhaseMessage returns the message of a report on the bootstrap of a
+// VM prefixed with the phase the bootstrap is in, e.g. "Kubeadm phase".
+func bootstrapPhaseMessage(report guestagent.Report) string {
+	switch {
+	case report.Phase == "":
+		return report.Message
+	case report.Message == "":
+		return fmt.Sprintf("%s phase", report.Phase)
+	default:
+		return fmt.Sprintf("%s phase: %s", report.Phase, report.Message)
+	}
+}
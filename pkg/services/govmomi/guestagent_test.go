@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/guestagent"
+)
+
+func Test_bootstrapPhaseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  guestagent.Report
+		message string
+	}{
+		{
+			name:    "without phase",
+			report:  guestagent.Report{Message: "cloud-init finished with errors"},
+			message: "cloud-init finished with errors",
+		},
+		{
+			name:    "without message",
+			report:  guestagent.Report{Phase: guestagent.PhaseKubeadm},
+			message: "Kubeadm phase",
+		},
+		{
+			name:    "with phase and message",
+			report:  guestagent.Report{Phase: guestagent.PhaseCloudInit, Message: "cloud-init is running modules-final"},
+			message: "CloudInit phase: cloud-init is running modules-final",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(bootstrapPhaseMessage(tc.report)).To(Equal(tc.message))
+		})
+	}
+}
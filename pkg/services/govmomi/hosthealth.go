@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// reconcileHostHealth sets the HostHealthyCondition of the VSphereVM from the
+// connection state of the ESXi host running its VM, and sets it on the node of
+// the VSphereVM, so that MachineHealthChecks can remediate the machines whose
+// host failed even though the node only turns unready after a while. The node
+// is only updated while the host is unhealthy, or once it recovers.
+func reconcileHostHealth(ctx *virtualMachineContext, state types.HostSystemConnectionState) error {
+	wasHealthy := !conditions.IsFalse(ctx.VSphereVM, infrav1.HostHealthyCondition)
+	markHostHealth(ctx.VSphereVM, state)
+	if wasHealthy && conditions.IsTrue(ctx.VSphereVM, infrav1.HostHealthyCondition) {
+		return nil
+	}
+	if !conditions.IsTrue(ctx.VSphereVM, infrav1.HostHealthyCondition) {
+		ctx.Logger.Info("host of the VM is unhealthy", "host", ctx.VSphereVM.Status.Host, "connectionState", state)
+	}
+	return setNodeHostHealthyCondition(ctx)
+}
+
+// markHostHealth sets the HostHealthyCondition of a VSphereVM from the
+// connection state of its host.
+func markHostHealth(vm *infrav1.VSphereVM, state types.HostSystemConnectionState) {
+	switch state {
+	case types.HostSystemConnectionStateNotResponding:
+		conditions.MarkFalse(vm, infrav1.HostHealthyCondition, infrav1.HostNotRespondingReason, clusterv1.ConditionSeverityError,
+			"host %s is not responding", vm.Status.Host)
+	case types.HostSystemConnectionStateDisconnected:
+		conditions.MarkFalse(vm, infrav1.HostHealthyCondition, infrav1.HostDisconnectedReason, clusterv1.ConditionSeverityError,
+			"host %s is disconnected", vm.Status.Host)
+	default:
+		conditions.MarkTrue(vm, infrav1.HostHealthyCondition)
+	}
+}
+
+// setNodeHostHealthyCondition sets the NodeHostHealthyCondition of the node
+// of the VSphereVM from its HostHealthyCondition.
+func setNodeHostHealthyCondition(ctx *virtualMachineContext) error {
+	if !ctx.Bootstrapped {
+		return nil
+	}
+
+	clusterKey := client.ObjectKey{Namespace: ctx.VSphereVM.Namespace, Name: ctx.VSphereVM.Labels[clusterv1.ClusterLabelName]}
+	clusterClient, err := remote.NewClusterClient(ctx, ctx.Name, ctx.Client, clusterKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a client to cluster %s", clusterKey)
+	}
+
+	nodeName, err := ctx.VSphereVM.HostName()
+	if err != nil {
+		return err
+	}
+	node := &corev1.Node{}
+	if err := clusterClient.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get node %s", nodeName)
+	}
+
+	patchHelper, err := patch.NewHelper(node, clusterClient)
+	if err != nil {
+		return err
+	}
+	if !setNodeCondition(node, nodeHostHealthyCondition(conditions.Get(ctx.VSphereVM, infrav1.HostHealthyCondition))) {
+		return nil
+	}
+	if err := patchHelper.Patch(ctx, node); err != nil {
+		return errors.Wrapf(err, "failed to patch node %s", node.Name)
+	}
+	return nil
+}
+
+// nodeHostHealthyCondition returns the NodeHostHealthyCondition of the node of
+// a VSphereVM with the given HostHealthyCondition.
+func nodeHostHealthyCondition(c *clusterv1.Condition) corev1.NodeCondition {
+	condition := corev1.NodeCondition{
+		Type:   infrav1.NodeHostHealthyCondition,
+		Status: corev1.ConditionTrue,
+		Reason: "HostHealthy",
+	}
+	if c != nil && c.Status != corev1.ConditionTrue {
+		condition.Status = c.Status
+		condition.Reason = c.Reason
+		condition.Message = c.Message
+	}
+	return condition
+}
+
+// setNodeCondition sets a condition of a node, and returns whether it
+// changed. The transition time is only updated when the status changes.
+func setNodeCondition(node *corev1.Node, condition corev1.NodeCondition) bool {
+	now := metav1.Now()
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	for i := range node.Status.Conditions {
+		existing := &node.Status.Conditions[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		*existing = condition
+		return true
+	}
+	node.Status.Conditions = append(node.Status.Conditions, condition)
+	return true
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_markHostHealth(t *testing.T) {
+	tests := []struct {
+		state  types.HostSystemConnectionState
+		status corev1.ConditionStatus
+		reason string
+	}{
+		{state: types.HostSystemConnectionStateConnected, status: corev1.ConditionTrue},
+		{state: types.HostSystemConnectionStateNotResponding, status: corev1.ConditionFalse, reason: infrav1.HostNotRespondingReason},
+		{state: types.HostSystemConnectionStateDisconnected, status: corev1.ConditionFalse, reason: infrav1.HostDisconnectedReason},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.state), func(t *testing.T) {
+			g := NewWithT(t)
+			vm := &infrav1.VSphereVM{Status: infrav1.VSphereVMStatus{Host: "esxi-1"}}
+			markHostHealth(vm, tt.state)
+
+			c := conditions.Get(vm, infrav1.HostHealthyCondition)
+			g.Expect(c).NotTo(BeNil())
+			g.Expect(c.Status).To(Equal(tt.status))
+			g.Expect(c.Reason).To(Equal(tt.reason))
+
+			nodeCondition := nodeHostHealthyCondition(c)
+			g.Expect(nodeCondition.Type).To(Equal(infrav1.NodeHostHealthyCondition))
+			g.Expect(nodeCondition.Status).To(Equal(tt.status))
+			if tt.reason != "" {
+				g.Expect(nodeCondition.Reason).To(Equal(tt.reason))
+				g.Expect(nodeCondition.Message).To(ContainSubstring("esxi-1"))
+			}
+		})
+	}
+}
+
+func Test_setNodeCondition(t *testing.T) {
+	g := NewWithT(t)
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	node := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+	}}}
+
+	unhealthy := corev1.NodeCondition{Type: infrav1.NodeHostHealthyCondition, Status: corev1.ConditionFalse, Reason: infrav1.HostNotRespondingReason}
+	g.Expect(setNodeCondition(node, unhealthy)).To(BeTrue())
+	g.Expect(node.Status.Conditions).To(HaveLen(2))
+	g.Expect(setNodeCondition(node, unhealthy)).To(BeFalse())
+
+	// The transition time is kept while the status does not change.
+	node.Status.Conditions[1].LastTransitionTime = past
+	disconnected := unhealthy
+	disconnected.Reason = infrav1.HostDisconnectedReason
+	g.Expect(setNodeCondition(node, disconnected)).To(BeTrue())
+	g.Expect(node.Status.Conditions[1].Reason).To(Equal(infrav1.HostDisconnectedReason))
+	g.Expect(node.Status.Conditions[1].LastTransitionTime).To(Equal(past))
+
+	g.Expect(setNodeCondition(node, nodeHostHealthyCondition(nil))).To(BeTrue())
+	g.Expect(node.Status.Conditions[1].Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(node.Status.Conditions[1].LastTransitionTime).NotTo(Equal(past))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+const (
+	// recentMigrationPeriod is the time the RecentlyMigratedCondition stays
+	// true after a migration of the VM.
+	recentMigrationPeriod = 10 * time.Minute
+
+	// migrationEventsMaxCount is the number of recent migration events of a
+	// VM looked up to find why it changed host.
+	migrationEventsMaxCount = 10
+)
+
+// migrationEventTypes are the vCenter events of the VMs moved to another
+// host.
+var migrationEventTypes = []string{
+	"VmMigratedEvent",
+	"DrsVmMigratedEvent",
+	"VmRelocatedEvent",
+	"VmRestartedOnAlternateHostEvent",
+}
+
+// reconcileMigration sets the RecentlyMigratedCondition of the VSphereVM when
+// its VM runs on another host than the one it was last seen on, and turns it
+// false once the VM stayed on its host for recentMigrationPeriod.
+func reconcileMigration(ctx *virtualMachineContext, previousHost, host string) {
+	if previousHost == "" || previousHost == host {
+		markMigrationSettled(ctx.VSphereVM, time.Now())
+		return
+	}
+
+	reason := infrav1.MigratedReason
+	events, err := event.NewManager(ctx.Session.Client.Client).QueryEvents(ctx, types.EventFilterSpec{
+		Entity: &types.EventFilterSpecByEntity{
+			Entity:    ctx.Ref,
+			Recursion: types.EventFilterSpecRecursionOptionSelf,
+		},
+		EventTypeId: migrationEventTypes,
+		MaxCount:    migrationEventsMaxCount,
+	})
+	if err != nil {
+		ctx.Logger.Error(err, "unable to get the migration events of the VM")
+	} else {
+		reason = migrationReason(events)
+	}
+
+	markMigrated(ctx.VSphereVM, reason, previousHost, host)
+	ctx.Logger.Info("VM was moved to another host", "sourceHost", previousHost, "host", host, "reason", reason)
+	ctx.Recorder.Eventf(ctx.VSphereVM, reason, "VM moved from host %s to host %s", previousHost, host)
+}
+
+// migrationReason returns the reason of the RecentlyMigratedCondition of a VM
+// from its latest migration event.
+func migrationReason(events []types.BaseEvent) string {
+	var latest types.BaseEvent
+	for _, e := range events {
+		if latest == nil || e.GetEvent().CreatedTime.After(latest.GetEvent().CreatedTime) {
+			latest = e
+		}
+	}
+	switch latest.(type) {
+	case *types.DrsVmMigratedEvent:
+		return infrav1.DRSMigratedReason
+	case *types.VmRestartedOnAlternateHostEvent:
+		return infrav1.HARestartedReason
+	default:
+		return infrav1.MigratedReason
+	}
+}
+
+// markMigrated sets the RecentlyMigratedCondition of a VSphereVM whose VM
+// moved to another host. The condition is replaced so that its transition
+// time is the time of the latest migration.
+func markMigrated(vm *infrav1.VSphereVM, reason, previousHost, host string) {
+	conditions.Delete(vm, infrav1.RecentlyMigratedCondition)
+	conditions.Set(vm, &clusterv1.Condition{
+		Type:    infrav1.RecentlyMigratedCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: fmt.Sprintf("moved from host %s to host %s", previousHost, host),
+	})
+}
+
+// markMigrationSettled turns the RecentlyMigratedCondition of a VSphereVM
+// false once its VM stayed on its host for recentMigrationPeriod. The message
+// of the last migration is kept.
+func markMigrationSettled(vm *infrav1.VSphereVM, now time.Time) {
+	c := conditions.Get(vm, infrav1.RecentlyMigratedCondition)
+	if c == nil || c.Status != corev1.ConditionTrue || now.Sub(c.LastTransitionTime.Time) < recentMigrationPeriod {
+		return
+	}
+	conditions.MarkFalse(vm, infrav1.RecentlyMigratedCondition, infrav1.MigrationSettledReason, clusterv1.ConditionSeverityInfo, "%s", c.Message)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_migrationReason(t *testing.T) {
+	now := time.Now()
+	vMotion := &types.VmMigratedEvent{VmEvent: types.VmEvent{Event: types.Event{CreatedTime: now.Add(-time.Hour)}}}
+	drs := &types.DrsVmMigratedEvent{VmMigratedEvent: types.VmMigratedEvent{VmEvent: types.VmEvent{Event: types.Event{CreatedTime: now.Add(-time.Minute)}}}}
+	ha := &types.VmRestartedOnAlternateHostEvent{VmPoweredOnEvent: types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{CreatedTime: now}}}}
+
+	tests := []struct {
+		name   string
+		events []types.BaseEvent
+		reason string
+	}{
+		{name: "without events", reason: infrav1.MigratedReason},
+		{name: "vMotion", events: []types.BaseEvent{vMotion}, reason: infrav1.MigratedReason},
+		{name: "latest migration by DRS", events: []types.BaseEvent{drs, vMotion}, reason: infrav1.DRSMigratedReason},
+		{name: "latest restart by HA", events: []types.BaseEvent{vMotion, ha, drs}, reason: infrav1.HARestartedReason},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(migrationReason(tt.events)).To(Equal(tt.reason))
+		})
+	}
+}
+
+func Test_markMigrationSettled(t *testing.T) {
+	g := NewWithT(t)
+	vm := &infrav1.VSphereVM{}
+
+	markMigrated(vm, infrav1.HARestartedReason, "esxi-1", "esxi-2")
+	c := conditions.Get(vm, infrav1.RecentlyMigratedCondition)
+	g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(c.Reason).To(Equal(infrav1.HARestartedReason))
+	g.Expect(c.Message).To(Equal("moved from host esxi-1 to host esxi-2"))
+
+	markMigrationSettled(vm, time.Now())
+	g.Expect(conditions.IsTrue(vm, infrav1.RecentlyMigratedCondition)).To(BeTrue())
+
+	markMigrationSettled(vm, time.Now().Add(recentMigrationPeriod))
+	c = conditions.Get(vm, infrav1.RecentlyMigratedCondition)
+	g.Expect(c.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(c.Reason).To(Equal(infrav1.MigrationSettledReason))
+	g.Expect(c.Message).To(Equal("moved from host esxi-1 to host esxi-2"))
+}
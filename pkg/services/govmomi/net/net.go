@@ -64,6 +64,13 @@ func GetNetworkStatus(
 	if err := pc.RetrieveOne(ctx, moRef, props, &obj); err != nil {
 		return nil, errors.Wrapf(err, "unable to fetch props %v for vm %v", props, moRef)
 	}
+	return GetNetworkStatusFromObject(obj)
+}
+
+// GetNetworkStatusFromObject returns the network information for a VM whose
+// config.hardware.device and guest.net properties have already been
+// retrieved.
+func GetNetworkStatusFromObject(obj mo.VirtualMachine) ([]NetworkStatus, error) {
 	if obj.Config == nil {
 		return nil, errors.New("config.hardware.device is nil")
 	}
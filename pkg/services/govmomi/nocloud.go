@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/cdrom"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/nocloud"
+)
+
+// usesNoCloudISO returns true if the bootstrap data of the VM is delivered
+// through a NoCloud ISO image, as decided when it was cloned.
+func usesNoCloudISO(vm *infrav1.VSphereVM) bool {
+	_, ok := vm.Annotations[infrav1.NoCloudISOAnnotation]
+	return ok
+}
+
+// getNoCloudISOSpec uploads the NoCloud ISO image with the bootstrap data and
+// the metadata to the directory of the VM, and returns the config spec
+// inserting it in a CD-ROM drive of the VM. An existing drive without ISO
+// image is used if any.
+func (vms *VMService) getNoCloudISOSpec(ctx *virtualMachineContext, metadata []byte) (*types.VirtualMachineConfigSpec, error) {
+	bootstrapData, format, err := vms.getBootstrapData(&ctx.VMContext)
+	if err != nil {
+		return nil, err
+	}
+	if bootstrapData, err = injectCloudConfigs(&ctx.VMContext, bootstrapData, format); err != nil {
+		return nil, err
+	}
+	iso, err := nocloud.SeedISO(bootstrapData, metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create the NoCloud ISO image for vm %s", ctx)
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.files.vmPathName", "config.hardware.device"}, &obj); err != nil {
+		return nil, errors.Wrapf(err, "unable to fetch the files and devices of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return nil, errors.Errorf("no config for vm %s", ctx)
+	}
+	var vmPath object.DatastorePath
+	if !vmPath.FromString(obj.Config.Files.VmPathName) {
+		return nil, errors.Errorf("invalid path %q of vm %s", obj.Config.Files.VmPathName, ctx)
+	}
+	datastore, err := ctx.Session.Finder.Datastore(ctx, vmPath.Datastore)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find the datastore %q of vm %s", vmPath.Datastore, ctx)
+	}
+	isoPath := path.Join(path.Dir(vmPath.Path), nocloud.ISOFileName)
+	if err := datastore.Upload(ctx, bytes.NewReader(iso), isoPath, &soap.Upload{ContentLength: int64(len(iso))}); err != nil {
+		return nil, errors.Wrapf(err, "unable to upload the NoCloud ISO image of vm %s", ctx)
+	}
+	isoFile := datastore.Path(isoPath)
+	ctx.VSphereVM.Annotations[infrav1.NoCloudISOAnnotation] = isoFile
+
+	spec := &types.VirtualMachineConfigSpec{
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: nocloud.MetadataKey, Value: base64.StdEncoding.EncodeToString(metadata)},
+		},
+	}
+
+	devices := object.VirtualDeviceList(obj.Config.Hardware.Device)
+	for _, device := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		if backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualCdromIsoBackingInfo); ok && backing.FileName == isoFile {
+			// The ISO image was already inserted, only its content changed.
+			return spec, nil
+		}
+	}
+	if spec.DeviceChange, err = cdrom.InsertSpecs(devices, []string{isoFile}); err != nil {
+		return nil, errors.Wrapf(err, "unable to insert the NoCloud ISO image in vm %s", ctx)
+	}
+	return spec, nil
+}
+
+// deleteNoCloudISO deletes the NoCloud ISO image of the VM, which is not
+// deleted along with it.
+func (vms *VMService) deleteNoCloudISO(ctx *virtualMachineContext) error {
+	isoFile := ctx.VSphereVM.Annotations[infrav1.NoCloudISOAnnotation]
+	if isoFile == "" {
+		return nil
+	}
+	datacenter, err := ctx.Session.Finder.DatacenterOrDefault(ctx, ctx.VSphereVM.Spec.Datacenter)
+	if err != nil {
+		return errors.Wrapf(err, "unable to find the datacenter of vm %s", ctx)
+	}
+	task, err := object.NewFileManager(ctx.Session.Client.Client).DeleteDatastoreFile(ctx, isoFile, datacenter)
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil && !types.IsFileNotFound(err) {
+		return errors.Wrapf(err, "unable to delete the NoCloud ISO image %s of vm %s", isoFile, ctx)
+	}
+	return nil
+}
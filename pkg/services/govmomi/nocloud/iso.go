@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nocloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sectorSize = 2048
+
+	// The layout of the image: the volume descriptors follow the system
+	// area, then come the path tables, the root directory and the data of
+	// the files.
+	primaryVolumeDescriptorSector = 16
+	terminatorSector              = 17
+	lPathTableSector              = 18
+	mPathTableSector              = 19
+	rootDirectorySector           = 20
+	firstFileSector               = 21
+
+	// pathTableSize is the size of a path table holding the root directory
+	// only.
+	pathTableSize = 10
+)
+
+// File is a file of an ISO image.
+type File struct {
+	// Name is the name of the file. It is stored upper cased, as Linux maps
+	// the names of ISO 9660 file systems without extensions to lower case.
+	Name string
+
+	// Data is the content of the file.
+	Data []byte
+}
+
+// NewISO returns an ISO 9660 image with the given volume identifier and the
+// given files in its root directory.
+func NewISO(volumeID string, files []File) ([]byte, error) {
+	files = append([]File(nil), files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	now := time.Now().UTC()
+
+	// Build the root directory, which locates the data of the files.
+	dir := &bytes.Buffer{}
+	dir.Write(directoryRecord([]byte{0}, rootDirectorySector, sectorSize, true, now))
+	dir.Write(directoryRecord([]byte{1}, rootDirectorySector, sectorSize, true, now))
+	sector := uint32(firstFileSector)
+	for _, f := range files {
+		if f.Name == "" || len(f.Name) > 30 || strings.ContainsAny(f.Name, "/;") {
+			return nil, errors.Errorf("invalid file name %q", f.Name)
+		}
+		dir.Write(directoryRecord([]byte(strings.ToUpper(f.Name)+";1"), sector, uint32(len(f.Data)), false, now))
+		sector += sectors(len(f.Data))
+	}
+	if dir.Len() > sectorSize {
+		return nil, errors.Errorf("too many files to fit in the root directory: %d", len(files))
+	}
+
+	image := make([]byte, int(sector)*sectorSize)
+	copy(image[primaryVolumeDescriptorSector*sectorSize:], primaryVolumeDescriptor(volumeID, sector, now))
+	copy(image[terminatorSector*sectorSize:], []byte{255, 'C', 'D', '0', '0', '1', 1})
+	copy(image[lPathTableSector*sectorSize:], pathTable(binary.LittleEndian))
+	copy(image[mPathTableSector*sectorSize:], pathTable(binary.BigEndian))
+	copy(image[rootDirectorySector*sectorSize:], dir.Bytes())
+	offset := firstFileSector * sectorSize
+	for _, f := range files {
+		copy(image[offset:], f.Data)
+		offset += int(sectors(len(f.Data))) * sectorSize
+	}
+	return image, nil
+}
+
+// sectors returns the number of sectors needed to store size bytes.
+func sectors(size int) uint32 {
+	return uint32((size + sectorSize - 1) / sectorSize)
+}
+
+func primaryVolumeDescriptor(volumeID string, volumeSize uint32, now time.Time) []byte {
+	d := make([]byte, sectorSize)
+	d[0] = 1
+	copy(d[1:6], "CD001")
+	d[6] = 1
+	copy(d[8:40], padded("", 32))
+	copy(d[40:72], padded(strings.ToUpper(volumeID), 32))
+	putBothEndian32(d[80:88], volumeSize)
+	putBothEndian16(d[120:124], 1)
+	putBothEndian16(d[124:128], 1)
+	putBothEndian16(d[128:132], sectorSize)
+	putBothEndian32(d[132:140], pathTableSize)
+	binary.LittleEndian.PutUint32(d[140:144], lPathTableSector)
+	binary.BigEndian.PutUint32(d[148:152], mPathTableSector)
+	copy(d[156:190], directoryRecord([]byte{0}, rootDirectorySector, sectorSize, true, now))
+	// The volume set, publisher, data preparer, application, copyright,
+	// abstract and bibliographic file identifiers are left blank.
+	copy(d[190:813], padded("", 813-190))
+	copy(d[813:830], decimalTime(now))
+	copy(d[830:847], decimalTime(now))
+	copy(d[847:864], decimalTime(time.Time{}))
+	copy(d[864:881], decimalTime(time.Time{}))
+	d[881] = 1
+	return d
+}
+
+func pathTable(order binary.ByteOrder) []byte {
+	t := make([]byte, pathTableSize)
+	t[0] = 1
+	order.PutUint32(t[2:6], rootDirectorySector)
+	order.PutUint16(t[6:8], 1)
+	return t
+}
+
+func directoryRecord(identifier []byte, sector, size uint32, isDir bool, now time.Time) []byte {
+	length := 33 + len(identifier)
+	if len(identifier)%2 == 0 {
+		length++
+	}
+	r := make([]byte, length)
+	r[0] = byte(length)
+	putBothEndian32(r[2:10], sector)
+	putBothEndian32(r[10:18], size)
+	r[18] = byte(now.Year() - 1900)
+	r[19] = byte(now.Month())
+	r[20] = byte(now.Day())
+	r[21] = byte(now.Hour())
+	r[22] = byte(now.Minute())
+	r[23] = byte(now.Second())
+	if isDir {
+		r[25] = 2
+	}
+	putBothEndian16(r[28:32], 1)
+	r[32] = byte(len(identifier))
+	copy(r[33:], identifier)
+	return r
+}
+
+// decimalTime returns the time in the format of the volume descriptors. The
+// zero time is the unspecified time.
+func decimalTime(t time.Time) []byte {
+	if t.IsZero() {
+		return append([]byte(strings.Repeat("0", 16)), 0)
+	}
+	return append([]byte(fmt.Sprintf("%04d%02d%02d%02d%02d%02d%02d",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/int(10*time.Millisecond))), 0)
+}
+
+func padded(s string, n int) []byte {
+	return []byte(s + strings.Repeat(" ", n-len(s)))
+}
+
+func putBothEndian16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b[0:2], v)
+	binary.BigEndian.PutUint16(b[2:4], v)
+}
+
+func putBothEndian32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:4], v)
+	binary.BigEndian.PutUint32(b[4:8], v)
+}
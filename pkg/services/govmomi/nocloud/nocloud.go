@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nocloud delivers the bootstrap data and metadata of a VM through an
+// ISO image attached to it, as read by the NoCloud datasource of cloud-init.
+// It is used for the bootstrap data exceeding the size of the guestinfo keys.
+package nocloud
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MaxGuestInfoSize is the maximum size of the base64 encoded bootstrap
+	// data delivered through the guestinfo keys of a VM. Larger data is not
+	// reliably delivered, depending on the version of vSphere.
+	MaxGuestInfoSize = 64 * 1024
+
+	// VolumeID is the volume identifier of the ISO image, which the NoCloud
+	// datasource looks for.
+	VolumeID = "CIDATA"
+
+	// ISOFileName is the name of the ISO image in the directory of the VM.
+	ISOFileName = "cidata.iso"
+
+	// MetadataKey is the extraConfig key holding the metadata of the ISO
+	// image of a VM, so that changes of the metadata are detected. It is not
+	// exposed to the guest, so that the VMware datasource of cloud-init does
+	// not pick the metadata without the user data.
+	MetadataKey = "capv.nocloud.metadata"
+)
+
+// ExceedsGuestInfoLimit returns true if the bootstrap data does not fit in the
+// guestinfo keys of a VM.
+func ExceedsGuestInfoLimit(bootstrapData []byte) bool {
+	return base64.StdEncoding.EncodedLen(len(bootstrapData)) > MaxGuestInfoSize
+}
+
+// SeedISO returns the NoCloud ISO image with the given user data and
+// metadata. The network configuration of the metadata is written to its own
+// file, where the NoCloud datasource reads it.
+func SeedISO(userData, metadata []byte) ([]byte, error) {
+	files := []File{
+		{Name: "user-data", Data: userData},
+		{Name: "meta-data", Data: metadata},
+	}
+
+	var doc struct {
+		Network interface{} `json:"network"`
+	}
+	if err := yaml.Unmarshal(metadata, &doc); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the metadata")
+	}
+	if doc.Network != nil {
+		network, err := yaml.Marshal(doc.Network)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to marshal the network configuration")
+		}
+		files = append(files, File{Name: "network-config", Data: network})
+	}
+
+	return NewISO(VolumeID, files)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nocloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// readISO returns the volume identifier and the files of the root directory
+// of an ISO image created by NewISO.
+func readISO(g *WithT, image []byte) (string, map[string][]byte) {
+	g.Expect(len(image) % sectorSize).To(BeZero())
+	pvd := image[primaryVolumeDescriptorSector*sectorSize:]
+	g.Expect(pvd[0]).To(Equal(byte(1)))
+	g.Expect(string(pvd[1:6])).To(Equal("CD001"))
+	g.Expect(binary.LittleEndian.Uint32(pvd[80:84])).To(Equal(uint32(len(image) / sectorSize)))
+	g.Expect(string(image[terminatorSector*sectorSize+1 : terminatorSector*sectorSize+6])).To(Equal("CD001"))
+
+	root := pvd[156:190]
+	dir := image[binary.LittleEndian.Uint32(root[2:6])*sectorSize:]
+	dir = dir[:binary.LittleEndian.Uint32(root[10:14])]
+
+	files := map[string][]byte{}
+	for len(dir) > 0 && dir[0] > 0 {
+		record := dir[:dir[0]]
+		dir = dir[dir[0]:]
+		if record[25]&2 != 0 {
+			continue
+		}
+		name := string(record[33 : 33+record[32]])
+		extent := binary.LittleEndian.Uint32(record[2:6]) * sectorSize
+		files[name] = image[extent : extent+binary.LittleEndian.Uint32(record[10:14])]
+	}
+	return strings.TrimRight(string(pvd[40:72]), " "), files
+}
+
+func TestNewISO(t *testing.T) {
+	g := NewWithT(t)
+
+	large := bytes.Repeat([]byte("x"), 3*sectorSize+1)
+	image, err := NewISO("cidata", []File{
+		{Name: "user-data", Data: large},
+		{Name: "meta-data", Data: []byte("instance-id: test-vm\n")},
+		{Name: "empty"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	volumeID, files := readISO(g, image)
+	g.Expect(volumeID).To(Equal("CIDATA"))
+	g.Expect(files).To(HaveLen(3))
+	g.Expect(files).To(HaveKeyWithValue("USER-DATA;1", large))
+	g.Expect(files).To(HaveKeyWithValue("META-DATA;1", []byte("instance-id: test-vm\n")))
+	g.Expect(files).To(HaveKeyWithValue("EMPTY;1", BeEmpty()))
+
+	_, err = NewISO("cidata", []File{{Name: "a;b"}})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSeedISO(t *testing.T) {
+	g := NewWithT(t)
+
+	metadata := []byte(`
+instance-id: "test-vm"
+local-hostname: "test-vm"
+network:
+  version: 2
+  ethernets:
+    id0:
+      dhcp4: true
+`)
+	image, err := SeedISO([]byte("#cloud-config\n"), metadata)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	volumeID, files := readISO(g, image)
+	g.Expect(volumeID).To(Equal(VolumeID))
+	g.Expect(files).To(HaveKeyWithValue("USER-DATA;1", []byte("#cloud-config\n")))
+	g.Expect(files).To(HaveKeyWithValue("META-DATA;1", metadata))
+	g.Expect(files).To(HaveKeyWithValue("NETWORK-CONFIG;1", []byte("ethernets:\n  id0:\n    dhcp4: true\nversion: 2\n")))
+}
+
+func TestExceedsGuestInfoLimit(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(ExceedsGuestInfoLimit(make([]byte, 1024))).To(BeFalse())
+	g.Expect(ExceedsGuestInfoLimit(make([]byte, MaxGuestInfoSize))).To(BeTrue())
+}
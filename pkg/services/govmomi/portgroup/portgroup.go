@@ -0,0 +1,271 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package portgroup manages the distributed port groups created for clusters.
+package portgroup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// DefaultNumPorts is the initial number of ports of the port groups which do
+// not set one. The port groups expand automatically beyond it.
+const DefaultNumPorts = 8
+
+// ownerDescriptionPrefix prefixes the description of the port groups created
+// by Ensure, which is followed by their owner.
+const ownerDescriptionPrefix = "Created by Cluster API Provider vSphere for "
+
+// errNotOwned is returned by Delete for a port group which was not created
+// for the given owner.
+var errNotOwned = errors.New("port group was not created by Cluster API Provider vSphere")
+
+// Ensure ensures the distributed port group with the given name exists on the
+// distributed switch of the spec, with its VLAN and security policy, and
+// returns its managed object reference and inventory path. The number of
+// ports is only set when the port group is created, since it expands
+// automatically as VMs are connected to it.
+//
+// The port group is marked as created for the given owner by its
+// description. An existing port group with the same name which is not marked
+// for the owner, e.g. created by an administrator or for another cluster, is
+// neither adopted nor reconfigured.
+func Ensure(ctx context.Context, s *session.Session, name, owner string, spec *infrav1.PortGroupSpec) (*infrav1.InventoryObject, error) {
+	finder := find.NewFinder(s.Client.Client, false)
+	dc, err := finder.DatacenterOrDefault(ctx, spec.Datacenter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find datacenter %q", spec.Datacenter)
+	}
+	finder.SetDatacenter(dc)
+
+	network, err := finder.Network(ctx, spec.Switch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find distributed switch %q", spec.Switch)
+	}
+	dvs, ok := network.(*object.DistributedVirtualSwitch)
+	if !ok {
+		return nil, errors.Errorf("%q is not a distributed switch", spec.Switch)
+	}
+
+	config := configSpec(name, owner, spec)
+	pg, err := findPortGroup(ctx, s, dvs, name)
+	if err != nil {
+		return nil, err
+	}
+	if pg != nil && !ownedBy(pg.Config, owner) {
+		return nil, errors.Errorf("port group %q already exists on distributed switch %q and was not created for %s", name, spec.Switch, owner)
+	}
+	switch {
+	case pg == nil:
+		t, err := dvs.AddPortgroup(ctx, []types.DVPortgroupConfigSpec{config})
+		if err == nil {
+			err = t.Wait(ctx)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create port group %q on distributed switch %q", name, spec.Switch)
+		}
+		if pg, err = findPortGroup(ctx, s, dvs, name); err != nil {
+			return nil, err
+		}
+		if pg == nil {
+			return nil, errors.Errorf("port group %q not found on distributed switch %q after its creation", name, spec.Switch)
+		}
+	case !upToDate(pg.Config, config):
+		config.ConfigVersion = pg.Config.ConfigVersion
+		config.NumPorts = pg.Config.NumPorts
+		t, err := object.NewDistributedVirtualPortgroup(s.Client.Client, pg.Reference()).Reconfigure(ctx, config)
+		if err == nil {
+			err = t.Wait(ctx)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to reconfigure port group %q", name)
+		}
+	}
+
+	path, err := find.InventoryPath(ctx, s.Client.Client, pg.Reference())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get the inventory path of port group %q", name)
+	}
+	return &infrav1.InventoryObject{Ref: pg.Reference().String(), Path: path}, nil
+}
+
+// Delete deletes the distributed port group with the given managed object
+// reference, if it was created by Ensure for the given owner. A port group
+// which no longer exists is considered deleted.
+func Delete(ctx context.Context, s *session.Session, ref, owner string) error {
+	var moRef types.ManagedObjectReference
+	if !moRef.FromString(ref) {
+		return errors.Errorf("invalid managed object reference %q", ref)
+	}
+	var pg mo.DistributedVirtualPortgroup
+	if err := s.RetrieveOne(ctx, moRef, []string{"config"}, &pg); err != nil {
+		if isManagedObjectNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to get port group %s", ref)
+	}
+	if !ownedBy(pg.Config, owner) {
+		return errors.Wrapf(errNotOwned, "failed to delete port group %s for %s", ref, owner)
+	}
+	t, err := object.NewDistributedVirtualPortgroup(s.Client.Client, moRef).Destroy(ctx)
+	if err == nil {
+		err = t.Wait(ctx)
+	}
+	if err != nil && !isManagedObjectNotFound(err) {
+		return errors.Wrapf(err, "failed to delete port group %s", ref)
+	}
+	return nil
+}
+
+// IsInUse returns true if the error is returned by Delete for a port group
+// that VMs are still connected to.
+func IsInUse(err error) bool {
+	if taskErr, ok := errors.Cause(err).(task.Error); ok {
+		_, ok := taskErr.Fault().(*types.ResourceInUse)
+		return ok
+	}
+	return false
+}
+
+// IsNotOwned returns true if the error is returned by Delete for a port group
+// which was not created for the owner.
+func IsNotOwned(err error) bool {
+	return errors.Cause(err) == errNotOwned
+}
+
+// ownerDescription returns the description marking a port group as created
+// for an owner.
+func ownerDescription(owner string) string {
+	return fmt.Sprintf("%s%s", ownerDescriptionPrefix, owner)
+}
+
+// ownedBy returns true if a port group was created by Ensure for the owner.
+func ownedBy(info types.DVPortgroupConfigInfo, owner string) bool {
+	return info.Description == ownerDescription(owner)
+}
+
+// findPortGroup returns the port group of the distributed switch with the
+// given name, or nil if there is none.
+func findPortGroup(ctx context.Context, s *session.Session, dvs *object.DistributedVirtualSwitch, name string) (*mo.DistributedVirtualPortgroup, error) {
+	var dvsMo mo.DistributedVirtualSwitch
+	if err := s.RetrieveOne(ctx, dvs.Reference(), []string{"portgroup"}, &dvsMo); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the port groups of distributed switch %s", dvs.Reference().Value)
+	}
+	if len(dvsMo.Portgroup) == 0 {
+		return nil, nil
+	}
+	var pgs []mo.DistributedVirtualPortgroup
+	if err := s.Retrieve(ctx, dvsMo.Portgroup, []string{"name", "config"}, &pgs); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the port groups of distributed switch %s", dvs.Reference().Value)
+	}
+	for i := range pgs {
+		if pgs[i].Name == name {
+			return &pgs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// configSpec returns the configuration of a port group.
+func configSpec(name, owner string, spec *infrav1.PortGroupSpec) types.DVPortgroupConfigSpec {
+	numPorts := spec.NumPorts
+	if numPorts == 0 {
+		numPorts = DefaultNumPorts
+	}
+	setting := &types.VMwareDVSPortSetting{
+		Vlan: &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: spec.VLANID},
+	}
+	if policy := spec.SecurityPolicy; policy != nil {
+		setting.SecurityPolicy = &types.DVSSecurityPolicy{
+			AllowPromiscuous: boolPolicy(policy.AllowPromiscuous),
+			MacChanges:       boolPolicy(policy.MACChanges),
+			ForgedTransmits:  boolPolicy(policy.ForgedTransmits),
+		}
+	}
+	return types.DVPortgroupConfigSpec{
+		Name:              name,
+		Description:       ownerDescription(owner),
+		Type:              string(types.DistributedVirtualPortgroupPortgroupTypeEarlyBinding),
+		NumPorts:          numPorts,
+		AutoExpand:        types.NewBool(true),
+		DefaultPortConfig: setting,
+	}
+}
+
+func boolPolicy(value *bool) *types.BoolPolicy {
+	if value == nil {
+		return nil
+	}
+	return &types.BoolPolicy{Value: types.NewBool(*value)}
+}
+
+// upToDate returns true if the VLAN and the security policy of a port group
+// are the ones of its configuration spec. The security settings the spec
+// leaves unset are not compared, as they are inherited from the switch.
+func upToDate(info types.DVPortgroupConfigInfo, spec types.DVPortgroupConfigSpec) bool {
+	current, ok := info.DefaultPortConfig.(*types.VMwareDVSPortSetting)
+	if !ok {
+		return false
+	}
+	desired := spec.DefaultPortConfig.(*types.VMwareDVSPortSetting)
+
+	vlan, ok := current.Vlan.(*types.VmwareDistributedVirtualSwitchVlanIdSpec)
+	if !ok || vlan.VlanId != desired.Vlan.(*types.VmwareDistributedVirtualSwitchVlanIdSpec).VlanId {
+		return false
+	}
+
+	if desired.SecurityPolicy == nil {
+		return true
+	}
+	var policy types.DVSSecurityPolicy
+	if current.SecurityPolicy != nil {
+		policy = *current.SecurityPolicy
+	}
+	return boolPolicyUpToDate(policy.AllowPromiscuous, desired.SecurityPolicy.AllowPromiscuous) &&
+		boolPolicyUpToDate(policy.MacChanges, desired.SecurityPolicy.MacChanges) &&
+		boolPolicyUpToDate(policy.ForgedTransmits, desired.SecurityPolicy.ForgedTransmits)
+}
+
+func boolPolicyUpToDate(current, desired *types.BoolPolicy) bool {
+	if desired == nil {
+		return true
+	}
+	return current != nil && current.Value != nil && *current.Value == *desired.Value
+}
+
+func isManagedObjectNotFound(err error) bool {
+	if soap.IsSoapFault(err) {
+		_, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound)
+		return ok
+	}
+	if taskErr, ok := err.(task.Error); ok {
+		_, ok := taskErr.Fault().(*types.ManagedObjectNotFound)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portgroup
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+func TestEnsureAndDelete(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	model := simulator.VPX()
+	g.Expect(model.Create()).To(Succeed())
+	defer model.Remove()
+	model.Service.TLS = new(tls.Config)
+	server := model.Service.NewServer()
+	defer server.Close()
+
+	pass, _ := server.URL.User.Password()
+	s, err := session.GetOrCreate(ctx, session.NewParams().
+		WithServer(server.URL.Host).
+		WithUserInfo(server.URL.User.Username(), pass).
+		WithDatacenter("*"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	const owner = "VSphereCluster ns/cluster"
+	spec := &infrav1.PortGroupSpec{Switch: "DVS0", VLANID: 100}
+
+	pg, err := Ensure(ctx, s, "ns_cluster", owner, spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pg.Path).To(Equal("/DC0/network/ns_cluster"))
+
+	var ref types.ManagedObjectReference
+	g.Expect(ref.FromString(pg.Ref)).To(BeTrue())
+	created := simulator.Map.Get(ref).(*simulator.DistributedVirtualPortgroup)
+	g.Expect(created.Config.NumPorts).To(Equal(int32(DefaultNumPorts)))
+	setting := created.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting)
+	g.Expect(setting.Vlan.(*types.VmwareDistributedVirtualSwitchVlanIdSpec).VlanId).To(Equal(int32(100)))
+	g.Expect(setting.SecurityPolicy).To(BeNil())
+
+	// The existing port group is reconfigured.
+	spec.VLANID = 200
+	spec.SecurityPolicy = &infrav1.PortGroupSecurityPolicy{ForgedTransmits: pointer.Bool(true)}
+	again, err := Ensure(ctx, s, "ns_cluster", owner, spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again).To(Equal(pg))
+	setting = created.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting)
+	g.Expect(setting.Vlan.(*types.VmwareDistributedVirtualSwitchVlanIdSpec).VlanId).To(Equal(int32(200)))
+	g.Expect(*setting.SecurityPolicy.ForgedTransmits.Value).To(BeTrue())
+	g.Expect(setting.SecurityPolicy.AllowPromiscuous).To(BeNil())
+	g.Expect(upToDate(created.Config, configSpec("ns_cluster", owner, spec))).To(BeTrue())
+
+	g.Expect(created.Config.Description).To(Equal(ownerDescription(owner)))
+
+	_, err = Ensure(ctx, s, "ns_cluster", owner, &infrav1.PortGroupSpec{Switch: "DC0_DVPG0"})
+	g.Expect(err).To(MatchError(ContainSubstring("is not a distributed switch")))
+
+	// The port group of another owner is neither adopted nor deleted.
+	_, err = Ensure(ctx, s, "ns_cluster", "VSphereCluster ns/other", spec)
+	g.Expect(err).To(MatchError(ContainSubstring("was not created for VSphereCluster ns/other")))
+	err = Delete(ctx, s, pg.Ref, "VSphereCluster ns/other")
+	g.Expect(IsNotOwned(err)).To(BeTrue())
+	g.Expect(simulator.Map.Get(ref)).NotTo(BeNil())
+
+	g.Expect(Delete(ctx, s, pg.Ref, owner)).To(Succeed())
+	g.Expect(simulator.Map.Get(ref)).To(BeNil())
+	// Deleting the port group again is a no-op.
+	g.Expect(Delete(ctx, s, pg.Ref, owner)).To(Succeed())
+}
+
+func TestEnsureAndDeleteUnmarkedPortGroup(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	model := simulator.VPX()
+	g.Expect(model.Create()).To(Succeed())
+	defer model.Remove()
+	model.Service.TLS = new(tls.Config)
+	server := model.Service.NewServer()
+	defer server.Close()
+
+	pass, _ := server.URL.User.Password()
+	s, err := session.GetOrCreate(ctx, session.NewParams().
+		WithServer(server.URL.Host).
+		WithUserInfo(server.URL.User.Username(), pass).
+		WithDatacenter("*"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// DC0_DVPG0 is created by the simulator, without the description of
+	// the port groups created by Ensure.
+	existing := simulator.Map.Any("DistributedVirtualPortgroup").(*simulator.DistributedVirtualPortgroup)
+	vlan := existing.Config.DefaultPortConfig
+
+	_, err = Ensure(ctx, s, existing.Name, "VSphereCluster ns/cluster", &infrav1.PortGroupSpec{Switch: "DVS0", VLANID: 100})
+	g.Expect(err).To(MatchError(ContainSubstring("already exists")))
+	g.Expect(existing.Config.DefaultPortConfig).To(Equal(vlan))
+
+	err = Delete(ctx, s, existing.Reference().String(), "VSphereCluster ns/cluster")
+	g.Expect(IsNotOwned(err)).To(BeTrue())
+	g.Expect(simulator.Map.Get(existing.Reference())).NotTo(BeNil())
+}
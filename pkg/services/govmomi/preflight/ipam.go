@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// CheckAddressPools checks that the IPAM pools the network devices of a clone
+// spec claim their IP addresses from exist, in the namespace of the machines
+// unless they are cluster-scoped. The pools the client is not allowed to read
+// are not checked. The returned error is only set when the pools could not be
+// read.
+func CheckAddressPools(ctx context.Context, c client.Client, namespace string, spec *infrav1.VirtualMachineCloneSpec, fldPath string) ([]Failure, error) {
+	var failures []Failure
+	for i, device := range spec.Network.Devices {
+		for j, poolRef := range device.AddressesFromPools {
+			poolPath := fmt.Sprintf("%s.network.devices[%d].addressesFromPools[%d]", fldPath, i, j)
+			if poolRef.APIGroup == nil || *poolRef.APIGroup == "" {
+				failures = append(failures, failure(infrav1.AddressPoolNotFoundReason, clusterv1.ConditionSeverityError, "%s.apiGroup: the API group of the pool is not set", poolPath))
+				continue
+			}
+			gk := schema.GroupKind{Group: *poolRef.APIGroup, Kind: poolRef.Kind}
+			mapping, err := c.RESTMapper().RESTMapping(gk)
+			if err != nil {
+				if meta.IsNoMatchError(err) {
+					failures = append(failures, failure(infrav1.AddressPoolNotFoundReason, clusterv1.ConditionSeverityError, "%s: the %s kind is not installed", poolPath, gk))
+					continue
+				}
+				return nil, errors.Wrapf(err, "unable to get the resource of the %s kind", gk)
+			}
+
+			key := client.ObjectKey{Namespace: namespace, Name: poolRef.Name}
+			if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+				key.Namespace = ""
+			}
+			pool := &unstructured.Unstructured{}
+			pool.SetGroupVersionKind(mapping.GroupVersionKind)
+			if err := c.Get(ctx, key, pool); err != nil {
+				if apierrors.IsNotFound(err) {
+					failures = append(failures, failure(infrav1.AddressPoolNotFoundReason, clusterv1.ConditionSeverityError, "%s: %s %s not found", poolPath, gk, key))
+					continue
+				}
+				if apierrors.IsForbidden(err) {
+					// The pools of IPAM providers the client may not read
+					// are not checked.
+					continue
+				}
+				return nil, errors.Wrapf(err, "unable to get %s %s", gk, key)
+			}
+		}
+	}
+	return failures, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func TestCheckAddressPools(t *testing.T) {
+	poolGVK := schema.GroupVersionKind{Group: "ipam.cluster.x-k8s.io", Version: "v1alpha1", Kind: "InClusterIPPool"}
+	globalPoolGVK := schema.GroupVersionKind{Group: "ipam.cluster.x-k8s.io", Version: "v1alpha1", Kind: "GlobalInClusterIPPool"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(poolGVK, meta.RESTScopeNamespace)
+	mapper.Add(globalPoolGVK, meta.RESTScopeRoot)
+
+	pool := &unstructured.Unstructured{}
+	pool.SetGroupVersionKind(poolGVK)
+	pool.SetNamespace("default")
+	pool.SetName("pool")
+	globalPool := &unstructured.Unstructured{}
+	globalPool.SetGroupVersionKind(globalPoolGVK)
+	globalPool.SetName("global-pool")
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRESTMapper(mapper).WithObjects(pool, globalPool).Build()
+
+	ref := func(kind, name string) corev1.TypedLocalObjectReference {
+		return corev1.TypedLocalObjectReference{APIGroup: pointer.String("ipam.cluster.x-k8s.io"), Kind: kind, Name: name}
+	}
+	tests := []struct {
+		name     string
+		pools    []corev1.TypedLocalObjectReference
+		messages []string
+	}{
+		{
+			name:  "existing pools pass",
+			pools: []corev1.TypedLocalObjectReference{ref("InClusterIPPool", "pool"), ref("GlobalInClusterIPPool", "global-pool")},
+		},
+		{
+			name:     "a missing pool fails",
+			pools:    []corev1.TypedLocalObjectReference{ref("InClusterIPPool", "pool"), ref("InClusterIPPool", "missing")},
+			messages: []string{"spec.network.devices[0].addressesFromPools[1]: InClusterIPPool.ipam.cluster.x-k8s.io default/missing not found"},
+		},
+		{
+			name:     "a kind which is not installed fails",
+			pools:    []corev1.TypedLocalObjectReference{ref("MissingIPPool", "pool")},
+			messages: []string{"spec.network.devices[0].addressesFromPools[0]: the MissingIPPool.ipam.cluster.x-k8s.io kind is not installed"},
+		},
+		{
+			name:     "a pool without API group fails",
+			pools:    []corev1.TypedLocalObjectReference{{Kind: "InClusterIPPool", Name: "pool"}},
+			messages: []string{"spec.network.devices[0].addressesFromPools[0].apiGroup: the API group of the pool is not set"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := &infrav1.VirtualMachineCloneSpec{
+				Network: infrav1.NetworkSpec{Devices: []infrav1.NetworkDeviceSpec{{AddressesFromPools: tt.pools}}},
+			}
+			failures, err := CheckAddressPools(context.Background(), c, "default", spec, "spec")
+			g.Expect(err).NotTo(HaveOccurred())
+			var messages []string
+			for _, f := range failures {
+				g.Expect(f.Reason).To(Equal(infrav1.AddressPoolNotFoundReason))
+				messages = append(messages, f.Message)
+			}
+			g.Expect(messages).To(Equal(tt.messages))
+		})
+	}
+}
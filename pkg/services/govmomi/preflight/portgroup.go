@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	capvfind "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// CheckPortGroups checks that the distributed port groups of the network
+// devices of a clone spec are neither VLAN trunks nor isolated private VLANs,
+// in which the machines cannot reach each other, and that they have the VLAN
+// ID and security policy of the validation, if any. The control plane
+// security policy of the validation is only expected for control plane
+// machines. The standard and NSX port groups, and the networks which are not
+// found, are not checked. All the failures have the Warning severity.
+func CheckPortGroups(ctx context.Context, s *session.Session, spec *infrav1.VirtualMachineCloneSpec, validation *infrav1.PortGroupValidation, controlPlane bool, fldPath string) ([]Failure, error) {
+	finder := find.NewFinder(s.Client.Client, false)
+	dc, err := finder.DatacenterOrDefault(ctx, spec.Datacenter)
+	if err != nil {
+		// The missing datacenter is reported by CheckCloneSpec, and fails
+		// the clone of the VMs.
+		return nil, nil
+	}
+	finder.SetDatacenter(dc)
+
+	expected := expectedSecurityPolicy(validation, controlPlane)
+	var failures []Failure
+	for i, device := range spec.Network.Devices {
+		if device.NetworkName == "" {
+			continue
+		}
+		network, err := capvfind.ByMoRef(s.Client.Client, "Network", capvfind.Network(finder))(ctx, device.NetworkName)
+		if err != nil {
+			continue
+		}
+		if network.Reference().Type != "DistributedVirtualPortgroup" {
+			continue
+		}
+		pg := object.NewDistributedVirtualPortgroup(s.Client.Client, network.Reference())
+		var pgMo mo.DistributedVirtualPortgroup
+		if err := pg.Properties(ctx, pg.Reference(), []string{"name", "config"}, &pgMo); err != nil {
+			return nil, errors.Wrapf(err, "unable to get the properties of port group %s", device.NetworkName)
+		}
+		setting, ok := pgMo.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting)
+		if !ok || pgMo.Config.BackingType == string(types.DistributedVirtualPortgroupBackingTypeNsx) {
+			continue
+		}
+
+		devicePath := fmt.Sprintf("%s.network.devices[%d].networkName", fldPath, i)
+		problems, err := vlanProblems(ctx, s, pgMo.Config, setting, validation)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, securityPolicyProblems(setting.SecurityPolicy, expected)...)
+		for _, problem := range problems {
+			failures = append(failures, failure(infrav1.PortGroupIncompatibleReason, clusterv1.ConditionSeverityWarning,
+				"%s: port group %s %s", devicePath, pgMo.Name, problem))
+		}
+	}
+	return failures, nil
+}
+
+// vlanProblems returns the problems of the VLAN of a port group.
+func vlanProblems(ctx context.Context, s *session.Session, config types.DVPortgroupConfigInfo, setting *types.VMwareDVSPortSetting, validation *infrav1.PortGroupValidation) ([]string, error) {
+	var problems []string
+	vlanID := int32(-1)
+	switch vlan := setting.Vlan.(type) {
+	case *types.VmwareDistributedVirtualSwitchVlanIdSpec:
+		vlanID = vlan.VlanId
+	case *types.VmwareDistributedVirtualSwitchTrunkVlanSpec:
+		problems = append(problems, "is a VLAN trunk, whose traffic must be tagged by the guests")
+	case *types.VmwareDistributedVirtualSwitchPvlanSpec:
+		isolated, err := isIsolatedPvlan(ctx, s, config.DistributedVirtualSwitch, vlan.PvlanId)
+		if err != nil {
+			return nil, err
+		}
+		if isolated {
+			problems = append(problems, fmt.Sprintf("is in the isolated private VLAN %d, in which the machines cannot reach each other", vlan.PvlanId))
+		}
+	}
+	if validation != nil && validation.VLANID != nil && vlanID != *validation.VLANID {
+		if vlanID < 0 {
+			problems = append(problems, fmt.Sprintf("does not have a VLAN ID, %d is expected", *validation.VLANID))
+		} else {
+			problems = append(problems, fmt.Sprintf("has the VLAN ID %d, %d is expected", vlanID, *validation.VLANID))
+		}
+	}
+	return problems, nil
+}
+
+// isIsolatedPvlan returns true if the secondary private VLAN of a distributed
+// switch is an isolated one.
+func isIsolatedPvlan(ctx context.Context, s *session.Session, dvs *types.ManagedObjectReference, pvlanID int32) (bool, error) {
+	if dvs == nil {
+		return false, nil
+	}
+	var dvsMo mo.DistributedVirtualSwitch
+	if err := s.RetrieveOne(ctx, *dvs, []string{"config"}, &dvsMo); err != nil {
+		return false, errors.Wrapf(err, "unable to get the configuration of distributed switch %s", dvs.Value)
+	}
+	config, ok := dvsMo.Config.(*types.VMwareDVSConfigInfo)
+	if !ok {
+		return false, nil
+	}
+	for _, entry := range config.PvlanConfig {
+		if entry.SecondaryVlanId == pvlanID {
+			return entry.PvlanType == string(types.VmwareDistributedVirtualSwitchPvlanPortTypeIsolated), nil
+		}
+	}
+	return false, nil
+}
+
+// expectedSecurityPolicy returns the security policy a port group is expected
+// to have, merging the control plane security policy of the validation for
+// control plane machines.
+func expectedSecurityPolicy(validation *infrav1.PortGroupValidation, controlPlane bool) infrav1.PortGroupSecurityPolicy {
+	var expected infrav1.PortGroupSecurityPolicy
+	if validation == nil {
+		return expected
+	}
+	policies := []*infrav1.PortGroupSecurityPolicy{validation.SecurityPolicy}
+	if controlPlane {
+		policies = append(policies, validation.ControlPlaneSecurityPolicy)
+	}
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if policy.AllowPromiscuous != nil {
+			expected.AllowPromiscuous = policy.AllowPromiscuous
+		}
+		if policy.MACChanges != nil {
+			expected.MACChanges = policy.MACChanges
+		}
+		if policy.ForgedTransmits != nil {
+			expected.ForgedTransmits = policy.ForgedTransmits
+		}
+	}
+	return expected
+}
+
+// securityPolicyProblems returns the settings of the security policy of a port
+// group which differ from the expected ones. The settings which are not
+// reported are not allowed, as by default.
+func securityPolicyProblems(policy *types.DVSSecurityPolicy, expected infrav1.PortGroupSecurityPolicy) []string {
+	if policy == nil {
+		policy = &types.DVSSecurityPolicy{}
+	}
+	var problems []string
+	for _, setting := range []struct {
+		name     string
+		actual   *types.BoolPolicy
+		expected *bool
+	}{
+		{"promiscuous mode", policy.AllowPromiscuous, expected.AllowPromiscuous},
+		{"MAC address changes", policy.MacChanges, expected.MACChanges},
+		{"forged transmits", policy.ForgedTransmits, expected.ForgedTransmits},
+	} {
+		if setting.expected == nil {
+			continue
+		}
+		actual := setting.actual != nil && setting.actual.Value != nil && *setting.actual.Value
+		switch {
+		case *setting.expected && !actual:
+			problems = append(problems, fmt.Sprintf("does not allow %s, which the cluster expects", setting.name))
+		case !*setting.expected && actual:
+			problems = append(problems, fmt.Sprintf("allows %s, which the cluster does not expect", setting.name))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestCheckPortGroups(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	s, err := session.GetOrCreate(context.Background(), session.NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	network, err := s.Finder.Network(context.Background(), "/DC0/network/DC0_DVPG0")
+	g.Expect(err).NotTo(HaveOccurred())
+	pg := simulator.Map.Get(network.Reference()).(*simulator.DistributedVirtualPortgroup)
+	dvs := simulator.Map.Get(*pg.Config.DistributedVirtualSwitch).(*simulator.DistributedVirtualSwitch)
+	dvs.Config.(*types.VMwareDVSConfigInfo).PvlanConfig = []types.VMwareDVSPvlanMapEntry{
+		{PrimaryVlanId: 10, SecondaryVlanId: 11, PvlanType: string(types.VmwareDistributedVirtualSwitchPvlanPortTypeIsolated)},
+		{PrimaryVlanId: 10, SecondaryVlanId: 12, PvlanType: string(types.VmwareDistributedVirtualSwitchPvlanPortTypeCommunity)},
+	}
+
+	spec := &infrav1.VirtualMachineCloneSpec{
+		Datacenter: "DC0",
+		Network: infrav1.NetworkSpec{
+			Devices: []infrav1.NetworkDeviceSpec{{NetworkName: "DC0_DVPG0"}, {NetworkName: "VM Network"}, {NetworkName: "missing"}},
+		},
+	}
+	forgedTransmits := &types.DVSSecurityPolicy{ForgedTransmits: &types.BoolPolicy{Value: types.NewBool(true)}}
+
+	tests := []struct {
+		name         string
+		vlan         types.BaseVmwareDistributedVirtualSwitchVlanSpec
+		security     *types.DVSSecurityPolicy
+		validation   *infrav1.PortGroupValidation
+		controlPlane bool
+		messages     []string
+	}{
+		{
+			name: "a port group with a VLAN ID passes without validation",
+			vlan: &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: 100},
+		},
+		{
+			name:     "a VLAN trunk fails",
+			vlan:     &types.VmwareDistributedVirtualSwitchTrunkVlanSpec{VlanId: []types.NumericRange{{Start: 100, End: 200}}},
+			messages: []string{"spec.network.devices[0].networkName: port group DC0_DVPG0 is a VLAN trunk, whose traffic must be tagged by the guests"},
+		},
+		{
+			name:     "an isolated private VLAN fails",
+			vlan:     &types.VmwareDistributedVirtualSwitchPvlanSpec{PvlanId: 11},
+			messages: []string{"spec.network.devices[0].networkName: port group DC0_DVPG0 is in the isolated private VLAN 11, in which the machines cannot reach each other"},
+		},
+		{
+			name: "a community private VLAN passes",
+			vlan: &types.VmwareDistributedVirtualSwitchPvlanSpec{PvlanId: 12},
+		},
+		{
+			name:       "another VLAN ID than the expected one fails",
+			vlan:       &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: 100},
+			validation: &infrav1.PortGroupValidation{VLANID: pointer.Int32(200)},
+			messages:   []string{"spec.network.devices[0].networkName: port group DC0_DVPG0 has the VLAN ID 100, 200 is expected"},
+		},
+		{
+			name: "the control plane security policy is only expected for control plane machines",
+			vlan: &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: 100},
+			validation: &infrav1.PortGroupValidation{
+				SecurityPolicy:             &infrav1.PortGroupSecurityPolicy{AllowPromiscuous: pointer.Bool(false)},
+				ControlPlaneSecurityPolicy: &infrav1.PortGroupSecurityPolicy{ForgedTransmits: pointer.Bool(true), MACChanges: pointer.Bool(true)},
+			},
+			security: forgedTransmits,
+		},
+		{
+			name: "a control plane machine without the control plane security policy fails",
+			vlan: &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: 100},
+			validation: &infrav1.PortGroupValidation{
+				SecurityPolicy:             &infrav1.PortGroupSecurityPolicy{ForgedTransmits: pointer.Bool(false)},
+				ControlPlaneSecurityPolicy: &infrav1.PortGroupSecurityPolicy{ForgedTransmits: pointer.Bool(true), MACChanges: pointer.Bool(true)},
+			},
+			security:     forgedTransmits,
+			controlPlane: true,
+			messages:     []string{"spec.network.devices[0].networkName: port group DC0_DVPG0 does not allow MAC address changes, which the cluster expects"},
+		},
+		{
+			name:       "a worker machine with a security policy allowing more than expected fails",
+			vlan:       &types.VmwareDistributedVirtualSwitchVlanIdSpec{VlanId: 100},
+			validation: &infrav1.PortGroupValidation{SecurityPolicy: &infrav1.PortGroupSecurityPolicy{ForgedTransmits: pointer.Bool(false)}},
+			security:   forgedTransmits,
+			messages:   []string{"spec.network.devices[0].networkName: port group DC0_DVPG0 allows forged transmits, which the cluster does not expect"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			pg.Config.DefaultPortConfig = &types.VMwareDVSPortSetting{Vlan: tt.vlan, SecurityPolicy: tt.security}
+
+			failures, err := CheckPortGroups(context.Background(), s, spec, tt.validation, tt.controlPlane, "spec")
+			g.Expect(err).NotTo(HaveOccurred())
+			messages := make([]string, 0, len(failures))
+			for _, f := range failures {
+				g.Expect(f.Reason).To(Equal(infrav1.PortGroupIncompatibleReason))
+				messages = append(messages, f.Message)
+			}
+			g.Expect(messages).To(ConsistOf(tt.messages))
+		})
+	}
+
+	t.Run("a port group given by managed object ID is checked", func(t *testing.T) {
+		g := NewWithT(t)
+		pg.Config.DefaultPortConfig = &types.VMwareDVSPortSetting{Vlan: &types.VmwareDistributedVirtualSwitchTrunkVlanSpec{VlanId: []types.NumericRange{{Start: 100, End: 200}}}}
+		byMoRef := &infrav1.VirtualMachineCloneSpec{
+			Datacenter: "DC0",
+			Network: infrav1.NetworkSpec{
+				Devices: []infrav1.NetworkDeviceSpec{{NetworkName: "moref:" + network.Reference().Value}},
+			},
+		}
+
+		failures, err := CheckPortGroups(context.Background(), s, byMoRef, nil, false, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Message).To(Equal("spec.network.devices[0].networkName: port group DC0_DVPG0 is a VLAN trunk, whose traffic must be tagged by the guests"))
+	})
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight checks that the VMs of a cluster can be cloned before the
+// first of them is.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	capvfind "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+const (
+	// privilegeClone and privilegeDeployTemplate allow cloning a VM and a
+	// template respectively.
+	privilegeClone          = "VirtualMachine.Provisioning.Clone"
+	privilegeDeployTemplate = "VirtualMachine.Provisioning.DeployTemplate"
+	// privilegeCreateFromExisting allows creating the clones in a folder.
+	privilegeCreateFromExisting = "VirtualMachine.Inventory.CreateFromExisting"
+	// privilegeAssignVMToPool allows creating the clones in a resource pool.
+	privilegeAssignVMToPool = "Resource.AssignVMToPool"
+	// privilegeAllocateSpace allows creating the clones on a datastore.
+	privilegeAllocateSpace = "Datastore.AllocateSpace"
+
+	// endpointTimeout bounds the resolution of the control plane endpoint and
+	// the attempt to connect to it.
+	endpointTimeout = 5 * time.Second
+)
+
+// Failure is a failed preflight check.
+type Failure struct {
+	// Reason is the reason of the PreflightChecksSucceeded condition
+	// documenting the failure.
+	Reason string
+
+	// Severity is Error for the failures preventing the VMs from being
+	// cloned, and Warning for the failures which may not.
+	Severity clusterv1.ConditionSeverity
+
+	Message string
+}
+
+func failure(reason string, severity clusterv1.ConditionSeverity, format string, args ...interface{}) Failure {
+	return Failure{Reason: reason, Severity: severity, Message: fmt.Sprintf(format, args...)}
+}
+
+// CheckCloneSpec checks that the template of a clone spec exists, that the
+// session has the privileges to clone it to the folder, resource pool and
+// datastore of the spec, and that the datastore has room for a full clone.
+// The fields left empty are not checked. The returned error is only set when
+// vCenter could not be queried.
+func CheckCloneSpec(ctx context.Context, s *session.Session, spec *infrav1.VirtualMachineCloneSpec, fldPath string) ([]Failure, error) {
+	// The finder of the session is shared, so the datacenter is set on a
+	// finder of its own.
+	finder := find.NewFinder(s.Client.Client, false)
+	dc, err := finder.DatacenterOrDefault(ctx, spec.Datacenter)
+	if err != nil {
+		return []Failure{failure(infrav1.InventoryNotFoundReason, clusterv1.ConditionSeverityError, "%s.datacenter: %s", fldPath, err)}, nil
+	}
+	finder.SetDatacenter(dc)
+
+	var failures []Failure
+	tpl, err := findTemplate(ctx, s, finder, dc, spec.Template)
+	if err != nil {
+		return append(failures, failure(infrav1.TemplateNotFoundReason, clusterv1.ConditionSeverityError, "%s.template: %s", fldPath, err)), nil
+	}
+	var tplMo mo.VirtualMachine
+	if err := tpl.Properties(ctx, tpl.Reference(), []string{"config.template", "summary.storage"}, &tplMo); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the properties of template %s", spec.Template)
+	}
+
+	clonePrivilege := privilegeClone
+	if tplMo.Config != nil && tplMo.Config.Template {
+		clonePrivilege = privilegeDeployTemplate
+	}
+	checks := []privilegeCheck{{tpl.Reference(), "template", clonePrivilege}}
+
+	// The folders of templates are created when the VMs are cloned. The
+	// objects given by managed object ID are found as the VMs are cloned.
+	if spec.Folder != "" && !infrav1.IsFolderTemplate(spec.Folder) {
+		folder, err := capvfind.ByMoRef(s.Client.Client, "Folder", capvfind.Folder(finder))(ctx, spec.Folder)
+		if err != nil {
+			failures = append(failures, failure(infrav1.InventoryNotFoundReason, clusterv1.ConditionSeverityError, "%s.folder: %s", fldPath, err))
+		} else {
+			checks = append(checks, privilegeCheck{folder.Reference(), "folder", privilegeCreateFromExisting})
+		}
+	}
+	if spec.ResourcePool != "" {
+		pool, err := capvfind.ByMoRef(s.Client.Client, "ResourcePool", capvfind.ResourcePool(finder))(ctx, spec.ResourcePool)
+		if err != nil {
+			failures = append(failures, failure(infrav1.InventoryNotFoundReason, clusterv1.ConditionSeverityError, "%s.resourcePool: %s", fldPath, err))
+		} else {
+			checks = append(checks, privilegeCheck{pool.Reference(), "resourcePool", privilegeAssignVMToPool})
+		}
+	}
+	var datastore *object.Datastore
+	if spec.Datastore != "" {
+		obj, err := capvfind.ByMoRef(s.Client.Client, "Datastore", capvfind.Datastore(finder))(ctx, spec.Datastore)
+		if err != nil {
+			failures = append(failures, failure(infrav1.InventoryNotFoundReason, clusterv1.ConditionSeverityError, "%s.datastore: %s", fldPath, err))
+		} else {
+			datastore = object.NewDatastore(s.Client.Client, obj.Reference())
+			checks = append(checks, privilegeCheck{datastore.Reference(), "datastore", privilegeAllocateSpace})
+		}
+	}
+
+	userSession, err := s.SessionManager.UserSession(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get the vCenter session")
+	}
+	if userSession == nil {
+		return nil, errors.New("the vCenter session is not logged in")
+	}
+	authz := object.NewAuthorizationManager(s.Client.Client)
+	for _, check := range checks {
+		granted, err := authz.HasPrivilegeOnEntity(ctx, check.ref, userSession.Key, []string{check.privilege})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to check the privileges on %s", check.ref)
+		}
+		if len(granted) == 0 || !granted[0] {
+			failures = append(failures, failure(infrav1.PermissionsMissingReason, clusterv1.ConditionSeverityError,
+				"%s.%s: user %s lacks the %s privilege", fldPath, check.field, userSession.UserName, check.privilege))
+		}
+	}
+
+	if datastore != nil {
+		var dsMo mo.Datastore
+		if err := datastore.Properties(ctx, datastore.Reference(), []string{"summary"}, &dsMo); err != nil {
+			return nil, errors.Wrapf(err, "unable to get the properties of datastore %s", spec.Datastore)
+		}
+		required := requiredSpace(spec, tplMo.Summary.Storage)
+		if dsMo.Summary.FreeSpace < required {
+			failures = append(failures, failure(infrav1.DatastoreSpaceLowReason, clusterv1.ConditionSeverityWarning,
+				"%s.datastore: %d GiB free, a full clone requires %d GiB", fldPath, dsMo.Summary.FreeSpace>>30, required>>30))
+		}
+	}
+	return failures, nil
+}
+
+// privilegeCheck is a privilege required on an object referenced by a field
+// of a clone spec.
+type privilegeCheck struct {
+	ref       types.ManagedObjectReference
+	field     string
+	privilege string
+}
+
+// requiredSpace returns the space in bytes a full clone of a template takes,
+// which is the storage of the template, or the disk size of the spec if it is
+// larger.
+func requiredSpace(spec *infrav1.VirtualMachineCloneSpec, storage *types.VirtualMachineStorageSummary) int64 {
+	var required int64
+	if storage != nil {
+		required = storage.Committed + storage.Uncommitted
+	}
+	if disk := int64(spec.DiskGiB) << 30; disk > required {
+		required = disk
+	}
+	return required
+}
+
+// findTemplate finds a template by managed object ID, instance UUID or name,
+// as the VMs are cloned.
+func findTemplate(ctx context.Context, s *session.Session, finder *find.Finder, dc *object.Datacenter, template string) (*object.VirtualMachine, error) {
+	if infrav1.IsMoRef(template) {
+		obj, err := capvfind.MoRef(ctx, s.Client.Client, "VirtualMachine", template)
+		if err != nil {
+			return nil, err
+		}
+		return obj.(*object.VirtualMachine), nil
+	}
+	if _, err := uuid.Parse(template); err == nil {
+		ref, err := object.NewSearchIndex(s.Client.Client).FindByUuid(ctx, dc, template, true, pointer.Bool(true))
+		if err != nil {
+			return nil, err
+		}
+		if ref != nil {
+			return object.NewVirtualMachine(s.Client.Client, ref.Reference()), nil
+		}
+	}
+	return finder.VirtualMachine(ctx, template)
+}
+
+// Resolver resolves host names.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// CheckControlPlaneEndpoint checks that the host of a control plane endpoint
+// resolves, and that nothing answers on the endpoint yet, as the control plane
+// the endpoint is for is not created.
+func CheckControlPlaneEndpoint(ctx context.Context, resolver Resolver, endpoint infrav1.APIEndpoint) []Failure {
+	if endpoint.IsZero() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, endpointTimeout)
+	defer cancel()
+	if net.ParseIP(endpoint.Host) == nil {
+		if _, err := resolver.LookupHost(ctx, endpoint.Host); err != nil {
+			return []Failure{failure(infrav1.ControlPlaneEndpointUnreachableReason, clusterv1.ConditionSeverityError,
+				"spec.controlPlaneEndpoint.host: %s", strings.TrimPrefix(err.Error(), "lookup "))}
+		}
+	}
+
+	address := net.JoinHostPort(endpoint.Host, strconv.Itoa(int(endpoint.Port)))
+	dialer := &net.Dialer{}
+	if conn, err := dialer.DialContext(ctx, "tcp", address); err == nil {
+		_ = conn.Close()
+		return []Failure{failure(infrav1.ControlPlaneEndpointUnreachableReason, clusterv1.ConditionSeverityWarning,
+			"spec.controlPlaneEndpoint: %s already answers before the control plane is created, it may be used by another machine", address)}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestCheckCloneSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	s, err := session.GetOrCreate(context.Background(), session.NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spec := func() *infrav1.VirtualMachineCloneSpec {
+		return &infrav1.VirtualMachineCloneSpec{
+			Datacenter:   "DC0",
+			Template:     "DC0_H0_VM0",
+			Folder:       "/DC0/vm",
+			Datastore:    "LocalDS_0",
+			ResourcePool: "/DC0/host/DC0_C0/Resources",
+		}
+	}
+
+	t.Run("a clonable spec passes", func(t *testing.T) {
+		g := NewWithT(t)
+		failures, err := CheckCloneSpec(context.Background(), s, spec(), "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+
+	t.Run("a missing template fails", func(t *testing.T) {
+		g := NewWithT(t)
+		missing := spec()
+		missing.Template = "missing-template"
+		failures, err := CheckCloneSpec(context.Background(), s, missing, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateNotFoundReason))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityError))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.template: "))
+	})
+
+	t.Run("missing inventory fails", func(t *testing.T) {
+		g := NewWithT(t)
+		missing := spec()
+		missing.Folder = "/DC0/vm/missing"
+		failures, err := CheckCloneSpec(context.Background(), s, missing, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.InventoryNotFoundReason))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.folder: "))
+	})
+
+	t.Run("the inventory can be given by managed object ID", func(t *testing.T) {
+		g := NewWithT(t)
+		folder, err := s.Finder.Folder(context.Background(), "/DC0/vm")
+		g.Expect(err).NotTo(HaveOccurred())
+		pool, err := s.Finder.ResourcePool(context.Background(), "/DC0/host/DC0_C0/Resources")
+		g.Expect(err).NotTo(HaveOccurred())
+		datastore, err := s.Finder.Datastore(context.Background(), "/DC0/datastore/LocalDS_0")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		byMoRef := spec()
+		byMoRef.Folder = "moref:" + folder.Reference().Value
+		byMoRef.ResourcePool = "moref:" + pool.Reference().Value
+		byMoRef.Datastore = "moref:" + datastore.Reference().Value
+		failures, err := CheckCloneSpec(context.Background(), s, byMoRef, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+
+		byMoRef.Datastore = "moref:datastore-missing"
+		failures, err = CheckCloneSpec(context.Background(), s, byMoRef, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.datastore: "))
+	})
+
+	t.Run("a datastore without room for a clone is a warning", func(t *testing.T) {
+		g := NewWithT(t)
+		large := spec()
+		large.DiskGiB = 1 << 30
+		failures, err := CheckCloneSpec(context.Background(), s, large, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.DatastoreSpaceLowReason))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+	})
+}
+
+func Test_requiredSpace(t *testing.T) {
+	g := NewWithT(t)
+	storage := &types.VirtualMachineStorageSummary{Committed: 2 << 30, Uncommitted: 18 << 30}
+	g.Expect(requiredSpace(&infrav1.VirtualMachineCloneSpec{}, storage)).To(Equal(int64(20 << 30)))
+	g.Expect(requiredSpace(&infrav1.VirtualMachineCloneSpec{DiskGiB: 40}, storage)).To(Equal(int64(40 << 30)))
+	g.Expect(requiredSpace(&infrav1.VirtualMachineCloneSpec{DiskGiB: 10}, nil)).To(Equal(int64(10 << 30)))
+}
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.Errorf("lookup %s: no such host", host)
+}
+
+func TestCheckControlPlaneEndpoint(t *testing.T) {
+	g := NewWithT(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+	usedPort := int32(listener.Addr().(*net.TCPAddr).Port)
+
+	unused, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	unusedPort := int32(unused.Addr().(*net.TCPAddr).Port)
+	g.Expect(unused.Close()).To(Succeed())
+
+	resolver := fakeResolver{"localhost": {"127.0.0.1"}}
+
+	t.Run("an unset endpoint is not checked", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(CheckControlPlaneEndpoint(context.Background(), resolver, infrav1.APIEndpoint{})).To(BeEmpty())
+	})
+
+	t.Run("an unused endpoint passes", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(CheckControlPlaneEndpoint(context.Background(), resolver, infrav1.APIEndpoint{Host: "127.0.0.1", Port: unusedPort})).To(BeEmpty())
+	})
+
+	t.Run("an unresolvable host fails", func(t *testing.T) {
+		g := NewWithT(t)
+		failures := CheckControlPlaneEndpoint(context.Background(), resolver, infrav1.APIEndpoint{Host: "api.missing.example.com", Port: 6443})
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.ControlPlaneEndpointUnreachableReason))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityError))
+	})
+
+	t.Run("an endpoint already in use is a warning", func(t *testing.T) {
+		g := NewWithT(t)
+		failures := CheckControlPlaneEndpoint(context.Background(), resolver, infrav1.APIEndpoint{Host: "127.0.0.1", Port: usedPort})
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+		g.Expect(failures[0].Message).To(ContainSubstring("already answers"))
+	})
+}
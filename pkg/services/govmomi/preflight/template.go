@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/template"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
+)
+
+const (
+	// tpmHardwareVersion is the oldest hardware version supporting a virtual
+	// trusted platform module.
+	tpmHardwareVersion = "vmx-14"
+
+	// virtualIOMMUHardwareVersion is the oldest hardware version supporting
+	// a virtual IOMMU.
+	virtualIOMMUHardwareVersion = "vmx-14"
+)
+
+// templateProperties are the properties of the templates CheckTemplate
+// checks.
+var templateProperties = []string{"config.version", "config.firmware", "config.guestId", "config.tools", "guest.toolsVersionStatus2"}
+
+// TemplateCache caches the properties of the templates checked by its
+// CheckTemplate, by vCenter and managed object, so that the templates used by
+// several machine templates, or checked on every reconcile of their cluster,
+// are retrieved from vCenter once per TTL. A nil cache caches nothing.
+type TemplateCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[templateKey]templateEntry
+}
+
+type templateKey struct {
+	server string
+	ref    types.ManagedObjectReference
+}
+
+type templateEntry struct {
+	tpl     mo.VirtualMachine
+	expires time.Time
+}
+
+// NewTemplateCache returns a TemplateCache caching the properties of the
+// templates for ttl.
+func NewTemplateCache(ttl time.Duration) *TemplateCache {
+	return &TemplateCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[templateKey]templateEntry{},
+	}
+}
+
+// CheckTemplate is CheckTemplate with the properties of the templates
+// retrieved through the cache.
+func (c *TemplateCache) CheckTemplate(ctx context.Context, s *session.Session, spec *infrav1.VirtualMachineCloneSpec, fldPath string) ([]Failure, error) {
+	return checkTemplate(ctx, c, s, spec, fldPath)
+}
+
+// properties returns the properties of a template, which are retrieved from
+// vCenter unless they were in the last TTL.
+func (c *TemplateCache) properties(ctx context.Context, s *session.Session, tpl *object.VirtualMachine) (mo.VirtualMachine, error) {
+	var tplMo mo.VirtualMachine
+	if c == nil {
+		err := tpl.Properties(ctx, tpl.Reference(), templateProperties, &tplMo)
+		return tplMo, err
+	}
+
+	key := templateKey{server: s.Client.URL().Host, ref: tpl.Reference()}
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.tpl, nil
+	}
+
+	if err := tpl.Properties(ctx, tpl.Reference(), templateProperties, &tplMo); err != nil {
+		return tplMo, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = templateEntry{tpl: tplMo, expires: now.Add(c.ttl)}
+	return tplMo, nil
+}
+
+// CheckTemplate checks that the template of a clone spec exists, that VMware
+// Tools are installed on it, and that its hardware version, firmware and
+// guest ID are compatible with the spec. Whether cloud-init is installed
+// cannot be told from the inventory, as templates are powered off, and is not
+// checked. The returned error is only set when vCenter could not be queried.
+func CheckTemplate(ctx context.Context, s *session.Session, spec *infrav1.VirtualMachineCloneSpec, fldPath string) ([]Failure, error) {
+	return checkTemplate(ctx, nil, s, spec, fldPath)
+}
+
+func checkTemplate(ctx context.Context, cache *TemplateCache, s *session.Session, spec *infrav1.VirtualMachineCloneSpec, fldPath string) ([]Failure, error) {
+	// The finder of the session is shared, so the datacenter is set on a
+	// finder of its own.
+	finder := find.NewFinder(s.Client.Client, false)
+	dc, err := finder.DatacenterOrDefault(ctx, spec.Datacenter)
+	if err != nil {
+		return []Failure{failure(infrav1.InventoryNotFoundReason, clusterv1.ConditionSeverityError, "%s.datacenter: %s", fldPath, err)}, nil
+	}
+	finder.SetDatacenter(dc)
+
+	tpl, err := findTemplate(ctx, s, finder, dc, spec.Template)
+	if err != nil {
+		return []Failure{failure(infrav1.TemplateNotFoundReason, clusterv1.ConditionSeverityError, "%s.template: %s", fldPath, err)}, nil
+	}
+	tplMo, err := cache.properties(ctx, s, tpl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get the properties of template %s", spec.Template)
+	}
+	if tplMo.Config == nil {
+		return nil, errors.Errorf("template %s has no configuration", spec.Template)
+	}
+
+	var failures []Failure
+	if !toolsInstalled(tplMo) {
+		failures = append(failures, failure(infrav1.TemplateToolsNotInstalledReason, clusterv1.ConditionSeverityWarning,
+			"%s.template: VMware Tools are not installed on template %s", fldPath, spec.Template))
+	}
+
+	if spec.HardwareVersion != "" {
+		older, err := util.LessThan(spec.HardwareVersion, tplMo.Config.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to compare hardware versions %s and %s", spec.HardwareVersion, tplMo.Config.Version)
+		}
+		if older {
+			failures = append(failures, failure(infrav1.TemplateHardwareVersionMismatchReason, clusterv1.ConditionSeverityError,
+				"%s.hardwareVersion: template %s has the newer hardware version %s", fldPath, spec.Template, tplMo.Config.Version))
+		}
+	}
+	for _, feature := range []struct {
+		field           string
+		enabled         bool
+		hardwareVersion string
+	}{
+		{"trustedPlatformModule", spec.TrustedPlatformModule, tpmHardwareVersion},
+		{"virtualIOMMU", spec.VirtualIOMMU, virtualIOMMUHardwareVersion},
+	} {
+		if !feature.enabled {
+			continue
+		}
+		// The VMs are upgraded to the hardware version of the spec before the
+		// device is added, so the template may be older.
+		version := tplMo.Config.Version
+		if spec.HardwareVersion != "" {
+			version = spec.HardwareVersion
+		}
+		older, err := util.LessThan(version, feature.hardwareVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to compare hardware versions %s and %s", version, feature.hardwareVersion)
+		}
+		if older {
+			failures = append(failures, failure(infrav1.TemplateHardwareVersionMismatchReason, clusterv1.ConditionSeverityError,
+				"%s.%s: requires hardware version %s or later, template %s has %s", fldPath, feature.field, feature.hardwareVersion, spec.Template, version))
+		}
+	}
+
+	for _, inc := range template.Incompatibilities(spec, tplMo.Config) {
+		failures = append(failures, failure(inc.Reason, clusterv1.ConditionSeverityError, "%s.%s: %s", fldPath, inc.Field, inc.Message))
+	}
+	return failures, nil
+}
+
+// toolsInstalled returns whether VMware Tools are installed on a template,
+// as last reported by its guest or recorded in its configuration.
+func toolsInstalled(tpl mo.VirtualMachine) bool {
+	if tpl.Config.Tools != nil && tpl.Config.Tools.ToolsVersion != 0 {
+		return true
+	}
+	return tpl.Guest != nil && tpl.Guest.ToolsVersionStatus2 != "" &&
+		tpl.Guest.ToolsVersionStatus2 != string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	s, err := session.GetOrCreate(context.Background(), session.NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The templates of the simulator have hardware version vmx-13 and BIOS
+	// firmware, and VMware Tools are installed on DC0_H0_VM0 only.
+	finder := find.NewFinder(s.Client.Client)
+	dc, err := finder.Datacenter(context.Background(), "DC0")
+	g.Expect(err).NotTo(HaveOccurred())
+	finder.SetDatacenter(dc)
+	tpl, err := finder.VirtualMachine(context.Background(), "DC0_H0_VM0")
+	g.Expect(err).NotTo(HaveOccurred())
+	simulator.Map.Get(tpl.Reference()).(*simulator.VirtualMachine).Config.Tools.ToolsVersion = 12352 //nolint:forcetypeassert
+
+	spec := func() *infrav1.VirtualMachineCloneSpec {
+		return &infrav1.VirtualMachineCloneSpec{
+			Datacenter: "DC0",
+			Template:   "DC0_H0_VM0",
+		}
+	}
+
+	t.Run("a valid template passes", func(t *testing.T) {
+		g := NewWithT(t)
+		valid := spec()
+		valid.HardwareVersion = "vmx-15"
+		failures, err := CheckTemplate(context.Background(), s, valid, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+
+	t.Run("a missing template fails", func(t *testing.T) {
+		g := NewWithT(t)
+		missing := spec()
+		missing.Template = "missing-template"
+		failures, err := CheckTemplate(context.Background(), s, missing, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateNotFoundReason))
+	})
+
+	t.Run("a template without VMware Tools is a warning", func(t *testing.T) {
+		g := NewWithT(t)
+		withoutTools := spec()
+		withoutTools.Template = "DC0_H0_VM1"
+		failures, err := CheckTemplate(context.Background(), s, withoutTools, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateToolsNotInstalledReason))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+	})
+
+	t.Run("a template newer than the hardware version of the spec fails", func(t *testing.T) {
+		g := NewWithT(t)
+		older := spec()
+		older.HardwareVersion = "vmx-11"
+		failures, err := CheckTemplate(context.Background(), s, older, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateHardwareVersionMismatchReason))
+		g.Expect(failures[0].Severity).To(Equal(clusterv1.ConditionSeverityError))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.hardwareVersion: "))
+	})
+
+	t.Run("a template with BIOS firmware fails with secure boot", func(t *testing.T) {
+		g := NewWithT(t)
+		secureBoot := spec()
+		secureBoot.SecureBoot = true
+		failures, err := CheckTemplate(context.Background(), s, secureBoot, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateFirmwareMismatchReason))
+	})
+
+	t.Run("the trusted platform module requires a recent hardware version", func(t *testing.T) {
+		g := NewWithT(t)
+		simulator.Map.Get(tpl.Reference()).(*simulator.VirtualMachine).Config.Firmware = string(types.GuestOsDescriptorFirmwareTypeEfi) //nolint:forcetypeassert
+		tpm := spec()
+		tpm.TrustedPlatformModule = true
+		failures, err := CheckTemplate(context.Background(), s, tpm, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateHardwareVersionMismatchReason))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.trustedPlatformModule: "))
+
+		tpm.HardwareVersion = "vmx-15"
+		failures, err = CheckTemplate(context.Background(), s, tpm, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+
+	t.Run("a template of another architecture fails", func(t *testing.T) {
+		g := NewWithT(t)
+		simulator.Map.Get(tpl.Reference()).(*simulator.VirtualMachine).Config.GuestId = "arm-ubuntu64Guest" //nolint:forcetypeassert
+		arm := spec()
+		failures, err := CheckTemplate(context.Background(), s, arm, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+		g.Expect(failures[0].Reason).To(Equal(infrav1.TemplateArchitectureMismatchReason))
+		g.Expect(failures[0].Message).To(HavePrefix("spec.architecture: "))
+
+		arm.Architecture = infrav1.ArchitectureArm64
+		failures, err = CheckTemplate(context.Background(), s, arm, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+
+	t.Run("the properties of the templates are cached", func(t *testing.T) {
+		g := NewWithT(t)
+		now := time.Now()
+		cache := NewTemplateCache(time.Minute)
+		cache.now = func() time.Time { return now }
+		withoutTools := spec()
+		withoutTools.Template = "DC0_H0_VM1"
+		vm1, err := finder.VirtualMachine(context.Background(), withoutTools.Template)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		failures, err := cache.CheckTemplate(context.Background(), s, withoutTools, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+
+		simulator.Map.Get(vm1.Reference()).(*simulator.VirtualMachine).Config.Tools.ToolsVersion = 12352 //nolint:forcetypeassert
+		failures, err = cache.CheckTemplate(context.Background(), s, withoutTools, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(HaveLen(1))
+
+		now = now.Add(2 * time.Minute)
+		failures, err = cache.CheckTemplate(context.Background(), s, withoutTools, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+
+	t.Run("a template can be given by managed object ID", func(t *testing.T) {
+		g := NewWithT(t)
+		byMoRef := spec()
+		byMoRef.Template = infrav1.MoRefPrefix + tpl.Reference().Value
+		byMoRef.Architecture = infrav1.ArchitectureArm64
+		failures, err := CheckTemplate(context.Background(), s, byMoRef, "spec")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(failures).To(BeEmpty())
+	})
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// recoveryAction is the action taken to recover a VM.
+type recoveryAction string
+
+const (
+	recoveryActionNone       recoveryAction = ""
+	recoveryActionPowerOn    recoveryAction = "powerOn"
+	recoveryActionReregister recoveryAction = "reregister"
+	recoveryActionRecreate   recoveryAction = "recreate"
+)
+
+// vmStateReason returns the reason of the VMProvisionedCondition of a VM in a
+// state it does not recover from by itself, along with its severity, or an
+// empty reason if the VM is healthy.
+func vmStateReason(runtime types.VirtualMachineRuntimeInfo) (string, clusterv1.ConditionSeverity) {
+	switch runtime.ConnectionState {
+	case types.VirtualMachineConnectionStateOrphaned:
+		return infrav1.VMOrphanedReason, clusterv1.ConditionSeverityError
+	case types.VirtualMachineConnectionStateInaccessible:
+		return infrav1.VMInaccessibleReason, clusterv1.ConditionSeverityError
+	case types.VirtualMachineConnectionStateInvalid:
+		return infrav1.VMInvalidReason, clusterv1.ConditionSeverityError
+	}
+	if runtime.PowerState == types.VirtualMachinePowerStateSuspended {
+		return infrav1.VMSuspendedReason, clusterv1.ConditionSeverityWarning
+	}
+	return "", clusterv1.ConditionSeverityNone
+}
+
+// getRecoveryAction returns the action the recovery policy of a VSphereVM
+// takes for a VM in the state of the given reason.
+func getRecoveryAction(reason string, policy infrav1.VMRecoveryPolicy, bootstrapped bool) recoveryAction {
+	if reason == infrav1.VMSuspendedReason {
+		switch policy {
+		case infrav1.VMRecoveryPowerOn, infrav1.VMRecoveryReregister, infrav1.VMRecoveryRecreate:
+			return recoveryActionPowerOn
+		}
+		return recoveryActionNone
+	}
+	switch {
+	case policy == infrav1.VMRecoveryReregister:
+		return recoveryActionReregister
+	case policy == infrav1.VMRecoveryRecreate && !bootstrapped:
+		return recoveryActionRecreate
+	}
+	return recoveryActionNone
+}
+
+// recreatesVM returns true if the VM of a VSphereVM is cloned again when it
+// is no longer found in vCenter.
+func recreatesVM(ctx *context.VMContext) bool {
+	return ctx.VSphereVM.Spec.RecoveryPolicy == infrav1.VMRecoveryRecreate && !ctx.Bootstrapped
+}
+
+// reconcileRecovery detects the VMs which are suspended, or orphaned,
+// inaccessible or invalid in vCenter, and which would otherwise never become
+// ready, and recovers them according to the recovery policy of the VSphereVM.
+// It returns false while the VM is in such a state.
+func (vms *VMService) reconcileRecovery(ctx *virtualMachineContext) (bool, error) {
+	var obj mo.VirtualMachine
+	if err := ctx.Obj.Properties(ctx, ctx.Ref, []string{"runtime.connectionState", "runtime.powerState"}, &obj); err != nil {
+		return false, errors.Wrapf(err, "unable to get the state of vm %s", ctx)
+	}
+	reason, severity := vmStateReason(obj.Runtime)
+	if reason == "" {
+		return true, nil
+	}
+
+	action := getRecoveryAction(reason, ctx.VSphereVM.Spec.RecoveryPolicy, ctx.Bootstrapped)
+	ctx.Logger.Info("VM needs to be recovered", "reason", reason, "connectionState", obj.Runtime.ConnectionState,
+		"powerState", obj.Runtime.PowerState, "recoveryPolicy", ctx.VSphereVM.Spec.RecoveryPolicy, "action", action)
+
+	var err error
+	switch action {
+	case recoveryActionNone:
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, reason, severity,
+			"VM is %s in vCenter and is not recovered by the %q recovery policy", vmStateDescription(obj.Runtime), ctx.VSphereVM.Spec.RecoveryPolicy)
+		return false, nil
+	case recoveryActionPowerOn:
+		err = vms.recoverByPowerOn(ctx)
+	case recoveryActionReregister:
+		err = vms.recoverByReregister(ctx)
+	case recoveryActionRecreate:
+		err = vms.recoverByRecreate(ctx)
+	}
+	if err != nil {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.RecoveryFailedReason, clusterv1.ConditionSeverityWarning,
+			"failed to recover the VM %s in vCenter with %s: %v", vmStateDescription(obj.Runtime), action, err)
+		return false, err
+	}
+	conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.RecoveringReason, clusterv1.ConditionSeverityInfo,
+		"VM was %s in vCenter and is being recovered with %s", vmStateDescription(obj.Runtime), action)
+	ctx.Recorder.Eventf(ctx.VSphereVM, infrav1.RecoveringReason, "Recovering the VM %s in vCenter with %s", vmStateDescription(obj.Runtime), action)
+	return false, nil
+}
+
+// vmStateDescription describes the state of a VM needing to be recovered.
+func vmStateDescription(runtime types.VirtualMachineRuntimeInfo) string {
+	if runtime.ConnectionState != "" && runtime.ConnectionState != types.VirtualMachineConnectionStateConnected {
+		return string(runtime.ConnectionState)
+	}
+	return string(runtime.PowerState)
+}
+
+// recoverByPowerOn powers on a suspended VM, which resumes it.
+func (vms *VMService) recoverByPowerOn(ctx *virtualMachineContext) error {
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationPower, ctx.Session.URL().Host)
+	task, err := ctx.Obj.PowerOn(ctx)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to trigger power on op for vm %s", ctx)
+	}
+	return trackRecoveryTask(ctx, task)
+}
+
+// recoverByReregister unregisters a VM and registers it again from its
+// configuration file, on the same host, folder and resource pool. The
+// registered VM keeps the BIOS UUID of the configuration file.
+func (vms *VMService) recoverByReregister(ctx *virtualMachineContext) error {
+	var obj mo.VirtualMachine
+	if err := ctx.Obj.Properties(ctx, ctx.Ref, []string{"summary.config.vmPathName", "parent", "resourcePool", "runtime.host"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to get the configuration file of vm %s", ctx)
+	}
+	vmPathName := obj.Summary.Config.VmPathName
+	if vmPathName == "" || obj.Parent == nil || obj.ResourcePool == nil {
+		return errors.Errorf("unable to find the configuration file, folder and resource pool of vm %s", ctx)
+	}
+	var host *object.HostSystem
+	if obj.Runtime.Host != nil {
+		host = object.NewHostSystem(ctx.Session.Client.Client, *obj.Runtime.Host)
+	}
+
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationRegister, ctx.Session.URL().Host)
+	err := ctx.Obj.Unregister(ctx)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unregister vm %s", ctx)
+	}
+
+	folder := object.NewFolder(ctx.Session.Client.Client, *obj.Parent)
+	pool := object.NewResourcePool(ctx.Session.Client.Client, *obj.ResourcePool)
+	done = metrics.TrackVSphereOperation(metrics.VSphereOperationRegister, ctx.Session.URL().Host)
+	task, err := folder.RegisterVM(ctx, vmPathName, ctx.VSphereVM.VMName(), false, pool, host)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to register vm %s from %s", ctx, vmPathName)
+	}
+	return trackRecoveryTask(ctx, task)
+}
+
+// recoverByRecreate unregisters a VM, so that it is cloned again with the
+// same BIOS UUID by the next reconcile. The files of the VM are left on its
+// datastore, as they are usually inaccessible anyway.
+func (vms *VMService) recoverByRecreate(ctx *virtualMachineContext) error {
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationRegister, ctx.Session.URL().Host)
+	err := ctx.Obj.Unregister(ctx)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unregister vm %s", ctx)
+	}
+	return nil
+}
+
+// trackRecoveryTask records the task recovering a VM on the VSphereVM, so
+// that the VSphereVM is reconciled again once it completes.
+func trackRecoveryTask(ctx *virtualMachineContext, task *object.Task) error {
+	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	if err := ctx.Patch(); err != nil {
+		ctx.Logger.Error(err, "patch failed", "vm", ctx.String())
+		return err
+	}
+	return nil
+}
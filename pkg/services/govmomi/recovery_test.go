@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_vmStateReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		runtime  types.VirtualMachineRuntimeInfo
+		reason   string
+		severity clusterv1.ConditionSeverity
+	}{
+		{
+			name:    "powered on",
+			runtime: types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateConnected, PowerState: types.VirtualMachinePowerStatePoweredOn},
+		},
+		{
+			name:    "powered off",
+			runtime: types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateConnected, PowerState: types.VirtualMachinePowerStatePoweredOff},
+		},
+		{
+			name:     "suspended",
+			runtime:  types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateConnected, PowerState: types.VirtualMachinePowerStateSuspended},
+			reason:   infrav1.VMSuspendedReason,
+			severity: clusterv1.ConditionSeverityWarning,
+		},
+		{
+			name:     "orphaned",
+			runtime:  types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateOrphaned, PowerState: types.VirtualMachinePowerStatePoweredOn},
+			reason:   infrav1.VMOrphanedReason,
+			severity: clusterv1.ConditionSeverityError,
+		},
+		{
+			name:     "inaccessible takes precedence over suspended",
+			runtime:  types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateInaccessible, PowerState: types.VirtualMachinePowerStateSuspended},
+			reason:   infrav1.VMInaccessibleReason,
+			severity: clusterv1.ConditionSeverityError,
+		},
+		{
+			name:     "invalid",
+			runtime:  types.VirtualMachineRuntimeInfo{ConnectionState: types.VirtualMachineConnectionStateInvalid, PowerState: types.VirtualMachinePowerStatePoweredOff},
+			reason:   infrav1.VMInvalidReason,
+			severity: clusterv1.ConditionSeverityError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			reason, severity := vmStateReason(tt.runtime)
+			g.Expect(reason).To(Equal(tt.reason))
+			g.Expect(severity).To(Equal(tt.severity))
+		})
+	}
+}
+
+func Test_getRecoveryAction(t *testing.T) {
+	tests := []struct {
+		reason       string
+		policy       infrav1.VMRecoveryPolicy
+		bootstrapped bool
+		action       recoveryAction
+	}{
+		{reason: infrav1.VMSuspendedReason, policy: "", action: recoveryActionNone},
+		{reason: infrav1.VMSuspendedReason, policy: infrav1.VMRecoveryNone, action: recoveryActionNone},
+		{reason: infrav1.VMSuspendedReason, policy: infrav1.VMRecoveryPowerOn, action: recoveryActionPowerOn},
+		{reason: infrav1.VMSuspendedReason, policy: infrav1.VMRecoveryReregister, action: recoveryActionPowerOn},
+		{reason: infrav1.VMSuspendedReason, policy: infrav1.VMRecoveryRecreate, bootstrapped: true, action: recoveryActionPowerOn},
+		{reason: infrav1.VMOrphanedReason, policy: infrav1.VMRecoveryPowerOn, action: recoveryActionNone},
+		{reason: infrav1.VMOrphanedReason, policy: infrav1.VMRecoveryReregister, bootstrapped: true, action: recoveryActionReregister},
+		{reason: infrav1.VMInvalidReason, policy: infrav1.VMRecoveryRecreate, action: recoveryActionRecreate},
+		{reason: infrav1.VMInaccessibleReason, policy: infrav1.VMRecoveryRecreate, bootstrapped: true, action: recoveryActionNone},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.reason+"/"+string(tt.policy), func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(getRecoveryAction(tt.reason, tt.policy, tt.bootstrapped)).To(Equal(tt.action))
+		})
+	}
+}
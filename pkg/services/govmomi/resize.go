@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
+)
+
+// guestShutdownForResizeTimeout is the time after which a VM whose guest
+// was asked to shut down to be resized is powered off.
+const guestShutdownForResizeTimeout = 5 * time.Minute
+
+// reconcileSize reconfigures an existing VM with the number of CPUs and the
+// memory of its spec when they differ from its current ones, with the same
+// defaults as the clone. The VM is resized while it runs if the CPUs and the
+// memory are only increased and can be hot-added. Otherwise its node is
+// cordoned and its guest shut down to be resized, then the VM is powered on
+// again by reconcilePowerState, and the node is uncordoned once the VM runs
+// with its new size. The VM is powered off if its guest did not shut down
+// within guestShutdownForResizeTimeout.
+func (vms *VMService) reconcileSize(ctx *virtualMachineContext) (bool, error) {
+	cloneSpec := &ctx.VSphereVM.Spec.VirtualMachineCloneSpec
+	if cloneSpec.NumCPUs == 0 && cloneSpec.MemoryMiB == 0 {
+		return true, nil
+	}
+
+	var obj mo.VirtualMachine
+	props := []string{"config.hardware.numCPU", "config.hardware.numCoresPerSocket", "config.hardware.memoryMB", "config.cpuHotAddEnabled", "config.memoryHotAddEnabled", "runtime.powerState"}
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, props, &obj); err != nil {
+		return false, errors.Wrapf(err, "unable to fetch the size of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return true, nil
+	}
+	poweredOn := obj.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn
+
+	spec, online, err := getResizeSpec(cloneSpec, obj.Config)
+	if err != nil {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, err
+	}
+	if spec == nil {
+		// Only the VMs being resized have the condition, which is set to true
+		// once they run with their new size.
+		if !conditions.IsFalse(ctx.VSphereVM, infrav1.VMResizedCondition) || !poweredOn {
+			return true, nil
+		}
+		if err := setNodeCordonedForResize(ctx, false); err != nil {
+			return false, err
+		}
+		conditions.MarkTrue(ctx.VSphereVM, infrav1.VMResizedCondition)
+		return true, nil
+	}
+
+	if poweredOn && !online {
+		if conditions.GetReason(ctx.VSphereVM, infrav1.VMResizedCondition) == infrav1.PoweringOffForResizeReason {
+			if since := conditions.GetLastTransitionTime(ctx.VSphereVM, infrav1.VMResizedCondition); since == nil || time.Since(since.Time) < guestShutdownForResizeTimeout {
+				ctx.Logger.Info("wait for the guest to shut down to resize")
+				return false, nil
+			}
+			ctx.Logger.Info("powering off to resize as the guest did not shut down", "timeout", guestShutdownForResizeTimeout)
+			done := metrics.TrackVSphereOperation(metrics.VSphereOperationPower, ctx.Session.URL().Host)
+			task, err := ctx.Obj.PowerOff(ctx)
+			done(err)
+			if err != nil {
+				conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+				return false, errors.Wrapf(err, "failed to trigger power off op for vm %s", ctx)
+			}
+			ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+			ctx.Logger.Info("wait for VM to be powered off")
+			return false, nil
+		}
+
+		if err := setNodeCordonedForResize(ctx, true); err != nil {
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return false, err
+		}
+		ctx.Logger.Info("shutting down the guest to resize", "numCPUs", spec.NumCPUs, "numCoresPerSocket", spec.NumCoresPerSocket, "memoryMB", spec.MemoryMB)
+		done := metrics.TrackVSphereOperation(metrics.VSphereOperationPower, ctx.Session.URL().Host)
+		err := ctx.Obj.ShutdownGuest(ctx)
+		done(err)
+		if err != nil {
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return false, errors.Wrapf(err, "failed to shut down the guest of vm %s", ctx)
+		}
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.PoweringOffForResizeReason, clusterv1.ConditionSeverityInfo, "")
+		ctx.Logger.Info("wait for the guest to shut down to resize")
+		return false, nil
+	}
+
+	ctx.Logger.Info("resizing", "numCPUs", spec.NumCPUs, "numCoresPerSocket", spec.NumCoresPerSocket, "memoryMB", spec.MemoryMB, "online", poweredOn)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	task, err := ctx.Obj.Reconfigure(ctx, *spec)
+	done(err)
+	if err != nil {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrapf(err, "unable to resize vm %s", ctx)
+	}
+	conditions.MarkFalse(ctx.VSphereVM, infrav1.VMResizedCondition, infrav1.ResizingReason, clusterv1.ConditionSeverityInfo, "")
+	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	ctx.Logger.Info("wait for the VM to be resized")
+	return false, nil
+}
+
+// getResizeSpec returns the config spec applying the number of CPUs and the
+// memory of the clone spec which differ from the config of a VM, or nil if
+// they do not differ, and whether it can be applied while the VM runs. The
+// clone spec is defaulted as by vcenter.Clone, except for the cores per
+// socket, which are kept unless set or unless the number of CPUs is not a
+// multiple of them. The number of CPUs must be a multiple of the cores per
+// socket the spec sets.
+func getResizeSpec(cloneSpec *infrav1.VirtualMachineCloneSpec, config *types.VirtualMachineConfigInfo) (*types.VirtualMachineConfigSpec, bool, error) {
+	numCPUs, numCoresPerSocket, memoryMiB := vcenter.Size(cloneSpec)
+	if current := config.Hardware.NumCoresPerSocket; cloneSpec.NumCoresPerSocket == 0 && current > 0 && numCPUs%current == 0 {
+		numCoresPerSocket = current
+	}
+	if numCPUs%numCoresPerSocket != 0 {
+		return nil, false, errors.Errorf("numCPUs %d is not a multiple of numCoresPerSocket %d", numCPUs, numCoresPerSocket)
+	}
+
+	spec := &types.VirtualMachineConfigSpec{}
+	changed, online := false, true
+
+	if numCPUs != config.Hardware.NumCPU {
+		spec.NumCPUs = numCPUs
+		changed = true
+		online = online && numCPUs > config.Hardware.NumCPU && pointer.BoolDeref(config.CpuHotAddEnabled, false)
+	}
+	// The VMs whose config does not report the cores per socket only have
+	// them changed if the spec sets them. They cannot be changed while the
+	// VM runs.
+	if numCoresPerSocket != config.Hardware.NumCoresPerSocket && (config.Hardware.NumCoresPerSocket > 0 || cloneSpec.NumCoresPerSocket > 0) {
+		spec.NumCoresPerSocket = numCoresPerSocket
+		changed = true
+		online = false
+	}
+	if memoryMiB != int64(config.Hardware.MemoryMB) {
+		spec.MemoryMB = memoryMiB
+		changed = true
+		online = online && memoryMiB > int64(config.Hardware.MemoryMB) && pointer.BoolDeref(config.MemoryHotAddEnabled, false)
+	}
+
+	if !changed {
+		return nil, false, nil
+	}
+	return spec, online, nil
+}
+
+// setNodeCordonedForResize cordons the node of the VM before it is powered
+// off to be resized, or uncordons it once the VM runs with its new size. The
+// nodes which were already cordoned are left as they are.
+func setNodeCordonedForResize(ctx *virtualMachineContext, cordon bool) error {
+	if !ctx.Bootstrapped {
+		return nil
+	}
+
+	clusterKey := client.ObjectKey{Namespace: ctx.VSphereVM.Namespace, Name: ctx.VSphereVM.Labels[clusterv1.ClusterLabelName]}
+	clusterClient, err := remote.NewClusterClient(ctx, ctx.Name, ctx.Client, clusterKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a client to cluster %s", clusterKey)
+	}
+
+	nodeName, err := ctx.VSphereVM.HostName()
+	if err != nil {
+		return err
+	}
+	node := &corev1.Node{}
+	if err := clusterClient.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get node %s", nodeName)
+	}
+	_, cordonedForResize := node.Annotations[infrav1.NodeCordonedForResizeAnnotation]
+	if cordon == cordonedForResize || (cordon && node.Spec.Unschedulable) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(node, clusterClient)
+	if err != nil {
+		return err
+	}
+	node.Spec.Unschedulable = cordon
+	if cordon {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[infrav1.NodeCordonedForResizeAnnotation] = ""
+		ctx.Logger.Info("cordoning node to resize the VM", "node", node.Name)
+	} else {
+		delete(node.Annotations, infrav1.NodeCordonedForResizeAnnotation)
+		ctx.Logger.Info("uncordoning node after resizing the VM", "node", node.Name)
+	}
+	if err := patchHelper.Patch(ctx, node); err != nil {
+		return errors.Wrapf(err, "failed to patch node %s", node.Name)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_getResizeSpec(t *testing.T) {
+	config := func(cpuHotAdd, memoryHotAdd bool) *types.VirtualMachineConfigInfo {
+		return &types.VirtualMachineConfigInfo{
+			Hardware:            types.VirtualHardware{NumCPU: 2, NumCoresPerSocket: 2, MemoryMB: 4096},
+			CpuHotAddEnabled:    pointer.Bool(cpuHotAdd),
+			MemoryHotAddEnabled: pointer.Bool(memoryHotAdd),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		cloneSpec infrav1.VirtualMachineCloneSpec
+		config    *types.VirtualMachineConfigInfo
+		spec      *types.VirtualMachineConfigSpec
+		online    bool
+		err       string
+	}{
+		{
+			name:      "with the current size",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 2, MemoryMiB: 4096},
+			config:    config(false, false),
+		},
+		{
+			name:      "with the minimum number of CPUs of the clone",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 1, MemoryMiB: 4096},
+			config:    config(true, true),
+		},
+		{
+			name:      "increases the CPUs and memory online with hot-add",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 4, MemoryMiB: 8192},
+			config:    config(true, true),
+			spec:      &types.VirtualMachineConfigSpec{NumCPUs: 4, MemoryMB: 8192},
+			online:    true,
+		},
+		{
+			name:      "increases the memory offline without memory hot-add",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 4, MemoryMiB: 8192},
+			config:    config(true, false),
+			spec:      &types.VirtualMachineConfigSpec{NumCPUs: 4, MemoryMB: 8192},
+		},
+		{
+			name:      "decreases the memory to the default of the clone offline",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 2},
+			config:    config(true, true),
+			spec:      &types.VirtualMachineConfigSpec{MemoryMB: 2048},
+		},
+		{
+			name:      "changes the cores per socket offline",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 4, NumCoresPerSocket: 4, MemoryMiB: 4096},
+			config:    config(true, true),
+			spec:      &types.VirtualMachineConfigSpec{NumCPUs: 4, NumCoresPerSocket: 4},
+		},
+		{
+			name:      "uses a single socket for CPUs which are not a multiple of the current cores per socket",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 3, MemoryMiB: 4096},
+			config:    config(true, true),
+			spec:      &types.VirtualMachineConfigSpec{NumCPUs: 3, NumCoresPerSocket: 3},
+		},
+		{
+			name:      "refuses CPUs which are not a multiple of the cores per socket",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{NumCPUs: 6, NumCoresPerSocket: 4, MemoryMiB: 4096},
+			config:    config(true, true),
+			err:       "numCPUs 6 is not a multiple of numCoresPerSocket 4",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec, online, err := getResizeSpec(&tt.cloneSpec, tt.config)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(spec).To(Equal(tt.spec))
+			g.Expect(online).To(Equal(tt.online))
+		})
+	}
+}
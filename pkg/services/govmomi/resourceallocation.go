@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// reconcileResourceAllocation reconfigures the VM with the hot-add settings
+// and the CPU and memory allocations of its spec which differ from its
+// current configuration. The hot-add settings can only be changed while the
+// VM is powered off, so they are not applied to VMs which are powered on.
+func (vms *VMService) reconcileResourceAllocation(ctx *virtualMachineContext) (bool, error) {
+	cloneSpec := &ctx.VSphereVM.Spec.VirtualMachineCloneSpec
+	if !cloneSpec.CPUHotAddEnabled && !cloneSpec.MemoryHotAddEnabled && cloneSpec.CPUAllocation == nil && cloneSpec.MemoryAllocation == nil {
+		return true, nil
+	}
+
+	var obj mo.VirtualMachine
+	props := []string{"config.cpuHotAddEnabled", "config.memoryHotAddEnabled", "config.cpuAllocation", "config.memoryAllocation", "runtime.powerState"}
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, props, &obj); err != nil {
+		return false, errors.Wrapf(err, "unable to fetch the resource allocation of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return true, nil
+	}
+
+	poweredOn := obj.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn
+	spec, ok := getResourceAllocationSpec(cloneSpec, obj.Config, poweredOn)
+	if !ok {
+		return true, nil
+	}
+
+	ctx.Logger.Info("updating resource allocation")
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	task, err := ctx.Obj.Reconfigure(ctx, *spec)
+	done(err)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to update the resource allocation of vm %s", ctx)
+	}
+	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	ctx.Logger.Info("wait for the resource allocation to be updated")
+	return false, nil
+}
+
+// getResourceAllocationSpec returns the config spec applying the hot-add
+// settings and the CPU and memory allocations of the clone spec which differ
+// from the config of a VM, and false if there is nothing to apply.
+func getResourceAllocationSpec(cloneSpec *infrav1.VirtualMachineCloneSpec, config *types.VirtualMachineConfigInfo, poweredOn bool) (*types.VirtualMachineConfigSpec, bool) {
+	spec := &types.VirtualMachineConfigSpec{}
+	changed := false
+
+	if !poweredOn {
+		if cloneSpec.CPUHotAddEnabled && !pointer.BoolDeref(config.CpuHotAddEnabled, false) {
+			spec.CpuHotAddEnabled = pointer.Bool(true)
+			changed = true
+		}
+		if cloneSpec.MemoryHotAddEnabled && !pointer.BoolDeref(config.MemoryHotAddEnabled, false) {
+			spec.MemoryHotAddEnabled = pointer.Bool(true)
+			changed = true
+		}
+	}
+
+	if allocation, ok := getResourceAllocationInfo(cloneSpec.CPUAllocation, config.CpuAllocation); ok {
+		spec.CpuAllocation = allocation
+		changed = true
+	}
+	if allocation, ok := getResourceAllocationInfo(cloneSpec.MemoryAllocation, config.MemoryAllocation); ok {
+		spec.MemoryAllocation = allocation
+		changed = true
+	}
+
+	return spec, changed
+}
+
+// getResourceAllocationInfo returns the fields of the allocation which differ
+// from the existing allocation of a VM, and false if there are none.
+func getResourceAllocationInfo(allocation *infrav1.ResourceAllocation, existing *types.ResourceAllocationInfo) (*types.ResourceAllocationInfo, bool) {
+	if allocation == nil {
+		return nil, false
+	}
+	if existing == nil {
+		existing = &types.ResourceAllocationInfo{}
+	}
+
+	info := &types.ResourceAllocationInfo{}
+	changed := false
+	if allocation.Reservation != nil && !int64PtrEqual(allocation.Reservation, existing.Reservation) {
+		info.Reservation = allocation.Reservation
+		changed = true
+	}
+	if allocation.Limit != nil && !int64PtrEqual(allocation.Limit, existing.Limit) {
+		info.Limit = allocation.Limit
+		changed = true
+	}
+	if allocation.SharesLevel != "" {
+		shares := &types.SharesInfo{Level: types.SharesLevel(allocation.SharesLevel)}
+		if allocation.SharesLevel == infrav1.SharesLevelCustom {
+			shares.Shares = allocation.Shares
+		}
+		if existing.Shares == nil || existing.Shares.Level != shares.Level ||
+			(shares.Level == types.SharesLevelCustom && existing.Shares.Shares != shares.Shares) {
+			info.Shares = shares
+			changed = true
+		}
+	}
+	return info, changed
+}
+
+func int64PtrEqual(a, b *int64) bool {
+	return a != nil && b != nil && *a == *b
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_getResourceAllocationSpec(t *testing.T) {
+	config := &types.VirtualMachineConfigInfo{
+		CpuHotAddEnabled:    pointer.Bool(false),
+		MemoryHotAddEnabled: pointer.Bool(true),
+		CpuAllocation: &types.ResourceAllocationInfo{
+			Reservation: pointer.Int64(0),
+			Limit:       pointer.Int64(-1),
+			Shares:      &types.SharesInfo{Level: types.SharesLevelNormal, Shares: 2000},
+		},
+		MemoryAllocation: &types.ResourceAllocationInfo{
+			Reservation: pointer.Int64(2048),
+			Limit:       pointer.Int64(-1),
+			Shares:      &types.SharesInfo{Level: types.SharesLevelCustom, Shares: 20480},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		cloneSpec infrav1.VirtualMachineCloneSpec
+		poweredOn bool
+		changed   bool
+		assert    func(*WithT, *types.VirtualMachineConfigSpec)
+	}{
+		{
+			name: "without hot-add and allocations",
+		},
+		{
+			name: "with the current hot-add and allocations",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{
+				MemoryHotAddEnabled: true,
+				CPUAllocation:       &infrav1.ResourceAllocation{Limit: pointer.Int64(-1), SharesLevel: infrav1.SharesLevelNormal},
+				MemoryAllocation:    &infrav1.ResourceAllocation{Reservation: pointer.Int64(2048), SharesLevel: infrav1.SharesLevelCustom, Shares: 20480},
+			},
+		},
+		{
+			name: "enables hot-add while powered off",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{
+				CPUHotAddEnabled:    true,
+				MemoryHotAddEnabled: true,
+			},
+			changed: true,
+			assert: func(g *WithT, spec *types.VirtualMachineConfigSpec) {
+				g.Expect(spec.CpuHotAddEnabled).To(Equal(pointer.Bool(true)))
+				g.Expect(spec.MemoryHotAddEnabled).To(BeNil())
+			},
+		},
+		{
+			name: "does not enable hot-add while powered on",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{
+				CPUHotAddEnabled: true,
+			},
+			poweredOn: true,
+		},
+		{
+			name: "updates the allocations which differ",
+			cloneSpec: infrav1.VirtualMachineCloneSpec{
+				CPUAllocation:    &infrav1.ResourceAllocation{Reservation: pointer.Int64(1000), Limit: pointer.Int64(-1), SharesLevel: infrav1.SharesLevelHigh},
+				MemoryAllocation: &infrav1.ResourceAllocation{Reservation: pointer.Int64(2048), SharesLevel: infrav1.SharesLevelCustom, Shares: 40960},
+			},
+			poweredOn: true,
+			changed:   true,
+			assert: func(g *WithT, spec *types.VirtualMachineConfigSpec) {
+				g.Expect(spec.CpuAllocation).To(Equal(&types.ResourceAllocationInfo{
+					Reservation: pointer.Int64(1000),
+					Shares:      &types.SharesInfo{Level: types.SharesLevelHigh},
+				}))
+				g.Expect(spec.MemoryAllocation).To(Equal(&types.ResourceAllocationInfo{
+					Shares: &types.SharesInfo{Level: types.SharesLevelCustom, Shares: 40960},
+				}))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec, changed := getResourceAllocationSpec(&tt.cloneSpec, config, tt.poweredOn)
+			g.Expect(changed).To(Equal(tt.changed))
+			if tt.assert != nil {
+				tt.assert(g, spec)
+			}
+		})
+	}
+}
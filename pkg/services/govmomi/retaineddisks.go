@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/exp/slices"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// retainedDiskFiles returns the datastore paths of the additional disks of a
+// VM which are retained on delete. The additional disks follow the primary
+// disk, in the order of the additional disks of the spec.
+func retainedDiskFiles(devices object.VirtualDeviceList, additionalDisks []infrav1.AdditionalDiskSpec) []string {
+	var files []string
+	for i, disk := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if i == 0 || i > len(additionalDisks) || !additionalDisks[i-1].RetainOnDelete {
+			continue
+		}
+		if file := diskFileName(disk); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// diskFileName returns the datastore path of the file backing a disk.
+func diskFileName(disk types.BaseVirtualDevice) string {
+	if backing, ok := disk.GetVirtualDevice().Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+		return backing.GetVirtualDeviceFileBackingInfo().FileName
+	}
+	return ""
+}
+
+// detachRetainedDisks detaches the additional disks of the VM which are
+// retained on delete, keeping their files. Their paths are recorded in the
+// status of the VSphereVM before they are detached, since the disks still
+// attached to the VM no longer match the additional disks of the spec once
+// they are.
+func (vms *VMService) detachRetainedDisks(ctx *virtualMachineContext) error {
+	devices, err := ctx.Obj.Device(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get the devices of vm %s", ctx)
+	}
+	if ctx.VSphereVM.Status.RetainedDisks == nil {
+		files := retainedDiskFiles(devices, ctx.VSphereVM.Spec.AdditionalDisks)
+		if len(files) == 0 {
+			return nil
+		}
+		ctx.VSphereVM.Status.RetainedDisks = files
+		if err := ctx.Patch(); err != nil {
+			ctx.Logger.Error(err, "patch failed", "vm", ctx.String())
+			return err
+		}
+	}
+
+	var disks []types.BaseVirtualDevice
+	for _, disk := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if slices.Contains(ctx.VSphereVM.Status.RetainedDisks, diskFileName(disk)) {
+			disks = append(disks, disk)
+		}
+	}
+	if len(disks) == 0 {
+		return nil
+	}
+
+	ctx.Logger.Info("detaching retained disks", "disks", ctx.VSphereVM.Status.RetainedDisks)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	err = ctx.Obj.RemoveDevice(ctx, true, disks...)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "unable to detach the retained disks of vm %s", ctx)
+	}
+	ctx.Recorder.Eventf(ctx.VSphereVM, "DisksRetained", "Detached the disks %s from the VM to retain them",
+		strings.Join(ctx.VSphereVM.Status.RetainedDisks, ", "))
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_retainedDiskFiles(t *testing.T) {
+	disk := func(file string) types.BaseVirtualDevice {
+		return &types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Backing: &types.VirtualDiskFlatVer2BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: file},
+				},
+			},
+		}
+	}
+	devices := object.VirtualDeviceList{
+		disk("[ds1] vm1/vm1.vmdk"),
+		&types.VirtualCdrom{},
+		disk("[ds1] vm1/vm1_1.vmdk"),
+		disk("[ds1] vm1/vm1_2.vmdk"),
+	}
+
+	tests := []struct {
+		name            string
+		additionalDisks []infrav1.AdditionalDiskSpec
+		files           []string
+	}{
+		{
+			name: "without additional disks",
+		},
+		{
+			name:            "without retained disks",
+			additionalDisks: []infrav1.AdditionalDiskSpec{{SizeGiB: 20}, {SizeGiB: 20}},
+		},
+		{
+			name:            "with a retained disk",
+			additionalDisks: []infrav1.AdditionalDiskSpec{{SizeGiB: 20}, {RetainOnDelete: true}},
+			files:           []string{"[ds1] vm1/vm1_2.vmdk"},
+		},
+		{
+			name:            "with more disks in the spec than on the VM",
+			additionalDisks: []infrav1.AdditionalDiskSpec{{RetainOnDelete: true}, {}, {RetainOnDelete: true}},
+			files:           []string{"[ds1] vm1/vm1_1.vmdk"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(retainedDiskFiles(devices, tc.additionalDisks)).To(Equal(tc.files))
+		})
+	}
+}
@@ -39,13 +39,19 @@ import (
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/cdrom"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/cluster"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/clustermodules"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/extra"
 	govmominet "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/net"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/nocloud"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vapp"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
 
@@ -65,8 +71,14 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 	}
 
 	// If there is an in-flight task associated with this VM then do not
-	// reconcile the VM until the task is completed.
-	if inFlight, err := reconcileInFlightTask(ctx); err != nil || inFlight {
+	// reconcile the VM until the task is completed. The VM left by a failed
+	// clone task may have to be deleted before the clone is retried.
+	task := getTask(ctx)
+	reportTaskFailure(ctx, task)
+	if deleting, err := reconcileFailedClone(ctx, task); err != nil || deleting {
+		return vm, err
+	}
+	if inFlight, err := checkAndRetryTask(ctx, task); err != nil || inFlight {
 		return vm, err
 	}
 
@@ -84,11 +96,17 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 			return vm, err
 		}
 
-		// If the machine was not found by BIOS UUID it means that it got deleted from vcenter directly
+		// If the machine was not found by BIOS UUID it means that it got deleted from vcenter directly,
+		// or unregistered to be recreated by the recreate recovery policy.
 		if wasNotFoundByBIOSUUID(err) {
-			ctx.VSphereVM.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.UpdateMachineError)
-			ctx.VSphereVM.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("Unable to find VM by BIOS UUID %s. The vm was removed from infra", ctx.VSphereVM.Spec.BiosUUID))
-			return vm, err
+			if !recreatesVM(ctx) {
+				ctx.VSphereVM.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.UpdateMachineError)
+				ctx.VSphereVM.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("Unable to find VM by BIOS UUID %s. The vm was removed from infra", ctx.VSphereVM.Spec.BiosUUID))
+				return vm, err
+			}
+			ctx.Logger.Info("recreating the VM removed from vCenter", "biosuuid", ctx.VSphereVM.Spec.BiosUUID)
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.RecoveringReason, clusterv1.ConditionSeverityInfo,
+				"VM was removed from vCenter and is being recreated")
 		}
 
 		// Otherwise, this is a new machine and the  the VM should be created.
@@ -98,17 +116,29 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CloningReason, clusterv1.ConditionSeverityInfo, "")
 		}
 
+		// The VM is not cloned again once the clone retry policy gave up,
+		// even if vCenter does not keep the failed clone task anymore.
+		if cloneAttemptsExhausted(ctx.VSphereVM) {
+			return vm, errors.Errorf("VM failed to be cloned %d times", ctx.VSphereVM.Status.CloneAttempts)
+		}
+
 		// Get the bootstrap data.
 		bootstrapData, format, err := vms.getBootstrapData(ctx)
 		if err != nil {
 			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 			return vm, err
 		}
+		bootstrapData, err = injectCloudConfigs(ctx, bootstrapData, format)
+		if err != nil {
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return vm, err
+		}
 
 		// Create the VM.
 		err = createVM(ctx, bootstrapData, format)
+		reconcileAmbiguousReference(ctx, err)
 		if err != nil {
-			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			markCloneFailed(ctx, err)
 		}
 		return vm, nil
 	}
@@ -127,6 +157,16 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 
 	vms.reconcileUUID(vmCtx)
 
+	if ok, err := vms.reconcileRecovery(vmCtx); err != nil || !ok {
+		return vm, err
+	}
+
+	// The host is reconciled first, so that a failed host is reported even
+	// though the VM cannot be reconfigured.
+	if err := vms.reconcileHostInfo(vmCtx); err != nil {
+		return vm, err
+	}
+
 	if err := vms.reconcileHardwareVersion(vmCtx); err != nil {
 		return vm, err
 	}
@@ -159,11 +199,27 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 		return vm, err
 	}
 
+	if ok, err := vms.reconcileResourceAllocation(vmCtx); err != nil || !ok {
+		return vm, err
+	}
+
+	if ok, err := vms.reconcileTimeSync(vmCtx); err != nil || !ok {
+		return vm, err
+	}
+
+	if ok, err := vms.reconcileSize(vmCtx); err != nil || !ok {
+		return vm, err
+	}
+
 	if ok, err := vms.reconcilePowerState(vmCtx); err != nil || !ok {
 		return vm, err
 	}
 
-	if err := vms.reconcileHostInfo(vmCtx); err != nil {
+	if err := vms.reconcileGuestAgentReports(vmCtx); err != nil {
+		return vm, err
+	}
+
+	if ok, err := vms.reconcileCDROMs(vmCtx); err != nil || !ok {
 		return vm, err
 	}
 
@@ -172,11 +228,21 @@ func (vms *VMService) ReconcileVM(ctx *context.VMContext) (vm infrav1.VirtualMac
 		return vm, err
 	}
 
+	if err := vms.reconcileCustomAttributes(vmCtx); err != nil {
+		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.CustomAttributesFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		return vm, err
+	}
+
+	if err := vms.reconcileDrift(vmCtx); err != nil {
+		return vm, err
+	}
+
 	vm.State = infrav1.VirtualMachineStateReady
 	return vm, nil
 }
 
-// DestroyVM powers off and destroys a virtual machine.
+// DestroyVM powers off and destroys a virtual machine, or retains it in vCenter
+// according to the deletion policy of the VSphereVM.
 func (vms *VMService) DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine, error) {
 	vm := infrav1.VirtualMachine{
 		Name:  ctx.VSphereVM.Name,
@@ -219,13 +285,16 @@ func (vms *VMService) DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine,
 		State:     &vm,
 	}
 
-	// Power off the VM.
+	// Power off the VM, unless it is retained as it is.
+	deletionPolicy := ctx.VSphereVM.Spec.DeletionPolicy
 	powerState, err := vms.getPowerState(vmCtx)
 	if err != nil {
 		return vm, err
 	}
-	if powerState == infrav1.VirtualMachinePowerStatePoweredOn {
+	if powerState == infrav1.VirtualMachinePowerStatePoweredOn && deletionPolicy != infrav1.VMDeletionRetain {
+		done := metrics.TrackVSphereOperation(metrics.VSphereOperationPower, ctx.Session.URL().Host)
 		task, err := vmCtx.Obj.PowerOff(ctx)
+		done(err)
 		if err != nil {
 			return vm, err
 		}
@@ -247,6 +316,23 @@ func (vms *VMService) DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine,
 		ctx.VSphereVM.Status.ModuleUUID = nil
 	}
 
+	// The retained VMs are left in vCenter along with their NoCloud ISO
+	// image, which they may still boot from.
+	if deletionPolicy == infrav1.VMDeletionRetain || deletionPolicy == infrav1.VMDeletionPowerOffOnly {
+		ctx.Logger.Info("retaining vm", "deletionPolicy", deletionPolicy)
+		vm.BiosUUID = vmCtx.Obj.UUID(ctx)
+		vm.State = infrav1.VirtualMachineStateRetained
+		return vm, nil
+	}
+
+	if err := vms.detachRetainedDisks(vmCtx); err != nil {
+		return vm, err
+	}
+
+	if err := vms.deleteNoCloudISO(vmCtx); err != nil {
+		return vm, err
+	}
+
 	// At this point the VM is not powered on and can be destroyed. Store the
 	// destroy task's reference and return a requeue error.
 	ctx.Logger.Info("destroying vm")
@@ -259,6 +345,56 @@ func (vms *VMService) DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine,
 	return vm, nil
 }
 
+// GetVM returns the state of a VM without changing it in vCenter, for the
+// VSphereVMs whose vSphere operations are paused. The task in flight, started
+// before the operations were paused, is still tracked. The VM is reported
+// ready once it exists and is powered on.
+func (vms *VMService) GetVM(ctx *context.VMContext) (infrav1.VirtualMachine, error) {
+	vm := infrav1.VirtualMachine{
+		Name:  ctx.VSphereVM.Name,
+		State: infrav1.VirtualMachineStatePending,
+	}
+
+	if inFlight, err := reconcileInFlightTask(ctx); err != nil || inFlight {
+		return vm, err
+	}
+
+	vmRef, err := findVM(ctx)
+	if err != nil {
+		if isNotFound(err) || isFolderNotFound(err) {
+			vm.State = infrav1.VirtualMachineStateNotFound
+			return vm, nil
+		}
+		return vm, err
+	}
+
+	vmCtx := &virtualMachineContext{
+		VMContext: *ctx,
+		Obj:       object.NewVirtualMachine(ctx.Session.Client.Client, vmRef),
+		Ref:       vmRef,
+		State:     &vm,
+	}
+
+	vms.reconcileUUID(vmCtx)
+
+	if err := vms.reconcileHostInfo(vmCtx); err != nil {
+		return vm, err
+	}
+
+	if err := vms.reconcileNetworkStatus(vmCtx); err != nil {
+		return vm, err
+	}
+
+	powerState, err := vms.getPowerState(vmCtx)
+	if err != nil {
+		return vm, err
+	}
+	if powerState == infrav1.VirtualMachinePowerStatePoweredOn {
+		vm.State = infrav1.VirtualMachineStateReady
+	}
+	return vm, nil
+}
+
 func (vms *VMService) reconcileNetworkStatus(ctx *virtualMachineContext) error {
 	netStatus, err := vms.getNetworkStatus(ctx)
 	if err != nil {
@@ -270,50 +406,106 @@ func (vms *VMService) reconcileNetworkStatus(ctx *virtualMachineContext) error {
 
 // reconcileIPAddressClaims ensures that VSphereVMs that are configured with
 // .spec.network.devices.addressFromPools have corresponding IPAddressClaims.
+// The missing claims of the other VSphereVMs of the cluster are created at the
+// same time, so that the addresses of the VMs created at once, e.g. when a
+// MachineDeployment scales up, are allocated in one batch rather than as each
+// VM is cloned.
 func (vms *VMService) reconcileIPAddressClaims(ctx *virtualMachineContext) (bool, error) {
-	for devIdx, device := range ctx.VSphereVM.Spec.Network.Devices {
+	if !hasAddressesFromPools(ctx.VSphereVM) {
+		return true, nil
+	}
+
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := ctx.Client.List(ctx, claims, client.InNamespace(ctx.VSphereVM.Namespace)); err != nil {
+		return false, errors.Wrapf(err, "failed to list the IPAddressClaims of namespace %s", ctx.VSphereVM.Namespace)
+	}
+	existing := map[string]bool{}
+	for _, claim := range claims.Items {
+		existing[claim.Name] = true
+	}
+
+	created, err := createIPAddressClaims(ctx, ctx.VSphereVM, existing)
+	if err != nil {
+		return false, err
+	}
+	if created {
+		msg := "Waiting for IPAddressClaim to have an IPAddress bound"
+		markIPAddressClaimedConditionWaitingForClaimAddress(ctx.VSphereVM, msg)
+	}
+
+	clusterName, ok := ctx.VSphereVM.Labels[clusterv1.ClusterLabelName]
+	if !ok {
+		return true, nil
+	}
+	siblings := &infrav1.VSphereVMList{}
+	if err := ctx.Client.List(ctx, siblings,
+		client.InNamespace(ctx.VSphereVM.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		ctx.Logger.Error(err, "unable to list the VSphereVMs of the cluster to create their IPAddressClaims")
+		return true, nil
+	}
+	for i := range siblings.Items {
+		vm := &siblings.Items[i]
+		if vm.Name == ctx.VSphereVM.Name || !vm.DeletionTimestamp.IsZero() || !hasAddressesFromPools(vm) {
+			continue
+		}
+		if _, err := createIPAddressClaims(ctx, vm, existing); err != nil {
+			ctx.Logger.Error(err, "unable to create the IPAddressClaims of VSphereVM", "vsphereVM", vm.Name)
+		}
+	}
+	return true, nil
+}
+
+// hasAddressesFromPools returns whether a network device of a VSphereVM gets
+// its addresses from pools.
+func hasAddressesFromPools(vm *infrav1.VSphereVM) bool {
+	for _, device := range vm.Spec.Network.Devices {
+		if len(device.AddressesFromPools) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// createIPAddressClaims creates the IPAddressClaims of a VSphereVM missing
+// from the existing claims, which are updated with the created ones, and
+// returns whether any was created.
+func createIPAddressClaims(ctx *virtualMachineContext, vm *infrav1.VSphereVM, existing map[string]bool) (bool, error) {
+	created := false
+	for devIdx, device := range vm.Spec.Network.Devices {
 		for poolRefIdx, poolRef := range device.AddressesFromPools {
-			// check if claim exists
-			ipAddrClaim := &ipamv1.IPAddressClaim{}
-			ipAddrClaimName := IPAddressClaimName(ctx.VSphereVM.Name, devIdx, poolRefIdx)
-			ipAddrClaimKey := apitypes.NamespacedName{
-				Namespace: ctx.VSphereVM.Namespace,
-				Name:      ipAddrClaimName,
-			}
-			var err error
-			if err = ctx.Client.Get(ctx, ipAddrClaimKey, ipAddrClaim); err != nil && !apierrors.IsNotFound(err) {
-				return false, err
-			}
-			if err == nil {
+			ipAddrClaimName := IPAddressClaimName(vm.Name, devIdx, poolRefIdx)
+			if existing[ipAddrClaimName] {
 				ctx.Logger.V(5).Info("IPAddressClaim found", "name", ipAddrClaimName)
+				continue
 			}
-			if apierrors.IsNotFound(err) {
-				ctx.Logger.Info("creating IPAddressClaim", "name", ipAddrClaimName)
-				claim := &ipamv1.IPAddressClaim{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      ipAddrClaimName,
-						Namespace: ctx.VSphereVM.Namespace,
-						OwnerReferences: []metav1.OwnerReference{
-							{
-								APIVersion: ctx.VSphereVM.APIVersion,
-								Kind:       ctx.VSphereVM.Kind,
-								Name:       ctx.VSphereVM.Name,
-								UID:        ctx.VSphereVM.UID,
-							},
+			ctx.Logger.Info("creating IPAddressClaim", "name", ipAddrClaimName, "vsphereVM", vm.Name)
+			claim := &ipamv1.IPAddressClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ipAddrClaimName,
+					Namespace: vm.Namespace,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: infrav1.GroupVersion.String(),
+							Kind:       "VSphereVM",
+							Name:       vm.Name,
+							UID:        vm.UID,
 						},
-						Finalizers: []string{infrav1.IPAddressClaimFinalizer},
 					},
-					Spec: ipamv1.IPAddressClaimSpec{PoolRef: poolRef},
-				}
-				if err = ctx.Client.Create(ctx, claim); err != nil {
-					return false, err
-				}
-				msg := "Waiting for IPAddressClaim to have an IPAddress bound"
-				markIPAddressClaimedConditionWaitingForClaimAddress(ctx.VSphereVM, msg)
+					Finalizers: []string{infrav1.IPAddressClaimFinalizer},
+				},
+				Spec: ipamv1.IPAddressClaimSpec{PoolRef: poolRef},
+			}
+			// The claim may have been created in the meantime for another
+			// VSphereVM of the cluster.
+			if err := ctx.Client.Create(ctx, claim); err != nil && !apierrors.IsAlreadyExists(err) {
+				return created, errors.Wrapf(err, "failed to create IPAddressClaim %s", ipAddrClaimName)
 			}
+			existing[ipAddrClaimName] = true
+			created = true
 		}
 	}
-	return true, nil
+	return created, nil
 }
 
 // reconcileIPAddresses prevents successful reconcilliation of a VSphereVM
@@ -447,7 +639,11 @@ func (vms *VMService) reconcileMetadata(ctx *virtualMachineContext) (bool, error
 		return false, err
 	}
 
-	newMetadata, err := util.GetMachineMetadata(ctx.VSphereVM.Name, *ctx.VSphereVM, ctx.IPAMState, ctx.State.Network...)
+	hostName, err := ctx.VSphereVM.HostName()
+	if err != nil {
+		return false, err
+	}
+	newMetadata, err := util.GetMachineMetadata(hostName, *ctx.VSphereVM, ctx.IPAMState, ctx.State.Network...)
 	if err != nil {
 		return false, err
 	}
@@ -476,7 +672,9 @@ func (vms *VMService) reconcilePowerState(ctx *virtualMachineContext) (bool, err
 	switch powerState {
 	case infrav1.VirtualMachinePowerStatePoweredOff:
 		ctx.Logger.Info("powering on")
+		done := metrics.TrackVSphereOperation(metrics.VSphereOperationPower, ctx.Session.URL().Host)
 		task, err := ctx.Obj.PowerOn(ctx)
+		done(err)
 		if err != nil {
 			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.PoweringOnFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 			return false, errors.Wrapf(err, "failed to trigger power on op for vm %s", ctx)
@@ -504,6 +702,46 @@ func (vms *VMService) reconcilePowerState(ctx *virtualMachineContext) (bool, err
 	}
 }
 
+// reconcileCDROMs ejects the ISO images of the CDROMs to eject once the
+// machine is bootstrapped.
+func (vms *VMService) reconcileCDROMs(ctx *virtualMachineContext) (bool, error) {
+	if !ctx.Bootstrapped {
+		return true, nil
+	}
+	var isoFiles []string
+	for i, spec := range ctx.VSphereVM.Spec.CDROMs {
+		if spec.EjectAfterBootstrap && i < len(ctx.VSphereVM.Status.ISOImages) {
+			isoFiles = append(isoFiles, ctx.VSphereVM.Status.ISOImages[i])
+		}
+	}
+	if len(isoFiles) == 0 {
+		return true, nil
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.hardware.device"}, &obj); err != nil {
+		return false, errors.Wrapf(err, "unable to fetch the devices of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return true, nil
+	}
+	deviceSpecs := cdrom.EjectSpecs(obj.Config.Hardware.Device, isoFiles)
+	if len(deviceSpecs) == 0 {
+		return true, nil
+	}
+
+	ctx.Logger.Info("ejecting ISO images", "isoImages", isoFiles)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	task, err := ctx.Obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{DeviceChange: deviceSpecs})
+	done(err)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to eject the ISO images of vm %s", ctx)
+	}
+	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	ctx.Logger.Info("wait for the ISO images to be ejected")
+	return false, nil
+}
+
 func (vms *VMService) reconcileStoragePolicy(ctx *virtualMachineContext) error {
 	if ctx.VSphereVM.Spec.StoragePolicyName == "" {
 		ctx.Logger.V(5).Info("storage policy not defined. skipping reconcile storage policy")
@@ -566,12 +804,14 @@ func (vms *VMService) reconcileStoragePolicy(ctx *virtualMachineContext) error {
 	}
 
 	if len(changes) > 0 {
+		done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
 		task, err := ctx.Obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{
 			VmProfile: []types.BaseVirtualMachineProfileSpec{
 				&types.VirtualMachineDefinedProfileSpec{ProfileId: storageProfileID},
 			},
 			DeviceChange: changes,
 		})
+		done(err)
 		if err != nil {
 			return errors.Wrapf(err, "unable to set storagePolicy on vm %s", ctx)
 		}
@@ -611,9 +851,15 @@ func (vms *VMService) reconcileHardwareVersion(ctx *virtualMachineContext) error
 }
 
 func (vms *VMService) getPowerState(ctx *virtualMachineContext) (infrav1.VirtualMachinePowerState, error) {
-	powerState, err := ctx.Obj.PowerState(ctx)
-	if err != nil {
-		return "", err
+	var powerState types.VirtualMachinePowerState
+	if obj, ok := ctx.Session.PropertyCache().VirtualMachine(ctx.Ref); ok {
+		powerState = obj.Runtime.PowerState
+	} else {
+		state, err := ctx.Obj.PowerState(ctx)
+		if err != nil {
+			return "", err
+		}
+		powerState = state
 	}
 
 	switch powerState {
@@ -633,7 +879,7 @@ func (vms *VMService) getMetadata(ctx *virtualMachineContext) (string, error) {
 		obj mo.VirtualMachine
 
 		pc    = property.DefaultCollector(ctx.Session.Client.Client)
-		props = []string{"config.extraConfig"}
+		props = []string{"config.extraConfig", "config.vAppConfig"}
 	)
 
 	if err := pc.RetrieveOne(ctx, ctx.Ref, props, &obj); err != nil {
@@ -643,6 +889,23 @@ func (vms *VMService) getMetadata(ctx *virtualMachineContext) (string, error) {
 		return "", nil
 	}
 
+	if ctx.VSphereVM.Spec.BootstrapDataDelivery == infrav1.BootstrapDataDeliveryVAppProperties {
+		value, ok := vapp.Get(obj.Config.VAppConfig, vapp.MetadataProperty)
+		if !ok || value == "" {
+			return "", nil
+		}
+		metadata, err := vapp.Decode(value)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to decode metadata for %s", ctx)
+		}
+		return string(metadata), nil
+	}
+
+	metadataKey := guestInfoKeyMetadata
+	if usesNoCloudISO(ctx.VSphereVM) {
+		metadataKey = nocloud.MetadataKey
+	}
+
 	var metadataBase64 string
 	for _, ec := range obj.Config.ExtraConfig {
 		if optVal := ec.GetOptionValue(); optVal != nil {
@@ -652,7 +915,7 @@ func (vms *VMService) getMetadata(ctx *virtualMachineContext) (string, error) {
 			//             base64, it should be okay to not check.
 			//nolint:gocritic
 			switch optVal.Key {
-			case guestInfoKeyMetadata:
+			case metadataKey:
 				if v, ok := optVal.Value.(string); ok {
 					metadataBase64 = v
 				}
@@ -672,27 +935,60 @@ func (vms *VMService) getMetadata(ctx *virtualMachineContext) (string, error) {
 	return string(metadataBuf), nil
 }
 
+// reconcileHostInfo records the ESXi host running the VM, its migrations to
+// other hosts, its topology, and the health of the host as seen by vCenter.
+// The topology is only resolved again when the VM moves to another host, its
+// datastore being refreshed on every reconcile.
 func (vms *VMService) reconcileHostInfo(ctx *virtualMachineContext) error {
 	host, err := ctx.Obj.HostSystem(ctx)
 	if err != nil {
 		return err
 	}
-	name, err := host.ObjectName(ctx)
-	if err != nil {
+	var obj mo.HostSystem
+	if err := host.Properties(ctx, host.Reference(), []string{"name", "runtime.connectionState"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to get the host of vm %s", ctx)
+	}
+	// The host vCenter placed the VM on when it was cloned is only recorded
+	// once.
+	if placement := ctx.VSphereVM.Status.Placement; placement != nil && placement.Host == nil {
+		hostRef := host.Reference()
+		if placement.Host, err = vcenter.InventoryObject(&ctx.VMContext, &hostRef); err != nil {
+			return err
+		}
+	}
+	previousHost := ctx.VSphereVM.Status.Host
+	reconcileMigration(ctx, previousHost, obj.Name)
+	ctx.VSphereVM.Status.Host = obj.Name
+	if err := reconcileTopology(ctx, previousHost != obj.Name); err != nil {
 		return err
 	}
-	ctx.VSphereVM.Status.Host = name
-	return nil
+	return reconcileHostHealth(ctx, obj.Runtime.ConnectionState)
 }
 
 func (vms *VMService) setMetadata(ctx *virtualMachineContext, metadata []byte) (string, error) {
-	var extraConfig extra.Config
-
-	extraConfig.SetCloudInitMetadata(metadata)
+	var spec types.VirtualMachineConfigSpec
+	switch {
+	case usesNoCloudISO(ctx.VSphereVM):
+		isoSpec, err := vms.getNoCloudISOSpec(ctx, metadata)
+		if err != nil {
+			return "", err
+		}
+		spec = *isoSpec
+	case ctx.VSphereVM.Spec.BootstrapDataDelivery == infrav1.BootstrapDataDeliveryVAppProperties:
+		vAppConfig, err := vms.getVAppMetadataSpec(ctx, metadata)
+		if err != nil {
+			return "", err
+		}
+		spec.VAppConfig = vAppConfig
+	default:
+		var extraConfig extra.Config
+		extraConfig.SetCloudInitMetadata(metadata)
+		spec.ExtraConfig = extraConfig
+	}
 
-	task, err := ctx.Obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{
-		ExtraConfig: extraConfig,
-	})
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	task, err := ctx.Obj.Reconfigure(ctx, spec)
+	done(err)
 	if err != nil {
 		return "", errors.Wrapf(err, "unable to set metadata on vm %s", ctx)
 	}
@@ -700,8 +996,35 @@ func (vms *VMService) setMetadata(ctx *virtualMachineContext, metadata []byte) (
 	return task.Reference().Value, nil
 }
 
+// getVAppMetadataSpec returns the vApp config spec setting the metadata as
+// vApp properties of the VM.
+func (vms *VMService) getVAppMetadataSpec(ctx *virtualMachineContext, metadata []byte) (types.BaseVmConfigSpec, error) {
+	properties := vapp.Properties{}
+	if err := properties.SetMetadata(metadata); err != nil {
+		return nil, errors.Wrapf(err, "unable to set the metadata as vApp properties on vm %s", ctx)
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.vAppConfig"}, &obj); err != nil {
+		return nil, errors.Wrapf(err, "unable to fetch the vApp config of vm %s", ctx)
+	}
+	var existing []types.VAppPropertyInfo
+	if obj.Config != nil && obj.Config.VAppConfig != nil {
+		existing = obj.Config.VAppConfig.GetVmConfigInfo().Property
+	}
+	return properties.ConfigSpec(existing), nil
+}
+
 func (vms *VMService) getNetworkStatus(ctx *virtualMachineContext) ([]infrav1.NetworkStatus, error) {
-	allNetStatus, err := govmominet.GetNetworkStatus(ctx, ctx.Session.Client.Client, ctx.Ref)
+	var (
+		allNetStatus []govmominet.NetworkStatus
+		err          error
+	)
+	if obj, ok := ctx.Session.PropertyCache().VirtualMachine(ctx.Ref); ok && obj.Config != nil {
+		allNetStatus, err = govmominet.GetNetworkStatusFromObject(obj)
+	} else {
+		allNetStatus, err = govmominet.GetNetworkStatus(ctx, ctx.Session.Client.Client, ctx.Ref)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -778,20 +1101,47 @@ func (vms *VMService) reconcileVMGroupInfo(ctx *virtualMachineContext) (bool, er
 	return true, nil
 }
 
+// reconcileTags attaches the tags of the spec which are not attached to the
+// VM yet. The tags removed from the spec are left attached, since they cannot
+// be told apart from the tags attached by other tools.
 func (vms *VMService) reconcileTags(ctx *virtualMachineContext) error {
 	if len(ctx.VSphereVM.Spec.TagIDs) == 0 {
 		ctx.Logger.V(5).Info("no tags defined. skipping tags reconciliation")
 		return nil
 	}
 
-	err := ctx.Session.TagManager.AttachMultipleTagsToObject(ctx, ctx.VSphereVM.Spec.TagIDs, ctx.Ref)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationTag, ctx.Session.URL().Host)
+	attached, err := ctx.Session.TagManager.ListAttachedTags(ctx, ctx.Ref)
+	done(err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the tags attached to VM %s", ctx.VSphereVM.Name)
+	}
+	missing := missingTags(ctx.VSphereVM.Spec.TagIDs, attached)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	done = metrics.TrackVSphereOperation(metrics.VSphereOperationTag, ctx.Session.URL().Host)
+	err = ctx.Session.TagManager.AttachMultipleTagsToObject(ctx, missing, ctx.Ref)
+	done(err)
 	if err != nil {
-		return errors.Wrapf(err, "failed to attach tags %v to VM %s", ctx.VSphereVM.Spec.TagIDs, ctx.VSphereVM.Name)
+		return errors.Wrapf(err, "failed to attach tags %v to VM %s", missing, ctx.VSphereVM.Name)
 	}
 
 	return nil
 }
 
+// missingTags returns the tags which are not in the attached ones.
+func missingTags(tagIDs, attached []string) []string {
+	var missing []string
+	for _, id := range tagIDs {
+		if !slices.Contains(attached, id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
 func (vms *VMService) reconcileClusterModuleMembership(ctx *virtualMachineContext) error {
 	if ctx.ClusterModuleInfo != nil {
 		ctx.Logger.V(5).Info("add vm to module", "moduleUUID", *ctx.ClusterModuleInfo)
@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1a1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -171,6 +172,54 @@ func Test_reconcileIPAddressClaims_ShouldGenerateIPAddressClaims(t *testing.T) {
 		claimedCondition := conditions.Get(ctx.VSphereVM, infrav1.IPAddressClaimedCondition)
 		g.Expect(claimedCondition).To(BeNil())
 	})
+
+	t.Run("creates the missing claims of the other VSphereVMs of the cluster", func(_ *testing.T) {
+		before()
+		_ = infrav1.AddToScheme(scheme)
+		vsphereVM := func(name, cluster string) *infrav1.VSphereVM {
+			return &infrav1.VSphereVM{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "my-namespace",
+					Labels:    map[string]string{clusterv1.ClusterLabelName: cluster},
+				},
+				Spec: infrav1.VSphereVMSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						Network: infrav1.NetworkSpec{
+							Devices: []infrav1.NetworkDeviceSpec{
+								{
+									AddressesFromPools: []corev1.TypedLocalObjectReference{
+										{
+											APIGroup: &myAPIGroup,
+											Name:     "my-pool-1",
+											Kind:     "my-pool-kind",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+		ctx.VSphereVM = vsphereVM("vsphereVM1", "my-cluster")
+		ctx.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			ctx.VSphereVM, vsphereVM("vsphereVM2", "my-cluster"), vsphereVM("vsphereVM3", "other-cluster"),
+		).Build()
+
+		reconciled, err := vms.reconcileIPAddressClaims(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(reconciled).To(BeTrue())
+
+		ipAddrClaims := &ipamv1a1.IPAddressClaimList{}
+		ctx.Client.List(ctx, ipAddrClaims)
+		g.Expect(ipAddrClaims.Items).To(HaveLen(2))
+		for _, claim := range ipAddrClaims.Items {
+			g.Expect(claim.Name).To(BeElementOf("vsphereVM1-0-0", "vsphereVM2-0-0"))
+			g.Expect(claim.OwnerReferences).To(HaveLen(1))
+			g.Expect(claim.OwnerReferences[0].Kind).To(Equal("VSphereVM"))
+		}
+	})
 }
 
 //nolint:errcheck
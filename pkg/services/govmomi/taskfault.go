@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// maxTaskFaults bounds the chain of causes of the fault of a task reported
+// in the status of a VSphereVM.
+const maxTaskFaults = 10
+
+// taskFailureDetails returns the fault of a failed task followed by the chain
+// of its causes.
+func taskFailureDetails(task *mo.Task) *infrav1.TaskFailureDetails {
+	details := &infrav1.TaskFailureDetails{
+		TaskRef:   task.Reference().Value,
+		Operation: task.Info.DescriptionId,
+	}
+	if task.Info.CompleteTime != nil {
+		details.CompleteTime = &metav1.Time{Time: *task.Info.CompleteTime}
+	}
+	for fault := task.Info.Error; fault != nil && len(details.Faults) < maxTaskFaults; {
+		taskFault := infrav1.TaskFault{
+			Type:    "MethodFault",
+			Message: fault.LocalizedMessage,
+		}
+		var cause *types.LocalizedMethodFault
+		if fault.Fault != nil {
+			t := reflect.TypeOf(fault.Fault)
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			taskFault.Type = t.Name()
+			if methodFault := fault.Fault.GetMethodFault(); methodFault != nil {
+				for _, message := range methodFault.FaultMessage {
+					if message.Message != "" {
+						taskFault.Details = append(taskFault.Details, message.Message)
+					} else {
+						taskFault.Details = append(taskFault.Details, message.Key)
+					}
+				}
+				cause = methodFault.FaultCause
+			}
+		}
+		details.Faults = append(details.Faults, taskFault)
+		fault = cause
+	}
+	return details
+}
+
+// taskFaultMessage returns the messages of the fault of a failed task and of
+// its causes, or their types if vCenter did not localize them.
+func taskFaultMessage(details *infrav1.TaskFailureDetails) string {
+	messages := make([]string, 0, len(details.Faults))
+	for _, fault := range details.Faults {
+		if fault.Message != "" {
+			messages = append(messages, fault.Message)
+		} else {
+			messages = append(messages, fault.Type)
+		}
+	}
+	return strings.Join(messages, ": ")
+}
+
+// reportTaskFailure emits an event with the fault of a failed task of a
+// VSphereVM the first time the failure is seen.
+func reportTaskFailure(ctx *context.VMContext, task *mo.Task) {
+	if task == nil || task.Info.State != types.TaskInfoStateError {
+		return
+	}
+	if reported := ctx.VSphereVM.Status.FailureDetails; reported != nil && reported.TaskRef == task.Reference().Value {
+		return
+	}
+	ctx.Recorder.Warnf(ctx.VSphereVM, "TaskFailed", "Task %s %s failed: %s",
+		task.Reference().Value, task.Info.DescriptionId, taskFaultMessage(taskFailureDetails(task)))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// armGuestIDPrefix is the prefix of the guest IDs of the ARM guests, e.g.
+// arm-ubuntu64Guest.
+const armGuestIDPrefix = "arm-"
+
+// Incompatibility is a mismatch between a template and the virtual machines
+// of a clone spec, which cannot be cloned from the template.
+type Incompatibility struct {
+	// Field is the field of the clone spec the template does not match,
+	// relative to the clone spec, e.g. "architecture".
+	Field string
+
+	// Reason is the reason of the conditions reporting the incompatibility.
+	Reason string
+
+	// Message describes the incompatibility.
+	Message string
+}
+
+// IncompatibleError is the error of the clone of a template incompatible
+// with the spec of a virtual machine.
+type IncompatibleError struct {
+	// Incompatibilities are the mismatches between the template and the spec.
+	Incompatibilities []Incompatibility
+}
+
+func (e *IncompatibleError) Error() string {
+	messages := make([]string, len(e.Incompatibilities))
+	for i, inc := range e.Incompatibilities {
+		messages[i] = fmt.Sprintf("%s: %s", inc.Field, inc.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Reason returns the reason of the first incompatibility, which is reported
+// by the conditions of the virtual machine.
+func (e *IncompatibleError) Reason() string {
+	return e.Incompatibilities[0].Reason
+}
+
+// Incompatibilities returns the mismatches between a template, given by its
+// configuration, and the virtual machines of a clone spec, i.e. between the
+// guest ID of the template and the architecture and operating system of the
+// spec, and between its firmware and the architecture, secure boot, trusted
+// platform module and virtual IOMMU of the spec. The guest ID is not checked when the template has
+// none, and the operating system when its guest ID is a generic one, e.g.
+// otherGuest64, which does not tell.
+func Incompatibilities(spec *infrav1.VirtualMachineCloneSpec, config *types.VirtualMachineConfigInfo) []Incompatibility {
+	if config == nil {
+		return nil
+	}
+	arch := spec.Architecture
+	if arch == "" {
+		arch = infrav1.ArchitectureAmd64
+	}
+	var incompatibilities []Incompatibility
+	if guestID := config.GuestId; guestID != "" {
+		if guestArch := guestArchitecture(guestID); guestArch != arch {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Field:   "architecture",
+				Reason:  infrav1.TemplateArchitectureMismatchReason,
+				Message: fmt.Sprintf("template %s has guest ID %s, which is %s and not %s", spec.Template, guestID, guestArch, arch),
+			})
+		}
+
+		if windows, known := windowsGuest(guestID); known && windows != (spec.OS == infrav1.Windows) {
+			message := "template %s has guest ID %s, which is not a Windows guest"
+			if windows {
+				message = "template %s has guest ID %s, which is a Windows guest"
+			}
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Field:   "os",
+				Reason:  infrav1.TemplateOSMismatchReason,
+				Message: fmt.Sprintf(message, spec.Template, guestID),
+			})
+		}
+	}
+
+	// The trusted platform module, secure boot and the virtual IOMMU are only
+	// available with EFI firmware, which cannot be changed without
+	// reinstalling the guest, and the arm64 virtual machines only boot with
+	// EFI firmware.
+	if config.Firmware != string(types.GuestOsDescriptorFirmwareTypeEfi) {
+		switch {
+		case spec.SecureBoot || spec.TrustedPlatformModule:
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Field:   "template",
+				Reason:  infrav1.TemplateFirmwareMismatchReason,
+				Message: fmt.Sprintf("template %s has %s firmware, secure boot and the trusted platform module require EFI firmware", spec.Template, config.Firmware),
+			})
+		case spec.VirtualIOMMU:
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Field:   "virtualIOMMU",
+				Reason:  infrav1.TemplateFirmwareMismatchReason,
+				Message: fmt.Sprintf("template %s has %s firmware, the virtual IOMMU requires EFI firmware", spec.Template, config.Firmware),
+			})
+		case arch == infrav1.ArchitectureArm64:
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Field:   "template",
+				Reason:  infrav1.TemplateFirmwareMismatchReason,
+				Message: fmt.Sprintf("template %s has %s firmware, arm64 virtual machines require EFI firmware", spec.Template, config.Firmware),
+			})
+		}
+	}
+	return incompatibilities
+}
+
+// guestArchitecture returns the architecture of a guest ID.
+func guestArchitecture(guestID string) infrav1.Architecture {
+	if strings.HasPrefix(guestID, armGuestIDPrefix) {
+		return infrav1.ArchitectureArm64
+	}
+	return infrav1.ArchitectureAmd64
+}
+
+// windowsGuest returns whether a guest ID is the one of a Windows guest, and
+// whether this is known, which it is not for the generic guest IDs.
+func windowsGuest(guestID string) (windows, known bool) {
+	guestID = strings.TrimPrefix(guestID, armGuestIDPrefix)
+	switch {
+	case strings.HasPrefix(guestID, "win"):
+		return true, true
+	case strings.HasPrefix(guestID, "otherGuest"), strings.HasPrefix(guestID, "other64Guest"):
+		return false, false
+	default:
+		return false, true
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func TestIncompatibilities(t *testing.T) {
+	efi := string(types.GuestOsDescriptorFirmwareTypeEfi)
+	bios := string(types.GuestOsDescriptorFirmwareTypeBios)
+
+	tests := []struct {
+		name    string
+		spec    infrav1.VirtualMachineCloneSpec
+		config  types.VirtualMachineConfigInfo
+		reasons []string
+	}{
+		{
+			name:   "an amd64 Linux template is compatible with the defaults",
+			config: types.VirtualMachineConfigInfo{GuestId: "ubuntu64Guest", Firmware: bios},
+		},
+		{
+			name:   "a template without guest ID is compatible",
+			spec:   infrav1.VirtualMachineCloneSpec{Architecture: infrav1.ArchitectureArm64, OS: infrav1.Windows},
+			config: types.VirtualMachineConfigInfo{Firmware: efi},
+		},
+		{
+			name:    "an arm64 template is not compatible with amd64 machines",
+			config:  types.VirtualMachineConfigInfo{GuestId: "arm-ubuntu64Guest", Firmware: efi},
+			reasons: []string{infrav1.TemplateArchitectureMismatchReason},
+		},
+		{
+			name:   "an arm64 template is compatible with arm64 machines",
+			spec:   infrav1.VirtualMachineCloneSpec{Architecture: infrav1.ArchitectureArm64},
+			config: types.VirtualMachineConfigInfo{GuestId: "arm-ubuntu64Guest", Firmware: efi},
+		},
+		{
+			name:    "an amd64 template is not compatible with arm64 machines",
+			spec:    infrav1.VirtualMachineCloneSpec{Architecture: infrav1.ArchitectureArm64},
+			config:  types.VirtualMachineConfigInfo{GuestId: "otherGuest64", Firmware: efi},
+			reasons: []string{infrav1.TemplateArchitectureMismatchReason},
+		},
+		{
+			name:    "a Windows template is not compatible with Linux machines",
+			config:  types.VirtualMachineConfigInfo{GuestId: "windows2019srv_64Guest", Firmware: efi},
+			reasons: []string{infrav1.TemplateOSMismatchReason},
+		},
+		{
+			name:    "a Linux template is not compatible with Windows machines",
+			spec:    infrav1.VirtualMachineCloneSpec{OS: infrav1.Windows},
+			config:  types.VirtualMachineConfigInfo{GuestId: "rhel8_64Guest", Firmware: efi},
+			reasons: []string{infrav1.TemplateOSMismatchReason},
+		},
+		{
+			name:   "a generic template is compatible with Windows machines",
+			spec:   infrav1.VirtualMachineCloneSpec{OS: infrav1.Windows},
+			config: types.VirtualMachineConfigInfo{GuestId: "otherGuest64", Firmware: efi},
+		},
+		{
+			name:    "a BIOS template is not compatible with arm64 machines",
+			spec:    infrav1.VirtualMachineCloneSpec{Architecture: infrav1.ArchitectureArm64},
+			config:  types.VirtualMachineConfigInfo{Firmware: bios},
+			reasons: []string{infrav1.TemplateFirmwareMismatchReason},
+		},
+		{
+			name:    "a BIOS template is not compatible with secure boot",
+			spec:    infrav1.VirtualMachineCloneSpec{SecureBoot: true},
+			config:  types.VirtualMachineConfigInfo{GuestId: "ubuntu64Guest", Firmware: bios},
+			reasons: []string{infrav1.TemplateFirmwareMismatchReason},
+		},
+		{
+			name:    "a BIOS template is not compatible with the virtual IOMMU",
+			spec:    infrav1.VirtualMachineCloneSpec{VirtualIOMMU: true, HardwareVirtualization: true},
+			config:  types.VirtualMachineConfigInfo{GuestId: "ubuntu64Guest", Firmware: bios},
+			reasons: []string{infrav1.TemplateFirmwareMismatchReason},
+		},
+		{
+			name:    "all the incompatibilities are reported",
+			spec:    infrav1.VirtualMachineCloneSpec{OS: infrav1.Windows, TrustedPlatformModule: true},
+			config:  types.VirtualMachineConfigInfo{GuestId: "arm-ubuntu64Guest", Firmware: bios},
+			reasons: []string{infrav1.TemplateArchitectureMismatchReason, infrav1.TemplateOSMismatchReason, infrav1.TemplateFirmwareMismatchReason},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tt.spec.Template = "tpl"
+			var reasons []string
+			for _, inc := range Incompatibilities(&tt.spec, &tt.config) {
+				reasons = append(reasons, inc.Reason)
+			}
+			g.Expect(reasons).To(Equal(tt.reasons))
+		})
+	}
+}
+
+func TestIncompatibleError(t *testing.T) {
+	g := NewWithT(t)
+	spec := &infrav1.VirtualMachineCloneSpec{Template: "ubuntu-2004-arm64"}
+	err := &IncompatibleError{Incompatibilities: Incompatibilities(spec, &types.VirtualMachineConfigInfo{GuestId: "arm-ubuntu64Guest"})}
+	g.Expect(err.Reason()).To(Equal(infrav1.TemplateArchitectureMismatchReason))
+	g.Expect(err).To(MatchError("architecture: template ubuntu-2004-arm64 has guest ID arm-ubuntu64Guest, which is arm64 and not amd64"))
+}
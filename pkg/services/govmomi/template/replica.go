@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+// Replica is a copy of a template stored on another datastore.
+type Replica struct {
+	// Name is the name of the replica.
+	Name string
+
+	// InstanceUUID is the instance UUID of the replica. It is empty until
+	// the replica exists.
+	InstanceUUID string
+
+	// TaskRef is the managed object reference of the task creating the
+	// replica, if it is in flight.
+	TaskRef string
+}
+
+// Ready returns true if the replica exists and can be cloned from.
+func (r Replica) Ready() bool {
+	return r.InstanceUUID != ""
+}
+
+// ReplicaName returns the name of the replica of a template on a datastore.
+func ReplicaName(template, datastore string) string {
+	return fmt.Sprintf("%s-%s", template, datastore)
+}
+
+// EnsureReplica ensures the template has a replica on the datastore. The
+// replica is created next to the template, in the same folder. A template
+// that is already stored on the datastore is its own replica.
+//
+// The replica is created asynchronously: as long as the returned replica is
+// not ready, EnsureReplica must be called again with its TaskRef.
+func EnsureReplica(ctx context.Context, s *session.Session, datacenter, templateID, datastore, taskRef string) (replica Replica, err error) {
+	finder := find.NewFinder(s.Client.Client, false)
+	dc, err := finder.Datacenter(ctx, datacenter)
+	if err != nil {
+		return Replica{}, errors.Wrapf(err, "unable to find datacenter %q", datacenter)
+	}
+	finder.SetDatacenter(dc)
+
+	tpl, err := findReplicatedTemplate(ctx, s, finder, dc, templateID)
+	if err != nil {
+		return Replica{}, err
+	}
+	ds, err := finder.Datastore(ctx, datastore)
+	if err != nil {
+		return Replica{}, errors.Wrapf(err, "unable to find datastore %q", datastore)
+	}
+
+	var tplMo mo.VirtualMachine
+	if err := tpl.Properties(ctx, tpl.Reference(), []string{"name", "parent", "datastore", "config.instanceUuid"}, &tplMo); err != nil {
+		return Replica{}, errors.Wrapf(err, "unable to get properties of template %q", templateID)
+	}
+	if tplMo.Config == nil || tplMo.Parent == nil {
+		return Replica{}, errors.Errorf("template %q is not accessible", templateID)
+	}
+	for _, ref := range tplMo.Datastore {
+		if ref == ds.Reference() {
+			return Replica{Name: tplMo.Name, InstanceUUID: tplMo.Config.InstanceUuid}, nil
+		}
+	}
+
+	replica = Replica{Name: ReplicaName(tplMo.Name, ds.Name())}
+	if taskRef != "" {
+		var task mo.Task
+		if err := s.RetrieveOne(ctx, types.ManagedObjectReference{Type: "Task", Value: taskRef}, []string{"info"}, &task); err == nil {
+			switch task.Info.State {
+			case types.TaskInfoStateQueued, types.TaskInfoStateRunning:
+				replica.TaskRef = taskRef
+				return replica, nil
+			case types.TaskInfoStateError:
+				if task.Info.Error != nil {
+					return replica, errors.Errorf("failed to create replica %q: %s", replica.Name, task.Info.Error.LocalizedMessage)
+				}
+				return replica, errors.Errorf("failed to create replica %q", replica.Name)
+			}
+		}
+	}
+
+	folder := object.NewFolder(s.Client.Client, *tplMo.Parent)
+	ref, err := object.NewSearchIndex(s.Client.Client).FindChild(ctx, folder, replica.Name)
+	if err != nil {
+		return replica, errors.Wrapf(err, "unable to find replica %q", replica.Name)
+	}
+	if ref != nil {
+		var replicaMo mo.VirtualMachine
+		if err := s.RetrieveOne(ctx, ref.Reference(), []string{"config.instanceUuid"}, &replicaMo); err != nil {
+			return replica, errors.Wrapf(err, "unable to get properties of replica %q", replica.Name)
+		}
+		if replicaMo.Config == nil {
+			return replica, errors.Errorf("replica %q is not accessible", replica.Name)
+		}
+		replica.InstanceUUID = replicaMo.Config.InstanceUuid
+		return replica, nil
+	}
+
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationClone, s.URL().Host)
+	defer func() { done(err) }()
+
+	spec := types.VirtualMachineCloneSpec{
+		Template: true,
+		Location: types.VirtualMachineRelocateSpec{
+			Datastore: types.NewReference(ds.Reference()),
+		},
+	}
+	task, err := tpl.Clone(ctx, folder, replica.Name, spec)
+	if err != nil {
+		return replica, errors.Wrapf(err, "failed to trigger the creation of replica %q", replica.Name)
+	}
+	replica.TaskRef = task.Reference().Value
+	return replica, nil
+}
+
+func findReplicatedTemplate(ctx context.Context, s *session.Session, finder *find.Finder, dc *object.Datacenter, templateID string) (*object.VirtualMachine, error) {
+	if isValidUUID(templateID) {
+		isInstanceUUID := true
+		ref, err := object.NewSearchIndex(s.Client.Client).FindByUuid(ctx, dc, templateID, true, &isInstanceUUID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error querying template by instance UUID %q", templateID)
+		}
+		if ref != nil {
+			return object.NewVirtualMachine(s.Client.Client, ref.Reference()), nil
+		}
+	}
+	tpl, err := finder.VirtualMachine(ctx, templateID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find template by name %q", templateID)
+	}
+	return tpl, nil
+}
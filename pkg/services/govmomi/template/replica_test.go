@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+func TestEnsureReplica(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	model := simulator.VPX()
+	model.Datastore = 2
+	g.Expect(model.Create()).To(Succeed())
+	defer model.Remove()
+	model.Service.TLS = new(tls.Config)
+	server := model.Service.NewServer()
+	defer server.Close()
+
+	pass, _ := server.URL.User.Password()
+	s, err := session.GetOrCreate(ctx, session.NewParams().
+		WithServer(server.URL.Host).
+		WithUserInfo(server.URL.User.Username(), pass).
+		WithDatacenter("*"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tpl := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	g.Expect(tpl.Datastore).To(HaveLen(1))
+	var source, target string
+	for _, obj := range simulator.Map.All("Datastore") {
+		ds := obj.(*simulator.Datastore)
+		if ds.Reference() == tpl.Datastore[0] {
+			source = ds.Name
+		} else {
+			target = ds.Name
+		}
+	}
+	g.Expect(source).NotTo(BeEmpty())
+	g.Expect(target).NotTo(BeEmpty())
+
+	t.Run("uses the template stored on the datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		replica, err := EnsureReplica(ctx, s, "DC0", tpl.Name, source, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(replica.Ready()).To(BeTrue())
+		g.Expect(replica.Name).To(Equal(tpl.Name))
+		g.Expect(replica.InstanceUUID).To(Equal(tpl.Config.InstanceUuid))
+	})
+
+	t.Run("creates the replica on another datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		replica, err := EnsureReplica(ctx, s, "DC0", tpl.Name, target, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(replica.Ready()).To(BeFalse())
+		g.Expect(replica.Name).To(Equal(ReplicaName(tpl.Name, target)))
+		g.Expect(replica.TaskRef).NotTo(BeEmpty())
+
+		task := object.NewTask(s.Client.Client, types.ManagedObjectReference{Type: "Task", Value: replica.TaskRef})
+		g.Expect(task.Wait(ctx)).To(Succeed())
+
+		replica, err = EnsureReplica(ctx, s, "DC0", tpl.Name, target, replica.TaskRef)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(replica.Ready()).To(BeTrue())
+		g.Expect(replica.TaskRef).To(BeEmpty())
+		g.Expect(replica.InstanceUUID).NotTo(Equal(tpl.Config.InstanceUuid))
+
+		// The existing replica is found by instance UUID of the template as well.
+		again, err := EnsureReplica(ctx, s, "DC0", tpl.Config.InstanceUuid, target, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(again).To(Equal(replica))
+	})
+
+	t.Run("fails for an unknown datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := EnsureReplica(ctx, s, "DC0", tpl.Name, "unknown", "")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -24,6 +24,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/object"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
 )
 
@@ -33,9 +36,16 @@ type tplContext interface {
 	GetSession() *session.Session
 }
 
-// FindTemplate finds a template based either on a UUID or name.
-func FindTemplate(ctx tplContext, templateID string) (*object.VirtualMachine, error) {
-	tpl, err := findTemplateByInstanceUUID(ctx, templateID)
+// FindTemplate finds a template based either on a managed object ID, a UUID
+// or name.
+func FindTemplate(ctx tplContext, templateID string) (tpl *object.VirtualMachine, err error) {
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationFind, ctx.GetSession().URL().Host)
+	defer func() { done(err) }()
+
+	if infrav1.IsMoRef(templateID) {
+		return findTemplateByMoRef(ctx, templateID)
+	}
+	tpl, err = findTemplateByInstanceUUID(ctx, templateID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,10 +70,20 @@ func findTemplateByInstanceUUID(ctx tplContext, templateID string) (*object.Virt
 	return nil, nil
 }
 
+func findTemplateByMoRef(ctx tplContext, templateID string) (*object.VirtualMachine, error) {
+	ctx.GetLogger().V(6).Info("find template by managed object ID", "moref", templateID)
+	obj, err := find.MoRef(ctx, ctx.GetSession().Client.Client, "VirtualMachine", templateID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error querying template by managed object ID")
+	}
+	return obj.(*object.VirtualMachine), nil
+}
+
 func findTemplateByName(ctx tplContext, templateID string) (*object.VirtualMachine, error) {
 	ctx.GetLogger().V(6).Info("find template by name", "name", templateID)
 	tpl, err := ctx.GetSession().Finder.VirtualMachine(ctx, templateID)
 	if err != nil {
+		err = find.Ambiguous(ctx, ctx.GetSession().Finder, "VirtualMachine", templateID, err)
 		return nil, errors.Wrapf(err, "unable to find template by name %q", templateID)
 	}
 	return tpl, nil
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// reconcileTimeSync reconfigures the VMware Tools of the VM with the time
+// synchronization settings of its spec which differ from its current
+// configuration, e.g. after they were changed in vCenter.
+func (vms *VMService) reconcileTimeSync(ctx *virtualMachineContext) (bool, error) {
+	timeSync := ctx.VSphereVM.Spec.TimeSync
+	if timeSync == nil || (timeSync.SyncOnStartup == nil && timeSync.PeriodicSync == nil) {
+		return true, nil
+	}
+
+	var obj mo.VirtualMachine
+	if err := ctx.Session.RetrieveOne(ctx, ctx.Ref, []string{"config.tools"}, &obj); err != nil {
+		return false, errors.Wrapf(err, "unable to fetch the tools config of vm %s", ctx)
+	}
+	if obj.Config == nil {
+		return true, nil
+	}
+
+	tools, ok := getTimeSyncToolsConfig(timeSync, obj.Config.Tools)
+	if !ok {
+		return true, nil
+	}
+
+	ctx.Logger.Info("updating time synchronization", "syncOnStartup", tools.SyncTimeWithHostAllowed, "periodicSync", tools.SyncTimeWithHost)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationReconfigure, ctx.Session.URL().Host)
+	task, err := ctx.Obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{Tools: tools})
+	done(err)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to update the time synchronization of vm %s", ctx)
+	}
+	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	ctx.Logger.Info("wait for the time synchronization to be updated")
+	return false, nil
+}
+
+// getTimeSyncToolsConfig returns the tools config applying the time
+// synchronization settings which differ from the existing tools config of a
+// VM, and false if there are none. Since the periodic synchronization cannot
+// be enabled without the synchronization on startup, the latter is enabled
+// along with the former when it is not set.
+func getTimeSyncToolsConfig(timeSync *infrav1.TimeSync, existing *types.ToolsConfigInfo) (*types.ToolsConfigInfo, bool) {
+	if existing == nil {
+		existing = &types.ToolsConfigInfo{}
+	}
+
+	syncOnStartup := timeSync.SyncOnStartup
+	if syncOnStartup == nil && pointer.BoolDeref(timeSync.PeriodicSync, false) {
+		syncOnStartup = pointer.Bool(true)
+	}
+
+	tools := &types.ToolsConfigInfo{}
+	changed := false
+	if syncOnStartup != nil && *syncOnStartup != pointer.BoolDeref(existing.SyncTimeWithHostAllowed, true) {
+		tools.SyncTimeWithHostAllowed = syncOnStartup
+		changed = true
+	}
+	if timeSync.PeriodicSync != nil && *timeSync.PeriodicSync != pointer.BoolDeref(existing.SyncTimeWithHost, false) {
+		tools.SyncTimeWithHost = timeSync.PeriodicSync
+		changed = true
+	}
+	return tools, changed
+}
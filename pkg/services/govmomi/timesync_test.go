@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/utils/pointer"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_getTimeSyncToolsConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeSync infrav1.TimeSync
+		existing *types.ToolsConfigInfo
+		changed  bool
+		expected *types.ToolsConfigInfo
+	}{
+		{
+			name:     "with the current settings",
+			timeSync: infrav1.TimeSync{SyncOnStartup: pointer.Bool(true), PeriodicSync: pointer.Bool(false)},
+			existing: &types.ToolsConfigInfo{SyncTimeWithHostAllowed: pointer.Bool(true), SyncTimeWithHost: pointer.Bool(false)},
+		},
+		{
+			name:     "without tools config",
+			timeSync: infrav1.TimeSync{SyncOnStartup: pointer.Bool(true), PeriodicSync: pointer.Bool(false)},
+		},
+		{
+			name:     "disables the periodic synchronization",
+			timeSync: infrav1.TimeSync{PeriodicSync: pointer.Bool(false)},
+			existing: &types.ToolsConfigInfo{SyncTimeWithHostAllowed: pointer.Bool(true), SyncTimeWithHost: pointer.Bool(true)},
+			changed:  true,
+			expected: &types.ToolsConfigInfo{SyncTimeWithHost: pointer.Bool(false)},
+		},
+		{
+			name:     "disables the synchronization on startup of a vCenter not reporting it",
+			timeSync: infrav1.TimeSync{SyncOnStartup: pointer.Bool(false)},
+			existing: &types.ToolsConfigInfo{},
+			changed:  true,
+			expected: &types.ToolsConfigInfo{SyncTimeWithHostAllowed: pointer.Bool(false)},
+		},
+		{
+			name:     "enables the synchronization on startup along with the periodic synchronization",
+			timeSync: infrav1.TimeSync{PeriodicSync: pointer.Bool(true)},
+			existing: &types.ToolsConfigInfo{SyncTimeWithHostAllowed: pointer.Bool(false), SyncTimeWithHost: pointer.Bool(false)},
+			changed:  true,
+			expected: &types.ToolsConfigInfo{SyncTimeWithHostAllowed: pointer.Bool(true), SyncTimeWithHost: pointer.Bool(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tools, changed := getTimeSyncToolsConfig(&tt.timeSync, tt.existing)
+			g.Expect(changed).To(Equal(tt.changed))
+			if tt.changed {
+				g.Expect(tools).To(Equal(tt.expected))
+			}
+		})
+	}
+}
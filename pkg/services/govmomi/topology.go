@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// reconcileTopology records the datacenter, compute cluster, resource pool and
+// datastore of the VM. The ancestors of the resource pool are only resolved
+// again when moved is true, i.e. when the VM moves to another host, while the
+// datastore is derived from the current configuration of the VM so that it
+// follows the VM when it is moved by Storage vMotion.
+func reconcileTopology(ctx *virtualMachineContext, moved bool) error {
+	var obj mo.VirtualMachine
+	if err := ctx.Obj.Properties(ctx, ctx.Ref, []string{"resourcePool", "config.files.vmPathName"}, &obj); err != nil {
+		return errors.Wrapf(err, "unable to get the placement of vm %s", ctx)
+	}
+	var vmPathName string
+	if obj.Config != nil {
+		vmPathName = obj.Config.Files.VmPathName
+	}
+
+	if topology := ctx.VSphereVM.Status.Topology; topology != nil && !moved {
+		ctx.VSphereVM.Status.Topology = withDatastore(topology, vmPathName)
+		return nil
+	}
+
+	var ancestors []mo.ManagedEntity
+	if obj.ResourcePool != nil {
+		c := ctx.Session.Client.Client
+		var err error
+		if ancestors, err = mo.Ancestors(ctx, c, c.ServiceContent.PropertyCollector, *obj.ResourcePool); err != nil {
+			return errors.Wrapf(err, "unable to get the resource pool of vm %s", ctx)
+		}
+	}
+	ctx.VSphereVM.Status.Topology = getTopology(ancestors, vmPathName)
+	return nil
+}
+
+// getTopology returns the topology of a VM from the ancestors of its resource
+// pool, the resource pool being the last one, and from the datastore path of
+// its configuration.
+func getTopology(ancestors []mo.ManagedEntity, vmPathName string) *infrav1.VSphereVMTopology {
+	topology := &infrav1.VSphereVMTopology{}
+	for _, entity := range ancestors {
+		switch entity.Self.Type {
+		case "Datacenter":
+			topology.Datacenter = entity.Name
+		case "ClusterComputeResource":
+			topology.ComputeCluster = entity.Name
+		}
+	}
+	if len(ancestors) > 0 {
+		topology.ResourcePool = ancestors[len(ancestors)-1].Name
+	}
+	return withDatastore(topology, vmPathName)
+}
+
+// withDatastore returns a copy of a topology whose datastore is the one of the
+// datastore path of the configuration of the VM.
+func withDatastore(topology *infrav1.VSphereVMTopology, vmPathName string) *infrav1.VSphereVMTopology {
+	topology = topology.DeepCopy()
+	topology.Datastore = ""
+	var path object.DatastorePath
+	if path.FromString(vmPathName) {
+		topology.Datastore = path.Datastore
+	}
+	return topology
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package govmomi
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func Test_getTopology(t *testing.T) {
+	entity := func(kind, name string) mo.ManagedEntity {
+		e := mo.ManagedEntity{Name: name}
+		e.Self = types.ManagedObjectReference{Type: kind, Value: name}
+		return e
+	}
+
+	tests := []struct {
+		name       string
+		ancestors  []mo.ManagedEntity
+		vmPathName string
+		want       *infrav1.VSphereVMTopology
+	}{
+		{
+			name: "in a resource pool of a compute cluster",
+			ancestors: []mo.ManagedEntity{
+				entity("Folder", "Datacenters"),
+				entity("Datacenter", "dc0"),
+				entity("Folder", "host"),
+				entity("ClusterComputeResource", "cluster0"),
+				entity("ResourcePool", "Resources"),
+				entity("ResourcePool", "workers"),
+			},
+			vmPathName: "[datastore1] machine-1/machine-1.vmx",
+			want:       &infrav1.VSphereVMTopology{Datacenter: "dc0", ComputeCluster: "cluster0", ResourcePool: "workers", Datastore: "datastore1"},
+		},
+		{
+			name: "on a standalone host",
+			ancestors: []mo.ManagedEntity{
+				entity("Folder", "Datacenters"),
+				entity("Datacenter", "dc0"),
+				entity("Folder", "host"),
+				entity("ComputeResource", "esxi-1"),
+				entity("ResourcePool", "Resources"),
+			},
+			want: &infrav1.VSphereVMTopology{Datacenter: "dc0", ResourcePool: "Resources"},
+		},
+		{
+			name: "without resource pool",
+			want: &infrav1.VSphereVMTopology{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(getTopology(tt.ancestors, tt.vmPathName)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_withDatastore(t *testing.T) {
+	topology := &infrav1.VSphereVMTopology{Datacenter: "dc0", ComputeCluster: "cluster0", ResourcePool: "workers", Datastore: "datastore1"}
+
+	t.Run("follows the VM moved to another datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(withDatastore(topology, "[datastore2] machine-1/machine-1.vmx")).To(Equal(
+			&infrav1.VSphereVMTopology{Datacenter: "dc0", ComputeCluster: "cluster0", ResourcePool: "workers", Datastore: "datastore2"}))
+		g.Expect(topology.Datastore).To(Equal("datastore1"))
+	})
+
+	t.Run("clears the datastore of a VM without configuration path", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(withDatastore(topology, "")).To(Equal(
+			&infrav1.VSphereVMTopology{Datacenter: "dc0", ComputeCluster: "cluster0", ResourcePool: "workers"}))
+	})
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package govmomi
 
 import (
+	goctx "context"
+	"fmt"
 	gonet "net"
 	"path"
 	"time"
@@ -27,13 +29,18 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/net"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
 )
 
 func sanitizeIPAddrs(ctx *context.VMContext, ipAddrs []string) []string {
@@ -57,7 +64,17 @@ func sanitizeIPAddrs(ctx *context.VMContext, ipAddrs []string) []string {
 //     which was assigned the value of the VSphereVM resource's UID string.
 //  3. If it is not found by instance UUID, fallback to an inventory path search
 //     using the vm folder path and the VSphereVM name
-func findVM(ctx *context.VMContext) (types.ManagedObjectReference, error) {
+func findVM(ctx *context.VMContext) (ref types.ManagedObjectReference, err error) {
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationFind, ctx.Session.URL().Host)
+	defer func() {
+		// A VM that does not exist yet is an expected outcome of the lookup.
+		if isNotFound(err) {
+			done(nil)
+			return
+		}
+		done(err)
+	}()
+
 	if biosUUID := ctx.VSphereVM.Spec.BiosUUID; biosUUID != "" {
 		objRef, err := ctx.Session.FindByBIOSUUID(ctx, biosUUID)
 		if err != nil {
@@ -78,11 +95,17 @@ func findVM(ctx *context.VMContext) (types.ManagedObjectReference, error) {
 	}
 	if objRef == nil {
 		// fallback to use inventory paths
-		folder, err := ctx.Session.Finder.FolderOrDefault(ctx, ctx.VSphereVM.Spec.Folder)
+		folderPath, err := vcenter.FolderPath(ctx)
+		if err != nil {
+			return types.ManagedObjectReference{}, err
+		}
+		folder, err := find.ByMoRef(ctx.Session.Client.Client, "Folder", func(c goctx.Context, name string) (object.Reference, error) {
+			return ctx.Session.Finder.FolderOrDefault(c, name)
+		})(ctx, folderPath)
 		if err != nil {
 			return types.ManagedObjectReference{}, err
 		}
-		inventoryPath := path.Join(folder.InventoryPath, ctx.VSphereVM.Name)
+		inventoryPath := path.Join(folder.(*object.Folder).InventoryPath, ctx.VSphereVM.VMName())
 		ctx.Logger.Info("using inventory path to find vm", "path", inventoryPath)
 		vm, err := ctx.Session.Finder.VirtualMachine(ctx, inventoryPath)
 		if err != nil {
@@ -107,10 +130,32 @@ func getTask(ctx *context.VMContext) *mo.Task {
 		Type:  morefTypeTask,
 		Value: ctx.VSphereVM.Status.TaskRef,
 	}
-	if err := ctx.Session.RetrieveOne(ctx, moRef, []string{"info"}, &obj); err != nil {
-		return nil
+	// The clone task of a cross-vCenter clone runs on the vCenter of the
+	// template. It is only looked up there, as its reference may also be the
+	// reference of another task on the vCenter of the VM.
+	s := ctx.Session
+	if isTemplateTask(ctx) {
+		if ctx.TemplateSession == nil {
+			return nil
+		}
+		s = ctx.TemplateSession
+	}
+	if cached, ok := s.PropertyCache().Task(moRef); ok {
+		return &cached
 	}
-	return &obj
+	if err := s.RetrieveOne(ctx, moRef, []string{"info"}, &obj); err == nil {
+		return &obj
+	}
+	return nil
+}
+
+// isTemplateTask returns true if the in-flight task of a VSphereVM is the
+// clone task of a cross-vCenter clone, which runs on the vCenter of the
+// template.
+func isTemplateTask(ctx *context.VMContext) bool {
+	task := ctx.VSphereVM.Status.Task
+	return ctx.VSphereVM.Spec.TemplateSource != nil && task != nil &&
+		task.Ref == ctx.VSphereVM.Status.TaskRef && task.Operation == vcenter.CloneTaskOperation
 }
 
 // reconcileInFlightTask determines if a task associated to the VSphereVM object
@@ -128,6 +173,7 @@ func checkAndRetryTask(ctx *context.VMContext, task *mo.Task) (bool, error) {
 	// resource's Status.TaskRef field.
 	if task == nil {
 		ctx.VSphereVM.Status.TaskRef = ""
+		clearTaskStatus(ctx)
 		return false, nil
 	}
 
@@ -143,16 +189,26 @@ func checkAndRetryTask(ctx *context.VMContext, task *mo.Task) (bool, error) {
 	switch task.Info.State {
 	case types.TaskInfoStateQueued:
 		logger.Info("task is still pending", "description-id", task.Info.DescriptionId)
+		setTaskStatus(ctx, task, infrav1.TaskStateQueued)
 		return true, nil
 	case types.TaskInfoStateRunning:
-		logger.Info("task is still running", "description-id", task.Info.DescriptionId)
+		logger.Info("task is still running", "description-id", task.Info.DescriptionId, "progress", task.Info.Progress)
+		setTaskStatus(ctx, task, infrav1.TaskStateRunning)
 		return true, nil
 	case types.TaskInfoStateSuccess:
 		logger.Info("task is a success", "description-id", task.Info.DescriptionId)
+		if isCloneTask(task) {
+			ctx.VSphereVM.Status.CloneAttempts = 0
+		}
+		if failed := ctx.VSphereVM.Status.FailureDetails; failed != nil && failed.Operation == task.Info.DescriptionId {
+			ctx.VSphereVM.Status.FailureDetails = nil
+		}
 		ctx.VSphereVM.Status.TaskRef = ""
+		clearTaskStatus(ctx)
 		return false, nil
 	case types.TaskInfoStateError:
 		logger.Info("task failed", "description-id", task.Info.DescriptionId)
+		clearTaskStatus(ctx)
 
 		// NOTE: When a task fails there is not simple way to understand which operation is failing (e.g. cloning or powering on)
 		// so we are reporting failures using a dedicated reason until we find a better solution.
@@ -161,12 +217,30 @@ func checkAndRetryTask(ctx *context.VMContext, task *mo.Task) (bool, error) {
 		if task.Info.Description != nil {
 			description = task.Info.Description.Message
 		}
+		// The fault of the task tells why it failed, e.g. which device of a
+		// clone spec vCenter rejected.
+		details := taskFailureDetails(task)
+		ctx.VSphereVM.Status.FailureDetails = details
+		if description == "" {
+			description = taskFaultMessage(details)
+		}
 		conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.TaskFailure, clusterv1.ConditionSeverityInfo, description)
 
+		// The failed clone task is kept as the task of the VSphereVM once its
+		// clone retry policy gives up, so that the VM is not cloned again.
+		if isCloneTask(task) && cloneAttemptsExhausted(ctx.VSphereVM) {
+			message := fmt.Sprintf("VM failed to be cloned %d times: %s", ctx.VSphereVM.Status.CloneAttempts, description)
+			conditions.MarkFalse(ctx.VSphereVM, infrav1.VMProvisionedCondition, infrav1.TaskFailure, clusterv1.ConditionSeverityError, message)
+			ctx.VSphereVM.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.CreateMachineError)
+			ctx.VSphereVM.Status.FailureMessage = pointer.StringPtr(message)
+			ctx.VSphereVM.Status.RetryAfter = metav1.Time{}
+			return true, nil
+		}
+
 		// Instead of directly requeuing the failed task, wait for the RetryAfter duration to pass
 		// before resetting the taskRef from the VSphereVM status.
 		if ctx.VSphereVM.Status.RetryAfter.IsZero() {
-			ctx.VSphereVM.Status.RetryAfter = metav1.Time{Time: time.Now().Add(1 * time.Minute)}
+			ctx.VSphereVM.Status.RetryAfter = metav1.Time{Time: time.Now().Add(taskRetryBackoff(ctx.VSphereVM, task))}
 		} else {
 			ctx.VSphereVM.Status.TaskRef = ""
 			ctx.VSphereVM.Status.RetryAfter = metav1.Time{}
@@ -177,6 +251,25 @@ func checkAndRetryTask(ctx *context.VMContext, task *mo.Task) (bool, error) {
 	}
 }
 
+// setTaskStatus reflects the in-flight task in the VSphereVM status.
+func setTaskStatus(ctx *context.VMContext, task *mo.Task, state infrav1.TaskState) {
+	ctx.VSphereVM.Status.Task = &infrav1.TaskStatus{
+		Ref:       task.Reference().Value,
+		Operation: task.Info.DescriptionId,
+		Entity:    task.Info.EntityName,
+		State:     state,
+	}
+	ctx.VSphereVM.Status.TaskProgress = nil
+	if state == infrav1.TaskStateRunning && task.Info.Progress > 0 {
+		ctx.VSphereVM.Status.TaskProgress = pointer.Int32Ptr(task.Info.Progress)
+	}
+}
+
+func clearTaskStatus(ctx *context.VMContext) {
+	ctx.VSphereVM.Status.Task = nil
+	ctx.VSphereVM.Status.TaskProgress = nil
+}
+
 func reconcileVSphereVMWhenNetworkIsReady(ctx *virtualMachineContext, powerOnTask *object.Task) {
 	reconcileVSphereVMOnChannel(
 		&ctx.VMContext,
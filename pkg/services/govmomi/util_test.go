@@ -30,6 +30,7 @@ import (
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vcenter"
 )
 
 func Test_ShouldRetryTask(t *testing.T) {
@@ -126,6 +127,115 @@ func Test_ShouldRetryTask(t *testing.T) {
 		g.Expect(conditions.IsFalse(vmCtx.VSphereVM, infrav1.VMProvisionedCondition))
 		g.Expect(vmCtx.VSphereVM.Status.RetryAfter.Unix()).To(BeNumerically("<=", metav1.Now().Add(1*time.Minute).Unix()))
 	})
+
+	t.Run("when task is in flight", func(t *testing.T) {
+		g := NewWithT(t)
+		vmCtx := &context.VMContext{
+			Logger: logr.Discard(),
+			VSphereVM: &infrav1.VSphereVM{Status: infrav1.VSphereVMStatus{
+				TaskRef: "task-123",
+			}},
+		}
+
+		task := baseTask(types.TaskInfoStateQueued, "")
+		task.Info.DescriptionId = "VirtualMachine.clone"
+		task.Info.EntityName = "template"
+		_, err := checkAndRetryTask(vmCtx, &task)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(vmCtx.VSphereVM.Status.Task).To(Equal(&infrav1.TaskStatus{
+			Ref:       "-for-logger",
+			Operation: "VirtualMachine.clone",
+			Entity:    "template",
+			State:     infrav1.TaskStateQueued,
+		}))
+		g.Expect(vmCtx.VSphereVM.Status.TaskProgress).To(BeNil())
+
+		task.Info.State = types.TaskInfoStateRunning
+		task.Info.Progress = 42
+		_, err = checkAndRetryTask(vmCtx, &task)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(vmCtx.VSphereVM.Status.Task.State).To(Equal(infrav1.TaskStateRunning))
+		g.Expect(*vmCtx.VSphereVM.Status.TaskProgress).To(Equal(int32(42)))
+
+		task.Info.State = types.TaskInfoStateSuccess
+		_, err = checkAndRetryTask(vmCtx, &task)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(vmCtx.VSphereVM.Status.Task).To(BeNil())
+		g.Expect(vmCtx.VSphereVM.Status.TaskProgress).To(BeNil())
+	})
+
+	t.Run("when clone task failed with a clone retry policy", func(t *testing.T) {
+		newVMCtx := func(attempts int32) *context.VMContext {
+			return &context.VMContext{
+				Logger: logr.Discard(),
+				VSphereVM: &infrav1.VSphereVM{
+					Spec: infrav1.VSphereVMSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						CloneRetryPolicy: &infrav1.CloneRetryPolicy{
+							MaxAttempts: 3,
+							Backoff:     &metav1.Duration{Duration: 2 * time.Minute},
+						},
+					}},
+					Status: infrav1.VSphereVMStatus{
+						TaskRef:       "task-123",
+						CloneAttempts: attempts,
+					},
+				},
+			}
+		}
+		task := baseTask(types.TaskInfoStateError, "template is broken")
+		task.Info.DescriptionId = "VirtualMachine.clone"
+
+		t.Run("the retries are backed off", func(t *testing.T) {
+			g := NewWithT(t)
+			vmCtx := newVMCtx(2)
+			reconciled, err := checkAndRetryTask(vmCtx, &task)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(reconciled).To(BeTrue())
+			g.Expect(vmCtx.VSphereVM.Status.RetryAfter.Unix()).To(BeNumerically(">", metav1.Now().Add(3*time.Minute).Unix()))
+			g.Expect(vmCtx.VSphereVM.Status.FailureReason).To(BeNil())
+		})
+
+		t.Run("the machine fails once the attempts are exhausted", func(t *testing.T) {
+			g := NewWithT(t)
+			vmCtx := newVMCtx(3)
+			reconciled, err := checkAndRetryTask(vmCtx, &task)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(reconciled).To(BeTrue())
+			g.Expect(vmCtx.VSphereVM.Status.TaskRef).To(Equal("task-123"))
+			g.Expect(vmCtx.VSphereVM.Status.RetryAfter.IsZero()).To(BeTrue())
+			g.Expect(vmCtx.VSphereVM.Status.FailureReason).NotTo(BeNil())
+			g.Expect(*vmCtx.VSphereVM.Status.FailureMessage).To(ContainSubstring("3 times"))
+		})
+
+		t.Run("the attempts are reset by a successful clone", func(t *testing.T) {
+			g := NewWithT(t)
+			vmCtx := newVMCtx(2)
+			success := baseTask(types.TaskInfoStateSuccess, "")
+			success.Info.DescriptionId = "VirtualMachine.clone"
+			_, err := checkAndRetryTask(vmCtx, &success)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(vmCtx.VSphereVM.Status.CloneAttempts).To(BeZero())
+		})
+	})
+}
+
+func Test_TaskRetryBackoff(t *testing.T) {
+	g := NewWithT(t)
+	vm := &infrav1.VSphereVM{}
+	clone := baseTask(types.TaskInfoStateError, "")
+	clone.Info.DescriptionId = "VirtualMachine.clone"
+	powerOn := baseTask(types.TaskInfoStateError, "")
+	powerOn.Info.DescriptionId = "VirtualMachine.powerOn"
+
+	vm.Status.CloneAttempts = 4
+	g.Expect(taskRetryBackoff(vm, &clone)).To(Equal(time.Minute))
+
+	vm.Spec.CloneRetryPolicy = &infrav1.CloneRetryPolicy{}
+	g.Expect(taskRetryBackoff(vm, &clone)).To(Equal(8 * time.Minute))
+	g.Expect(taskRetryBackoff(vm, &powerOn)).To(Equal(time.Minute))
+
+	vm.Status.CloneAttempts = 10
+	g.Expect(taskRetryBackoff(vm, &clone)).To(Equal(10 * time.Minute))
 }
 
 func baseTask(state types.TaskInfoState, errorDescription string) mo.Task {
@@ -148,3 +258,79 @@ func baseTask(state types.TaskInfoState, errorDescription string) mo.Task {
 	}
 	return t
 }
+
+func Test_TaskFailureDetails(t *testing.T) {
+	g := NewWithT(t)
+	task := baseTask(types.TaskInfoStateError, "")
+	task.Info.DescriptionId = "VirtualMachine.clone"
+	task.Info.Error = &types.LocalizedMethodFault{
+		Fault: &types.InvalidDeviceSpec{
+			InvalidVmConfig: types.InvalidVmConfig{
+				VmConfigFault: types.VmConfigFault{
+					VimFault: types.VimFault{
+						MethodFault: types.MethodFault{
+							FaultCause: &types.LocalizedMethodFault{
+								Fault: &types.FileNotFound{},
+							},
+							FaultMessage: []types.LocalizableMessage{{Key: "msg.disk.noBackEnd"}},
+						},
+					},
+				},
+			},
+		},
+		LocalizedMessage: "Invalid configuration for device '0'.",
+	}
+
+	details := taskFailureDetails(&task)
+	g.Expect(details.TaskRef).To(Equal("-for-logger"))
+	g.Expect(details.Operation).To(Equal("VirtualMachine.clone"))
+	g.Expect(details.Faults).To(Equal([]infrav1.TaskFault{
+		{Type: "InvalidDeviceSpec", Message: "Invalid configuration for device '0'.", Details: []string{"msg.disk.noBackEnd"}},
+		{Type: "FileNotFound"},
+	}))
+	g.Expect(taskFaultMessage(details)).To(Equal("Invalid configuration for device '0'.: FileNotFound"))
+
+	vmCtx := &context.VMContext{
+		Logger:    logr.Discard(),
+		VSphereVM: &infrav1.VSphereVM{Status: infrav1.VSphereVMStatus{TaskRef: "task-123"}},
+	}
+	_, err := checkAndRetryTask(vmCtx, &task)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(vmCtx.VSphereVM.Status.FailureDetails).To(Equal(details))
+	g.Expect(conditions.GetMessage(vmCtx.VSphereVM, infrav1.VMProvisionedCondition)).To(Equal(taskFaultMessage(details)))
+
+	success := baseTask(types.TaskInfoStateSuccess, "")
+	success.Info.DescriptionId = "VirtualMachine.clone"
+	_, err = checkAndRetryTask(vmCtx, &success)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(vmCtx.VSphereVM.Status.FailureDetails).To(BeNil())
+}
+
+func Test_isTemplateTask(t *testing.T) {
+	g := NewWithT(t)
+	vm := &infrav1.VSphereVM{
+		Spec: infrav1.VSphereVMSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+			TemplateSource: &infrav1.TemplateSource{Server: "vc2.example.com"},
+		}},
+		Status: infrav1.VSphereVMStatus{
+			TaskRef: "task-123",
+			Task:    &infrav1.TaskStatus{Ref: "task-123", Operation: vcenter.CloneTaskOperation},
+		},
+	}
+	vmCtx := &context.VMContext{VSphereVM: vm}
+	g.Expect(isTemplateTask(vmCtx)).To(BeTrue())
+
+	// The other tasks run on the vCenter of the VM.
+	vm.Status.Task.Operation = "VirtualMachine.powerOn"
+	g.Expect(isTemplateTask(vmCtx)).To(BeFalse())
+
+	// The status of the task is stale until the new task is retrieved.
+	vm.Status.Task.Operation = vcenter.CloneTaskOperation
+	vm.Status.TaskRef = "task-124"
+	g.Expect(isTemplateTask(vmCtx)).To(BeFalse())
+
+	// The clones from the vCenter of the VM run on it.
+	vm.Status.TaskRef = "task-123"
+	vm.Spec.TemplateSource = nil
+	g.Expect(isTemplateTask(vmCtx)).To(BeFalse())
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vapp delivers the bootstrap data and metadata of a VM through the
+// vApp properties of its OVF environment, as read by the OVF datasource of
+// cloud-init.
+package vapp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// UserDataProperty is the vApp property holding the user data.
+	UserDataProperty = "user-data"
+
+	// MetadataProperty is the vApp property holding the CAPV metadata.
+	MetadataProperty = "metadata"
+
+	// InstanceIDProperty is the vApp property holding the instance ID.
+	InstanceIDProperty = "instance-id"
+
+	// HostnameProperty is the vApp property holding the hostname.
+	HostnameProperty = "hostname"
+
+	// NetworkConfigProperty is the vApp property holding the network
+	// configuration of the metadata.
+	NetworkConfigProperty = "network-config"
+
+	// MaxPropertySize is the maximum size of the encoded value of a vApp
+	// property. Values exceeding it once base64 encoded are gzipped.
+	MaxPropertySize = 64 * 1024
+
+	// ovfEnvironmentTransport exposes the OVF environment to the guest as the
+	// guestinfo.ovfEnv key, from which it is read through VMware Tools.
+	ovfEnvironmentTransport = "com.vmware.guestInfo"
+)
+
+// Properties are the values of vApp properties, by ID.
+type Properties map[string]string
+
+// SetUserData sets the user data property to the encoded data.
+func (p Properties) SetUserData(data []byte) error {
+	value, err := Encode(data)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode the user data")
+	}
+	p[UserDataProperty] = value
+	return nil
+}
+
+// SetMetadata sets the metadata property to the encoded metadata, along with
+// the instance ID, hostname and network configuration properties read by
+// cloud-init.
+func (p Properties) SetMetadata(metadata []byte) error {
+	value, err := Encode(metadata)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode the metadata")
+	}
+	p[MetadataProperty] = value
+
+	var doc struct {
+		InstanceID    string      `json:"instance-id"`
+		LocalHostname string      `json:"local-hostname"`
+		Network       interface{} `json:"network"`
+	}
+	if err := yaml.Unmarshal(metadata, &doc); err != nil {
+		return errors.Wrap(err, "unable to parse the metadata")
+	}
+	p[InstanceIDProperty] = doc.InstanceID
+	p[HostnameProperty] = doc.LocalHostname
+	if doc.Network != nil {
+		network, err := yaml.Marshal(doc.Network)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal the network configuration")
+		}
+		if p[NetworkConfigProperty], err = Encode(network); err != nil {
+			return errors.Wrap(err, "unable to encode the network configuration")
+		}
+	}
+	return nil
+}
+
+// ConfigSpec returns the vApp config spec setting the properties. The
+// properties already defined on the VM, e.g. by the OVF descriptor of its
+// image, are edited while the others are added.
+func (p Properties) ConfigSpec(existing []types.VAppPropertyInfo) *types.VmConfigSpec {
+	keys := map[string]int32{}
+	var nextKey int32
+	for _, property := range existing {
+		keys[property.Id] = property.Key
+		if property.Key >= nextKey {
+			nextKey = property.Key + 1
+		}
+	}
+
+	ids := make([]string, 0, len(p))
+	for id := range p {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	spec := &types.VmConfigSpec{
+		OvfEnvironmentTransport: []string{ovfEnvironmentTransport},
+	}
+	for _, id := range ids {
+		property := types.VAppPropertySpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationEdit},
+			Info: &types.VAppPropertyInfo{
+				Id:    id,
+				Value: p[id],
+			},
+		}
+		if key, ok := keys[id]; ok {
+			property.Info.Key = key
+		} else {
+			property.Operation = types.ArrayUpdateOperationAdd
+			property.Info.Key = nextKey
+			property.Info.Type = "string"
+			nextKey++
+		}
+		spec.Property = append(spec.Property, property)
+	}
+	return spec
+}
+
+// Get returns the value of the property with the given ID from the vApp
+// config of a VM.
+func Get(config types.BaseVmConfigInfo, id string) (string, bool) {
+	if config == nil {
+		return "", false
+	}
+	for _, property := range config.GetVmConfigInfo().Property {
+		if property.Id == id {
+			return property.Value, true
+		}
+	}
+	return "", false
+}
+
+// Encode returns the data as a base64 encoded string. The data is gzipped
+// first if the string would otherwise exceed MaxPropertySize. An error is
+// returned if the data does not fit even once gzipped.
+func Encode(data []byte) (string, error) {
+	value := base64.StdEncoding.EncodeToString(data)
+	if len(value) <= MaxPropertySize {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	value = base64.StdEncoding.EncodeToString(buf.Bytes())
+	if len(value) > MaxPropertySize {
+		return "", errors.Errorf("the encoded data is %d bytes long once gzipped, which exceeds the maximum of %d bytes", len(value), MaxPropertySize)
+	}
+	return value, nil
+}
+
+// Decode returns the data encoded by Encode.
+func Decode(value string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// The data was not gzipped.
+		return data, nil //nolint:nilerr
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
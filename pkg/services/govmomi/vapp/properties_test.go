@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vapp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestEncode(t *testing.T) {
+	t.Run("encodes small data as base64", func(t *testing.T) {
+		g := NewWithT(t)
+		value, err := Encode([]byte("#cloud-config"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(value).To(Equal(base64.StdEncoding.EncodeToString([]byte("#cloud-config"))))
+
+		data, err := Decode(value)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).To(Equal("#cloud-config"))
+	})
+
+	t.Run("gzips large data", func(t *testing.T) {
+		g := NewWithT(t)
+		large := bytes.Repeat([]byte("write_files: []\n"), MaxPropertySize)
+		value, err := Encode(large)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(len(value)).To(BeNumerically("<=", MaxPropertySize))
+
+		data, err := Decode(value)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(Equal(large))
+	})
+
+	t.Run("fails for data that does not fit once gzipped", func(t *testing.T) {
+		g := NewWithT(t)
+		random := make([]byte, MaxPropertySize)
+		_, err := rand.Read(random)
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = Encode(random)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestSetMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	metadata := []byte(`
+instance-id: "test-vm"
+local-hostname: "test-vm"
+network:
+  version: 2
+  ethernets:
+    id0:
+      dhcp4: true
+`)
+	properties := Properties{}
+	g.Expect(properties.SetMetadata(metadata)).To(Succeed())
+	g.Expect(properties).To(HaveKeyWithValue(InstanceIDProperty, "test-vm"))
+	g.Expect(properties).To(HaveKeyWithValue(HostnameProperty, "test-vm"))
+	g.Expect(properties).To(HaveKey(MetadataProperty))
+
+	network, err := Decode(properties[NetworkConfigProperty])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(network)).To(Equal("ethernets:\n  id0:\n    dhcp4: true\nversion: 2\n"))
+}
+
+func TestConfigSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	properties := Properties{
+		UserDataProperty:   "dXNlcg==",
+		InstanceIDProperty: "test-vm",
+	}
+	spec := properties.ConfigSpec([]types.VAppPropertyInfo{
+		{Key: 3, Id: UserDataProperty},
+		{Key: 7, Id: "seedfrom"},
+	})
+
+	g.Expect(spec.OvfEnvironmentTransport).To(ConsistOf("com.vmware.guestInfo"))
+	g.Expect(spec.Property).To(HaveLen(2))
+	g.Expect(spec.Property[0].Operation).To(Equal(types.ArrayUpdateOperationAdd))
+	g.Expect(spec.Property[0].Info.Id).To(Equal(InstanceIDProperty))
+	g.Expect(spec.Property[0].Info.Key).To(Equal(int32(8)))
+	g.Expect(spec.Property[0].Info.Value).To(Equal("test-vm"))
+	g.Expect(spec.Property[1].Operation).To(Equal(types.ArrayUpdateOperationEdit))
+	g.Expect(spec.Property[1].Info.Id).To(Equal(UserDataProperty))
+	g.Expect(spec.Property[1].Info.Key).To(Equal(int32(3)))
+	g.Expect(spec.Property[1].Info.Value).To(Equal("dXNlcg=="))
+
+	value, ok := Get(&types.VmConfigInfo{Property: []types.VAppPropertyInfo{{Id: MetadataProperty, Value: "bWV0YQ=="}}}, MetadataProperty)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value).To(Equal("bWV0YQ=="))
+}
@@ -32,8 +32,12 @@ import (
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/cdrom"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/extra"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/nocloud"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/template"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/vapp"
 )
 
 const (
@@ -51,56 +55,126 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		ControllerContext: ctx.ControllerContext,
 		VSphereVM:         ctx.VSphereVM,
 		Session:           ctx.Session,
+		TemplateSession:   ctx.TemplateSession,
 		Logger:            ctx.Logger.WithName("vcenter"),
 		PatchHelper:       ctx.PatchHelper,
 	}
 	ctx.Logger.Info("starting clone process")
 
-	var extraConfig extra.Config
+	var (
+		extraConfig    extra.Config
+		vAppProperties = vapp.Properties{}
+	)
 	if len(bootstrapData) > 0 {
 		ctx.Logger.Info("applied bootstrap data to VM clone spec")
-		switch format {
-		case bootstrapv1.CloudConfig:
-			extraConfig.SetCloudInitUserData(bootstrapData)
-		case bootstrapv1.Ignition:
-			extraConfig.SetIgnitionUserData(bootstrapData)
+		if ctx.VSphereVM.Spec.BootstrapDataDelivery == infrav1.BootstrapDataDeliveryVAppProperties {
+			if format == bootstrapv1.Ignition {
+				return errors.Errorf("ignition bootstrap data cannot be delivered through vApp properties for %q", ctx)
+			}
+			if err := vAppProperties.SetUserData(bootstrapData); err != nil {
+				return errors.Wrapf(err, "unable to set the bootstrap data as vApp property for %q", ctx)
+			}
+		} else {
+			switch format {
+			case bootstrapv1.CloudConfig:
+				// Bootstrap data too large for the guestinfo keys is
+				// delivered through a NoCloud ISO image, attached along
+				// with the metadata once the VM is created.
+				if nocloud.ExceedsGuestInfoLimit(bootstrapData) {
+					ctx.Logger.Info("bootstrap data exceeds the size of the guestinfo keys, delivering it through a NoCloud ISO image", "size", len(bootstrapData))
+					if ctx.VSphereVM.Annotations == nil {
+						ctx.VSphereVM.Annotations = map[string]string{}
+					}
+					ctx.VSphereVM.Annotations[infrav1.NoCloudISOAnnotation] = ""
+				} else {
+					extraConfig.SetCloudInitUserData(bootstrapData)
+				}
+			case bootstrapv1.Ignition:
+				if nocloud.ExceedsGuestInfoLimit(bootstrapData) {
+					return errors.Errorf("ignition bootstrap data of %d bytes exceeds the maximum size of %d bytes of the guestinfo keys once encoded for %q", len(bootstrapData), nocloud.MaxGuestInfoSize, ctx)
+				}
+				extraConfig.SetIgnitionUserData(bootstrapData)
+			}
 		}
 	}
 	if ctx.VSphereVM.Spec.CustomVMXKeys != nil {
-		ctx.Logger.Info("applied custom vmx keys o VM clone spec")
+		ctx.Logger.Info("applied custom vmx keys to VM clone spec")
 		if err := extraConfig.SetCustomVMXKeys(ctx.VSphereVM.Spec.CustomVMXKeys); err != nil {
-			return err
+			return errors.Wrapf(err, "unable to set the custom VMX keys of %q", ctx)
 		}
 	}
-	tpl, err := template.FindTemplate(ctx, ctx.VSphereVM.Spec.Template)
+
+	// The template is looked up on the vCenter it is cloned from, which is
+	// not the vCenter of the VM for cross-vCenter clones.
+	tplCtx := ctx
+	if ctx.VSphereVM.Spec.TemplateSource != nil {
+		if ctx.TemplateSession == nil {
+			return errors.Errorf("no session to the template source %s for %q", ctx.VSphereVM.Spec.TemplateSource.Server, ctx)
+		}
+		tplCtx = &context.VMContext{
+			ControllerContext: ctx.ControllerContext,
+			VSphereVM:         ctx.VSphereVM,
+			Session:           ctx.TemplateSession,
+			Logger:            ctx.Logger,
+		}
+	}
+	tpl, err := template.FindTemplate(tplCtx, ctx.VSphereVM.Spec.Template)
 	if err != nil {
 		return err
 	}
 
+	// Record the immutable identifier the template was resolved to, so the
+	// clone source is known even if the template is later renamed.
+	var tplObj mo.VirtualMachine
+	if err := tpl.Properties(ctx, tpl.Reference(), []string{"config.instanceUuid", "config.vAppConfig", "config.firmware", "config.guestId", "summary.storage"}, &tplObj); err != nil {
+		return errors.Wrapf(err, "error getting instance uuid for template %s", ctx.VSphereVM.Spec.Template)
+	}
+	if tplObj.Config != nil {
+		ctx.VSphereVM.Status.TemplateInstanceUUID = tplObj.Config.InstanceUuid
+	}
+
+	// The VM is not cloned from a template it cannot run, e.g. of another
+	// architecture, before any folder is created for it.
+	if incompatibilities := template.Incompatibilities(&ctx.VSphereVM.Spec.VirtualMachineCloneSpec, tplObj.Config); len(incompatibilities) > 0 {
+		return &template.IncompatibleError{Incompatibilities: incompatibilities}
+	}
+
+	folder, err := ensureFolder(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The compute selector is only used when no resource pool is set, e.g.
+	// by the failure domain of the VM.
+	var pool *object.ResourcePool
+	if ctx.VSphereVM.Spec.ComputeSelector != nil && ctx.VSphereVM.Spec.ResourcePool == "" {
+		computeCluster, err := selectComputeCluster(ctx, ctx.VSphereVM.Spec.ComputeSelector)
+		if err != nil {
+			return err
+		}
+		ctx.Logger.Info("selected compute cluster", "computeCluster", computeCluster.name, "vms", computeCluster.vms, "freeMemory", computeCluster.freeMemory)
+		ctx.VSphereVM.Status.ComputeCluster = computeCluster.name
+		pool = object.NewResourcePool(ctx.Session.Client.Client, computeCluster.resourcePool)
+	} else {
+		pool, err = findResourcePool(ctx, ctx.VSphereVM.Spec.ResourcePool)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get resource pool for %q", ctx)
+		}
+	}
+
 	// If a linked clone is requested then a MoRef for a snapshot must be
 	// found with which to perform the linked clone.
 	var snapshotRef *types.ManagedObjectReference
-	//nolint:nestif
-	if ctx.VSphereVM.Spec.CloneMode == "" || ctx.VSphereVM.Spec.CloneMode == infrav1.LinkedClone {
+	// Linked clones share the disks of the snapshot, so they are not
+	// possible across vCenters.
+	switch {
+	case ctx.VSphereVM.Spec.TemplateSource != nil:
+		ctx.Logger.Info("cross-vCenter clone requested", "templateSource", ctx.VSphereVM.Spec.TemplateSource.Server)
+	case ctx.VSphereVM.Spec.CloneMode == "" || ctx.VSphereVM.Spec.CloneMode == infrav1.LinkedClone:
 		ctx.Logger.Info("linked clone requested")
-		// If the name of a snapshot was not provided then find the template's
-		// current snapshot.
-		if snapshotName := ctx.VSphereVM.Spec.Snapshot; snapshotName == "" {
-			ctx.Logger.Info("searching for current snapshot")
-			var vm mo.VirtualMachine
-			if err := tpl.Properties(ctx, tpl.Reference(), []string{"snapshot"}, &vm); err != nil {
-				return errors.Wrapf(err, "error getting snapshot information for template %s", ctx.VSphereVM.Spec.Template)
-			}
-			if vm.Snapshot != nil {
-				snapshotRef = vm.Snapshot.CurrentSnapshot
-			}
-		} else {
-			ctx.Logger.Info("searching for snapshot by name", "snapshotName", snapshotName)
-			var err error
-			snapshotRef, err = tpl.FindSnapshot(ctx, snapshotName)
-			if err != nil {
-				ctx.Logger.Info("failed to find snapshot", "snapshotName", snapshotName)
-			}
+		snapshotRef, err = getLinkedCloneSnapshot(ctx, tpl, pool)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -116,16 +190,6 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		diskMoveType = linkCloneDiskMoveType
 	}
 
-	folder, err := ctx.Session.Finder.FolderOrDefault(ctx, ctx.VSphereVM.Spec.Folder)
-	if err != nil {
-		return errors.Wrapf(err, "unable to get folder for %q", ctx)
-	}
-
-	pool, err := ctx.Session.Finder.ResourcePoolOrDefault(ctx, ctx.VSphereVM.Spec.ResourcePool)
-	if err != nil {
-		return errors.Wrapf(err, "unable to get resource pool for %q", ctx)
-	}
-
 	devices, err := tpl.Device(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "error getting devices for %q", ctx)
@@ -143,7 +207,7 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		deviceSpecs = append(deviceSpecs, diskSpecs...)
 	}
 
-	networkSpecs, err := getNetworkSpecs(ctx, devices)
+	networkSpecs, networks, err := getNetworkSpecs(ctx, devices)
 	if err != nil {
 		return errors.Wrapf(err, "error getting network specs for %q", ctx)
 	}
@@ -162,19 +226,20 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		deviceSpecs = append(deviceSpecs, gpuSpecs...)
 	}
 
-	numCPUs := ctx.VSphereVM.Spec.NumCPUs
-	if numCPUs < 2 {
-		numCPUs = 2
-	}
-	numCoresPerSocket := ctx.VSphereVM.Spec.NumCoresPerSocket
-	if numCoresPerSocket == 0 {
-		numCoresPerSocket = numCPUs
+	if len(ctx.VSphereVM.Spec.CDROMs) != 0 {
+		cdromSpecs, err := getCDROMSpecs(ctx, devices)
+		if err != nil {
+			return errors.Wrapf(err, "error getting cdrom specs for %q", ctx)
+		}
+		deviceSpecs = append(deviceSpecs, cdromSpecs...)
 	}
-	memMiB := ctx.VSphereVM.Spec.MemoryMiB
-	if memMiB == 0 {
-		memMiB = 2048
+
+	if ctx.VSphereVM.Spec.TrustedPlatformModule {
+		deviceSpecs = append(deviceSpecs, getTPMSpecs(devices)...)
 	}
 
+	numCPUs, numCoresPerSocket, memMiB := Size(&ctx.VSphereVM.Spec.VirtualMachineCloneSpec)
+
 	spec := types.VirtualMachineCloneSpec{
 		Config: &types.VirtualMachineConfigSpec{
 			// Assign the clone's InstanceUUID the value of the Kubernetes Machine
@@ -201,6 +266,28 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		Snapshot: snapshotRef,
 	}
 
+	// A VM recreated by the recreate recovery policy keeps the BIOS UUID of
+	// the VM it replaces, so that the provider ID of its machine is unchanged.
+	if biosUUID := ctx.VSphereVM.Spec.BiosUUID; biosUUID != "" {
+		spec.Config.Uuid = biosUUID
+	}
+
+	// The vApp properties defined by the OVF descriptor of the template are
+	// edited rather than added.
+	if len(vAppProperties) > 0 {
+		var existing []types.VAppPropertyInfo
+		if tplObj.Config != nil && tplObj.Config.VAppConfig != nil {
+			existing = tplObj.Config.VAppConfig.GetVmConfigInfo().Property
+		}
+		spec.Config.VAppConfig = vAppProperties.ConfigSpec(existing)
+	}
+
+	customization, err := getCustomizationSpec(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "error getting guest customization spec for %q", ctx)
+	}
+	spec.Customization = customization
+
 	// For PCI devices, the memory for the VM needs to be reserved
 	// We can replace this once we have another way of reserving memory option
 	// exposed via the API types.
@@ -208,9 +295,33 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		spec.Config.MemoryReservationLockedToMax = pointer.Bool(true)
 	}
 
+	if ctx.VSphereVM.Spec.SecureBoot {
+		spec.Config.BootOptions = &types.VirtualMachineBootOptions{
+			EfiSecureBootEnabled: pointer.Bool(true),
+		}
+	}
+
+	// Hardware-assisted virtualization and the virtual IOMMU are exposed to
+	// the guests running nested VMs, e.g. with KubeVirt.
+	if ctx.VSphereVM.Spec.HardwareVirtualization {
+		spec.Config.NestedHVEnabled = pointer.Bool(true)
+	}
+	if ctx.VSphereVM.Spec.VirtualIOMMU {
+		spec.Config.Flags.VvtdEnabled = pointer.Bool(true)
+	}
+
+	// The time synchronization settings which are set are applied to the
+	// clone, and the other ones keep the values of the template.
+	if timeSync := ctx.VSphereVM.Spec.TimeSync; timeSync != nil && (timeSync.SyncOnStartup != nil || timeSync.PeriodicSync != nil) {
+		spec.Config.Tools = &types.ToolsConfigInfo{
+			SyncTimeWithHostAllowed: timeSync.SyncOnStartup,
+			SyncTimeWithHost:        timeSync.PeriodicSync,
+		}
+	}
+
 	var datastoreRef *types.ManagedObjectReference
 	if ctx.VSphereVM.Spec.Datastore != "" {
-		datastore, err := ctx.Session.Finder.Datastore(ctx, ctx.VSphereVM.Spec.Datastore)
+		datastore, err := findDatastore(ctx, ctx.VSphereVM.Spec.Datastore)
 		if err != nil {
 			return errors.Wrapf(err, "unable to get datastore %s for %q", ctx.VSphereVM.Spec.Datastore, ctx)
 		}
@@ -218,6 +329,10 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		spec.Location.Datastore = datastoreRef
 	}
 
+	// The datastores compatible with the storage policy, to which the
+	// datastore selector is restricted when a storage policy is set.
+	var compatibleRefs []types.ManagedObjectReference
+	var storagePolicyApplied bool
 	var storageProfileID string
 	//nolint:nestif
 	if ctx.VSphereVM.Spec.StoragePolicyName != "" {
@@ -254,6 +369,11 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 			if !found {
 				return fmt.Errorf("couldn't find specified datastore: %s in compatible list of datastores for storage policy", ctx.VSphereVM.Spec.Datastore)
 			}
+		} else if ctx.VSphereVM.Spec.DatastoreSelector != nil {
+			storagePolicyApplied = true
+			for _, ds := range result.CompatibleDatastores() {
+				compatibleRefs = append(compatibleRefs, types.ManagedObjectReference{Type: ds.HubType, Value: ds.HubId})
+			}
 		} else {
 			rand.Seed(time.Now().UnixNano())
 			ds := result.CompatibleDatastores()[rand.Intn(len(result.CompatibleDatastores()))] //nolint:gosec
@@ -261,9 +381,23 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 		}
 	}
 
+	// The datastore selector is only used when no datastore is set, e.g. by
+	// the failure domain of the VM.
+	if datastoreRef == nil && ctx.VSphereVM.Spec.DatastoreSelector != nil {
+		required := cloneRequiredSpace(&ctx.VSphereVM.Spec.VirtualMachineCloneSpec, snapshotRef != nil, tplObj.Summary.Storage)
+		datastore, err := selectDatastore(ctx, ctx.VSphereVM.Spec.DatastoreSelector, compatibleRefs, storagePolicyApplied, required)
+		if err != nil {
+			return err
+		}
+		ctx.Logger.Info("selected datastore", "datastore", datastore.Summary.Name, "freeSpace", datastore.Summary.FreeSpace)
+		ctx.VSphereVM.Status.Datastore = datastore.Summary.Name
+		datastoreRef = types.NewReference(datastore.Reference())
+		spec.Location.Datastore = datastoreRef
+	}
+
 	if datastoreRef == nil {
 		// if no datastore defined through VM spec or storage policy, use default
-		datastore, err := ctx.Session.Finder.DefaultDatastore(ctx)
+		datastore, err := findDatastore(ctx, "")
 		if err != nil {
 			return errors.Wrapf(err, "unable to get default datastore for %q", ctx)
 		}
@@ -273,13 +407,49 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 	disks := devices.SelectByType((*types.VirtualDisk)(nil))
 	spec.Location.Disk = getDiskLocators(disks, *datastoreRef)
 
-	ctx.Logger.Info("cloning machine", "namespace", ctx.VSphereVM.Namespace, "name", ctx.VSphereVM.Name, "cloneType", ctx.VSphereVM.Status.CloneMode)
-	task, err := tpl.Clone(ctx, folder, ctx.VSphereVM.Name, spec)
+	// Cross-vCenter clones are initiated on the vCenter of the template, and
+	// target the inventory of the VM's vCenter through its service locator.
+	if ctx.VSphereVM.Spec.TemplateSource != nil {
+		spec.Location.Datastore = datastoreRef
+		spec.Location.Service = ctx.Session.ServiceLocator()
+	}
+
+	// The template is not in the inventory of the VM's vCenter for
+	// cross-vCenter clones, so DRS cannot be asked for a placement.
+	var templateRef *types.ManagedObjectReference
+	if ctx.VSphereVM.Spec.TemplateSource == nil {
+		templateRef = types.NewReference(tpl.Reference())
+	}
+	if err := restrictHostsToNetworks(ctx, &spec, templateRef, *datastoreRef, networks); err != nil {
+		return err
+	}
+
+	// Record what is asked from vCenter, so that it can be reviewed on the
+	// VSphereVM.
+	if err := setCloneSpecSummary(ctx.VSphereVM, &spec); err != nil {
+		return err
+	}
+	if err := setPlacement(ctx, &spec, datastoreRef, networks); err != nil {
+		return err
+	}
+
+	ctx.Logger.Info("cloning machine", "namespace", ctx.VSphereVM.Namespace, "name", ctx.VSphereVM.Name, "vmName", ctx.VSphereVM.VMName(), "cloneType", ctx.VSphereVM.Status.CloneMode)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationClone, tplCtx.Session.URL().Host)
+	task, err := tpl.Clone(ctx, folder, ctx.VSphereVM.VMName(), spec)
+	done(err)
 	if err != nil {
 		return errors.Wrapf(err, "error trigging clone op for machine %s", ctx)
 	}
 
 	ctx.VSphereVM.Status.TaskRef = task.Reference().Value
+	// The status of the task tells that it is the clone task, which runs on
+	// the vCenter of the template for the cross-vCenter clones.
+	ctx.VSphereVM.Status.Task = &infrav1.TaskStatus{
+		Ref:       task.Reference().Value,
+		Operation: CloneTaskOperation,
+		State:     infrav1.TaskStateQueued,
+	}
+	ctx.VSphereVM.Status.CloneAttempts++
 
 	// patch the vsphereVM early to ensure that the task is
 	// reflected in the status right away, this avoid situations
@@ -290,6 +460,36 @@ func Clone(ctx *context.VMContext, bootstrapData []byte, format bootstrapv1.Form
 	return nil
 }
 
+// MinNumCPUs and DefaultMemoryMiB are the minimum number of CPUs and the
+// default memory of the VMs.
+const (
+	MinNumCPUs       = 2
+	DefaultMemoryMiB = 2048
+)
+
+// CloneTaskOperation is the operation of the tasks cloning the VMs.
+const CloneTaskOperation = "VirtualMachine.clone"
+
+// Size returns the number of CPUs, the number of cores per socket and the
+// memory a VM is cloned with for a clone spec. The number of CPUs is at
+// least MinNumCPUs, the cores per socket default to the number of CPUs, i.e.
+// a single socket, and the memory to DefaultMemoryMiB.
+func Size(spec *infrav1.VirtualMachineCloneSpec) (numCPUs, numCoresPerSocket int32, memoryMiB int64) {
+	numCPUs = spec.NumCPUs
+	if numCPUs < MinNumCPUs {
+		numCPUs = MinNumCPUs
+	}
+	numCoresPerSocket = spec.NumCoresPerSocket
+	if numCoresPerSocket == 0 {
+		numCoresPerSocket = numCPUs
+	}
+	memoryMiB = spec.MemoryMiB
+	if memoryMiB == 0 {
+		memoryMiB = DefaultMemoryMiB
+	}
+	return numCPUs, numCoresPerSocket, memoryMiB
+}
+
 func newVMFlagInfo() *types.VirtualMachineFlagInfo {
 	diskUUIDEnabled := true
 	return &types.VirtualMachineFlagInfo{
@@ -338,9 +538,12 @@ func getDiskSpec(ctx *context.VMContext, devices object.VirtualDeviceList) ([]ty
 		for i, disk := range disks[1:] {
 			var diskCloneCapacityKB int64
 			// Check if additional Disks have been provided
-			if len(ctx.VSphereVM.Spec.AdditionalDisksGiB) > i {
+			switch additionalDisks := ctx.VSphereVM.Spec.AdditionalDisks; {
+			case len(ctx.VSphereVM.Spec.AdditionalDisksGiB) > i:
 				diskCloneCapacityKB = int64(ctx.VSphereVM.Spec.AdditionalDisksGiB[i]) * 1024 * 1024
-			} else {
+			case len(additionalDisks) > i && additionalDisks[i].SizeGiB > 0:
+				diskCloneCapacityKB = int64(additionalDisks[i].SizeGiB) * 1024 * 1024
+			default:
 				diskCloneCapacityKB = disk.(*types.VirtualDisk).CapacityInKB
 			}
 			additionalDiskConfigSpec, err := getDiskConfigSpec(disk.(*types.VirtualDisk), diskCloneCapacityKB)
@@ -367,10 +570,16 @@ func getDiskConfigSpec(disk *types.VirtualDisk, diskCloneCapacityKB int64) (type
 	}, nil
 }
 
+// ethCardType is the type of the NICs of the VMs, which replace the NICs of
+// the template. The paravirtual vmxnet3 NICs are available to both the amd64
+// and the arm64 VMs, unlike the emulated ones, e.g. e1000.
 const ethCardType = "vmxnet3"
 
-func getNetworkSpecs(ctx *context.VMContext, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, error) {
+// getNetworkSpecs returns the specs replacing the NICs of the template with
+// the network devices of the VSphereVM, and the networks of the devices.
+func getNetworkSpecs(ctx *context.VMContext, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, []types.ManagedObjectReference, error) {
 	deviceSpecs := []types.BaseVirtualDeviceConfigSpec{}
+	networks := make([]types.ManagedObjectReference, 0, len(ctx.VSphereVM.Spec.Network.Devices))
 
 	// Remove any existing NICs
 	for _, dev := range devices.SelectByType((*types.VirtualEthernetCard)(nil)) {
@@ -384,17 +593,17 @@ func getNetworkSpecs(ctx *context.VMContext, devices object.VirtualDeviceList) (
 	key := int32(-100)
 	for i := range ctx.VSphereVM.Spec.Network.Devices {
 		netSpec := &ctx.VSphereVM.Spec.Network.Devices[i]
-		ref, err := ctx.Session.Finder.Network(ctx, netSpec.NetworkName)
+		ref, err := findNetwork(ctx, netSpec.NetworkName)
 		if err != nil {
-			return nil, errors.Wrapf(err, "unable to find network %q", netSpec.NetworkName)
+			return nil, nil, errors.Wrapf(err, "unable to find network %q", netSpec.NetworkName)
 		}
 		backing, err := ref.EthernetCardBackingInfo(ctx)
 		if err != nil {
-			return nil, errors.Wrapf(err, "unable to create new ethernet card backing info for network %q on %q", netSpec.NetworkName, ctx)
+			return nil, nil, errors.Wrapf(err, "unable to create new ethernet card backing info for network %q on %q", netSpec.NetworkName, ctx)
 		}
 		dev, err := object.EthernetCardTypes().CreateEthernetCard(ethCardType, backing)
 		if err != nil {
-			return nil, errors.Wrapf(err, "unable to create new ethernet card %q for network %q on %q", ethCardType, netSpec.NetworkName, ctx)
+			return nil, nil, errors.Wrapf(err, "unable to create new ethernet card %q for network %q on %q", ethCardType, netSpec.NetworkName, ctx)
 		}
 
 		// Get the actual NIC object. This is safe to assert without a check
@@ -418,11 +627,12 @@ func getNetworkSpecs(ctx *context.VMContext, devices object.VirtualDeviceList) (
 			Device:    dev,
 			Operation: types.VirtualDeviceConfigSpecOperationAdd,
 		})
+		networks = append(networks, ref.Reference())
 		ctx.Logger.V(4).Info("created network device", "eth-card-type", ethCardType, "network-spec", netSpec)
 		key--
 	}
 
-	return deviceSpecs, nil
+	return deviceSpecs, networks, nil
 }
 
 func createPCIPassThroughDevice(deviceKey int32, backingInfo types.BaseVirtualDeviceBackingInfo) types.BaseVirtualDevice {
@@ -462,3 +672,42 @@ func getGpuSpecs(ctx *context.VMContext) ([]types.BaseVirtualDeviceConfigSpec, e
 	}
 	return deviceSpecs, nil
 }
+
+// getTPMSpecs returns the device change adding a virtual TPM device to the
+// clone of a template with the given devices, unless it already has one.
+func getTPMSpecs(devices object.VirtualDeviceList) []types.BaseVirtualDeviceConfigSpec {
+	if len(devices.SelectByType((*types.VirtualTPM)(nil))) > 0 {
+		return nil
+	}
+	return []types.BaseVirtualDeviceConfigSpec{
+		&types.VirtualDeviceConfigSpec{
+			Device: &types.VirtualTPM{
+				VirtualDevice: types.VirtualDevice{
+					// Assign a temporary device key to ensure that a unique
+					// one will be generated when the device is created.
+					Key: -300,
+				},
+			},
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		},
+	}
+}
+
+// getCDROMSpecs returns the device changes inserting the ISO images of the
+// CDROMs of the VSphereVM, and records their datastore paths in its status.
+func getCDROMSpecs(ctx *context.VMContext, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	isoFiles := make([]string, 0, len(ctx.VSphereVM.Spec.CDROMs))
+	for _, spec := range ctx.VSphereVM.Spec.CDROMs {
+		isoFile, err := cdrom.ResolveISO(ctx, ctx.Session, spec)
+		if err != nil {
+			return nil, err
+		}
+		isoFiles = append(isoFiles, isoFile)
+	}
+	specs, err := cdrom.InsertSpecs(devices, isoFiles)
+	if err != nil {
+		return nil, err
+	}
+	ctx.VSphereVM.Status.ISOImages = isoFiles
+	return specs, nil
+}
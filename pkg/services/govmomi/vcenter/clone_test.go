@@ -60,6 +60,7 @@ func TestGetDiskSpec(t *testing.T) {
 		expectDevice             bool
 		cloneDiskSize            int32
 		additionalCloneDiskSizes []int32
+		additionalCloneDisks     []v1beta1.AdditionalDiskSpec
 		name                     string
 		disks                    object.VirtualDeviceList
 		err                      string
@@ -102,6 +103,13 @@ func TestGetDiskSpec(t *testing.T) {
 			additionalCloneDiskSizes: []int32{defaultSizeGiB},
 			err:                      "Error getting disk config spec for additional disk: can't resize template disk down, initial capacity is larger: 7340032KiB > 5242880KiB",
 		},
+		{
+			name:                 "Successfully clone template with additional disks",
+			disks:                append(defaultDisks, defaultDisks...),
+			cloneDiskSize:        defaultSizeGiB + 3,
+			additionalCloneDisks: []v1beta1.AdditionalDiskSpec{{SizeGiB: defaultSizeGiB + 3, RetainOnDelete: true}},
+			expectDevice:         true,
+		},
 	}
 
 	for _, test := range testCases {
@@ -110,6 +118,7 @@ func TestGetDiskSpec(t *testing.T) {
 			cloneSpec := v1beta1.VirtualMachineCloneSpec{
 				DiskGiB:            tc.cloneDiskSize,
 				AdditionalDisksGiB: tc.additionalCloneDiskSizes,
+				AdditionalDisks:    tc.additionalCloneDisks,
 			}
 			vsphereVM := &v1beta1.VSphereVM{
 				Spec: v1beta1.VSphereVMSpec{
@@ -136,6 +145,9 @@ func TestGetDiskSpec(t *testing.T) {
 					secondaryDevice := devices[1]
 					validateDiskSpec(t, secondaryDevice, tc.additionalCloneDiskSizes[0])
 				}
+				if len(tc.additionalCloneDisks) != 0 {
+					validateDiskSpec(t, devices[1], tc.additionalCloneDisks[0].SizeGiB)
+				}
 			}
 		})
 	}
@@ -262,3 +274,43 @@ func initSimulator(t *testing.T) (*simulator.Model, *session.Session, *simulator
 
 	return model, authSession, server
 }
+
+func TestTPMSpecs(t *testing.T) {
+	testCases := []struct {
+		name    string
+		devices object.VirtualDeviceList
+		added   bool
+	}{
+		{
+			name:  "template without TPM",
+			added: true,
+		},
+		{
+			name:    "template with TPM",
+			devices: object.VirtualDeviceList{&types.VirtualTPM{VirtualDevice: types.VirtualDevice{Key: 11000}}},
+		},
+	}
+
+	for _, test := range testCases {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			deviceSpecs := getTPMSpecs(tc.devices)
+			if !tc.added {
+				if len(deviceSpecs) != 0 {
+					t.Fatalf("Expected no deviceSpecs, but got: %d", len(deviceSpecs))
+				}
+				return
+			}
+			if len(deviceSpecs) != 1 {
+				t.Fatalf("Expected one deviceSpec, but got: %d", len(deviceSpecs))
+			}
+			deviceSpec := deviceSpecs[0].GetVirtualDeviceConfigSpec()
+			if deviceSpec.Operation != types.VirtualDeviceConfigSpecOperationAdd {
+				t.Fatalf("incorrect operation: %s", deviceSpec.Operation)
+			}
+			if _, ok := deviceSpec.Device.(*types.VirtualTPM); !ok {
+				t.Fatalf("incorrect device: %T", deviceSpec.Device)
+			}
+		})
+	}
+}
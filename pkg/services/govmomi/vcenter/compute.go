@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// computeClusterUsage is the usage of the hosts of a compute cluster which
+// are not in maintenance mode.
+type computeClusterUsage struct {
+	name         string
+	resourcePool types.ManagedObjectReference
+	hosts        int
+	vms          int
+	freeMemory   int64
+}
+
+// selectComputeCluster selects the compute cluster a VM is cloned to among
+// the candidates of its compute selector, and returns its usage.
+func selectComputeCluster(ctx *context.VMContext, selector *infrav1.ComputeSelector) (*computeClusterUsage, error) {
+	dc, err := findDatacenter(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get datacenter for %q", ctx)
+	}
+	v, err := view.NewManager(ctx.Session.Client.Client).CreateContainerView(ctx, dc.Reference(), []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create a view of the compute clusters for %q", ctx)
+	}
+	defer func() {
+		_ = v.Destroy(ctx)
+	}()
+	var clusters []mo.ClusterComputeResource
+	if err := v.Retrieve(ctx, []string{"ClusterComputeResource"}, []string{"name", "resourcePool", "host"}, &clusters); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the compute clusters for %q", ctx)
+	}
+
+	nameRegex, err := regexp.Compile(selector.NameRegex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid name regex of the compute selector of %q", ctx)
+	}
+	var tagged map[types.ManagedObjectReference]bool
+	if len(selector.TagIDs) > 0 {
+		if tagged, err = taggedObjects(ctx, selector.TagIDs, "ClusterComputeResource"); err != nil {
+			return nil, err
+		}
+	}
+	var candidates []mo.ClusterComputeResource
+	for _, cluster := range clusters {
+		if cluster.ResourcePool == nil || !nameRegex.MatchString(cluster.Name) {
+			continue
+		}
+		if tagged != nil && !tagged[cluster.Reference()] {
+			continue
+		}
+		candidates = append(candidates, cluster)
+	}
+	if len(candidates) == 0 {
+		return nil, errors.Errorf("no compute cluster of datacenter %s matches the compute selector of %q", dc.Name(), ctx)
+	}
+
+	usages, err := computeClusterUsages(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	usage := pickComputeCluster(usages, selector.Policy)
+	if usage == nil {
+		return nil, errors.Errorf("none of the compute clusters matching the compute selector of %q has a host out of maintenance mode", ctx)
+	}
+	return usage, nil
+}
+
+// computeClusterUsages returns the usage of the hosts of compute clusters.
+func computeClusterUsages(ctx *context.VMContext, clusters []mo.ClusterComputeResource) ([]computeClusterUsage, error) {
+	var hostRefs []types.ManagedObjectReference
+	for _, cluster := range clusters {
+		hostRefs = append(hostRefs, cluster.Host...)
+	}
+	var hosts []mo.HostSystem
+	if len(hostRefs) > 0 {
+		props := []string{"vm", "runtime.inMaintenanceMode", "summary.hardware.memorySize", "summary.quickStats.overallMemoryUsage"}
+		if err := property.DefaultCollector(ctx.Session.Client.Client).Retrieve(ctx, hostRefs, props, &hosts); err != nil {
+			return nil, errors.Wrapf(err, "unable to get the hosts of the compute clusters for %q", ctx)
+		}
+	}
+	hostsByRef := map[types.ManagedObjectReference]*mo.HostSystem{}
+	for i := range hosts {
+		hostsByRef[hosts[i].Reference()] = &hosts[i]
+	}
+
+	usages := make([]computeClusterUsage, 0, len(clusters))
+	for _, cluster := range clusters {
+		usage := computeClusterUsage{name: cluster.Name, resourcePool: *cluster.ResourcePool}
+		for _, ref := range cluster.Host {
+			host, ok := hostsByRef[ref]
+			if !ok || host.Runtime.InMaintenanceMode || host.Summary.Hardware == nil {
+				continue
+			}
+			usage.hosts++
+			usage.vms += len(host.Vm)
+			usage.freeMemory += host.Summary.Hardware.MemorySize - int64(host.Summary.QuickStats.OverallMemoryUsage)<<20
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// pickComputeCluster returns the compute cluster with the fewest VMs or the
+// most free memory, depending on the policy, among the ones with hosts out of
+// maintenance mode. Compute clusters with the same score are picked by name,
+// so that the selection is stable.
+func pickComputeCluster(usages []computeClusterUsage, policy infrav1.ComputeSelectionPolicy) *computeClusterUsage {
+	var fits []*computeClusterUsage
+	for i := range usages {
+		if usages[i].hosts > 0 {
+			fits = append(fits, &usages[i])
+		}
+	}
+	if len(fits) == 0 {
+		return nil
+	}
+
+	sort.Slice(fits, func(i, j int) bool {
+		a, b := fits[i], fits[j]
+		switch {
+		case policy == infrav1.ComputeSelectionLeastVMs && a.vms != b.vms:
+			return a.vms < b.vms
+		case policy != infrav1.ComputeSelectionLeastVMs && a.freeMemory != b.freeMemory:
+			return a.freeMemory > b.freeMemory
+		}
+		return a.name < b.name
+	})
+	return fits[0]
+}
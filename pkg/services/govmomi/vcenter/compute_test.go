@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestPickComputeCluster(t *testing.T) {
+	usages := func() []computeClusterUsage {
+		return []computeClusterUsage{
+			{name: "busy", hosts: 2, vms: 40, freeMemory: 512 << 30},
+			{name: "idle", hosts: 2, vms: 5, freeMemory: 64 << 30},
+			{name: "big", hosts: 4, vms: 20, freeMemory: 512 << 30},
+			{name: "maintenance", vms: 0, freeMemory: 0},
+		}
+	}
+	tests := []struct {
+		name     string
+		usages   []computeClusterUsage
+		policy   infrav1.ComputeSelectionPolicy
+		expected string
+	}{
+		{
+			name:     "picks the compute cluster with the most free memory by default",
+			usages:   usages(),
+			expected: "big",
+		},
+		{
+			name:     "picks the compute cluster with the least VMs",
+			usages:   usages(),
+			policy:   infrav1.ComputeSelectionLeastVMs,
+			expected: "idle",
+		},
+		{
+			name:   "fails without host out of maintenance mode",
+			usages: []computeClusterUsage{{name: "maintenance"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			usage := pickComputeCluster(tt.usages, tt.policy)
+			if tt.expected == "" {
+				g.Expect(usage).To(BeNil())
+				return
+			}
+			g.Expect(usage).NotTo(BeNil())
+			g.Expect(usage.name).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestSelectComputeCluster(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	vmContext.Session = session
+
+	t.Run("selects a compute cluster by name", func(t *testing.T) {
+		g := NewWithT(t)
+		usage, err := selectComputeCluster(vmContext, &infrav1.ComputeSelector{NameRegex: "^DC0_C"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(usage.name).To(Equal("DC0_C0"))
+		g.Expect(usage.hosts).To(BeNumerically(">", 0))
+		g.Expect(usage.resourcePool.Type).To(Equal("ResourcePool"))
+	})
+
+	t.Run("fails when no compute cluster matches", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := selectComputeCluster(vmContext, &infrav1.ComputeSelector{NameRegex: "^missing$"})
+		g.Expect(err).To(MatchError(ContainSubstring("matches the compute selector")))
+	})
+}
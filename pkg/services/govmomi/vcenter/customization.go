@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// getCustomizationSpec returns the guest OS customization to apply to the VM
+// while it is cloned, or nil if the VSphereVM does not request any.
+func getCustomizationSpec(ctx *context.VMContext) (*types.CustomizationSpec, error) {
+	spec := ctx.VSphereVM.Spec.CustomizationSpec
+	if spec == nil {
+		return nil, nil
+	}
+
+	if spec.Name != "" {
+		ctx.Logger.Info("using guest customization spec from vCenter", "name", spec.Name)
+		item, err := object.NewCustomizationSpecManager(ctx.Session.Client.Client).GetCustomizationSpec(ctx, spec.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get guest customization spec %q", spec.Name)
+		}
+		return &item.Spec, nil
+	}
+
+	customization := &types.CustomizationSpec{
+		GlobalIPSettings: getCustomizationGlobalIPSettings(ctx.VSphereVM.Spec.Network),
+	}
+	for _, device := range ctx.VSphereVM.Spec.Network.Devices {
+		adapter, err := getCustomizationIPSettings(device)
+		if err != nil {
+			return nil, err
+		}
+		customization.NicSettingMap = append(customization.NicSettingMap, types.CustomizationAdapterMapping{Adapter: adapter})
+	}
+
+	shortHostName, err := ctx.VSphereVM.ShortHostName()
+	if err != nil {
+		return nil, err
+	}
+	hostName := &types.CustomizationFixedName{Name: shortHostName}
+	switch {
+	case spec.Linux != nil:
+		domain := spec.Linux.Domain
+		if domain == "" {
+			domain = ctx.VSphereVM.Spec.HostNameDomain
+		}
+		customization.Identity = &types.CustomizationLinuxPrep{
+			HostName:   hostName,
+			Domain:     domain,
+			TimeZone:   spec.Linux.TimeZone,
+			HwClockUTC: spec.Linux.HWClockUTC,
+		}
+	case spec.Windows != nil:
+		sysprep, err := getCustomizationSysprep(ctx, spec.Windows, hostName)
+		if err != nil {
+			return nil, err
+		}
+		customization.Identity = sysprep
+	default:
+		return nil, errors.Errorf("guest customization spec for %q has neither a name nor an inline customization", ctx)
+	}
+	return customization, nil
+}
+
+func getCustomizationSysprep(ctx *context.VMContext, windows *infrav1.WindowsCustomization, computerName types.BaseCustomizationName) (*types.CustomizationSysprep, error) {
+	sysprep := &types.CustomizationSysprep{
+		GuiUnattended: types.CustomizationGuiUnattended{
+			TimeZone: windows.TimeZone,
+		},
+		UserData: types.CustomizationUserData{
+			FullName:     windows.FullName,
+			OrgName:      windows.OrgName,
+			ComputerName: computerName,
+			ProductId:    windows.ProductKey,
+		},
+		Identification: types.CustomizationIdentification{
+			JoinWorkgroup: windows.Workgroup,
+			JoinDomain:    windows.Domain,
+			DomainAdmin:   windows.DomainAdmin,
+		},
+	}
+
+	if ref := windows.DomainAdminPasswordSecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: ctx.VSphereVM.Namespace, Name: ref.Name}
+		if err := ctx.Client.Get(ctx, key, secret); err != nil {
+			return nil, errors.Wrapf(err, "unable to get domain admin password secret %s", key)
+		}
+		password, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, errors.Errorf("secret %s has no key %q", key, ref.Key)
+		}
+		sysprep.Identification.DomainAdminPassword = &types.CustomizationPassword{
+			Value:     string(password),
+			PlainText: true,
+		}
+	}
+	return sysprep, nil
+}
+
+func getCustomizationGlobalIPSettings(network infrav1.NetworkSpec) types.CustomizationGlobalIPSettings {
+	var settings types.CustomizationGlobalIPSettings
+	for _, device := range network.Devices {
+		settings.DnsServerList = append(settings.DnsServerList, device.Nameservers...)
+		settings.DnsSuffixList = append(settings.DnsSuffixList, device.SearchDomains...)
+	}
+	return settings
+}
+
+// getCustomizationIPSettings returns the customization of a network device.
+// The first static IPv4 address is used if there is one, otherwise the device
+// is configured through DHCP.
+func getCustomizationIPSettings(device infrav1.NetworkDeviceSpec) (types.CustomizationIPSettings, error) {
+	settings := types.CustomizationIPSettings{
+		Ip:            &types.CustomizationDhcpIpGenerator{},
+		DnsServerList: device.Nameservers,
+	}
+
+	var ipv6 []types.BaseCustomizationIpV6Generator
+	for _, addr := range device.IPAddrs {
+		ip, ipNet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return settings, errors.Wrapf(err, "invalid ip address %q", addr)
+		}
+		if ip.To4() != nil {
+			if _, ok := settings.Ip.(*types.CustomizationFixedIp); !ok && !device.DHCP4 {
+				settings.Ip = &types.CustomizationFixedIp{IpAddress: ip.String()}
+				settings.SubnetMask = net.IP(ipNet.Mask).String()
+			}
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		ipv6 = append(ipv6, &types.CustomizationFixedIpV6{IpAddress: ip.String(), SubnetMask: int32(ones)})
+	}
+	if device.Gateway4 != "" {
+		settings.Gateway = []string{device.Gateway4}
+	}
+
+	switch {
+	case len(ipv6) > 0 && !device.DHCP6:
+		settings.IpV6Spec = &types.CustomizationIPSettingsIpV6AddressSpec{Ip: ipv6}
+		if device.Gateway6 != "" {
+			settings.IpV6Spec.Gateway = []string{device.Gateway6}
+		}
+	case device.DHCP6:
+		settings.IpV6Spec = &types.CustomizationIPSettingsIpV6AddressSpec{
+			Ip: []types.BaseCustomizationIpV6Generator{&types.CustomizationDhcpIpV6Generator{}},
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+func TestGetCustomizationIPSettings(t *testing.T) {
+	t.Run("static addresses", func(t *testing.T) {
+		g := NewWithT(t)
+		settings, err := getCustomizationIPSettings(infrav1.NetworkDeviceSpec{
+			IPAddrs:     []string{"192.168.1.10/24", "fd00::10/64"},
+			Gateway4:    "192.168.1.1",
+			Gateway6:    "fd00::1",
+			Nameservers: []string{"8.8.8.8"},
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(settings.Ip).To(Equal(&types.CustomizationFixedIp{IpAddress: "192.168.1.10"}))
+		g.Expect(settings.SubnetMask).To(Equal("255.255.255.0"))
+		g.Expect(settings.Gateway).To(ConsistOf("192.168.1.1"))
+		g.Expect(settings.DnsServerList).To(ConsistOf("8.8.8.8"))
+		g.Expect(settings.IpV6Spec).NotTo(BeNil())
+		g.Expect(settings.IpV6Spec.Ip).To(ConsistOf(&types.CustomizationFixedIpV6{IpAddress: "fd00::10", SubnetMask: 64}))
+		g.Expect(settings.IpV6Spec.Gateway).To(ConsistOf("fd00::1"))
+	})
+
+	t.Run("dhcp", func(t *testing.T) {
+		g := NewWithT(t)
+		settings, err := getCustomizationIPSettings(infrav1.NetworkDeviceSpec{DHCP4: true, DHCP6: true})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(settings.Ip).To(Equal(&types.CustomizationDhcpIpGenerator{}))
+		g.Expect(settings.IpV6Spec.Ip).To(ConsistOf(&types.CustomizationDhcpIpV6Generator{}))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := getCustomizationIPSettings(infrav1.NetworkDeviceSpec{IPAddrs: []string{"192.168.1.10"}})
+		g.Expect(err).To(HaveOccurred())
+	})
+}
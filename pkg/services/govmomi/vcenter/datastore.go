@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// selectDatastore selects the datastore a VM is cloned to among the
+// candidates of its datastore selector. When restricted is set, only the
+// candidates referenced by compatible are considered, e.g. the datastores
+// compatible with the storage policy of the VM, so that an empty compatible
+// list selects no datastore. required is the space in bytes the clone takes on
+// the datastore.
+func selectDatastore(ctx *context.VMContext, selector *infrav1.DatastoreSelector, compatible []types.ManagedObjectReference, restricted bool, required int64) (*mo.Datastore, error) {
+	dc, err := findDatacenter(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get datacenter for %q", ctx)
+	}
+	v, err := view.NewManager(ctx.Session.Client.Client).CreateContainerView(ctx, dc.Reference(), []string{"Datastore"}, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create a view of the datastores for %q", ctx)
+	}
+	defer func() {
+		_ = v.Destroy(ctx)
+	}()
+	var datastores []mo.Datastore
+	if err := v.Retrieve(ctx, []string{"Datastore"}, []string{"summary"}, &datastores); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the datastores for %q", ctx)
+	}
+
+	candidates, err := datastoreCandidates(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	if restricted {
+		compatibleSet := map[types.ManagedObjectReference]bool{}
+		for _, ref := range compatible {
+			compatibleSet[ref] = true
+		}
+		for ref := range candidates {
+			if !compatibleSet[ref] {
+				delete(candidates, ref)
+			}
+		}
+	}
+	var filtered []mo.Datastore
+	for _, ds := range datastores {
+		if candidates[ds.Reference()] {
+			filtered = append(filtered, ds)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, errors.Errorf("no datastore of datacenter %s matches the datastore selector of %q", dc.Name(), ctx)
+	}
+
+	antiAffinity := selector.ControlPlaneAntiAffinity
+	if antiAffinity == "" || antiAffinity == infrav1.DatastoreAntiAffinityNone || !isControlPlane(ctx.VSphereVM) {
+		datastore, err := pickDatastore(filtered, required, selector.MaxProvisionedPercent)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to select a datastore for %q", ctx)
+		}
+		return datastore, nil
+	}
+
+	used, err := controlPlaneDatastores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	datastore, err := pickDatastore(unusedDatastores(filtered, used), required, selector.MaxProvisionedPercent)
+	if err != nil && antiAffinity == infrav1.DatastoreAntiAffinityPreferred {
+		ctx.Logger.Info("no datastore without other control plane VMs fits, ignoring the preferred anti-affinity", "reason", err.Error())
+		datastore, err = pickDatastore(filtered, required, selector.MaxProvisionedPercent)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to select a datastore for %q with %s control plane anti-affinity", ctx, antiAffinity)
+	}
+	return datastore, nil
+}
+
+// isControlPlane returns true if a VSphereVM is the VM of a control plane
+// machine.
+func isControlPlane(vm *infrav1.VSphereVM) bool {
+	_, ok := vm.Labels[clusterv1.MachineControlPlaneLabelName]
+	return ok
+}
+
+// controlPlaneDatastores returns the names of the datastores of the VMs of
+// the other control plane machines of the cluster of a VSphereVM, which are
+// not being deleted.
+func controlPlaneDatastores(ctx *context.VMContext) (map[string]bool, error) {
+	vms := &infrav1.VSphereVMList{}
+	if err := ctx.Client.List(ctx, vms,
+		client.InNamespace(ctx.VSphereVM.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: ctx.VSphereVM.Labels[clusterv1.ClusterLabelName]},
+		client.HasLabels{clusterv1.MachineControlPlaneLabelName}); err != nil {
+		return nil, errors.Wrapf(err, "unable to list the control plane VSphereVMs of the cluster of %q", ctx)
+	}
+	used := map[string]bool{}
+	for i := range vms.Items {
+		vm := &vms.Items[i]
+		if vm.Name == ctx.VSphereVM.Name || !vm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// The datastore selected for the VM, or else the one of its spec.
+		if vm.Status.Datastore != "" {
+			used[vm.Status.Datastore] = true
+		} else if vm.Spec.Datastore != "" {
+			used[path.Base(vm.Spec.Datastore)] = true
+		}
+	}
+	return used, nil
+}
+
+// unusedDatastores returns the datastores whose name is not used.
+func unusedDatastores(datastores []mo.Datastore, used map[string]bool) []mo.Datastore {
+	var unused []mo.Datastore
+	for _, ds := range datastores {
+		if !used[ds.Summary.Name] {
+			unused = append(unused, ds)
+		}
+	}
+	return unused
+}
+
+// datastoreCandidates returns the references of the datastores listed by a
+// datastore selector, or attached to all its tags.
+func datastoreCandidates(ctx *context.VMContext, selector *infrav1.DatastoreSelector) (map[types.ManagedObjectReference]bool, error) {
+	candidates := map[types.ManagedObjectReference]bool{}
+	if len(selector.Datastores) > 0 {
+		for _, name := range selector.Datastores {
+			datastore, err := findDatastore(ctx, name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to get datastore %s for %q", name, ctx)
+			}
+			candidates[datastore.Reference()] = true
+		}
+		return candidates, nil
+	}
+	return taggedObjects(ctx, selector.TagIDs, "Datastore")
+}
+
+// taggedObjects returns the references of the objects of a type which are
+// attached to all the tags.
+func taggedObjects(ctx *context.VMContext, tagIDs []string, kind string) (map[types.ManagedObjectReference]bool, error) {
+	var candidates map[types.ManagedObjectReference]bool
+	for i, tagID := range tagIDs {
+		objects, err := ctx.Session.TagManager.ListAttachedObjects(ctx, tagID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list the objects attached to tag %s for %q", tagID, ctx)
+		}
+		tagged := map[types.ManagedObjectReference]bool{}
+		for _, obj := range objects {
+			if ref := obj.Reference(); ref.Type == kind && (i == 0 || candidates[ref]) {
+				tagged[ref] = true
+			}
+		}
+		candidates = tagged
+	}
+	return candidates, nil
+}
+
+// pickDatastore returns the accessible datastore with the most free space
+// among the ones which have the required space in bytes, and whose space
+// provisioned with the clone stays within maxProvisionedPercent of their
+// capacity. Datastores with the same free space are picked by name, so that
+// the selection is stable.
+func pickDatastore(datastores []mo.Datastore, required int64, maxProvisionedPercent *int32) (*mo.Datastore, error) {
+	var fits []*mo.Datastore
+	for i := range datastores {
+		summary := datastores[i].Summary
+		if !summary.Accessible || summary.MaintenanceMode == string(types.DatastoreSummaryMaintenanceModeStateInMaintenance) {
+			continue
+		}
+		if summary.FreeSpace < required {
+			continue
+		}
+		if maxProvisionedPercent != nil && summary.Capacity > 0 {
+			provisioned := summary.Capacity - summary.FreeSpace + summary.Uncommitted + required
+			if provisioned*100 > int64(*maxProvisionedPercent)*summary.Capacity {
+				continue
+			}
+		}
+		fits = append(fits, &datastores[i])
+	}
+	if len(fits) == 0 {
+		return nil, errors.Errorf("none of the %d candidate datastores is accessible with %d GiB free within the provisioning limit", len(datastores), required>>30)
+	}
+
+	sort.Slice(fits, func(i, j int) bool {
+		if fits[i].Summary.FreeSpace != fits[j].Summary.FreeSpace {
+			return fits[i].Summary.FreeSpace > fits[j].Summary.FreeSpace
+		}
+		return fits[i].Summary.Name < fits[j].Summary.Name
+	})
+	return fits[0], nil
+}
+
+// cloneRequiredSpace returns the space in bytes a clone takes on its
+// datastore. Linked clones only write a delta disk, whereas full clones take
+// the storage of the template, or the disk size of the spec if it is larger.
+func cloneRequiredSpace(spec *infrav1.VirtualMachineCloneSpec, linked bool, storage *types.VirtualMachineStorageSummary) int64 {
+	if linked {
+		return 0
+	}
+	var required int64
+	if storage != nil {
+		required = storage.Committed + storage.Uncommitted
+	}
+	if disk := int64(spec.DiskGiB) << 30; disk > required {
+		required = disk
+	}
+	return required
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestPickDatastore(t *testing.T) {
+	datastore := func(name string, capacityGiB, freeGiB, uncommittedGiB int64) mo.Datastore {
+		return mo.Datastore{Summary: types.DatastoreSummary{
+			Name:        name,
+			Capacity:    capacityGiB << 30,
+			FreeSpace:   freeGiB << 30,
+			Uncommitted: uncommittedGiB << 30,
+			Accessible:  true,
+		}}
+	}
+	inMaintenance := datastore("maintenance", 1000, 900, 0)
+	inMaintenance.Summary.MaintenanceMode = string(types.DatastoreSummaryMaintenanceModeStateInMaintenance)
+	inaccessible := datastore("inaccessible", 1000, 900, 0)
+	inaccessible.Summary.Accessible = false
+
+	tests := []struct {
+		name                  string
+		datastores            []mo.Datastore
+		requiredGiB           int64
+		maxProvisionedPercent *int32
+		expected              string
+	}{
+		{
+			name:       "picks the datastore with the most free space",
+			datastores: []mo.Datastore{datastore("ds1", 1000, 100, 0), datastore("ds2", 1000, 300, 0), datastore("ds3", 1000, 200, 0)},
+			expected:   "ds2",
+		},
+		{
+			name:       "picks by name among datastores with the same free space",
+			datastores: []mo.Datastore{datastore("ds2", 1000, 100, 0), datastore("ds1", 1000, 100, 0)},
+			expected:   "ds1",
+		},
+		{
+			name:        "skips the datastores without the required space",
+			datastores:  []mo.Datastore{datastore("ds1", 1000, 10, 0), datastore("ds2", 100, 50, 0)},
+			requiredGiB: 20,
+			expected:    "ds2",
+		},
+		{
+			name:       "skips the datastores which are inaccessible or in maintenance",
+			datastores: []mo.Datastore{inMaintenance, inaccessible, datastore("ds1", 1000, 100, 0)},
+			expected:   "ds1",
+		},
+		{
+			name:                  "skips the datastores provisioned over the limit",
+			datastores:            []mo.Datastore{datastore("ds1", 1000, 500, 800), datastore("ds2", 1000, 300, 0)},
+			requiredGiB:           100,
+			maxProvisionedPercent: pointer.Int32(120),
+			expected:              "ds2",
+		},
+		{
+			name:                  "fails when no datastore fits",
+			datastores:            []mo.Datastore{datastore("ds1", 1000, 500, 800), inMaintenance},
+			requiredGiB:           100,
+			maxProvisionedPercent: pointer.Int32(120),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ds, err := pickDatastore(tt.datastores, tt.requiredGiB<<30, tt.maxProvisionedPercent)
+			if tt.expected == "" {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ds.Summary.Name).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestCloneRequiredSpace(t *testing.T) {
+	g := NewWithT(t)
+	storage := &types.VirtualMachineStorageSummary{Committed: 2 << 30, Uncommitted: 18 << 30}
+	g.Expect(cloneRequiredSpace(&infrav1.VirtualMachineCloneSpec{}, false, storage)).To(Equal(int64(20 << 30)))
+	g.Expect(cloneRequiredSpace(&infrav1.VirtualMachineCloneSpec{DiskGiB: 40}, false, storage)).To(Equal(int64(40 << 30)))
+	g.Expect(cloneRequiredSpace(&infrav1.VirtualMachineCloneSpec{DiskGiB: 40}, true, storage)).To(BeZero())
+}
+
+func TestSelectDatastore(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	vmContext.Session = session
+
+	t.Run("selects a listed datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		ds, err := selectDatastore(vmContext, &infrav1.DatastoreSelector{Datastores: []string{"LocalDS_0"}}, nil, false, 0)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ds.Summary.Name).To(Equal("LocalDS_0"))
+	})
+
+	t.Run("fails for a missing datastore", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := selectDatastore(vmContext, &infrav1.DatastoreSelector{Datastores: []string{"missing"}}, nil, false, 0)
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("fails when no candidate is compatible", func(t *testing.T) {
+		g := NewWithT(t)
+		compatible := []types.ManagedObjectReference{{Type: "Datastore", Value: "missing"}}
+		_, err := selectDatastore(vmContext, &infrav1.DatastoreSelector{Datastores: []string{"LocalDS_0"}}, compatible, true, 0)
+		g.Expect(err).To(MatchError(ContainSubstring("matches the datastore selector")))
+	})
+
+	t.Run("fails when no datastore is compatible", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := selectDatastore(vmContext, &infrav1.DatastoreSelector{Datastores: []string{"LocalDS_0"}}, nil, true, 0)
+		g.Expect(err).To(MatchError(ContainSubstring("matches the datastore selector")))
+	})
+}
+
+func TestSelectDatastoreControlPlaneAntiAffinity(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	labels := map[string]string{
+		clusterv1.ClusterLabelName:             "my-cluster",
+		clusterv1.MachineControlPlaneLabelName: "",
+	}
+	other := &infrav1.VSphereVM{
+		ObjectMeta: metav1.ObjectMeta{Namespace: fake.Namespace, Name: "other-control-plane", Labels: labels},
+		Status:     infrav1.VSphereVMStatus{Datastore: "LocalDS_0"},
+	}
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext(other)))
+	vmContext.Session = session
+	vmContext.VSphereVM.Labels = labels
+	selector := func(antiAffinity infrav1.DatastoreAntiAffinity) *infrav1.DatastoreSelector {
+		return &infrav1.DatastoreSelector{Datastores: []string{"LocalDS_0"}, ControlPlaneAntiAffinity: antiAffinity}
+	}
+
+	t.Run("selects a datastore used by another control plane VM without anti-affinity", func(t *testing.T) {
+		g := NewWithT(t)
+		ds, err := selectDatastore(vmContext, selector(infrav1.DatastoreAntiAffinityNone), nil, false, 0)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ds.Summary.Name).To(Equal("LocalDS_0"))
+	})
+
+	t.Run("falls back to a datastore used by another control plane VM with preferred anti-affinity", func(t *testing.T) {
+		g := NewWithT(t)
+		ds, err := selectDatastore(vmContext, selector(infrav1.DatastoreAntiAffinityPreferred), nil, false, 0)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ds.Summary.Name).To(Equal("LocalDS_0"))
+	})
+
+	t.Run("fails without a datastore free of other control plane VMs with required anti-affinity", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := selectDatastore(vmContext, selector(infrav1.DatastoreAntiAffinityRequired), nil, false, 0)
+		g.Expect(err).To(MatchError(ContainSubstring("required control plane anti-affinity")))
+	})
+}
+
+func TestUnusedDatastores(t *testing.T) {
+	g := NewWithT(t)
+	datastores := []mo.Datastore{
+		{Summary: types.DatastoreSummary{Name: "ds1"}},
+		{Summary: types.DatastoreSummary{Name: "ds2"}},
+		{Summary: types.DatastoreSummary{Name: "ds3"}},
+	}
+	unused := unusedDatastores(datastores, map[string]bool{"ds1": true, "ds3": true})
+	g.Expect(unused).To(HaveLen(1))
+	g.Expect(unused[0].Summary.Name).To(Equal("ds2"))
+}
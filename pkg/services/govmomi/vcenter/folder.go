@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// FolderPath returns the folder of a VM, rendering it if it is a template.
+// The relative paths of the templates are made absolute from the VM folder of
+// the datacenter, so that the folders created for them are not looked up
+// elsewhere in the inventory.
+func FolderPath(ctx *context.VMContext) (string, error) {
+	folder, err := ctx.VSphereVM.RenderFolder()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render the folder of %q", ctx)
+	}
+	if !infrav1.IsFolderTemplate(ctx.VSphereVM.Spec.Folder) || strings.HasPrefix(folder, "/") {
+		return folder, nil
+	}
+	vmFolder, err := findFolder(ctx, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to get the VM folder of the datacenter for %q", ctx)
+	}
+	return path.Join(vmFolder.InventoryPath, folder), nil
+}
+
+// ensureFolder returns the folder a VM is cloned in. The missing folders of
+// the path of a template are created, the folders which are not templates
+// being expected to exist.
+func ensureFolder(ctx *context.VMContext) (*object.Folder, error) {
+	folderPath, err := FolderPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := findFolder(ctx, folderPath)
+	switch {
+	case err == nil:
+		return folder, nil
+	case !infrav1.IsFolderTemplate(ctx.VSphereVM.Spec.Folder) || !isNotFound(err):
+		return nil, errors.Wrapf(err, "unable to get folder for %q", ctx)
+	}
+
+	// The missing folders are created below the deepest existing one.
+	elements := strings.Split(strings.TrimPrefix(folderPath, "/"), "/")
+	i := len(elements) - 1
+	for ; i > 0; i-- {
+		folder, err = ctx.Session.Finder.Folder(ctx, "/"+path.Join(elements[:i]...))
+		if err == nil {
+			break
+		}
+		if !isNotFound(err) {
+			return nil, errors.Wrapf(err, "unable to get folder for %q", ctx)
+		}
+	}
+	if i == 0 {
+		return nil, errors.Errorf("none of the parent folders of %s exists for %q", folderPath, ctx)
+	}
+	for _, name := range elements[i:] {
+		child, err := folder.CreateFolder(ctx, name)
+		switch {
+		case err == nil:
+			ctx.Logger.Info("created folder", "folder", path.Join(folder.InventoryPath, name))
+		case isDuplicateName(err):
+			// The folder was created concurrently, e.g. for another VM of
+			// the same MachineDeployment.
+			if child, err = ctx.Session.Finder.Folder(ctx, path.Join(folder.InventoryPath, name)); err != nil {
+				return nil, errors.Wrapf(err, "unable to get folder for %q", ctx)
+			}
+		default:
+			return nil, errors.Wrapf(err, "failed to create folder %s in %s for %q", name, folder.InventoryPath, ctx)
+		}
+		child.SetInventoryPath(path.Join(folder.InventoryPath, name))
+		folder = child
+	}
+	return folder, nil
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*find.NotFoundError)
+	return ok
+}
+
+func isDuplicateName(err error) bool {
+	if soap.IsSoapFault(err) {
+		_, ok := soap.ToSoapFault(err).VimFault().(types.DuplicateName)
+		return ok
+	}
+	return false
+}
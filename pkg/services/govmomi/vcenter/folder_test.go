@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestEnsureFolder(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	vmContext.Session = session
+	vmContext.VSphereVM.Labels = map[string]string{
+		clusterv1.ClusterLabelName:           "prod",
+		clusterv1.MachineDeploymentLabelName: "md-0",
+	}
+
+	t.Run("creates the missing folders of a template", func(t *testing.T) {
+		g := NewWithT(t)
+		vmContext.VSphereVM.Spec.Folder = "k8s/{{ .ClusterName }}/{{ .MachineDeployment }}"
+		folder, err := ensureFolder(vmContext)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(folder.InventoryPath).To(Equal("/DC0/vm/k8s/prod/md-0"))
+
+		found, err := session.Finder.Folder(vmContext, "/DC0/vm/k8s/prod/md-0")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(found.Reference()).To(Equal(folder.Reference()))
+
+		again, err := ensureFolder(vmContext)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(again.Reference()).To(Equal(folder.Reference()))
+	})
+
+	t.Run("creates the folders below an absolute path", func(t *testing.T) {
+		g := NewWithT(t)
+		vmContext.VSphereVM.Spec.Folder = "/DC0/vm/k8s/{{ .ClusterName }}/{{ if .ControlPlane }}control-plane{{ end }}/md"
+		folder, err := ensureFolder(vmContext)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(folder.InventoryPath).To(Equal("/DC0/vm/k8s/prod/md"))
+	})
+
+	t.Run("does not create the folders which are not templates", func(t *testing.T) {
+		g := NewWithT(t)
+		vmContext.VSphereVM.Spec.Folder = "/DC0/vm/missing"
+		_, err := ensureFolder(vmContext)
+		g.Expect(err).To(MatchError(ContainSubstring("unable to get folder")))
+	})
+
+	t.Run("fails without an existing parent folder", func(t *testing.T) {
+		g := NewWithT(t)
+		vmContext.VSphereVM.Spec.Folder = "/DC9/{{ .ClusterName }}"
+		_, err := ensureFolder(vmContext)
+		g.Expect(err).To(MatchError(ContainSubstring("none of the parent folders")))
+	})
+}
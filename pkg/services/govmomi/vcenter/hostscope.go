@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// networkHosts are the hosts of the compute resource of a resource pool,
+// split by whether they carry the standard port groups of a VM.
+type networkHosts struct {
+	owner types.ManagedObjectReference
+	// compatible are the hosts out of maintenance mode which carry all the
+	// standard port groups.
+	compatible []types.ManagedObjectReference
+	// reaching are the compatible hosts which mount the datastore of the VM.
+	reaching []types.ManagedObjectReference
+	// incompatible are the names of the hosts missing one of them.
+	incompatible []string
+}
+
+// restrictHostsToNetworks restricts the placement of the clone of a VM to the
+// hosts of its compute cluster carrying all the standard port groups of its
+// network devices, and records the other hosts in the status of the
+// VSphereVM. Unlike a distributed port group, which spans all the hosts of
+// its switch, a standard port group only exists on the hosts it is added to,
+// so that the VM could otherwise be placed on a host without its network.
+// The placement of the VMs whose hosts are all compatible is left to DRS, and
+// a host already set in the clone spec is kept as long as it is compatible.
+func restrictHostsToNetworks(ctx *context.VMContext, spec *types.VirtualMachineCloneSpec, template *types.ManagedObjectReference, datastore types.ManagedObjectReference, networks []types.ManagedObjectReference) error {
+	ctx.VSphereVM.Status.IncompatibleHosts = nil
+	hosts, err := getNetworkHosts(ctx, *spec.Location.Pool, datastore, networks)
+	if err != nil || hosts == nil || len(hosts.incompatible) == 0 {
+		return err
+	}
+
+	ctx.VSphereVM.Status.IncompatibleHosts = hosts.incompatible
+	ctx.Logger.Info("restricting the placement to the hosts carrying the standard port groups", "incompatibleHosts", hosts.incompatible)
+	if len(hosts.compatible) == 0 {
+		return errors.Errorf("no host of the compute resource of %q carries all the standard port groups of its network devices", ctx)
+	}
+	if spec.Location.Host != nil {
+		for _, host := range hosts.compatible {
+			if host == *spec.Location.Host {
+				return nil
+			}
+		}
+		return errors.Errorf("host %s does not carry all the standard port groups of the network devices of %q", spec.Location.Host.Value, ctx)
+	}
+	if len(hosts.reaching) == 0 {
+		return errors.Errorf("no host of the compute resource of %q carrying all the standard port groups of its network devices mounts datastore %s", ctx, datastore.Value)
+	}
+	spec.Location.Host = recommendHost(ctx, hosts, spec, template)
+	return nil
+}
+
+// getNetworkHosts returns the hosts of the compute resource of a resource
+// pool, split by whether they carry all the standard port groups among the
+// networks, or nil if there are none.
+func getNetworkHosts(ctx *context.VMContext, pool types.ManagedObjectReference, datastore types.ManagedObjectReference, networks []types.ManagedObjectReference) (*networkHosts, error) {
+	var standard []types.ManagedObjectReference
+	seen := map[types.ManagedObjectReference]bool{}
+	for _, ref := range networks {
+		if ref.Type == "Network" && !seen[ref] {
+			seen[ref] = true
+			standard = append(standard, ref)
+		}
+	}
+	if len(standard) == 0 {
+		return nil, nil
+	}
+
+	var portGroups []mo.Network
+	if err := ctx.Session.Retrieve(ctx, standard, []string{"name", "host"}, &portGroups); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the hosts of the standard port groups of %q", ctx)
+	}
+	carrying := map[types.ManagedObjectReference]int{}
+	for _, pg := range portGroups {
+		for _, host := range pg.Host {
+			carrying[host]++
+		}
+	}
+
+	var poolMo mo.ResourcePool
+	if err := ctx.Session.RetrieveOne(ctx, pool, []string{"owner"}, &poolMo); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the owner of resource pool %s for %q", pool.Value, ctx)
+	}
+	var owner mo.ComputeResource
+	if err := ctx.Session.RetrieveOne(ctx, poolMo.Owner, []string{"host"}, &owner); err != nil {
+		return nil, errors.Wrapf(err, "unable to get the hosts of compute resource %s for %q", poolMo.Owner.Value, ctx)
+	}
+	var hosts []mo.HostSystem
+	if len(owner.Host) > 0 {
+		if err := ctx.Session.Retrieve(ctx, owner.Host, []string{"name", "datastore", "runtime.inMaintenanceMode"}, &hosts); err != nil {
+			return nil, errors.Wrapf(err, "unable to get the hosts of compute resource %s for %q", poolMo.Owner.Value, ctx)
+		}
+	}
+
+	result := &networkHosts{owner: poolMo.Owner}
+	for _, host := range hosts {
+		switch {
+		case carrying[host.Reference()] < len(portGroups):
+			result.incompatible = append(result.incompatible, host.Name)
+		case !host.Runtime.InMaintenanceMode:
+			result.compatible = append(result.compatible, host.Reference())
+			if mounts(host, datastore) {
+				result.reaching = append(result.reaching, host.Reference())
+			}
+		}
+	}
+	sort.Strings(result.incompatible)
+	return result, nil
+}
+
+// mounts returns whether a host mounts a datastore.
+func mounts(host mo.HostSystem, datastore types.ManagedObjectReference) bool {
+	for _, ref := range host.Datastore {
+		if ref == datastore {
+			return true
+		}
+	}
+	return false
+}
+
+// recommendHost returns the host recommended by DRS among the compatible
+// hosts mounting the datastore of the VM for the clone of a template. The
+// first of them is returned when DRS cannot be asked, e.g. for the standalone
+// hosts or the clones from another vCenter, or when it has no recommendation.
+func recommendHost(ctx *context.VMContext, hosts *networkHosts, spec *types.VirtualMachineCloneSpec, template *types.ManagedObjectReference) *types.ManagedObjectReference {
+	if hosts.owner.Type != "ClusterComputeResource" || template == nil {
+		return &hosts.reaching[0]
+	}
+	cluster := object.NewClusterComputeResource(ctx.Session.Client.Client, hosts.owner)
+	result, err := cluster.PlaceVm(ctx, types.PlacementSpec{
+		PlacementType: string(types.PlacementSpecPlacementTypeClone),
+		Vm:            template,
+		CloneSpec:     spec,
+		CloneName:     ctx.VSphereVM.VMName(),
+		Hosts:         hosts.reaching,
+	})
+	if err != nil {
+		// DRS is not enabled on all compute clusters.
+		ctx.Logger.Info("unable to get a placement recommendation, using the first compatible host", "computeCluster", hosts.owner.Value, "error", err.Error())
+		return &hosts.reaching[0]
+	}
+	for _, recommendation := range result.Recommendations {
+		for _, action := range recommendation.Action {
+			if placement, ok := action.(*types.PlacementAction); ok && placement.TargetHost != nil {
+				return placement.TargetHost
+			}
+		}
+	}
+	return &hosts.reaching[0]
+}
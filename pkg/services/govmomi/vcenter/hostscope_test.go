@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestRestrictHostsToNetworks(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	vmContext.Session = session
+
+	pool, err := session.Finder.ResourcePool(vmContext, "/DC0/host/DC0_C0/Resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	standard, err := session.Finder.Network(vmContext, "/DC0/network/VM Network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	distributed, err := session.Finder.Network(vmContext, "/DC0/network/DC0_DVPG0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := simulator.Map.Get(simulator.Map.Get(pool.Reference()).(*simulator.ResourcePool).Owner).(*simulator.ClusterComputeResource)
+	hosts := cluster.Host
+	hostName := func(ref types.ManagedObjectReference) string {
+		return simulator.Map.Get(ref).(*simulator.HostSystem).Name
+	}
+	network := simulator.Map.Get(standard.Reference()).(*mo.Network)
+	datastore := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-test"}
+	mount := func(mounting []types.ManagedObjectReference) {
+		for _, ref := range hosts {
+			host := simulator.Map.Get(ref).(*simulator.HostSystem)
+			host.Datastore = nil
+			for _, m := range mounting {
+				if m == ref {
+					host.Datastore = []types.ManagedObjectReference{datastore}
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		carrying             []types.ManagedObjectReference
+		mounting             []types.ManagedObjectReference
+		networks             []types.ManagedObjectReference
+		host                 *types.ManagedObjectReference
+		expectedHost         *types.ManagedObjectReference
+		expectedIncompatible []string
+		expectedErr          string
+	}{
+		{
+			name:     "leaves the placement to DRS when all the hosts carry the port group",
+			carrying: hosts,
+			networks: []types.ManagedObjectReference{standard.Reference()},
+		},
+		{
+			name:     "leaves the placement to DRS for distributed port groups",
+			networks: []types.ManagedObjectReference{distributed.Reference()},
+		},
+		{
+			name:                 "restricts the placement to the hosts carrying the port group",
+			carrying:             hosts[1:2],
+			mounting:             hosts,
+			networks:             []types.ManagedObjectReference{standard.Reference(), distributed.Reference(), standard.Reference()},
+			expectedHost:         &hosts[1],
+			expectedIncompatible: []string{hostName(hosts[0]), hostName(hosts[2])},
+		},
+		{
+			name:                 "restricts the placement to the hosts mounting the datastore",
+			carrying:             hosts[1:],
+			mounting:             hosts[2:],
+			networks:             []types.ManagedObjectReference{standard.Reference()},
+			expectedHost:         &hosts[2],
+			expectedIncompatible: []string{hostName(hosts[0])},
+		},
+		{
+			name:                 "fails when no host carrying the port group mounts the datastore",
+			carrying:             hosts[1:2],
+			mounting:             hosts[2:],
+			networks:             []types.ManagedObjectReference{standard.Reference()},
+			expectedIncompatible: []string{hostName(hosts[0]), hostName(hosts[2])},
+			expectedErr:          "mounts datastore datastore-test",
+		},
+		{
+			name:                 "keeps a compatible host set in the clone spec",
+			carrying:             hosts[1:],
+			mounting:             hosts,
+			networks:             []types.ManagedObjectReference{standard.Reference()},
+			host:                 &hosts[2],
+			expectedHost:         &hosts[2],
+			expectedIncompatible: []string{hostName(hosts[0])},
+		},
+		{
+			name:                 "fails for an incompatible host set in the clone spec",
+			carrying:             hosts[1:],
+			mounting:             hosts,
+			networks:             []types.ManagedObjectReference{standard.Reference()},
+			host:                 &hosts[0],
+			expectedHost:         &hosts[0],
+			expectedIncompatible: []string{hostName(hosts[0])},
+			expectedErr:          "does not carry all the standard port groups",
+		},
+		{
+			name:                 "fails when no host carries the port group",
+			networks:             []types.ManagedObjectReference{standard.Reference()},
+			expectedIncompatible: []string{hostName(hosts[0]), hostName(hosts[1]), hostName(hosts[2])},
+			expectedErr:          "carries all the standard port groups",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			network.Host = tt.carrying
+			mount(tt.mounting)
+			vmContext.VSphereVM.Status.IncompatibleHosts = []string{"stale"}
+			spec := &types.VirtualMachineCloneSpec{
+				Location: types.VirtualMachineRelocateSpec{Pool: types.NewReference(pool.Reference()), Host: tt.host},
+			}
+
+			// The template is not set, as the simulator cannot recommend
+			// a placement among a subset of the hosts.
+			err := restrictHostsToNetworks(vmContext, spec, nil, datastore, tt.networks)
+			if tt.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectedErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(spec.Location.Host).To(Equal(tt.expectedHost))
+			g.Expect(vmContext.VSphereVM.Status.IncompatibleHosts).To(Equal(tt.expectedIncompatible))
+		})
+	}
+}
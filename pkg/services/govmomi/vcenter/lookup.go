@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	goctx "context"
+
+	"github.com/vmware/govmomi/object"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+)
+
+// lookupScope returns the scope the lookups of the inventory made for a VM
+// are shared in, i.e. its cluster.
+func lookupScope(ctx *context.VMContext) string {
+	return ctx.VSphereVM.Namespace + "/" + ctx.VSphereVM.Labels[clusterv1.ClusterLabelName]
+}
+
+// resolvePath resolves a path with byPath, unless it is a managed object ID,
+// through the cache of the paths resolved in the datacenter of a VM by the
+// sessions to its vCenter. The paths matching several objects fail with a
+// find.AmbiguousError. The path is resolved under c, the context of the
+// lookup shared among the VMs of the cluster.
+func resolvePath(c goctx.Context, ctx *context.VMContext, kind, path string, byPath find.ByPathFunc) (object.Reference, error) {
+	client := ctx.Session.Client.Client
+	return find.Paths.Resolve(c, client, ctx.VSphereVM.Spec.Server, ctx.Session.Username(), ctx.VSphereVM.Spec.Datacenter, kind, path,
+		find.ByMoRef(client, kind, func(c goctx.Context, path string) (object.Reference, error) {
+			obj, err := byPath(c, path)
+			if err != nil {
+				return nil, find.Ambiguous(c, ctx.Session.Finder, kind, path, err)
+			}
+			return obj, nil
+		}))
+}
+
+// findDatacenter returns the datacenter of a VM, or the default one.
+func findDatacenter(ctx *context.VMContext) (*object.Datacenter, error) {
+	obj, err := ctx.Session.Lookups().Get(ctx, lookupScope(ctx), "Datacenter", ctx.VSphereVM.Spec.Datacenter, func(c goctx.Context) (interface{}, error) {
+		return resolvePath(c, ctx, "Datacenter", ctx.VSphereVM.Spec.Datacenter, func(c goctx.Context, path string) (object.Reference, error) {
+			return ctx.Session.Finder.DatacenterOrDefault(c, path)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*object.Datacenter), nil
+}
+
+// findFolder returns the folder at a path, or the default VM folder if the
+// path is empty.
+func findFolder(ctx *context.VMContext, path string) (*object.Folder, error) {
+	obj, err := ctx.Session.Lookups().Get(ctx, lookupScope(ctx), "Folder", path, func(c goctx.Context) (interface{}, error) {
+		return resolvePath(c, ctx, "Folder", path, func(c goctx.Context, path string) (object.Reference, error) {
+			return ctx.Session.Finder.FolderOrDefault(c, path)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*object.Folder), nil
+}
+
+// findResourcePool returns the resource pool at a path, or the default one if
+// the path is empty.
+func findResourcePool(ctx *context.VMContext, path string) (*object.ResourcePool, error) {
+	obj, err := ctx.Session.Lookups().Get(ctx, lookupScope(ctx), "ResourcePool", path, func(c goctx.Context) (interface{}, error) {
+		return resolvePath(c, ctx, "ResourcePool", path, func(c goctx.Context, path string) (object.Reference, error) {
+			return ctx.Session.Finder.ResourcePoolOrDefault(c, path)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*object.ResourcePool), nil
+}
+
+// findDatastore returns the datastore at a path, or the default one if the
+// path is empty.
+func findDatastore(ctx *context.VMContext, path string) (*object.Datastore, error) {
+	obj, err := ctx.Session.Lookups().Get(ctx, lookupScope(ctx), "Datastore", path, func(c goctx.Context) (interface{}, error) {
+		return resolvePath(c, ctx, "Datastore", path, func(c goctx.Context, path string) (object.Reference, error) {
+			return ctx.Session.Finder.DatastoreOrDefault(c, path)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*object.Datastore), nil
+}
+
+// findNetwork returns the network at a path.
+func findNetwork(ctx *context.VMContext, path string) (object.NetworkReference, error) {
+	obj, err := ctx.Session.Lookups().Get(ctx, lookupScope(ctx), "Network", path, func(c goctx.Context) (interface{}, error) {
+		return resolvePath(c, ctx, "Network", path, func(c goctx.Context, path string) (object.Reference, error) {
+			return ctx.Session.Finder.Network(c, path)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(object.NetworkReference), nil
+}
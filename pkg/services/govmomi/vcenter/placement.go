@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+)
+
+// setPlacement records the folder, resource pool, datastore and networks
+// resolved for the clone of a VM in the status of its VSphereVM, by managed
+// object reference and inventory path. The host is recorded once the clone
+// completes, as it is chosen by vCenter.
+func setPlacement(ctx *context.VMContext, spec *types.VirtualMachineCloneSpec, datastore *types.ManagedObjectReference, networks []types.ManagedObjectReference) error {
+	placement := &infrav1.VSphereVMPlacement{}
+	var err error
+	if placement.Folder, err = InventoryObject(ctx, spec.Location.Folder); err != nil {
+		return err
+	}
+	if placement.ResourcePool, err = InventoryObject(ctx, spec.Location.Pool); err != nil {
+		return err
+	}
+	if placement.Datastore, err = InventoryObject(ctx, datastore); err != nil {
+		return err
+	}
+	for i := range networks {
+		network, err := InventoryObject(ctx, &networks[i])
+		if err != nil {
+			return err
+		}
+		placement.Networks = append(placement.Networks, *network)
+	}
+	ctx.VSphereVM.Status.Placement = placement
+	return nil
+}
+
+// InventoryObject returns the managed object reference and inventory path of
+// an object of the vCenter of a VM.
+func InventoryObject(ctx *context.VMContext, ref *types.ManagedObjectReference) (*infrav1.InventoryObject, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	path, err := find.InventoryPath(ctx, ctx.Session.Client.Client, *ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get the inventory path of %s", ref)
+	}
+	return &infrav1.InventoryObject{Ref: ref.String(), Path: path}, nil
+}
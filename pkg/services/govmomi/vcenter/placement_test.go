@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestSetPlacement(t *testing.T) {
+	g := NewWithT(t)
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+	vmContext.Session = session
+
+	folder, err := session.Finder.FolderOrDefault(vmContext, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	pool, err := session.Finder.ResourcePoolOrDefault(vmContext, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	datastore, err := session.Finder.Datastore(vmContext, "LocalDS_0")
+	g.Expect(err).NotTo(HaveOccurred())
+	network, err := session.Finder.Network(vmContext, "VM Network")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spec := &types.VirtualMachineCloneSpec{Location: types.VirtualMachineRelocateSpec{
+		Folder: types.NewReference(folder.Reference()),
+		Pool:   types.NewReference(pool.Reference()),
+	}}
+	g.Expect(setPlacement(vmContext, spec, types.NewReference(datastore.Reference()), []types.ManagedObjectReference{network.Reference()})).To(Succeed())
+
+	placement := vmContext.VSphereVM.Status.Placement
+	g.Expect(placement).NotTo(BeNil())
+	g.Expect(placement.Folder).To(Equal(&infrav1.InventoryObject{Ref: folder.Reference().String(), Path: "/DC0/vm"}))
+	g.Expect(placement.ResourcePool.Ref).To(Equal(pool.Reference().String()))
+	g.Expect(placement.ResourcePool.Path).To(HavePrefix("/DC0/host/"))
+	g.Expect(placement.Datastore).To(Equal(&infrav1.InventoryObject{Ref: datastore.Reference().String(), Path: "/DC0/datastore/LocalDS_0"}))
+	g.Expect(placement.Networks).To(Equal([]infrav1.InventoryObject{{Ref: network.Reference().String(), Path: "/DC0/network/VM Network"}}))
+	g.Expect(placement.Host).To(BeNil())
+}
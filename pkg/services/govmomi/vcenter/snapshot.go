@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+const linkedCloneSnapshotDescription = "Created by Cluster API Provider vSphere for linked clones"
+
+// snapshotLocks serializes the creation of snapshots per source VM/template
+// so that concurrent clones do not create duplicate snapshots.
+var snapshotLocks sync.Map
+
+// getLinkedCloneSnapshot returns the snapshot of the source VM/template from
+// which a linked clone is created. A nil reference is returned if a full
+// clone should be created instead, as configured by the fallback policy.
+func getLinkedCloneSnapshot(ctx *context.VMContext, tpl *object.VirtualMachine, pool *object.ResourcePool) (*types.ManagedObjectReference, error) {
+	linkedClone := ctx.VSphereVM.Spec.LinkedClone
+	if linkedClone == nil {
+		linkedClone = &infrav1.LinkedCloneSpec{}
+	}
+	snapshotName := ctx.VSphereVM.Spec.Snapshot
+
+	snapshotRef, err := findLinkedCloneSnapshot(ctx, tpl, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotRef == nil && linkedClone.CreateSnapshot {
+		if snapshotName == "" {
+			snapshotName = infrav1.DefaultLinkedCloneSnapshotName
+		}
+		if snapshotRef, err = createLinkedCloneSnapshot(ctx, tpl, pool, snapshotName); err != nil {
+			return nil, err
+		}
+	}
+	if snapshotRef == nil {
+		return nil, linkedCloneFallback(ctx, linkedClone, "no snapshot found on template %s", ctx.VSphereVM.Spec.Template)
+	}
+
+	if linkedClone.MaxSnapshotDepth > 0 {
+		var vm mo.VirtualMachine
+		if err := tpl.Properties(ctx, tpl.Reference(), []string{"snapshot"}, &vm); err != nil {
+			return nil, errors.Wrapf(err, "error getting snapshot information for template %s", ctx.VSphereVM.Spec.Template)
+		}
+		var depth int32
+		if vm.Snapshot != nil {
+			depth = snapshotDepth(vm.Snapshot.RootSnapshotList, *snapshotRef, 1)
+		}
+		if depth > linkedClone.MaxSnapshotDepth {
+			return nil, linkedCloneFallback(ctx, linkedClone, "snapshot %s of template %s has a depth of %d, which exceeds the maximum of %d",
+				snapshotRef.Value, ctx.VSphereVM.Spec.Template, depth, linkedClone.MaxSnapshotDepth)
+		}
+	}
+	return snapshotRef, nil
+}
+
+// findLinkedCloneSnapshot returns the snapshot with the given name, or the
+// current snapshot if the name is empty. A nil reference is returned if there
+// is no such snapshot.
+func findLinkedCloneSnapshot(ctx *context.VMContext, tpl *object.VirtualMachine, snapshotName string) (*types.ManagedObjectReference, error) {
+	if snapshotName == "" {
+		ctx.Logger.Info("searching for current snapshot")
+		var vm mo.VirtualMachine
+		if err := tpl.Properties(ctx, tpl.Reference(), []string{"snapshot"}, &vm); err != nil {
+			return nil, errors.Wrapf(err, "error getting snapshot information for template %s", ctx.VSphereVM.Spec.Template)
+		}
+		if vm.Snapshot == nil {
+			return nil, nil
+		}
+		return vm.Snapshot.CurrentSnapshot, nil
+	}
+
+	ctx.Logger.Info("searching for snapshot by name", "snapshotName", snapshotName)
+	snapshotRef, err := tpl.FindSnapshot(ctx, snapshotName)
+	if err != nil {
+		ctx.Logger.Info("failed to find snapshot", "snapshotName", snapshotName)
+		return nil, nil
+	}
+	return snapshotRef, nil
+}
+
+// createLinkedCloneSnapshot creates a snapshot with the given name on the
+// source VM/template, unless it was created in the meantime. Templates
+// cannot be snapshotted, so they are temporarily marked as a VM in the given
+// resource pool.
+func createLinkedCloneSnapshot(ctx *context.VMContext, tpl *object.VirtualMachine, pool *object.ResourcePool, snapshotName string) (*types.ManagedObjectReference, error) {
+	lock, _ := snapshotLocks.LoadOrStore(fmt.Sprintf("%s/%s", ctx.Session.URL().Host, tpl.Reference().Value), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()         //nolint:forcetypeassert
+	defer lock.(*sync.Mutex).Unlock() //nolint:forcetypeassert
+
+	if snapshotRef, err := tpl.FindSnapshot(ctx, snapshotName); err == nil {
+		return snapshotRef, nil
+	}
+
+	var vm mo.VirtualMachine
+	if err := tpl.Properties(ctx, tpl.Reference(), []string{"config.template"}, &vm); err != nil {
+		return nil, errors.Wrapf(err, "error getting configuration of template %s", ctx.VSphereVM.Spec.Template)
+	}
+	if vm.Config != nil && vm.Config.Template {
+		if err := tpl.MarkAsVirtualMachine(ctx, *pool, nil); err != nil {
+			return nil, errors.Wrapf(err, "error marking template %s as a virtual machine", ctx.VSphereVM.Spec.Template)
+		}
+		defer func() {
+			if err := tpl.MarkAsTemplate(ctx); err != nil {
+				ctx.Logger.Error(err, "failed to mark virtual machine as a template", "template", ctx.VSphereVM.Spec.Template)
+			}
+		}()
+	}
+
+	ctx.Logger.Info("creating snapshot for linked clones", "template", ctx.VSphereVM.Spec.Template, "snapshotName", snapshotName)
+	done := metrics.TrackVSphereOperation(metrics.VSphereOperationSnapshot, ctx.Session.URL().Host)
+	task, err := tpl.CreateSnapshot(ctx, snapshotName, linkedCloneSnapshotDescription, false, false)
+	done(err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating snapshot %s on template %s", snapshotName, ctx.VSphereVM.Spec.Template)
+	}
+	info, err := task.WaitForResult(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating snapshot %s on template %s", snapshotName, ctx.VSphereVM.Spec.Template)
+	}
+	snapshotRef, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return nil, errors.Errorf("unexpected result %T of snapshot creation on template %s", info.Result, ctx.VSphereVM.Spec.Template)
+	}
+	return &snapshotRef, nil
+}
+
+// linkedCloneFallback applies the fallback policy when no suitable snapshot
+// is available. An error is returned if the clone must not proceed.
+func linkedCloneFallback(ctx *context.VMContext, linkedClone *infrav1.LinkedCloneSpec, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if linkedClone.FallbackPolicy == infrav1.LinkedCloneFallbackFail {
+		return errors.Errorf("unable to create linked clone: %s", msg)
+	}
+	ctx.Logger.Info("falling back to full clone", "reason", msg)
+	return nil
+}
+
+// snapshotDepth returns the depth of the snapshot in the given snapshot trees,
+// or 0 if it is not found.
+func snapshotDepth(trees []types.VirtualMachineSnapshotTree, ref types.ManagedObjectReference, depth int32) int32 {
+	for _, tree := range trees {
+		if tree.Snapshot == ref {
+			return depth
+		}
+		if d := snapshotDepth(tree.ChildSnapshotList, ref, depth+1); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
+)
+
+func TestGetLinkedCloneSnapshot(t *testing.T) {
+	tests := []struct {
+		name          string
+		snapshot      string
+		linkedClone   *infrav1.LinkedCloneSpec
+		snapshots     []string
+		expectErr     bool
+		expectedName  string
+		expectedDepth int32
+	}{
+		{
+			name: "falls back to a full clone without snapshot",
+		},
+		{
+			name:        "fails without snapshot when the fallback policy is fail",
+			linkedClone: &infrav1.LinkedCloneSpec{FallbackPolicy: infrav1.LinkedCloneFallbackFail},
+			expectErr:   true,
+		},
+		{
+			name:          "uses the current snapshot",
+			snapshots:     []string{"base", "current"},
+			expectedName:  "current",
+			expectedDepth: 2,
+		},
+		{
+			name:          "uses the named snapshot",
+			snapshot:      "base",
+			snapshots:     []string{"base", "current"},
+			expectedName:  "base",
+			expectedDepth: 1,
+		},
+		{
+			name:          "creates the default snapshot",
+			linkedClone:   &infrav1.LinkedCloneSpec{CreateSnapshot: true},
+			expectedName:  infrav1.DefaultLinkedCloneSnapshotName,
+			expectedDepth: 1,
+		},
+		{
+			name:          "creates the named snapshot",
+			snapshot:      "pinned",
+			linkedClone:   &infrav1.LinkedCloneSpec{CreateSnapshot: true},
+			snapshots:     []string{"base"},
+			expectedName:  "pinned",
+			expectedDepth: 2,
+		},
+		{
+			name:        "falls back to a full clone when the snapshot is too deep",
+			snapshots:   []string{"base", "current"},
+			linkedClone: &infrav1.LinkedCloneSpec{MaxSnapshotDepth: 1},
+		},
+		{
+			name:        "fails when the snapshot is too deep and the fallback policy is fail",
+			snapshots:   []string{"base", "current"},
+			linkedClone: &infrav1.LinkedCloneSpec{MaxSnapshotDepth: 1, FallbackPolicy: infrav1.LinkedCloneFallbackFail},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			model, session, server := initSimulator(t)
+			t.Cleanup(model.Remove)
+			t.Cleanup(server.Close)
+
+			vmContext := fake.NewVMContext(fake.NewControllerContext(fake.NewControllerManagerContext()))
+			vmContext.Session = session
+			vmContext.VSphereVM.Spec.Snapshot = tt.snapshot
+			vmContext.VSphereVM.Spec.LinkedClone = tt.linkedClone
+
+			vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine) //nolint:forcetypeassert
+			tpl := object.NewVirtualMachine(session.Client.Client, vm.Reference())
+			for _, name := range tt.snapshots {
+				task, err := tpl.CreateSnapshot(vmContext, name, "", false, false)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(task.Wait(vmContext)).To(Succeed())
+			}
+			pool, err := session.Finder.ResourcePoolOrDefault(vmContext, "")
+			g.Expect(err).NotTo(HaveOccurred())
+
+			snapshotRef, err := getLinkedCloneSnapshot(vmContext, tpl, pool)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			if tt.expectedName == "" {
+				g.Expect(snapshotRef).To(BeNil())
+				return
+			}
+			g.Expect(snapshotRef).NotTo(BeNil())
+
+			expectedRef, err := tpl.FindSnapshot(vmContext, tt.expectedName)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(*snapshotRef).To(Equal(*expectedRef))
+			g.Expect(snapshotDepth(vm.Snapshot.RootSnapshotList, *snapshotRef, 1)).To(Equal(tt.expectedDepth))
+		})
+	}
+}
+
+func TestSnapshotDepth(t *testing.T) {
+	g := NewWithT(t)
+	ref := func(value string) types.ManagedObjectReference {
+		return types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: value}
+	}
+	trees := []types.VirtualMachineSnapshotTree{
+		{
+			Snapshot: ref("snapshot-1"),
+			ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+				{Snapshot: ref("snapshot-2")},
+				{
+					Snapshot:          ref("snapshot-3"),
+					ChildSnapshotList: []types.VirtualMachineSnapshotTree{{Snapshot: ref("snapshot-4")}},
+				},
+			},
+		},
+	}
+
+	g.Expect(snapshotDepth(trees, ref("snapshot-1"), 1)).To(Equal(int32(1)))
+	g.Expect(snapshotDepth(trees, ref("snapshot-2"), 1)).To(Equal(int32(2)))
+	g.Expect(snapshotDepth(trees, ref("snapshot-4"), 1)).To(Equal(int32(3)))
+	g.Expect(snapshotDepth(trees, ref("snapshot-5"), 1)).To(Equal(int32(0)))
+}
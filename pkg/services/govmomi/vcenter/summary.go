@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// cloneSpecSummary is the summary of the clone spec CAPV sent to vCenter to
+// create a VM. It is redacted: the values of the extraConfig keys, which
+// include the bootstrap data, and the guest customization are left out.
+type cloneSpecSummary struct {
+	Template             string            `json:"template"`
+	TemplateInstanceUUID string            `json:"templateInstanceUUID,omitempty"`
+	TemplateServer       string            `json:"templateServer,omitempty"`
+	CloneMode            infrav1.CloneMode `json:"cloneMode"`
+	Snapshot             string            `json:"snapshot,omitempty"`
+
+	Folder       string `json:"folder,omitempty"`
+	ResourcePool string `json:"resourcePool,omitempty"`
+	Datastore    string `json:"datastore,omitempty"`
+
+	NumCPUs                      int32 `json:"numCPUs"`
+	NumCoresPerSocket            int32 `json:"numCoresPerSocket"`
+	MemoryMiB                    int64 `json:"memoryMiB"`
+	MemoryReservationLockedToMax bool  `json:"memoryReservationLockedToMax,omitempty"`
+	SecureBoot                   bool  `json:"secureBoot,omitempty"`
+
+	Devices         []deviceSummary `json:"devices,omitempty"`
+	Disks           []diskSummary   `json:"disks,omitempty"`
+	ExtraConfigKeys []string        `json:"extraConfigKeys,omitempty"`
+	Customization   bool            `json:"customization,omitempty"`
+}
+
+// deviceSummary is the summary of a device change of the clone spec.
+type deviceSummary struct {
+	Operation  string `json:"operation"`
+	Type       string `json:"type"`
+	Key        int32  `json:"key"`
+	Network    string `json:"network,omitempty"`
+	MacAddress string `json:"macAddress,omitempty"`
+	CapacityKB int64  `json:"capacityKB,omitempty"`
+}
+
+// diskSummary is the summary of the location of a disk of the clone.
+type diskSummary struct {
+	Key          int32  `json:"key"`
+	Datastore    string `json:"datastore"`
+	DiskMoveType string `json:"diskMoveType,omitempty"`
+}
+
+// setCloneSpecSummary records the summary of the clone spec in the
+// CloneSpecSummaryAnnotation of the VSphereVM.
+func setCloneSpecSummary(vm *infrav1.VSphereVM, spec *types.VirtualMachineCloneSpec) error {
+	summary := newCloneSpecSummary(vm, spec)
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the clone spec summary of %s/%s", vm.Namespace, vm.Name)
+	}
+	annotations := vm.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[infrav1.CloneSpecSummaryAnnotation] = string(data)
+	vm.SetAnnotations(annotations)
+	return nil
+}
+
+func newCloneSpecSummary(vm *infrav1.VSphereVM, spec *types.VirtualMachineCloneSpec) cloneSpecSummary {
+	summary := cloneSpecSummary{
+		Template:             vm.Spec.Template,
+		TemplateInstanceUUID: vm.Status.TemplateInstanceUUID,
+		CloneMode:            vm.Status.CloneMode,
+		Snapshot:             vm.Status.Snapshot,
+		Folder:               refString(spec.Location.Folder),
+		ResourcePool:         refString(spec.Location.Pool),
+		Datastore:            refString(spec.Location.Datastore),
+		Customization:        spec.Customization != nil,
+	}
+	if vm.Spec.TemplateSource != nil {
+		summary.TemplateServer = vm.Spec.TemplateSource.Server
+	}
+
+	if config := spec.Config; config != nil {
+		summary.NumCPUs = config.NumCPUs
+		summary.NumCoresPerSocket = config.NumCoresPerSocket
+		summary.MemoryMiB = config.MemoryMB
+		if config.MemoryReservationLockedToMax != nil {
+			summary.MemoryReservationLockedToMax = *config.MemoryReservationLockedToMax
+		}
+		if config.BootOptions != nil && config.BootOptions.EfiSecureBootEnabled != nil {
+			summary.SecureBoot = *config.BootOptions.EfiSecureBootEnabled
+		}
+		for _, change := range config.DeviceChange {
+			summary.Devices = append(summary.Devices, newDeviceSummary(change.GetVirtualDeviceConfigSpec()))
+		}
+		for _, option := range config.ExtraConfig {
+			summary.ExtraConfigKeys = append(summary.ExtraConfigKeys, option.GetOptionValue().Key)
+		}
+		sort.Strings(summary.ExtraConfigKeys)
+	}
+
+	for _, disk := range spec.Location.Disk {
+		summary.Disks = append(summary.Disks, diskSummary{
+			Key:          disk.DiskId,
+			Datastore:    disk.Datastore.String(),
+			DiskMoveType: disk.DiskMoveType,
+		})
+	}
+	return summary
+}
+
+func newDeviceSummary(change *types.VirtualDeviceConfigSpec) deviceSummary {
+	summary := deviceSummary{
+		Operation: string(change.Operation),
+	}
+	if change.Device == nil {
+		return summary
+	}
+	summary.Type = reflect.Indirect(reflect.ValueOf(change.Device)).Type().Name()
+	summary.Key = change.Device.GetVirtualDevice().Key
+
+	switch device := change.Device.(type) {
+	case *types.VirtualDisk:
+		summary.CapacityKB = device.CapacityInKB
+	case types.BaseVirtualEthernetCard:
+		card := device.GetVirtualEthernetCard()
+		summary.MacAddress = card.MacAddress
+		switch backing := card.Backing.(type) {
+		case *types.VirtualEthernetCardNetworkBackingInfo:
+			summary.Network = backing.DeviceName
+		case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+			summary.Network = backing.Port.PortgroupKey
+		case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+			summary.Network = backing.OpaqueNetworkId
+		}
+	}
+	return summary
+}
+
+func refString(ref *types.ManagedObjectReference) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.String()
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vcenter
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/extra"
+)
+
+func TestSetCloneSpecSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	vm := &infrav1.VSphereVM{}
+	vm.Spec.Template = "ubuntu"
+	vm.Status.CloneMode = infrav1.FullClone
+
+	var extraConfig extra.Config
+	extraConfig.SetCloudInitUserData([]byte("secret"))
+
+	datastore := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"}
+	spec := &types.VirtualMachineCloneSpec{
+		Config: &types.VirtualMachineConfigSpec{
+			NumCPUs:           2,
+			NumCoresPerSocket: 1,
+			MemoryMB:          4096,
+			ExtraConfig:       extraConfig,
+			DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+				&types.VirtualDeviceConfigSpec{
+					Operation: types.VirtualDeviceConfigSpecOperationEdit,
+					Device: &types.VirtualDisk{
+						VirtualDevice: types.VirtualDevice{Key: 2000},
+						CapacityInKB:  20 * 1024 * 1024,
+					},
+				},
+				&types.VirtualDeviceConfigSpec{
+					Operation: types.VirtualDeviceConfigSpecOperationAdd,
+					Device: &types.VirtualVmxnet3{
+						VirtualVmxnet: types.VirtualVmxnet{
+							VirtualEthernetCard: types.VirtualEthernetCard{
+								VirtualDevice: types.VirtualDevice{
+									Key:     -100,
+									Backing: &types.VirtualEthernetCardNetworkBackingInfo{VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{DeviceName: "VM Network"}},
+								},
+								MacAddress: "00:50:56:00:00:01",
+							},
+						},
+					},
+				},
+			},
+		},
+		Location: types.VirtualMachineRelocateSpec{
+			Folder: &types.ManagedObjectReference{Type: "Folder", Value: "group-v3"},
+			Pool:   &types.ManagedObjectReference{Type: "ResourcePool", Value: "resgroup-1"},
+			Disk: []types.VirtualMachineRelocateSpecDiskLocator{
+				{DiskId: 2000, Datastore: datastore},
+			},
+		},
+		Customization: &types.CustomizationSpec{},
+	}
+
+	g.Expect(setCloneSpecSummary(vm, spec)).To(Succeed())
+	data, ok := vm.Annotations[infrav1.CloneSpecSummaryAnnotation]
+	g.Expect(ok).To(BeTrue())
+	g.Expect(data).NotTo(ContainSubstring("secret"))
+
+	var summary cloneSpecSummary
+	g.Expect(json.Unmarshal([]byte(data), &summary)).To(Succeed())
+	g.Expect(summary.Template).To(Equal("ubuntu"))
+	g.Expect(summary.CloneMode).To(Equal(infrav1.FullClone))
+	g.Expect(summary.Folder).To(Equal("Folder:group-v3"))
+	g.Expect(summary.ResourcePool).To(Equal("ResourcePool:resgroup-1"))
+	g.Expect(summary.MemoryMiB).To(Equal(int64(4096)))
+	g.Expect(summary.Customization).To(BeTrue())
+	g.Expect(summary.ExtraConfigKeys).To(ConsistOf("guestinfo.userdata", "guestinfo.userdata.encoding"))
+	g.Expect(summary.Devices).To(ConsistOf(
+		deviceSummary{Operation: "edit", Type: "VirtualDisk", Key: 2000, CapacityKB: 20 * 1024 * 1024},
+		deviceSummary{Operation: "add", Type: "VirtualVmxnet3", Key: -100, Network: "VM Network", MacAddress: "00:50:56:00:00:01"},
+	))
+	g.Expect(summary.Disks).To(ConsistOf(diskSummary{Key: 2000, Datastore: "Datastore:datastore-1"}))
+}
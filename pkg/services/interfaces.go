@@ -36,6 +36,11 @@ type VSphereMachineService interface {
 	SyncFailureReason(ctx context.MachineContext) (bool, error)
 	ReconcileNormal(ctx context.MachineContext) (bool, error)
 	GetHostInfo(ctx context.MachineContext) (string, error)
+	// GetTopologyInfo returns the names of the vSphere inventory objects the
+	// VM of the machine is placed in, or of the devices it is attached to, by
+	// node label. An empty name means the VM is not placed in such an object,
+	// or has no such device.
+	GetTopologyInfo(ctx context.MachineContext) (map[string]string, error)
 }
 
 // VirtualMachineService is a service for creating/updating/deleting virtual
@@ -44,8 +49,12 @@ type VirtualMachineService interface {
 	// ReconcileVM reconciles a VM with the intended state.
 	ReconcileVM(ctx *context.VMContext) (infrav1.VirtualMachine, error)
 
-	// DestroyVM powers off and removes a VM from the inventory.
+	// DestroyVM powers off and removes a VM from the inventory, or retains
+	// it according to the deletion policy of the VSphereVM.
 	DestroyVM(ctx *context.VMContext) (infrav1.VirtualMachine, error)
+
+	// GetVM returns the state of a VM without changing it in vSphere.
+	GetVM(ctx *context.VMContext) (infrav1.VirtualMachine, error)
 }
 
 // ControlPlaneEndpointService is a service for reconciling load balanced control plane endpoints.
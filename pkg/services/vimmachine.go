@@ -19,6 +19,8 @@ package services
 import (
 	goctx "context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -36,6 +38,7 @@ import (
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	infrautilv1 "sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
@@ -138,6 +141,22 @@ func (v *VimMachineService) ReconcileNormal(c context.MachineContext) (bool, err
 	vmObj.SetAPIVersion(vm.GetObjectKind().GroupVersionKind().GroupVersion().String())
 	vmObj.SetKind(vm.GetObjectKind().GroupVersionKind().Kind)
 
+	// Mirror the conditions reported by the guest agent, the health of the
+	// host of the VM, its migrations, its drift and its ambiguous references,
+	// if any.
+	for _, t := range []clusterv1.ConditionType{
+		infrav1.GuestBootstrapSucceededCondition,
+		infrav1.GuestNodeHealthyCondition,
+		infrav1.HostHealthyCondition,
+		infrav1.RecentlyMigratedCondition,
+		infrav1.SpecDriftedCondition,
+		infrav1.AmbiguousReferenceCondition,
+	} {
+		if conditions.Has(conditions.UnstructuredGetter(vmObj), t) {
+			conditions.SetMirror(ctx.VSphereMachine, t, conditions.UnstructuredGetter(vmObj))
+		}
+	}
+
 	// Waits the VM's ready state.
 	if ok, err := v.waitReadyState(ctx, vmObj); !ok {
 		if err != nil {
@@ -174,9 +193,33 @@ func (v *VimMachineService) ReconcileNormal(c context.MachineContext) (bool, err
 }
 
 func (v *VimMachineService) GetHostInfo(c context.MachineContext) (string, error) {
+	vsphereVM, err := v.getProvisionedVSphereVM(c)
+	if err != nil || vsphereVM == nil {
+		return "", err
+	}
+	return vsphereVM.Status.Host, nil
+}
+
+func (v *VimMachineService) GetTopologyInfo(c context.MachineContext) (map[string]string, error) {
+	vsphereVM, err := v.getProvisionedVSphereVM(c)
+	if err != nil || vsphereVM == nil || vsphereVM.Status.Topology == nil {
+		return nil, err
+	}
+	topology := vsphereVM.Status.Topology
+	return map[string]string{
+		constants.DatacenterInfoLabel:     topology.Datacenter,
+		constants.ComputeClusterInfoLabel: topology.ComputeCluster,
+		constants.ResourcePoolInfoLabel:   topology.ResourcePool,
+		constants.DatastoreInfoLabel:      topology.Datastore,
+	}, nil
+}
+
+// getProvisionedVSphereVM returns the VSphereVM of the machine, or nil if its
+// VM is not provisioned yet.
+func (v *VimMachineService) getProvisionedVSphereVM(c context.MachineContext) (*infrav1.VSphereVM, error) {
 	ctx, ok := c.(*context.VIMMachineContext)
 	if !ok {
-		return "", errors.New("received unexpected VIMMachineContext type")
+		return nil, errors.New("received unexpected VIMMachineContext type")
 	}
 
 	vsphereVM := &infrav1.VSphereVM{}
@@ -184,14 +227,14 @@ func (v *VimMachineService) GetHostInfo(c context.MachineContext) (string, error
 		Namespace: ctx.Machine.Namespace,
 		Name:      ctx.Machine.Name,
 	}, vsphereVM); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if conditions.IsTrue(vsphereVM, infrav1.VMProvisionedCondition) {
-		return vsphereVM.Status.Host, nil
+		return vsphereVM, nil
 	}
 	ctx.Logger.V(4).Info("VMProvisionedCondition is set to false", "vsphereVM", vsphereVM.Name)
-	return "", nil
+	return nil, nil
 }
 
 func (v *VimMachineService) findVMPre7(ctx *context.VIMMachineContext) (*infrav1.VSphereVM, error) {
@@ -371,13 +414,22 @@ func (v *VimMachineService) createOrPatchVSPhereVM(ctx *context.VIMMachineContex
 			vm.Labels[clusterv1.MachineControlPlaneLabelName] = val
 		}
 
+		// The MachineDeployment of the machine is part of the data the folder
+		// of the VSphereVM is rendered with.
+		if val, ok := ctx.Machine.Labels[clusterv1.MachineDeploymentLabelName]; ok {
+			vm.Labels[clusterv1.MachineDeploymentLabelName] = val
+		}
+
 		// Copy the VSphereMachine's VM clone spec into the VSphereVM's
 		// clone spec.
 		ctx.VSphereMachine.Spec.VirtualMachineCloneSpec.DeepCopyInto(&vm.Spec.VirtualMachineCloneSpec)
 
 		// If Failure Domain is present on CAPI machine, use that to override the vm clone spec.
+		// The conflicting values are reported by the PlacementResolved condition.
 		if overrideFunc, ok := v.generateOverrideFunc(ctx); ok {
-			overrideFunc(vm)
+			overrideFunc(vm).setCondition(ctx.VSphereMachine)
+		} else {
+			conditions.Delete(ctx.VSphereMachine, infrav1.PlacementResolvedCondition)
 		}
 
 		// Several of the VSphereVM's clone spec properties can be derived
@@ -392,9 +444,48 @@ func (v *VimMachineService) createOrPatchVSPhereVM(ctx *context.VIMMachineContex
 		if vm.Spec.Thumbprint == "" {
 			vm.Spec.Thumbprint = ctx.VSphereCluster.Spec.Thumbprint
 		}
+		// The port group created for the cluster takes precedence over the
+		// network of the default placement.
+		ctx.VSphereCluster.Status.ApplyPortGroup(&vm.Spec.VirtualMachineCloneSpec)
+		ctx.VSphereCluster.Spec.DefaultPlacement.ApplyTo(&vm.Spec.VirtualMachineCloneSpec)
+		ctx.VSphereCluster.Spec.ApplyNameservers(&vm.Spec.VirtualMachineCloneSpec)
+
+		// Render the per-zone values of the clone spec, which are only known
+		// once the failure domain of the machine is.
+		if err := vm.Spec.RenderZoneTemplates(v.zoneTemplateData(ctx)); err != nil {
+			return errors.Wrapf(err, "failed to render the per-zone values of %s", ctx)
+		}
+
+		// Clone the VM from the replica of the template on the datastore of
+		// its failure domain, once it is ready. The template of an existing
+		// VSphereVM is kept, as it cannot be changed.
+		if ctx.VSphereCluster.Spec.TemplateReplication != nil {
+			if vsphereVM != nil {
+				vm.Spec.Template = vsphereVM.Spec.Template
+			} else if failureDomain := machineFailureDomain(ctx); failureDomain != nil && vm.Spec.TemplateSource == nil {
+				if instanceUUID, ok := ctx.VSphereCluster.ReadyTemplateReplica(vm.Spec.Template, *failureDomain); ok {
+					vm.Spec.Template = instanceUUID
+				}
+			}
+		}
 		if vsphereVM != nil {
 			vm.Spec.BiosUUID = vsphereVM.Spec.BiosUUID
 		}
+
+		// The proxy and the NTP servers are configured in the guest through
+		// the bootstrap data, which is only delivered when the VM is created.
+		if vsphereVM == nil {
+			vm.Spec.Proxy = ctx.VSphereCluster.Spec.Proxy.DeepCopy()
+			vm.Spec.NTPServers = append([]string(nil), ctx.VSphereCluster.Spec.NTPServers...)
+		}
+
+		// The name of the VM in vSphere is set once, as the VM is not
+		// renamed.
+		if vsphereVM == nil && ctx.VSphereMachine.Spec.NamingStrategy != nil {
+			if vm.Spec.VMName, err = ctx.VSphereMachine.Spec.NamingStrategy.VMName(vmNameTemplateData(ctx)); err != nil {
+				return errors.Wrapf(err, "failed to generate the VM name of %s", ctx)
+			}
+		}
 		return nil
 	}
 
@@ -452,50 +543,187 @@ func (v *VimMachineService) createOrPatchVSPhereVM(ctx *context.VIMMachineContex
 
 // generateOverrideFunc returns a function which can override the values in the VSphereVM Spec
 // with the values from the FailureDomain (if any) set on the owner CAPI machine.
+// The values set by both the VSphereMachine and the FailureDomain are the ones of the
+// source having precedence according to the PlacementPrecedence of the VSphereMachine,
+// except for the server and datacenter which always are the ones of the FailureDomain.
+// The function returns the values which conflicted.
 //nolint:nestif
-func (v *VimMachineService) generateOverrideFunc(ctx *context.VIMMachineContext) (func(vm *infrav1.VSphereVM), bool) {
-	failureDomainName := ctx.Machine.Spec.FailureDomain
-	if failureDomainName == nil {
-		return nil, false
-	}
-
-	// Use the failureDomain name to fetch the vSphereDeploymentZone object
-	var vsphereDeploymentZone infrav1.VSphereDeploymentZone
-	if err := ctx.Client.Get(ctx, client.ObjectKey{Name: *failureDomainName}, &vsphereDeploymentZone); err != nil {
-		ctx.Logger.Error(err, "unable to fetch vsphere deployment zone", "name", *failureDomainName)
-		return nil, false
-	}
-
-	var vsphereFailureDomain infrav1.VSphereFailureDomain
-	if err := ctx.Client.Get(ctx, client.ObjectKey{Name: vsphereDeploymentZone.Spec.FailureDomain}, &vsphereFailureDomain); err != nil {
-		ctx.Logger.Error(err, "unable to fetch failure domain", "name", vsphereDeploymentZone.Spec.FailureDomain)
+func (v *VimMachineService) generateOverrideFunc(ctx *context.VIMMachineContext) (func(vm *infrav1.VSphereVM) *placementResolution, bool) {
+	vsphereDeploymentZone, vsphereFailureDomain, ok := v.getFailureDomain(ctx)
+	if !ok {
 		return nil, false
 	}
+	machineWins := ctx.VSphereMachine.Spec.PlacementPrecedence == infrav1.MachinePrecedence
 
-	overrideWithFailureDomainFunc := func(vm *infrav1.VSphereVM) {
+	overrideWithFailureDomainFunc := func(vm *infrav1.VSphereVM) *placementResolution {
+		r := &placementResolution{}
+		if vm.Spec.Server != "" && vm.Spec.Server != vsphereDeploymentZone.Spec.Server {
+			r.conflict("server", quote(vm.Spec.Server), quote(vsphereDeploymentZone.Spec.Server), false)
+		}
 		vm.Spec.Server = vsphereDeploymentZone.Spec.Server
+		if vm.Spec.Datacenter != "" && vm.Spec.Datacenter != vsphereFailureDomain.Spec.Topology.Datacenter {
+			r.conflict("datacenter", quote(vm.Spec.Datacenter), quote(vsphereFailureDomain.Spec.Topology.Datacenter), false)
+		}
 		vm.Spec.Datacenter = vsphereFailureDomain.Spec.Topology.Datacenter
-		if vsphereDeploymentZone.Spec.PlacementConstraint.Folder != "" {
-			vm.Spec.Folder = vsphereDeploymentZone.Spec.PlacementConstraint.Folder
+
+		if folder := vsphereDeploymentZone.Spec.PlacementConstraint.Folder; folder != "" {
+			switch {
+			case vm.Spec.Folder == "" || vm.Spec.Folder == folder:
+				vm.Spec.Folder = folder
+			case machineWins:
+				r.conflict("folder", quote(vm.Spec.Folder), quote(folder), true)
+			default:
+				r.conflict("folder", quote(vm.Spec.Folder), quote(folder), false)
+				vm.Spec.Folder = folder
+			}
 		}
 		if vsphereDeploymentZone.Spec.PlacementConstraint.ResourcePool != "" {
-			vm.Spec.ResourcePool = vsphereDeploymentZone.Spec.PlacementConstraint.ResourcePool
+			resourcePool := vsphereDeploymentZone.ResourcePoolPath()
+			machineValue := quote(vm.Spec.ResourcePool)
+			if vm.Spec.ComputeSelector != nil {
+				machineValue = "the compute selector"
+			}
+			switch {
+			case vm.Spec.ComputeSelector == nil && (vm.Spec.ResourcePool == "" || vm.Spec.ResourcePool == resourcePool):
+				vm.Spec.ResourcePool = resourcePool
+			case machineWins:
+				r.conflict("resourcePool", machineValue, quote(resourcePool), true)
+			default:
+				r.conflict("resourcePool", machineValue, quote(resourcePool), false)
+				vm.Spec.ResourcePool = resourcePool
+				vm.Spec.ComputeSelector = nil
+			}
 		}
-		if vsphereFailureDomain.Spec.Topology.Datastore != "" {
-			vm.Spec.Datastore = vsphereFailureDomain.Spec.Topology.Datastore
+		if datastore := vsphereFailureDomain.Spec.Topology.Datastore; datastore != "" {
+			machineValue := quote(vm.Spec.Datastore)
+			if vm.Spec.DatastoreSelector != nil {
+				machineValue = "the datastore selector"
+			}
+			switch {
+			case vm.Spec.DatastoreSelector == nil && (vm.Spec.Datastore == "" || vm.Spec.Datastore == datastore):
+				vm.Spec.Datastore = datastore
+			case machineWins:
+				r.conflict("datastore", machineValue, quote(datastore), true)
+			default:
+				r.conflict("datastore", machineValue, quote(datastore), false)
+				vm.Spec.Datastore = datastore
+				vm.Spec.DatastoreSelector = nil
+			}
 		}
 		if len(vsphereFailureDomain.Spec.Topology.Networks) > 0 {
-			vm.Spec.Network.Devices = overrideNetworkDeviceSpecs(vm.Spec.Network.Devices, vsphereFailureDomain.Spec.Topology.Networks)
+			vm.Spec.Network.Devices = overrideNetworkDeviceSpecs(vm.Spec.Network.Devices, vsphereFailureDomain.Spec.Topology.Networks, machineWins, r)
 		}
+		return r
 	}
 	return overrideWithFailureDomainFunc, true
 }
 
+// placementResolution records the placement values which are set to different
+// values by a VSphereMachine and its failure domain.
+type placementResolution struct {
+	conflicts []string
+
+	// machineOverridden is true when at least one of the conflicting values of
+	// the VSphereMachine is overridden by the one of the failure domain.
+	machineOverridden bool
+}
+
+// conflict records a field set to different values by the VSphereMachine and
+// its failure domain, and the one which is used.
+func (r *placementResolution) conflict(field, machineValue, failureDomainValue string, machineWins bool) {
+	if machineWins {
+		r.conflicts = append(r.conflicts, fmt.Sprintf("%s: %s of the machine overrides %s", field, machineValue, failureDomainValue))
+		return
+	}
+	r.machineOverridden = true
+	r.conflicts = append(r.conflicts, fmt.Sprintf("%s: %s of the failure domain overrides %s", field, failureDomainValue, machineValue))
+}
+
+// setCondition sets the PlacementResolvedCondition of the VSphereMachine,
+// which lists the conflicting values and the ones in effect.
+func (r *placementResolution) setCondition(vsphereMachine *infrav1.VSphereMachine) {
+	if len(r.conflicts) == 0 {
+		conditions.MarkTrue(vsphereMachine, infrav1.PlacementResolvedCondition)
+		return
+	}
+	reason := infrav1.FailureDomainPlacementOverriddenReason
+	if r.machineOverridden {
+		reason = infrav1.MachinePlacementOverriddenReason
+	}
+	conditions.MarkFalse(vsphereMachine, infrav1.PlacementResolvedCondition, reason, clusterv1.ConditionSeverityInfo, strings.Join(r.conflicts, "; "))
+}
+
+func quote(value string) string {
+	return fmt.Sprintf("%q", value)
+}
+
+// zoneTemplateData returns the data the per-zone values of the VSphereVM
+// Spec are rendered with, or nil if the owner CAPI machine has no
+// FailureDomain.
+func (v *VimMachineService) zoneTemplateData(ctx *context.VIMMachineContext) *infrav1.ZoneTemplateData {
+	vsphereDeploymentZone, vsphereFailureDomain, ok := v.getFailureDomain(ctx)
+	if !ok {
+		return nil
+	}
+	return &infrav1.ZoneTemplateData{
+		FailureDomain: vsphereDeploymentZone.Name,
+		Zone:          vsphereFailureDomain.Spec.Zone.Name,
+		Region:        vsphereFailureDomain.Spec.Region.Name,
+	}
+}
+
+// vmNameTemplateData returns the data the VM name of a machine is rendered
+// with.
+func vmNameTemplateData(ctx *context.VIMMachineContext) *infrav1.VMNameTemplateData {
+	_, isControlPlane := ctx.Machine.Labels[clusterv1.MachineControlPlaneLabelName]
+	return &infrav1.VMNameTemplateData{
+		Namespace:         ctx.Machine.Namespace,
+		MachineName:       ctx.Machine.Name,
+		ClusterName:       ctx.Machine.Labels[clusterv1.ClusterLabelName],
+		MachineDeployment: ctx.Machine.Labels[clusterv1.MachineDeploymentLabelName],
+		ControlPlane:      isControlPlane,
+	}
+}
+
+// getFailureDomain returns the VSphereDeploymentZone and the
+// VSphereFailureDomain of the FailureDomain set on the owner CAPI machine.
+func (v *VimMachineService) getFailureDomain(ctx *context.VIMMachineContext) (*infrav1.VSphereDeploymentZone, *infrav1.VSphereFailureDomain, bool) {
+	failureDomainName := machineFailureDomain(ctx)
+	if failureDomainName == nil {
+		return nil, nil, false
+	}
+
+	// Use the failureDomain name to fetch the vSphereDeploymentZone object
+	vsphereDeploymentZone := &infrav1.VSphereDeploymentZone{}
+	if err := ctx.Client.Get(ctx, client.ObjectKey{Name: *failureDomainName}, vsphereDeploymentZone); err != nil {
+		ctx.Logger.Error(err, "unable to fetch vsphere deployment zone", "name", *failureDomainName)
+		return nil, nil, false
+	}
+
+	vsphereFailureDomain := &infrav1.VSphereFailureDomain{}
+	if err := ctx.Client.Get(ctx, client.ObjectKey{Name: vsphereDeploymentZone.Spec.FailureDomain}, vsphereFailureDomain); err != nil {
+		ctx.Logger.Error(err, "unable to fetch failure domain", "name", vsphereDeploymentZone.Spec.FailureDomain)
+		return nil, nil, false
+	}
+	return vsphereDeploymentZone, vsphereFailureDomain, true
+}
+
+// machineFailureDomain returns the FailureDomain of the owner CAPI machine,
+// or the one of the VSphereMachine until the CAPI machine takes it, e.g. when
+// it was set by the failure domain balancing.
+func machineFailureDomain(ctx *context.VIMMachineContext) *string {
+	if ctx.Machine.Spec.FailureDomain != nil {
+		return ctx.Machine.Spec.FailureDomain
+	}
+	return ctx.VSphereMachine.Spec.FailureDomain
+}
+
 // overrideNetworkDeviceSpecs updates the network devices with the network definitions from the PlacementConstraint.
-// The substitution is done based on the order in which the network devices have been defined.
+// The substitution is done based on the order in which the network devices have been defined. When machineWins is true,
+// only the network devices without a network name are updated. The conflicting network names are recorded in r.
 //
 // In case there are more network definitions than the number of network devices specified, the definitions are appended to the list.
-func overrideNetworkDeviceSpecs(deviceSpecs []infrav1.NetworkDeviceSpec, networks []string) []infrav1.NetworkDeviceSpec {
+func overrideNetworkDeviceSpecs(deviceSpecs []infrav1.NetworkDeviceSpec, networks []string, machineWins bool, r *placementResolution) []infrav1.NetworkDeviceSpec {
 	index, length := 0, len(networks)
 
 	devices := make([]infrav1.NetworkDeviceSpec, 0, integer.IntMax(length, len(deviceSpecs)))
@@ -504,7 +732,13 @@ func overrideNetworkDeviceSpecs(deviceSpecs []infrav1.NetworkDeviceSpec, network
 		vmNetworkDeviceSpec := deviceSpecs[i]
 		if i < length {
 			index++
-			vmNetworkDeviceSpec.NetworkName = networks[i]
+			name := vmNetworkDeviceSpec.NetworkName
+			if name != "" && name != networks[i] {
+				r.conflict(fmt.Sprintf("network.devices[%d].networkName", i), quote(name), quote(networks[i]), machineWins)
+			}
+			if name == "" || !machineWins {
+				vmNetworkDeviceSpec.NetworkName = networks[i]
+			}
 		}
 		devices = append(devices, vmNetworkDeviceSpec)
 	}
@@ -25,8 +25,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/fake"
 )
@@ -51,6 +53,8 @@ var _ = Describe("VimMachineService_GenerateOverrideFunc", func() {
 		return &infrav1.VSphereFailureDomain{
 			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("fd-%s", suffix)},
 			Spec: infrav1.VSphereFailureDomainSpec{
+				Region: infrav1.FailureDomain{Name: "region"},
+				Zone:   infrav1.FailureDomain{Name: suffix},
 				Topology: infrav1.Topology{
 					Datacenter: fmt.Sprintf("dc-%s", suffix),
 					Datastore:  fmt.Sprintf("ds-%s", suffix),
@@ -76,6 +80,10 @@ var _ = Describe("VimMachineService_GenerateOverrideFunc", func() {
 			_, ok := vimMachineService.generateOverrideFunc(machineCtx)
 			Expect(ok).To(BeFalse())
 		})
+
+		It("does not return zone template data", func() {
+			Expect(vimMachineService.zoneTemplateData(machineCtx)).To(BeNil())
+		})
 	})
 
 	Context("When Failure Domain is present", func() {
@@ -102,6 +110,14 @@ var _ = Describe("VimMachineService_GenerateOverrideFunc", func() {
 			Expect(vm.Spec.Datacenter).To(Equal("dc-one"))
 		})
 
+		It("returns the zone template data of the failure domain", func() {
+			Expect(vimMachineService.zoneTemplateData(machineCtx)).To(Equal(&infrav1.ZoneTemplateData{
+				FailureDomain: "zone-one",
+				Zone:          "one",
+				Region:        "region",
+			}))
+		})
+
 		Context("for non-existent failure domain value", func() {
 			BeforeEach(func() {
 				machineCtx.Machine.Spec.FailureDomain = pointer.String("non-existent-zone")
@@ -183,6 +199,90 @@ var _ = Describe("VimMachineService_GenerateOverrideFunc", func() {
 				Expect(devices[2].NetworkName).To(Equal("baz"))
 			})
 		})
+
+		Context("with placement values set by the machine", func() {
+			machineVM := func() *infrav1.VSphereVM {
+				return &infrav1.VSphereVM{
+					Spec: infrav1.VSphereVMSpec{
+						VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+							Folder:       "folder-machine",
+							ResourcePool: "rp-machine",
+							Datastore:    "ds-one",
+							Network:      infrav1.NetworkSpec{Devices: []infrav1.NetworkDeviceSpec{{NetworkName: "nw-machine"}, {}}},
+						},
+					},
+				}
+			}
+
+			It("uses the failure domain values by default and reports the overridden ones", func() {
+				overrideFunc, ok := vimMachineService.generateOverrideFunc(machineCtx)
+				Expect(ok).To(BeTrue())
+
+				vm := machineVM()
+				overrideFunc(vm).setCondition(machineCtx.VSphereMachine)
+
+				Expect(vm.Spec.Folder).To(Equal("folder-one"))
+				Expect(vm.Spec.ResourcePool).To(Equal("rp-one"))
+				Expect(vm.Spec.Datastore).To(Equal("ds-one"))
+				Expect(vm.Spec.Network.Devices[0].NetworkName).To(Equal("nw-one"))
+				Expect(vm.Spec.Network.Devices[1].NetworkName).To(Equal("another-nw"))
+
+				condition := conditions.Get(machineCtx.VSphereMachine, infrav1.PlacementResolvedCondition)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(condition.Reason).To(Equal(infrav1.MachinePlacementOverriddenReason))
+				Expect(condition.Message).To(Equal(`folder: "folder-one" of the failure domain overrides "folder-machine"; ` +
+					`resourcePool: "rp-one" of the failure domain overrides "rp-machine"; ` +
+					`network.devices[0].networkName: "nw-one" of the failure domain overrides "nw-machine"`))
+			})
+
+			It("keeps the machine values with the Machine precedence and fills the others", func() {
+				machineCtx.VSphereMachine.Spec.PlacementPrecedence = infrav1.MachinePrecedence
+				overrideFunc, ok := vimMachineService.generateOverrideFunc(machineCtx)
+				Expect(ok).To(BeTrue())
+
+				vm := machineVM()
+				vm.Spec.Datastore = ""
+				vm.Spec.DatastoreSelector = &infrav1.DatastoreSelector{Datastores: []string{"ds-machine"}}
+				overrideFunc(vm).setCondition(machineCtx.VSphereMachine)
+
+				Expect(vm.Spec.Server).To(Equal("server-one"))
+				Expect(vm.Spec.Datacenter).To(Equal("dc-one"))
+				Expect(vm.Spec.Folder).To(Equal("folder-machine"))
+				Expect(vm.Spec.ResourcePool).To(Equal("rp-machine"))
+				Expect(vm.Spec.Datastore).To(BeEmpty())
+				Expect(vm.Spec.DatastoreSelector).NotTo(BeNil())
+				Expect(vm.Spec.Network.Devices[0].NetworkName).To(Equal("nw-machine"))
+				Expect(vm.Spec.Network.Devices[1].NetworkName).To(Equal("another-nw"))
+
+				condition := conditions.Get(machineCtx.VSphereMachine, infrav1.PlacementResolvedCondition)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Reason).To(Equal(infrav1.FailureDomainPlacementOverriddenReason))
+				Expect(condition.Message).To(ContainSubstring(`datastore: the datastore selector of the machine overrides "ds-one"`))
+			})
+
+			It("always uses the server and datacenter of the failure domain", func() {
+				machineCtx.VSphereMachine.Spec.PlacementPrecedence = infrav1.MachinePrecedence
+				overrideFunc, ok := vimMachineService.generateOverrideFunc(machineCtx)
+				Expect(ok).To(BeTrue())
+
+				vm := &infrav1.VSphereVM{Spec: infrav1.VSphereVMSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Server: "server-machine"}}}
+				overrideFunc(vm).setCondition(machineCtx.VSphereMachine)
+
+				Expect(vm.Spec.Server).To(Equal("server-one"))
+				Expect(conditions.GetReason(machineCtx.VSphereMachine, infrav1.PlacementResolvedCondition)).To(Equal(infrav1.MachinePlacementOverriddenReason))
+			})
+
+			It("marks the condition true when no value conflicts", func() {
+				overrideFunc, ok := vimMachineService.generateOverrideFunc(machineCtx)
+				Expect(ok).To(BeTrue())
+
+				vm := &infrav1.VSphereVM{Spec: infrav1.VSphereVMSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Folder: "folder-one"}}}
+				overrideFunc(vm).setCondition(machineCtx.VSphereMachine)
+
+				Expect(conditions.IsTrue(machineCtx.VSphereMachine, infrav1.PlacementResolvedCondition)).To(BeTrue())
+			})
+		})
 	})
 })
 
@@ -202,6 +302,11 @@ var _ = Describe("VimMachineService_GetHostInfo", func() {
 			},
 			Status: infrav1.VSphereVMStatus{
 				Host: hostAddr,
+				Topology: &infrav1.VSphereVMTopology{
+					Datacenter:   "dc0",
+					ResourcePool: "Resources",
+					Datastore:    "datastore1",
+				},
 				Conditions: []clusterv1.Condition{
 					{
 						Type:   infrav1.VMProvisionedCondition,
@@ -222,6 +327,16 @@ var _ = Describe("VimMachineService_GetHostInfo", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(host).To(Equal(hostAddr))
 		})
+		It("Fetches the topology from the VSphereVM object", func() {
+			topology, err := vimMachineService.GetTopologyInfo(machineCtx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(topology).To(Equal(map[string]string{
+				constants.DatacenterInfoLabel:     "dc0",
+				constants.ComputeClusterInfoLabel: "",
+				constants.ResourcePoolInfoLabel:   "Resources",
+				constants.DatastoreInfoLabel:      "datastore1",
+			}))
+		})
 	})
 
 	Context("When VMProvisioned Condition is unset", func() {
@@ -234,6 +349,11 @@ var _ = Describe("VimMachineService_GetHostInfo", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(host).To(BeEmpty())
 		})
+		It("returns no topology", func() {
+			topology, err := vimMachineService.GetTopologyInfo(machineCtx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(topology).To(BeNil())
+		})
 	})
 
 })
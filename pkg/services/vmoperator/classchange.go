@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmoperator
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	vmoprv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmwarev1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/vmware/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
+)
+
+// getVMClassName returns the class of the VM Operator VirtualMachine of a
+// VSphereMachine. The class of an existing VM is only changed when the class
+// of the VSphereMachine changes with the in-place strategy.
+func getVMClassName(ctx *vmware.SupervisorMachineContext, vm *vmoprv1.VirtualMachine) string {
+	if vm.Spec.ClassName != "" && ctx.VSphereMachine.Spec.ClassChangeStrategy == vmwarev1.ClassChangeStrategyReplace {
+		return vm.Spec.ClassName
+	}
+	return ctx.VSphereMachine.Spec.ClassName
+}
+
+// reconcileClassChange rolls out the class of the machines whose class
+// changed with the replace strategy. The class is set on a copy of the
+// machine template of their MachineDeployment, which is then switched to it
+// for its rolling update to replace the machines, as the MachineSets create
+// the replacements from their template.
+func reconcileClassChange(ctx *vmware.SupervisorMachineContext, vm *vmoprv1.VirtualMachine) error {
+	if vm.Spec.ClassName == ctx.VSphereMachine.Spec.ClassName {
+		if conditions.Has(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition) {
+			conditions.MarkTrue(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition)
+		}
+		return nil
+	}
+	if !ctx.Machine.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	className := ctx.VSphereMachine.Spec.ClassName
+
+	machineDeployment, err := getMachineDeployment(ctx)
+	if err != nil {
+		return err
+	}
+	if machineDeployment == nil {
+		conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacementNotSupportedReason, clusterv1.ConditionSeverityWarning,
+			"VM has class %s: only the machines of MachineDeployments can be replaced", vm.Spec.ClassName)
+		return nil
+	}
+	if _, ok := machineDeployment.Labels[clusterv1.ClusterTopologyOwnedLabel]; ok {
+		conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacementNotSupportedReason, clusterv1.ConditionSeverityWarning,
+			"VM has class %s: the class of the machines of MachineDeployment %s is changed in the topology of the cluster", vm.Spec.ClassName, machineDeployment.Name)
+		return nil
+	}
+
+	ref := machineDeployment.Spec.Template.Spec.InfrastructureRef
+	if ref.Kind != "VSphereMachineTemplate" || ref.GroupVersionKind().Group != vmwarev1.GroupVersion.Group {
+		conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacementNotSupportedReason, clusterv1.ConditionSeverityWarning,
+			"VM has class %s: MachineDeployment %s does not use a VSphereMachineTemplate", vm.Spec.ClassName, machineDeployment.Name)
+		return nil
+	}
+	template := &vmwarev1.VSphereMachineTemplate{}
+	key := client.ObjectKey{Namespace: machineDeployment.Namespace, Name: ref.Name}
+	if err := ctx.Client.Get(ctx, key, template); err != nil {
+		return errors.Wrapf(err, "failed to get VSphereMachineTemplate %s", key)
+	}
+	if template.Spec.Template.Spec.ClassName == className {
+		conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacingReason, clusterv1.ConditionSeverityInfo,
+			"VM has class %s: MachineDeployment %s is rolling out class %s", vm.Spec.ClassName, machineDeployment.Name, className)
+		return nil
+	}
+
+	// The templates are immutable, so the new class is set on a copy of the
+	// template, named after the class so that the machines changed to the
+	// same class share it.
+	newTemplate := &vmwarev1.VSphereMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       template.Namespace,
+			Name:            fmt.Sprintf("%s-%s", template.Name, className),
+			Labels:          template.Labels,
+			Annotations:     template.Annotations,
+			OwnerReferences: template.OwnerReferences,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	newTemplate.Spec.Template.Spec.ClassName = className
+	if err := ctx.Client.Create(ctx, newTemplate); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create VSphereMachineTemplate %s/%s", newTemplate.Namespace, newTemplate.Name)
+	}
+
+	ctx.Logger.Info("Rolling out the class of the machine to its MachineDeployment", "machineDeployment", machineDeployment.Name,
+		"from", vm.Spec.ClassName, "to", className, "template", newTemplate.Name)
+	patch := client.MergeFrom(machineDeployment.DeepCopy())
+	machineDeployment.Spec.Template.Spec.InfrastructureRef.Name = newTemplate.Name
+	if err := ctx.Client.Patch(ctx, machineDeployment, patch); err != nil {
+		return errors.Wrapf(err, "failed to patch MachineDeployment %s/%s", machineDeployment.Namespace, machineDeployment.Name)
+	}
+	conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacingReason, clusterv1.ConditionSeverityInfo,
+		"VM has class %s: MachineDeployment %s is rolling out class %s", vm.Spec.ClassName, machineDeployment.Name, className)
+	ctx.Recorder.Eventf(ctx.VSphereMachine, "ClassChanged", "Switched MachineDeployment %s to template %s to replace the VMs of class %s with VMs of class %s",
+		machineDeployment.Name, newTemplate.Name, vm.Spec.ClassName, className)
+	return nil
+}
+
+// getMachineDeployment returns the MachineDeployment of the MachineSet of a
+// machine, or nil if the machine is not owned by a MachineDeployment.
+func getMachineDeployment(ctx *vmware.SupervisorMachineContext) (*clusterv1.MachineDeployment, error) {
+	owner := metav1.GetControllerOf(ctx.Machine)
+	if owner == nil || owner.Kind != "MachineSet" {
+		return nil, nil
+	}
+	machineSet := &clusterv1.MachineSet{}
+	key := client.ObjectKey{Namespace: ctx.Machine.Namespace, Name: owner.Name}
+	if err := ctx.Client.Get(ctx, key, machineSet); err != nil {
+		return nil, errors.Wrapf(err, "failed to get MachineSet %s", key)
+	}
+	owner = metav1.GetControllerOf(machineSet)
+	if owner == nil || owner.Kind != "MachineDeployment" {
+		return nil, nil
+	}
+	machineDeployment := &clusterv1.MachineDeployment{}
+	key = client.ObjectKey{Namespace: machineSet.Namespace, Name: owner.Name}
+	if err := ctx.Client.Get(ctx, key, machineDeployment); err != nil {
+		return nil, errors.Wrapf(err, "failed to get MachineDeployment %s", key)
+	}
+	return machineDeployment, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmoperator
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	vmoprv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientrecord "k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmwarev1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/vmware/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/record"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
+)
+
+var _ = Describe("VirtualMachineClass changes", func() {
+	const (
+		machineName    = "test-machine"
+		clusterName    = "test-cluster"
+		deploymentName = "test-md"
+		templateName   = "test-template"
+		oldClass       = "best-effort-small"
+		newClass       = "best-effort-large"
+	)
+	var (
+		ctx               *vmware.SupervisorMachineContext
+		vm                *vmoprv1.VirtualMachine
+		machineDeployment *clusterv1.MachineDeployment
+	)
+
+	BeforeEach(func() {
+		cluster := util.CreateCluster(clusterName)
+		vsphereCluster := util.CreateVSphereCluster(clusterName)
+		machine := util.CreateMachine(machineName, clusterName, "", "v1.23.5")
+		machine.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "MachineSet",
+			Name:       "test-machineset",
+			Controller: pointer.Bool(true),
+		}}
+		vsphereMachine := util.CreateVSphereMachine(machineName, clusterName, "", newClass, "test-image", "test-storageClass")
+		vsphereMachine.Spec.ClassChangeStrategy = vmwarev1.ClassChangeStrategyReplace
+		clusterContext := util.CreateClusterContext(cluster, vsphereCluster)
+		clusterContext.ControllerContext.Recorder = record.New(clientrecord.NewFakeRecorder(10))
+		ctx = util.CreateMachineContext(clusterContext, machine, vsphereMachine)
+		ctx.ControllerContext = clusterContext.ControllerContext
+		Expect(ctx.Client.Create(ctx, machine)).To(Succeed())
+
+		machineDeployment = &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: machine.Namespace, Name: deploymentName},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: clusterName,
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						ClusterName: clusterName,
+						InfrastructureRef: corev1.ObjectReference{
+							APIVersion: vmwarev1.GroupVersion.String(),
+							Kind:       "VSphereMachineTemplate",
+							Name:       templateName,
+						},
+					},
+				},
+			},
+		}
+		Expect(ctx.Client.Create(ctx, machineDeployment)).To(Succeed())
+		Expect(ctx.Client.Create(ctx, &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: machine.Namespace,
+				Name:      "test-machineset",
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       "MachineDeployment",
+					Name:       deploymentName,
+					Controller: pointer.Bool(true),
+				}},
+			},
+			Spec: clusterv1.MachineSetSpec{ClusterName: clusterName},
+		})).To(Succeed())
+		Expect(ctx.Client.Create(ctx, &vmwarev1.VSphereMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: machine.Namespace, Name: templateName},
+			Spec: vmwarev1.VSphereMachineTemplateSpec{
+				Template: vmwarev1.VSphereMachineTemplateResource{
+					Spec: vmwarev1.VSphereMachineSpec{
+						ClassName:           oldClass,
+						ClassChangeStrategy: vmwarev1.ClassChangeStrategyReplace,
+						ImageName:           "test-image",
+					},
+				},
+			},
+		})).To(Succeed())
+
+		vm = &vmoprv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: machineName},
+			Spec:       vmoprv1.VirtualMachineSpec{ClassName: oldClass},
+		}
+	})
+
+	expectCondition := func(status corev1.ConditionStatus, reason string) {
+		c := conditions.Get(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition)
+		Expect(c).NotTo(BeNil())
+		Expect(c.Status).To(Equal(status))
+		Expect(c.Reason).To(Equal(reason))
+	}
+
+	deploymentTemplate := func() string {
+		md := &clusterv1.MachineDeployment{}
+		Expect(ctx.Client.Get(ctx, client.ObjectKeyFromObject(machineDeployment), md)).To(Succeed())
+		return md.Spec.Template.Spec.InfrastructureRef.Name
+	}
+
+	Specify("the class of an existing VM only follows the machine in place", func() {
+		Expect(getVMClassName(ctx, vm)).To(Equal(oldClass))
+		Expect(getVMClassName(ctx, &vmoprv1.VirtualMachine{})).To(Equal(newClass))
+
+		ctx.VSphereMachine.Spec.ClassChangeStrategy = vmwarev1.ClassChangeStrategyInPlace
+		Expect(getVMClassName(ctx, vm)).To(Equal(newClass))
+	})
+
+	Specify("the class is rolled out by the MachineDeployment of the machine", func() {
+		Expect(reconcileClassChange(ctx, vm)).To(Succeed())
+		expectCondition(corev1.ConditionFalse, vmwarev1.ReplacingReason)
+		Expect(deploymentTemplate()).To(Equal(templateName + "-" + newClass))
+
+		template := &vmwarev1.VSphereMachineTemplate{}
+		Expect(ctx.Client.Get(ctx, client.ObjectKey{Namespace: machineDeployment.Namespace, Name: templateName + "-" + newClass}, template)).To(Succeed())
+		Expect(template.Spec.Template.Spec.ClassName).To(Equal(newClass))
+		Expect(template.Spec.Template.Spec.ImageName).To(Equal("test-image"))
+
+		// The machine is not deleted, the MachineDeployment replaces it.
+		Expect(ctx.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Machine), &clusterv1.Machine{})).To(Succeed())
+
+		// The other machines of the MachineDeployment share the template.
+		Expect(reconcileClassChange(ctx, vm)).To(Succeed())
+		expectCondition(corev1.ConditionFalse, vmwarev1.ReplacingReason)
+		Expect(deploymentTemplate()).To(Equal(templateName + "-" + newClass))
+	})
+
+	Specify("the machines which are not owned by a MachineDeployment are not replaced", func() {
+		ctx.Machine.OwnerReferences[0].Kind = "KubeadmControlPlane"
+
+		Expect(reconcileClassChange(ctx, vm)).To(Succeed())
+		expectCondition(corev1.ConditionFalse, vmwarev1.ReplacementNotSupportedReason)
+		Expect(deploymentTemplate()).To(Equal(templateName))
+	})
+
+	Specify("the machines of a cluster topology are not replaced", func() {
+		machineDeployment.Labels = map[string]string{clusterv1.ClusterTopologyOwnedLabel: ""}
+		Expect(ctx.Client.Update(ctx, machineDeployment)).To(Succeed())
+
+		Expect(reconcileClassChange(ctx, vm)).To(Succeed())
+		expectCondition(corev1.ConditionFalse, vmwarev1.ReplacementNotSupportedReason)
+		Expect(deploymentTemplate()).To(Equal(templateName))
+	})
+
+	Specify("the condition turns true once the VM has the class of the machine", func() {
+		conditions.MarkFalse(ctx.VSphereMachine, vmwarev1.VMClassUpToDateCondition, vmwarev1.ReplacingReason, clusterv1.ConditionSeverityInfo, "")
+		vm.Spec.ClassName = newClass
+
+		Expect(reconcileClassChange(ctx, vm)).To(Succeed())
+		expectCondition(corev1.ConditionTrue, "")
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmoperator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	vmoprv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
+)
+
+// getVMClassDevices returns the vGPU and DirectPath I/O devices of the
+// VirtualMachineClass of a VSphereMachine. The devices are empty when the
+// class is not found, which VM Operator reports in the prerequisites of the
+// VM.
+func getVMClassDevices(ctx *vmware.SupervisorMachineContext) (vmoprv1.VirtualDevices, error) {
+	class := &vmoprv1.VirtualMachineClass{}
+	if err := ctx.Client.Get(ctx, client.ObjectKey{Name: ctx.VSphereMachine.Spec.ClassName}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return vmoprv1.VirtualDevices{}, nil
+		}
+		return vmoprv1.VirtualDevices{}, errors.Wrapf(err, "failed to get VirtualMachineClass %s", ctx.VSphereMachine.Spec.ClassName)
+	}
+	return class.Spec.Hardware.Devices, nil
+}
+
+// validateVMClassDevices validates that the devices of a VirtualMachineClass
+// identify the vGPU profiles and the PCI devices to attach.
+func validateVMClassDevices(devices vmoprv1.VirtualDevices) error {
+	var errs []error
+	for i, vgpu := range devices.VGPUDevices {
+		if vgpu.ProfileName == "" {
+			errs = append(errs, errors.Errorf("vGPU device %d has no profile name", i))
+		}
+	}
+	for i, device := range devices.DynamicDirectPathIODevices {
+		if device.VendorID == 0 || device.DeviceID == 0 {
+			errs = append(errs, errors.Errorf("DirectPath I/O device %d has no vendor or device ID", i))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// describeVMClassDevices describes the devices of a VirtualMachineClass, or
+// returns an empty string if it has none.
+func describeVMClassDevices(devices vmoprv1.VirtualDevices) string {
+	var descriptions []string
+	for _, vgpu := range devices.VGPUDevices {
+		descriptions = append(descriptions, fmt.Sprintf("vGPU profile %s", vgpu.ProfileName))
+	}
+	for _, device := range devices.DynamicDirectPathIODevices {
+		description := fmt.Sprintf("DirectPath I/O device %04x:%04x", device.VendorID, device.DeviceID)
+		if device.CustomLabel != "" {
+			description += fmt.Sprintf(" (%s)", device.CustomLabel)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// vmClassDeviceLabels returns the node labels describing the devices of a
+// VirtualMachineClass. The labels of the devices it does not have are empty,
+// so that they are removed from the Machine.
+func vmClassDeviceLabels(devices vmoprv1.VirtualDevices) map[string]string {
+	profiles := map[string]bool{}
+	for _, vgpu := range devices.VGPUDevices {
+		profiles[vgpu.ProfileName] = true
+	}
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := map[string]string{
+		constants.VGPUProfileInfoLabel:         strings.Join(names, "."),
+		constants.DirectPathIODevicesInfoLabel: "",
+	}
+	if n := len(devices.DynamicDirectPathIODevices); n > 0 {
+		labels[constants.DirectPathIODevicesInfoLabel] = strconv.Itoa(n)
+	}
+	return labels
+}
@@ -155,6 +155,19 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	// Set the VM state. Will get reset throughout the reconcile
 	ctx.VSphereMachine.Status.VMStatus = vmwarev1.VirtualMachineStatePending
 
+	// The vGPU and DirectPath I/O devices of the VirtualMachineClass are
+	// validated before the VM is created, since they cannot be attached to it
+	// otherwise.
+	classDevices, err := getVMClassDevices(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := validateVMClassDevices(classDevices); err != nil {
+		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, vmwarev1.InvalidVMClassDevicesReason, clusterv1.ConditionSeverityError,
+			"VirtualMachineClass %s: %v", ctx.VSphereMachine.Spec.ClassName, err)
+		return false, errors.Wrapf(err, "invalid devices in VirtualMachineClass %s", ctx.VSphereMachine.Spec.ClassName)
+	}
+
 	// Define the VM Operator VirtualMachine resource to reconcile.
 	vmOperatorVM := v.newVMOperatorVM(ctx)
 
@@ -169,15 +182,12 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	// Update the VM's state to Pending
 	ctx.VSphereMachine.Status.VMStatus = vmwarev1.VirtualMachineStatePending
 
-	// Since vm operator only has one condition for now, we can't set vspheremachine's condition fully based on virtualmachine's
-	// condition. Once vm operator surfaces enough conditions in virtualmachine, we could simply mirror the conditions in vspheremachine.
-	// For now, we set conditions based on the whole virtualmachine status.
-	// TODO: vm-operator does not use the cluster-api condition type. so can't use cluster-api util functions to fetch the condition
-	for _, cond := range vmOperatorVM.Status.Conditions {
-		if cond.Type == vmoprv1.VirtualMachinePrereqReadyCondition && cond.Severity == vmoprv1.ConditionSeverityError {
-			conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, cond.Reason, clusterv1.ConditionSeverityError, cond.Message)
-			return false, errors.Errorf("vm prerequisites check fails: %s", ctx)
-		}
+	// The VM prerequisites, e.g. its VirtualMachineClass and
+	// VirtualMachineImage, are not retried until the VSphereMachine changes.
+	if cond := getVMOperatorCondition(vmOperatorVM, vmoprv1.VirtualMachinePrereqReadyCondition); cond != nil &&
+		cond.Status == corev1.ConditionFalse && cond.Severity == vmoprv1.ConditionSeverityError {
+		markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.VMProvisionStartedReason, "")
+		return false, errors.Errorf("vm prerequisites check fails: %s", ctx)
 	}
 
 	// Requeue until the VM Operator VirtualMachine has:
@@ -186,7 +196,15 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	// * An IP address
 	// * A BIOS UUID
 	if vmOperatorVM.Status.Phase != vmoprv1.Created {
-		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, vmwarev1.VMProvisionStartedReason, clusterv1.ConditionSeverityInfo, "")
+		// The VMs with vGPU or DirectPath I/O devices can only be placed on the
+		// hosts which have the devices available, which VM Operator does not
+		// report.
+		if devices := describeVMClassDevices(classDevices); devices != "" {
+			markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.WaitingForDevicePlacementReason,
+				"waiting for a host with %s available", devices)
+		} else {
+			markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.VMProvisionStartedReason, "")
+		}
 		ctx.Logger.Info(fmt.Sprintf("vm is not yet created: %s", ctx))
 		return true, nil
 	}
@@ -194,7 +212,7 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	ctx.VSphereMachine.Status.VMStatus = vmwarev1.VirtualMachineStateCreated
 
 	if vmOperatorVM.Status.PowerState != vmoprv1.VirtualMachinePoweredOn {
-		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, vmwarev1.PoweringOnReason, clusterv1.ConditionSeverityInfo, "")
+		markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.PoweringOnReason, "")
 		ctx.Logger.Info(fmt.Sprintf("vm is not yet powered on: %s", ctx))
 		return true, nil
 	}
@@ -202,13 +220,13 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	ctx.VSphereMachine.Status.VMStatus = vmwarev1.VirtualMachineStatePoweredOn
 
 	if vmOperatorVM.Status.VmIp == "" {
-		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, vmwarev1.WaitingForNetworkAddressReason, clusterv1.ConditionSeverityInfo, "")
+		markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.WaitingForNetworkAddressReason, "")
 		ctx.Logger.Info(fmt.Sprintf("vm does not have an IP address: %s", ctx))
 		return true, nil
 	}
 
 	if vmOperatorVM.Status.BiosUUID == "" {
-		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, vmwarev1.WaitingForBIOSUUIDReason, clusterv1.ConditionSeverityInfo, "")
+		markVMNotProvisioned(ctx, vmOperatorVM, vmwarev1.WaitingForBIOSUUIDReason, "")
 		ctx.Logger.Info(fmt.Sprintf("vm does not have a BIOS UUID: %s", ctx))
 		return true, nil
 	}
@@ -226,7 +244,72 @@ func (v *VmopMachineService) ReconcileNormal(c context.MachineContext) (bool, er
 	// Mark the VSphereMachine as Ready
 	ctx.VSphereMachine.Status.Ready = true
 	conditions.MarkTrue(ctx.VSphereMachine, infrav1.VMProvisionedCondition)
-	return false, nil
+
+	// Roll out the class of the machine if it changed with the replace
+	// strategy.
+	return false, reconcileClassChange(ctx, vmOperatorVM)
+}
+
+// markVMNotProvisioned marks the VMProvisionedCondition of a VSphereMachine
+// false with the reason and message of the most severe false condition of its
+// VM Operator VirtualMachine, e.g. a missing VirtualMachineImage or a failed
+// guest customization. The given reason and message, which only tell the
+// phase the VirtualMachine is in, are used when none of its conditions is
+// false.
+func markVMNotProvisioned(ctx *vmware.SupervisorMachineContext, vm *vmoprv1.VirtualMachine, reason, messageFormat string, messageArgs ...interface{}) {
+	cond := falseVMOperatorCondition(vm)
+	if cond == nil {
+		conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, reason, clusterv1.ConditionSeverityInfo, messageFormat, messageArgs...)
+		return
+	}
+	severity := clusterv1.ConditionSeverity(cond.Severity)
+	if severity == clusterv1.ConditionSeverityNone {
+		severity = clusterv1.ConditionSeverityInfo
+	}
+	if cond.Reason != "" {
+		reason = cond.Reason
+	}
+	message := string(cond.Type)
+	if cond.Message != "" {
+		message += ": " + cond.Message
+	}
+	conditions.MarkFalse(ctx.VSphereMachine, infrav1.VMProvisionedCondition, reason, severity, "%s", message)
+}
+
+// getVMOperatorCondition returns the condition of a type of a VM Operator
+// VirtualMachine, or nil. VM Operator does not use the Cluster API condition
+// type, so the Cluster API utilities cannot fetch it.
+func getVMOperatorCondition(vm *vmoprv1.VirtualMachine, t vmoprv1.ConditionType) *vmoprv1.Condition {
+	for i := range vm.Status.Conditions {
+		if vm.Status.Conditions[i].Type == t {
+			return &vm.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// vmOperatorSeverities ranks the severities of the VM Operator conditions.
+var vmOperatorSeverities = map[vmoprv1.ConditionSeverity]int{
+	vmoprv1.ConditionSeverityError:   3,
+	vmoprv1.ConditionSeverityWarning: 2,
+	vmoprv1.ConditionSeverityInfo:    1,
+}
+
+// falseVMOperatorCondition returns the most severe false condition of a VM
+// Operator VirtualMachine, the first one among the conditions of the same
+// severity, or nil.
+func falseVMOperatorCondition(vm *vmoprv1.VirtualMachine) *vmoprv1.Condition {
+	var found *vmoprv1.Condition
+	for i := range vm.Status.Conditions {
+		cond := &vm.Status.Conditions[i]
+		if cond.Status != corev1.ConditionFalse {
+			continue
+		}
+		if found == nil || vmOperatorSeverities[cond.Severity] > vmOperatorSeverities[found.Severity] {
+			found = cond
+		}
+	}
+	return found
 }
 
 func (v VmopMachineService) GetHostInfo(c context.MachineContext) (string, error) {
@@ -246,6 +329,21 @@ func (v VmopMachineService) GetHostInfo(c context.MachineContext) (string, error
 	return vmOperatorVM.Status.Host, nil
 }
 
+// GetTopologyInfo returns no topology, as the VM operator does not report the
+// inventory objects its VMs are placed in, but the labels describing the vGPU
+// and DirectPath I/O devices of the VirtualMachineClass of the VM.
+func (v VmopMachineService) GetTopologyInfo(c context.MachineContext) (map[string]string, error) {
+	ctx, ok := c.(*vmware.SupervisorMachineContext)
+	if !ok {
+		return nil, errors.New("received unexpected SupervisorMachineContext type")
+	}
+	devices, err := getVMClassDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vmClassDeviceLabels(devices), nil
+}
+
 func (v VmopMachineService) newVMOperatorVM(ctx *vmware.SupervisorMachineContext) *vmoprv1.VirtualMachine {
 	return &vmoprv1.VirtualMachine{
 		ObjectMeta: metav1.ObjectMeta{
@@ -278,8 +376,8 @@ func (v VmopMachineService) reconcileVMOperatorVM(ctx *vmware.SupervisorMachineC
 		// Define a new VM Operator virtual machine.
 		// NOTE: Set field-by-field in order to preserve changes made directly
 		//  to the VirtualMachine spec by other sources (e.g. the cloud provider)
-		vmOperatorVM.Spec.ImageName = ctx.VSphereMachine.Spec.ImageName
-		vmOperatorVM.Spec.ClassName = ctx.VSphereMachine.Spec.ClassName
+		vmOperatorVM.Spec.ImageName = ctx.VSphereMachine.Spec.GetImageName()
+		vmOperatorVM.Spec.ClassName = getVMClassName(ctx, vmOperatorVM)
 		vmOperatorVM.Spec.StorageClass = ctx.VSphereMachine.Spec.StorageClass
 		vmOperatorVM.Spec.PowerState = vmoprv1.VirtualMachinePoweredOn
 		vmOperatorVM.Spec.ResourcePolicyName = ctx.VSphereCluster.Status.ResourcePolicyName
@@ -28,11 +28,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	vmwarev1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/vmware/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/constants"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context/vmware"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
 )
@@ -431,6 +433,147 @@ var _ = Describe("VirtualMachine tests", func() {
 			verifyOutput(ctx)
 		})
 
+		Specify("Reconcile machine when the guest customization of the vm fails", func() {
+			secretName := machine.GetName() + "-data"
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: machine.GetNamespace(),
+				},
+				Data: map[string][]byte{
+					"value": []byte(bootstrapData),
+				},
+			}
+			Expect(ctx.Client.Create(ctx, secret)).To(Succeed())
+			machine.Spec.Bootstrap.DataSecretName = &secretName
+
+			requeue, err = vmService.ReconcileNormal(ctx)
+			vmopVM = getReconciledVM(ctx)
+			vmopVM.Status.Phase = vmoprv1.Created
+			vmopVM.Status.PowerState = vmoprv1.VirtualMachinePoweredOn
+			errMessage := "failed to apply the cloud-init network configuration"
+			vmopVM.Status.Conditions = append(vmopVM.Status.Conditions,
+				vmoprv1.Condition{
+					Type:   vmoprv1.VirtualMachinePrereqReadyCondition,
+					Status: corev1.ConditionTrue,
+				},
+				vmoprv1.Condition{
+					Type:     "GuestCustomization",
+					Status:   corev1.ConditionFalse,
+					Reason:   "GuestCustomizationFailed",
+					Severity: vmoprv1.ConditionSeverityError,
+					Message:  errMessage,
+				})
+
+			updateReconciledVM(ctx, vmopVM)
+			requeue, err = vmService.ReconcileNormal(ctx)
+
+			expectedImageName = imageName
+			expectReconcileError = false
+			expectedRequeue = true
+			expectVMOpVM = true
+			expectedState = vmwarev1.VirtualMachineStatePoweredOn
+			expectedConditions = append(expectedConditions, clusterv1.Condition{
+				Type:     infrav1.VMProvisionedCondition,
+				Status:   corev1.ConditionFalse,
+				Severity: clusterv1.ConditionSeverityError,
+				Reason:   "GuestCustomizationFailed",
+				Message:  "GuestCustomization: " + errMessage,
+			})
+			verifyOutput(ctx)
+		})
+
+		Specify("Reconcile machine with a VirtualMachineClass with devices", func() {
+			vmClass := &vmoprv1.VirtualMachineClass{
+				ObjectMeta: metav1.ObjectMeta{Name: className},
+				Spec: vmoprv1.VirtualMachineClassSpec{
+					Hardware: vmoprv1.VirtualMachineClassHardware{
+						Devices: vmoprv1.VirtualDevices{
+							VGPUDevices: []vmoprv1.VGPUDevice{{ProfileName: "grid_t4-4q"}},
+							DynamicDirectPathIODevices: []vmoprv1.DynamicDirectPathIODevice{
+								{VendorID: 0x10de, DeviceID: 0x1eb8, CustomLabel: "t4"},
+							},
+						},
+					},
+				},
+			}
+			Expect(ctx.Client.Create(ctx, vmClass)).To(Succeed())
+
+			secretName := machine.GetName() + "-data"
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: machine.GetNamespace(),
+				},
+				Data: map[string][]byte{
+					"value": []byte(bootstrapData),
+				},
+			}
+			Expect(ctx.Client.Create(ctx, secret)).To(Succeed())
+			machine.Spec.Bootstrap.DataSecretName = &secretName
+
+			By("VirtualMachine is waiting for a host with the devices")
+			requeue, err = vmService.ReconcileNormal(ctx)
+			expectedImageName = imageName
+			expectReconcileError = false
+			expectedRequeue = true
+			expectVMOpVM = true
+			expectedConditions = append(expectedConditions, clusterv1.Condition{
+				Type:    infrav1.VMProvisionedCondition,
+				Status:  corev1.ConditionFalse,
+				Reason:  vmwarev1.WaitingForDevicePlacementReason,
+				Message: "vGPU profile grid_t4-4q, DirectPath I/O device 10de:1eb8 (t4)",
+			})
+			verifyOutput(ctx)
+
+			By("The devices are reported as node labels")
+			labels, labelsErr := vmService.GetTopologyInfo(ctx)
+			Expect(labelsErr).NotTo(HaveOccurred())
+			Expect(labels).To(Equal(map[string]string{
+				constants.VGPUProfileInfoLabel:         "grid_t4-4q",
+				constants.DirectPathIODevicesInfoLabel: "1",
+			}))
+
+			By("VirtualMachineClass has an invalid device")
+			vmClass.Spec.Hardware.Devices.VGPUDevices[0].ProfileName = ""
+			Expect(ctx.Client.Update(ctx, vmClass)).To(Succeed())
+			requeue, err = vmService.ReconcileNormal(ctx)
+			expectReconcileError = true
+			expectedRequeue = false
+			expectedConditions[0].Severity = clusterv1.ConditionSeverityError
+			expectedConditions[0].Reason = vmwarev1.InvalidVMClassDevicesReason
+			expectedConditions[0].Message = "vGPU device 0 has no profile name"
+			verifyOutput(ctx)
+		})
+
+		Specify("Reconcile Machine with the image of its failure domain", func() {
+			secretName := machine.GetName() + "-data"
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: machine.GetNamespace(),
+				},
+				Data: map[string][]byte{
+					"value": []byte(bootstrapData),
+				},
+			}
+			Expect(ctx.Client.Create(ctx, secret)).To(Succeed())
+			machine.Spec.Bootstrap.DataSecretName = &secretName
+			machine.Spec.FailureDomain = pointer.String("zone-b")
+			vsphereMachine.Spec.FailureDomainImageNames = map[string]string{
+				"zone-a": "zone-a-imageName",
+				"zone-b": "zone-b-imageName",
+			}
+
+			expectReconcileError = false
+			expectVMOpVM = true
+			expectedImageName = "zone-b-imageName"
+			expectedRequeue = true
+
+			requeue, err = vmService.ReconcileNormal(ctx)
+			verifyOutput(ctx)
+		})
+
 		Specify("Preserve changes made by other sources", func() {
 			secretName := machine.GetName() + "-data"
 			secret := &corev1.Secret{
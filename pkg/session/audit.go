@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
+)
+
+// auditor is the auditor of the calls changing the vSphere inventory made by
+// the sessions created from now on, if any.
+var auditor *audit.Auditor
+
+// SetAuditor records the calls changing the vSphere inventory made by the
+// sessions created from now on with a. The calls are attributed to the object
+// returned by audit.ObjectFrom for their context. It is meant to be called
+// once, before any session is created.
+func SetAuditor(a *audit.Auditor) {
+	auditor = a
+}
+
+// auditSOAPRoundTripper returns a round tripper auditing the SOAP calls of rt
+// which change the vSphere inventory, made to server by user.
+func auditSOAPRoundTripper(rt soap.RoundTripper, a *audit.Auditor, server, user string) soap.RoundTripper {
+	return &auditRoundTripper{RoundTripper: rt, auditor: a, server: server, user: user}
+}
+
+type auditRoundTripper struct {
+	soap.RoundTripper
+	auditor      *audit.Auditor
+	server, user string
+}
+
+func (t *auditRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+	if isReadOnlyMethod(method) {
+		return t.RoundTripper.RoundTrip(ctx, req, res)
+	}
+
+	err := t.RoundTripper.RoundTrip(ctx, req, res)
+	r := audit.Record{Server: t.server, User: t.user, Call: method}
+	if ref := callTarget(req); ref != nil {
+		r.Target = ref.String()
+	}
+	if err != nil {
+		r.Error = err.Error()
+	} else if task := callTask(res); task != nil {
+		r.Task = task.String()
+	}
+	t.auditor.Audit(audit.ObjectFrom(ctx), r)
+	return err
+}
+
+// callTask returns the task started by a SOAP call, i.e. the returned value of
+// its response when it is a task, if any.
+func callTask(res soap.HasFault) *types.ManagedObjectReference {
+	body := reflect.Indirect(reflect.ValueOf(res))
+	if body.Kind() != reflect.Struct {
+		return nil
+	}
+	r := body.FieldByName("Res")
+	if !r.IsValid() {
+		return nil
+	}
+	r = reflect.Indirect(r)
+	if r.Kind() != reflect.Struct {
+		return nil
+	}
+	v := r.FieldByName("Returnval")
+	if !v.IsValid() {
+		return nil
+	}
+	ref, ok := v.Interface().(types.ManagedObjectReference)
+	if !ok || ref.Type != "Task" {
+		return nil
+	}
+	return &ref
+}
+
+// auditHTTPRoundTripper returns a round tripper auditing the REST calls of rt
+// which change the vSphere inventory, made to server by user.
+func auditHTTPRoundTripper(rt http.RoundTripper, a *audit.Auditor, server, user string) http.RoundTripper {
+	return &auditHTTPTransport{RoundTripper: rt, auditor: a, server: server, user: user}
+}
+
+type auditHTTPTransport struct {
+	http.RoundTripper
+	auditor      *audit.Auditor
+	server, user string
+}
+
+func (t *auditHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isReadOnlyRequest(req) {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	res, err := t.RoundTripper.RoundTrip(req)
+	r := audit.Record{Server: t.server, User: t.user, Call: fmt.Sprintf("%s %s", req.Method, req.URL.Path)}
+	if action := req.URL.Query().Get("~action"); action != "" {
+		r.Call = fmt.Sprintf("%s?~action=%s", r.Call, action)
+	}
+	switch {
+	case err != nil:
+		r.Error = err.Error()
+	case res.StatusCode >= http.StatusBadRequest:
+		r.Error = res.Status
+	}
+	t.auditor.Audit(audit.ObjectFrom(req.Context()), r)
+	return res, err
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/audit"
+)
+
+func TestAuditRoundTripper(t *testing.T) {
+	g := NewWithT(t)
+	sink := &bytes.Buffer{}
+	a := audit.New(logr.Discard(), nil, nil, sink)
+	inner := &recordingRoundTripper{}
+	rt := auditSOAPRoundTripper(dryRunSOAPRoundTripper(inner, logr.Discard()), a, "vcenter", "capv@vsphere.local")
+
+	vm := &infrav1.VSphereVM{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm"}}
+	ctx := audit.WithObject(context.Background(), vm)
+	g.Expect(rt.RoundTrip(ctx, &methods.RetrievePropertiesBody{}, &methods.RetrievePropertiesBody{})).To(Succeed())
+	req := &methods.PowerOnVM_TaskBody{Req: &types.PowerOnVM_Task{This: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}}}
+	g.Expect(rt.RoundTrip(ctx, req, &methods.PowerOnVM_TaskBody{})).NotTo(Succeed())
+
+	// Only the call changing the inventory is audited.
+	var r audit.Record
+	g.Expect(json.Unmarshal(sink.Bytes(), &r)).To(Succeed())
+	g.Expect(r.Server).To(Equal("vcenter"))
+	g.Expect(r.User).To(Equal("capv@vsphere.local"))
+	g.Expect(r.Call).To(Equal("PowerOnVM_Task"))
+	g.Expect(r.Target).To(Equal("VirtualMachine:vm-42"))
+	g.Expect(r.Error).To(Equal("dry run: PowerOnVM_Task on VirtualMachine:vm-42 was not executed"))
+	g.Expect(r.Object.Name).To(Equal("vm"))
+}
+
+func Test_callTask(t *testing.T) {
+	g := NewWithT(t)
+	task := types.ManagedObjectReference{Type: "Task", Value: "task-1"}
+	g.Expect(callTask(&methods.CloneVM_TaskBody{Res: &types.CloneVM_TaskResponse{Returnval: task}})).To(Equal(&task))
+	g.Expect(callTask(&methods.CloneVM_TaskBody{})).To(BeNil())
+	g.Expect(callTask(&methods.RetrievePropertiesBody{Res: &types.RetrievePropertiesResponse{}})).To(BeNil())
+}
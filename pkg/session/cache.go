@@ -0,0 +1,362 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// propertyCacheRestartPeriod is the time to wait before re-establishing
+	// the property collector watch after it terminated unexpectedly.
+	propertyCacheRestartPeriod = 30 * time.Second
+
+	propName            = "name"
+	propPowerState      = "runtime.powerState"
+	propHost            = "runtime.host"
+	propDevices         = "config.hardware.device"
+	propGuestNet        = "guest.net"
+	propTaskInfo        = "info"
+	propConnectionState = "runtime.connectionState"
+)
+
+// cachedVMProperties are the VirtualMachine properties kept up to date by
+// the PropertyCache.
+var cachedVMProperties = []string{propName, propPowerState, propHost, propDevices, propGuestNet}
+
+// cachedHostProperties are the HostSystem properties kept up to date by the
+// PropertyCache.
+var cachedHostProperties = []string{propConnectionState}
+
+// PropertyCache is a cache of VirtualMachine, HostSystem and Task properties
+// that is kept up to date by a single PropertyCollector. All the VMs and hosts
+// in the session's datacenter are watched through container views, and tasks
+// are watched through the recent tasks of the TaskManager.
+//
+// Lookups never block on vCenter: when an object is not (yet) present in the
+// cache, callers are expected to fall back to retrieving the properties
+// directly.
+type PropertyCache struct {
+	client *vim25.Client
+	server string
+	logger logr.Logger
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	synced bool
+	vms    map[string]*cachedVM
+	hosts  map[string]types.HostSystemConnectionState
+	tasks  map[string]types.TaskInfo
+}
+
+// cachedVM holds the watched properties of a VirtualMachine. The slices are
+// replaced, never mutated, when a property changes so that they may be safely
+// handed out to callers.
+type cachedVM struct {
+	name       string
+	powerState types.VirtualMachinePowerState
+	host       *types.ManagedObjectReference
+	hasConfig  bool
+	devices    []types.BaseVirtualDevice
+	hasGuest   bool
+	guestNet   []types.GuestNicInfo
+}
+
+func newPropertyCache(logger logr.Logger, client *vim25.Client, server string) *PropertyCache {
+	return &PropertyCache{
+		client: client,
+		server: server,
+		logger: logger.WithName("property-cache"),
+		vms:    map[string]*cachedVM{},
+		hosts:  map[string]types.HostSystemConnectionState{},
+		tasks:  map[string]types.TaskInfo{},
+	}
+}
+
+// start begins watching the VMs and hosts under the given container in a background
+// goroutine. The watch is re-established if it fails until stop is called.
+func (c *PropertyCache) start(container types.ManagedObjectReference) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.watch(ctx, container); err != nil && ctx.Err() == nil {
+			c.logger.Error(err, "property collector watch terminated", "container", container)
+		}
+		c.reset()
+	}, propertyCacheRestartPeriod)
+}
+
+// stop terminates the background watch and drops all cached data.
+func (c *PropertyCache) stop() {
+	if c == nil || c.cancel == nil {
+		return
+	}
+	c.cancel()
+	c.reset()
+}
+
+func (c *PropertyCache) watch(ctx context.Context, container types.ManagedObjectReference) error {
+	m := view.NewManager(c.client)
+	v, err := m.CreateContainerView(ctx, container, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = v.Destroy(context.Background())
+	}()
+	hv, err := m.CreateContainerView(ctx, container, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = hv.Destroy(context.Background())
+	}()
+
+	filter := new(property.WaitFilter).
+		Add(v.Reference(), "VirtualMachine", cachedVMProperties, v.TraversalSpec()).
+		Add(hv.Reference(), "HostSystem", cachedHostProperties, hv.TraversalSpec()).
+		Add(*c.client.ServiceContent.TaskManager, "Task", []string{propTaskInfo}, &types.TraversalSpec{
+			Type: "TaskManager",
+			Path: "recentTask",
+		})
+
+	c.logger.V(4).Info("starting property collector watch", "container", container)
+	return property.WaitForUpdates(ctx, property.DefaultCollector(c.client), filter, func(updates []types.ObjectUpdate) bool {
+		c.apply(updates)
+		return false
+	})
+}
+
+// apply records a batch of object updates received from the
+// PropertyCollector. The first batch contains the full initial state, after
+// which the cache is considered synced. A VMEvent is emitted for every task
+// targeting a VM that completed since the previous batch, and for every VM of
+// a host whose connection state changed.
+func (c *PropertyCache) apply(updates []types.ObjectUpdate) {
+	for _, e := range c.applyLocked(updates) {
+		notifyVMEvent(e)
+	}
+}
+
+func (c *PropertyCache) applyLocked(updates []types.ObjectUpdate) []VMEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []VMEvent
+	var changedHosts []string
+
+	for _, update := range updates {
+		key := update.Obj.Value
+		switch update.Obj.Type {
+		case "VirtualMachine":
+			if update.Kind == types.ObjectUpdateKindLeave {
+				delete(c.vms, key)
+				continue
+			}
+			vm, ok := c.vms[key]
+			if !ok {
+				vm = &cachedVM{}
+				c.vms[key] = vm
+			}
+			for _, change := range update.ChangeSet {
+				vm.applyChange(change)
+			}
+		case "HostSystem":
+			if update.Kind == types.ObjectUpdateKindLeave {
+				delete(c.hosts, key)
+				continue
+			}
+			for _, change := range update.ChangeSet {
+				if change.Name != propConnectionState {
+					continue
+				}
+				state, _ := change.Val.(types.HostSystemConnectionState)
+				if prev, ok := c.hosts[key]; ok && prev != state {
+					changedHosts = append(changedHosts, key)
+				}
+				c.hosts[key] = state
+			}
+		case "Task":
+			if update.Kind == types.ObjectUpdateKindLeave {
+				delete(c.tasks, key)
+				continue
+			}
+			for _, change := range update.ChangeSet {
+				if change.Name != propTaskInfo {
+					continue
+				}
+				if info, ok := change.Val.(types.TaskInfo); ok {
+					if prev, ok := c.tasks[key]; ok && !isTaskDone(prev) && isTaskDone(info) &&
+						info.Entity != nil && info.Entity.Type == "VirtualMachine" {
+						events = append(events, VMEvent{
+							Server: c.server,
+							Ref:    *info.Entity,
+							Name:   info.EntityName,
+							Reason: VMEventReasonTaskCompleted,
+						})
+					}
+					c.tasks[key] = info
+				} else {
+					delete(c.tasks, key)
+				}
+			}
+		}
+	}
+	// The VMs are looked up once all the updates are applied, so that the
+	// VMs moved to another host in the same batch are not notified.
+	for _, host := range changedHosts {
+		for key, vm := range c.vms {
+			if vm.host == nil || vm.host.Value != host {
+				continue
+			}
+			events = append(events, VMEvent{
+				Server: c.server,
+				Ref:    types.ManagedObjectReference{Type: "VirtualMachine", Value: key},
+				Name:   vm.name,
+				Reason: VMEventReasonHostConnectionStateChanged,
+			})
+		}
+	}
+	c.synced = true
+	return events
+}
+
+func isTaskDone(info types.TaskInfo) bool {
+	return info.State == types.TaskInfoStateSuccess || info.State == types.TaskInfoStateError
+}
+
+func (vm *cachedVM) applyChange(change types.PropertyChange) {
+	switch change.Name {
+	case propName:
+		vm.name, _ = change.Val.(string)
+	case propHost:
+		if val, ok := change.Val.(types.ManagedObjectReference); ok {
+			vm.host = &val
+		} else {
+			vm.host = nil
+		}
+	case propPowerState:
+		if val, ok := change.Val.(types.VirtualMachinePowerState); ok {
+			vm.powerState = val
+		} else {
+			vm.powerState = ""
+		}
+	case propDevices:
+		if val, ok := change.Val.(types.ArrayOfVirtualDevice); ok {
+			vm.hasConfig, vm.devices = true, val.VirtualDevice
+		} else {
+			vm.hasConfig, vm.devices = false, nil
+		}
+	case propGuestNet:
+		if val, ok := change.Val.(types.ArrayOfGuestNicInfo); ok {
+			vm.hasGuest, vm.guestNet = true, val.GuestNicInfo
+		} else {
+			vm.hasGuest, vm.guestNet = false, nil
+		}
+	}
+}
+
+func (c *PropertyCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.synced = false
+	c.vms = map[string]*cachedVM{}
+	c.hosts = map[string]types.HostSystemConnectionState{}
+	c.tasks = map[string]types.TaskInfo{}
+}
+
+// VirtualMachine returns the cached name, runtime.powerState, runtime.host,
+// config.hardware.device and guest.net properties of the VM with the given
+// reference. The boolean is
+// false if the cache is not synced or does not know about the VM.
+func (c *PropertyCache) VirtualMachine(ref types.ManagedObjectReference) (mo.VirtualMachine, bool) {
+	if c == nil {
+		return mo.VirtualMachine{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	vm, ok := c.vms[ref.Value]
+	if !c.synced || !ok || vm.powerState == "" {
+		return mo.VirtualMachine{}, false
+	}
+	obj := mo.VirtualMachine{}
+	obj.Self = ref
+	obj.Name = vm.name
+	obj.Runtime.PowerState = vm.powerState
+	obj.Runtime.Host = vm.host
+	if vm.hasConfig {
+		obj.Config = &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{Device: vm.devices},
+		}
+	}
+	if vm.hasGuest {
+		obj.Guest = &types.GuestInfo{Net: vm.guestNet}
+	}
+	return obj, true
+}
+
+// Task returns the cached info property of the task with the given reference.
+// The boolean is false if the cache is not synced or the task is not one of
+// the TaskManager's recent tasks.
+func (c *PropertyCache) Task(ref types.ManagedObjectReference) (mo.Task, bool) {
+	if c == nil {
+		return mo.Task{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	info, ok := c.tasks[ref.Value]
+	if !c.synced || !ok {
+		return mo.Task{}, false
+	}
+	obj := mo.Task{Info: info}
+	obj.Self = ref
+	return obj, true
+}
+
+// ActiveTasks returns the number of the TaskManager's recent tasks which are
+// queued or running. The boolean is false if the cache is not synced.
+func (c *PropertyCache) ActiveTasks() (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.synced {
+		return 0, false
+	}
+	active := 0
+	for _, info := range c.tasks {
+		if !isTaskDone(info) {
+			active++
+		}
+	}
+	return active, true
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/klog/v2"
+)
+
+func TestPropertyCache(t *testing.T) {
+	vmRef := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	taskRef := types.ManagedObjectReference{Type: "Task", Value: "task-1"}
+
+	t.Run("misses before the initial update is received", func(t *testing.T) {
+		g := NewWithT(t)
+		c := newPropertyCache(klog.Background(), nil, "vcenter")
+		_, ok := c.VirtualMachine(vmRef)
+		g.Expect(ok).To(BeFalse())
+		_, ok = c.Task(taskRef)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("nil cache always misses", func(t *testing.T) {
+		g := NewWithT(t)
+		var c *PropertyCache
+		_, ok := c.VirtualMachine(vmRef)
+		g.Expect(ok).To(BeFalse())
+		_, ok = c.Task(taskRef)
+		g.Expect(ok).To(BeFalse())
+		c.stop()
+	})
+
+	t.Run("tracks vm and task updates", func(t *testing.T) {
+		g := NewWithT(t)
+		c := newPropertyCache(klog.Background(), nil, "vcenter")
+		c.apply([]types.ObjectUpdate{
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  vmRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: propPowerState, Op: types.PropertyChangeOpAssign, Val: types.VirtualMachinePowerStatePoweredOff},
+					{Name: propDevices, Op: types.PropertyChangeOpAssign, Val: types.ArrayOfVirtualDevice{
+						VirtualDevice: []types.BaseVirtualDevice{&types.VirtualVmxnet3{}},
+					}},
+				},
+			},
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  taskRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: propTaskInfo, Op: types.PropertyChangeOpAssign, Val: types.TaskInfo{State: types.TaskInfoStateRunning}},
+				},
+			},
+		})
+
+		vm, ok := c.VirtualMachine(vmRef)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(vm.Self).To(Equal(vmRef))
+		g.Expect(vm.Runtime.PowerState).To(Equal(types.VirtualMachinePowerStatePoweredOff))
+		g.Expect(vm.Config).ToNot(BeNil())
+		g.Expect(vm.Config.Hardware.Device).To(HaveLen(1))
+		g.Expect(vm.Guest).To(BeNil())
+
+		task, ok := c.Task(taskRef)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(task.Info.State).To(Equal(types.TaskInfoStateRunning))
+
+		c.apply([]types.ObjectUpdate{
+			{
+				Kind: types.ObjectUpdateKindModify,
+				Obj:  vmRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: propPowerState, Op: types.PropertyChangeOpAssign, Val: types.VirtualMachinePowerStatePoweredOn},
+					{Name: propGuestNet, Op: types.PropertyChangeOpAssign, Val: types.ArrayOfGuestNicInfo{
+						GuestNicInfo: []types.GuestNicInfo{{MacAddress: "00:00:00:00:00:01", IpAddress: []string{"192.168.0.10"}}},
+					}},
+				},
+			},
+			{
+				Kind: types.ObjectUpdateKindLeave,
+				Obj:  taskRef,
+			},
+		})
+
+		vm, ok = c.VirtualMachine(vmRef)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(vm.Runtime.PowerState).To(Equal(types.VirtualMachinePowerStatePoweredOn))
+		g.Expect(vm.Guest).ToNot(BeNil())
+		g.Expect(vm.Guest.Net[0].IpAddress).To(ConsistOf("192.168.0.10"))
+
+		_, ok = c.Task(taskRef)
+		g.Expect(ok).To(BeFalse())
+
+		c.reset()
+		_, ok = c.VirtualMachine(vmRef)
+		g.Expect(ok).To(BeFalse())
+	})
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// dryRun is true when the sessions created from now on must not execute the
+// calls changing the vSphere inventory.
+var dryRun bool
+
+// readOnlyMethods are the methods of the SOAP calls which do not change the
+// vSphere inventory, besides the ones starting with readOnlyPrefixes. They
+// include the calls of the property collectors, views and event history
+// collectors, which are created and destroyed to read the inventory and its
+// events, and the placement recommendations of the compute clusters.
+var readOnlyMethods = map[string]bool{
+	"AcquireCloneTicket":           true,
+	"CancelRetrievePropertiesEx":   true,
+	"CancelWaitForUpdates":         true,
+	"CheckForUpdates":              true,
+	"ContinueRetrievePropertiesEx": true,
+	"CreateCollectorForEvents":     true,
+	"CreateContainerView":          true,
+	"CreateFilter":                 true,
+	"CreateListView":               true,
+	"CreatePropertyCollector":      true,
+	"CurrentTime":                  true,
+	"DestroyCollector":             true,
+	"DestroyPropertyCollector":     true,
+	"DestroyPropertyFilter":        true,
+	"DestroyView":                  true,
+	"Login":                        true,
+	"LoginByToken":                 true,
+	"Logout":                       true,
+	"PlaceVm":                      true,
+	"ReadNextEvents":               true,
+	"ReadPreviousEvents":           true,
+	"ResetCollector":               true,
+	"RewindCollector":              true,
+	"SessionIsActive":              true,
+	"SetCollectorPageSize":         true,
+	"WaitForUpdates":               true,
+	"WaitForUpdatesEx":             true,
+}
+
+var readOnlyPrefixes = []string{"Fetch", "Find", "Has", "Query", "Retrieve"}
+
+// SetDryRun prevents the sessions created from now on from executing the calls
+// changing the vSphere inventory, e.g. the clones, deletions and
+// reconfigurations of the VMs. The calls are logged and fail instead, so that
+// the controllers report what they would have done in the conditions of the
+// objects they reconcile. It is meant to be called once, before any session is
+// created.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// isReadOnlyMethod returns true if the SOAP calls of a method do not change
+// the vSphere inventory.
+func isReadOnlyMethod(method string) bool {
+	if readOnlyMethods[method] {
+		return true
+	}
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnlyRequest returns true if a REST call does not change the vSphere
+// inventory: the GET calls, the calls of the session, and the actions listing
+// or getting objects, e.g. list-attached-tags, which are sent as POST calls.
+func isReadOnlyRequest(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	if strings.HasSuffix(req.URL.Path, "/session") {
+		return true
+	}
+	query := req.URL.Query()
+	for _, action := range append(query["~action"], query["action"]...) {
+		if strings.HasPrefix(action, "list") || strings.HasPrefix(action, "get") {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunError returns the error of a call which was not executed.
+func dryRunError(call string) error {
+	return errors.Errorf("dry run: %s was not executed", call)
+}
+
+// dryRunSOAPRoundTripper returns a round tripper failing the SOAP calls of rt
+// which change the vSphere inventory.
+func dryRunSOAPRoundTripper(rt soap.RoundTripper, logger logr.Logger) soap.RoundTripper {
+	return &dryRunRoundTripper{RoundTripper: rt, logger: logger}
+}
+
+type dryRunRoundTripper struct {
+	soap.RoundTripper
+	logger logr.Logger
+}
+
+func (t *dryRunRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+	if isReadOnlyMethod(method) {
+		return t.RoundTripper.RoundTrip(ctx, req, res)
+	}
+	call := method
+	ref := callTarget(req)
+	if ref != nil {
+		call = fmt.Sprintf("%s on %s", method, ref)
+	}
+	t.logger.Info("dry run: not executing vSphere call", "method", method, "object", ref)
+	return dryRunError(call)
+}
+
+// callTarget returns the managed object a SOAP call is made on, i.e. the This
+// field of its request, if any.
+func callTarget(req soap.HasFault) *types.ManagedObjectReference {
+	body := reflect.Indirect(reflect.ValueOf(req))
+	if body.Kind() != reflect.Struct || body.NumField() == 0 {
+		return nil
+	}
+	r := reflect.Indirect(body.Field(0))
+	if r.Kind() != reflect.Struct {
+		return nil
+	}
+	this := r.FieldByName("This")
+	if !this.IsValid() {
+		return nil
+	}
+	ref, ok := this.Interface().(types.ManagedObjectReference)
+	if !ok {
+		return nil
+	}
+	return &ref
+}
+
+// dryRunHTTPRoundTripper returns a round tripper failing the REST calls of rt
+// which change the vSphere inventory.
+func dryRunHTTPRoundTripper(rt http.RoundTripper, logger logr.Logger) http.RoundTripper {
+	return &dryRunHTTPTransport{RoundTripper: rt, logger: logger}
+}
+
+type dryRunHTTPTransport struct {
+	http.RoundTripper
+	logger logr.Logger
+}
+
+func (t *dryRunHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isReadOnlyRequest(req) {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	t.logger.Info("dry run: not executing vSphere call", "method", req.Method, "path", req.URL.Path, "query", req.URL.RawQuery)
+	return nil, dryRunError(fmt.Sprintf("%s %s", req.Method, req.URL.Path))
+}
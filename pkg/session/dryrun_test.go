@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDryRunRoundTripper(t *testing.T) {
+	g := NewWithT(t)
+	inner := &recordingRoundTripper{}
+	rt := dryRunSOAPRoundTripper(inner, logr.Discard())
+	ctx := context.Background()
+
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}
+	err := rt.RoundTrip(ctx, &methods.CloneVM_TaskBody{Req: &types.CloneVM_Task{This: vm}}, &methods.CloneVM_TaskBody{})
+	g.Expect(err).To(MatchError("dry run: CloneVM_Task on VirtualMachine:vm-42 was not executed"))
+	g.Expect(rt.RoundTrip(ctx, &methods.Destroy_TaskBody{}, &methods.Destroy_TaskBody{})).To(MatchError("dry run: Destroy_Task was not executed"))
+	g.Expect(rt.RoundTrip(ctx, &methods.ReconfigVM_TaskBody{}, &methods.ReconfigVM_TaskBody{})).NotTo(Succeed())
+
+	g.Expect(rt.RoundTrip(ctx, &methods.RetrievePropertiesBody{}, &methods.RetrievePropertiesBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.FindByUuidBody{}, &methods.FindByUuidBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.WaitForUpdatesExBody{}, &methods.WaitForUpdatesExBody{})).To(Succeed())
+	g.Expect(inner.calls).To(Equal([]string{"RetrievePropertiesBody", "FindByUuidBody", "WaitForUpdatesExBody"}))
+
+	// The calls of the event history collectors and the placement
+	// recommendations only read the inventory.
+	inner.calls = nil
+	g.Expect(rt.RoundTrip(ctx, &methods.CreateCollectorForEventsBody{}, &methods.CreateCollectorForEventsBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.SetCollectorPageSizeBody{}, &methods.SetCollectorPageSizeBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.ReadPreviousEventsBody{}, &methods.ReadPreviousEventsBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.ReadNextEventsBody{}, &methods.ReadNextEventsBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.DestroyCollectorBody{}, &methods.DestroyCollectorBody{})).To(Succeed())
+	g.Expect(rt.RoundTrip(ctx, &methods.PlaceVmBody{}, &methods.PlaceVmBody{})).To(Succeed())
+	g.Expect(inner.calls).To(Equal([]string{
+		"CreateCollectorForEventsBody", "SetCollectorPageSizeBody", "ReadPreviousEventsBody",
+		"ReadNextEventsBody", "DestroyCollectorBody", "PlaceVmBody",
+	}))
+}
+
+func TestDryRunHTTPRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	client := &http.Client{Transport: dryRunHTTPRoundTripper(http.DefaultTransport, logr.Discard())}
+
+	tests := []struct {
+		method   string
+		path     string
+		executed bool
+	}{
+		{method: http.MethodGet, path: "/rest/vcenter/cluster/modules", executed: true},
+		{method: http.MethodPost, path: "/rest/com/vmware/cis/session", executed: true},
+		{method: http.MethodPost, path: "/rest/com/vmware/cis/tagging/tag-association?~action=list-attached-tags", executed: true},
+		{method: http.MethodPost, path: "/rest/vcenter/cluster/modules"},
+		{method: http.MethodDelete, path: "/rest/vcenter/cluster/modules/module-1"},
+		{method: http.MethodPost, path: "/rest/com/vmware/cis/tagging/tag-association/tag-1?~action=attach"},
+		{method: http.MethodPatch, path: "/rest/com/vmware/cis/tagging/tag/tag-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			g := NewWithT(t)
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader("{}"))
+			g.Expect(err).NotTo(HaveOccurred())
+			res, err := client.Do(req)
+			if !tt.executed {
+				g.Expect(err).To(MatchError(ContainSubstring("dry run")))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+	}
+}
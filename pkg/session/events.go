@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// eventWatcherRestartPeriod is the time to wait before re-establishing
+	// the event history collector after it terminated unexpectedly.
+	eventWatcherRestartPeriod = 30 * time.Second
+
+	// eventWatcherPageSize is the number of events read from the event
+	// history collector at a time.
+	eventWatcherPageSize = 100
+
+	// VMEventReasonTaskCompleted is the reason of the VMEvents emitted when a
+	// task targeting a VM succeeds or fails.
+	VMEventReasonTaskCompleted = "TaskCompleted"
+
+	// VMEventReasonHostConnectionStateChanged is the reason of the VMEvents
+	// emitted for the VMs of an ESXi host when its connection state changes,
+	// e.g. when it stops responding.
+	VMEventReasonHostConnectionStateChanged = "HostConnectionStateChanged"
+)
+
+// watchedVMEventTypes are the vCenter event types that are turned into
+// VMEvents.
+var watchedVMEventTypes = []string{
+	"VmPoweredOffEvent",
+	"VmPoweredOnEvent",
+	"VmSuspendedEvent",
+	"VmMigratedEvent",
+	"DrsVmMigratedEvent",
+	"VmRelocatedEvent",
+	"VmRestartedOnAlternateHostEvent",
+	"VmRemovedEvent",
+}
+
+// VMEvent is a change to a virtual machine observed on a vCenter.
+type VMEvent struct {
+	// Server is the vCenter server of the session the event was observed on.
+	Server string
+
+	// Ref is the reference of the VM.
+	Ref types.ManagedObjectReference
+
+	// Name is the name of the VM.
+	Name string
+
+	// Reason is the vCenter event type, VMEventReasonTaskCompleted or
+	// VMEventReasonHostConnectionStateChanged.
+	Reason string
+}
+
+// VMEventHandler is notified of the VMEvents observed by all the sessions.
+type VMEventHandler func(VMEvent)
+
+var (
+	vmEventHandlersMu sync.RWMutex
+	vmEventHandlers   []VMEventHandler
+)
+
+// RegisterVMEventHandler registers a handler for the VMEvents observed by
+// all the sessions. Sessions only watch the vCenter event stream when at
+// least one handler was registered before they were created.
+func RegisterVMEventHandler(handler VMEventHandler) {
+	vmEventHandlersMu.Lock()
+	defer vmEventHandlersMu.Unlock()
+	vmEventHandlers = append(vmEventHandlers, handler)
+}
+
+func hasVMEventHandlers() bool {
+	vmEventHandlersMu.RLock()
+	defer vmEventHandlersMu.RUnlock()
+	return len(vmEventHandlers) > 0
+}
+
+func notifyVMEvent(e VMEvent) {
+	vmEventHandlersMu.RLock()
+	defer vmEventHandlersMu.RUnlock()
+	for _, handler := range vmEventHandlers {
+		handler(e)
+	}
+}
+
+// eventWatcher turns the VM events of a vCenter into VMEvents.
+type eventWatcher struct {
+	client *vim25.Client
+	server string
+	logger logr.Logger
+	cancel context.CancelFunc
+}
+
+func newEventWatcher(logger logr.Logger, client *vim25.Client, server string) *eventWatcher {
+	return &eventWatcher{
+		client: client,
+		server: server,
+		logger: logger.WithName("event-watcher"),
+	}
+}
+
+// start begins watching the events of the VMs under the given container in a
+// background goroutine. The watch is re-established if it fails until stop is
+// called.
+func (w *eventWatcher) start(container types.ManagedObjectReference) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		w.logger.V(4).Info("starting event history collector", "container", container)
+		err := event.NewManager(w.client).Events(ctx, []types.ManagedObjectReference{container}, eventWatcherPageSize, true, false,
+			func(_ types.ManagedObjectReference, events []types.BaseEvent) error {
+				w.handle(events)
+				return nil
+			}, watchedVMEventTypes...)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error(err, "event history collector terminated", "container", container)
+		}
+	}, eventWatcherRestartPeriod)
+}
+
+// stop terminates the background watch.
+func (w *eventWatcher) stop() {
+	if w == nil || w.cancel == nil {
+		return
+	}
+	w.cancel()
+}
+
+func (w *eventWatcher) handle(events []types.BaseEvent) {
+	for _, e := range events {
+		vm := e.GetEvent().Vm
+		if vm == nil {
+			continue
+		}
+		w.logger.V(6).Info("observed vm event", "vmref", vm.Vm, "name", vm.Name, "event", eventTypeName(e))
+		notifyVMEvent(VMEvent{
+			Server: w.server,
+			Ref:    vm.Vm,
+			Name:   vm.Name,
+			Reason: eventTypeName(e),
+		})
+	}
+}
+
+// eventTypeName returns the vCenter type name of the event, e.g.
+// "VmPoweredOffEvent".
+func eventTypeName(e types.BaseEvent) string {
+	switch e.(type) {
+	case *types.VmPoweredOffEvent:
+		return "VmPoweredOffEvent"
+	case *types.VmPoweredOnEvent:
+		return "VmPoweredOnEvent"
+	case *types.VmSuspendedEvent:
+		return "VmSuspendedEvent"
+	case *types.DrsVmMigratedEvent:
+		return "DrsVmMigratedEvent"
+	case *types.VmMigratedEvent:
+		return "VmMigratedEvent"
+	case *types.VmRelocatedEvent:
+		return "VmRelocatedEvent"
+	case *types.VmRestartedOnAlternateHostEvent:
+		return "VmRestartedOnAlternateHostEvent"
+	case *types.VmRemovedEvent:
+		return "VmRemovedEvent"
+	default:
+		return "VmEvent"
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/klog/v2"
+)
+
+func TestVMEvents(t *testing.T) {
+	g := NewWithT(t)
+
+	var (
+		mu       sync.Mutex
+		received []VMEvent
+	)
+	RegisterVMEventHandler(func(e VMEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, e)
+	})
+	g.Expect(hasVMEventHandlers()).To(BeTrue())
+
+	vmRef := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	vmArg := &types.VmEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "machine-1"},
+		Vm:                  vmRef,
+	}
+
+	// Events observed by the event history collector.
+	w := newEventWatcher(klog.Background(), nil, "vcenter")
+	w.handle([]types.BaseEvent{
+		&types.VmPoweredOffEvent{VmEvent: types.VmEvent{Event: types.Event{Vm: vmArg}}},
+		&types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{}}},
+	})
+	g.Expect(received).To(ConsistOf(VMEvent{Server: "vcenter", Ref: vmRef, Name: "machine-1", Reason: "VmPoweredOffEvent"}))
+
+	// Task completion observed by the property cache.
+	received = nil
+	taskRef := types.ManagedObjectReference{Type: "Task", Value: "task-1"}
+	taskUpdate := func(state types.TaskInfoState) types.ObjectUpdate {
+		return types.ObjectUpdate{
+			Kind: types.ObjectUpdateKindModify,
+			Obj:  taskRef,
+			ChangeSet: []types.PropertyChange{{
+				Name: propTaskInfo,
+				Op:   types.PropertyChangeOpAssign,
+				Val:  types.TaskInfo{State: state, Entity: &vmRef, EntityName: "machine-1"},
+			}},
+		}
+	}
+	c := newPropertyCache(klog.Background(), nil, "vcenter")
+	c.apply([]types.ObjectUpdate{taskUpdate(types.TaskInfoStateRunning)})
+	g.Expect(received).To(BeEmpty())
+	c.apply([]types.ObjectUpdate{taskUpdate(types.TaskInfoStateSuccess)})
+	g.Expect(received).To(ConsistOf(VMEvent{Server: "vcenter", Ref: vmRef, Name: "machine-1", Reason: VMEventReasonTaskCompleted}))
+	c.apply([]types.ObjectUpdate{taskUpdate(types.TaskInfoStateSuccess)})
+	g.Expect(received).To(HaveLen(1))
+
+	// Connection state changes of the host of the VM observed by the
+	// property cache.
+	received = nil
+	hostRef := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+	hostUpdate := func(state types.HostSystemConnectionState) types.ObjectUpdate {
+		return types.ObjectUpdate{
+			Kind: types.ObjectUpdateKindModify,
+			Obj:  hostRef,
+			ChangeSet: []types.PropertyChange{{
+				Name: propConnectionState,
+				Op:   types.PropertyChangeOpAssign,
+				Val:  state,
+			}},
+		}
+	}
+	c.apply([]types.ObjectUpdate{
+		{
+			Kind: types.ObjectUpdateKindEnter,
+			Obj:  vmRef,
+			ChangeSet: []types.PropertyChange{
+				{Name: propName, Op: types.PropertyChangeOpAssign, Val: "machine-1"},
+				{Name: propHost, Op: types.PropertyChangeOpAssign, Val: hostRef},
+			},
+		},
+		hostUpdate(types.HostSystemConnectionStateConnected),
+	})
+	g.Expect(received).To(BeEmpty())
+	c.apply([]types.ObjectUpdate{hostUpdate(types.HostSystemConnectionStateNotResponding)})
+	g.Expect(received).To(ConsistOf(VMEvent{Server: "vcenter", Ref: vmRef, Name: "machine-1", Reason: VMEventReasonHostConnectionStateChanged}))
+	c.apply([]types.ObjectUpdate{hostUpdate(types.HostSystemConnectionStateNotResponding)})
+	g.Expect(received).To(HaveLen(1))
+}
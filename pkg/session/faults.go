@@ -0,0 +1,278 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"sigs.k8s.io/yaml"
+)
+
+// FaultSessionDrop is the fault of a FaultRule which logs the session out of
+// vCenter, as when vCenter expires it, before failing the call as not
+// authenticated.
+const FaultSessionDrop = "SessionDrop"
+
+// faultInjection is the fault injection of the sessions created from now on,
+// if any.
+var faultInjection *FaultInjection
+
+// FaultInjection describes the faults injected into the calls the sessions
+// make to vCenter, so that the resilience of the controllers to a degraded
+// vCenter can be tested. It must never be enabled in production.
+type FaultInjection struct {
+	// Rules are evaluated in order for each call, and the first one matching
+	// the call is applied to it.
+	Rules []FaultRule `json:"rules"`
+
+	// random returns a number in [0.0,1.0) compared to the probability of the
+	// rules.
+	random func() float64
+}
+
+// FaultRule injects latency and faults into some of the calls to vCenter.
+type FaultRule struct {
+	// Methods are the vSphere API methods of the SOAP calls the rule applies
+	// to, e.g. CloneVM_Task.
+	Methods []string `json:"methods,omitempty"`
+
+	// Paths are substrings of the paths of the REST calls the rule applies
+	// to, e.g. /vcenter/cluster/modules for the cluster modules. The rule
+	// applies to all the calls when neither Methods nor Paths is set.
+	Paths []string `json:"paths,omitempty"`
+
+	// Probability is the ratio of the matching calls the rule applies to,
+	// between 0 and 1. Defaults to 1.
+	Probability *float64 `json:"probability,omitempty"`
+
+	// Latency is added to the matching calls, e.g. 2s.
+	Latency string `json:"latency,omitempty"`
+
+	// Fault fails the matching calls instead of sending them. It is either
+	// SessionDrop or the name of a vSphere fault, e.g. TaskInProgress or
+	// HostNotConnected. REST calls fail as unauthorized for SessionDrop, and
+	// as unavailable otherwise.
+	Fault string `json:"fault,omitempty"`
+
+	latency   time.Duration
+	faultType reflect.Type
+}
+
+// LoadFaultInjection reads a FaultInjection from a YAML or JSON file.
+func LoadFaultInjection(path string) (*FaultInjection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read fault injection config %s", path)
+	}
+	f := &FaultInjection{}
+	if err := yaml.UnmarshalStrict(data, f); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse fault injection config %s", path)
+	}
+	if err := f.init(); err != nil {
+		return nil, errors.Wrapf(err, "invalid fault injection config %s", path)
+	}
+	return f, nil
+}
+
+// SetFaultInjection injects the faults of f into the calls of the sessions
+// created from now on. It is meant to be called once, before any session is
+// created.
+func SetFaultInjection(f *FaultInjection) {
+	faultInjection = f
+}
+
+// init validates the rules and parses their latency and fault.
+func (f *FaultInjection) init() error {
+	baseMethodFault := reflect.TypeOf((*types.BaseMethodFault)(nil)).Elem()
+	for i := range f.Rules {
+		r := &f.Rules[i]
+		if r.Probability != nil && (*r.Probability < 0 || *r.Probability > 1) {
+			return errors.Errorf("rules[%d]: probability %v is not between 0 and 1", i, *r.Probability)
+		}
+		if r.Latency != "" {
+			latency, err := time.ParseDuration(r.Latency)
+			if err != nil {
+				return errors.Wrapf(err, "rules[%d]: invalid latency", i)
+			}
+			r.latency = latency
+		}
+		if r.Fault != "" && r.Fault != FaultSessionDrop {
+			t, ok := types.TypeFunc()(r.Fault)
+			if !ok || !reflect.PtrTo(t).Implements(baseMethodFault) {
+				return errors.Errorf("rules[%d]: %s is not a vSphere fault", i, r.Fault)
+			}
+			r.faultType = t
+		}
+	}
+	if f.random == nil {
+		f.random = rand.Float64 //nolint:gosec
+	}
+	return nil
+}
+
+// rule returns the rule applied to a SOAP call of a method or a REST call
+// of a path, if any.
+func (f *FaultInjection) rule(method, path string) *FaultRule {
+	for i := range f.Rules {
+		r := &f.Rules[i]
+		if !r.matches(method, path) {
+			continue
+		}
+		if r.Probability != nil && f.random() >= *r.Probability {
+			continue
+		}
+		return r
+	}
+	return nil
+}
+
+func (r *FaultRule) matches(method, path string) bool {
+	if len(r.Methods) == 0 && len(r.Paths) == 0 {
+		return true
+	}
+	if method != "" {
+		for _, m := range r.Methods {
+			if m == method {
+				return true
+			}
+		}
+	}
+	if path != "" {
+		for _, p := range r.Paths {
+			if strings.Contains(path, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// delay waits for the latency of the rule, or until ctx is done.
+func (r *FaultRule) delay(ctx context.Context) error {
+	if r.latency == 0 {
+		return nil
+	}
+	timer := time.NewTimer(r.latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// soapFault returns the error of a SOAP call failed with the fault of the
+// rule, which is decoded the same way as the faults sent by vCenter.
+func (r *FaultRule) soapFault(method string) error {
+	var fault types.AnyType = types.NotAuthenticated{}
+	if r.faultType != nil {
+		fault = reflect.New(r.faultType).Elem().Interface()
+	}
+	f := &soap.Fault{
+		Code:   "ServerFaultCode",
+		String: fmt.Sprintf("injected %s fault in %s", r.Fault, method),
+	}
+	f.Detail.Fault = fault
+	return soap.WrapSoapFault(f)
+}
+
+// soapRoundTripper returns a round tripper injecting the faults into the SOAP
+// calls of rt. sessionManager is the session manager the session is logged
+// out of for the SessionDrop fault.
+func (f *FaultInjection) soapRoundTripper(rt soap.RoundTripper, sessionManager types.ManagedObjectReference) soap.RoundTripper {
+	return &faultRoundTripper{RoundTripper: rt, faults: f, sessionManager: sessionManager}
+}
+
+type faultRoundTripper struct {
+	soap.RoundTripper
+	faults         *FaultInjection
+	sessionManager types.ManagedObjectReference
+}
+
+func (t *faultRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+	r := t.faults.rule(method, "")
+	if r == nil {
+		return t.RoundTripper.RoundTrip(ctx, req, res)
+	}
+	if err := r.delay(ctx); err != nil {
+		return err
+	}
+	switch r.Fault {
+	case "":
+		return t.RoundTripper.RoundTrip(ctx, req, res)
+	case FaultSessionDrop:
+		// Log out with the wrapped round tripper, so that the logout
+		// itself is not subject to faults.
+		_, _ = methods.Logout(ctx, t.RoundTripper, &types.Logout{This: t.sessionManager})
+	}
+	return r.soapFault(method)
+}
+
+// httpRoundTripper returns a round tripper injecting the faults into the
+// REST calls of rt.
+func (f *FaultInjection) httpRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return &faultHTTPRoundTripper{RoundTripper: rt, faults: f}
+}
+
+type faultHTTPRoundTripper struct {
+	http.RoundTripper
+	faults *FaultInjection
+}
+
+func (t *faultHTTPRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := t.faults.rule("", req.URL.Path)
+	if r == nil {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	if err := r.delay(req.Context()); err != nil {
+		return nil, err
+	}
+	if r.Fault == "" {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	status := http.StatusServiceUnavailable
+	if r.Fault == FaultSessionDrop {
+		status = http.StatusUnauthorized
+	}
+	message := fmt.Sprintf("injected %s fault in %s %s", r.Fault, req.Method, req.URL.Path)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader(message)),
+		ContentLength: int64(len(message)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestLoadFaultInjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "valid config",
+			config: `
+rules:
+- methods: [CloneVM_Task]
+  probability: 0.5
+  latency: 2s
+  fault: TaskInProgress
+- paths: [/vcenter/cluster/modules]
+  fault: SessionDrop
+`,
+		},
+		{
+			name:   "probability over 1",
+			config: "rules:\n- probability: 1.5\n",
+			err:    "probability",
+		},
+		{
+			name:   "invalid latency",
+			config: "rules:\n- latency: soon\n",
+			err:    "invalid latency",
+		},
+		{
+			name:   "unknown fault",
+			config: "rules:\n- fault: VirtualMachine\n",
+			err:    "is not a vSphere fault",
+		},
+		{
+			name:   "unknown field",
+			config: "rules:\n- method: CloneVM_Task\n",
+			err:    "unable to parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			path := filepath.Join(t.TempDir(), "faults.yaml")
+			g.Expect(os.WriteFile(path, []byte(tt.config), 0600)).To(Succeed())
+
+			f, err := LoadFaultInjection(path)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(f.Rules).To(HaveLen(2))
+			g.Expect(f.Rules[0].latency.Seconds()).To(Equal(2.0))
+			g.Expect(f.Rules[0].faultType).To(Equal(reflect.TypeOf(types.TaskInProgress{})))
+		})
+	}
+}
+
+// recordingRoundTripper records the bodies of the SOAP calls it receives.
+type recordingRoundTripper struct {
+	calls []string
+}
+
+func (r *recordingRoundTripper) RoundTrip(_ context.Context, req, _ soap.HasFault) error {
+	r.calls = append(r.calls, reflect.Indirect(reflect.ValueOf(req)).Type().Name())
+	return nil
+}
+
+func TestFaultRoundTripper(t *testing.T) {
+	sessionManager := types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"}
+	newRoundTripper := func(random float64, rules ...FaultRule) (soap.RoundTripper, *recordingRoundTripper) {
+		f := &FaultInjection{Rules: rules, random: func() float64 { return random }}
+		if err := f.init(); err != nil {
+			t.Fatal(err)
+		}
+		inner := &recordingRoundTripper{}
+		return f.soapRoundTripper(inner, sessionManager), inner
+	}
+	probability := func(p float64) *float64 { return &p }
+
+	t.Run("fails the matching calls with the vSphere fault", func(t *testing.T) {
+		g := NewWithT(t)
+		rt, inner := newRoundTripper(0, FaultRule{Methods: []string{"CloneVM_Task"}, Fault: "TaskInProgress"})
+
+		err := rt.RoundTrip(context.Background(), &methods.CloneVM_TaskBody{}, &methods.CloneVM_TaskBody{})
+		g.Expect(soap.IsSoapFault(err)).To(BeTrue())
+		g.Expect(soap.ToSoapFault(err).VimFault()).To(BeAssignableToTypeOf(types.TaskInProgress{}))
+
+		g.Expect(rt.RoundTrip(context.Background(), &methods.PowerOnVM_TaskBody{}, &methods.PowerOnVM_TaskBody{})).To(Succeed())
+		g.Expect(inner.calls).To(Equal([]string{"PowerOnVM_TaskBody"}))
+	})
+
+	t.Run("sends the calls the probability does not hit", func(t *testing.T) {
+		g := NewWithT(t)
+		rt, inner := newRoundTripper(0.6, FaultRule{Probability: probability(0.5), Fault: "TaskInProgress"})
+
+		g.Expect(rt.RoundTrip(context.Background(), &methods.CloneVM_TaskBody{}, &methods.CloneVM_TaskBody{})).To(Succeed())
+		g.Expect(inner.calls).To(Equal([]string{"CloneVM_TaskBody"}))
+	})
+
+	t.Run("logs the session out for a session drop", func(t *testing.T) {
+		g := NewWithT(t)
+		rt, inner := newRoundTripper(0, FaultRule{Methods: []string{"RetrieveProperties"}, Fault: FaultSessionDrop})
+
+		err := rt.RoundTrip(context.Background(), &methods.RetrievePropertiesBody{}, &methods.RetrievePropertiesBody{})
+		g.Expect(soap.IsSoapFault(err)).To(BeTrue())
+		g.Expect(soap.ToSoapFault(err).VimFault()).To(BeAssignableToTypeOf(types.NotAuthenticated{}))
+		g.Expect(inner.calls).To(Equal([]string{"LogoutBody"}))
+	})
+
+	t.Run("gives up the latency when the context is done", func(t *testing.T) {
+		g := NewWithT(t)
+		rt, inner := newRoundTripper(0, FaultRule{Latency: "1h"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := rt.RoundTrip(ctx, &methods.CloneVM_TaskBody{}, &methods.CloneVM_TaskBody{})
+		g.Expect(err).To(MatchError(context.Canceled))
+		g.Expect(inner.calls).To(BeEmpty())
+	})
+}
+
+func TestFaultHTTPRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	f := &FaultInjection{Rules: []FaultRule{
+		{Paths: []string{"/vcenter/cluster/modules"}, Fault: "ResourceInUse"},
+		{Paths: []string{"/session"}, Fault: FaultSessionDrop},
+	}}
+	if err := f.init(); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: f.httpRoundTripper(http.DefaultTransport)}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/rest/vcenter/cluster/modules", status: http.StatusServiceUnavailable},
+		{path: "/rest/com/vmware/cis/session", status: http.StatusUnauthorized},
+		{path: "/rest/com/vmware/cis/tagging/tag", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			g := NewWithT(t)
+			res, err := client.Get(server.URL + tt.path)
+			g.Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			g.Expect(res.StatusCode).To(Equal(tt.status))
+			if tt.status != http.StatusOK {
+				body, err := io.ReadAll(res.Body)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(string(body)).To(ContainSubstring("injected"))
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/soap"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+// fencing is the fencing of the unreachable vCenter servers, if enabled.
+var fencing *fence
+
+// SetFencing fences the vCenter servers which failed to respond to threshold
+// consecutive calls, e.g. because they are down or the connections to them
+// time out. GetOrCreate fails with an UnavailableError for a fenced server
+// instead of connecting to it, so that the controllers skip the objects on
+// that server rather than spending their workers waiting for it. A fenced
+// server is probed again by the first GetOrCreate after duration, and is no
+// longer fenced once it responds. It is meant to be called once, before any
+// session is created, and a threshold of zero disables the fencing.
+func SetFencing(logger logr.Logger, threshold int, duration time.Duration) {
+	if threshold <= 0 {
+		fencing = nil
+		return
+	}
+	fencing = &fence{
+		logger:    logger,
+		threshold: threshold,
+		duration:  duration,
+		now:       time.Now,
+		servers:   map[string]*serverHealth{},
+	}
+}
+
+// UnavailableError is the error of GetOrCreate for a fenced vCenter server.
+type UnavailableError struct {
+	// Server is the fenced vCenter server.
+	Server string
+
+	// RetryAfter is the time after which the server is probed again.
+	RetryAfter time.Duration
+
+	// Err is the last error of the calls to the server.
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("vCenter %s is fenced as unreachable, retrying in %s: %v", e.Server, e.RetryAfter.Round(time.Second), e.Err)
+}
+
+type fence struct {
+	logger    logr.Logger
+	threshold int
+	duration  time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	servers map[string]*serverHealth
+}
+
+// serverHealth is the health of a vCenter server which failed to respond to
+// its last calls.
+type serverHealth struct {
+	// failures is the number of consecutive calls the server failed to
+	// respond to.
+	failures int
+
+	// err is the error of the last of these calls.
+	err error
+
+	// until is the time the server is fenced until, once failures reached
+	// the threshold.
+	until time.Time
+}
+
+// check returns an UnavailableError if server is fenced. Once the fence
+// duration elapsed, it lets a single caller through to probe the server,
+// and fences it again for the others.
+func (f *fence) check(server string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h, ok := f.servers[server]
+	if !ok || h.failures < f.threshold {
+		return nil
+	}
+	now := f.now()
+	if now.Before(h.until) {
+		return &UnavailableError{Server: server, RetryAfter: h.until.Sub(now), Err: h.err}
+	}
+	h.until = now.Add(f.duration)
+	return nil
+}
+
+// record records the outcome of a call to server. The calls failing with a
+// fault responded, while the ones failing for other reasons, e.g. an invalid
+// certificate or a canceled context, say nothing of its health.
+func (f *fence) record(server string, err error) {
+	switch {
+	case err == nil || soap.IsSoapFault(err) || soap.IsVimFault(err):
+		f.succeed(server)
+	case isUnreachable(err):
+		f.fail(server, err)
+	}
+}
+
+// succeed records a call server responded to.
+func (f *fence) succeed(server string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h, ok := f.servers[server]
+	if !ok {
+		return
+	}
+	if h.failures >= f.threshold {
+		f.logger.Info("vCenter responded, no longer fencing it", "server", server)
+		metrics.SetVCenterFenced(server, false)
+	}
+	delete(f.servers, server)
+}
+
+// fail records a call server failed to respond to.
+func (f *fence) fail(server string, err error) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h, ok := f.servers[server]
+	if !ok {
+		h = &serverHealth{}
+		f.servers[server] = h
+	}
+	h.failures++
+	h.err = err
+	if h.failures < f.threshold {
+		return
+	}
+	if h.failures == f.threshold {
+		f.logger.Error(err, "vCenter failed to respond, fencing it", "server", server, "failures", h.failures, "duration", f.duration)
+		metrics.SetVCenterFenced(server, true)
+	}
+	h.until = f.now().Add(f.duration)
+}
+
+// isUnreachable returns true if err is the error of a call which did not get
+// a response from the server.
+func isUnreachable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// soapRoundTripper returns a round tripper recording the outcome of the SOAP
+// calls of rt to server.
+func (f *fence) soapRoundTripper(rt soap.RoundTripper, server string) soap.RoundTripper {
+	return &fenceRoundTripper{RoundTripper: rt, fence: f, server: server}
+}
+
+type fenceRoundTripper struct {
+	soap.RoundTripper
+	fence  *fence
+	server string
+}
+
+func (t *fenceRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	err := t.RoundTripper.RoundTrip(ctx, req, res)
+	t.fence.record(t.server, err)
+	return err
+}
+
+// httpRoundTripper returns a round tripper recording the outcome of the REST
+// calls of rt to server. The calls failing as unavailable, e.g. while the
+// services of vCenter restart, did not get a response from vCenter.
+func (f *fence) httpRoundTripper(rt http.RoundTripper, server string) http.RoundTripper {
+	return &fenceHTTPTransport{RoundTripper: rt, fence: f, server: server}
+}
+
+type fenceHTTPTransport struct {
+	http.RoundTripper
+	fence  *fence
+	server string
+}
+
+func (t *fenceHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.fence.record(t.server, err)
+	case res.StatusCode == http.StatusBadGateway || res.StatusCode == http.StatusServiceUnavailable || res.StatusCode == http.StatusGatewayTimeout:
+		t.fence.fail(t.server, errors.New(res.Status))
+	default:
+		t.fence.succeed(t.server)
+	}
+	return res, err
+}
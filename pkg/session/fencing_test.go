@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// failingRoundTripper fails the SOAP calls it receives with err.
+type failingRoundTripper struct {
+	err error
+}
+
+func (r *failingRoundTripper) RoundTrip(_ context.Context, _, _ soap.HasFault) error {
+	return r.err
+}
+
+func TestFence(t *testing.T) {
+	g := NewWithT(t)
+	SetFencing(logr.Discard(), 2, time.Minute)
+	defer SetFencing(logr.Discard(), 0, 0)
+	now := time.Now()
+	fencing.now = func() time.Time { return now }
+
+	inner := &failingRoundTripper{}
+	rt := fencing.soapRoundTripper(inner, "vcenter.example.com")
+	call := func() error {
+		return rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{})
+	}
+	unreachable := &url.Error{Op: "Post", URL: "https://vcenter.example.com/sdk", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	// A single failure does not fence the server.
+	inner.err = unreachable
+	g.Expect(call()).To(HaveOccurred())
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+
+	// A fault resets the failures, as the server responded.
+	inner.err = soap.WrapVimFault(&types.NotFound{})
+	g.Expect(call()).To(HaveOccurred())
+	inner.err = unreachable
+	g.Expect(call()).To(HaveOccurred())
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+
+	// Errors saying nothing of the server are ignored.
+	inner.err = context.Canceled
+	g.Expect(call()).To(HaveOccurred())
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+
+	inner.err = unreachable
+	g.Expect(call()).To(HaveOccurred())
+	err := fencing.check("vcenter.example.com")
+	var unavailable *UnavailableError
+	g.Expect(errors.As(err, &unavailable)).To(BeTrue())
+	g.Expect(unavailable.Server).To(Equal("vcenter.example.com"))
+	g.Expect(unavailable.RetryAfter).To(Equal(time.Minute))
+	g.Expect(unavailable.Err).To(Equal(unreachable))
+	g.Expect(fencing.check("other.example.com")).To(Succeed())
+
+	now = now.Add(20 * time.Second)
+	g.Expect(fencing.check("vcenter.example.com")).To(MatchError(ContainSubstring("retrying in 40s")))
+
+	// A single probe goes through once the fence duration elapsed, and a
+	// failed probe fences the server again.
+	now = now.Add(time.Minute)
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+	g.Expect(fencing.check("vcenter.example.com")).NotTo(Succeed())
+	g.Expect(call()).To(HaveOccurred())
+	now = now.Add(30 * time.Second)
+	g.Expect(fencing.check("vcenter.example.com")).NotTo(Succeed())
+
+	// A successful probe lifts the fence.
+	now = now.Add(time.Minute)
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+	inner.err = nil
+	g.Expect(call()).To(Succeed())
+	g.Expect(fencing.check("vcenter.example.com")).To(Succeed())
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: &url.Error{Op: "Post", URL: "https://vcenter", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: true},
+		{name: "deadline exceeded", err: errors.Wrap(context.DeadlineExceeded, "timed out"), want: true},
+		{name: "connection closed", err: &url.Error{Op: "Post", URL: "https://vcenter", Err: io.EOF}, want: true},
+		{name: "context canceled", err: context.Canceled},
+		{name: "invalid certificate", err: &url.Error{Op: "Post", URL: "https://vcenter", Err: errors.New("x509: certificate signed by unknown authority")}},
+		{name: "vim fault", err: soap.WrapVimFault(&types.NotAuthenticated{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(isUnreachable(tt.err)).To(Equal(tt.want))
+		})
+	}
+}
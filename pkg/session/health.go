@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/metrics"
+)
+
+const (
+	// healthCheckTimeout is the time a session has to respond to a health
+	// check.
+	healthCheckTimeout = 10 * time.Second
+
+	// healthCheckMaxIdle is the time after which the identities no session
+	// was requested for, e.g. the ones of deleted clusters, are no longer
+	// checked.
+	healthCheckMaxIdle = time.Hour
+
+	// healthCheckLivenessFailures is the number of consecutive checks no
+	// session is alive in after which the liveness check fails.
+	healthCheckLivenessFailures = 3
+)
+
+// sessionIdentity is a user of a vCenter server sessions are requested for.
+type sessionIdentity struct {
+	server, username string
+}
+
+// identities are the times sessions were last requested for each identity.
+var identities sync.Map
+
+// HealthChecker checks periodically that a session is alive for each of the
+// identities sessions were requested for, so that the manager reports the
+// vCenters it can no longer log in to, or whose sessions no longer respond.
+type HealthChecker struct {
+	logger   logr.Logger
+	interval time.Duration
+	liveness bool
+
+	mu       sync.Mutex
+	err      error
+	failures int
+}
+
+// NewHealthChecker returns a HealthChecker checking the sessions every
+// interval. The failed checks are logged and reported by the
+// capv_vsphere_session_alive metric. When liveness is true, the checks in
+// which no session at all is alive also fail the Ready check of the
+// HealthChecker, and its Check once they failed healthCheckLivenessFailures
+// times in a row. A single vCenter which is down does not fail them, as
+// restarting the manager does not help then.
+func NewHealthChecker(logger logr.Logger, interval time.Duration, liveness bool) *HealthChecker {
+	return &HealthChecker{logger: logger, interval: interval, liveness: liveness}
+}
+
+// Start checks the sessions every interval until ctx is done.
+func (h *HealthChecker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, h.check, h.interval)
+	return nil
+}
+
+// NeedLeaderElection returns false, so that all the replicas check their
+// sessions. The replicas which are not elected have none.
+func (h *HealthChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// Check is the healthz.Checker of the sessions, which returns the error of
+// the last check in liveness mode, once no session was alive in
+// healthCheckLivenessFailures checks in a row.
+func (h *HealthChecker) Check(_ *http.Request) error {
+	if !h.liveness {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures < healthCheckLivenessFailures {
+		return nil
+	}
+	return h.err
+}
+
+// Ready is the readyz.Checker of the sessions, which returns the error of the
+// last check in liveness mode, when no session was alive in it.
+func (h *HealthChecker) Ready(_ *http.Request) error {
+	if !h.liveness {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *HealthChecker) check(ctx context.Context) {
+	var errs []error
+	checked := 0
+	now := time.Now()
+	identities.Range(func(key, value interface{}) bool {
+		id := key.(sessionIdentity)
+		if now.Sub(value.(time.Time)) > healthCheckMaxIdle {
+			identities.Delete(key)
+			metrics.ForgetVSphereSessionAlive(id.server, id.username)
+			return true
+		}
+		checked++
+		alive := isAlive(ctx, id)
+		metrics.SetVSphereSessionAlive(id.server, id.username, alive)
+		if !alive {
+			errs = append(errs, errors.Errorf("no session of %s on vCenter %s is alive", id.username, id.server))
+		}
+		return true
+	})
+
+	err := kerrors.NewAggregate(errs)
+	if err != nil {
+		h.logger.Error(err, "vCenter health check failed")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if checked == 0 || len(errs) < checked {
+		h.err = nil
+		h.failures = 0
+		return
+	}
+	h.err = errors.Wrap(err, "no session of any vCenter is alive")
+	h.failures++
+}
+
+// isAlive returns true if one of the cached sessions of an identity, in any
+// datacenter, is active.
+func isAlive(ctx context.Context, id sessionIdentity) bool {
+	alive := false
+	sessionCache.Range(func(_, value interface{}) bool {
+		s := value.(*Session)
+		if s.server != id.server || s.Username() != id.username {
+			return true
+		}
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		active, err := s.SessionManager.SessionIsActive(ctx)
+		alive = err == nil && active
+		return !alive
+	})
+	return alive
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestHealthChecker(t *testing.T) {
+	g := NewWithT(t)
+	// Forget the identities of the sessions of the other tests.
+	identities.Range(func(key, _ interface{}) bool {
+		identities.Delete(key)
+		return true
+	})
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	if err != nil {
+		t.Fatalf("failed to create VC simulator")
+	}
+	defer simr.Destroy()
+
+	params := NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password())
+	s, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).NotTo(HaveOccurred())
+	id := sessionIdentity{server: simr.ServerURL().Host, username: simr.Username()}
+
+	liveness := NewHealthChecker(logr.Discard(), time.Minute, true)
+	report := NewHealthChecker(logr.Discard(), time.Minute, false)
+	liveness.check(context.Background())
+	g.Expect(liveness.Check(nil)).To(Succeed())
+	g.Expect(liveness.Ready(nil)).To(Succeed())
+
+	// A vCenter which is down does not fail the checks while the sessions of
+	// the other vCenters are alive.
+	down := sessionIdentity{server: "vcenter.example.com", username: "nobody"}
+	identities.Store(down, time.Now())
+	liveness.check(context.Background())
+	g.Expect(liveness.Check(nil)).To(Succeed())
+	g.Expect(liveness.Ready(nil)).To(Succeed())
+
+	// The checks fail once the session of the other identity is dropped by
+	// vCenter too: the readiness check right away, and the liveness check
+	// once no session was alive in several checks in a row.
+	sessionInfo, err := s.SessionManager.UserSession(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.TagManager.Logout(context.Background())).To(Succeed())
+	g.Expect(simr.Run(fmt.Sprintf("session.rm %s", sessionInfo.Key))).To(Succeed())
+
+	for i := 1; i < healthCheckLivenessFailures; i++ {
+		liveness.check(context.Background())
+		g.Expect(liveness.Ready(nil)).To(MatchError(ContainSubstring("no session of %s on vCenter %s is alive", id.username, id.server)))
+		g.Expect(liveness.Check(nil)).To(Succeed())
+	}
+	liveness.check(context.Background())
+	g.Expect(liveness.Check(nil)).To(MatchError(ContainSubstring("no session of %s on vCenter %s is alive", id.username, id.server)))
+	report.check(context.Background())
+	g.Expect(report.Check(nil)).To(Succeed())
+	g.Expect(report.Ready(nil)).To(Succeed())
+
+	// The identities no session was requested for in a while are forgotten.
+	identities.Store(id, time.Now().Add(-2*healthCheckMaxIdle))
+	identities.Store(down, time.Now().Add(-2*healthCheckMaxIdle))
+	liveness.check(context.Background())
+	g.Expect(liveness.Check(nil)).To(Succeed())
+	g.Expect(liveness.Ready(nil)).To(Succeed())
+	_, ok := identities.Load(id)
+	g.Expect(ok).To(BeFalse())
+}
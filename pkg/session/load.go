@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const (
+	// latencyWeight is the weight of the latest call in the moving average
+	// of the latency of the calls to a server.
+	latencyWeight = 0.2
+
+	// targetLatency and targetActiveTasks are the latency and the number
+	// of active tasks of a server the requeue periods are not scaled for.
+	targetLatency     = 250 * time.Millisecond
+	targetActiveTasks = 16
+
+	// minLoadFactor and maxLoadFactor bound the factor the requeue periods
+	// are scaled by.
+	minLoadFactor = 0.5
+	maxLoadFactor = 6.0
+)
+
+// latencies are the moving averages of the latency of the calls to each
+// server.
+var latencies sync.Map
+
+// Load is the load of a vCenter server, as measured by the sessions to it.
+type Load struct {
+	// Latency is the moving average of the latency of the calls to the
+	// server.
+	Latency time.Duration
+
+	// ActiveTasks is the number of recent tasks of the server which are
+	// queued or running.
+	ActiveTasks int
+}
+
+// ServerLoad returns the load of a vCenter server. It is zero for the servers
+// no session was created for.
+func ServerLoad(server string) Load {
+	var load Load
+	if value, ok := latencies.Load(server); ok {
+		load.Latency = value.(*movingAverage).get()
+	}
+	sessionCache.Range(func(_, value interface{}) bool {
+		s := value.(*Session)
+		if s.server != server {
+			return true
+		}
+		// The sessions of the server see the same recent tasks, unless
+		// their users see different parts of the inventory.
+		if tasks, ok := s.propertyCache.ActiveTasks(); ok && tasks > load.ActiveTasks {
+			load.ActiveTasks = tasks
+		}
+		return true
+	})
+	return load
+}
+
+// Scale scales the period an object waiting for a server is requeued after by
+// the load of the server: it is shortened down to half of it for an idle
+// server, and lengthened up to six times for a server whose calls are slow or
+// which runs many tasks, so that the busy servers are not polled as often as
+// the idle ones. The period is not scaled for a server whose load is unknown.
+func (l Load) Scale(period time.Duration) time.Duration {
+	if l == (Load{}) {
+		return period
+	}
+	factor := math.Max(float64(l.Latency)/float64(targetLatency), float64(l.ActiveTasks)/targetActiveTasks)
+	factor = math.Min(math.Max(factor, minLoadFactor), maxLoadFactor)
+	return time.Duration(factor * float64(period))
+}
+
+// movingAverage is an exponentially weighted moving average of durations.
+type movingAverage struct {
+	mu    sync.Mutex
+	value time.Duration
+}
+
+func (a *movingAverage) observe(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.value == 0 {
+		a.value = d
+		return
+	}
+	a.value = time.Duration(latencyWeight*float64(d) + (1-latencyWeight)*float64(a.value))
+}
+
+func (a *movingAverage) get() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.value
+}
+
+// latencySOAPRoundTripper returns a round tripper measuring the latency of the
+// SOAP calls of rt to server which got a response. The calls waiting for
+// updates, which block until the inventory changes, are not measured.
+func latencySOAPRoundTripper(rt soap.RoundTripper, server string) soap.RoundTripper {
+	value, _ := latencies.LoadOrStore(server, &movingAverage{})
+	return &latencyRoundTripper{RoundTripper: rt, latency: value.(*movingAverage)}
+}
+
+type latencyRoundTripper struct {
+	soap.RoundTripper
+	latency *movingAverage
+}
+
+func (t *latencyRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+	if strings.HasPrefix(method, "WaitFor") {
+		return t.RoundTripper.RoundTrip(ctx, req, res)
+	}
+	start := time.Now()
+	err := t.RoundTripper.RoundTrip(ctx, req, res)
+	if err == nil || soap.IsSoapFault(err) || soap.IsVimFault(err) {
+		t.latency.observe(time.Since(start))
+	}
+	return err
+}
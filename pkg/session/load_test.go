@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestLoadScale(t *testing.T) {
+	tests := []struct {
+		name string
+		load Load
+		want time.Duration
+	}{
+		{name: "unknown load", load: Load{}, want: 10 * time.Second},
+		{name: "idle server", load: Load{Latency: 20 * time.Millisecond}, want: 5 * time.Second},
+		{name: "nominal latency", load: Load{Latency: targetLatency}, want: 10 * time.Second},
+		{name: "slow calls", load: Load{Latency: 3 * targetLatency, ActiveTasks: 4}, want: 30 * time.Second},
+		{name: "many tasks", load: Load{Latency: 50 * time.Millisecond, ActiveTasks: 2 * targetActiveTasks}, want: 20 * time.Second},
+		{name: "overloaded server", load: Load{Latency: time.Minute, ActiveTasks: 1000}, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tt.load.Scale(10 * time.Second)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestLatencyRoundTripper(t *testing.T) {
+	g := NewWithT(t)
+	defer latencies.Delete("vcenter.example.com")
+
+	inner := &recordingRoundTripper{}
+	rt := latencySOAPRoundTripper(inner, "vcenter.example.com")
+	g.Expect(ServerLoad("vcenter.example.com")).To(Equal(Load{}))
+
+	// The calls waiting for updates are not measured.
+	g.Expect(rt.RoundTrip(context.Background(), &methods.WaitForUpdatesExBody{}, &methods.WaitForUpdatesExBody{})).To(Succeed())
+	g.Expect(ServerLoad("vcenter.example.com")).To(Equal(Load{}))
+
+	g.Expect(rt.RoundTrip(context.Background(), &methods.RetrievePropertiesBody{Req: &types.RetrieveProperties{}}, &methods.RetrievePropertiesBody{})).To(Succeed())
+	g.Expect(ServerLoad("vcenter.example.com").Latency).To(BeNumerically(">", 0))
+	g.Expect(inner.calls).To(Equal([]string{"WaitForUpdatesExBody", "RetrievePropertiesBody"}))
+}
+
+func TestMovingAverage(t *testing.T) {
+	g := NewWithT(t)
+	a := &movingAverage{}
+	a.observe(time.Second)
+	g.Expect(a.get()).To(Equal(time.Second))
+	a.observe(2 * time.Second)
+	g.Expect(a.get()).To(Equal(1200 * time.Millisecond))
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// lookupCacheTTL is the time the results of the lookups of the inventory
+	// are reused for, which spans the reconciles of the VSphereVMs created at
+	// once when a MachineDeployment scales up.
+	lookupCacheTTL = 30 * time.Second
+
+	// lookupTimeout bounds the lookups of the inventory, which are run under a
+	// context detached from the reconcile which started them, as the
+	// reconciles of the other VMs of the scope wait for them.
+	lookupTimeout = time.Minute
+)
+
+// LookupCache shares the results of the lookups of the inventory, e.g. of the
+// datacenter, folder, resource pool, datastore and networks of the VMs, among
+// the VMs of a cluster, so that they are resolved once per cluster rather
+// than once per VM when many VMs are cloned at once. The concurrent lookups of
+// the same object wait for the first one, and the failed lookups are not
+// cached. The lookups are not cancelled with the context of the caller which
+// started them, so that they do not fail for the callers waiting for them.
+type LookupCache struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[lookupKey]*lookupEntry
+}
+
+// lookupKey identifies a lookup of an object of a kind at a path, made for the
+// VMs of a scope, e.g. a cluster.
+type lookupKey struct {
+	scope, kind, path string
+}
+
+type lookupEntry struct {
+	done    chan struct{}
+	obj     interface{}
+	err     error
+	expires time.Time
+}
+
+func newLookupCache() *LookupCache {
+	return &LookupCache{now: time.Now, entries: map[lookupKey]*lookupEntry{}}
+}
+
+// Lookups returns the cache of the lookups of the inventory of the session.
+// The returned value may be nil, in which case nothing is cached.
+func (s *Session) Lookups() *LookupCache {
+	return s.lookups
+}
+
+// Get returns the object of a kind at a path for the VMs of a scope, which is
+// looked up with lookup unless it was looked up for the scope in the last
+// lookupCacheTTL. The lookup is run under a context detached from ctx, which
+// only bounds the time the caller waits for it.
+func (c *LookupCache) Get(ctx context.Context, scope, kind, path string, lookup func(context.Context) (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return lookup(ctx)
+	}
+	key := lookupKey{scope: scope, kind: kind, path: path}
+
+	c.mu.Lock()
+	now := c.now()
+	for k, e := range c.entries {
+		if isDone(e) && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &lookupEntry{done: make(chan struct{})}
+		c.entries[key] = e
+		go c.lookup(key, e, lookup)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-e.done:
+		return e.obj, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lookup runs the lookup of an entry under a detached context, then records
+// its result.
+func (c *LookupCache) lookup(key lookupKey, e *lookupEntry, lookup func(context.Context) (interface{}, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	obj, err := lookup(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.obj, e.err = obj, err
+	e.expires = c.now().Add(lookupCacheTTL)
+	if e.err != nil {
+		delete(c.entries, key)
+	}
+	close(e.done)
+}
+
+func isDone(e *lookupEntry) bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func TestLookupCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	c := newLookupCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var lookups int32
+	lookup := func(obj string, err error) func(context.Context) (interface{}, error) {
+		return func(context.Context) (interface{}, error) {
+			atomic.AddInt32(&lookups, 1)
+			return obj, err
+		}
+	}
+
+	// The lookups are shared among the VMs of a scope.
+	g.Expect(c.Get(ctx, "default/prod", "Datastore", "ds-1", lookup("datastore-1", nil))).To(Equal("datastore-1"))
+	g.Expect(c.Get(ctx, "default/prod", "Datastore", "ds-1", lookup("datastore-2", nil))).To(Equal("datastore-1"))
+	g.Expect(lookups).To(BeEquivalentTo(1))
+	g.Expect(c.Get(ctx, "default/prod", "Network", "ds-1", lookup("network-1", nil))).To(Equal("network-1"))
+	g.Expect(c.Get(ctx, "default/test", "Datastore", "ds-1", lookup("datastore-3", nil))).To(Equal("datastore-3"))
+	g.Expect(lookups).To(BeEquivalentTo(3))
+
+	// The failed lookups are not cached.
+	_, err := c.Get(ctx, "default/prod", "Folder", "vms", lookup("", errors.New("folder 'vms' not found")))
+	g.Expect(err).To(MatchError("folder 'vms' not found"))
+	g.Expect(c.Get(ctx, "default/prod", "Folder", "vms", lookup("folder-1", nil))).To(Equal("folder-1"))
+	g.Expect(lookups).To(BeEquivalentTo(5))
+
+	// The lookups expire.
+	now = now.Add(lookupCacheTTL + time.Second)
+	g.Expect(c.Get(ctx, "default/prod", "Datastore", "ds-1", lookup("datastore-2", nil))).To(Equal("datastore-2"))
+	g.Expect(lookups).To(BeEquivalentTo(6))
+
+	// A nil cache caches nothing.
+	var nilCache *LookupCache
+	g.Expect(nilCache.Get(ctx, "default/prod", "Datastore", "ds-1", lookup("datastore-4", nil))).To(Equal("datastore-4"))
+}
+
+func TestLookupCacheConcurrentLookups(t *testing.T) {
+	g := NewWithT(t)
+	c := newLookupCache()
+
+	var lookups int32
+	release := make(chan struct{})
+	lookup := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&lookups, 1)
+		select {
+		case <-release:
+			return "datastore-1", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The caller which started the lookup gives up, which does not fail the
+	// lookup for the other callers.
+	canceled, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.Get(canceled, "default/prod", "Datastore", "ds-1", lookup)
+		errs <- err
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&lookups) }).Should(BeEquivalentTo(1))
+	cancel()
+	g.Expect(<-errs).To(MatchError(context.Canceled))
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.Get(ctx, "default/prod", "Datastore", "ds-1", lookup)
+		}(i)
+	}
+	g.Eventually(func() int32 { return atomic.LoadInt32(&lookups) }).Should(BeEquivalentTo(1))
+	close(release)
+	wg.Wait()
+
+	g.Expect(lookups).To(BeEquivalentTo(1))
+	for _, r := range results {
+		g.Expect(r).To(Equal("datastore-1"))
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ObjectNotFoundHandler is notified of the managed objects the calls of all
+// the sessions to server failed to find, e.g. because they were deleted.
+type ObjectNotFoundHandler func(server string, ref types.ManagedObjectReference)
+
+var (
+	objectNotFoundHandlersMu sync.RWMutex
+	objectNotFoundHandlers   []ObjectNotFoundHandler
+)
+
+// RegisterObjectNotFoundHandler registers a handler for the managed objects
+// the calls of all the sessions failed to find. Sessions only notify the
+// handlers registered before they were created.
+func RegisterObjectNotFoundHandler(handler ObjectNotFoundHandler) {
+	objectNotFoundHandlersMu.Lock()
+	defer objectNotFoundHandlersMu.Unlock()
+	objectNotFoundHandlers = append(objectNotFoundHandlers, handler)
+}
+
+func hasObjectNotFoundHandlers() bool {
+	objectNotFoundHandlersMu.RLock()
+	defer objectNotFoundHandlersMu.RUnlock()
+	return len(objectNotFoundHandlers) > 0
+}
+
+func notifyObjectNotFound(server string, ref types.ManagedObjectReference) {
+	objectNotFoundHandlersMu.RLock()
+	defer objectNotFoundHandlersMu.RUnlock()
+	for _, handler := range objectNotFoundHandlers {
+		handler(server, ref)
+	}
+}
+
+// notFoundSOAPRoundTripper returns a round tripper notifying the handlers of
+// the managed objects the SOAP calls of rt to server failed to find.
+func notFoundSOAPRoundTripper(rt soap.RoundTripper, server string) soap.RoundTripper {
+	return &notFoundRoundTripper{RoundTripper: rt, server: server}
+}
+
+type notFoundRoundTripper struct {
+	soap.RoundTripper
+	server string
+}
+
+func (t *notFoundRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	err := t.RoundTripper.RoundTrip(ctx, req, res)
+	if err != nil && soap.IsSoapFault(err) {
+		if fault, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound); ok {
+			notifyObjectNotFound(t.server, fault.Obj)
+		}
+	}
+	return err
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package session
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
 	"net/url"
 	"sync"
 	"time"
@@ -30,16 +32,22 @@ import (
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/session/keepalive"
+	"github.com/vmware/govmomi/sts"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 )
 
+// tokenRefreshMargin is the time before the expiry of its token a session
+// logged in with a token is recycled, so that it logs in with a new token.
+const tokenRefreshMargin = 5 * time.Minute
+
 // global Session map against sessionKeys
 // in map[sessionKey]Session.
 var sessionCache sync.Map
@@ -50,6 +58,16 @@ type Session struct {
 	Finder     *find.Finder
 	datacenter *object.Datacenter
 	TagManager *tags.Manager
+
+	server        string
+	userinfo      *url.Userinfo
+	thumbprint    string
+	caBundle      []byte
+	tokenProvider TokenProvider
+	token         *Token
+	propertyCache *PropertyCache
+	eventWatcher  *eventWatcher
+	lookups       *LookupCache
 }
 
 type Feature struct {
@@ -61,11 +79,13 @@ func DefaultFeature() Feature {
 }
 
 type Params struct {
-	server     string
-	datacenter string
-	userinfo   *url.Userinfo
-	thumbprint string
-	feature    Feature
+	server        string
+	datacenter    string
+	userinfo      *url.Userinfo
+	thumbprint    string
+	caBundle      []byte
+	tokenProvider TokenProvider
+	feature       Feature
 }
 
 func NewParams() *Params {
@@ -94,6 +114,29 @@ func (p *Params) WithThumbprint(thumbprint string) *Params {
 	return p
 }
 
+// WithCABundle sets the PEM-encoded certificates of the certificate
+// authorities the certificate of the server is verified with, before falling
+// back to the thumbprint.
+func (p *Params) WithCABundle(caBundle []byte) *Params {
+	p.caBundle = caBundle
+	return p
+}
+
+// WithTokenProvider sets the provider of the SAML tokens the session logs in
+// with, in place of the user info.
+func (p *Params) WithTokenProvider(tokenProvider TokenProvider) *Params {
+	p.tokenProvider = tokenProvider
+	return p
+}
+
+// username returns the name of the principal of the session.
+func (p *Params) username() string {
+	if p.tokenProvider != nil {
+		return p.tokenProvider.Name()
+	}
+	return p.userinfo.Username()
+}
+
 func (p *Params) WithFeatures(feature Feature) *Params {
 	p.feature = feature
 	return p
@@ -103,8 +146,14 @@ func (p *Params) WithFeatures(feature Feature) *Params {
 // already exist.
 func GetOrCreate(ctx context.Context, params *Params) (*Session, error) {
 	logger := ctrl.LoggerFrom(ctx).WithName("session")
+	identities.Store(sessionIdentity{server: params.server, username: params.username()}, time.Now())
+
+	// A fenced server is not waited for until it is probed again.
+	if err := fencing.check(params.server); err != nil {
+		return nil, err
+	}
 
-	sessionKey := params.server + params.userinfo.Username() + params.datacenter
+	sessionKey := params.server + params.username() + params.datacenter
 	if cachedSession, ok := sessionCache.Load(sessionKey); ok {
 		s := cachedSession.(*Session)
 		logger = logger.WithValues("server", params.server, "datacenter", params.datacenter)
@@ -119,7 +168,20 @@ func GetOrCreate(ctx context.Context, params *Params) (*Session, error) {
 			logger.Error(err, "unable to check if rest session is active")
 		}
 
-		if vimSessionActive && tagManagerSession != nil {
+		// The session of a user whose password was rotated is recycled, so
+		// that the new password is checked and used from then on. So is the
+		// session of a server whose CA bundle changed, so that the server
+		// is verified with the new certificate authorities.
+		password, _ := params.userinfo.Password()
+		if cachedPassword, _ := s.userinfo.Password(); cachedPassword != password {
+			logger.Info("password of the cached vSphere client session changed, recycling the session")
+		} else if !bytes.Equal(s.caBundle, params.caBundle) {
+			logger.Info("CA bundle of the cached vSphere client session changed, recycling the session")
+		} else if s.tokenProvider != params.tokenProvider {
+			logger.Info("token of the cached vSphere client session changed, recycling the session")
+		} else if s.token != nil && !s.token.Expiry.IsZero() && time.Now().Add(tokenRefreshMargin).After(s.token.Expiry) {
+			logger.Info("token of the cached vSphere client session expires, recycling the session")
+		} else if vimSessionActive && tagManagerSession != nil {
 			logger.V(2).Info("found active cached vSphere client session")
 			return s, nil
 		}
@@ -135,23 +197,50 @@ func GetOrCreate(ctx context.Context, params *Params) (*Session, error) {
 	}
 
 	soapURL.User = params.userinfo
-	client, err := newClient(ctx, logger, sessionKey, soapURL, params.thumbprint, params.feature)
+	client, token, err := newClient(ctx, logger, sessionKey, soapURL, params.thumbprint, params.caBundle, params.tokenProvider, params.feature)
 	if err != nil {
+		fencing.record(params.server, err)
 		return nil, err
 	}
 
-	session := Session{Client: client}
+	session := Session{Client: client, server: params.server, userinfo: params.userinfo, thumbprint: params.thumbprint, caBundle: params.caBundle}
+	session.tokenProvider, session.token = params.tokenProvider, token
+	session.lookups = newLookupCache()
 	session.UserAgent = infrav1.GroupVersion.String()
 
 	// Assign the finder to the session.
 	session.Finder = find.NewFinder(session.Client.Client, false)
 	// Assign tag manager to the session.
-	manager, err := newManager(ctx, logger, sessionKey, client.Client, soapURL.User, params.feature)
+	manager, err := newManager(ctx, logger, sessionKey, client.Client, soapURL.User, token, params.feature)
 	if err != nil {
+		fencing.record(params.server, err)
 		return nil, errors.Wrap(err, "unable to create tags manager")
 	}
 	session.TagManager = manager
 
+	// Track the health of the server through the calls of the session,
+	// including the ones of its property cache and event watcher.
+	if fencing != nil {
+		client.Client.RoundTripper = fencing.soapRoundTripper(client.Client.RoundTripper, params.server)
+		manager.Transport = fencing.httpRoundTripper(manager.Transport, params.server)
+	}
+
+	// Measure the latency of the calls of the session, by which the requeues
+	// of the objects waiting for the server are scaled.
+	client.Client.RoundTripper = latencySOAPRoundTripper(client.Client.RoundTripper, params.server)
+
+	// Let the caches of the inventory drop the objects which no longer exist.
+	if hasObjectNotFoundHandlers() {
+		client.Client.RoundTripper = notFoundSOAPRoundTripper(client.Client.RoundTripper, params.server)
+	}
+
+	// Audit the calls of the session changing the inventory, including the
+	// ones which fail because of a dry run.
+	if auditor != nil {
+		client.Client.RoundTripper = auditSOAPRoundTripper(client.Client.RoundTripper, auditor, params.server, params.username())
+		manager.Transport = auditHTTPRoundTripper(manager.Transport, auditor, params.server, params.username())
+	}
+
 	// Assign the datacenter if one was specified.
 	if params.datacenter != "" {
 		dc, err := session.Finder.Datacenter(ctx, params.datacenter)
@@ -161,6 +250,20 @@ func GetOrCreate(ctx context.Context, params *Params) (*Session, error) {
 		session.datacenter = dc
 		session.Finder.SetDatacenter(dc)
 	}
+
+	// Start watching the VMs and hosts of the datacenter, or of the whole
+	// inventory if no datacenter was specified.
+	container := session.Client.ServiceContent.RootFolder
+	if session.datacenter != nil {
+		container = session.datacenter.Reference()
+	}
+	session.propertyCache = newPropertyCache(logger, session.Client.Client, params.server)
+	session.propertyCache.start(container)
+	if hasVMEventHandlers() {
+		session.eventWatcher = newEventWatcher(logger, session.Client.Client, params.server)
+		session.eventWatcher.start(container)
+	}
+
 	// Cache the session.
 	sessionCache.Store(sessionKey, &session)
 
@@ -169,16 +272,30 @@ func GetOrCreate(ctx context.Context, params *Params) (*Session, error) {
 	return &session, nil
 }
 
-func newClient(ctx context.Context, logger logr.Logger, sessionKey string, url *url.URL, thumbprint string, feature Feature) (*govmomi.Client, error) {
-	insecure := thumbprint == ""
+func newClient(ctx context.Context, logger logr.Logger, sessionKey string, url *url.URL, thumbprint string, caBundle []byte, tokenProvider TokenProvider, feature Feature) (*govmomi.Client, *Token, error) {
+	insecure := thumbprint == "" && len(caBundle) == 0
 	soapClient := soap.NewClient(url, insecure)
-	if !insecure {
+	if len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, nil, errors.New("the CA bundle holds no PEM-encoded certificate")
+		}
+		soapClient.DefaultTransport().TLSClientConfig.RootCAs = pool
+	}
+	if thumbprint != "" {
 		soapClient.SetThumbprint(url.Host, thumbprint)
 	}
 
 	vimClient, err := vim25.NewClient(ctx, soapClient)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if dryRun {
+		vimClient.RoundTripper = dryRunSOAPRoundTripper(vimClient.RoundTripper, logger)
+	}
+	if faultInjection != nil {
+		vimClient.RoundTripper = faultInjection.soapRoundTripper(vimClient.RoundTripper, *vimClient.ServiceContent.SessionManager)
 	}
 
 	c := &govmomi.Client{
@@ -201,16 +318,30 @@ func newClient(ctx context.Context, logger logr.Logger, sessionKey string, url *
 		return err
 	})
 
+	if tokenProvider != nil {
+		token, err := tokenProvider.Token(ctx, soapClient)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to get a token")
+		}
+		header := soap.Header{Security: &sts.Signer{Token: token.SAML}}
+		if err := c.SessionManager.LoginByToken(c.WithHeader(ctx, header)); err != nil {
+			return nil, nil, err
+		}
+		return c, token, nil
+	}
+
 	if err := c.Login(ctx, url.User); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return c, nil
+	return c, nil, nil
 }
 
 func clearCache(logger logr.Logger, sessionKey string) {
 	if cachedSession, ok := sessionCache.Load(sessionKey); ok {
 		s := cachedSession.(*Session)
+		s.propertyCache.stop()
+		s.eventWatcher.stop()
 
 		// check for the presence of tagmanager session
 		// since calling Logout on an expired session blocks
@@ -240,9 +371,29 @@ func clearCache(logger logr.Logger, sessionKey string) {
 	sessionCache.Delete(sessionKey)
 }
 
+// Clear logs out and drops the cached sessions of a user of a server, in all
+// datacenters, so that the next GetOrCreate logs in again. It is used when the
+// credentials of the user are rotated.
+func Clear(ctx context.Context, server, username string) {
+	logger := ctrl.LoggerFrom(ctx).WithName("session")
+	sessionCache.Range(func(key, value interface{}) bool {
+		s := value.(*Session)
+		if s.server == server && s.Username() == username {
+			clearCache(logger, key.(string))
+		}
+		return true
+	})
+}
+
 // newManager creates a Manager that encompasses the REST Client for the VSphere tagging API.
-func newManager(ctx context.Context, logger logr.Logger, sessionKey string, client *vim25.Client, user *url.Userinfo, feature Feature) (*tags.Manager, error) {
+func newManager(ctx context.Context, logger logr.Logger, sessionKey string, client *vim25.Client, user *url.Userinfo, token *Token, feature Feature) (*tags.Manager, error) {
 	rc := rest.NewClient(client)
+	if dryRun {
+		rc.Transport = dryRunHTTPRoundTripper(rc.Transport, logger)
+	}
+	if faultInjection != nil {
+		rc.Transport = faultInjection.httpRoundTripper(rc.Transport)
+	}
 	rc.Transport = keepalive.NewHandlerREST(rc, feature.KeepAliveDuration, func() error {
 		s, err := rc.Session(ctx)
 		if err != nil {
@@ -256,12 +407,54 @@ func newManager(ctx context.Context, logger logr.Logger, sessionKey string, clie
 		clearCache(logger, sessionKey)
 		return errors.New("rest client session expired")
 	})
+	if token != nil {
+		if err := rc.LoginByToken(rc.WithSigner(ctx, &sts.Signer{Token: token.SAML})); err != nil {
+			return nil, err
+		}
+		return tags.NewManager(rc), nil
+	}
 	if err := rc.Login(ctx, user); err != nil {
 		return nil, err
 	}
 	return tags.NewManager(rc), nil
 }
 
+// ServiceLocator returns the locator of the session's vCenter, which allows
+// another vCenter to operate on it on behalf of the session's user, e.g. to
+// clone a VM across vCenters.
+func (s *Session) ServiceLocator() *types.ServiceLocator {
+	u := s.URL()
+	locator := &types.ServiceLocator{
+		InstanceUuid:  s.ServiceContent.About.InstanceUuid,
+		Url:           (&url.URL{Scheme: u.Scheme, Host: u.Host}).String(),
+		SslThumbprint: s.thumbprint,
+	}
+	if s.token != nil {
+		locator.Credential = &types.ServiceLocatorSAMLCredential{Token: s.token.SAML}
+	} else if s.userinfo != nil {
+		password, _ := s.userinfo.Password()
+		locator.Credential = &types.ServiceLocatorNamePassword{
+			Username: s.userinfo.Username(),
+			Password: password,
+		}
+	}
+	return locator
+}
+
+// Username returns the name of the principal of the session.
+func (s *Session) Username() string {
+	if s.tokenProvider != nil {
+		return s.tokenProvider.Name()
+	}
+	return s.userinfo.Username()
+}
+
+// PropertyCache returns the cache of VM, host and task properties for the session.
+// The returned value may be nil, in which case all lookups miss.
+func (s *Session) PropertyCache() *PropertyCache {
+	return s.propertyCache
+}
+
 func (s *Session) GetVersion() (infrav1.VCenterVersion, error) {
 	svcVersion := s.ServiceContent.About.Version
 	version, err := semver.New(svcVersion)
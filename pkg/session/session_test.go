@@ -27,6 +27,8 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -233,3 +235,147 @@ func TestGetSessionWithKeepAliveTagManagerLogout(t *testing.T) {
 	g.Expect(sessionInfo.Key).ToNot(BeEquivalentTo(sessionKey))
 	assertSessionCountEqualTo(g, simr, 1)
 }
+
+func TestClearSession(t *testing.T) {
+	g := NewWithT(t)
+	ctrl.SetLogger(klog.Background())
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	if err != nil {
+		t.Fatalf("failed to create VC simulator")
+	}
+	defer simr.Destroy()
+
+	params := NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()).
+		WithDatacenter("*")
+
+	s, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s).ToNot(BeNil())
+	assertSessionCountEqualTo(g, simr, 1)
+
+	// The sessions of other users are kept.
+	Clear(context.Background(), simr.ServerURL().Host, "other-user")
+	_, ok := sessionCache.Load(simr.ServerURL().Host + simr.Username() + "*")
+	g.Expect(ok).To(BeTrue())
+
+	Clear(context.Background(), simr.ServerURL().Host, simr.Username())
+	_, ok = sessionCache.Load(simr.ServerURL().Host + simr.Username() + "*")
+	g.Expect(ok).To(BeFalse())
+	assertSessionCountEqualTo(g, simr, 0)
+}
+
+func TestGetSessionWithCABundle(t *testing.T) {
+	g := NewWithT(t)
+	ctrl.SetLogger(klog.Background())
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	if err != nil {
+		t.Fatalf("failed to create VC simulator")
+	}
+	defer simr.Destroy()
+
+	params := NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password())
+
+	insecure, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The session is recycled once the server is trusted through a CA
+	// bundle, which verifies the certificate of the server.
+	verified, err := GetOrCreate(context.Background(), params.WithCABundle(simr.CABundle()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(verified).ToNot(BeIdenticalTo(insecure))
+	assertSessionCountEqualTo(g, simr, 1)
+
+	cached, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cached).To(BeIdenticalTo(verified))
+
+	_, err = GetOrCreate(context.Background(), params.WithCABundle([]byte("not a certificate")))
+	g.Expect(err).To(MatchError(ContainSubstring("no PEM-encoded certificate")))
+}
+
+// testTokenProvider provides a static SAML token.
+type testTokenProvider struct {
+	name   string
+	expiry time.Time
+}
+
+func (p testTokenProvider) Name() string {
+	return p.name
+}
+
+func (p testTokenProvider) Token(_ context.Context, _ *soap.Client) (*Token, error) {
+	saml := fmt.Sprintf(`<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion">`+
+		`<saml2:Subject><saml2:NameID>%s</saml2:NameID></saml2:Subject></saml2:Assertion>`, p.name)
+	return &Token{SAML: saml, Expiry: p.expiry}, nil
+}
+
+func TestGetSessionWithToken(t *testing.T) {
+	g := NewWithT(t)
+	ctrl.SetLogger(klog.Background())
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	if err != nil {
+		t.Fatalf("failed to create VC simulator")
+	}
+	defer simr.Destroy()
+
+	params := NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithTokenProvider(testTokenProvider{name: "token-user@vsphere.local", expiry: time.Now().Add(time.Hour)})
+
+	s, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	userSession, err := s.SessionManager.UserSession(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(userSession.UserName).To(Equal("token-user@vsphere.local"))
+	g.Expect(s.ServiceLocator().Credential).To(BeAssignableToTypeOf(&types.ServiceLocatorSAMLCredential{}))
+
+	cached, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cached).To(BeIdenticalTo(s))
+
+	// The session is recycled before its token expires.
+	params = params.WithTokenProvider(testTokenProvider{name: "token-user@vsphere.local", expiry: time.Now().Add(time.Minute)})
+	refreshed, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(refreshed).ToNot(BeIdenticalTo(s))
+	recycled, err := GetOrCreate(context.Background(), params)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recycled).ToNot(BeIdenticalTo(refreshed))
+}
+
+func TestSessionServiceLocator(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	if err != nil {
+		t.Fatalf("failed to create VC simulator")
+	}
+	defer simr.Destroy()
+
+	params := NewParams().
+		WithServer(simr.ServerURL().Host).
+		WithUserInfo(simr.Username(), simr.Password()).
+		WithThumbprint("AA:BB")
+	s := &Session{userinfo: params.userinfo, thumbprint: params.thumbprint}
+	s.Client, _, err = newClient(context.Background(), klog.Background(), "locator", simr.ServerURL(), "", nil, nil, DefaultFeature())
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = s.Logout(context.Background())
+	}()
+
+	locator := s.ServiceLocator()
+	g.Expect(locator.InstanceUuid).To(Equal(s.ServiceContent.About.InstanceUuid))
+	g.Expect(locator.Url).To(Equal("https://" + simr.ServerURL().Host))
+	g.Expect(locator.SslThumbprint).To(Equal("AA:BB"))
+	g.Expect(locator.Credential).To(Equal(&types.ServiceLocatorNamePassword{
+		Username: simr.Username(),
+		Password: simr.Password(),
+	}))
+}
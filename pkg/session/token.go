@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Token is a bearer SAML token a vCenter session logs in with.
+type Token struct {
+	// SAML is the XML assertion of the token.
+	SAML string
+
+	// Expiry is the time the token expires at, or zero if unknown.
+	Expiry time.Time
+}
+
+// TokenProvider provides the SAML tokens the sessions of a vCenter user log in
+// with, in place of a username and password. The implementations are
+// comparable, so that the sessions can tell whether the provider of a user
+// changed.
+type TokenProvider interface {
+	// Name is the name of the principal of the tokens, which keys the cached
+	// sessions of the principal.
+	Name() string
+
+	// Token returns a new token for the vCenter of a client, which is used
+	// for the requests to the vCenter.
+	Token(ctx context.Context, c *soap.Client) (*Token, error)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shard splits the objects reconciled by several instances of the
+// controller manager by vCenter server or namespace, so that a slow vCenter
+// only slows down the instance reconciling its objects.
+package shard
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Shard is the share of the objects reconciled by an instance of the
+// controller manager. A nil Shard reconciles all the objects.
+type Shard struct {
+	servers           sets.String
+	namespaceSelector labels.Selector
+	clusterWide       bool
+}
+
+// New returns the Shard of the objects on the given vCenter servers, or on
+// all of them if none is given, in the namespaces matching a label selector,
+// or in all of them if it is empty. The shard also reconciles the objects
+// which are neither on a server nor in a namespace, e.g. the
+// VSphereClusterIdentities, when clusterWide is true. It returns nil when
+// neither the servers nor the selector restricts the objects.
+func New(servers []string, namespaceSelector string, clusterWide bool) (*Shard, error) {
+	s := &Shard{servers: sets.NewString(), clusterWide: clusterWide}
+	for _, server := range servers {
+		if server = strings.TrimSpace(server); server != "" {
+			s.servers.Insert(host(server))
+		}
+	}
+	if namespaceSelector != "" {
+		selector, err := labels.Parse(namespaceSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid namespace selector %q", namespaceSelector)
+		}
+		s.namespaceSelector = selector
+	}
+	if s.servers.Len() == 0 && s.namespaceSelector == nil {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// Owns returns true if the objects of a namespace on a vCenter server are
+// reconciled by the shard. The objects whose server is not known are only
+// owned by the shards which are not restricted to some servers, and the
+// cluster-scoped objects, whose namespace is empty, by the shards of any
+// namespace.
+func (s *Shard) Owns(ctx context.Context, c client.Reader, namespace, server string) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	if s.servers.Len() > 0 && !s.servers.Has(host(server)) {
+		return false, nil
+	}
+	if s.namespaceSelector == nil || namespace == "" {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, errors.Wrapf(err, "unable to get namespace %s", namespace)
+	}
+	return s.namespaceSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// OwnsClusterWide returns true if the objects which are neither on a vCenter
+// server nor in a namespace are reconciled by the shard. They are reconciled
+// by a single shard, designated with clusterWide, so that the shards do not
+// reconcile them concurrently.
+func (s *Shard) OwnsClusterWide() bool {
+	return s == nil || s.clusterWide
+}
+
+// String returns a description of the shard.
+func (s *Shard) String() string {
+	if s == nil {
+		return "all"
+	}
+	var parts []string
+	if s.servers.Len() > 0 {
+		parts = append(parts, "servers "+strings.Join(s.servers.List(), ","))
+	}
+	if s.namespaceSelector != nil {
+		parts = append(parts, "namespaces "+s.namespaceSelector.String())
+	}
+	if s.clusterWide {
+		parts = append(parts, "cluster-wide objects")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// host returns the host of a vCenter server, which is given either as a host
+// or as a URL, e.g. https://vcenter.example.com/sdk.
+func host(server string) string {
+	server = strings.ToLower(server)
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+3:]
+	}
+	if i := strings.IndexAny(server, "/?"); i >= 0 {
+		server = server[:i]
+	}
+	return server
+}
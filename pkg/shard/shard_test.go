@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestShard_Owns(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"capv-shard": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"capv-shard": "b"}}},
+	).Build()
+
+	tests := []struct {
+		name              string
+		servers           []string
+		namespaceSelector string
+		namespace         string
+		server            string
+		owns              bool
+	}{
+		{name: "without restriction", namespace: "team-b", server: "vc2.example.com", owns: true},
+		{name: "on a server of the shard", servers: []string{"vc1.example.com"}, namespace: "team-b", server: "https://VC1.example.com/sdk", owns: true},
+		{name: "on another server", servers: []string{"vc1.example.com"}, namespace: "team-a", server: "vc2.example.com"},
+		{name: "on an unknown server", servers: []string{"vc1.example.com"}, namespace: "team-a"},
+		{name: "in a namespace of the shard", namespaceSelector: "capv-shard=a", namespace: "team-a", server: "vc2.example.com", owns: true},
+		{name: "in another namespace", namespaceSelector: "capv-shard=a", namespace: "team-b", server: "vc1.example.com"},
+		{name: "cluster-scoped", namespaceSelector: "capv-shard=a", server: "vc1.example.com", owns: true},
+		{name: "in a namespace on a server of the shard", servers: []string{"vc1.example.com"}, namespaceSelector: "capv-shard=a", namespace: "team-a", server: "vc1.example.com", owns: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			s, err := New(tt.servers, tt.namespaceSelector, false)
+			g.Expect(err).NotTo(HaveOccurred())
+			owns, err := s.Owns(context.Background(), c, tt.namespace, tt.server)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(owns).To(Equal(tt.owns))
+		})
+	}
+
+	t.Run("fails for a missing namespace", func(t *testing.T) {
+		g := NewWithT(t)
+		s, err := New(nil, "capv-shard=a", false)
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = s.Owns(context.Background(), c, "team-c", "vc1.example.com")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestNew(t *testing.T) {
+	g := NewWithT(t)
+	s, err := New([]string{" ", ""}, "", true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(BeNil())
+	g.Expect(s.String()).To(Equal("all"))
+
+	_, err = New(nil, "capv-shard in (a", false)
+	g.Expect(err).To(HaveOccurred())
+
+	s, err = New([]string{"vc1.example.com", "https://vc2.example.com/sdk"}, "capv-shard=a", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.String()).To(Equal("servers vc1.example.com,vc2.example.com, namespaces capv-shard=a"))
+
+	s, err = New([]string{"vc1.example.com"}, "", true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.String()).To(Equal("servers vc1.example.com, cluster-wide objects"))
+}
+
+func TestShard_OwnsClusterWide(t *testing.T) {
+	g := NewWithT(t)
+	var s *Shard
+	g.Expect(s.OwnsClusterWide()).To(BeTrue())
+
+	s, err := New([]string{"vc1.example.com"}, "", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.OwnsClusterWide()).To(BeFalse())
+
+	s, err = New([]string{"vc1.example.com"}, "", true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s.OwnsClusterWide()).To(BeTrue())
+}
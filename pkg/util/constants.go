@@ -35,7 +35,7 @@ network:
       set-name: "eth{{ $i }}"
       {{- end }}
       wakeonlan: true
-      {{- if or $net.DHCP4 $net.DHCP6 }}
+      {{- if or $net.DHCP4 $net.DHCP6 (eq $net.IPv6AddressMode "statelessDHCPv6") }}
       dhcp4: {{ $net.DHCP4 }}
 	  {{- if $net.DHCP4Overrides }}
       dhcp4-overrides:
@@ -67,7 +67,7 @@ network:
         use-routes: "{{ $net.DHCP4Overrides.UseRoutes }}"
 	    {{- end }}
 	  {{- end }}
-      dhcp6: {{ $net.DHCP6 }}
+      dhcp6: {{ or $net.DHCP6 (eq $net.IPv6AddressMode "statelessDHCPv6") }}
 	  {{- if $net.DHCP6Overrides }}
       dhcp6-overrides:
 	    {{- if $net.DHCP6Overrides.Hostname }}
@@ -99,6 +99,9 @@ network:
 	    {{- end }}
 	  {{- end }}
       {{- end }}
+      {{- if $net.IPv6AddressMode }}
+      accept-ra: true
+      {{- end }}
       {{- if $net.IPAddrs }}
       addresses:
       {{- range $net.IPAddrs }}
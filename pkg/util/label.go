@@ -19,7 +19,9 @@ package util
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strings"
+	"unicode"
 
 	"k8s.io/apimachinery/pkg/util/validation"
 )
@@ -43,6 +45,25 @@ func SanitizeHostInfoLabel(info string) string {
 	return truncateLabelLength(info)
 }
 
+// invalidLabelValueChars matches the characters which are not allowed in
+// label values.
+var invalidLabelValueChars = regexp.MustCompile(`[^-A-Za-z0-9_.]`)
+
+// SanitizeLabelValue turns the name of a vSphere inventory object, e.g. a
+// datastore or a resource pool, into a valid label value. The characters which
+// are not allowed are replaced with `-`, and the value is truncated to the
+// maximum length of label values and trimmed to start and end with an
+// alphanumeric character.
+func SanitizeLabelValue(name string) string {
+	value := invalidLabelValueChars.ReplaceAllString(name, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
 // stripZoneInfo removes the zone info from an IPv6 address.
 // This might not be exactly relevant since zone is used for link-local addresses and
 // would not be meaningful outside the host.
@@ -109,3 +109,41 @@ func testDNSLogic(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeLabelValue(t *testing.T) {
+	tests := []struct {
+		name, input, expected string
+	}{
+		{
+			name:     "for a valid name",
+			input:    "vsanDatastore",
+			expected: "vsanDatastore",
+		},
+		{
+			name:     "for a name with spaces and slashes",
+			input:    "Compute Cluster/Rack 1",
+			expected: "Compute-Cluster-Rack-1",
+		},
+		{
+			name:     "for a name starting and ending with invalid characters",
+			input:    "(datastore 1)",
+			expected: "datastore-1",
+		},
+		{
+			name:     "for a name with > 63 characters",
+			input:    "resource-pool-of-the-workers-of-the-production-cluster-in-the-east-region",
+			expected: "resource-pool-of-the-workers-of-the-production-cluster-in-the-e",
+		},
+		{
+			name:  "for a name without valid characters",
+			input: "***",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+			g.Expect(SanitizeLabelValue(tt.input)).To(gomega.Equal(tt.expected))
+		})
+	}
+}
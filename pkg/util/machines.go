@@ -131,9 +131,14 @@ func GetMachineMetadata(hostname string, vsphereVM infrav1.VSphereVM, ipamState
 			// break early as we already wait for ipv4 and ipv6
 			continue
 		}
-		// check static IPs
-		for _, ipStr := range vsphereVM.Spec.Network.Devices[i].IPAddrs {
+		// check static IPs, including the ones allocated from IPAM pools, so
+		// that dual-stack devices wait for both families
+		for _, ipStr := range devices[i].IPAddrs {
 			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				// static IPs are usually in the CIDR format
+				ip, _, _ = net.ParseCIDR(ipStr)
+			}
 			// check the IP family
 			if ip != nil {
 				if ip.To4() == nil {
@@ -147,7 +152,7 @@ func GetMachineMetadata(hostname string, vsphereVM infrav1.VSphereVM, ipamState
 		if vsphereVM.Spec.Network.Devices[i].DHCP4 {
 			waitForIPv4 = true
 		}
-		if vsphereVM.Spec.Network.Devices[i].DHCP6 {
+		if vsphereVM.Spec.Network.Devices[i].DHCP6 || vsphereVM.Spec.Network.Devices[i].IPv6AddressMode != "" {
 			waitForIPv6 = true
 		}
 	}
@@ -538,6 +538,102 @@ network:
       addresses:
       - "192.168.4.21"
       gateway4: "192.168.4.1"
+`,
+		},
+		{
+			name: "dhcp4+static6+dual-stack-nameservers",
+			machine: &infrav1.VSphereVM{
+				Spec: infrav1.VSphereVMSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						Network: infrav1.NetworkSpec{
+							Devices: []infrav1.NetworkDeviceSpec{
+								{
+									NetworkName: "network1",
+									MACAddr:     "00:00:00:00:00",
+									DHCP4:       true,
+									IPAddrs:     []string{"2001:db8::10/64"},
+									Gateway6:    "2001:db8::1",
+									Nameservers: []string{"10.0.0.53", "2001:db8::53"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: `
+instance-id: "test-vm"
+local-hostname: "test-vm"
+wait-on-network:
+  ipv4: true
+  ipv6: true
+network:
+  version: 2
+  ethernets:
+    id0:
+      match:
+        macaddress: "00:00:00:00:00"
+      set-name: "eth0"
+      wakeonlan: true
+      dhcp4: true
+      dhcp6: false
+      addresses:
+      - "2001:db8::10/64"
+      gateway6: "2001:db8::1"
+      nameservers:
+        addresses:
+        - "10.0.0.53"
+        - "2001:db8::53"
+`,
+		},
+		{
+			name: "slaac+stateless-dhcpv6",
+			machine: &infrav1.VSphereVM{
+				Spec: infrav1.VSphereVMSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						Network: infrav1.NetworkSpec{
+							Devices: []infrav1.NetworkDeviceSpec{
+								{
+									NetworkName:     "network1",
+									MACAddr:         "00:00:00:00:00",
+									IPv6AddressMode: infrav1.IPv6AddressModeSLAAC,
+									Nameservers:     []string{"2001:db8::53"},
+								},
+								{
+									NetworkName:     "network2",
+									MACAddr:         "00:00:00:00:01",
+									IPv6AddressMode: infrav1.IPv6AddressModeStatelessDHCPv6,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: `
+instance-id: "test-vm"
+local-hostname: "test-vm"
+wait-on-network:
+  ipv4: false
+  ipv6: true
+network:
+  version: 2
+  ethernets:
+    id0:
+      match:
+        macaddress: "00:00:00:00:00"
+      set-name: "eth0"
+      wakeonlan: true
+      accept-ra: true
+      nameservers:
+        addresses:
+        - "2001:db8::53"
+    id1:
+      match:
+        macaddress: "00:00:00:00:01"
+      set-name: "eth1"
+      wakeonlan: true
+      dhcp4: false
+      dhcp6: true
+      accept-ra: true
 `,
 		},
 		{
@@ -807,7 +903,7 @@ instance-id: "test-vm"
 local-hostname: "test-vm"
 wait-on-network:
   ipv4: true
-  ipv6: false
+  ipv6: true
 network:
   version: 2
   ethernets:
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// GetTemplateInstanceUUID returns the instance UUID the given name based
+// template reference was resolved to when cloning the VSphereVMs of a
+// namespace. An empty string is returned if no VSphereVM cloned from the
+// template has recorded the UUID yet. An error is returned if the VSphereVMs
+// disagree, which means the template was replaced between clones.
+func GetTemplateInstanceUUID(ctx context.Context, c client.Client, namespace, server, template string) (string, error) {
+	vmList := &infrav1.VSphereVMList{}
+	if err := c.List(ctx, vmList, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+
+	var instanceUUID string
+	for _, vm := range vmList.Items {
+		if vm.Spec.Server != server || vm.Spec.Template != template || vm.Status.TemplateInstanceUUID == "" {
+			continue
+		}
+		if instanceUUID != "" && instanceUUID != vm.Status.TemplateInstanceUUID {
+			return "", errors.Errorf("template %q was resolved to more than one instance UUID (%s, %s)",
+				template, instanceUUID, vm.Status.TemplateInstanceUUID)
+		}
+		instanceUUID = vm.Status.TemplateInstanceUUID
+	}
+	return instanceUUID, nil
+}
+
+// MigrateTemplateToInstanceUUID rewrites a name based template reference in
+// the clone spec to the given instance UUID. It returns true if the spec was
+// modified.
+func MigrateTemplateToInstanceUUID(spec *infrav1.VirtualMachineCloneSpec, instanceUUID string) bool {
+	if instanceUUID == "" || infrav1.IsTemplateInstanceUUID(spec.Template) || spec.Template == instanceUUID {
+		return false
+	}
+	spec.Template = instanceUUID
+	return true
+}
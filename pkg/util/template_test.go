@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/util"
+)
+
+func Test_GetTemplateInstanceUUID(t *testing.T) {
+	const (
+		tplUUID      = "42107e4c-2d1d-4ac1-a5d7-2c8e2b0fd2b0"
+		otherTplUUID = "4210d3a0-6dbb-4d2b-b1c1-1b8c5c2e8e11"
+	)
+	vm := func(name, template, instanceUUID string) client.Object {
+		return &infrav1.VSphereVM{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec: infrav1.VSphereVMSpec{
+				VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+					Server:   "vcenter",
+					Template: template,
+				},
+			},
+			Status: infrav1.VSphereVMStatus{TemplateInstanceUUID: instanceUUID},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		objs        []client.Object
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "no vm cloned from the template",
+			objs:     []client.Object{vm("vm-1", "other", tplUUID)},
+			expected: "",
+		},
+		{
+			name:     "vms agree on the instance uuid",
+			objs:     []client.Object{vm("vm-1", "ubuntu", tplUUID), vm("vm-2", "ubuntu", tplUUID), vm("vm-3", "ubuntu", "")},
+			expected: tplUUID,
+		},
+		{
+			name:        "template was replaced between clones",
+			objs:        []client.Object{vm("vm-1", "ubuntu", tplUUID), vm("vm-2", "ubuntu", otherTplUUID)},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			scheme := runtime.NewScheme()
+			g.Expect(infrav1.AddToScheme(scheme)).To(gomega.Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objs...).Build()
+
+			instanceUUID, err := util.GetTemplateInstanceUUID(context.Background(), c, "ns", "vcenter", "ubuntu")
+			if tt.expectedErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(instanceUUID).To(gomega.Equal(tt.expected))
+		})
+	}
+}
+
+func Test_MigrateTemplateToInstanceUUID(t *testing.T) {
+	g := gomega.NewWithT(t)
+	const tplUUID = "42107e4c-2d1d-4ac1-a5d7-2c8e2b0fd2b0"
+
+	spec := &infrav1.VirtualMachineCloneSpec{Template: "ubuntu"}
+	g.Expect(util.MigrateTemplateToInstanceUUID(spec, "")).To(gomega.BeFalse())
+	g.Expect(util.MigrateTemplateToInstanceUUID(spec, tplUUID)).To(gomega.BeTrue())
+	g.Expect(spec.Template).To(gomega.Equal(tplUUID))
+	g.Expect(util.MigrateTemplateToInstanceUUID(spec, tplUUID)).To(gomega.BeFalse())
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Name is the name of the command.
+const Name = "validate"
+
+// Run runs the command with its arguments, and returns its exit code: 0 if
+// all the objects are valid, 1 if some are not, and 2 if the command failed.
+func Run(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	fs := pflag.NewFlagSet(Name, pflag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: manager %s -f FILE [flags]\n\n", Name)
+		fmt.Fprintln(stderr, "Runs the defaulting and validation of the admission webhooks on the objects of the")
+		fmt.Fprintln(stderr, "infrastructure.cluster.x-k8s.io API group in the files, and optionally checks the")
+		fmt.Fprintf(stderr, "vSphere inventory they reference.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringSliceVarP(&opts.Filenames, "filename", "f", nil,
+		"File holding the objects to validate, - for the standard input. May be repeated.")
+	fs.StringVarP(&opts.Namespace, "namespace", "n", "default",
+		"Namespace of the objects which do not set one.")
+	fs.StringVar(&opts.Kubeconfig, "kubeconfig", "",
+		"Kubeconfig of the management cluster. The existing objects are validated as updates.")
+	fs.BoolVar(&opts.TenantIsolation, "tenant-isolation", false,
+		"Validate the objects against the VSphereTenantPolicies of the management cluster. Requires --kubeconfig.")
+	fs.StringVar(&opts.Server, "server", "",
+		"vCenter whose inventory referenced by the objects is checked. The inventory is not checked if unset.")
+	fs.StringVar(&opts.Username, "username", os.Getenv("VSPHERE_USERNAME"),
+		"Username of the vCenter. Defaults to $VSPHERE_USERNAME.")
+	fs.StringVar(&opts.Password, "password", "",
+		"Password of the vCenter. Defaults to $VSPHERE_PASSWORD.")
+	fs.StringVar(&opts.Thumbprint, "thumbprint", "",
+		"SHA-1 thumbprint of the vCenter certificate. The certificate is not verified if unset.")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if opts.Password == "" {
+		opts.Password = os.Getenv("VSPHERE_PASSWORD")
+	}
+	if len(opts.Filenames) == 0 {
+		fmt.Fprintln(stderr, "at least one file is required")
+		fs.Usage()
+		return 2
+	}
+
+	results, err := Validate(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if !Print(stdout, results) {
+		return 1
+	}
+	return 0
+}
+
+func newClient(kubeconfig string, scheme *runtime.Scheme) (client.Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load kubeconfig %s", kubeconfig)
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the management cluster client")
+	}
+	return c, nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/pbm"
+	govmomisession "github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	capvfind "sigs.k8s.io/cluster-api-provider-vsphere/pkg/services/govmomi/find"
+)
+
+// inventoryChecker checks that the vSphere inventory referenced by the
+// objects exists in a vCenter. The session is opened on the first check, so
+// that no session is opened if there is nothing to check.
+type inventoryChecker struct {
+	opts   Options
+	client *govmomi.Client
+	pbm    *pbm.Client
+	err    error
+
+	// shared is true if the client belongs to a session that outlives the
+	// checker, and must not be logged out.
+	shared bool
+}
+
+func newInventoryChecker(opts Options) *inventoryChecker {
+	return &inventoryChecker{opts: opts}
+}
+
+// newSharedInventoryChecker returns an inventoryChecker using the client of
+// an existing session to the server of the options.
+func newSharedInventoryChecker(opts Options, c *govmomi.Client) *inventoryChecker {
+	return &inventoryChecker{opts: opts, client: c, shared: true}
+}
+
+// login opens a session to the vCenter, and returns the error of the first
+// attempt on the next calls if it failed.
+func (i *inventoryChecker) login(ctx context.Context) error {
+	if i.client != nil || i.err != nil {
+		return i.err
+	}
+
+	soapURL, err := soap.ParseURL(i.opts.Server)
+	if err != nil || soapURL == nil {
+		i.err = errors.Errorf("error parsing vSphere URL %q", i.opts.Server)
+		return i.err
+	}
+	soapURL.User = url.UserPassword(i.opts.Username, i.opts.Password)
+
+	soapClient := soap.NewClient(soapURL, i.opts.Thumbprint == "")
+	if i.opts.Thumbprint != "" {
+		soapClient.SetThumbprint(soapURL.Host, i.opts.Thumbprint)
+	}
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		i.err = errors.Wrapf(err, "error connecting to %s", i.opts.Server)
+		return i.err
+	}
+	c := &govmomi.Client{Client: vimClient, SessionManager: govmomisession.NewManager(vimClient)}
+	if err := c.Login(ctx, soapURL.User); err != nil {
+		i.err = errors.Wrapf(err, "error logging in to %s", i.opts.Server)
+		return i.err
+	}
+	i.client = c
+	return nil
+}
+
+func (i *inventoryChecker) close(ctx context.Context) {
+	if i.client != nil && !i.shared {
+		_ = i.client.Logout(ctx)
+	}
+}
+
+// check checks the inventory referenced by an object.
+func (i *inventoryChecker) check(ctx context.Context, obj client.Object, objs []client.Object, result *Result) {
+	var server string
+	var check func(*find.Finder) []string
+	switch o := obj.(type) {
+	case *infrav1.VSphereFailureDomain:
+		check = func(finder *find.Finder) []string { return i.checkFailureDomain(ctx, finder, o) }
+	case *infrav1.VSphereDeploymentZone:
+		server = o.Spec.Server
+		check = func(finder *find.Finder) []string { return i.checkDeploymentZone(ctx, finder, o, objs) }
+	default:
+		spec, fldPath, _ := cloneSpec(obj)
+		if spec == nil {
+			return
+		}
+		server = spec.Server
+		check = func(finder *find.Finder) []string {
+			return i.checkCloneSpec(ctx, finder, spec, fldPath.String(), result)
+		}
+	}
+
+	if server != "" && !sameServer(server, i.opts.Server) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("inventory not checked, the object references the vCenter %s", server))
+		return
+	}
+	if err := i.login(ctx); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+	result.Errors = append(result.Errors, check(find.NewFinder(i.client.Client, false))...)
+}
+
+// sameServer returns true if a server of an object is the server of the
+// options, which may be a URL.
+func sameServer(server, optsServer string) bool {
+	if u, err := soap.ParseURL(optsServer); err == nil && u != nil {
+		optsServer = u.Hostname()
+	}
+	return strings.EqualFold(server, optsServer)
+}
+
+// setDatacenter sets the datacenter of the finder, the default datacenter
+// being used if it is empty.
+func setDatacenter(ctx context.Context, finder *find.Finder, datacenter, fldPath string) (*object.Datacenter, []string) {
+	dc, err := finder.DatacenterOrDefault(ctx, datacenter)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s.datacenter: %s", fldPath, err)}
+	}
+	finder.SetDatacenter(dc)
+	return dc, nil
+}
+
+func (i *inventoryChecker) checkCloneSpec(ctx context.Context, finder *find.Finder, spec *infrav1.VirtualMachineCloneSpec, fldPath string, result *Result) []string {
+	dc, msgs := setDatacenter(ctx, finder, spec.Datacenter, fldPath)
+	if dc == nil {
+		return msgs
+	}
+
+	if spec.TemplateSource != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s.template not checked, the template is cloned from the vCenter %s", fldPath, spec.TemplateSource.Server))
+	} else if err := i.findTemplate(ctx, finder, dc, spec.Template); err != nil {
+		msgs = append(msgs, fmt.Sprintf("%s.template: %s", fldPath, err))
+	}
+
+	if isSet(spec.Folder) {
+		if _, err := i.find(ctx, "Folder", spec.Folder, capvfind.Folder(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.folder: %s", fldPath, err))
+		}
+	}
+	if isSet(spec.Datastore) {
+		if _, err := i.find(ctx, "Datastore", spec.Datastore, capvfind.Datastore(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.datastore: %s", fldPath, err))
+		}
+	}
+	if isSet(spec.ResourcePool) {
+		if _, err := i.find(ctx, "ResourcePool", spec.ResourcePool, capvfind.ResourcePool(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.resourcePool: %s", fldPath, err))
+		}
+	}
+	for j, device := range spec.Network.Devices {
+		if isSet(device.NetworkName) {
+			if _, err := i.find(ctx, "Network", device.NetworkName, capvfind.Network(finder)); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s.network.devices[%d].networkName: %s", fldPath, j, err))
+			}
+		}
+	}
+	if spec.StoragePolicyName != "" {
+		if err := i.findStoragePolicy(ctx, spec.StoragePolicyName); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.storagePolicyName: %s", fldPath, err))
+		}
+	}
+	return msgs
+}
+
+// find finds an object of the given kind by managed object ID, or by name or
+// inventory path with byPath, as the VMs are cloned.
+func (i *inventoryChecker) find(ctx context.Context, kind, path string, byPath capvfind.ByPathFunc) (object.Reference, error) {
+	return capvfind.ByMoRef(i.client.Client, kind, byPath)(ctx, path)
+}
+
+// findTemplate finds a template by managed object ID, instance UUID or name,
+// as the VMs are cloned.
+func (i *inventoryChecker) findTemplate(ctx context.Context, finder *find.Finder, dc *object.Datacenter, template string) error {
+	if infrav1.IsMoRef(template) {
+		_, err := capvfind.MoRef(ctx, i.client.Client, "VirtualMachine", template)
+		return err
+	}
+	if _, err := uuid.Parse(template); err == nil {
+		ref, err := object.NewSearchIndex(i.client.Client).FindByUuid(ctx, dc, template, true, pointer.Bool(true))
+		if err != nil {
+			return err
+		}
+		if ref != nil {
+			return nil
+		}
+	}
+	_, err := finder.VirtualMachine(ctx, template)
+	return err
+}
+
+func (i *inventoryChecker) findStoragePolicy(ctx context.Context, name string) error {
+	if i.pbm == nil {
+		pbmClient, err := pbm.NewClient(ctx, i.client.Client)
+		if err != nil {
+			return errors.Wrap(err, "unable to create storage policy client")
+		}
+		i.pbm = pbmClient
+	}
+	_, err := i.pbm.ProfileIDByName(ctx, name)
+	return err
+}
+
+func (i *inventoryChecker) checkFailureDomain(ctx context.Context, finder *find.Finder, failureDomain *infrav1.VSphereFailureDomain) []string {
+	topology := failureDomain.Spec.Topology
+	dc, msgs := setDatacenter(ctx, finder, topology.Datacenter, "spec.topology")
+	if dc == nil {
+		return msgs
+	}
+
+	if topology.ComputeCluster != nil {
+		if _, err := i.find(ctx, "ClusterComputeResource", *topology.ComputeCluster, capvfind.ClusterComputeResource(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("spec.topology.computeCluster: %s", err))
+		}
+	}
+	if topology.Datastore != "" {
+		if _, err := i.find(ctx, "Datastore", topology.Datastore, capvfind.Datastore(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("spec.topology.datastore: %s", err))
+		}
+	}
+	for j, network := range topology.Networks {
+		if _, err := i.find(ctx, "Network", network, capvfind.Network(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("spec.topology.networks[%d]: %s", j, err))
+		}
+	}
+	return msgs
+}
+
+// checkDeploymentZone checks the placement constraint of a deployment zone,
+// in the datacenter of its failure domain if the failure domain is one of the
+// validated objects.
+func (i *inventoryChecker) checkDeploymentZone(ctx context.Context, finder *find.Finder, zone *infrav1.VSphereDeploymentZone, objs []client.Object) []string {
+	datacenter := ""
+	for _, obj := range objs {
+		if failureDomain, ok := obj.(*infrav1.VSphereFailureDomain); ok && failureDomain.Name == zone.Spec.FailureDomain {
+			datacenter = failureDomain.Spec.Topology.Datacenter
+		}
+	}
+	dc, msgs := setDatacenter(ctx, finder, datacenter, "spec.failureDomain")
+	if dc == nil {
+		return msgs
+	}
+
+	constraint := zone.Spec.PlacementConstraint
+	if constraint.ResourcePool != "" {
+		if _, err := i.find(ctx, "ResourcePool", constraint.ResourcePool, capvfind.ResourcePool(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("spec.placementConstraint.resourcePool: %s", err))
+		}
+	}
+	if constraint.Folder != "" {
+		if _, err := i.find(ctx, "Folder", constraint.Folder, capvfind.Folder(finder)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("spec.placementConstraint.folder: %s", err))
+		}
+	}
+	return msgs
+}
+
+// isSet returns false for the empty values and the values rendered from
+// templates, which cannot be checked.
+func isSet(value string) bool {
+	return value != "" && !strings.Contains(value, "{{")
+}
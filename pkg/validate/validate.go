@@ -0,0 +1,378 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validate implements the validate command of the manager binary,
+// which runs the defaulting and validation of the admission webhooks on
+// cluster definitions before they are applied, and optionally checks the
+// vSphere inventory they reference.
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1a3 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1alpha3"
+	infrav1a4 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1alpha4"
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+// Options are the options of the validation.
+type Options struct {
+	// Filenames are the files holding the objects to validate, "-" being the
+	// standard input.
+	Filenames []string
+
+	// Namespace is the namespace of the objects which do not set one.
+	Namespace string
+
+	// Kubeconfig is the path of the kubeconfig of the management cluster.
+	// When set, the objects existing in the management cluster are validated
+	// as updates, and the objects are validated against the
+	// VSphereTenantPolicies if TenantIsolation is true.
+	Kubeconfig string
+
+	// TenantIsolation validates the objects against the VSphereTenantPolicies
+	// of the management cluster, as the TenantIsolation feature gate does.
+	TenantIsolation bool
+
+	// Server is the vCenter whose inventory is checked. The inventory is not
+	// checked when it is empty.
+	Server     string
+	Username   string
+	Password   string
+	Thumbprint string
+}
+
+// Result is the outcome of the validation of an object.
+type Result struct {
+	// Object identifies the validated object, as "<kind> <namespace>/<name>".
+	Object string
+
+	// Errors are the reasons the object would be rejected, or the inventory
+	// it references that does not exist.
+	Errors []string
+
+	// Warnings do not prevent the object from being applied.
+	Warnings []string
+}
+
+// Valid returns true if the object has no error.
+func (r Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = infrav1a3.AddToScheme(scheme)
+	_ = infrav1a4.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
+	return scheme
+}
+
+// Validate validates the objects of the infrastructure API group in the
+// files of the options. The objects of the other API groups are ignored.
+func Validate(ctx context.Context, opts Options) ([]Result, error) {
+	scheme := newScheme()
+
+	var docs [][]byte
+	for _, filename := range opts.Filenames {
+		fileDocs, err := readDocuments(filename)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+	}
+
+	var c client.Client
+	if opts.Kubeconfig != "" {
+		var err error
+		if c, err = newClient(opts.Kubeconfig, scheme); err != nil {
+			return nil, err
+		}
+	} else if opts.TenantIsolation {
+		return nil, errors.New("a kubeconfig is required to validate the objects against the tenant policies")
+	}
+
+	var inventory *inventoryChecker
+	if opts.Server != "" {
+		inventory = newInventoryChecker(opts)
+	}
+
+	objs := make([]client.Object, 0, len(docs))
+	results := make([]Result, 0, len(docs))
+	for _, doc := range docs {
+		obj, result, ok := decode(scheme, doc, opts.Namespace)
+		if !ok {
+			continue
+		}
+		if obj != nil {
+			validateObject(ctx, c, opts.TenantIsolation, obj, &result)
+		}
+		objs = append(objs, obj)
+		results = append(results, result)
+	}
+
+	// The inventory is checked once all the objects are loaded, since the
+	// deployment zones reference the failure domains.
+	if inventory != nil {
+		defer inventory.close(ctx)
+		for i, obj := range objs {
+			if obj != nil {
+				inventory.check(ctx, obj, objs, &results[i])
+			}
+		}
+	}
+	return results, nil
+}
+
+// readDocuments returns the YAML documents of a file.
+func readDocuments(filename string) ([][]byte, error) {
+	var in io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", filename)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var docs [][]byte
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", filename)
+		}
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// decode decodes a document into the hub version of its kind, converting it
+// if needed. It returns false if the document is not an object of the
+// infrastructure API group, and a nil object along with the errors if it
+// cannot be decoded.
+func decode(scheme *runtime.Scheme, doc []byte, namespace string) (client.Object, Result, bool) {
+	typeMeta := runtime.TypeMeta{}
+	if err := utilyaml.Unmarshal(doc, &typeMeta); err != nil {
+		return nil, Result{Object: "<unknown>", Errors: []string{err.Error()}}, true
+	}
+	gvk := schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind)
+	if gvk.Group != infrav1.GroupVersion.Group {
+		return nil, Result{}, false
+	}
+
+	result := Result{Object: gvk.Kind}
+	decoder := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDeserializer()
+	decoded, _, err := decoder.Decode(doc, nil, nil)
+	switch {
+	case runtime.IsStrictDecodingError(err):
+		// The object is decoded along with the unknown and duplicate
+		// fields, which are dropped when it is applied.
+		result.Warnings = append(result.Warnings, err.Error())
+	case err != nil:
+		result.Errors = append(result.Errors, err.Error())
+		return nil, result, true
+	}
+
+	if convertible, ok := decoded.(conversion.Convertible); ok {
+		hub, err := scheme.New(infrav1.GroupVersion.WithKind(gvk.Kind))
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s is not supported by %s", gvk.Kind, infrav1.GroupVersion))
+			return nil, result, true
+		}
+		if err := convertible.ConvertTo(hub.(conversion.Hub)); err != nil {
+			result.Errors = append(result.Errors, errors.Wrapf(err, "failed to convert to %s", infrav1.GroupVersion).Error())
+			return nil, result, true
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is deprecated, converted to %s", gvk.GroupVersion(), infrav1.GroupVersion))
+		decoded = hub
+	}
+
+	obj, ok := decoded.(client.Object)
+	if !ok {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s is not an object", gvk.Kind))
+		return nil, result, true
+	}
+	obj.GetObjectKind().SetGroupVersionKind(infrav1.GroupVersion.WithKind(gvk.Kind))
+	if obj.GetNamespace() == "" && isNamespaced(gvk.Kind) {
+		obj.SetNamespace(namespace)
+	}
+	result.Object = objectName(obj)
+	return obj, result, true
+}
+
+// isNamespaced returns false for the cluster scoped kinds.
+func isNamespaced(kind string) bool {
+	switch kind {
+	case "VSphereClusterIdentity", "VSphereDeploymentZone", "VSphereFailureDomain", "VSphereTenantPolicy":
+		return false
+	}
+	return true
+}
+
+func objectName(obj client.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", kind, obj.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+}
+
+// validateObject runs the defaulting and validation of the admission
+// webhooks of the object. The object is validated as an update of the object
+// of the management cluster if it exists.
+func validateObject(ctx context.Context, c client.Client, tenantIsolation bool, obj client.Object, result *Result) {
+	if defaulter, ok := obj.(webhook.Defaulter); ok {
+		defaulter.Default()
+	}
+
+	var existing client.Object
+	if c != nil {
+		existing = obj.DeepCopyObject().(client.Object)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+			if !apierrors.IsNotFound(err) {
+				result.Errors = append(result.Errors, errors.Wrap(err, "failed to get the object from the management cluster").Error())
+				return
+			}
+			existing = nil
+		}
+	}
+
+	var err error
+	switch o := obj.(type) {
+	case *infrav1.VSphereMachineTemplate:
+		validator := &infrav1.VSphereMachineTemplateWebhook{}
+		if existing != nil {
+			// The update validation reads the admission request from the
+			// context, as the webhook server sets it, to tell the dry runs
+			// of the topology controller apart.
+			reqCtx := admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: pointer.Bool(false)}})
+			err = validator.ValidateUpdate(reqCtx, existing, o)
+		} else {
+			err = validator.ValidateCreate(ctx, o)
+		}
+	case webhook.Validator:
+		if existing != nil {
+			err = o.ValidateUpdate(existing)
+		} else {
+			err = o.ValidateCreate()
+		}
+	}
+	result.Errors = append(result.Errors, causes(err)...)
+
+	spec, fldPath, allowUnset := cloneSpec(obj)
+	if spec == nil {
+		return
+	}
+	if warning := infrav1.TemplateLookupWarning(fldPath.Child("template").String(), spec.Template); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+	if tenantIsolation {
+		allErrs, err := infrav1.ValidateTenancy(ctx, c, obj.GetNamespace(), spec, fldPath, allowUnset)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return
+		}
+		result.Errors = append(result.Errors, fieldErrors(allErrs)...)
+	}
+}
+
+// cloneSpec returns the clone spec of the VSphereMachines,
+// VSphereMachineTemplates and VSphereVMs, and whether its placement may be
+// left unset, or nil for the other objects.
+func cloneSpec(obj client.Object) (*infrav1.VirtualMachineCloneSpec, *field.Path, bool) {
+	switch o := obj.(type) {
+	case *infrav1.VSphereMachine:
+		return &o.Spec.VirtualMachineCloneSpec, field.NewPath("spec"), true
+	case *infrav1.VSphereMachineTemplate:
+		return &o.Spec.Template.Spec.VirtualMachineCloneSpec, field.NewPath("spec", "template", "spec"), true
+	case *infrav1.VSphereVM:
+		return &o.Spec.VirtualMachineCloneSpec, field.NewPath("spec"), false
+	}
+	return nil, nil, false
+}
+
+// causes returns the messages of the causes of a validation error.
+func causes(err error) []string {
+	if err == nil {
+		return nil
+	}
+	statusErr, ok := err.(apierrors.APIStatus)
+	if !ok || statusErr.Status().Details == nil || len(statusErr.Status().Details.Causes) == 0 {
+		return []string{err.Error()}
+	}
+	msgs := make([]string, 0, len(statusErr.Status().Details.Causes))
+	for _, cause := range statusErr.Status().Details.Causes {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+	}
+	return msgs
+}
+
+func fieldErrors(allErrs field.ErrorList) []string {
+	msgs := make([]string, 0, len(allErrs))
+	for _, err := range allErrs {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
+
+// Print writes the results, and returns true if all the objects are valid.
+func Print(w io.Writer, results []Result) bool {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Valid() && !results[j].Valid() })
+	valid := true
+	for _, result := range results {
+		status := "valid"
+		if !result.Valid() {
+			status = "invalid"
+			valid = false
+		}
+		fmt.Fprintf(w, "%s: %s\n", result.Object, status)
+		for _, msg := range result.Errors {
+			fmt.Fprintf(w, "  error: %s\n", msg)
+		}
+		for _, msg := range result.Warnings {
+			fmt.Fprintf(w, "  warning: %s\n", msg)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+)
+
+const clusterYAML = `apiVersion: cluster.x-k8s.io/v1beta1
+kind: Cluster
+metadata:
+  name: test
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+kind: VSphereMachine
+metadata:
+  name: valid
+spec:
+  template: 42163d7f-2a84-4ae0-b6a9-bf2bdda1e5d4
+  network:
+    devices:
+    - networkName: VM Network
+      dhcp4: true
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+kind: VSphereMachine
+metadata:
+  name: invalid-ip
+  namespace: test
+spec:
+  template: 42163d7f-2a84-4ae0-b6a9-bf2bdda1e5d4
+  network:
+    devices:
+    - networkName: VM Network
+      ipAddrs:
+      - 192.168.1.10
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+kind: VSphereMachine
+metadata:
+  name: unknown-field
+spec:
+  template: 42163d7f-2a84-4ae0-b6a9-bf2bdda1e5d4
+  numCPU: 2
+  network:
+    devices: []
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: VSphereMachineTemplate
+metadata:
+  name: converted
+spec:
+  template:
+    spec:
+      template: 42163d7f-2a84-4ae0-b6a9-bf2bdda1e5d4
+      network:
+        devices: []
+`
+
+func TestValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	filename := filepath.Join(t.TempDir(), "cluster.yaml")
+	g.Expect(os.WriteFile(filename, []byte(clusterYAML), 0600)).To(Succeed())
+
+	results, err := Validate(context.Background(), Options{Filenames: []string{filename}, Namespace: "default"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results).To(HaveLen(4))
+
+	g.Expect(results[0].Object).To(Equal("VSphereMachine default/valid"))
+	g.Expect(results[0].Valid()).To(BeTrue())
+	g.Expect(results[0].Warnings).To(BeEmpty())
+
+	g.Expect(results[1].Object).To(Equal("VSphereMachine test/invalid-ip"))
+	g.Expect(results[1].Errors).To(ConsistOf(ContainSubstring("ip addresses should be in the CIDR format")))
+
+	g.Expect(results[2].Valid()).To(BeTrue())
+	g.Expect(results[2].Warnings).To(ConsistOf(ContainSubstring(`unknown field "spec.numCPU"`)))
+
+	g.Expect(results[3].Object).To(Equal("VSphereMachineTemplate default/converted"))
+	g.Expect(results[3].Valid()).To(BeTrue())
+	g.Expect(results[3].Warnings).To(ConsistOf(ContainSubstring("infrastructure.cluster.x-k8s.io/v1alpha4 is deprecated")))
+
+	var out bytes.Buffer
+	g.Expect(Print(&out, results)).To(BeFalse())
+	g.Expect(out.String()).To(ContainSubstring("VSphereMachine test/invalid-ip: invalid\n  error: "))
+}
+
+func TestValidate_TenantIsolationRequiresKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := Validate(context.Background(), Options{TenantIsolation: true})
+	g.Expect(err).To(MatchError(ContainSubstring("a kubeconfig is required")))
+}
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+
+	var stdout, stderr bytes.Buffer
+	g.Expect(Run(nil, &stdout, &stderr)).To(Equal(2))
+	g.Expect(stderr.String()).To(ContainSubstring("at least one file is required"))
+}
+
+func Test_validateObject_TemplateUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	existing := &infrav1.VSphereMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md-0"},
+		Spec: infrav1.VSphereMachineTemplateSpec{Template: infrav1.VSphereMachineTemplateResource{
+			Spec: infrav1.VSphereMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Template: "ubuntu-2004", NumCPUs: 2}},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+
+	// The templates are immutable, which is reported rather than the missing
+	// admission request of the update validation.
+	updated := existing.DeepCopy()
+	updated.Spec.Template.Spec.NumCPUs = 4
+	result := &Result{}
+	validateObject(context.Background(), c, false, updated, result)
+	g.Expect(result.Errors).To(ContainElement(ContainSubstring("spec.template.spec.numCPUs")))
+	g.Expect(result.Errors).NotTo(ContainElement(ContainSubstring("admission.Request")))
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/identity"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/session"
+)
+
+const inventoryWebhookPath = "/validate-infrastructure-cluster-x-k8s-io-v1beta1-inventory"
+
+// inventorySessionTimeout is the time the webhook waits for a session to the
+// vCenter of an object, well within the timeout of the API server calling it,
+// before it allows the object unchecked.
+const inventorySessionTimeout = 5 * time.Second
+
+// +kubebuilder:webhook:verbs=create,path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-inventory,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=vspheremachinetemplates;vspherevms,versions=v1beta1,name=validation.inventory.infrastructure.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+// InventoryWebhook is an admission webhook that rejects the creation of the
+// VSphereMachineTemplates and VSphereVMs referencing a datacenter, template,
+// folder, datastore, resource pool, network or storage policy which does not
+// exist in their vCenter.
+//
+// The vCenter is accessed with the credentials of the VSphereCluster of the
+// object, or with the credentials of the manager. The requests are allowed
+// with a warning when the vCenter cannot be reached, so that an outage of
+// vCenter does not block the creation of objects.
+// +kubebuilder:object:generate=false
+type InventoryWebhook struct {
+	// Enabled reflects the InventoryValidation feature gate. All the requests
+	// are allowed when it is false.
+	Enabled bool
+
+	// Namespace is the namespace of the manager, which holds the secrets of
+	// the VSphereClusterIdentities.
+	Namespace string
+
+	// Username and Password are the credentials of the manager, used for the
+	// objects whose cluster has no identity.
+	Username string
+	Password string
+
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &InventoryWebhook{}
+
+func (w *InventoryWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w.client = mgr.GetClient()
+	mgr.GetWebhookServer().Register(inventoryWebhookPath, &webhook.Admission{Handler: w})
+	return nil
+}
+
+// InjectDecoder injects the decoder into the webhook.
+func (w *InventoryWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// Handle checks the inventory referenced by the clone spec of a new object.
+func (w *InventoryWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if !w.Enabled || req.Operation != admissionv1.Create {
+		return admission.Allowed("")
+	}
+
+	var obj client.Object
+	switch req.Kind.Kind {
+	case "VSphereMachineTemplate":
+		obj = &infrav1.VSphereMachineTemplate{}
+	case "VSphereVM":
+		obj = &infrav1.VSphereVM{}
+	default:
+		return admission.Allowed("")
+	}
+	if err := w.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(req.Namespace)
+	}
+	spec, fldPath, _ := cloneSpec(obj)
+
+	// The placement of the machine templates of clusters spread over failure
+	// domains is completed by the failure domain of each machine.
+	if _, ok := obj.(*infrav1.VSphereMachineTemplate); ok && spec.Datacenter == "" {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("inventory not checked, %s.datacenter is set by the failure domains", fldPath))
+	}
+
+	params, err := w.sessionParams(ctx, obj, spec)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if params == nil {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("inventory not checked, %s.server is not set", fldPath))
+	}
+	sessionCtx, cancel := context.WithTimeout(ctx, inventorySessionTimeout)
+	defer cancel()
+	s, err := session.GetOrCreate(sessionCtx, params)
+	if err != nil {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("inventory not checked: %s", err))
+	}
+
+	// The checker sets the datacenter of its finder, so it does not use the
+	// finder of the session, which is shared with the controllers.
+	result := &Result{}
+	checker := newSharedInventoryChecker(Options{Server: spec.Server}, s.Client)
+	result.Errors = checker.checkCloneSpec(ctx, find.NewFinder(s.Client.Client, false), spec, fldPath.String(), result)
+	if len(result.Errors) > 0 {
+		return admission.Denied(fmt.Sprintf("%s %s references vSphere inventory which does not exist in %s: %s",
+			req.Kind.Kind, req.Name, spec.Server, strings.Join(result.Errors, "; ")))
+	}
+	return admission.Allowed("").WithWarnings(result.Warnings...)
+}
+
+// sessionParams returns the parameters of the session to the vCenter of an
+// object, or nil if the vCenter is unknown. The server and thumbprint of the
+// spec are filled in from the VSphereCluster of the object when unset.
+func (w *InventoryWebhook) sessionParams(ctx context.Context, obj client.Object, spec *infrav1.VirtualMachineCloneSpec) (*session.Params, error) {
+	vsphereCluster, err := w.getVSphereCluster(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password := w.Username, w.Password
+	var caBundle []byte
+	var tokenProvider session.TokenProvider
+	if vsphereCluster != nil {
+		if spec.Server == "" {
+			spec.Server = vsphereCluster.Spec.Server
+		}
+		if spec.Thumbprint == "" {
+			spec.Thumbprint = vsphereCluster.Spec.Thumbprint
+		}
+		if vsphereCluster.Spec.IdentityRef != nil {
+			creds, err := identity.GetCredentials(ctx, w.client, vsphereCluster, w.Namespace)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the credentials of VSphereCluster %s", vsphereCluster.Name)
+			}
+			username, password, tokenProvider = creds.Username, creds.Password, creds.TokenProvider
+		}
+		caBundle, err = identity.GetCABundle(ctx, w.client, vsphereCluster, w.Namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the CA bundle of VSphereCluster %s", vsphereCluster.Name)
+		}
+	}
+	if spec.Server == "" {
+		return nil, nil
+	}
+	return session.NewParams().
+		WithServer(spec.Server).
+		WithThumbprint(spec.Thumbprint).
+		WithCABundle(caBundle).
+		WithUserInfo(username, password).
+		WithTokenProvider(tokenProvider), nil
+}
+
+// getVSphereCluster returns the VSphereCluster of the cluster an object is
+// labeled with, or nil if the object has no cluster yet.
+func (w *InventoryWebhook) getVSphereCluster(ctx context.Context, obj client.Object) (*infrav1.VSphereCluster, error) {
+	clusterName := obj.GetLabels()[clusterv1.ClusterLabelName]
+	if clusterName == "" {
+		return nil, nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := w.client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get Cluster %s", clusterName)
+	}
+	if cluster.Spec.InfrastructureRef == nil {
+		return nil, nil
+	}
+	vsphereCluster := &infrav1.VSphereCluster{}
+	if err := w.client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: cluster.Spec.InfrastructureRef.Name}, vsphereCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get VSphereCluster %s", cluster.Spec.InfrastructureRef.Name)
+	}
+	return vsphereCluster, nil
+}
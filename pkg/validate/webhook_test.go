@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/simulator"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
+)
+
+func TestInventoryWebhook(t *testing.T) {
+	g := NewWithT(t)
+
+	simr, err := vcsim.NewBuilder().WithModel(simulator.VPX()).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer simr.Destroy()
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	decoder, err := admission.NewDecoder(scheme)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	w := &InventoryWebhook{
+		Enabled:  true,
+		Username: simr.Username(),
+		Password: simr.Password(),
+		client:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+	}
+	g.Expect(w.InjectDecoder(decoder)).To(Succeed())
+
+	request := func(operation admissionv1.Operation, spec infrav1.VirtualMachineCloneSpec) admission.Request {
+		vm := &infrav1.VSphereVM{
+			TypeMeta:   metav1.TypeMeta{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereVM"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm"},
+			Spec:       infrav1.VSphereVMSpec{VirtualMachineCloneSpec: spec},
+		}
+		raw, err := json.Marshal(vm)
+		g.Expect(err).NotTo(HaveOccurred())
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			Kind:      metav1.GroupVersionKind{Group: infrav1.GroupVersion.Group, Version: infrav1.GroupVersion.Version, Kind: "VSphereVM"},
+			Namespace: "default",
+			Name:      "vm",
+			Object:    runtime.RawExtension{Raw: raw},
+		}}
+	}
+	spec := infrav1.VirtualMachineCloneSpec{
+		Server:       simr.ServerURL().Host,
+		Datacenter:   "DC0",
+		Template:     "DC0_H0_VM0",
+		Datastore:    "LocalDS_0",
+		ResourcePool: "/DC0/host/DC0_C0/Resources",
+		Network: infrav1.NetworkSpec{
+			Devices: []infrav1.NetworkDeviceSpec{{NetworkName: "VM Network"}},
+		},
+	}
+
+	t.Run("existing inventory is allowed", func(t *testing.T) {
+		g := NewWithT(t)
+		resp := w.Handle(context.Background(), request(admissionv1.Create, spec))
+		g.Expect(resp.Allowed).To(BeTrue())
+	})
+
+	t.Run("missing inventory is rejected with the fields referencing it", func(t *testing.T) {
+		g := NewWithT(t)
+		invalid := *spec.DeepCopy()
+		invalid.Datastore = "missing-ds"
+		invalid.Network.Devices[0].NetworkName = "missing-network"
+		resp := w.Handle(context.Background(), request(admissionv1.Create, invalid))
+		g.Expect(resp.Allowed).To(BeFalse())
+		g.Expect(resp.Result.Message).To(ContainSubstring("spec.datastore"))
+		g.Expect(resp.Result.Message).To(ContainSubstring("spec.network.devices[0].networkName"))
+	})
+
+	t.Run("inventory given by managed object ID is allowed", func(t *testing.T) {
+		g := NewWithT(t)
+		byMoRef := *spec.DeepCopy()
+		byMoRef.Template = "moref:" + simulator.Map.Any("VirtualMachine").Reference().Value
+		byMoRef.Datastore = "moref:" + simulator.Map.Any("Datastore").Reference().Value
+		byMoRef.ResourcePool = "moref:" + simulator.Map.Any("ResourcePool").Reference().Value
+		byMoRef.Network.Devices[0].NetworkName = "moref:" + simulator.Map.Any("Network").Reference().Value
+		resp := w.Handle(context.Background(), request(admissionv1.Create, byMoRef))
+		g.Expect(resp.Allowed).To(BeTrue())
+
+		byMoRef.Datastore = "moref:datastore-missing"
+		resp = w.Handle(context.Background(), request(admissionv1.Create, byMoRef))
+		g.Expect(resp.Allowed).To(BeFalse())
+		g.Expect(resp.Result.Message).To(ContainSubstring("spec.datastore"))
+	})
+
+	t.Run("updates are not checked", func(t *testing.T) {
+		g := NewWithT(t)
+		invalid := *spec.DeepCopy()
+		invalid.Datastore = "missing-ds"
+		resp := w.Handle(context.Background(), request(admissionv1.Update, invalid))
+		g.Expect(resp.Allowed).To(BeTrue())
+	})
+
+	t.Run("objects without server are allowed with a warning", func(t *testing.T) {
+		g := NewWithT(t)
+		unset := *spec.DeepCopy()
+		unset.Server = ""
+		resp := w.Handle(context.Background(), request(admissionv1.Create, unset))
+		g.Expect(resp.Allowed).To(BeTrue())
+		g.Expect(resp.Warnings).To(ConsistOf(ContainSubstring("spec.server is not set")))
+	})
+
+	t.Run("nothing is checked when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+		disabled := *w
+		disabled.Enabled = false
+		invalid := *spec.DeepCopy()
+		invalid.Datastore = "missing-ds"
+		resp := disabled.Handle(context.Background(), request(admissionv1.Create, invalid))
+		g.Expect(resp.Allowed).To(BeTrue())
+	})
+}
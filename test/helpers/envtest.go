@@ -40,6 +40,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +52,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/context"
 	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/manager"
+	"sigs.k8s.io/cluster-api-provider-vsphere/pkg/validate"
 	"sigs.k8s.io/cluster-api-provider-vsphere/test/helpers/vcsim"
 )
 
@@ -71,6 +73,7 @@ func init() {
 	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	utilruntime.Must(admissionv1.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(addonsv1.AddToScheme(scheme))
 	utilruntime.Must(infrav1alpha3.AddToScheme(scheme))
 	utilruntime.Must(infrav1alpha4.AddToScheme(scheme))
 	utilruntime.Must(infrav1.AddToScheme(scheme))
@@ -139,6 +142,10 @@ func NewTestEnvironment() *TestEnvironment {
 		Password:   simr.Password(),
 	}
 	managerOpts.AddToManager = func(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+		if err := (&infrav1.VSphereCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
 		if err := (&infrav1.VSphereMachine{}).SetupWebhookWithManager(mgr); err != nil {
 			return err
 		}
@@ -159,6 +166,34 @@ func NewTestEnvironment() *TestEnvironment {
 			return err
 		}
 
+		if err := (&infrav1.TemplateLookupWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&infrav1.TenantIsolationWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&infrav1.ClusterPlacementWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&infrav1.ClusterSettingsWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&infrav1.IdentityQuotaWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&infrav1.FailureDomainBalancingWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
+		if err := (&validate.InventoryWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+
 		return nil
 	}
 
@@ -18,6 +18,7 @@ package vcsim
 
 //nolint
 import (
+	"encoding/pem"
 	"fmt"
 	"net/url"
 
@@ -58,3 +59,9 @@ func (s Simulator) Password() string {
 	pwd, _ := s.server.URL.User.Password()
 	return pwd
 }
+
+// CABundle returns the PEM-encoded certificate of the simulator, which is
+// self-signed.
+func (s Simulator) CABundle() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.server.Certificate().Raw})
+}